@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"yetaXYZ/oracle/common"
+	"yetaXYZ/oracle/credentials"
+	"yetaXYZ/oracle/fetch"
+	"yetaXYZ/oracle/sources/crypto"
+	"yetaXYZ/oracle/sources/dex"
+	"yetaXYZ/oracle/symbols"
+)
+
+// operatorKey is the request context key holding the authenticated operator
+type operatorKey struct{}
+
+// parseOperators builds the operator token table from ORACLE_ADMIN_TOKEN,
+// which authenticates as "admin", and ORACLE_ADMIN_TOKENS, a comma-separated
+// list of name:token entries for multi-operator approval workflows
+func parseOperators(adminToken, operatorTokens string) (map[string]string, error) {
+	operators := make(map[string]string)
+	if adminToken != "" {
+		operators["admin"] = adminToken
+	}
+	for _, entry := range strings.Split(operatorTokens, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, token, ok := strings.Cut(entry, ":")
+		if !ok || name == "" || token == "" {
+			return nil, fmt.Errorf("invalid operator entry %q, expected name:token", entry)
+		}
+		if _, exists := operators[name]; exists {
+			return nil, fmt.Errorf("duplicate operator %s", name)
+		}
+		operators[name] = token
+	}
+	return operators, nil
+}
+
+// operatorTokens returns the tokens each operator may present: those of the
+// current ORACLE_ADMIN_TOKEN and ORACLE_ADMIN_TOKENS and, until they have
+// drained, those of values replaced by a credentials reload
+func operatorTokens() map[string][]string {
+	tokens := make(map[string][]string)
+	for _, token := range credentials.Values("ORACLE_ADMIN_TOKEN") {
+		tokens["admin"] = append(tokens["admin"], token)
+	}
+	for _, list := range credentials.Values("ORACLE_ADMIN_TOKENS") {
+		operators, err := parseOperators("", list)
+		if err != nil {
+			continue // reported when the credentials were loaded
+		}
+		for name, token := range operators {
+			tokens[name] = append(tokens[name], token)
+		}
+	}
+	return tokens
+}
+
+// requireAdmin restricts a handler that changes state to operators of a
+// primary instance
+func (s *Server) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	operator := s.requireOperator(next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Changes made on a replica would not reach the primary
+		if s.replica != nil && len(operatorTokens()) > 0 {
+			http.Error(w, "admin API unavailable on read replicas", http.StatusForbidden)
+			return
+		}
+		operator(w, r)
+	}
+}
+
+// requireOperator restricts a handler to callers presenting an operator
+// token and records the operator in the request context. Admin endpoints
+// are disabled entirely when no operator tokens are configured.
+func (s *Server) requireOperator(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		operators := operatorTokens()
+		if len(operators) == 0 {
+			http.Error(w, "admin API disabled", http.StatusForbidden)
+			return
+		}
+
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		operator := ""
+		for name, accepted := range operators {
+			for _, expected := range accepted {
+				if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1 {
+					operator = name
+				}
+			}
+		}
+		if operator == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), operatorKey{}, operator)))
+	}
+}
+
+// operatorFrom returns the operator authenticated by requireAdmin
+func operatorFrom(r *http.Request) string {
+	operator, _ := r.Context().Value(operatorKey{}).(string)
+	return operator
+}
+
+// dryRun reports whether an admin request asks to validate and preview a
+// change with ?dryRun=true instead of applying it
+func dryRun(r *http.Request) bool {
+	value, _ := strconv.ParseBool(r.URL.Query().Get("dryRun"))
+	return value
+}
+
+// writeDryRun writes the result of a dry run, marked as such
+func writeDryRun(w http.ResponseWriter, result map[string]interface{}) {
+	result["dryRun"] = true
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleDiscoverPools handles DEX pool discovery requests
+func (s *Server) handleDiscoverPools() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Chain string `json:"chain"`
+			Base  string `json:"base"`
+			Quote string `json:"quote"`
+			Pair  string `json:"pair"`
+			Limit int    `json:"limit"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		if req.Chain == "" || req.Base == "" || req.Quote == "" {
+			http.Error(w, "chain, base and quote are required", http.StatusBadRequest)
+			return
+		}
+		if req.Limit <= 0 {
+			req.Limit = 3
+		}
+
+		tokenA, err := symbols.New(s.config).Address(req.Chain, req.Base)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		tokenB, err := symbols.New(s.config).Address(req.Chain, req.Quote)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+
+		candidates, err := dex.DiscoverAll(ctx, fetch.NewClient(30*time.Second), s.config, req.Chain, tokenA, tokenB, req.Limit)
+		if err != nil {
+			log.Printf("Pool discovery failed: %v", err)
+			http.Error(w, fmt.Sprintf("pool discovery failed: %v", err), http.StatusBadGateway)
+			return
+		}
+
+		response := map[string]interface{}{
+			"candidates": candidates,
+		}
+
+		// Suggest the pair's DEX sources with the candidates applied, without
+		// changing the running configuration
+		if req.Pair != "" {
+			pairConfig, err := crypto.GetPairConfig(req.Pair)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			suggested := *pairConfig
+			suggested.Sources.DEX.Pools = append([]common.DEXPool(nil), pairConfig.Sources.DEX.Pools...)
+			dex.ApplyCandidates(&suggested, candidates)
+			response["suggestedSources"] = suggested.Sources
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"yetaXYZ/oracle/analytics"
+)
+
+// handleCorrelation returns the pairwise return correlation matrix for a set
+// of feeds computed from stored history
+func (s *Server) handleCorrelation() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		symbols := strings.Split(query.Get("symbols"), ",")
+		if len(symbols) < 2 || symbols[0] == "" {
+			http.Error(w, "symbols must list at least two feeds", http.StatusBadRequest)
+			return
+		}
+		// The route is metered against all feeds; each feed compared must
+		// be readable as well
+		for _, symbol := range symbols {
+			if !s.readable(consumerFrom(r), symbol) {
+				http.Error(w, fmt.Sprintf("not subscribed to %s", symbol), http.StatusForbidden)
+				return
+			}
+		}
+
+		window, err := durationParam(query.Get("window"), 24*time.Hour)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid window: %v", err), http.StatusBadRequest)
+			return
+		}
+		sample, err := durationParam(query.Get("sample"), time.Minute)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid sample: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		now := time.Now()
+		matrix, err := s.statistics.CorrelationMatrix(symbols, window, sample, now)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to compute correlations: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		response := map[string]interface{}{
+			"symbols":   symbols,
+			"window":    window.String(),
+			"sample":    sample.String(),
+			"matrix":    matrix,
+			"timestamp": now,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// handleDeviation returns, for one feed, the average deviation of each source
+// from the final aggregate over a window, bucketed for heatmap display
+func (s *Server) handleDeviation() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		symbol := query.Get("symbol")
+		if symbol == "" {
+			http.Error(w, "symbol is required", http.StatusBadRequest)
+			return
+		}
+		if !s.readable(consumerFrom(r), symbol) {
+			http.Error(w, fmt.Sprintf("not subscribed to %s", symbol), http.StatusForbidden)
+			return
+		}
+
+		window, err := durationParam(query.Get("window"), 24*time.Hour)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid window: %v", err), http.StatusBadRequest)
+			return
+		}
+		bucket, err := durationParam(query.Get("bucket"), time.Hour)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid bucket: %v", err), http.StatusBadRequest)
+			return
+		}
+		if window/bucket > 1000 {
+			http.Error(w, "window spans too many buckets", http.StatusBadRequest)
+			return
+		}
+
+		now := time.Now()
+		from := now.Add(-window)
+		rounds, err := s.store.Rounds(symbol, from, now)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to load rounds: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		response := map[string]interface{}{
+			"symbol":    symbol,
+			"window":    window.String(),
+			"bucket":    bucket.String(),
+			"rounds":    len(rounds),
+			"heatmap":   analytics.Deviations(rounds, from, now, bucket),
+			"timestamp": now,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// handleWeightSuggestions returns the latest source weight suggestions as a
+// config diff for operator review; they are computed on demand before the
+// first background run
+func (s *Server) handleWeightSuggestions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		suggestions := s.weights.Latest()
+		if suggestions == nil {
+			suggestions = s.weights.Compute(time.Now())
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(suggestions)
+	}
+}
+
+// handleManipulationReport returns the latest manipulation detection report
+// for security review; it is computed on demand before the first background
+// run
+func (s *Server) handleManipulationReport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := s.forensics.Latest()
+		if report == nil {
+			report = s.forensics.Compute(time.Now())
+		}
+		// Findings of feeds the caller may not read are left out
+		visible := *report
+		visible.Findings = make([]analytics.Finding, 0, len(report.Findings))
+		for _, finding := range report.Findings {
+			if s.readable(consumerFrom(r), finding.Symbol) {
+				visible.Findings = append(visible.Findings, finding)
+			}
+		}
+		report = &visible
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	}
+}
+
+// handleColdStart returns the bootstrapped statistics of pairs still
+// without enough history of their own
+func (s *Server) handleColdStart() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"pairs": s.coldStart.Baselines(),
+		})
+	}
+}
+
+// durationParam parses an optional duration query parameter
+func durationParam(value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("must be positive")
+	}
+	return d, nil
+}
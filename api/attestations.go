@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"yetaXYZ/oracle/attestation"
+)
+
+// handleAttestations lists every configured question and its resolution,
+// leaving out questions resolved from feeds the caller may not read
+func (s *Server) handleAttestations() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.attestor == nil {
+			http.Error(w, "attestations are disabled", http.StatusNotFound)
+			return
+		}
+		consumer := s.caller(r)
+		attestations := make([]*attestation.Attestation, 0)
+		for _, a := range s.attestor.List() {
+			if s.readableAll(consumer, s.attestor.Feeds(a.ID)) {
+				attestations = append(attestations, a)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"attestations": attestations,
+		})
+	}
+}
+
+// handleAttestation returns one question's resolution
+func (s *Server) handleAttestation() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.attestor == nil {
+			http.Error(w, "attestations are disabled", http.StatusNotFound)
+			return
+		}
+		id := mux.Vars(r)["id"]
+		resolution, err := s.attestor.Get(id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		// Questions on feeds the caller may not read are not revealed
+		if !s.readableAll(s.caller(r), s.attestor.Feeds(id)) {
+			http.Error(w, fmt.Sprintf("unknown question %s", id), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resolution)
+	}
+}
+
+// handleDisputeAttestation records the calling operator's dispute of a
+// proposed outcome
+func (s *Server) handleDisputeAttestation() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.attestor == nil {
+			http.Error(w, "attestations are disabled", http.StatusNotFound)
+			return
+		}
+		var req struct {
+			Reason string `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		if dryRun(r) {
+			attestation, err := s.attestor.PreviewDispute(operatorFrom(r), mux.Vars(r)["id"], req.Reason, time.Now())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			writeDryRun(w, map[string]interface{}{"attestation": attestation})
+			return
+		}
+		attestation, err := s.attestor.Dispute(operatorFrom(r), mux.Vars(r)["id"], req.Reason, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(attestation)
+	}
+}
+
+// handleSettleAttestation finalizes a disputed question with the outcome
+// given by the calling operator
+func (s *Server) handleSettleAttestation() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.attestor == nil {
+			http.Error(w, "attestations are disabled", http.StatusNotFound)
+			return
+		}
+		var req struct {
+			Outcome string `json:"outcome"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		if dryRun(r) {
+			attestation, err := s.attestor.PreviewSettle(operatorFrom(r), mux.Vars(r)["id"], req.Outcome, time.Now())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			writeDryRun(w, map[string]interface{}{"attestation": attestation})
+			return
+		}
+		attestation, err := s.attestor.Settle(r.Context(), operatorFrom(r), mux.Vars(r)["id"], req.Outcome, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(attestation)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"yetaXYZ/oracle/common"
+	"yetaXYZ/oracle/sides"
+	"yetaXYZ/oracle/sources/crypto"
+)
+
+// feedInputs returns the exchanges, providers and input feeds a feed's
+// value is computed from
+func (s *Server) feedInputs(symbol string) []string {
+	if pair, err := crypto.GetPairConfig(symbol); err == nil {
+		var inputs []string
+		for _, sources := range append([]common.SourcesConfig{pair.Sources}, pair.FallbackTiers...) {
+			if sources.CEX.Enabled {
+				inputs = append(inputs, sources.CEX.Exchanges...)
+			}
+			if sources.DEX.Enabled {
+				for _, exchanges := range sources.DEX.Exchanges {
+					inputs = append(inputs, exchanges...)
+				}
+				for _, pool := range sources.DEX.Pools {
+					inputs = append(inputs, pool.Exchange)
+				}
+				for _, subgraph := range sources.DEX.Subgraphs {
+					inputs = append(inputs, subgraph.Exchange)
+				}
+			}
+		}
+		return inputs
+	}
+	if derived, ok := crypto.DerivedConfig[symbol]; ok {
+		return derived.Inputs
+	}
+	if statistic, ok := crypto.StatisticsConfig[symbol]; ok {
+		return statistic.Inputs
+	}
+	if s.pegs.IsPeg(symbol) {
+		return s.pegs.Inputs(symbol)
+	}
+	if s.sides.IsSide(symbol) {
+		pair, _, _ := sides.Split(symbol)
+		return []string{pair}
+	}
+	if provider := s.rates.Provider(symbol); provider != "" {
+		return []string{provider}
+	}
+	return nil
+}
+
+// attributions returns the attributions owed for serving the given feeds
+func (s *Server) attributions(symbols ...string) []common.Attribution {
+	return s.attribution.Resolve(s.feedInputs, symbols...)
+}
+
+// summaryAttributions returns the attributions owed for a list of feeds
+func (s *Server) summaryAttributions(feeds []feedSummary) []common.Attribution {
+	symbols := make([]string, len(feeds))
+	for i, feed := range feeds {
+		symbols[i] = feed.Symbol
+	}
+	return s.attributions(symbols...)
+}
+
+// handleAttributions lists the attribution requirements and terms of every
+// data provider, or with ?symbol= those owed for one feed
+func (s *Server) handleAttributions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		symbol := r.URL.Query().Get("symbol")
+		if symbol == "" {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"providers": s.attribution.Providers(),
+			})
+			return
+		}
+		if !s.knownFeed(symbol) && !s.rates.IsBenchmark(symbol) {
+			http.Error(w, fmt.Sprintf("unknown feed %s", symbol), http.StatusNotFound)
+			return
+		}
+		attributions := s.attributions(symbol)
+		if attributions == nil {
+			attributions = []common.Attribution{}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"symbol":       symbol,
+			"attributions": attributions,
+		})
+	}
+}
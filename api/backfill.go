@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"yetaXYZ/oracle/backfill"
+	"yetaXYZ/oracle/sources/crypto"
+)
+
+// handleBackfill populates the store with history of a pair built from
+// exchange klines, so a newly added feed has candles, change and volatility
+func (s *Server) handleBackfill() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Symbol   string `json:"symbol"`
+			Lookback string `json:"lookback"`
+			Interval string `json:"interval"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		if req.Symbol == "" {
+			http.Error(w, "symbol is required", http.StatusBadRequest)
+			return
+		}
+		if req.Lookback == "" {
+			req.Lookback = "24h"
+		}
+		if req.Interval == "" {
+			req.Interval = "5m"
+		}
+		lookback, err := time.ParseDuration(req.Lookback)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid lookback: %v", err), http.StatusBadRequest)
+			return
+		}
+		interval, err := time.ParseDuration(req.Interval)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid interval: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		pairConfig, err := crypto.GetPairConfig(req.Symbol)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		backfiller := backfill.New(s.config, s.store)
+		run := backfiller.Run
+		if dryRun(r) {
+			run = backfiller.Preview
+		}
+		report, err := run(req.Symbol, pairConfig, lookback, interval, time.Now())
+		if err != nil && report == nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Printf("Backfill of %s failed: %v", req.Symbol, err)
+			http.Error(w, fmt.Sprintf("backfill failed: %v", err), http.StatusBadGateway)
+			return
+		}
+		if !report.DryRun {
+			log.Printf("Operator %s backfilled %d rounds of %s", operatorFrom(r), report.Rounds, req.Symbol)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	}
+}
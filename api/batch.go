@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"yetaXYZ/oracle/common"
+	"yetaXYZ/oracle/sources/crypto"
+)
+
+// Batch limits
+const (
+	maxBatchSymbols     = 100
+	defaultBatchTimeout = 2 * time.Second
+	maxBatchTimeout     = 30 * time.Second
+)
+
+// Per-feed statuses of a batch response
+const (
+	batchOK      = "ok"
+	batchPending = "pending"
+	batchError   = "error"
+)
+
+// batchEntry is the outcome of one feed of a batch request
+type batchEntry struct {
+	Status string `json:"status"`
+	// Result is the feed's round, reduced to the fields requested
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+	// Failures gives why each source failed when too few returned a price
+	Failures []common.SourceFailure `json:"failures,omitempty"`
+}
+
+// batchParams parses ?symbols=A,B and the optional ?timeoutMs= deadline
+func batchParams(r *http.Request) ([]string, time.Duration, error) {
+	query := r.URL.Query()
+	symbols := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, symbol := range strings.Split(query.Get("symbols"), ",") {
+		symbol = strings.TrimSpace(symbol)
+		if symbol == "" || seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+		symbols = append(symbols, symbol)
+	}
+	if len(symbols) == 0 {
+		return nil, 0, fmt.Errorf("symbols is required")
+	}
+	if len(symbols) > maxBatchSymbols {
+		return nil, 0, fmt.Errorf("at most %d symbols per request", maxBatchSymbols)
+	}
+
+	timeout := defaultBatchTimeout
+	if value := query.Get("timeoutMs"); value != "" {
+		ms, err := strconv.Atoi(value)
+		if err != nil || ms <= 0 {
+			return nil, 0, fmt.Errorf("timeoutMs must be a positive integer")
+		}
+		timeout = time.Duration(ms) * time.Millisecond
+		if timeout > maxBatchTimeout {
+			timeout = maxBatchTimeout
+		}
+	}
+	return symbols, timeout, nil
+}
+
+// handleBatchPrices returns the current round of several feeds at once.
+// Feeds are fetched concurrently; those still fetching at the deadline are
+// marked pending rather than holding the response for the slowest feed.
+func (s *Server) handleBatchPrices() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		symbols, timeout, err := batchParams(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fields, err := fieldsParam(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		type outcome struct {
+			symbol string
+			entry  batchEntry
+		}
+		// Buffered so that feeds finishing after the deadline do not block
+		done := make(chan outcome, len(symbols))
+		entries := make(map[string]batchEntry, len(symbols))
+		consumer := consumerFrom(r)
+		fetching := 0
+		for _, symbol := range symbols {
+			switch {
+			case !s.knownFeed(symbol):
+				entries[symbol] = batchEntry{Status: batchError, Error: fmt.Sprintf("unknown feed %s", symbol)}
+			case !s.readable(consumer, symbol):
+				entries[symbol] = batchEntry{Status: batchError, Error: fmt.Sprintf("not subscribed to %s", symbol)}
+			default:
+				entries[symbol] = batchEntry{Status: batchPending}
+				fetching++
+				go func(symbol string) {
+					result, _, err := s.latestFeed(symbol)
+					if err != nil {
+						entry := batchEntry{Status: batchError, Error: err.Error()}
+						if insufficient, ok := err.(*crypto.InsufficientSourcesError); ok {
+							entry.Failures = insufficient.Failures
+						}
+						done <- outcome{symbol, entry}
+						return
+					}
+					value, err := selectFields(result, fields)
+					if err != nil {
+						done <- outcome{symbol, batchEntry{Status: batchError, Error: err.Error()}}
+						return
+					}
+					done <- outcome{symbol, batchEntry{Status: batchOK, Result: value}}
+				}(symbol)
+			}
+		}
+
+		deadline := time.NewTimer(timeout)
+		defer deadline.Stop()
+	collect:
+		for ; fetching > 0; fetching-- {
+			select {
+			case o := <-done:
+				entries[o.symbol] = o.entry
+			case <-deadline.C:
+				break collect
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"timestamp": time.Now(),
+			"complete":  fetching == 0,
+			"timeoutMs": timeout.Milliseconds(),
+			"prices":    entries,
+		})
+	}
+}
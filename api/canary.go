@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"yetaXYZ/oracle/canary"
+	"yetaXYZ/oracle/credentials"
+	"yetaXYZ/oracle/events"
+)
+
+// canaryComparator returns a comparator against ORACLE_PRODUCTION_URL,
+// authenticated with ORACLE_PRODUCTION_API_KEY if set, when ORACLE_MODE is
+// "canary", and nil otherwise
+func canaryComparator(configDir string, bus *events.Bus) (*canary.Comparator, error) {
+	if os.Getenv("ORACLE_MODE") != "canary" {
+		return nil, nil
+	}
+	production := os.Getenv("ORACLE_PRODUCTION_URL")
+	if production == "" {
+		return nil, fmt.Errorf("ORACLE_PRODUCTION_URL is required in canary mode")
+	}
+	config, err := canary.LoadConfig(configDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid canary config: %v", err)
+	}
+	comparator := canary.NewComparator(config, bus, production)
+	comparator.SetAPIKey(credentials.Get("ORACLE_PRODUCTION_API_KEY"))
+	return comparator, nil
+}
+
+// sideEffects reports whether the instance may act beyond serving its own
+// API: publish on-chain, deliver webhooks, account rewards, or produce
+// randomness and attestations. Replicas and canaries may not.
+func (s *Server) sideEffects() bool {
+	return s.replica == nil && s.canary == nil
+}
+
+// handleCanaryReport compares the canary's rounds against production's
+// over the comparison window
+func (s *Server) handleCanaryReport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.canary == nil {
+			http.Error(w, "not running in canary mode", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.canary.Report(time.Now()))
+	}
+}
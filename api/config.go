@@ -0,0 +1,27 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"yetaXYZ/oracle/sources/crypto"
+)
+
+// handleEffectiveConfig returns the configuration the server is running
+// with, including changes activated through proposals since startup, for
+// oraclectl config diff to compare against a deployment's repository
+func (s *Server) handleEffectiveConfig() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		snapshot, err := crypto.CurrentConfig()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"version":  snapshot.Version,
+			"loadedAt": snapshot.LoadedAt,
+			"config":   snapshot.Effective(),
+		})
+	}
+}
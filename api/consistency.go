@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"yetaXYZ/oracle/consistency"
+	"yetaXYZ/oracle/pegs"
+)
+
+// handleConsistency returns the latest result of every triangular
+// consistency check, leaving out triangles of feeds the caller may not read
+func (s *Server) handleConsistency() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		consumer := s.caller(r)
+		triangles := make([]*consistency.Result, 0)
+		for _, result := range s.triangles.Results() {
+			if s.readableAll(consumer, s.triangles.Feeds(result.Name)) {
+				triangles = append(triangles, result)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"triangles": triangles,
+		})
+	}
+}
+
+// handlePegs returns the latest check of every monitored peg the caller
+// may read
+func (s *Server) handlePegs() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		consumer := s.caller(r)
+		checks := make([]*pegs.Result, 0)
+		for _, result := range s.pegs.Results() {
+			if s.readable(consumer, result.Symbol) {
+				checks = append(checks, result)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"pegs": checks,
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"yetaXYZ/oracle/credentials"
+	"yetaXYZ/oracle/events"
+	"yetaXYZ/oracle/fetch"
+	"yetaXYZ/oracle/sources/crypto"
+)
+
+// onCredentialsChange applies rotated credentials that were resolved once
+// rather than on every use
+func (s *Server) onCredentialsChange(changed []string) {
+	log.Printf("Credentials rotated: %s", strings.Join(changed, ", "))
+
+	// Header values referencing credentials are expanded when configured
+	fetch.Configure(crypto.BaseConfig, fetch.InstanceID())
+
+	for _, name := range changed {
+		switch {
+		case name == "ORACLE_ADMIN_TOKEN" || name == "ORACLE_ADMIN_TOKENS":
+			if _, err := parseOperators(credentials.Get("ORACLE_ADMIN_TOKEN"), credentials.Get("ORACLE_ADMIN_TOKENS")); err != nil {
+				s.credentialAlert(fmt.Sprintf("invalid admin tokens: %v", err))
+			}
+		case name == "ORACLE_PRIMARY_API_KEY" && s.replica != nil:
+			// The open stream stays authenticated; reconnects use the new key
+			s.replica.SetAPIKey(credentials.Get(name))
+		case name == "ORACLE_PRIMARY_ADMIN_TOKEN" && s.standby != nil:
+			s.standby.SetToken(credentials.Get(name))
+		case name == "ORACLE_PRODUCTION_API_KEY" && s.canary != nil:
+			s.canary.SetAPIKey(credentials.Get(name))
+		case s.randomness != nil && name == s.randomness.KeyEnv():
+			if err := s.randomness.Rekey(credentials.Get(name), time.Now()); err != nil {
+				s.credentialAlert(err.Error())
+			}
+		}
+	}
+}
+
+// credentialAlert raises an alert for a rotated credential that could not
+// be applied; the previous value stays in use where one is held
+func (s *Server) credentialAlert(message string) {
+	s.bus.Publish(events.Event{
+		Type:      events.Alert,
+		Timestamp: time.Now(),
+		Payload: &events.AlertPayload{
+			Severity: events.SeverityCritical,
+			Kind:     "credential_rotation_failed",
+			Message:  message,
+		},
+	})
+}
+
+// handleCredentials lists the file-backed and rotated credentials, never
+// their values
+func (s *Server) handleCredentials() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		store := credentials.Default()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"file":        store.Path(),
+			"credentials": store.Status(time.Now()),
+		})
+	}
+}
+
+// handleReloadCredentials re-reads the credentials file without waiting for
+// the file watcher
+func (s *Server) handleReloadCredentials() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		store := credentials.Default()
+		if store.Path() == "" {
+			http.Error(w, "credentials file is not configured", http.StatusNotFound)
+			return
+		}
+		if dryRun(r) {
+			pending, err := store.Pending()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if pending == nil {
+				pending = []string{}
+			}
+			writeDryRun(w, map[string]interface{}{"changed": pending})
+			return
+		}
+		changed, err := store.Reload(time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Credentials reloaded by %s", operatorFrom(r))
+
+		if changed == nil {
+			changed = []string{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"changed":  changed,
+			"reloaded": time.Now().UTC(),
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// dashboardFiles holds the operator dashboard, built into the binary so it
+// is always served alongside the API it reads
+//
+//go:embed dashboard
+var dashboardFiles embed.FS
+
+// dashboardHandler serves the embedded dashboard under /dashboard/
+func dashboardHandler() http.Handler {
+	root, err := fs.Sub(dashboardFiles, "dashboard")
+	if err != nil {
+		panic(err) // the embedded directory is fixed at build time
+	}
+	return http.StripPrefix("/dashboard/", http.FileServer(http.FS(root)))
+}
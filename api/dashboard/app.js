@@ -0,0 +1,155 @@
+// Operator dashboard: loads a snapshot from the API, then applies live
+// rounds and alerts from the server-sent event stream.
+(function () {
+  'use strict';
+
+  var feeds = {};    // symbol -> summary row
+  var rounds = {};   // symbol -> latest aggregate with per-source prices
+  var selected = null;
+
+  function $(id) { return document.getElementById(id); }
+
+  function fmt(value, digits) {
+    if (value === null || value === undefined) { return '–'; }
+    return Number(value).toLocaleString(undefined, { maximumFractionDigits: digits });
+  }
+
+  function pct(value) {
+    if (value === null || value === undefined) { return '–'; }
+    return (value * 100).toFixed(2) + '%';
+  }
+
+  function age(timestamp) {
+    var secs = Math.max(0, (Date.now() - new Date(timestamp).getTime()) / 1000);
+    if (secs < 60) { return Math.round(secs) + 's ago'; }
+    if (secs < 3600) { return Math.round(secs / 60) + 'm ago'; }
+    return Math.round(secs / 3600) + 'h ago';
+  }
+
+  function cell(row, text, cls) {
+    var td = document.createElement('td');
+    td.textContent = text;
+    if (cls) { td.className = cls; }
+    row.appendChild(td);
+    return td;
+  }
+
+  function badge(row, text) {
+    var span = document.createElement('span');
+    span.className = 'badge ' + text;
+    span.textContent = text;
+    cell(row, '').appendChild(span);
+  }
+
+  function renderFeeds() {
+    var body = $('feeds').querySelector('tbody');
+    body.innerHTML = '';
+    Object.keys(feeds).sort().forEach(function (symbol) {
+      var f = feeds[symbol];
+      var row = document.createElement('tr');
+      row.className = 'feed' + (symbol === selected ? ' active' : '');
+      cell(row, symbol);
+      cell(row, f.kind);
+      cell(row, fmt(f.price, 8), 'num');
+      cell(row, pct(f.change24h), 'num');
+      badge(row, f.quality);
+      cell(row, f.sources, 'num');
+      cell(row, f.updated ? age(f.updated) : '–');
+      row.onclick = function () { selected = symbol; renderFeeds(); renderSources(); };
+      body.appendChild(row);
+    });
+  }
+
+  function renderSources() {
+    var body = $('sources').querySelector('tbody');
+    body.innerHTML = '';
+    $('selected').textContent = selected || 'select a feed';
+    var round = rounds[selected];
+    if (!round || !round.sources) { return; }
+    round.sources.forEach(function (s) {
+      var row = document.createElement('tr');
+      cell(row, s.source);
+      cell(row, s.tier || 'primary');
+      cell(row, fmt(s.price, 8), 'num');
+      cell(row, fmt(s.volume, 2), 'num');
+      cell(row, round.price ? pct((s.price - round.price) / round.price) : '–', 'num');
+      body.appendChild(row);
+    });
+  }
+
+  function addAlert(alert) {
+    var item = document.createElement('li');
+    var span = document.createElement('span');
+    span.className = 'badge ' + alert.severity;
+    span.textContent = alert.severity;
+    item.appendChild(span);
+    item.appendChild(document.createTextNode(' ' + new Date(alert.timestamp).toLocaleTimeString() +
+      ' ' + alert.kind + (alert.symbol ? ' ' + alert.symbol : '') + ': ' + alert.message));
+    var list = $('alerts');
+    list.insertBefore(item, list.firstChild);
+    while (list.children.length > 50) { list.removeChild(list.lastChild); }
+  }
+
+  function loadSummary() {
+    return fetch('/api/v1/summary').then(function (r) { return r.json(); }).then(function (data) {
+      data.feeds.forEach(function (f) {
+        feeds[f.symbol] = {
+          kind: f.kind,
+          price: f.price,
+          change24h: f.change24h,
+          quality: f.quality,
+          sources: f.sources,
+          updated: f.ageSeconds === null ? null : new Date(Date.now() - f.ageSeconds * 1000)
+        };
+      });
+      renderFeeds();
+    });
+  }
+
+  function loadHealth() {
+    fetch('/api/v1/health').then(function (r) { return r.json(); }).then(function (h) {
+      $('health').textContent = h.status;
+      $('health').className = 'badge ' + h.status;
+      $('config').textContent = h.configVersion ? 'config ' + h.configVersion.slice(0, 12) : '';
+    }).catch(function () {
+      $('health').textContent = 'unreachable';
+      $('health').className = 'badge critical';
+    });
+  }
+
+  function loadAlerts() {
+    fetch('/api/v1/alerts').then(function (r) { return r.json(); }).then(function (data) {
+      data.alerts.forEach(addAlert);
+    });
+  }
+
+  function connect() {
+    var stream = new EventSource('/api/v1/stream');
+    stream.onopen = function () { $('stream').textContent = 'stream: live'; };
+    stream.onerror = function () { $('stream').textContent = 'stream: reconnecting'; };
+
+    stream.addEventListener('aggregate', function (e) {
+      var round = JSON.parse(e.data);
+      rounds[round.symbol] = round;
+      var f = feeds[round.symbol] || { kind: 'pair', change24h: null };
+      f.price = round.price;
+      f.sources = (round.sources || []).length;
+      f.updated = round.timestamp;
+      f.quality = round.marketClosed ? 'market_closed' : (round.fallbackReason ? 'degraded' : 'ok');
+      feeds[round.symbol] = f;
+      renderFeeds();
+      if (round.symbol === selected) { renderSources(); }
+    });
+
+    stream.addEventListener('alert', function (e) {
+      addAlert(JSON.parse(e.data));
+    });
+  }
+
+  loadSummary();
+  loadHealth();
+  loadAlerts();
+  connect();
+  setInterval(loadHealth, 10000);
+  setInterval(loadSummary, 60000);
+})();
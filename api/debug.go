@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"time"
+)
+
+// defaultMutexFraction samples one in this many mutex contention events
+// while the debug listener is enabled
+const defaultMutexFraction = 10
+
+// debugAddr returns ORACLE_DEBUG_ADDR, the address of the diagnostics
+// listener, or an empty string when it is disabled. Profiles expose
+// internals and cost CPU, so the address must be on the loopback interface.
+func debugAddr() (string, error) {
+	addr := os.Getenv("ORACLE_DEBUG_ADDR")
+	if addr == "" {
+		return "", nil
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid ORACLE_DEBUG_ADDR: %v", err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return "", fmt.Errorf("invalid ORACLE_DEBUG_ADDR: %s is not a loopback address", host)
+	}
+	return addr, nil
+}
+
+// mutexFraction returns ORACLE_DEBUG_MUTEX_FRACTION, the sampling rate of
+// the mutex profile; 0 leaves it off
+func mutexFraction() (int, error) {
+	value := os.Getenv("ORACLE_DEBUG_MUTEX_FRACTION")
+	if value == "" {
+		return defaultMutexFraction, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid ORACLE_DEBUG_MUTEX_FRACTION: %q", value)
+	}
+	return n, nil
+}
+
+// debugServer returns the diagnostics listener configured by
+// ORACLE_DEBUG_ADDR, enabling the mutex profile, or nil when it is disabled
+func (s *Server) debugServer() (*http.Server, error) {
+	addr, err := debugAddr()
+	if err != nil || addr == "" {
+		return nil, err
+	}
+	fraction, err := mutexFraction()
+	if err != nil {
+		return nil, err
+	}
+	runtime.SetMutexProfileFraction(fraction)
+	return &http.Server{Addr: addr, Handler: s.debugHandler()}, nil
+}
+
+// debugHandler serves pprof profiles and runtime statistics to operators.
+// It is mounted on the debug listener only, never on the public router.
+func (s *Server) debugHandler() http.Handler {
+	mux := http.NewServeMux()
+	// The index also serves the named profiles: heap, goroutine, mutex,
+	// block, allocs and threadcreate
+	mux.HandleFunc("/debug/pprof/", s.requireOperator(pprof.Index))
+	mux.HandleFunc("/debug/pprof/cmdline", s.requireOperator(pprof.Cmdline))
+	mux.HandleFunc("/debug/pprof/profile", s.requireOperator(pprof.Profile))
+	mux.HandleFunc("/debug/pprof/symbol", s.requireOperator(pprof.Symbol))
+	mux.HandleFunc("/debug/pprof/trace", s.requireOperator(pprof.Trace))
+	mux.HandleFunc("/debug/runtime", s.requireOperator(s.handleRuntimeStats()))
+	return mux
+}
+
+// handleRuntimeStats reports goroutines, memory and garbage collection
+func (s *Server) handleRuntimeStats() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+		gc := debug.GCStats{PauseQuantiles: make([]time.Duration, 5)}
+		debug.ReadGCStats(&gc)
+
+		pauses := make(map[string]string, len(gc.PauseQuantiles))
+		for i, q := range []string{"min", "p25", "p50", "p75", "max"} {
+			pauses[q] = gc.PauseQuantiles[i].String()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"goVersion":  runtime.Version(),
+			"goroutines": runtime.NumGoroutine(),
+			"cpus":       runtime.NumCPU(),
+			"gomaxprocs": runtime.GOMAXPROCS(0),
+			"memory": map[string]uint64{
+				"heapAlloc":    mem.HeapAlloc,
+				"heapInuse":    mem.HeapInuse,
+				"heapIdle":     mem.HeapIdle,
+				"heapReleased": mem.HeapReleased,
+				"heapObjects":  mem.HeapObjects,
+				"stackInuse":   mem.StackInuse,
+				"sys":          mem.Sys,
+				"totalAlloc":   mem.TotalAlloc,
+			},
+			"gc": map[string]interface{}{
+				"count":       gc.NumGC,
+				"lastGC":      gc.LastGC,
+				"pauseTotal":  gc.PauseTotal.String(),
+				"pauses":      pauses,
+				"nextGC":      mem.NextGC,
+				"cpuFraction": mem.GCCPUFraction,
+			},
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"yetaXYZ/oracle/drill"
+	"yetaXYZ/oracle/sources/crypto"
+)
+
+// handleListDrills lists the drills in effect
+func (s *Server) handleListDrills() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"drills": s.drills.Active(time.Now()),
+		})
+	}
+}
+
+// handleStartDrill injects a failure for a game-day drill: forces a source
+// open, marks a feed degraded or delays a feed's publications, until the
+// drill expires or is stopped
+func (s *Server) handleStartDrill() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Kind     string `json:"kind"`
+			Target   string `json:"target"`
+			Duration string `json:"duration"`
+			Delay    string `json:"delay"`
+			Reason   string `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		spec := drill.Spec{Kind: req.Kind, Target: req.Target, Operator: operatorFrom(r), Reason: req.Reason}
+		var err error
+		if req.Duration != "" {
+			if spec.Duration, err = time.ParseDuration(req.Duration); err != nil {
+				http.Error(w, fmt.Sprintf("invalid duration: %v", err), http.StatusBadRequest)
+				return
+			}
+		}
+		if req.Delay != "" {
+			if spec.Delay, err = time.ParseDuration(req.Delay); err != nil {
+				http.Error(w, fmt.Sprintf("invalid delay: %v", err), http.StatusBadRequest)
+				return
+			}
+		}
+		if status, err := s.drillTarget(spec); err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		if dryRun(r) {
+			planned, err := drill.Plan(spec, time.Now())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			writeDryRun(w, map[string]interface{}{"drill": planned})
+			return
+		}
+		started, err := s.drills.Start(spec, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(started)
+	}
+}
+
+// drillTarget checks that a drill targets something it can affect: a source
+// of some pair, a scheduled feed, or a feed published on-chain
+func (s *Server) drillTarget(spec drill.Spec) (int, error) {
+	switch spec.Kind {
+	case drill.KindSourceOpen:
+		snapshot, err := crypto.CurrentConfig()
+		if err != nil {
+			return http.StatusServiceUnavailable, err
+		}
+		for _, pair := range snapshot.Pairs {
+			for _, source := range crypto.PairSources(pair) {
+				if source == spec.Target {
+					return 0, nil
+				}
+			}
+		}
+		return http.StatusNotFound, fmt.Errorf("no pair uses source %s", spec.Target)
+	case drill.KindFeedDegraded:
+		for _, feed := range s.scheduler.Feeds() {
+			if feed.Symbol == spec.Target {
+				return 0, nil
+			}
+		}
+		return http.StatusNotFound, fmt.Errorf("%s is not a scheduled feed", spec.Target)
+	case drill.KindPublishDelay:
+		if s.publishing == nil {
+			return http.StatusNotFound, fmt.Errorf("on-chain publishing is disabled")
+		}
+		if !s.knownFeed(spec.Target) {
+			return http.StatusNotFound, fmt.Errorf("unknown feed %s", spec.Target)
+		}
+	}
+	return 0, nil
+}
+
+// handleStopDrill ends a drill before it expires
+func (s *Server) handleStopDrill() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+		if dryRun(r) {
+			active, ok := s.drills.Get(id)
+			if !ok {
+				http.Error(w, fmt.Sprintf("no active drill %s", id), http.StatusNotFound)
+				return
+			}
+			writeDryRun(w, map[string]interface{}{"drill": active})
+			return
+		}
+		stopped, err := s.drills.Stop(id, operatorFrom(r))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"stopped": stopped,
+		})
+	}
+}
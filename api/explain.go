@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"yetaXYZ/oracle/common"
+	"yetaXYZ/oracle/sources/crypto"
+)
+
+// handleExplain traces how the most recent round of a feed was aggregated
+// from its sources: raw values, staleness, IQR fences, weights and the
+// weighted median walk
+func (s *Server) handleExplain() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		symbol := mux.Vars(r)["symbol"]
+
+		if s.derived.IsDerived(symbol) || s.statistics.IsStatistic(symbol) || s.pegs.IsPeg(symbol) || s.sides.IsSide(symbol) {
+			http.Error(w, fmt.Sprintf("feed %s is computed inside the oracle, not aggregated from sources", symbol), http.StatusBadRequest)
+			return
+		}
+		snapshot, err := crypto.CurrentConfig()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		pair, err := snapshot.PairConfig(symbol)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		result := s.latestRound(symbol)
+		if result == nil {
+			http.Error(w, fmt.Sprintf("no round yet for feed %s", symbol), http.StatusServiceUnavailable)
+			return
+		}
+		if result.Backfilled || result.Candle != nil {
+			http.Error(w, fmt.Sprintf("latest round of %s was not aggregated live", symbol), http.StatusConflict)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(crypto.Explain(pair, result, snapshot.Version))
+	}
+}
+
+// latestRound returns the most recent round of a fetched feed without
+// aggregating a new one: replicated, scheduled or, otherwise, stored
+func (s *Server) latestRound(symbol string) *common.AggregateResult {
+	if s.replica != nil {
+		return s.replicated(symbol)
+	}
+	if result, ok := s.scheduler.Latest(symbol); ok {
+		return result
+	}
+	result, err := s.store.Latest(symbol)
+	if err != nil {
+		return nil
+	}
+	return result
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"yetaXYZ/oracle/common"
+)
+
+// resultFields are the top-level fields of a feed value that ?fields= may
+// select: those of a round plus the windows, attributions and execution
+// estimate added on request
+var resultFields = func() map[string]bool {
+	fields := map[string]bool{"windows": true, "attributions": true, "execution": true}
+	var collect func(t reflect.Type)
+	collect = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.Anonymous {
+				collect(field.Type)
+				continue
+			}
+			if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+				fields[name] = true
+			}
+		}
+	}
+	collect(reflect.TypeOf(common.AggregateResult{}))
+	return fields
+}()
+
+// fieldsParam parses ?fields=price,timestamp, the fields of each feed value
+// to return; nil returns every field
+func fieldsParam(r *http.Request) ([]string, error) {
+	param := r.URL.Query().Get("fields")
+	if param == "" {
+		return nil, nil
+	}
+	fields := make([]string, 0)
+	for _, name := range strings.Split(param, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !resultFields[name] {
+			known := make([]string, 0, len(resultFields))
+			for field := range resultFields {
+				known = append(known, field)
+			}
+			sort.Strings(known)
+			return nil, fmt.Errorf("unknown field %q, want some of %s", name, strings.Join(known, ", "))
+		}
+		fields = append(fields, name)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("fields must name at least one field")
+	}
+	return fields, nil
+}
+
+// selectFields returns the selected top-level fields of a feed value, or
+// the value itself when fields is nil. Fields the value lacks, such as an
+// unset optional one, are left out.
+func selectFields(value interface{}, fields []string) (interface{}, error) {
+	if fields == nil {
+		return value, nil
+	}
+	if response, ok := value.(map[string]interface{}); ok {
+		selected := make(map[string]interface{}, len(fields))
+		for _, name := range fields {
+			if v, ok := response[name]; ok {
+				selected[name] = v
+			}
+		}
+		return selected, nil
+	}
+
+	// Nested values are copied through without being decoded
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &all); err != nil {
+		return nil, err
+	}
+	selected := make(map[string]json.RawMessage, len(fields))
+	for _, name := range fields {
+		if v, ok := all[name]; ok {
+			selected[name] = v
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// handleFreezes lists the pairs frozen on divergence awaiting
+// acknowledgement and the most recently acknowledged freezes
+func (s *Server) handleFreezes() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		frozen, acknowledged := s.aggregator.Freezes()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"frozen":       frozen,
+			"acknowledged": acknowledged,
+		})
+	}
+}
+
+// handleAcknowledgeFreeze resumes a frozen pair on the calling operator's
+// authority, recording the reason given
+func (s *Server) handleAcknowledgeFreeze() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Reason string `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		symbol := mux.Vars(r)["feedID"]
+		reason := strings.TrimSpace(req.Reason)
+		if reason == "" {
+			http.Error(w, "a reason is required", http.StatusBadRequest)
+			return
+		}
+		if dryRun(r) {
+			freeze, err := s.aggregator.PreviewAcknowledge(symbol, operatorFrom(r), reason)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			writeDryRun(w, map[string]interface{}{"freeze": freeze})
+			return
+		}
+		freeze, err := s.aggregator.Acknowledge(symbol, operatorFrom(r), reason)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		// Serve the next good round without waiting for the schedule
+		if err := s.scheduler.Trigger(symbol); err != nil {
+			log.Printf("Round of %s after its freeze was acknowledged not started: %v", symbol, err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"acknowledged": freeze,
+		})
+	}
+}
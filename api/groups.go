@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"yetaXYZ/oracle/common"
+	"yetaXYZ/oracle/proposals"
+	"yetaXYZ/oracle/sources/crypto"
+)
+
+// inGroup keeps the feeds tagged with the request's ?group=, all of them
+// without one
+func inGroup(r *http.Request, feeds []feedSummary) []feedSummary {
+	group := r.URL.Query().Get("group")
+	if group == "" {
+		return feeds
+	}
+	out := make([]feedSummary, 0, len(feeds))
+	for _, feed := range feeds {
+		for _, g := range feed.Groups {
+			if g == group {
+				out = append(out, feed)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// groupMembers returns the pairs of the request's group, writing a 404 when
+// the group has none
+func groupMembers(w http.ResponseWriter, r *http.Request) (string, []string, bool) {
+	group := mux.Vars(r)["group"]
+	snapshot, err := crypto.CurrentConfig()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return group, nil, false
+	}
+	members := snapshot.Group(group)
+	if len(members) == 0 {
+		http.Error(w, fmt.Sprintf("unknown group %s", group), http.StatusNotFound)
+		return group, nil, false
+	}
+	return group, members, true
+}
+
+// handleGroups lists every feed group with its pairs
+func (s *Server) handleGroups() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		snapshot, err := crypto.CurrentConfig()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"groups": snapshot.Groups(),
+		})
+	}
+}
+
+// handlePauseGroup pauses or resumes the scheduled rounds of every pair in
+// a group. Paused pairs keep serving their last round.
+func (s *Server) handlePauseGroup(paused bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		group, members, ok := groupMembers(w, r)
+		if !ok {
+			return
+		}
+		response := map[string]interface{}{
+			"group":  group,
+			"feeds":  members,
+			"paused": paused,
+		}
+		if dryRun(r) {
+			writeDryRun(w, response)
+			return
+		}
+		// Pairs added since startup are not scheduled yet
+		skipped := make(map[string]string)
+		for _, symbol := range members {
+			if err := s.scheduler.SetPaused(symbol, paused); err != nil {
+				skipped[symbol] = err.Error()
+			}
+		}
+		response["skipped"] = skipped
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// handleGroupHeartbeat proposes a new update interval for every pair in a
+// group, one proposal per pair subject to the approval policy
+func (s *Server) handleGroupHeartbeat() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		group, members, ok := groupMembers(w, r)
+		if !ok {
+			return
+		}
+		var req struct {
+			UpdateFrequencySeconds int    `json:"updateFrequencySeconds"`
+			Reason                 string `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		if req.UpdateFrequencySeconds <= 0 {
+			http.Error(w, "updateFrequencySeconds must be positive", http.StatusBadRequest)
+			return
+		}
+		if req.Reason == "" {
+			req.Reason = fmt.Sprintf("group %s heartbeat %ds", group, req.UpdateFrequencySeconds)
+		}
+
+		snapshot, err := crypto.CurrentConfig()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		// Every member is validated before anything is proposed, so an
+		// invalid pair leaves the group untouched
+		pairs := make(map[string]*common.PairConfig, len(members))
+		for _, symbol := range members {
+			pair := *snapshot.Pairs[symbol]
+			pair.UpdateFrequencySeconds = req.UpdateFrequencySeconds
+			if _, err := crypto.PreviewPairConfig(symbol, &pair); err != nil {
+				http.Error(w, fmt.Sprintf("%s: %v", symbol, err), http.StatusUnprocessableEntity)
+				return
+			}
+			pairs[symbol] = &pair
+		}
+
+		now := time.Now()
+		created := make([]*proposals.Proposal, 0, len(members))
+		failed := make(map[string]string)
+		for _, symbol := range members {
+			var proposal *proposals.Proposal
+			if dryRun(r) {
+				proposal, err = s.proposals.PreviewPropose(operatorFrom(r), symbol, pairs[symbol], req.Reason, now)
+			} else {
+				proposal, err = s.proposals.Propose(operatorFrom(r), symbol, pairs[symbol], req.Reason, now)
+			}
+			if err != nil {
+				failed[symbol] = err.Error()
+				continue
+			}
+			created = append(created, proposal)
+		}
+
+		response := map[string]interface{}{
+			"group":     group,
+			"proposals": created,
+		}
+		if dryRun(r) {
+			writeDryRun(w, response)
+			return
+		}
+		status := http.StatusCreated
+		switch {
+		case len(created) == 0:
+			// Nothing was proposed, so the request can be retried as is
+			http.Error(w, fmt.Sprintf("no proposal recorded: %v", failed), http.StatusInternalServerError)
+			return
+		case len(failed) > 0:
+			// Proposals made for the other members stand
+			status = http.StatusMultiStatus
+			response["failed"] = failed
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// handleRepublishGroup starts a fresh round of every pair in a group now,
+// which the publish pipeline then publishes as usual. Pairs that cannot
+// run a round are reported as skipped.
+func (s *Server) handleRepublishGroup() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		group, members, ok := groupMembers(w, r)
+		if !ok {
+			return
+		}
+		if dryRun(r) {
+			writeDryRun(w, map[string]interface{}{
+				"group": group,
+				"feeds": members,
+			})
+			return
+		}
+
+		triggered := make([]string, 0, len(members))
+		skipped := make(map[string]string)
+		for _, symbol := range members {
+			if err := s.scheduler.Trigger(symbol); err != nil {
+				skipped[symbol] = err.Error()
+				continue
+			}
+			triggered = append(triggered, symbol)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"group":     group,
+			"triggered": triggered,
+			"skipped":   skipped,
+		})
+	}
+}
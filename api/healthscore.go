@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"yetaXYZ/oracle/health"
+	"yetaXYZ/oracle/sources/crypto"
+)
+
+// handleHealthScore scores every scheduled feed and the instance as a whole
+// for weighted load balancing across replicas of differing freshness.
+// ?format=text answers with the bare instance score, for agent checks; the
+// X-Health-Score header carries it either way.
+func (s *Server) handleHealthScore() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		ready := s.scheduler.Priming().Done
+		if s.replica != nil {
+			ready = s.replica.Status().Connected
+		}
+
+		snapshot, _ := crypto.CurrentConfig()
+		states := s.scheduler.States()
+		feeds := make([]health.FeedScore, 0, len(states))
+		for _, feed := range s.scheduler.Feeds() {
+			state := states[feed.Symbol]
+			result, _ := s.scheduler.Latest(feed.Symbol)
+			if s.replica != nil {
+				result = s.replicated(feed.Symbol)
+			}
+			f := health.Feed{
+				Symbol:       feed.Symbol,
+				Interval:     feed.Interval,
+				Failures:     state.Failures,
+				Paused:       state.Paused,
+				MarketClosed: state.MarketClosed,
+			}
+			if snapshot != nil {
+				if pair, ok := snapshot.Pairs[feed.Symbol]; ok {
+					f.MinimumSources = pair.MinimumSources
+				}
+			}
+			if result != nil {
+				f.Available = true
+				f.Age = now.Sub(result.Timestamp)
+				f.Sources = len(result.Sources)
+				f.Fallback = result.FallbackReason != ""
+			}
+			feeds = append(feeds, health.Score(f))
+		}
+		score := health.Instance(feeds, ready)
+
+		w.Header().Set("X-Health-Score", strconv.FormatFloat(score, 'f', -1, 64))
+		if r.URL.Query().Get("format") == "text" {
+			w.Header().Set("Content-Type", "text/plain")
+			fmt.Fprintf(w, "%d\n", int(score))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"timestamp": now,
+			"score":     score,
+			"ready":     ready,
+			"feeds":     feeds,
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// idempotencyTTL is how long an admin response is kept for replay
+const idempotencyTTL = 24 * time.Hour
+
+// maxIdempotentBody bounds the request bodies hashed for idempotency
+const maxIdempotentBody = 1 << 20
+
+// idempotentResponse is the recorded outcome of an admin request
+type idempotentResponse struct {
+	fingerprint string // hash of the method, URL and body
+	done        bool
+	status      int
+	contentType string
+	body        []byte
+	expires     time.Time
+}
+
+// idempotencyCache keeps admin responses by operator and Idempotency-Key
+type idempotencyCache struct {
+	mu        sync.Mutex
+	responses map[string]*idempotentResponse
+}
+
+// begin claims key for a request, returning the recorded response when the
+// key was already used
+func (c *idempotencyCache) begin(key, fingerprint string, now time.Time) (*idempotentResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.responses == nil {
+		c.responses = make(map[string]*idempotentResponse)
+	}
+	for k, resp := range c.responses {
+		if resp.done && now.After(resp.expires) {
+			delete(c.responses, k)
+		}
+	}
+	if resp, ok := c.responses[key]; ok {
+		copied := *resp
+		return &copied, true
+	}
+	c.responses[key] = &idempotentResponse{fingerprint: fingerprint}
+	return nil, false
+}
+
+// finish records the response for key; server errors release the key so
+// the request can be retried
+func (c *idempotencyCache) finish(key string, rec *responseRecorder, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if rec.status >= 500 {
+		delete(c.responses, key)
+		return
+	}
+	resp := c.responses[key]
+	resp.done = true
+	resp.status = rec.status
+	if resp.status == 0 {
+		resp.status = http.StatusOK
+	}
+	resp.contentType = rec.Header().Get("Content-Type")
+	resp.body = rec.body.Bytes()
+	resp.expires = now.Add(idempotencyTTL)
+}
+
+// responseRecorder passes a response through while keeping a copy
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// idempotent makes an admin mutation safe to retry: a request carrying an
+// Idempotency-Key header runs once per operator and key, and retries with
+// the same key and body replay the recorded response. Dry runs change
+// nothing and are never recorded.
+func (s *Server) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" || dryRun(r) {
+			next(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBody+1))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to read request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		if len(body) > maxIdempotentBody {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(append([]byte(r.Method+" "+r.URL.String()+"\n"), body...))
+		fingerprint := hex.EncodeToString(sum[:])
+
+		cacheKey := operatorFrom(r) + "\x00" + key
+		if resp, ok := s.idempotency.begin(cacheKey, fingerprint, time.Now()); ok {
+			switch {
+			case resp.fingerprint != fingerprint:
+				http.Error(w, "Idempotency-Key was already used for a different request", http.StatusUnprocessableEntity)
+			case !resp.done:
+				http.Error(w, "a request with this Idempotency-Key is in progress", http.StatusConflict)
+			default:
+				if resp.contentType != "" {
+					w.Header().Set("Content-Type", resp.contentType)
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(resp.status)
+				w.Write(resp.body)
+			}
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w}
+		panicked := true
+		defer func() {
+			if panicked {
+				// Released like a server error, so the request can be
+				// retried rather than staying in progress
+				rec.status = http.StatusInternalServerError
+			}
+			s.idempotency.finish(cacheKey, rec, time.Now())
+		}()
+		next(rec, r)
+		panicked = false
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"yetaXYZ/oracle/common"
+)
+
+const (
+	// defaultNextTimeout and maxNextTimeout bound how long a long poll for
+	// the next round is held open
+	defaultNextTimeout = 30 * time.Second
+	maxNextTimeout     = 2 * time.Minute
+	// maxNextWaiters caps the long polls held open at once
+	maxNextWaiters = 10000
+)
+
+// roundWaiters wakes the requests long-polling for the next round of a feed
+type roundWaiters struct {
+	mu      sync.Mutex
+	waiting map[string]map[chan *common.AggregateResult]struct{}
+	count   int
+}
+
+// wait registers for the next round of symbol. It returns false when too
+// many requests are already waiting; cancel must be called otherwise.
+func (w *roundWaiters) wait(symbol string) (next chan *common.AggregateResult, cancel func(), ok bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.count >= maxNextWaiters {
+		return nil, nil, false
+	}
+	if w.waiting == nil {
+		w.waiting = make(map[string]map[chan *common.AggregateResult]struct{})
+	}
+	if w.waiting[symbol] == nil {
+		w.waiting[symbol] = make(map[chan *common.AggregateResult]struct{})
+	}
+	next = make(chan *common.AggregateResult, 1)
+	w.waiting[symbol][next] = struct{}{}
+	w.count++
+	return next, func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if _, ok := w.waiting[symbol][next]; ok {
+			delete(w.waiting[symbol], next)
+			w.count--
+		}
+		if len(w.waiting[symbol]) == 0 {
+			delete(w.waiting, symbol)
+		}
+	}, true
+}
+
+// notify hands a completed round to every request waiting for its feed
+func (w *roundWaiters) notify(result *common.AggregateResult) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for next := range w.waiting[result.Symbol] {
+		next <- result
+		w.count--
+	}
+	delete(w.waiting, result.Symbol)
+}
+
+// handleNextPrice holds the request until the feed's next round completes
+// and returns that round, or answers 204 No Content once ?timeout= (default
+// 30s) passes without one, giving consumers push-like freshness over plain
+// HTTP
+func (s *Server) handleNextPrice() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		symbol := mux.Vars(r)["symbol"]
+		if !s.knownFeed(symbol) {
+			http.Error(w, fmt.Sprintf("unknown feed %s", symbol), http.StatusNotFound)
+			return
+		}
+		timeout := defaultNextTimeout
+		if value := r.URL.Query().Get("timeout"); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 || d > maxNextTimeout {
+				http.Error(w, fmt.Sprintf("timeout must be a positive duration of at most %s", maxNextTimeout), http.StatusBadRequest)
+				return
+			}
+			timeout = d
+		}
+
+		next, cancel, ok := s.next.wait(symbol)
+		if !ok {
+			http.Error(w, "too many requests waiting for rounds", http.StatusServiceUnavailable)
+			return
+		}
+		defer cancel()
+
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case <-r.Context().Done():
+		case <-timer.C:
+			w.Header().Set("Cache-Control", "no-store")
+			w.WriteHeader(http.StatusNoContent)
+		case result := <-next:
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Cache-Control", "no-store")
+			json.NewEncoder(w).Encode(windowedResult{AggregateResult: result, Attributions: s.attributions(symbol)})
+		}
+	}
+}
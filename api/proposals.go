@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"yetaXYZ/oracle/common"
+	"yetaXYZ/oracle/proposals"
+	"yetaXYZ/oracle/scheduler"
+	"yetaXYZ/oracle/sources/crypto"
+)
+
+// configApplier activates proposals against the on-disk configuration
+type configApplier struct {
+	configDir string
+	server    *Server
+}
+
+// PairVersion returns the version of a pair's running configuration
+func (a *configApplier) PairVersion(symbol string) string {
+	snapshot, err := crypto.CurrentConfig()
+	if err != nil {
+		return ""
+	}
+	return snapshot.PairVersion(symbol)
+}
+
+// Apply writes and activates a pair configuration, moving a scheduled
+// feed to its new update interval
+func (a *configApplier) Apply(symbol string, pair *common.PairConfig) error {
+	if err := crypto.ApplyPairConfig(a.configDir, symbol, pair); err != nil {
+		return err
+	}
+	if a.server != nil && a.server.scheduler != nil {
+		a.server.scheduler.SetInterval(symbol, scheduler.Interval(pair))
+	}
+	return nil
+}
+
+// proposalPolicy reads the approval policy from the environment:
+// ORACLE_PROPOSAL_APPROVALS (default 1) and ORACLE_PROPOSAL_TIMELOCK
+// (a duration, default none). Pending proposals expire after 7 days.
+func proposalPolicy() (proposals.Policy, error) {
+	policy := proposals.Policy{Approvals: 1, Expiry: 7 * 24 * time.Hour}
+	if value := os.Getenv("ORACLE_PROPOSAL_APPROVALS"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return policy, fmt.Errorf("invalid ORACLE_PROPOSAL_APPROVALS: %q", value)
+		}
+		policy.Approvals = n
+	}
+	if value := os.Getenv("ORACLE_PROPOSAL_TIMELOCK"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return policy, fmt.Errorf("invalid ORACLE_PROPOSAL_TIMELOCK: %q", value)
+		}
+		policy.Timelock = d
+	}
+	if policy.Approvals == 0 && policy.Timelock == 0 {
+		return policy, fmt.Errorf("config proposals need approvals or a timelock")
+	}
+	return policy, nil
+}
+
+// handleListProposals lists proposals, optionally filtered by ?status=
+func (s *Server) handleListProposals() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"proposals": s.proposals.List(r.URL.Query().Get("status")),
+		})
+	}
+}
+
+// handleCreateProposal proposes a new configuration for a pair
+func (s *Server) handleCreateProposal() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Symbol string             `json:"symbol"`
+			Pair   *common.PairConfig `json:"pair"`
+			Reason string             `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		if dryRun(r) {
+			proposal, err := s.proposals.PreviewPropose(operatorFrom(r), req.Symbol, req.Pair, req.Reason, time.Now())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			s.previewProposal(w, proposal)
+			return
+		}
+		proposal, err := s.proposals.Propose(operatorFrom(r), req.Symbol, req.Pair, req.Reason, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(proposal)
+	}
+}
+
+// handleApproveProposal records the calling operator's approval
+func (s *Server) handleApproveProposal() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if dryRun(r) {
+			proposal, err := s.proposals.PreviewApprove(operatorFrom(r), mux.Vars(r)["id"], time.Now())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			s.previewProposal(w, proposal)
+			return
+		}
+		proposal, err := s.proposals.Approve(operatorFrom(r), mux.Vars(r)["id"], time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(proposal)
+	}
+}
+
+// handleCancelProposal withdraws a pending proposal
+func (s *Server) handleCancelProposal() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if dryRun(r) {
+			proposal, err := s.proposals.PreviewCancel(operatorFrom(r), mux.Vars(r)["id"], time.Now())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			writeDryRun(w, map[string]interface{}{"proposal": proposal})
+			return
+		}
+		proposal, err := s.proposals.Cancel(operatorFrom(r), mux.Vars(r)["id"], time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(proposal)
+	}
+}
+
+// previewProposal validates a proposal's pair configuration against the
+// running configuration and writes the proposal with the changes to the
+// feed's behavior it would make once active
+func (s *Server) previewProposal(w http.ResponseWriter, proposal *proposals.Proposal) {
+	changes, err := crypto.PreviewPairConfig(proposal.Symbol, proposal.Pair)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	writeDryRun(w, map[string]interface{}{
+		"proposal": proposal,
+		"changes":  changes,
+	})
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"yetaXYZ/oracle/publish"
+)
+
+// handlePublishes returns the on-chain publish receipts of a feed, newest
+// round first
+func (s *Server) handlePublishes() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.publishJournal == nil {
+			http.Error(w, "on-chain publishing is disabled", http.StatusNotFound)
+			return
+		}
+
+		symbol := mux.Vars(r)["feedID"]
+		receipts := s.publishJournal.List(symbol)
+
+		limit := 100
+		if value := r.URL.Query().Get("limit"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+		if len(receipts) > limit {
+			receipts = receipts[:limit]
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"feedId":   symbol,
+			"receipts": receipts,
+		})
+	}
+}
+
+// handlePublishComposition returns the source breakdown published with a
+// round and whether it matches the hash recorded with it and on-chain
+func (s *Server) handlePublishComposition() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.publishing == nil {
+			http.Error(w, "on-chain publishing is disabled", http.StatusNotFound)
+			return
+		}
+
+		vars := mux.Vars(r)
+		roundID, err := strconv.ParseUint(vars["roundID"], 10, 64)
+		if err != nil {
+			http.Error(w, "invalid round ID", http.StatusBadRequest)
+			return
+		}
+		receipt, ok := s.publishJournal.Get(vars["feedID"], roundID)
+		if !ok || receipt.Composition == nil {
+			http.Error(w, "no composition published for this round", http.StatusNotFound)
+			return
+		}
+
+		response := map[string]interface{}{
+			"feedId":      receipt.Symbol,
+			"roundId":     receipt.RoundID,
+			"value":       receipt.Value,
+			"status":      receipt.Status,
+			"txHash":      receipt.TxHash,
+			"composition": receipt.Composition,
+		}
+		onChain, err := s.publishing.VerifyComposition(r.Context(), receipt)
+		response["verified"] = err == nil
+		response["checkedOnChain"] = onChain
+		if err != nil {
+			response["error"] = err.Error()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// handlePublishFunding returns the balance checks and top-ups of the
+// publishing account on a test network
+func (s *Server) handlePublishFunding() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.funding == nil {
+			http.Error(w, "publisher funding is disabled", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.funding.Status())
+	}
+}
+
+// handlePublishHolds returns the rounds withheld from publication by the
+// rate-of-change breaker
+func (s *Server) handlePublishHolds() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.publishing == nil {
+			http.Error(w, "on-chain publishing is disabled", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"holds": s.publishing.Holds(),
+		})
+	}
+}
+
+// handleOverridePublishHold publishes a feed's held round on the calling
+// operator's authority
+func (s *Server) handleOverridePublishHold() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.publishing == nil {
+			http.Error(w, "on-chain publishing is disabled", http.StatusNotFound)
+			return
+		}
+		symbol := mux.Vars(r)["feedID"]
+		if dryRun(r) {
+			hold, err := s.publishing.PreviewOverride(symbol)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			writeDryRun(w, map[string]interface{}{"hold": hold})
+			return
+		}
+		hold, err := s.publishing.Override(r.Context(), operatorFrom(r), symbol)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"published": hold,
+		})
+	}
+}
+
+// handlePublisherStatus reports the publishing account's nonces per chain:
+// gaps, stuck transactions and the replacements made
+func (s *Server) handlePublisherStatus() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.publishing == nil {
+			http.Error(w, "on-chain publishing is disabled", http.StatusNotFound)
+			return
+		}
+		chains := make([]publish.NonceStatus, 0, 1)
+		if status, ok := s.publishing.NonceStatus(); ok {
+			chains = append(chains, status)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"chains": chains,
+		})
+	}
+}
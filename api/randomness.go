@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"yetaXYZ/oracle/randomness"
+)
+
+// maxSeedBytes bounds seeds of requested proofs
+const maxSeedBytes = 256
+
+// handleRandomness returns the latest beacon round with what is needed to
+// verify it
+func (s *Server) handleRandomness() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.randomness == nil {
+			http.Error(w, "randomness is disabled", http.StatusNotFound)
+			return
+		}
+		round, ok := s.randomness.Latest()
+		if !ok {
+			http.Error(w, "no randomness round produced yet", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.randomnessResponse(round))
+	}
+}
+
+// handleRandomnessRound returns a beacon round by number
+func (s *Server) handleRandomnessRound() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.randomness == nil {
+			http.Error(w, "randomness is disabled", http.StatusNotFound)
+			return
+		}
+		n, err := strconv.ParseUint(mux.Vars(r)["round"], 10, 64)
+		if err != nil {
+			http.Error(w, "round must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		round, err := s.randomness.Round(n, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.randomnessResponse(round))
+	}
+}
+
+// handleRandomnessProof proves the hex-encoded seed parameter with the
+// operator's VRF key
+func (s *Server) handleRandomnessProof() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.randomness == nil {
+			http.Error(w, "randomness is disabled", http.StatusNotFound)
+			return
+		}
+		seed, err := hex.DecodeString(strings.TrimPrefix(r.URL.Query().Get("seed"), "0x"))
+		if err != nil || len(seed) == 0 || len(seed) > maxSeedBytes {
+			http.Error(w, fmt.Sprintf("seed must be 1 to %d hex-encoded bytes", maxSeedBytes), http.StatusBadRequest)
+			return
+		}
+		proof, err := s.randomness.Prove(seed)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(proof)
+	}
+}
+
+// randomnessResponse adds the verification parameters to a round; a vrf
+// round is verified with the key that signed it, which is not the current
+// one for rounds before a rotation
+func (s *Server) randomnessResponse(round *randomness.Round) map[string]interface{} {
+	response := map[string]interface{}{
+		"round": round,
+	}
+	if round.PublicKey != "" {
+		response["suite"] = randomness.Suite
+		response["publicKey"] = round.PublicKey
+	}
+	return response
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"yetaXYZ/oracle/sources/rates"
+)
+
+// handleRates returns the latest observation of every benchmark rate
+func (s *Server) handleRates() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		observations := make([]*rates.Observation, 0)
+		for _, name := range s.rates.Benchmarks() {
+			if obs, ok := s.rates.Latest(name); ok {
+				observations = append(observations, obs)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"rates": observations,
+		})
+	}
+}
+
+// handleGetRate returns the latest observation of a single benchmark rate
+func (s *Server) handleGetRate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := mux.Vars(r)["benchmark"]
+		if !s.rates.IsBenchmark(name) {
+			http.Error(w, fmt.Sprintf("unknown benchmark %s", name), http.StatusNotFound)
+			return
+		}
+
+		obs, ok := s.rates.Latest(name)
+		if !ok {
+			http.Error(w, fmt.Sprintf("no value yet for benchmark %s", name), http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(obs)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"yetaXYZ/oracle/common"
+	"yetaXYZ/oracle/credentials"
+	"yetaXYZ/oracle/events"
+	"yetaXYZ/oracle/replica"
+)
+
+// replicaFollower returns a follower of ORACLE_PRIMARY_URL, authenticated
+// with ORACLE_PRIMARY_API_KEY if set, when ORACLE_MODE is "replica", and nil
+// for a primary instance
+func replicaFollower(bus *events.Bus) (*replica.Follower, error) {
+	switch mode := os.Getenv("ORACLE_MODE"); mode {
+	case "", "primary", "canary", "standby":
+		return nil, nil
+	case "replica":
+		primary := os.Getenv("ORACLE_PRIMARY_URL")
+		if primary == "" {
+			return nil, fmt.Errorf("ORACLE_PRIMARY_URL is required in replica mode")
+		}
+		follower := replica.NewFollower(primary, bus)
+		// A metered primary requires an API key on its stream
+		follower.SetAPIKey(credentials.Get("ORACLE_PRIMARY_API_KEY"))
+		return follower, nil
+	default:
+		return nil, fmt.Errorf("invalid ORACLE_MODE: %q", mode)
+	}
+}
+
+// replicated returns the latest round of a feed received from the primary
+func (s *Server) replicated(symbol string) *common.AggregateResult {
+	result, err := s.store.Latest(symbol)
+	if err != nil {
+		return nil
+	}
+	return result
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"yetaXYZ/oracle/sources/crypto"
+)
+
+// handleRound reconstructs a past round of a feed from the store: the
+// source prices it was aggregated from, the parameters of the config
+// version it ran under, the aggregation replayed under them and, for a
+// published round, its publish receipt
+func (s *Server) handleRound() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		symbol := vars["feedID"]
+		roundID, err := strconv.ParseUint(vars["roundID"], 10, 64)
+		if err != nil || roundID == 0 {
+			http.Error(w, "invalid round ID", http.StatusBadRequest)
+			return
+		}
+		result, err := s.store.Round(symbol, roundID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		response := map[string]interface{}{
+			"feedId":        symbol,
+			"roundId":       roundID,
+			"configVersion": result.ConfigVersion,
+			"round":         result,
+		}
+		// Evidence comes from the config the round ran under, never the
+		// current one
+		snapshot, archived := crypto.ConfigAt(result.ConfigVersion)
+		response["configArchived"] = archived
+		if archived {
+			if pair, err := snapshot.PairConfig(symbol); err == nil {
+				response["config"] = pair
+				if !result.Backfilled {
+					response["explanation"] = crypto.Explain(pair, result, result.ConfigVersion)
+				}
+			} else if feed, ok := snapshot.Derived[symbol]; ok {
+				response["config"] = feed
+			} else if feed, ok := snapshot.Statistics[symbol]; ok {
+				response["config"] = feed
+			}
+		}
+		if s.publishJournal != nil {
+			if receipt, ok := s.publishJournal.Get(symbol, roundID); ok {
+				response["publish"] = receipt
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
@@ -1,31 +1,135 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
+	"yetaXYZ/oracle/analytics"
+	"yetaXYZ/oracle/attestation"
+	"yetaXYZ/oracle/attribution"
+	"yetaXYZ/oracle/backfill"
+	"yetaXYZ/oracle/calendar"
+	"yetaXYZ/oracle/canary"
 	"yetaXYZ/oracle/common"
+	"yetaXYZ/oracle/consistency"
+	"yetaXYZ/oracle/costs"
+	"yetaXYZ/oracle/credentials"
+	"yetaXYZ/oracle/derived"
+	"yetaXYZ/oracle/drill"
+	"yetaXYZ/oracle/events"
+	"yetaXYZ/oracle/evm"
+	"yetaXYZ/oracle/fetch"
+	"yetaXYZ/oracle/metering"
+	"yetaXYZ/oracle/pegs"
+	"yetaXYZ/oracle/proposals"
+	"yetaXYZ/oracle/publish"
+	"yetaXYZ/oracle/randomness"
+	"yetaXYZ/oracle/replica"
+	"yetaXYZ/oracle/rewards"
+	"yetaXYZ/oracle/scheduler"
+	"yetaXYZ/oracle/sides"
 	"yetaXYZ/oracle/sources/crypto"
+	"yetaXYZ/oracle/sources/rates"
+	"yetaXYZ/oracle/standby"
+	"yetaXYZ/oracle/store"
+	"yetaXYZ/oracle/symbols"
+	"yetaXYZ/oracle/webhooks"
 )
 
 // Server represents the API server
 type Server struct {
-	router     *mux.Router
-	aggregator *crypto.CryptoAggregator
-	config     *common.BaseConfig
+	router      *mux.Router
+	aggregator  *crypto.CryptoAggregator
+	maintenance *crypto.MaintenanceMonitor
+	books       *crypto.BookStreams
+	auditor     *crypto.Auditor
+	config      *common.BaseConfig
+	bus         *events.Bus
+	scheduler   *scheduler.Scheduler
+	onDemand    *scheduler.OnDemand
+	derived     *derived.Engine
+	sides       *sides.Feeds
+	store       store.Store
+	retention   *store.Compactor
+	statistics  *analytics.Service
+	rings       *analytics.Rings
+	weights     *analytics.WeightAdvisor
+	forensics   *analytics.ManipulationDetector
+	coldStart   *analytics.ColdStart
+	rates       *rates.Service
+	triangles   *consistency.Checker
+	pegs        *pegs.Monitor
+	alerts      *alertLog
+	attribution *attribution.Registry
+	meter       *metering.Meter
+	costs       *costs.Tracker
+	proposals   *proposals.Manager
+	idempotency *idempotencyCache
+	drills      *drill.Drills
+	next        roundWaiters
+
+	// publishing is nil when on-chain publication is disabled
+	publishing     *publish.Pipeline
+	publishJournal *publish.Journal
+	// funding is nil unless the publishing profile tops up its account
+	funding *publish.Funder
+	// webhooks is nil unless state-transition webhooks are enabled
+	webhooks *webhooks.Notifier
+	// rewards is nil unless source rewards accounting is enabled
+	rewards *rewards.Ledger
+	// randomness is nil unless the randomness beacon is enabled
+	randomness *randomness.Beacon
+	// attestor is nil unless event outcome attestation is enabled
+	attestor *attestation.Attestor
+
+	// replica is set on query-only instances following a primary
+	replica *replica.Follower
+	// canary is set on instances aggregating in shadow of production to
+	// compare their rounds before promotion
+	canary *canary.Comparator
+	// standby is set on instances mirroring a leader's state to take over
+	// from it
+	standby *standby.Mirror
 }
 
-// NewServer creates a new API server
-func NewServer() (*Server, error) {
+// NewServer creates a new API server; env selects the environment profile
+// of the publishing target, empty for the default
+func NewServer(env string) (*Server, error) {
+	// Keys may come from a reloadable credentials file instead of the
+	// environment, set up by main
+	creds := credentials.Default()
+
 	// Load configuration
 	configDir := filepath.Join("..", "config")
+	// Benchmark rates and price indices may be inputs of derived feeds
+	ratesConfig, err := rates.LoadConfig(configDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rates config: %v", err)
+	}
+	for name := range ratesConfig.Benchmarks {
+		crypto.ExternalFeeds[name] = true
+	}
+	// and so may the rates of monitored pegs
+	pegsConfig, err := pegs.LoadConfig(configDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pegs config: %v", err)
+	}
+	for symbol := range pegsConfig.Pegs {
+		crypto.ExternalFeeds[symbol] = true
+	}
 	if err := crypto.LoadConfig(configDir); err != nil {
 		return nil, fmt.Errorf("failed to load config: %v", err)
 	}
@@ -35,23 +139,499 @@ func NewServer() (*Server, error) {
 		return nil, fmt.Errorf("invalid configuration: %v", err)
 	}
 
-	// Create aggregator
+	// Identify the oracle's upstream traffic and this node
+	fetch.Configure(crypto.BaseConfig, fetch.InstanceID())
+
+	// Staging deployments can inject faults into source responses
+	chaosConfig, err := fetch.LoadChaosConfig(configDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chaos config: %v", err)
+	}
+
+	if _, err := parseOperators(credentials.Get("ORACLE_ADMIN_TOKEN"), credentials.Get("ORACLE_ADMIN_TOKENS")); err != nil {
+		return nil, fmt.Errorf("invalid admin tokens: %v", err)
+	}
+
+	// Consumers of a metered oracle authenticate with API keys
+	meteringConfig, err := metering.LoadConfig(configDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metering config: %v", err)
+	}
+	meter, err := metering.NewMeter(meteringConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metering config: %v", err)
+	}
+
+	// Create event bus and aggregator
+	bus := events.NewBus()
+	// Alert when an upstream API changes the shape of its responses
+	fetch.OnSchemaChange(func(change fetch.SchemaChange) {
+		severity := events.SeverityInfo
+		if len(change.Removed) > 0 {
+			severity = events.SeverityWarning
+		}
+		bus.Publish(events.Event{
+			Type: events.Alert,
+			Payload: &events.AlertPayload{
+				Severity: severity,
+				Kind:     "source_schema_changed",
+				Message:  fmt.Sprintf("%s %s responses changed shape: added %v, removed %v", change.Source, change.Endpoint, change.Added, change.Removed),
+			},
+		})
+	})
+	// Estimate what each request to a paid source costs against its budgets
+	costsConfig, err := costs.LoadConfig(configDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid costs config: %v", err)
+	}
+	tracker, err := costs.NewTracker(costsConfig, bus, fetch.HostSources)
+	if err != nil {
+		return nil, err
+	}
+	fetch.OnResponse(func(host string, status int, header http.Header) {
+		tracker.Observe(host, header, time.Now())
+	})
 	aggregator := crypto.NewCryptoAggregator(crypto.BaseConfig)
+	aggregator.SetEventBus(bus)
+
+	// Skip exchanges under current or announced maintenance
+	maintenance := crypto.NewMaintenanceMonitor(crypto.BaseConfig)
+	aggregator.SetMaintenance(maintenance)
+
+	// Price exchanges with an orderBook config from streamed books
+	books := crypto.NewBookStreams()
+	aggregator.SetBooks(books)
+
+	// Operators may inject failures for game-day drills
+	drills := drill.New(bus)
+	aggregator.SetDrills(drills)
+
+	// Hand REST and DEX fetches to worker processes when a worker queue is
+	// configured
+	queue, err := workerQueue()
+	if err != nil {
+		return nil, err
+	}
+	if queue != nil {
+		aggregator.SetWorkers(queue)
+	}
 
 	server := &Server{
-		router:     mux.NewRouter(),
-		aggregator: aggregator,
-		config:     crypto.BaseConfig,
+		router:      mux.NewRouter(),
+		aggregator:  aggregator,
+		maintenance: maintenance,
+		books:       books,
+		auditor:     crypto.NewAuditor(aggregator, bus, crypto.DefaultAuditConfig),
+		config:      crypto.BaseConfig,
+		bus:         bus,
+		alerts:      &alertLog{},
+		meter:       meter,
+		costs:       tracker,
+		idempotency: &idempotencyCache{},
+		drills:      drills,
+	}
+
+	// A read replica serves rounds replicated from a primary and runs no
+	// scheduler, fetchers or publishing of its own
+	server.replica, err = replicaFollower(bus)
+	if err != nil {
+		return nil, err
+	}
+	// A canary aggregates like a primary but, like a replica, has no side
+	// effects; its rounds are compared against production's
+	server.canary, err = canaryComparator(configDir, bus)
+	if err != nil {
+		return nil, err
+	}
+	// A standby is configured like a primary but mirrors the leader's state
+	// and stays idle until promoted
+	server.standby, err = standbyMirror(bus)
+	if err != nil {
+		return nil, err
+	}
+
+	// Recompute derived feeds whenever one of their inputs updates
+	feeds := make(map[string]bool, len(crypto.PairsConfig))
+	for symbol := range crypto.PairsConfig {
+		feeds[symbol] = true
+	}
+	for symbol := range crypto.ExternalFeeds {
+		feeds[symbol] = true
+	}
+	graph, err := derived.NewGraph(crypto.DerivedConfig, feeds)
+	if err != nil {
+		return nil, fmt.Errorf("invalid derived feeds: %v", err)
+	}
+	server.derived = derived.NewEngine(graph, bus)
+	// Split the bid, mid and ask of pairs aggregating both sides into
+	// sub-feeds
+	server.sides = sides.New(crypto.PairsConfig, bus)
+	if server.replica == nil {
+		server.derived.Start()
+		server.sides.Start()
+	}
+
+	storeConfig, err := store.LoadConfig(configDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid store config: %v", err)
+	}
+
+	// Persist every completed round and compute statistic feeds from history
+	server.store = store.NewMemoryStore()
+	store.Record(server.store, bus)
+	if storeConfig.ConfigArchive != "" {
+		// Keep the parameters of past rounds for their reconstruction
+		if err := crypto.SetConfigArchive(storeConfig.ConfigArchive); err != nil {
+			return nil, fmt.Errorf("invalid store config: %v", err)
+		}
+	}
+	if storeConfig.FreezeState != "" {
+		// Keep pairs frozen across restarts until acknowledged
+		if err := aggregator.SetFreezeState(storeConfig.FreezeState); err != nil {
+			return nil, fmt.Errorf("invalid store config: %v", err)
+		}
+	} else {
+		for symbol, pair := range crypto.PairsConfig {
+			if pair.Freeze != nil {
+				log.Printf("Pair %s freezes on divergence but store freezeState is unset; a restart resumes frozen pairs unacknowledged", symbol)
+			}
+		}
+	}
+	// Keep each feed's latest rounds in memory for TWAP windows and
+	// statistic feeds
+	server.rings = analytics.NewRings(storeConfig.RingSize)
+	server.rings.Subscribe(bus)
+	server.statistics = analytics.NewService(server.store, bus, crypto.StatisticsConfig)
+	server.statistics.SetRings(server.rings)
+
+	// Downsample and expire old history so the store does not grow forever
+	server.retention = store.NewCompactor(server.store, storeConfig.Retention)
+
+	// Benchmark sources against final prices to suggest weights for review
+	server.weights = analytics.NewWeightAdvisor(server.store, func() map[string]*common.PairConfig {
+		snapshot, err := crypto.CurrentConfig()
+		if err != nil {
+			return nil
+		}
+		return snapshot.Pairs
+	}, 7*24*time.Hour, 100)
+
+	// Flag stored rounds that suggest manipulation for security review
+	server.forensics = analytics.NewManipulationDetector(server.store, bus, func() map[string]*common.PairConfig {
+		snapshot, err := crypto.CurrentConfig()
+		if err != nil {
+			return nil
+		}
+		return snapshot.Pairs
+	}, 7*24*time.Hour, analytics.DefaultManipulationThresholds)
+
+	// Bootstrap volatility and sanity bounds of new pairs from a proxy feed
+	// or exchange klines until they have history of their own
+	server.coldStart = analytics.NewColdStart(server.store, bus, func() map[string]*common.PairConfig {
+		snapshot, err := crypto.CurrentConfig()
+		if err != nil {
+			return nil
+		}
+		return snapshot.Pairs
+	}, backfill.New(crypto.BaseConfig, server.store).History)
+	server.statistics.SetColdStart(server.coldStart)
+
+	// Cross-check related feeds against the prices their legs imply
+	consistencyConfig, err := consistency.LoadConfig(configDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid consistency config: %v", err)
+	}
+	for symbol := range crypto.DerivedConfig {
+		feeds[symbol] = true
+	}
+	if err := consistencyConfig.Validate(feeds); err != nil {
+		return nil, fmt.Errorf("invalid consistency config: %v", err)
+	}
+	server.triangles = consistency.NewChecker(consistencyConfig, server.store, bus)
+
+	// Compare wrapped and bridged assets' DEX prices on each chain with
+	// their canonical feeds
+	pegInputs := make(map[string]bool, len(feeds))
+	for symbol := range feeds {
+		if _, isPeg := pegsConfig.Pegs[symbol]; !isPeg {
+			pegInputs[symbol] = true
+		}
+	}
+	if err := pegsConfig.Validate(crypto.BaseConfig, pegInputs); err != nil {
+		return nil, fmt.Errorf("invalid pegs config: %v", err)
+	}
+	server.pegs = pegs.NewMonitor(pegsConfig, crypto.BaseConfig, server.store, bus, fetch.NewClient(15*time.Second))
+
+	// Poll benchmark interest rates and price indices alongside the price feeds
+	server.rates = rates.NewService(ratesConfig, bus)
+
+	// Responses credit the providers whose data they carry
+	server.attribution, err = attribution.NewRegistry(crypto.BaseConfig, ratesConfig.Attributions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attributions: %v", err)
+	}
+
+	// Config changes made through the admin API are proposals that need a
+	// second operator's approval and/or a timelock before activation
+	policy, err := proposalPolicy()
+	if err != nil {
+		return nil, err
+	}
+	server.proposals, err = proposals.NewManager(policy, &configApplier{configDir: configDir, server: server}, os.Getenv("ORACLE_PROPOSALS_FILE"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Account for each source's participation in rounds for reward reports
+	rewardsConfig, err := rewards.LoadConfig(configDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rewards config: %v", err)
+	}
+	if rewardsConfig.Enabled && server.sideEffects() {
+		server.rewards, err = rewards.NewLedger(rewardsConfig, bus, func(symbol string) bool {
+			_, err := crypto.GetPairConfig(symbol)
+			return err == nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Publish configured feeds on-chain, continuing round numbering from the
+	// receipt journal so rounds are never published twice across restarts
+	publishConfig, err := publish.LoadConfig(configDir, env)
+	if err != nil {
+		return nil, fmt.Errorf("invalid publish config: %v", err)
+	}
+	var publisher publish.Publisher
+	if publishConfig.Enabled && server.sideEffects() {
+		if err := publishConfig.ResolveChain(crypto.BaseConfig.Chains); err != nil {
+			return nil, fmt.Errorf("invalid publish config: %v", err)
+		}
+		journal, err := publish.OpenJournal(publishConfig.Journal)
+		if err != nil {
+			return nil, err
+		}
+		aggregator.ResumeRounds(journal.LastRounds())
+		client := evm.NewClient(publishConfig.RPCUrl, fetch.NewClient(15*time.Second))
+		publisher, err = publish.NewPublisher(publishConfig, client)
+		if err != nil {
+			return nil, fmt.Errorf("invalid publish config: %v", err)
+		}
+		server.publishJournal = journal
+		server.publishing = publish.NewPipeline(publishConfig, journal, publisher, bus)
+		server.publishing.SetSourceList(func(symbol string) []string {
+			pair, err := crypto.GetPairConfig(symbol)
+			if err != nil {
+				return nil
+			}
+			return crypto.PairSources(pair)
+		})
+		server.publishing.SetDrills(drills)
+		if publishConfig.Funding != nil {
+			server.funding = publish.NewFunder(publishConfig, client, bus)
+		}
+		if env != "" {
+			log.Printf("Publishing with the %s profile to %s on chain %s", env, publishConfig.Contract, publishConfig.Chain)
+		}
+	}
+	// Corrupted rounds must never reach a contract
+	if chaosConfig.Enabled && server.publishing != nil {
+		return nil, fmt.Errorf("chaos mode cannot be enabled while publishing on-chain")
+	}
+	fetch.EnableChaos(chaosConfig)
+
+	// Schedule all configured pairs, priming them with a staggered start and
+	// fetching only during their markets' trading sessions
+	calendars, err := calendar.LoadConfig(configDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid calendar config: %v", err)
+	}
+	scheduled, err := scheduler.FeedsFromConfig(crypto.BaseConfig, crypto.PairsConfig, calendars)
+	if err != nil {
+		return nil, fmt.Errorf("invalid feed schedule: %v", err)
+	}
+	server.scheduler = scheduler.New(aggregator, scheduled, scheduler.Options{
+		StaggerWindow: 2 * time.Second,
+	})
+	server.onDemand = scheduler.NewOnDemand(aggregator, crypto.PairsConfig)
+
+	// Convert sources quoted in other members of a quote class with the
+	// latest rounds of the members' feeds, which may be peg rates of the
+	// depeg monitor such as USDTUSD
+	aggregator.SetFeedLookup(func(symbol string) (*common.AggregateResult, bool) {
+		if server.derived.IsDerived(symbol) {
+			return server.derived.Latest(symbol)
+		}
+		if server.pegs.IsPeg(symbol) {
+			return server.pegs.Latest(symbol)
+		}
+		return server.scheduler.Latest(symbol)
+	})
+
+	// Notify monitoring of feed and source state transitions; feed states
+	// are the summary's quality flags once priming is done
+	webhooksConfig, err := webhooks.LoadConfig(configDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhooks config: %v", err)
+	}
+	if webhooksConfig.Enabled && server.sideEffects() {
+		server.webhooks = webhooks.NewNotifier(webhooksConfig, func() map[string]string {
+			if !server.scheduler.Priming().Done {
+				return nil
+			}
+			states := make(map[string]string)
+			for _, feed := range server.summaries(time.Now()) {
+				states[feed.Symbol] = feed.Quality
+			}
+			return states
+		}, bus)
+	}
+
+	// Produce verifiable randomness, published under its own feed ID when
+	// publishing is enabled
+	randomnessConfig, err := randomness.LoadConfig(configDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid randomness config: %v", err)
+	}
+	if randomnessConfig.Enabled && server.sideEffects() {
+		server.randomness, err = randomness.NewBeacon(randomnessConfig, bus)
+		if err != nil {
+			return nil, fmt.Errorf("invalid randomness config: %v", err)
+		}
+		if publisher != nil {
+			server.randomness.SetSubmitter(publisher)
+		}
+	}
+
+	// Attest outcomes of configured questions, resolving feed questions
+	// from the round store
+	attestationConfig, err := attestation.LoadConfig(configDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attestation config: %v", err)
+	}
+	if attestationConfig.Enabled && server.sideEffects() {
+		server.attestor, err = attestation.NewAttestor(attestationConfig, server.store.Rounds, bus)
+		if err != nil {
+			return nil, fmt.Errorf("invalid attestation config: %v", err)
+		}
+		if publisher != nil {
+			server.attestor.SetSubmitter(publisher)
+		}
 	}
 
+	// Wake requests long-polling for the next round of a feed
+	bus.SubscribeFunc(256, func(e events.Event) {
+		if result, ok := e.Payload.(*common.AggregateResult); ok {
+			server.next.notify(result)
+		}
+	}, events.Aggregate).Named("next")
+
+	// Log alerts independently of the code paths raising them
+	bus.SubscribeFunc(100, func(e events.Event) {
+		if alert, ok := e.Payload.(*events.AlertPayload); ok {
+			log.Printf("ALERT [%s] %s %s: %s", alert.Severity, alert.Kind, e.Symbol, alert.Message)
+			server.alerts.add(e, alert)
+		}
+	}, events.Alert).Named("alerts")
+
+	creds.OnChange(server.onCredentialsChange)
+
+	for _, feed := range server.meter.PrivateFeeds() {
+		if !server.knownFeed(feed) {
+			return nil, fmt.Errorf("invalid metering config: unknown private feed %s", feed)
+		}
+	}
+	// Feeds computed from private feeds are as private as their inputs
+	server.meter.SetInputs(server.feedInputs)
+
 	server.routes()
 	return server, nil
 }
 
 // routes sets up the API routes
 func (s *Server) routes() {
-	s.router.HandleFunc("/api/v1/prices/{symbol}", s.handleGetPrice()).Methods("GET")
+	s.router.HandleFunc("/api/v1/prices", s.metered(s.handleBatchPrices())).Methods("GET")
+	s.router.HandleFunc("/api/v1/prices/{symbol}", withSuccessor("/api/v2/feeds/{symbol}", s.metered(s.handleGetPrice()))).Methods("GET")
+	s.router.HandleFunc("/api/v1/prices/{symbol}/explain", s.metered(s.handleExplain())).Methods("GET")
+	s.router.HandleFunc("/api/v1/prices/{symbol}/next", s.metered(s.handleNextPrice())).Methods("GET")
 	s.router.HandleFunc("/api/v1/health", s.handleHealth()).Methods("GET")
+	s.router.HandleFunc("/healthz/score", s.handleHealthScore()).Methods("GET")
+	s.router.HandleFunc("/api/v1/metrics/transport", s.handleTransportMetrics()).Methods("GET")
+	s.router.HandleFunc("/api/v1/metrics/store", s.handleStoreMetrics()).Methods("GET")
+	s.router.HandleFunc("/api/v1/metrics/rpc", s.handleRPCMetrics()).Methods("GET")
+	s.router.HandleFunc("/api/v1/metrics/events", s.handleEventMetrics()).Methods("GET")
+	s.router.HandleFunc("/api/v1/ondemand", s.handleOnDemand()).Methods("GET")
+	s.router.HandleFunc("/api/v1/summary", withSuccessor("/api/v2/feeds", s.metered(s.handleSummary()))).Methods("GET")
+	s.router.HandleFunc("/api/v1/stream", s.metered(s.handleStream())).Methods("GET")
+	s.router.HandleFunc("/api/v1/usage", s.handleUsage()).Methods("GET")
+	s.router.HandleFunc("/api/v1/alerts", withSuccessor("/api/v2/alerts", s.handleAlerts())).Methods("GET")
+	s.router.HandleFunc("/api/v1/webhooks", s.handleWebhooks()).Methods("GET")
+	s.router.HandleFunc("/api/v1/attributions", s.handleAttributions()).Methods("GET")
+	s.router.HandleFunc("/api/v1/randomness", s.handleRandomness()).Methods("GET")
+	s.router.HandleFunc("/api/v1/attestations", s.handleAttestations()).Methods("GET")
+	s.router.HandleFunc("/api/v1/attestations/{id}", s.handleAttestation()).Methods("GET")
+	s.router.HandleFunc("/api/v1/randomness/prove", s.handleRandomnessProof()).Methods("GET")
+	s.router.HandleFunc("/api/v1/randomness/{round}", s.handleRandomnessRound()).Methods("GET")
+	s.router.HandleFunc("/api/v1/publishes/funding", s.handlePublishFunding()).Methods("GET")
+	s.router.HandleFunc("/api/v1/publishes/holds", s.handlePublishHolds()).Methods("GET")
+	s.router.HandleFunc("/api/v1/canary/report", s.handleCanaryReport()).Methods("GET")
+	s.router.HandleFunc("/api/v1/publisher/status", s.handlePublisherStatus()).Methods("GET")
+	s.router.HandleFunc("/api/v1/publishes/{feedID}", s.metered(s.handlePublishes())).Methods("GET")
+	s.router.HandleFunc("/api/v1/publishes/{feedID}/{roundID}/composition", s.metered(s.handlePublishComposition())).Methods("GET")
+	s.router.HandleFunc("/api/v1/rounds/{feedID}/{roundID}", s.metered(s.handleRound())).Methods("GET")
+	s.router.HandleFunc("/api/v1/analytics/correlation", s.metered(s.handleCorrelation())).Methods("GET")
+	s.router.HandleFunc("/api/v1/analytics/deviation", s.metered(s.handleDeviation())).Methods("GET")
+	s.router.HandleFunc("/api/v1/analytics/weights", s.handleWeightSuggestions()).Methods("GET")
+	s.router.HandleFunc("/api/v1/analytics/manipulation", s.metered(s.handleManipulationReport())).Methods("GET")
+	s.router.HandleFunc("/api/v1/analytics/coldstart", s.handleColdStart()).Methods("GET")
+	s.router.HandleFunc("/api/v1/consistency", s.handleConsistency()).Methods("GET")
+	s.router.HandleFunc("/api/v1/pegs", s.handlePegs()).Methods("GET")
+	s.router.HandleFunc("/api/v1/maintenance", s.handleMaintenance()).Methods("GET")
+	s.router.HandleFunc("/api/v1/sources/audit", s.handleSourceAudit()).Methods("GET")
+	s.router.HandleFunc("/api/v1/sources/schemas", s.handleSourceSchemas()).Methods("GET")
+	s.router.HandleFunc("/api/v1/sources/costs", s.handleSourceCosts()).Methods("GET")
+	s.router.HandleFunc("/api/v1/sources/latency", s.handleSourceLatency()).Methods("GET")
+	s.router.HandleFunc("/api/v1/orderbooks", s.handleOrderBooks()).Methods("GET")
+	s.router.HandleFunc("/api/v1/rates", s.handleRates()).Methods("GET")
+	s.router.HandleFunc("/api/v1/rates/{benchmark}", s.handleGetRate()).Methods("GET")
+
+	// v2 namespace with a common response envelope
+	s.v2Routes()
+
+	// Embedded operator dashboard
+	s.router.Handle("/dashboard", http.RedirectHandler("/dashboard/", http.StatusMovedPermanently))
+	s.router.PathPrefix("/dashboard/").Handler(dashboardHandler())
+
+	// Admin routes
+	s.router.HandleFunc("/api/v1/admin/pools/discover", s.requireAdmin(s.handleDiscoverPools())).Methods("POST")
+	s.router.HandleFunc("/api/v1/admin/backfill", s.requireAdmin(s.idempotent(s.handleBackfill()))).Methods("POST")
+	s.router.HandleFunc("/api/v1/admin/usage", s.requireOperator(s.handleUsageExport())).Methods("GET")
+	s.router.HandleFunc("/api/v1/admin/rewards", s.requireOperator(s.handleRewards())).Methods("GET")
+	// Credentials are per node, so replicas rotate theirs too
+	s.router.HandleFunc("/api/v1/admin/credentials", s.requireOperator(s.handleCredentials())).Methods("GET")
+	s.router.HandleFunc("/api/v1/admin/credentials/reload", s.requireOperator(s.idempotent(s.handleReloadCredentials()))).Methods("POST")
+	s.router.HandleFunc("/api/v1/admin/proposals", s.requireAdmin(s.handleListProposals())).Methods("GET")
+	s.router.HandleFunc("/api/v1/admin/proposals", s.requireAdmin(s.idempotent(s.handleCreateProposal()))).Methods("POST")
+	s.router.HandleFunc("/api/v1/admin/proposals/{id}/approve", s.requireAdmin(s.idempotent(s.handleApproveProposal()))).Methods("POST")
+	s.router.HandleFunc("/api/v1/admin/proposals/{id}/cancel", s.requireAdmin(s.idempotent(s.handleCancelProposal()))).Methods("POST")
+	s.router.HandleFunc("/api/v1/admin/config", s.requireOperator(s.handleEffectiveConfig())).Methods("GET")
+	s.router.HandleFunc("/api/v1/admin/standby", s.requireOperator(s.handleStandbyStatus())).Methods("GET")
+	s.router.HandleFunc("/api/v1/admin/standby/stream", s.requireOperator(s.handleStandbyStream())).Methods("GET")
+	s.router.HandleFunc("/api/v1/admin/standby/promote", s.requireAdmin(s.idempotent(s.handlePromoteStandby()))).Methods("POST")
+	s.router.HandleFunc("/api/v1/admin/groups", s.requireOperator(s.handleGroups())).Methods("GET")
+	s.router.HandleFunc("/api/v1/admin/groups/{group}/pause", s.requireAdmin(s.idempotent(s.handlePauseGroup(true)))).Methods("POST")
+	s.router.HandleFunc("/api/v1/admin/groups/{group}/resume", s.requireAdmin(s.idempotent(s.handlePauseGroup(false)))).Methods("POST")
+	s.router.HandleFunc("/api/v1/admin/groups/{group}/heartbeat", s.requireAdmin(s.idempotent(s.handleGroupHeartbeat()))).Methods("POST")
+	s.router.HandleFunc("/api/v1/admin/groups/{group}/republish", s.requireAdmin(s.idempotent(s.handleRepublishGroup()))).Methods("POST")
+	s.router.HandleFunc("/api/v1/admin/attestations/{id}/dispute", s.requireAdmin(s.idempotent(s.handleDisputeAttestation()))).Methods("POST")
+	s.router.HandleFunc("/api/v1/admin/attestations/{id}/settle", s.requireAdmin(s.idempotent(s.handleSettleAttestation()))).Methods("POST")
+	s.router.HandleFunc("/api/v1/admin/publishes/{feedID}/override", s.requireAdmin(s.idempotent(s.handleOverridePublishHold()))).Methods("POST")
+	s.router.HandleFunc("/api/v1/admin/freezes", s.requireOperator(s.handleFreezes())).Methods("GET")
+	s.router.HandleFunc("/api/v1/admin/feeds/{feedID}/acknowledge", s.requireAdmin(s.idempotent(s.handleAcknowledgeFreeze()))).Methods("POST")
+	s.router.HandleFunc("/api/v1/admin/drills", s.requireOperator(s.handleListDrills())).Methods("GET")
+	s.router.HandleFunc("/api/v1/admin/drills", s.requireAdmin(s.idempotent(s.handleStartDrill()))).Methods("POST")
+	s.router.HandleFunc("/api/v1/admin/drills/{id}/stop", s.requireAdmin(s.idempotent(s.handleStopDrill()))).Methods("POST")
 }
 
 // handleGetPrice handles price requests
@@ -60,34 +640,400 @@ func (s *Server) handleGetPrice() http.HandlerFunc {
 		vars := mux.Vars(r)
 		symbol := vars["symbol"]
 
-		// Fetch price using the original symbol format
-		price, err := s.aggregator.FetchPrice(symbol)
+		requested, err := windowsParam(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fields, err := fieldsParam(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		size, side, err := executionParams(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		price, fetched, err := s.latestFeed(symbol)
 		if err != nil {
+			if unavailable(err) {
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+			if insufficient, ok := err.(*crypto.InsufficientSourcesError); ok {
+				writeInsufficientSources(w, insufficient)
+				return
+			}
 			log.Printf("Error fetching price for %s: %v", symbol, err)
 			http.Error(w, fmt.Sprintf("failed to fetch price: %v", err), http.StatusInternalServerError)
 			return
 		}
+		windows, err := s.priceWindows(symbol, price, requested)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// Replicated, computed and carried values are served in full
+		if !fetched {
+			value, err := selectFields(windowedResult{AggregateResult: price, Windows: windows, Attributions: s.attributions(symbol)}, fields)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(value)
+			return
+		}
 
 		// Return response
 		response := map[string]interface{}{
-			"symbol":    symbol,
-			"price":     price.Price,
-			"volume":    price.Volume,
-			"timestamp": price.Timestamp,
+			"symbol":        symbol,
+			"price":         price.Price,
+			"volume":        price.Volume,
+			"timestamp":     price.Timestamp,
+			"roundId":       price.RoundID,
+			"configVersion": price.ConfigVersion,
+		}
+		if windows != nil {
+			response["windows"] = windows
+		}
+		if attributions := s.attributions(symbol); len(attributions) > 0 {
+			response["attributions"] = attributions
 		}
 
+		// Add size-adjusted execution estimate when a trade size is requested
+		if estimate := s.executionEstimate(r.Context(), symbol, size, side); estimate != nil {
+			response["execution"] = estimate
+		}
+
+		value, err := selectFields(response, fields)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(response)
+		json.NewEncoder(w).Encode(value)
+	}
+}
+
+// executionParams parses ?size= and ?side=; size is 0 when no execution
+// estimate was requested
+func executionParams(r *http.Request) (size float64, side string, err error) {
+	sizeParam := r.URL.Query().Get("size")
+	if sizeParam == "" {
+		return 0, "", nil
+	}
+	size, err = strconv.ParseFloat(sizeParam, 64)
+	if err != nil || size <= 0 {
+		return 0, "", fmt.Errorf("size must be a positive number")
+	}
+
+	side = r.URL.Query().Get("side")
+	if side == "" {
+		side = crypto.SideBuy
+	}
+	if side != crypto.SideBuy && side != crypto.SideSell {
+		return 0, "", fmt.Errorf("side must be buy or sell")
+	}
+	return size, side, nil
+}
+
+// executionEstimate estimates filling size on side of a pair; nil when no
+// estimate was requested or none could be made
+func (s *Server) executionEstimate(ctx context.Context, symbol string, size float64, side string) *common.ExecutionEstimate {
+	if size == 0 {
+		return nil
+	}
+	estimate, err := s.aggregator.FetchExecutionEstimate(ctx, symbol, size, side)
+	if err != nil {
+		log.Printf("Error estimating execution for %s size %.2f: %v", symbol, size, err)
+		return nil
+	}
+	return estimate
+}
+
+// noValueError reports a feed that has no value to serve yet
+type noValueError struct {
+	Symbol string
+}
+
+func (e *noValueError) Error() string {
+	return fmt.Sprintf("no value yet for feed %s", e.Symbol)
+}
+
+// unavailable reports whether err is a feed having no value to serve rather
+// than a failure to fetch one
+func unavailable(err error) bool {
+	switch err.(type) {
+	case *noValueError, *scheduler.CapError:
+		return true
+	}
+	return false
+}
+
+// writeInsufficientSources answers 503 for a round that obtained too few
+// prices, with why each missing source failed
+func writeInsufficientSources(w http.ResponseWriter, err *crypto.InsufficientSourcesError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":   err.Error(),
+		"symbol":  err.Symbol,
+		"got":     err.Got,
+		"need":    err.Need,
+		"sources": err.Failures,
+	})
+}
+
+// latestFeed returns the current value of a feed: replicated from the
+// primary, computed in-process, carried over a market close or, otherwise,
+// fetched from sources, in which case fetched is true
+func (s *Server) latestFeed(symbol string) (result *common.AggregateResult, fetched bool, err error) {
+	// Replicas serve every feed from the rounds replicated from the primary,
+	// and standbys from the rounds mirrored from the leader
+	if s.replica != nil || s.standingBy() {
+		if result = s.replicated(symbol); result == nil {
+			return nil, false, &noValueError{Symbol: symbol}
+		}
+		return result, false, nil
+	}
+
+	// Derived and statistic feeds are computed in-process, not fetched
+	if result, computed := s.computedFeed(symbol); computed {
+		if result == nil {
+			return nil, false, &noValueError{Symbol: symbol}
+		}
+		return result, false, nil
+	}
+
+	// Rarely queried pairs are aggregated on query and cached for a while
+	if s.onDemand.Serves(symbol) {
+		result, err = s.onDemand.Get(symbol, time.Now())
+		return result, true, err
 	}
+
+	// Scheduled pairs serve the scheduler's latest round, so reads never
+	// fetch from sources; outside trading sessions that is the last close
+	if _, err := crypto.GetPairConfig(symbol); err != nil {
+		return nil, true, err
+	}
+	result, ok := s.scheduler.Latest(symbols.PairSymbol(symbol))
+	if !ok {
+		return nil, false, &noValueError{Symbol: symbol}
+	}
+	return result, !result.MarketClosed, nil
+}
+
+// computedFeed returns the latest value of a feed computed inside the oracle
+// rather than fetched from sources; computed is false for fetched feeds
+func (s *Server) computedFeed(symbol string) (result *common.AggregateResult, computed bool) {
+	switch {
+	case s.replica != nil && (s.derived.IsDerived(symbol) || s.statistics.IsStatistic(symbol) || s.pegs.IsPeg(symbol) || s.sides.IsSide(symbol)):
+		return s.replicated(symbol), true
+	case s.derived.IsDerived(symbol):
+		result, _ = s.derived.Latest(symbol)
+		return result, true
+	case s.sides.IsSide(symbol):
+		result, _ = s.sides.Latest(symbol)
+		return result, true
+	case s.statistics.IsStatistic(symbol):
+		result, _ = s.statistics.Latest(symbol)
+		return result, true
+	case s.pegs.IsPeg(symbol):
+		result, _ = s.pegs.Latest(symbol)
+		return result, true
+	}
+	return nil, false
 }
 
 // handleHealth handles health check requests
 func (s *Server) handleHealth() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		response := map[string]interface{}{
-			"status":    "ok",
 			"timestamp": time.Now(),
 		}
+		if s.replica != nil {
+			replication := s.replica.Status()
+			response["mode"] = "replica"
+			response["replication"] = replication
+			response["status"] = "ok"
+			if !replication.Connected {
+				response["status"] = "disconnected"
+			}
+		} else if s.standingBy() {
+			mirroring := s.standby.Status()
+			response["mode"] = "standby"
+			response["standby"] = mirroring
+			response["status"] = "ok"
+			if !mirroring.Connected {
+				response["status"] = "disconnected"
+			}
+		} else {
+			priming := s.scheduler.Priming()
+			response["mode"] = "primary"
+			if s.canary != nil {
+				response["mode"] = "canary"
+			}
+			response["priming"] = priming
+			response["status"] = "ok"
+			if !priming.Done {
+				response["status"] = "warming_up"
+			}
+		}
+		if fetch.ChaosEnabled() {
+			response["chaos"] = true
+		}
+		if drills := s.drills.Active(time.Now()); len(drills) > 0 {
+			response["drills"] = len(drills)
+		}
+		if snapshot, err := crypto.CurrentConfig(); err == nil {
+			response["configVersion"] = snapshot.Version
+			response["configLoadedAt"] = snapshot.LoadedAt
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// handleOnDemand reports the cache and round cap of every on-demand feed
+func (s *Server) handleOnDemand() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"feeds": s.onDemand.States(time.Now()),
+		})
+	}
+}
+
+// handleMaintenance lists current and announced exchange maintenance windows
+func (s *Server) handleMaintenance() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"windows": s.maintenance.Windows(),
+		})
+	}
+}
+
+// handleOrderBooks reports the state of the streamed exchange order books
+func (s *Server) handleOrderBooks() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"books": s.books.Status(),
+		})
+	}
+}
+
+// handleSourceAudit lists the spot-check records of audited sources,
+// flagged ones first
+func (s *Server) handleSourceAudit() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"sources": s.auditor.Audits(),
+		})
+	}
+}
+
+// handleSourceLatency reports the p95 latency of exchanges with a latency
+// SLO and which of them are deprioritized
+func (s *Server) handleSourceLatency() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"sources": s.aggregator.LatencyStats(),
+		})
+	}
+}
+
+// handleSourceSchemas lists the recorded response shape of every tracked
+// source endpoint
+func (s *Server) handleSourceSchemas() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"schemas": fetch.Schemas(),
+		})
+	}
+}
+
+// handleSourceCosts returns the estimated daily and monthly usage of paid
+// sources, ?days= (default 30) and ?months= (default 12) back
+func (s *Server) handleSourceCosts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		days, months := 30, 12
+		for name, target := range map[string]*int{"days": &days, "months": &months} {
+			if value := r.URL.Query().Get(name); value != "" {
+				n, err := strconv.Atoi(value)
+				if err != nil || n <= 0 || n > 400 {
+					http.Error(w, name+" must be an integer between 1 and 400", http.StatusBadRequest)
+					return
+				}
+				*target = n
+			}
+		}
+
+		now := time.Now().UTC()
+		firstMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1-months, 0)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"timestamp": now,
+			"currency":  s.costs.Currency(),
+			"daily":     s.costs.Daily(now.AddDate(0, 0, 1-days), now),
+			"monthly":   s.costs.Monthly(firstMonth, now),
+		})
+	}
+}
+
+// handleTransportMetrics reports connection reuse of the shared upstream transport
+func (s *Server) handleTransportMetrics() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(fetch.Stats())
+	}
+}
+
+// handleRPCMetrics reports the health of the chains' RPC endpoints
+func (s *Server) handleRPCMetrics() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"endpoints": evm.EndpointHealth(),
+		})
+	}
+}
+
+// handleEventMetrics reports how far each event bus subscriber is behind
+// and how many events it dropped
+func (s *Server) handleEventMetrics() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"subscribers": s.bus.Stats(),
+		})
+	}
+}
+
+// handleStoreMetrics reports the size of the historical store and the
+// outcome of the last retention compaction
+func (s *Server) handleStoreMetrics() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats, err := s.store.Stats()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to read store stats: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		response := map[string]interface{}{
+			"store":          stats,
+			"retention":      s.retention.Retention(),
+			"lastCompaction": s.retention.Last(),
+			"rings":          s.rings.Stats(),
+		}
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(response)
@@ -95,11 +1041,39 @@ func (s *Server) handleHealth() http.HandlerFunc {
 }
 
 func main() {
-	server, err := NewServer()
+	// Secrets stay out of the logs, and keys may come from a reloadable
+	// credentials file
+	if _, err := credentials.Setup(); err != nil {
+		log.Fatalf("Failed to load credentials: %v", err)
+	}
+
+	env := flag.String("env", os.Getenv("ORACLE_ENV"), "environment profile of the publishing target, e.g. staging or prod")
+	flag.Parse()
+
+	server, err := NewServer(*env)
 	if err != nil {
 		log.Fatalf("Failed to create server: %v", err)
 	}
 
+	// Background work and open streams stop on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go server.retention.Run(ctx, server.retention.Retention().Interval())
+	go server.costs.Run(ctx, server.costs.Interval())
+	go server.meter.Run(ctx, server.meter.Interval())
+	go credentials.Default().Run(ctx, credentials.Default().Interval())
+	go server.bus.WatchDrops(ctx, time.Minute)
+	switch {
+	case server.replica != nil:
+		go server.replica.Run(ctx)
+	case server.standby != nil:
+		// Background work starts once the standby is promoted
+		go server.standby.Run(ctx, server.takeOver)
+	default:
+		server.startJobs(ctx)
+	}
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
@@ -116,8 +1090,89 @@ func main() {
 	// Wrap router with CORS middleware
 	handler := c.Handler(server.router)
 
-	log.Printf("Server starting on port %s", port)
-	if err := http.ListenAndServe(":"+port, handler); err != nil {
-		log.Fatalf("Server failed: %v", err)
+	httpServer := &http.Server{
+		Addr:        ":" + port,
+		Handler:     handler,
+		BaseContext: func(net.Listener) context.Context { return ctx },
 	}
-} 
\ No newline at end of file
+	go func() {
+		log.Printf("Server starting on port %s", port)
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
+
+	// Profiles and runtime statistics are served on a loopback listener of
+	// their own, to operators only
+	debugServer, err := server.debugServer()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if debugServer != nil {
+		go func() {
+			log.Printf("Debug listener starting on %s", debugServer.Addr)
+			if err := debugServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Debug listener failed: %v", err)
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	stop()
+	log.Printf("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Shutdown: %v", err)
+	}
+	// Requests served while shutting down are billed too
+	if err := server.meter.Flush(); err != nil {
+		log.Printf("Failed to flush usage: %v", err)
+	}
+	if debugServer != nil {
+		// CPU profiles and traces in progress are cut short
+		debugServer.Close()
+	}
+	server.shutdown(shutdownCtx)
+}
+
+// startJobs starts the scheduler, publishing and the other background work
+// of an instance that fetches its own rounds
+func (s *Server) startJobs(ctx context.Context) {
+	if s.publishing != nil {
+		s.publishing.Start(ctx, 5*time.Second)
+	}
+	if s.funding != nil {
+		go s.funding.Run(ctx, s.funding.Interval())
+	}
+	go s.proposals.Run(ctx, 10*time.Second)
+	if s.canary != nil {
+		go s.canary.Run(ctx, time.Minute)
+	}
+	if err := s.scheduler.Start(ctx); err != nil {
+		log.Fatalf("Failed to start scheduler: %v", err)
+	}
+	go s.statistics.Run(ctx, time.Minute)
+	go s.weights.Run(ctx, time.Hour)
+	go s.forensics.Run(ctx, time.Hour)
+	go s.coldStart.Run(ctx, time.Hour)
+	go s.rates.Run(ctx, s.rates.Interval())
+	go s.maintenance.Run(ctx, 5*time.Minute)
+	go s.drills.Run(ctx, 10*time.Second)
+	go s.books.Run(ctx, time.Minute)
+	go s.auditor.Run(ctx)
+	go s.triangles.Run(ctx, s.triangles.Interval())
+	go s.pegs.Run(ctx, s.pegs.Interval())
+	if s.webhooks != nil {
+		go s.webhooks.Run(ctx, s.webhooks.Interval())
+	}
+	if s.rewards != nil {
+		go s.rewards.Run(ctx, time.Minute)
+	}
+	if s.randomness != nil {
+		go s.randomness.Run(ctx, s.randomness.Interval())
+	}
+	if s.attestor != nil {
+		go s.attestor.Run(ctx, s.attestor.Interval())
+	}
+}
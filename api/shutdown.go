@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+)
+
+// shutdownTimeout bounds the orderly shutdown after SIGINT or SIGTERM
+const shutdownTimeout = 30 * time.Second
+
+// shutdown drains the node once its run context is cancelled: rounds in
+// flight complete or are recorded as aborted, events queued for the store,
+// derived feeds and the publisher are delivered, and the publisher finishes
+// its current transaction. Queued webhook deliveries are sent, or logged
+// if the deadline passes. Anything left unsent or unconfirmed stays in the
+// publish journal and is resumed on restart.
+func (s *Server) shutdown(ctx context.Context) {
+	if aborted := s.scheduler.Drain(ctx); len(aborted) > 0 {
+		log.Printf("Shutdown aborted rounds of %s", strings.Join(aborted, ", "))
+	}
+	if err := s.bus.Close(ctx); err != nil {
+		log.Printf("Shutdown: %v", err)
+	}
+	if s.webhooks != nil {
+		if err := s.webhooks.Close(ctx); err != nil {
+			log.Printf("Shutdown: %v", err)
+		}
+	}
+	if s.publishing != nil {
+		if err := s.publishing.Stop(ctx); err != nil {
+			log.Printf("Shutdown: %v", err)
+		}
+	}
+	log.Printf("Shutdown complete")
+}
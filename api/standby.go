@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"yetaXYZ/oracle/common"
+	"yetaXYZ/oracle/credentials"
+	"yetaXYZ/oracle/events"
+	"yetaXYZ/oracle/sources/crypto"
+	"yetaXYZ/oracle/standby"
+)
+
+// standbyMirror returns a mirror of ORACLE_PRIMARY_URL, authenticated with
+// the operator token ORACLE_PRIMARY_ADMIN_TOKEN, when ORACLE_MODE is
+// "standby", and nil otherwise. ORACLE_STANDBY_FAILOVER, a duration, lets
+// the standby promote itself once the leader is unreachable for that long.
+func standbyMirror(bus *events.Bus) (*standby.Mirror, error) {
+	if os.Getenv("ORACLE_MODE") != "standby" {
+		return nil, nil
+	}
+	leader := os.Getenv("ORACLE_PRIMARY_URL")
+	if leader == "" {
+		return nil, fmt.Errorf("ORACLE_PRIMARY_URL is required in standby mode")
+	}
+	mirror := standby.NewMirror(leader, bus)
+	mirror.SetToken(credentials.Get("ORACLE_PRIMARY_ADMIN_TOKEN"))
+	if value := os.Getenv("ORACLE_STANDBY_FAILOVER"); value != "" {
+		after, err := time.ParseDuration(value)
+		if err != nil || after <= 0 {
+			return nil, fmt.Errorf("invalid ORACLE_STANDBY_FAILOVER: %q", value)
+		}
+		mirror.SetFailover(after)
+	}
+	return mirror, nil
+}
+
+// standingBy reports whether the instance is a standby not yet promoted
+func (s *Server) standingBy() bool {
+	return s.standby != nil && !s.standby.Status().Promoted
+}
+
+// standbyState returns the state standbys mirror: the latest round of each
+// scheduled pair, the round IDs issued and the publish breaker's state
+func (s *Server) standbyState() standby.State {
+	state := standby.State{
+		Rounds:   make(map[string]*common.AggregateResult),
+		RoundIDs: s.aggregator.Rounds(),
+	}
+	for symbol, feed := range s.scheduler.States() {
+		if feed.Result != nil {
+			state.Rounds[symbol] = feed.Result
+		}
+	}
+	if s.publishing != nil {
+		breaker := s.publishing.BreakerState()
+		state.Breaker = &breaker
+	}
+	freezes := s.aggregator.FreezeState()
+	state.Freezes = &freezes
+	return state
+}
+
+// handleStandbyStream streams the leader's state to a standby. Only pairs
+// are mirrored; a standby computes derived and statistic feeds itself.
+func (s *Server) handleStandbyStream() http.HandlerFunc {
+	leader := standby.NewLeader(s.bus, s.standbyState, func(symbol string) bool {
+		_, err := crypto.GetPairConfig(symbol)
+		return err == nil
+	})
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.sideEffects() || s.standingBy() {
+			http.Error(w, "only a primary can lead standbys", http.StatusConflict)
+			return
+		}
+		leader.ServeHTTP(w, r)
+	}
+}
+
+// handleStandbyStatus reports a standby's link to its leader
+func (s *Server) handleStandbyStatus() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.standby == nil {
+			http.Error(w, "not running in standby mode", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.standby.Status())
+	}
+}
+
+// handlePromoteStandby makes a standby the leader, taking over the state
+// it mirrored
+func (s *Server) handlePromoteStandby() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.standby == nil {
+			http.Error(w, "not running in standby mode", http.StatusNotFound)
+			return
+		}
+		if !s.standingBy() {
+			http.Error(w, "already promoted", http.StatusConflict)
+			return
+		}
+		if dryRun(r) {
+			writeDryRun(w, map[string]interface{}{"standby": s.standby.Status()})
+			return
+		}
+		status, err := s.standby.Promote(r.Context(), operatorFrom(r))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status)
+	}
+}
+
+// takeOver makes a promoted standby the leader: round numbering, feed
+// values, freezes and publish breaker holds continue from the mirrored state, so
+// feeds are served and published without priming, and background work
+// starts
+func (s *Server) takeOver(ctx context.Context, state standby.State) {
+	s.aggregator.ResumeRounds(state.RoundIDs)
+	rounds := make([]*common.AggregateResult, 0, len(state.Rounds))
+	for _, result := range state.Rounds {
+		rounds = append(rounds, result)
+	}
+	s.scheduler.Warm(rounds)
+	if s.publishing != nil && state.Breaker != nil {
+		s.publishing.RestoreBreaker(*state.Breaker)
+	}
+	// Pairs frozen on the leader stay frozen until acknowledged here
+	if state.Freezes != nil {
+		s.aggregator.RestoreFreezes(*state.Freezes)
+	}
+	s.startJobs(ctx)
+
+	status := s.standby.Status()
+	log.Printf("Took over as leader with %d warm feeds", len(rounds))
+	s.bus.Publish(events.Event{
+		Type:      events.Alert,
+		Timestamp: time.Now(),
+		Payload: &events.AlertPayload{
+			Severity: events.SeverityCritical,
+			Kind:     "standby_promoted",
+			Message:  fmt.Sprintf("standby promoted to leader by %s, taking over %d feeds from %s", status.PromotedBy, len(rounds), status.Leader),
+		},
+	})
+}
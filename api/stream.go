@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"yetaXYZ/oracle/events"
+	"yetaXYZ/oracle/metering"
+)
+
+// recentAlertLimit bounds the alerts kept for clients that connect late
+const recentAlertLimit = 50
+
+// alertRecord is an alert as served to clients
+type alertRecord struct {
+	Symbol    string    `json:"symbol,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	*events.AlertPayload
+}
+
+// alertLog keeps the most recent alerts, newest last
+type alertLog struct {
+	mu     sync.Mutex
+	alerts []alertRecord
+}
+
+// add records an alert event, evicting the oldest beyond the limit
+func (l *alertLog) add(e events.Event, alert *events.AlertPayload) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.alerts = append(l.alerts, alertRecord{Symbol: e.Symbol, Timestamp: e.Timestamp, AlertPayload: alert})
+	if len(l.alerts) > recentAlertLimit {
+		l.alerts = l.alerts[len(l.alerts)-recentAlertLimit:]
+	}
+}
+
+// recent returns a copy of the recorded alerts
+func (l *alertLog) recent() []alertRecord {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]alertRecord{}, l.alerts...)
+}
+
+// visibleAlerts returns the recorded alerts the caller may see: those of
+// private feeds only reach the consumers allowed to read them, as in the
+// stream
+func (s *Server) visibleAlerts(r *http.Request) []alertRecord {
+	consumer := s.caller(r)
+	alerts := s.alerts.recent()
+	out := make([]alertRecord, 0, len(alerts))
+	for _, alert := range alerts {
+		if alert.Symbol == "" || s.meter.Allowed(consumer, alert.Symbol) {
+			out = append(out, alert)
+		}
+	}
+	return out
+}
+
+// handleAlerts returns the most recent alerts, newest last
+func (s *Server) handleAlerts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"alerts": s.visibleAlerts(r),
+		})
+	}
+}
+
+// handleStream streams completed rounds and alerts as server-sent events
+// until the client disconnects. Slow clients miss events rather than
+// holding up the bus.
+func (s *Server) handleStream() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		sub := s.bus.Subscribe(100, events.Aggregate, events.Alert).Named("stream")
+		defer sub.Close()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		flusher.Flush()
+
+		keepAlive := time.NewTicker(15 * time.Second)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+			case e, ok := <-sub.C:
+				if !ok {
+					return // bus closed at shutdown
+				}
+				// Private feeds only reach the consumers allowed to read them
+				consumer := consumerFrom(r)
+				if e.Symbol != "" && !s.meter.Allowed(consumer, e.Symbol) {
+					continue
+				}
+				// Metered consumers only receive their subscribed feeds
+				if consumer != nil && s.meter.Enabled() {
+					feed := e.Symbol
+					if feed == "" {
+						feed = metering.AllFeeds
+					}
+					if err := s.meter.Message(consumer, feed, time.Now()); err != nil {
+						if _, exceeded := err.(*metering.QuotaError); exceeded {
+							fmt.Fprintf(w, "event: error\ndata: %q\n\n", err.Error())
+							flusher.Flush()
+							return
+						}
+						continue
+					}
+				}
+				payload := e.Payload
+				if alert, ok := e.Payload.(*events.AlertPayload); ok {
+					payload = alertRecord{Symbol: e.Symbol, Timestamp: e.Timestamp, AlertPayload: alert}
+				}
+				data, err := json.Marshal(payload)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+			}
+			flusher.Flush()
+		}
+	}
+}
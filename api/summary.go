@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"yetaXYZ/oracle/common"
+	"yetaXYZ/oracle/sources/crypto"
+)
+
+// Feed quality flags reported by the summary endpoint
+const (
+	qualityOK           = "ok"
+	qualityDegraded     = "degraded"
+	qualityStale        = "stale"
+	qualityMarketClosed = "market_closed"
+	qualityPaused       = "paused"
+	qualityFrozen       = "frozen"
+	qualityUnavailable  = "unavailable"
+)
+
+// staleIntervals is how many missed update intervals make a feed stale
+const staleIntervals = 3
+
+// feedSummary is the compact per-feed view served by the summary endpoint
+type feedSummary struct {
+	Symbol    string   `json:"symbol"`
+	Kind      string   `json:"kind"` // pair, derived, statistic, peg or side
+	Price     *float64 `json:"price"`
+	Change24h *float64 `json:"change24h"` // fraction, null without 24h of history
+	Quality   string   `json:"quality"`
+	Sources   int      `json:"sources"`
+	AgeSecs   *float64 `json:"ageSeconds"`
+	Groups    []string `json:"groups,omitempty"`
+}
+
+// handleSummary returns every feed's latest state in one payload for status
+// dashboards. It only reads the scheduler cache, computed feeds and the
+// round store and never triggers upstream fetches.
+func (s *Server) handleSummary() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		feeds := inGroup(r, s.subscribed(r, s.summaries(now)))
+		response := map[string]interface{}{
+			"timestamp": now,
+			"feeds":     feeds,
+		}
+		if attributions := s.summaryAttributions(feeds); len(attributions) > 0 {
+			response["attributions"] = attributions
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// summaries returns the summary of every pair, then every derived and
+// statistic feed, each group ordered by symbol
+func (s *Server) summaries(now time.Time) []feedSummary {
+	states := s.scheduler.States()
+	feeds := make([]feedSummary, 0, len(states))
+	snapshot, _ := crypto.CurrentConfig()
+
+	for _, feed := range s.scheduler.Feeds() {
+		state := states[feed.Symbol]
+		result, _ := s.scheduler.Latest(feed.Symbol)
+		if s.replica != nil {
+			result = s.replicated(feed.Symbol)
+		}
+		summary := s.summarize(feed.Symbol, "pair", result, now)
+		if snapshot != nil {
+			if pair, ok := snapshot.Pairs[feed.Symbol]; ok {
+				summary.Groups = pair.Groups
+			}
+		}
+
+		switch {
+		case result == nil:
+		case result.Frozen:
+			summary.Quality = qualityFrozen
+		case state.Paused:
+			summary.Quality = qualityPaused
+		case state.MarketClosed:
+			summary.Quality = qualityMarketClosed
+		case now.Sub(result.Timestamp) > staleIntervals*feed.Interval:
+			summary.Quality = qualityStale
+		case state.Failures > 0 || result.FallbackReason != "" || s.drills.FeedDegraded(feed.Symbol, now):
+			summary.Quality = qualityDegraded
+		}
+		feeds = append(feeds, summary)
+	}
+
+	computed := make([]string, 0, len(crypto.DerivedConfig)+len(crypto.StatisticsConfig))
+	for symbol := range crypto.DerivedConfig {
+		computed = append(computed, symbol)
+	}
+	for symbol := range crypto.StatisticsConfig {
+		computed = append(computed, symbol)
+	}
+	computed = append(computed, s.pegs.Symbols()...)
+	computed = append(computed, s.sides.Symbols()...)
+	sort.Strings(computed)
+	for _, symbol := range computed {
+		kind := "derived"
+		switch {
+		case s.statistics.IsStatistic(symbol):
+			kind = "statistic"
+		case s.pegs.IsPeg(symbol):
+			kind = "peg"
+		case s.sides.IsSide(symbol):
+			kind = "side"
+		}
+		result, _ := s.computedFeed(symbol)
+		feeds = append(feeds, s.summarize(symbol, kind, result, now))
+	}
+	return feeds
+}
+
+// summarize builds the summary of a feed from its latest result
+func (s *Server) summarize(symbol, kind string, result *common.AggregateResult, now time.Time) feedSummary {
+	summary := feedSummary{Symbol: symbol, Kind: kind, Quality: qualityUnavailable}
+	if result == nil {
+		return summary
+	}
+
+	price := result.Price
+	age := now.Sub(result.Timestamp).Seconds()
+	summary.Price = &price
+	summary.AgeSecs = &age
+	summary.Sources = len(result.Sources)
+	summary.Quality = qualityOK
+	summary.Change24h = s.change24h(symbol, price, now)
+	return summary
+}
+
+// change24h returns the relative change against the last stored round at
+// least 24 hours old, or nil when there is no such round
+func (s *Server) change24h(symbol string, price float64, now time.Time) *float64 {
+	dayAgo := now.Add(-24 * time.Hour)
+	rounds, err := s.store.Rounds(symbol, dayAgo.Add(-24*time.Hour), dayAgo)
+	if err != nil || len(rounds) == 0 {
+		return nil
+	}
+	previous := rounds[len(rounds)-1].Price
+	if previous == 0 {
+		return nil
+	}
+	change := (price - previous) / previous
+	return &change
+}
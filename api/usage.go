@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"yetaXYZ/oracle/metering"
+	"yetaXYZ/oracle/rewards"
+)
+
+// consumerKey is the request context key holding the metered consumer
+type consumerKey struct{}
+
+// apiKey returns the API key of a request, from the X-API-Key header or,
+// for EventSource clients that cannot set headers, the apiKey parameter
+func apiKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	return r.URL.Query().Get("apiKey")
+}
+
+// metered requires an API key on a feed endpoint when metering is enabled,
+// enforces the consumer's subscription and request quota and counts the
+// request against the {symbol} or {feedID} route variable, or all feeds
+// without one. Private feeds require a permitted consumer's key even without metering.
+func (s *Server) metered(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		feed := vars["symbol"]
+		if feed == "" {
+			feed = vars["feedID"]
+		}
+		if feed == "" {
+			feed = metering.AllFeeds
+		}
+
+		consumer := s.meter.Authenticate(apiKey(r))
+		if !s.meter.Enabled() {
+			if err := s.meter.Authorize(consumer, feed); err != nil {
+				if consumer == nil {
+					meteringError(w, r, http.StatusUnauthorized, codeUnauthorized, err.Error())
+				} else {
+					meteringError(w, r, http.StatusForbidden, codeForbidden, err.Error())
+				}
+				return
+			}
+			// An optional key reveals the caller's private feeds in lists
+			if consumer != nil {
+				r = r.WithContext(context.WithValue(r.Context(), consumerKey{}, consumer))
+			}
+			next(w, r)
+			return
+		}
+
+		if consumer == nil {
+			meteringError(w, r, http.StatusUnauthorized, codeUnauthorized, "missing or unknown API key")
+			return
+		}
+		if err := s.meter.Request(consumer, feed, time.Now()); err != nil {
+			switch err.(type) {
+			case *metering.NotSubscribedError, *metering.PrivateFeedError:
+				meteringError(w, r, http.StatusForbidden, codeForbidden, err.Error())
+			case *metering.QuotaError:
+				meteringError(w, r, http.StatusTooManyRequests, codeQuotaExceeded, err.Error())
+			default:
+				meteringError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+			}
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), consumerKey{}, consumer)))
+	}
+}
+
+// meteringError rejects a metered request, in the v2 envelope on v2 routes
+func meteringError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	if strings.HasPrefix(r.URL.Path, "/api/v2/") {
+		writeError(w, status, code, message)
+		return
+	}
+	http.Error(w, message, status)
+}
+
+// consumerFrom returns the consumer authenticated by metered, nil for
+// anonymous callers of an unmetered oracle
+func consumerFrom(r *http.Request) *metering.Consumer {
+	consumer, _ := r.Context().Value(consumerKey{}).(*metering.Consumer)
+	return consumer
+}
+
+// subscribed drops the feeds outside the requesting consumer's subscription
+// and the private feeds it may not read
+func (s *Server) subscribed(r *http.Request, feeds []feedSummary) []feedSummary {
+	consumer := consumerFrom(r)
+	out := make([]feedSummary, 0, len(feeds))
+	for _, feed := range feeds {
+		if s.readable(consumer, feed.Symbol) {
+			out = append(out, feed)
+		}
+	}
+	return out
+}
+
+// caller returns the consumer of a request: the one metered
+// authenticated, or on an unmetered route the one its optional API key
+// identifies
+func (s *Server) caller(r *http.Request) *metering.Consumer {
+	if consumer := consumerFrom(r); consumer != nil {
+		return consumer
+	}
+	return s.meter.Authenticate(apiKey(r))
+}
+
+// readable reports whether consumer, nil for anonymous callers, may read
+// feed: its subscription when metering is enabled and the private feed ACLs
+func (s *Server) readable(consumer *metering.Consumer, feed string) bool {
+	if !s.meter.Allowed(consumer, feed) {
+		return false
+	}
+	return consumer == nil || !s.meter.Enabled() || consumer.Subscribed(feed)
+}
+
+// readableAll reports whether consumer may read every one of feeds
+func (s *Server) readableAll(consumer *metering.Consumer, feeds []string) bool {
+	for _, feed := range feeds {
+		if !s.readable(consumer, feed) {
+			return false
+		}
+	}
+	return true
+}
+
+// usageRange parses the from and to days (YYYY-MM-DD, default today)
+func usageRange(r *http.Request) (from, to time.Time, err error) {
+	query := r.URL.Query()
+	to = time.Now().UTC()
+	if value := query.Get("to"); value != "" {
+		if to, err = time.Parse("2006-01-02", value); err != nil {
+			return from, to, fmt.Errorf("invalid to: %v", err)
+		}
+	}
+	from = to
+	if value := query.Get("from"); value != "" {
+		if from, err = time.Parse("2006-01-02", value); err != nil {
+			return from, to, fmt.Errorf("invalid from: %v", err)
+		}
+	}
+	if from.After(to) {
+		return from, to, fmt.Errorf("from must not be after to")
+	}
+	return from, to, nil
+}
+
+// handleUsage returns the calling consumer's subscription, quota and usage
+func (s *Server) handleUsage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.meter.Enabled() {
+			http.Error(w, "metering disabled", http.StatusNotFound)
+			return
+		}
+		consumer := s.meter.Authenticate(apiKey(r))
+		if consumer == nil {
+			http.Error(w, "missing or unknown API key", http.StatusUnauthorized)
+			return
+		}
+		from, to, err := usageRange(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"consumer":  consumer.Name,
+			"feeds":     consumer.Feeds,
+			"quota":     consumer.Quota,
+			"remaining": s.meter.Remaining(consumer, time.Now()),
+			"usage":     s.meter.Records(consumer.Name, from, to),
+		})
+	}
+}
+
+// handleUsageExport returns the usage of every consumer, or the one given
+// by the consumer parameter, as JSON or, with format=csv, as CSV for billing
+func (s *Server) handleUsageExport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		from, to, err := usageRange(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		records := s.meter.Records(r.URL.Query().Get("consumer"), from, to)
+
+		if r.URL.Query().Get("format") == "csv" {
+			w.Header().Set("Content-Type", "text/csv")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=usage-%s-%s.csv", from.Format("20060102"), to.Format("20060102")))
+			metering.WriteCSV(w, records)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"from":    from.Format("2006-01-02"),
+			"to":      to.Format("2006-01-02"),
+			"records": records,
+		})
+	}
+}
+
+// handleRewards returns the source reward reports of the epochs starting
+// between the from and to days, as JSON or, with format=csv, as CSV
+func (s *Server) handleRewards() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.rewards == nil {
+			http.Error(w, "rewards accounting disabled", http.StatusNotFound)
+			return
+		}
+		from, to, err := usageRange(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		reports := s.rewards.Reports(from, to.Add(24*time.Hour-time.Nanosecond))
+
+		if r.URL.Query().Get("format") == "csv" {
+			w.Header().Set("Content-Type", "text/csv")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=rewards-%s-%s.csv", from.Format("20060102"), to.Format("20060102")))
+			rewards.WriteCSV(w, reports)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"from":    from.Format("2006-01-02"),
+			"to":      to.Format("2006-01-02"),
+			"reports": reports,
+		})
+	}
+}
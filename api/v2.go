@@ -0,0 +1,342 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"yetaXYZ/oracle/common"
+	"yetaXYZ/oracle/sources/crypto"
+)
+
+// apiVersion is reported in the meta block of every v2 response
+const apiVersion = "2"
+
+// Page sizes of v2 list endpoints
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
+// Error codes of v2 responses
+const (
+	codeInvalidParameter    = "invalid_parameter"
+	codeNotFound            = "not_found"
+	codeUnavailable         = "unavailable"
+	codeUpstreamError       = "upstream_error"
+	codeInternal            = "internal"
+	codeUnauthorized        = "unauthorized"
+	codeForbidden           = "forbidden"
+	codeQuotaExceeded       = "quota_exceeded"
+	codeInsufficientSources = "insufficient_sources"
+)
+
+// envelope wraps every v2 response: data on success, error on failure and
+// meta on both
+type envelope struct {
+	Data  interface{} `json:"data,omitempty"`
+	Meta  meta        `json:"meta"`
+	Error *apiError   `json:"error,omitempty"`
+}
+
+// meta describes a v2 response; NextCursor is set when a list has more pages
+type meta struct {
+	APIVersion string    `json:"apiVersion"`
+	Timestamp  time.Time `json:"timestamp"`
+	Limit      int       `json:"limit,omitempty"`
+	NextCursor string    `json:"nextCursor,omitempty"`
+	// Attributions are owed to the providers of the data in the response
+	Attributions []common.Attribution `json:"attributions,omitempty"`
+}
+
+// apiError is a machine-readable error code with a human-readable message
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Sources lists the failed sources of an insufficient_sources error
+	Sources []common.SourceFailure `json:"sources,omitempty"`
+}
+
+// writeData writes a successful v2 response
+func writeData(w http.ResponseWriter, data interface{}, m meta) {
+	m.APIVersion = apiVersion
+	m.Timestamp = time.Now()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(envelope{Data: data, Meta: m})
+}
+
+// writeError writes a failed v2 response
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeAPIError(w, status, &apiError{Code: code, Message: message})
+}
+
+// writeAPIError writes a failed v2 response carrying err
+func writeAPIError(w http.ResponseWriter, status int, err *apiError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(envelope{
+		Meta:  meta{APIVersion: apiVersion, Timestamp: time.Now()},
+		Error: err,
+	})
+}
+
+// page is a window of a list selected by the limit and cursor parameters.
+// Cursors are opaque to clients and only valid for the same query.
+type page struct {
+	offset int
+	limit  int
+	// until pins a time-ranged list's default upper bound to that of its
+	// first page, so items added while paging do not shift the offsets
+	until time.Time
+}
+
+// pageParams parses the limit and cursor query parameters
+func pageParams(query url.Values) (page, error) {
+	p := page{limit: defaultPageLimit}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxPageLimit {
+			return p, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+		p.limit = limit
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+		position, until, pinned := strings.Cut(string(decoded), ",u:")
+		offset, convErr := strconv.Atoi(strings.TrimPrefix(position, "o:"))
+		if err != nil || convErr != nil || !strings.HasPrefix(position, "o:") || offset < 0 {
+			return p, fmt.Errorf("invalid cursor")
+		}
+		p.offset = offset
+		if pinned {
+			nanos, err := strconv.ParseInt(until, 10, 64)
+			if err != nil {
+				return p, fmt.Errorf("invalid cursor")
+			}
+			p.until = time.Unix(0, nanos).UTC()
+		}
+	}
+	return p, nil
+}
+
+// bounds returns the slice bounds of the page within n items and the meta
+// block with the cursor of the following page, if any
+func (p page) bounds(n int) (start, end int, m meta) {
+	start, end = p.offset, p.offset+p.limit
+	if start > n {
+		start = n
+	}
+	if end > n {
+		end = n
+	}
+	m.Limit = p.limit
+	if end < n {
+		cursor := "o:" + strconv.Itoa(end)
+		if !p.until.IsZero() {
+			cursor += ",u:" + strconv.FormatInt(p.until.UnixNano(), 10)
+		}
+		m.NextCursor = base64.RawURLEncoding.EncodeToString([]byte(cursor))
+	}
+	return start, end, m
+}
+
+// withSuccessor points v1 clients at the v2 endpoint replacing a route with
+// a Link header; {name} placeholders are filled from the route variables
+func withSuccessor(path string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		successor := path
+		for name, value := range mux.Vars(r) {
+			successor = strings.Replace(successor, "{"+name+"}", url.PathEscape(value), 1)
+		}
+		w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+		next(w, r)
+	}
+}
+
+// v2Routes sets up the v2 API routes
+func (s *Server) v2Routes() {
+	v2 := s.router.PathPrefix("/api/v2").Subrouter()
+	v2.HandleFunc("/feeds", s.metered(s.handleV2Feeds())).Methods("GET")
+	v2.HandleFunc("/feeds/{symbol}", s.metered(s.handleV2Feed())).Methods("GET")
+	v2.HandleFunc("/feeds/{symbol}/history", s.metered(s.handleV2History())).Methods("GET")
+	v2.HandleFunc("/alerts", s.handleV2Alerts()).Methods("GET")
+	v2.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, codeNotFound, "no such endpoint")
+	})
+}
+
+// knownFeed reports whether symbol is a configured pair, derived,
+// statistic, peg or bid/ask sub-feed
+func (s *Server) knownFeed(symbol string) bool {
+	if _, err := crypto.GetPairConfig(symbol); err == nil {
+		return true
+	}
+	return s.derived.IsDerived(symbol) || s.statistics.IsStatistic(symbol) || s.pegs.IsPeg(symbol) || s.sides.IsSide(symbol)
+}
+
+// handleV2Feeds lists the summary of every feed
+func (s *Server) handleV2Feeds() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, err := pageParams(r.URL.Query())
+		if err != nil {
+			writeError(w, http.StatusBadRequest, codeInvalidParameter, err.Error())
+			return
+		}
+		feeds := inGroup(r, s.subscribed(r, s.summaries(time.Now())))
+		start, end, m := p.bounds(len(feeds))
+		m.Attributions = s.summaryAttributions(feeds[start:end])
+		writeData(w, feeds[start:end], m)
+	}
+}
+
+// handleV2Feed returns the current round of a feed
+func (s *Server) handleV2Feed() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		symbol := mux.Vars(r)["symbol"]
+		if !s.knownFeed(symbol) {
+			writeError(w, http.StatusNotFound, codeNotFound, fmt.Sprintf("unknown feed %s", symbol))
+			return
+		}
+
+		requested, err := windowsParam(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, codeInvalidParameter, err.Error())
+			return
+		}
+		fields, err := fieldsParam(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, codeInvalidParameter, err.Error())
+			return
+		}
+		size, side, err := executionParams(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, codeInvalidParameter, err.Error())
+			return
+		}
+
+		result, fetched, err := s.latestFeed(symbol)
+		if err != nil {
+			if unavailable(err) {
+				writeError(w, http.StatusServiceUnavailable, codeUnavailable, err.Error())
+				return
+			}
+			if insufficient, ok := err.(*crypto.InsufficientSourcesError); ok {
+				writeAPIError(w, http.StatusServiceUnavailable, &apiError{
+					Code:    codeInsufficientSources,
+					Message: err.Error(),
+					Sources: insufficient.Failures,
+				})
+				return
+			}
+			writeError(w, http.StatusBadGateway, codeUpstreamError, fmt.Sprintf("failed to fetch price: %v", err))
+			return
+		}
+		windows, err := s.priceWindows(symbol, result, requested)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, codeInternal, err.Error())
+			return
+		}
+		// Binary consumers get the canonical protobuf encoding of the round
+		if strings.Contains(r.Header.Get("Accept"), "application/x-protobuf") {
+			w.Header().Set("Content-Type", common.ProtoContentType)
+			w.Write(result.MarshalProto())
+			return
+		}
+		m := meta{Attributions: s.attributions(symbol)}
+		var data interface{} = result
+		var execution *common.ExecutionEstimate
+		if fetched {
+			execution = s.executionEstimate(r.Context(), symbol, size, side)
+		}
+		if windows != nil || execution != nil {
+			data = windowedResult{AggregateResult: result, Windows: windows, Execution: execution}
+		}
+		if data, err = selectFields(data, fields); err != nil {
+			writeError(w, http.StatusInternalServerError, codeInternal, err.Error())
+			return
+		}
+		writeData(w, data, m)
+	}
+}
+
+// handleV2History returns the stored rounds of a feed, newest first, within
+// from and to (RFC 3339, default the last 24 hours)
+func (s *Server) handleV2History() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		symbol := mux.Vars(r)["symbol"]
+		if !s.knownFeed(symbol) {
+			writeError(w, http.StatusNotFound, codeNotFound, fmt.Sprintf("unknown feed %s", symbol))
+			return
+		}
+
+		query := r.URL.Query()
+		p, err := pageParams(query)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, codeInvalidParameter, err.Error())
+			return
+		}
+		// Without to, the first page's now bounds every following page
+		if p.until.IsZero() && query.Get("to") == "" {
+			p.until = time.Now().UTC()
+		}
+		to, err := timeParam(query.Get("to"), p.until)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, codeInvalidParameter, fmt.Sprintf("invalid to: %v", err))
+			return
+		}
+		from, err := timeParam(query.Get("from"), to.Add(-24*time.Hour))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, codeInvalidParameter, fmt.Sprintf("invalid from: %v", err))
+			return
+		}
+		if from.After(to) {
+			writeError(w, http.StatusBadRequest, codeInvalidParameter, "from must not be after to")
+			return
+		}
+
+		rounds, err := s.store.Rounds(symbol, from, to)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, codeInternal, fmt.Sprintf("failed to load rounds: %v", err))
+			return
+		}
+		newest := make([]*common.AggregateResult, len(rounds))
+		for i, round := range rounds {
+			newest[len(rounds)-1-i] = round
+		}
+		start, end, m := p.bounds(len(newest))
+		m.Attributions = s.attributions(symbol)
+		writeData(w, newest[start:end], m)
+	}
+}
+
+// handleV2Alerts returns the most recent alerts, newest first
+func (s *Server) handleV2Alerts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, err := pageParams(r.URL.Query())
+		if err != nil {
+			writeError(w, http.StatusBadRequest, codeInvalidParameter, err.Error())
+			return
+		}
+		alerts := s.visibleAlerts(r)
+		newest := make([]alertRecord, len(alerts))
+		for i, alert := range alerts {
+			newest[len(alerts)-1-i] = alert
+		}
+		start, end, m := p.bounds(len(newest))
+		writeData(w, newest[start:end], m)
+	}
+}
+
+// timeParam parses an RFC 3339 time parameter
+func timeParam(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
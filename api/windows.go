@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"yetaXYZ/oracle/analytics"
+	"yetaXYZ/oracle/common"
+)
+
+// windowedResult is a round served together with its time-window prices,
+// the attributions owed for it and, on request, an execution estimate
+type windowedResult struct {
+	*common.AggregateResult
+	Windows      map[string]*analytics.WindowPrice `json:"windows,omitempty"`
+	Attributions []common.Attribution              `json:"attributions,omitempty"`
+	Execution    *common.ExecutionEstimate         `json:"execution,omitempty"`
+}
+
+// windowsParam parses ?windows=spot,1m,1h; nil when none were requested
+func windowsParam(r *http.Request) ([]analytics.Window, error) {
+	param := r.URL.Query().Get("windows")
+	if param == "" {
+		return nil, nil
+	}
+	return analytics.ParseWindows(param)
+}
+
+// priceWindows computes the requested windows of a feed from its recent
+// rounds; nil when none were requested
+func (s *Server) priceWindows(symbol string, latest *common.AggregateResult, windows []analytics.Window) (map[string]*analytics.WindowPrice, error) {
+	if windows == nil {
+		return nil, nil
+	}
+	return analytics.Windows(s.rings, s.store, symbol, latest, windows, time.Now())
+}
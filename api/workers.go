@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"yetaXYZ/oracle/workers"
+)
+
+// workerQueue returns the queue of ORACLE_WORKER_QUEUE, a NATS URL, when
+// set, making the instance a coordinator whose fetches are run by
+// oracle-worker processes, and nil otherwise. ORACLE_WORKER_SUBJECT
+// overrides the subject jobs are published on.
+func workerQueue() (*workers.NATS, error) {
+	queueURL := os.Getenv("ORACLE_WORKER_QUEUE")
+	if queueURL == "" {
+		return nil, nil
+	}
+	hostname, _ := os.Hostname()
+	queue, err := workers.NewNATS(queueURL, os.Getenv("ORACLE_WORKER_SUBJECT"), "coordinator-"+hostname)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ORACLE_WORKER_QUEUE: %v", err)
+	}
+	return queue, nil
+}
@@ -0,0 +1,61 @@
+// Command oracle-worker runs source fetches for a coordinating oracle
+// instance: it takes fetch jobs from the worker queue, runs the fetchers
+// and replies with the prices. Workers keep no state beyond the base
+// config, so any number of them can share a queue.
+package main
+
+import (
+    "context"
+    "flag"
+    "log"
+    "os"
+    "os/signal"
+    "syscall"
+
+    "yetaXYZ/oracle/credentials"
+    "yetaXYZ/oracle/fetch"
+    "yetaXYZ/oracle/sources/crypto"
+    "yetaXYZ/oracle/workers"
+)
+
+func main() {
+    hostname, _ := os.Hostname()
+    configDir := flag.String("config", "config", "Configuration directory")
+    queueURL := flag.String("queue", os.Getenv("ORACLE_WORKER_QUEUE"), "NATS URL of the worker queue, e.g. nats://localhost:4222")
+    subject := flag.String("subject", os.Getenv("ORACLE_WORKER_SUBJECT"), "Subject jobs are published on (default "+workers.DefaultSubject+")")
+    name := flag.String("name", hostname, "Worker name reported in results")
+    concurrency := flag.Int("concurrency", 32, "Jobs run at once")
+    flag.Parse()
+
+    if *queueURL == "" {
+        log.Fatal("a worker queue is required: set -queue or ORACLE_WORKER_QUEUE")
+    }
+    // Secrets stay out of the logs, and source keys may come from the
+    // same reloadable credentials file the API uses
+    creds, err := credentials.Setup()
+    if err != nil {
+        log.Fatalf("Failed to load credentials: %v", err)
+    }
+    if err := crypto.LoadConfig(*configDir); err != nil {
+        log.Fatalf("Failed to load config: %v", err)
+    }
+    // Fetches carry the configured identity and client certificates
+    fetch.Configure(crypto.BaseConfig, fetch.InstanceID())
+    // Rotated header credentials and client certificates apply to new fetches
+    creds.OnChange(func([]string) {
+        fetch.Configure(crypto.BaseConfig, fetch.InstanceID())
+    })
+    queue, err := workers.NewNATS(*queueURL, *subject, *name)
+    if err != nil {
+        log.Fatal(err)
+    }
+    queue.SetConcurrency(*concurrency)
+
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer stop()
+    go creds.Run(ctx, creds.Interval())
+    aggregator := crypto.NewCryptoAggregator(crypto.BaseConfig)
+    if err := queue.Serve(ctx, aggregator.RunJob); err != nil {
+        log.Fatal(err)
+    }
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+    "bufio"
+    "encoding/json"
+    "flag"
+    "fmt"
+    "os"
+    "sort"
+    "strings"
+    "time"
+
+    "yetaXYZ/oracle/fetch"
+    "yetaXYZ/oracle/sources/crypto"
+    "yetaXYZ/oracle/symbols"
+    "yetaXYZ/oracle/tokenlist"
+)
+
+// urlList is a repeatable URL flag
+type urlList []string
+
+func (l *urlList) String() string { return strings.Join(*l, ",") }
+
+func (l *urlList) Set(value string) error {
+    *l = append(*l, value)
+    return nil
+}
+
+// runAssetAdd resolves an asset's metadata and per-chain addresses from
+// token lists and CoinGecko and, once the operator confirms, writes it to
+// assets.json
+func runAssetAdd(args []string) error {
+    // Accept the symbol before the flags, as in "asset add ARB -yes"
+    symbol := ""
+    if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+        symbol, args = args[0], args[1:]
+    }
+
+    fs := flag.NewFlagSet("asset add", flag.ExitOnError)
+    configDir := fs.String("config", "config", "Configuration directory")
+    var lists urlList
+    fs.Var(&lists, "list", "Token list URL, repeatable (default the Uniswap default list)")
+    coinGecko := fs.String("coingecko", tokenlist.DefaultCoinGeckoURL, "CoinGecko API base URL, empty to skip CoinGecko")
+    coinGeckoID := fs.String("coingecko-id", "", "CoinGecko coin ID when several coins share the symbol")
+    yes := fs.Bool("yes", false, "Write without asking for confirmation")
+    fs.Parse(args)
+
+    if symbol == "" {
+        symbol = fs.Arg(0)
+    }
+    if symbol == "" {
+        return fmt.Errorf("usage: oraclectl asset add SYMBOL [flags]")
+    }
+    symbol = symbols.Canonical(symbol)
+    if len(lists) == 0 {
+        lists = urlList{tokenlist.DefaultListURL}
+    }
+
+    if err := crypto.LoadConfig(*configDir); err != nil {
+        return err
+    }
+    if _, err := crypto.GetAssetConfig(symbol); err == nil {
+        return fmt.Errorf("asset %s is already configured", symbol)
+    }
+    chains := make(map[string]bool, len(crypto.BaseConfig.Chains))
+    for chainID := range crypto.BaseConfig.Chains {
+        chains[chainID] = true
+    }
+
+    client := fetch.NewClient(2 * time.Minute)
+    fetched := make([]*tokenlist.List, 0, len(lists))
+    for _, url := range lists {
+        list, err := tokenlist.FetchList(client, url)
+        if err != nil {
+            return err
+        }
+        fetched = append(fetched, list)
+    }
+    var platforms []tokenlist.Platform
+    var coins []tokenlist.Coin
+    if *coinGecko != "" {
+        var err error
+        if platforms, err = tokenlist.FetchPlatforms(client, *coinGecko); err != nil {
+            return err
+        }
+        if coins, err = tokenlist.FetchCoins(client, *coinGecko); err != nil {
+            return err
+        }
+    }
+
+    candidate, err := tokenlist.Resolve(symbol, chains, fetched, platforms, coins, *coinGeckoID)
+    if err != nil {
+        return err
+    }
+
+    entry, err := json.MarshalIndent(map[string]interface{}{symbol: candidate.Asset}, "", "    ")
+    if err != nil {
+        return err
+    }
+    fmt.Println(string(entry))
+    chainIDs := make([]string, 0, len(candidate.Sources))
+    for chainID := range candidate.Sources {
+        chainIDs = append(chainIDs, chainID)
+    }
+    sort.Strings(chainIDs)
+    for _, chainID := range chainIDs {
+        fmt.Fprintf(os.Stderr, "chain %s (%s): found in %s\n", chainID, crypto.BaseConfig.Chains[chainID].Name, strings.Join(candidate.Sources[chainID], ", "))
+    }
+    for _, conflict := range candidate.Conflicts {
+        fmt.Fprintf(os.Stderr, "warning: %s\n", conflict)
+    }
+    book := symbols.New(crypto.BaseConfig)
+    for _, chainID := range chainIDs {
+        address := candidate.Asset.Chains[chainID].Address
+        if others := book.SymbolsAt(chainID, address); address != "" && len(others) > 0 {
+            fmt.Fprintf(os.Stderr, "warning: chain %s address %s is already configured for %s\n", chainID, address, strings.Join(others, ", "))
+        }
+    }
+
+    if !*yes {
+        fmt.Fprintf(os.Stderr, "Write %s to assets.json? [y/N] ", symbol)
+        answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+        if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+            fmt.Fprintln(os.Stderr, "not written")
+            return nil
+        }
+    }
+    if err := crypto.UpdateAssetConfig(*configDir, symbol, &candidate.Asset); err != nil {
+        return err
+    }
+    fmt.Fprintf(os.Stderr, "added %s to assets.json\n", symbol)
+    return nil
+}
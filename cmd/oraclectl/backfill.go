@@ -0,0 +1,78 @@
+package main
+
+import (
+    "bytes"
+    "encoding/json"
+    "flag"
+    "fmt"
+    "io"
+    "net/http"
+    "os"
+    "strings"
+    "time"
+)
+
+// runBackfillRun asks a running oracle to backfill a feed's history from
+// exchange klines through the admin API
+func runBackfillRun(args []string) error {
+    fs := flag.NewFlagSet("backfill run", flag.ExitOnError)
+    server := fs.String("server", "http://localhost:8080", "Oracle API base URL")
+    symbol := fs.String("symbol", "", "Pair to backfill (e.g. BTCUSDT)")
+    lookback := fs.Duration("lookback", 24*time.Hour, "How far back to backfill")
+    interval := fs.Duration("interval", 5*time.Minute, "Round interval: 1m, 5m, 15m or 1h")
+    fs.Parse(args)
+
+    if *symbol == "" {
+        return fmt.Errorf("-symbol is required")
+    }
+    token := os.Getenv("ORACLE_ADMIN_TOKEN")
+    if token == "" {
+        return fmt.Errorf("ORACLE_ADMIN_TOKEN is required")
+    }
+
+    body, err := json.Marshal(map[string]string{
+        "symbol":   *symbol,
+        "lookback": lookback.String(),
+        "interval": interval.String(),
+    })
+    if err != nil {
+        return err
+    }
+    req, err := http.NewRequest("POST", strings.TrimRight(*server, "/")+"/api/v1/admin/backfill", bytes.NewReader(body))
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set("Authorization", "Bearer "+token)
+
+    client := &http.Client{Timeout: 5 * time.Minute}
+    resp, err := client.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    data, err := io.ReadAll(resp.Body)
+    if err != nil {
+        return err
+    }
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("backfill failed: %s: %s", resp.Status, strings.TrimSpace(string(data)))
+    }
+
+    var report struct {
+        Rounds  int               `json:"rounds"`
+        From    time.Time         `json:"from"`
+        To      time.Time         `json:"to"`
+        Candles map[string]int    `json:"candles"`
+        Errors  map[string]string `json:"errors"`
+    }
+    if err := json.Unmarshal(data, &report); err != nil {
+        return fmt.Errorf("failed to parse response: %v", err)
+    }
+    for exchange, message := range report.Errors {
+        fmt.Fprintf(os.Stderr, "warning: %s: %s\n", exchange, message)
+    }
+    fmt.Fprintf(os.Stderr, "backfilled %d rounds of %s from %s to %s\n", report.Rounds, *symbol,
+        report.From.Format(time.RFC3339), report.To.Format(time.RFC3339))
+    return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+    "encoding/json"
+    "flag"
+    "fmt"
+    "os"
+    "time"
+
+    "yetaXYZ/oracle/canary"
+    "yetaXYZ/oracle/fetch"
+)
+
+// runCanaryCheck prints a canary instance's comparison report and fails
+// unless it passes, so that deploy pipelines can gate promotion on it
+func runCanaryCheck(args []string) error {
+    fs := flag.NewFlagSet("canary check", flag.ExitOnError)
+    url := fs.String("url", "", "Base URL of the canary instance")
+    fs.Parse(args)
+
+    if *url == "" {
+        return fmt.Errorf("-url is required")
+    }
+    report, err := canary.FetchReport(fetch.NewClient(30*time.Second), *url)
+    if err != nil {
+        return err
+    }
+
+    enc := json.NewEncoder(os.Stdout)
+    enc.SetIndent("", "    ")
+    if err := enc.Encode(report); err != nil {
+        return err
+    }
+    if !report.Pass {
+        return fmt.Errorf("canary failed %d check(s); do not promote", len(report.Failures))
+    }
+    fmt.Fprintf(os.Stderr, "canary matched production on %d feed(s) since %s\n", len(report.Feeds), report.From.Format(time.RFC3339))
+    return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+    "encoding/json"
+    "flag"
+    "fmt"
+    "io"
+    "net/http"
+    "os"
+    "strings"
+    "time"
+
+    "yetaXYZ/oracle/sources/crypto"
+)
+
+// deployedConfig is the response of the admin config endpoint, which is
+// also the format of config snapshots saved from it
+type deployedConfig struct {
+    Version  string                  `json:"version"`
+    LoadedAt time.Time               `json:"loadedAt"`
+    Config   *crypto.EffectiveConfig `json:"config"`
+}
+
+// runConfigDiff compares the local configs with the effective config of a
+// running oracle, or of a snapshot saved from one, and fails on drift so
+// that pipelines can catch changes made outside the repository
+func runConfigDiff(args []string) error {
+    fs := flag.NewFlagSet("config diff", flag.ExitOnError)
+    configDir := fs.String("config", "config", "Configuration directory")
+    against := fs.String("against", "", "Oracle API base URL, or a snapshot saved from /api/v1/admin/config")
+    fs.Parse(args)
+
+    if *against == "" {
+        return fmt.Errorf("-against is required")
+    }
+    if err := crypto.LoadConfig(*configDir); err != nil {
+        return err
+    }
+    local, err := crypto.CurrentConfig()
+    if err != nil {
+        return err
+    }
+
+    var deployed *deployedConfig
+    if strings.HasPrefix(*against, "http://") || strings.HasPrefix(*against, "https://") {
+        deployed, err = fetchDeployedConfig(*against)
+    } else {
+        deployed, err = readDeployedConfig(*against)
+    }
+    if err != nil {
+        return err
+    }
+
+    changes, err := crypto.DiffConfig(*deployed.Config, local.Effective())
+    if err != nil {
+        return err
+    }
+    enc := json.NewEncoder(os.Stdout)
+    enc.SetIndent("", "    ")
+    if err := enc.Encode(map[string]interface{}{
+        "localVersion":    local.Version,
+        "deployedVersion": deployed.Version,
+        "changes":         changes,
+    }); err != nil {
+        return err
+    }
+    if len(changes) > 0 {
+        return fmt.Errorf("%d setting(s) differ between %s and %s", len(changes), *configDir, *against)
+    }
+    fmt.Fprintf(os.Stderr, "%s matches %s (version %s)\n", *configDir, *against, local.Version)
+    return nil
+}
+
+// fetchDeployedConfig reads a running oracle's effective config through the
+// admin API
+func fetchDeployedConfig(server string) (*deployedConfig, error) {
+    token := os.Getenv("ORACLE_ADMIN_TOKEN")
+    if token == "" {
+        return nil, fmt.Errorf("ORACLE_ADMIN_TOKEN is required")
+    }
+    req, err := http.NewRequest("GET", strings.TrimRight(server, "/")+"/api/v1/admin/config", nil)
+    if err != nil {
+        return nil, err
+    }
+    req.Header.Set("Authorization", "Bearer "+token)
+
+    client := &http.Client{Timeout: 30 * time.Second}
+    resp, err := client.Do(req)
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+    data, err := io.ReadAll(resp.Body)
+    if err != nil {
+        return nil, err
+    }
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("failed to fetch config: %s: %s", resp.Status, strings.TrimSpace(string(data)))
+    }
+    return parseDeployedConfig(data)
+}
+
+// readDeployedConfig reads a config snapshot saved from the admin API
+func readDeployedConfig(path string) (*deployedConfig, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read config snapshot: %v", err)
+    }
+    return parseDeployedConfig(data)
+}
+
+func parseDeployedConfig(data []byte) (*deployedConfig, error) {
+    var deployed deployedConfig
+    if err := json.Unmarshal(data, &deployed); err != nil {
+        return nil, fmt.Errorf("failed to parse config: %v", err)
+    }
+    if deployed.Config == nil || deployed.Config.Base == nil {
+        return nil, fmt.Errorf("failed to parse config: no config document")
+    }
+    return &deployed, nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+    "bufio"
+    "context"
+    "encoding/hex"
+    "encoding/json"
+    "flag"
+    "fmt"
+    "os"
+    "strings"
+    "time"
+
+    "yetaXYZ/oracle/evm"
+    "yetaXYZ/oracle/fetch"
+    "yetaXYZ/oracle/publish"
+    "yetaXYZ/oracle/sources/crypto"
+)
+
+// runContractDeploy deploys the reference PriceFeed contract to the chain
+// of a publish profile and authorizes the publishing account
+func runContractDeploy(args []string) error {
+    fs := flag.NewFlagSet("contract deploy", flag.ExitOnError)
+    configDir := fs.String("config", "config", "Configuration directory")
+    env := fs.String("env", "", "Publish profile to deploy for, e.g. staging")
+    artifact := fs.String("artifact", "contracts/build/PriceFeed.bin", "Compiled PriceFeed: solc --bin output or a Hardhat/Foundry artifact")
+    decimals := fs.Int("decimals", -1, "Feed decimals (default the publish config's decimals)")
+    description := fs.String("description", "yetaXYZ oracle feeds", "Contract description")
+    rpcURL := fs.String("rpc", "", "RPC URL (default the profile's)")
+    from := fs.String("from", "", "Deploying account, held by the node or its signer (default the profile's from)")
+    publisher := fs.String("publisher", "", "Account authorized to publish (default the profile's from)")
+    timeout := fs.Duration("timeout", 5*time.Minute, "How long to wait for each transaction to be mined")
+    yes := fs.Bool("yes", false, "Deploy to a non-testnet chain without asking for confirmation")
+    fs.Parse(args)
+
+    if err := crypto.LoadConfig(*configDir); err != nil {
+        return err
+    }
+    config, err := publish.LoadConfig(*configDir, *env)
+    if err != nil {
+        return err
+    }
+    if err := config.ResolveChain(crypto.BaseConfig.Chains); err != nil {
+        return err
+    }
+    if *rpcURL == "" {
+        *rpcURL = config.RPCUrl
+    }
+    if *from == "" {
+        *from = config.From
+    }
+    if *publisher == "" {
+        *publisher = config.From
+    }
+    if *decimals < 0 {
+        *decimals = config.Decimals
+    }
+    if *rpcURL == "" || *from == "" {
+        return fmt.Errorf("an RPC URL and deploying account are required, from the publish config or -rpc and -from")
+    }
+
+    code, err := loadBytecode(*artifact)
+    if err != nil {
+        return err
+    }
+    constructorArgs, err := evm.PriceFeedConstructorArgs(*decimals, *description)
+    if err != nil {
+        return err
+    }
+
+    chain := crypto.BaseConfig.Chains[config.Chain]
+    if chain.Type != publish.ChainTypeTestnet && !*yes {
+        name := chain.Name
+        if name == "" {
+            name = *rpcURL
+        }
+        fmt.Fprintf(os.Stderr, "Deploy PriceFeed to %s from %s? [y/N] ", name, *from)
+        answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+        if !strings.EqualFold(strings.TrimSpace(answer), "y") {
+            return fmt.Errorf("aborted")
+        }
+    }
+
+    client := evm.NewClient(*rpcURL, fetch.NewClient(15*time.Second))
+    ctx := context.Background()
+
+    hash, err := client.Deploy(ctx, *from, append(code, constructorArgs...))
+    if err != nil {
+        return fmt.Errorf("failed to send deployment: %v", err)
+    }
+    fmt.Fprintf(os.Stderr, "deployment sent: %s\n", hash)
+    receipt, err := waitReceipt(ctx, client, hash, *timeout)
+    if err != nil {
+        return err
+    }
+    if !receipt.Success || receipt.ContractAddress == "" {
+        return fmt.Errorf("deployment %s reverted", hash)
+    }
+    fmt.Fprintf(os.Stderr, "PriceFeed deployed at %s (block %d)\n", receipt.ContractAddress, receipt.BlockNumber)
+
+    feed := evm.NewPriceFeed(client, receipt.ContractAddress)
+    hash, err = feed.SetPublisher(ctx, *from, *publisher, true)
+    if err != nil {
+        return fmt.Errorf("failed to authorize publisher %s: %v", *publisher, err)
+    }
+    authorized, err := waitReceipt(ctx, client, hash, *timeout)
+    if err != nil {
+        return err
+    }
+    if !authorized.Success {
+        return fmt.Errorf("authorizing publisher %s reverted", *publisher)
+    }
+    fmt.Fprintf(os.Stderr, "authorized publisher %s\n", *publisher)
+
+    // The address goes on stdout for scripts
+    fmt.Println(feed.Address())
+    fmt.Fprintf(os.Stderr, "set \"contract\": %q and \"contractType\": %q in the publish config to publish to it\n", feed.Address(), publish.ContractPriceFeed)
+    return nil
+}
+
+// waitReceipt polls for a transaction's receipt until it is mined
+func waitReceipt(ctx context.Context, client *evm.Client, hash string, timeout time.Duration) (*evm.TxReceipt, error) {
+    deadline := time.Now().Add(timeout)
+    for {
+        receipt, err := client.TransactionReceipt(ctx, hash)
+        if err != nil {
+            return nil, fmt.Errorf("failed to fetch receipt of %s: %v", hash, err)
+        }
+        if receipt != nil {
+            return receipt, nil
+        }
+        if time.Now().After(deadline) {
+            return nil, fmt.Errorf("%s not mined after %s", hash, timeout)
+        }
+        time.Sleep(2 * time.Second)
+    }
+}
+
+// loadBytecode reads contract creation bytecode from solc --bin output or
+// from the bytecode field of a Hardhat or Foundry artifact
+func loadBytecode(path string) ([]byte, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read contract artifact (compile contracts/PriceFeed.sol first): %v", err)
+    }
+
+    text := strings.TrimSpace(string(data))
+    if strings.HasPrefix(text, "{") {
+        var artifact struct {
+            Bytecode json.RawMessage `json:"bytecode"`
+        }
+        if err := json.Unmarshal(data, &artifact); err != nil {
+            return nil, fmt.Errorf("failed to parse contract artifact: %v", err)
+        }
+        // Hardhat stores a hex string, Foundry an object with the hex in "object"
+        var foundry struct {
+            Object string `json:"object"`
+        }
+        if err := json.Unmarshal(artifact.Bytecode, &text); err != nil {
+            if err := json.Unmarshal(artifact.Bytecode, &foundry); err != nil {
+                return nil, fmt.Errorf("contract artifact has no bytecode")
+            }
+            text = foundry.Object
+        }
+    }
+
+    code, err := hex.DecodeString(strings.TrimPrefix(text, "0x"))
+    if err != nil || len(code) == 0 {
+        return nil, fmt.Errorf("contract artifact %s holds no valid bytecode", path)
+    }
+    return code, nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "sort"
+)
+
+// command is a single oraclectl subcommand
+type command struct {
+    usage string
+    run   func(args []string) error
+}
+
+var commands = map[string]command{
+    "asset add": {
+        usage: "onboard an asset from token lists into assets.json",
+        run:   runAssetAdd,
+    },
+    "backfill run": {
+        usage: "backfill a feed's history from exchange klines",
+        run:   runBackfillRun,
+    },
+    "canary check": {
+        usage: "fail unless a canary's comparison against production passes",
+        run:   runCanaryCheck,
+    },
+    "config diff": {
+        usage: "compare local configs with a running oracle's effective config",
+        run:   runConfigDiff,
+    },
+    "contract deploy": {
+        usage: "deploy the reference PriceFeed contract for a publish profile",
+        run:   runContractDeploy,
+    },
+    "plan capacity": {
+        usage: "estimate request rates, rate-limit headroom, spend and memory of a config",
+        run:   runPlanCapacity,
+    },
+    "pools discover": {
+        usage: "find the deepest DEX pools for a token pair",
+        run:   runPoolsDiscover,
+    },
+    "profile apply": {
+        usage: "point pairs at a tuning profile in pairs.json",
+        run:   runProfileApply,
+    },
+    "profile export": {
+        usage: "export tuning profiles, or a pair's parameters as one",
+        run:   runProfileExport,
+    },
+    "profile import": {
+        usage: "add exported tuning profiles to pairs.json",
+        run:   runProfileImport,
+    },
+    "registry import": {
+        usage: "generate pairs from a Chainlink or Pyth feed registry",
+        run:   runRegistryImport,
+    },
+    "weights apply": {
+        usage: "apply reviewed source weight suggestions to pairs.json",
+        run:   runWeightsApply,
+    },
+}
+
+func main() {
+    if len(os.Args) < 3 {
+        usage()
+        os.Exit(2)
+    }
+
+    name := os.Args[1] + " " + os.Args[2]
+    cmd, ok := commands[name]
+    if !ok {
+        fmt.Fprintf(os.Stderr, "unknown command: %s\n", name)
+        usage()
+        os.Exit(2)
+    }
+
+    if err := cmd.run(os.Args[3:]); err != nil {
+        fmt.Fprintf(os.Stderr, "error: %v\n", err)
+        os.Exit(1)
+    }
+}
+
+func usage() {
+    fmt.Fprintln(os.Stderr, "usage: oraclectl <group> <command> [flags]")
+    fmt.Fprintln(os.Stderr, "\ncommands:")
+    names := make([]string, 0, len(commands))
+    for name := range commands {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    for _, name := range names {
+        fmt.Fprintf(os.Stderr, "  %-20s %s\n", name, commands[name].usage)
+    }
+}
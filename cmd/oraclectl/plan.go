@@ -0,0 +1,61 @@
+package main
+
+import (
+    "encoding/json"
+    "flag"
+    "fmt"
+    "os"
+
+    "yetaXYZ/oracle/costs"
+    "yetaXYZ/oracle/planner"
+    "yetaXYZ/oracle/sources/crypto"
+    "yetaXYZ/oracle/store"
+)
+
+// runPlanCapacity prints the outbound request rate per upstream host,
+// rate-limit headroom, paid source spend and memory of the feeds a config
+// schedules, optionally with more pairs like an existing one
+func runPlanCapacity(args []string) error {
+    fs := flag.NewFlagSet("plan capacity", flag.ExitOnError)
+    configDir := fs.String("config", "config", "Configuration directory")
+    like := fs.String("like", "", "Plan for more pairs configured like this one")
+    count := fs.Int("count", 0, "Number of pairs added with -like")
+    strict := fs.Bool("strict", false, "Fail when the plan has warnings")
+    fs.Parse(args)
+
+    if (*like == "") != (*count == 0) {
+        return fmt.Errorf("-like and -count go together")
+    }
+    if err := crypto.LoadConfig(*configDir); err != nil {
+        return err
+    }
+    costsConfig, err := costs.LoadConfig(*configDir)
+    if err != nil {
+        return err
+    }
+    storeConfig, err := store.LoadConfig(*configDir)
+    if err != nil {
+        return err
+    }
+
+    in := planner.Input{Base: crypto.BaseConfig, Pairs: crypto.PairsConfig, Costs: costsConfig, Store: storeConfig}
+    if *like != "" {
+        if in, err = in.WithCopies(*like, *count); err != nil {
+            return err
+        }
+    }
+    plan := planner.Build(in)
+
+    enc := json.NewEncoder(os.Stdout)
+    enc.SetIndent("", "    ")
+    if err := enc.Encode(plan); err != nil {
+        return err
+    }
+    for _, warning := range plan.Warnings {
+        fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+    }
+    if *strict && len(plan.Warnings) > 0 {
+        return fmt.Errorf("%d warning(s)", len(plan.Warnings))
+    }
+    return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "flag"
+    "fmt"
+    "os"
+    "time"
+
+    "yetaXYZ/oracle/fetch"
+    "yetaXYZ/oracle/sources/crypto"
+    "yetaXYZ/oracle/sources/dex"
+    "yetaXYZ/oracle/symbols"
+)
+
+// runPoolsDiscover finds candidate pools and optionally writes them into
+// the pair's DEX sources in pairs.json
+func runPoolsDiscover(args []string) error {
+    fs := flag.NewFlagSet("pools discover", flag.ExitOnError)
+    configDir := fs.String("config", "config", "Configuration directory")
+    chain := fs.String("chain", "1", "Chain ID")
+    base := fs.String("base", "", "Base asset symbol or token address")
+    quote := fs.String("quote", "", "Quote asset symbol or token address")
+    pair := fs.String("pair", "", "Pair to add suggested pools to (e.g. ETHUSDC)")
+    limit := fs.Int("limit", 3, "Maximum number of pools to suggest")
+    write := fs.Bool("write", false, "Write suggested pools into pairs.json")
+    fs.Parse(args)
+
+    if *base == "" || *quote == "" {
+        return fmt.Errorf("-base and -quote are required")
+    }
+    if *write && *pair == "" {
+        return fmt.Errorf("-write requires -pair")
+    }
+
+    if err := crypto.LoadConfig(*configDir); err != nil {
+        return err
+    }
+
+    tokenA, err := symbols.New(crypto.BaseConfig).Address(*chain, *base)
+    if err != nil {
+        return err
+    }
+    tokenB, err := symbols.New(crypto.BaseConfig).Address(*chain, *quote)
+    if err != nil {
+        return err
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+
+    candidates, err := dex.DiscoverAll(ctx, fetch.NewClient(30*time.Second), crypto.BaseConfig, *chain, tokenA, tokenB, *limit)
+    if err != nil {
+        return err
+    }
+
+    enc := json.NewEncoder(os.Stdout)
+    enc.SetIndent("", "    ")
+    if err := enc.Encode(candidates); err != nil {
+        return err
+    }
+
+    if !*write {
+        return nil
+    }
+
+    pairConfig, err := crypto.GetPairConfig(*pair)
+    if err != nil {
+        return err
+    }
+    added := dex.ApplyCandidates(pairConfig, candidates)
+    if added == 0 {
+        fmt.Fprintln(os.Stderr, "no new pools to add")
+        return nil
+    }
+    if err := crypto.UpdatePairConfig(*configDir, *pair, pairConfig); err != nil {
+        return err
+    }
+    fmt.Fprintf(os.Stderr, "added %d pool(s) to %s\n", added, *pair)
+    return nil
+}
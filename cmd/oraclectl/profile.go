@@ -0,0 +1,222 @@
+package main
+
+import (
+    "encoding/json"
+    "flag"
+    "fmt"
+    "os"
+    "reflect"
+    "sort"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/sources/crypto"
+)
+
+// profilesDocument is the format profiles are exported and imported in,
+// the profiles section of pairs.json
+type profilesDocument struct {
+    Profiles map[string]common.AggregationParams `json:"profiles"`
+}
+
+// runProfileApply points pairs at a tuning profile, replacing their own
+// aggregation parameters, and with -write updates pairs.json
+func runProfileApply(args []string) error {
+    fs := flag.NewFlagSet("profile apply", flag.ExitOnError)
+    configDir := fs.String("config", "config", "Configuration directory")
+    name := fs.String("profile", "", "Profile to apply")
+    pairs := fs.String("pairs", "", "Comma-separated pairs to apply it to")
+    group := fs.String("group", "", "Apply it to every pair of this group")
+    write := fs.Bool("write", false, "Write the changes into pairs.json")
+    fs.Parse(args)
+
+    if *name == "" {
+        return fmt.Errorf("-profile is required")
+    }
+    if *pairs == "" && *group == "" {
+        return fmt.Errorf("-pairs or -group is required")
+    }
+    if err := crypto.LoadConfig(*configDir); err != nil {
+        return err
+    }
+    profile, ok := crypto.ProfilesConfig[*name]
+    if !ok {
+        return fmt.Errorf("unknown profile %s", *name)
+    }
+    snapshot, err := crypto.CurrentConfig()
+    if err != nil {
+        return err
+    }
+
+    selected := make(map[string]bool)
+    for _, symbol := range splitList(*pairs) {
+        selected[symbol] = true
+    }
+    if *group != "" {
+        members := snapshot.Group(*group)
+        if len(members) == 0 {
+            return fmt.Errorf("unknown group %s", *group)
+        }
+        for _, symbol := range members {
+            selected[symbol] = true
+        }
+    }
+    symbols := make([]string, 0, len(selected))
+    for symbol := range selected {
+        symbols = append(symbols, symbol)
+    }
+    sort.Strings(symbols)
+
+    updated := make(map[string]*common.PairConfig, len(symbols))
+    changes := make(map[string][]crypto.Change, len(symbols))
+    for _, symbol := range symbols {
+        current, err := crypto.GetPairConfig(symbol)
+        if err != nil {
+            return err
+        }
+        pair := *current
+        pair.Profile = *name
+        pair.Aggregation = profile
+        diff, err := crypto.PreviewPairConfig(symbol, &pair)
+        if err != nil {
+            return fmt.Errorf("pair %s: %v", symbol, err)
+        }
+        updated[symbol] = &pair
+        changes[symbol] = diff
+    }
+
+    enc := json.NewEncoder(os.Stdout)
+    enc.SetIndent("", "    ")
+    if err := enc.Encode(map[string]interface{}{
+        "profile": *name,
+        "changes": changes,
+    }); err != nil {
+        return err
+    }
+    if !*write {
+        fmt.Fprintf(os.Stderr, "%d pair(s) to update; rerun with -write to apply %s\n", len(symbols), *name)
+        return nil
+    }
+    for _, symbol := range symbols {
+        if err := crypto.UpdatePairConfig(*configDir, symbol, updated[symbol]); err != nil {
+            return err
+        }
+    }
+    fmt.Fprintf(os.Stderr, "applied %s to %d pair(s)\n", *name, len(symbols))
+    return nil
+}
+
+// runProfileExport prints profiles in the format profile import reads:
+// those of pairs.json, or one made from a pair's aggregation parameters to
+// replace copies of them
+func runProfileExport(args []string) error {
+    fs := flag.NewFlagSet("profile export", flag.ExitOnError)
+    configDir := fs.String("config", "config", "Configuration directory")
+    names := fs.String("profiles", "", "Comma-separated profiles to export (default all)")
+    fromPair := fs.String("from-pair", "", "Export this pair's aggregation parameters instead")
+    as := fs.String("name", "", "Name of the profile exported with -from-pair")
+    fs.Parse(args)
+
+    if *fromPair != "" && *as == "" {
+        return fmt.Errorf("-from-pair requires -name")
+    }
+    if err := crypto.LoadConfig(*configDir); err != nil {
+        return err
+    }
+
+    doc := profilesDocument{Profiles: make(map[string]common.AggregationParams)}
+    switch {
+    case *fromPair != "":
+        pair, err := crypto.GetPairConfig(*fromPair)
+        if err != nil {
+            return err
+        }
+        doc.Profiles[*as] = pair.Aggregation
+    case *names != "":
+        for _, name := range splitList(*names) {
+            profile, ok := crypto.ProfilesConfig[name]
+            if !ok {
+                return fmt.Errorf("unknown profile %s", name)
+            }
+            doc.Profiles[name] = profile
+        }
+    default:
+        for name, profile := range crypto.ProfilesConfig {
+            doc.Profiles[name] = profile
+        }
+    }
+
+    enc := json.NewEncoder(os.Stdout)
+    enc.SetIndent("", "    ")
+    return enc.Encode(doc)
+}
+
+// runProfileImport adds the profiles of an export, or of another
+// deployment's pairs.json, to pairs.json with -write
+func runProfileImport(args []string) error {
+    fs := flag.NewFlagSet("profile import", flag.ExitOnError)
+    configDir := fs.String("config", "config", "Configuration directory")
+    file := fs.String("file", "", "Profiles JSON file")
+    replace := fs.Bool("replace", false, "Replace existing profiles that differ")
+    write := fs.Bool("write", false, "Write the profiles into pairs.json")
+    fs.Parse(args)
+
+    if *file == "" {
+        return fmt.Errorf("-file is required")
+    }
+    data, err := os.ReadFile(*file)
+    if err != nil {
+        return err
+    }
+    var doc profilesDocument
+    if err := json.Unmarshal(data, &doc); err != nil {
+        return fmt.Errorf("failed to parse profiles: %v", err)
+    }
+    if len(doc.Profiles) == 0 {
+        return fmt.Errorf("%s defines no profiles", *file)
+    }
+    if err := crypto.LoadConfig(*configDir); err != nil {
+        return err
+    }
+
+    // Replacing a profile retunes every pair that references it
+    users := make(map[string][]string)
+    for symbol, pair := range crypto.PairsConfig {
+        users[pair.Profile] = append(users[pair.Profile], symbol)
+    }
+    imported := make(map[string]common.AggregationParams, len(doc.Profiles))
+    names := make([]string, 0, len(doc.Profiles))
+    for name := range doc.Profiles {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    for _, name := range names {
+        profile := doc.Profiles[name]
+        existing, exists := crypto.ProfilesConfig[name]
+        switch {
+        case !exists:
+            fmt.Fprintf(os.Stderr, "new profile %s\n", name)
+        case reflect.DeepEqual(existing, profile):
+            continue
+        case !*replace:
+            return fmt.Errorf("profile %s differs from the one in %s; rerun with -replace to replace it", name, *configDir)
+        default:
+            sort.Strings(users[name])
+            fmt.Fprintf(os.Stderr, "replacing profile %s, used by %d pair(s) %v\n", name, len(users[name]), users[name])
+        }
+        imported[name] = profile
+    }
+
+    if len(imported) == 0 {
+        fmt.Fprintln(os.Stderr, "no profiles to import")
+        return nil
+    }
+    if !*write {
+        fmt.Fprintf(os.Stderr, "%d profile(s) to import; rerun with -write to add them\n", len(imported))
+        return nil
+    }
+    if err := crypto.ApplyProfiles(*configDir, imported); err != nil {
+        return err
+    }
+    fmt.Fprintf(os.Stderr, "imported %d profile(s) into pairs.json\n", len(imported))
+    return nil
+}
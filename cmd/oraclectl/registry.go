@@ -0,0 +1,108 @@
+package main
+
+import (
+    "encoding/json"
+    "flag"
+    "fmt"
+    "os"
+    "sort"
+    "strings"
+    "time"
+
+    "yetaXYZ/oracle/fetch"
+    "yetaXYZ/oracle/registry"
+    "yetaXYZ/oracle/sources/crypto"
+)
+
+// runRegistryImport generates pairs matching the feeds of a Chainlink or
+// Pyth registry and, with -write, adds them to pairs.json
+func runRegistryImport(args []string) error {
+    fs := flag.NewFlagSet("registry import", flag.ExitOnError)
+    configDir := fs.String("config", "config", "Configuration directory")
+    from := fs.String("from", "", "Registry to import: chainlink or pyth")
+    location := fs.String("url", "", "Registry URL or file (default the registry's public list)")
+    quotes := fs.String("quote", "", "Quote mappings, e.g. USD=USDT,ETH=WETH")
+    exchanges := fs.String("exchanges", "", "Comma-separated CEX sources of the pairs (default all configured)")
+    only := fs.String("only", "", "Comma-separated base assets to import (default all)")
+    frequency := fs.Int("frequency", 0, "Update frequency of the pairs in seconds (default 5)")
+    group := fs.String("group", "", "Group tag of the pairs (default <registry>-import)")
+    write := fs.Bool("write", false, "Write the imported pairs into pairs.json")
+    fs.Parse(args)
+
+    if *location == "" {
+        switch *from {
+        case registry.Chainlink:
+            *location = registry.DefaultChainlinkURL
+        case registry.Pyth:
+            *location = registry.DefaultPythURL
+        default:
+            return fmt.Errorf("-from must be %s or %s", registry.Chainlink, registry.Pyth)
+        }
+    }
+    opts := registry.Options{
+        Exchanges:              splitList(*exchanges),
+        Quotes:                 make(map[string]string),
+        Only:                   make(map[string]bool),
+        UpdateFrequencySeconds: *frequency,
+        Group:                  *group,
+    }
+    for _, mapping := range splitList(*quotes) {
+        parts := strings.SplitN(mapping, "=", 2)
+        if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+            return fmt.Errorf("invalid quote mapping %q, want FROM=TO", mapping)
+        }
+        opts.Quotes[strings.ToUpper(parts[0])] = strings.ToUpper(parts[1])
+    }
+    for _, asset := range splitList(*only) {
+        opts.Only[strings.ToUpper(asset)] = true
+    }
+
+    if err := crypto.LoadConfig(*configDir); err != nil {
+        return err
+    }
+    feeds, err := registry.Load(fetch.NewClient(2*time.Minute), *from, *location)
+    if err != nil {
+        return err
+    }
+    plan, err := registry.Build(feeds, crypto.BaseConfig, crypto.PairsConfig, opts)
+    if err != nil {
+        return err
+    }
+
+    enc := json.NewEncoder(os.Stdout)
+    enc.SetIndent("", "    ")
+    if err := enc.Encode(plan.Pairs); err != nil {
+        return err
+    }
+    names := make([]string, 0, len(plan.Skipped))
+    for name := range plan.Skipped {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    for _, name := range names {
+        fmt.Fprintf(os.Stderr, "skipped %s: %s\n", name, plan.Skipped[name])
+    }
+
+    if !*write {
+        fmt.Fprintf(os.Stderr, "%d pair(s) to import; rerun with -write to add them\n", len(plan.Pairs))
+        return nil
+    }
+    for _, imported := range plan.Pairs {
+        if err := crypto.UpdatePairConfig(*configDir, imported.Symbol, imported.Pair); err != nil {
+            return err
+        }
+    }
+    fmt.Fprintf(os.Stderr, "added %d pair(s) to pairs.json\n", len(plan.Pairs))
+    return nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+    out := make([]string, 0)
+    for _, item := range strings.Split(value, ",") {
+        if item = strings.TrimSpace(item); item != "" {
+            out = append(out, item)
+        }
+    }
+    return out
+}
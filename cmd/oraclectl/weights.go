@@ -0,0 +1,72 @@
+package main
+
+import (
+    "encoding/json"
+    "flag"
+    "fmt"
+    "os"
+    "sort"
+
+    "yetaXYZ/oracle/analytics"
+    "yetaXYZ/oracle/sources/crypto"
+)
+
+// runWeightsApply writes reviewed weight suggestions, as served by
+// /api/v1/analytics/weights, into the pairs' sourceWeights in pairs.json
+func runWeightsApply(args []string) error {
+    fs := flag.NewFlagSet("weights apply", flag.ExitOnError)
+    configDir := fs.String("config", "config", "Configuration directory")
+    file := fs.String("file", "", "Weight suggestions JSON file")
+    pair := fs.String("pair", "", "Only apply suggestions for this pair")
+    fs.Parse(args)
+
+    if *file == "" {
+        return fmt.Errorf("-file is required")
+    }
+
+    data, err := os.ReadFile(*file)
+    if err != nil {
+        return err
+    }
+    var suggestions analytics.WeightSuggestions
+    if err := json.Unmarshal(data, &suggestions); err != nil {
+        return fmt.Errorf("failed to parse suggestions: %v", err)
+    }
+
+    if err := crypto.LoadConfig(*configDir); err != nil {
+        return err
+    }
+
+    symbols := make([]string, 0, len(suggestions.Pairs))
+    for symbol := range suggestions.Pairs {
+        if *pair == "" || symbol == *pair {
+            symbols = append(symbols, symbol)
+        }
+    }
+    sort.Strings(symbols)
+    if len(symbols) == 0 {
+        fmt.Fprintln(os.Stderr, "no suggestions to apply")
+        return nil
+    }
+
+    for _, symbol := range symbols {
+        pairConfig, err := crypto.GetPairConfig(symbol)
+        if err != nil {
+            return err
+        }
+        if pairConfig.SourceWeights == nil {
+            pairConfig.SourceWeights = make(map[string]float64)
+        }
+        for source, weight := range suggestions.Pairs[symbol].SourceWeights {
+            if weight <= 0 {
+                return fmt.Errorf("pair %s: weight of source %s must be positive", symbol, source)
+            }
+            pairConfig.SourceWeights[source] = weight
+        }
+        if err := crypto.UpdatePairConfig(*configDir, symbol, pairConfig); err != nil {
+            return err
+        }
+        fmt.Fprintf(os.Stderr, "updated source weights of %s\n", symbol)
+    }
+    return nil
+}
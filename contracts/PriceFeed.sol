@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.19;
+
+/// @title PriceFeed
+/// @notice Reference feed contract for the oracle's publish pipeline. Each
+/// feed keeps every published round, keyed by the oracle's round ID, and
+/// only authorized publishers may submit.
+contract PriceFeed {
+    struct Round {
+        uint256 value;
+        uint256 updatedAt;
+    }
+
+    /// @dev Which sources contributed to a round: bit i of `sources` is the
+    /// i-th configured source of the feed, and `hash` commits to the full
+    /// breakdown kept off-chain by the oracle
+    struct Composition {
+        uint256 sources;
+        bytes32 hash;
+    }
+
+    address public owner;
+    uint8 public immutable decimals;
+    string public description;
+
+    mapping(address => bool) public publishers;
+    mapping(bytes32 => uint64) public latestRound;
+    mapping(bytes32 => mapping(uint64 => Round)) private rounds;
+    mapping(bytes32 => mapping(uint64 => Composition)) private compositions;
+
+    event FeedUpdated(bytes32 indexed feedId, uint64 indexed roundId, uint256 value, uint256 updatedAt);
+    event CompositionPublished(bytes32 indexed feedId, uint64 indexed roundId, uint256 sources, bytes32 compositionHash);
+    event PublisherSet(address indexed publisher, bool allowed);
+    event OwnershipTransferred(address indexed previousOwner, address indexed newOwner);
+
+    modifier onlyOwner() {
+        require(msg.sender == owner, "Not owner");
+        _;
+    }
+
+    modifier onlyPublisher() {
+        require(publishers[msg.sender], "Not publisher");
+        _;
+    }
+
+    constructor(uint8 _decimals, string memory _description) {
+        owner = msg.sender;
+        decimals = _decimals;
+        description = _description;
+        emit OwnershipTransferred(address(0), msg.sender);
+    }
+
+    /// @notice Records a round of a feed. Rounds must increase, so replays
+    /// and stale submissions revert.
+    function updateFeed(bytes32 feedId, uint64 roundId, uint256 value) external onlyPublisher {
+        _record(feedId, roundId, value);
+    }
+
+    /// @notice Records a round of a feed along with the bitmap of sources
+    /// that contributed to it and the hash of its off-chain breakdown
+    function updateFeedWithComposition(bytes32 feedId, uint64 roundId, uint256 value, uint256 sources, bytes32 compositionHash) external onlyPublisher {
+        _record(feedId, roundId, value);
+        compositions[feedId][roundId] = Composition(sources, compositionHash);
+        emit CompositionPublished(feedId, roundId, sources, compositionHash);
+    }
+
+    function _record(bytes32 feedId, uint64 roundId, uint256 value) private {
+        require(value > 0, "Value must be positive");
+        require(roundId > latestRound[feedId], "Stale round");
+
+        rounds[feedId][roundId] = Round(value, block.timestamp);
+        latestRound[feedId] = roundId;
+        emit FeedUpdated(feedId, roundId, value, block.timestamp);
+    }
+
+    /// @notice Returns the latest round of a feed
+    function latestRoundData(bytes32 feedId) external view returns (uint64 roundId, uint256 value, uint256 updatedAt) {
+        roundId = latestRound[feedId];
+        require(roundId != 0, "No data");
+        Round storage round = rounds[feedId][roundId];
+        return (roundId, round.value, round.updatedAt);
+    }
+
+    /// @notice Returns a published round of a feed
+    function getRoundData(bytes32 feedId, uint64 roundId) external view returns (uint256 value, uint256 updatedAt) {
+        Round storage round = rounds[feedId][roundId];
+        require(round.updatedAt != 0, "No data");
+        return (round.value, round.updatedAt);
+    }
+
+    /// @notice Returns the source composition of a round; zero for rounds
+    /// published without one
+    function getRoundComposition(bytes32 feedId, uint64 roundId) external view returns (uint256 sources, bytes32 compositionHash) {
+        require(rounds[feedId][roundId].updatedAt != 0, "No data");
+        Composition storage composition = compositions[feedId][roundId];
+        return (composition.sources, composition.hash);
+    }
+
+    function setPublisher(address publisher, bool allowed) external onlyOwner {
+        publishers[publisher] = allowed;
+        emit PublisherSet(publisher, allowed);
+    }
+
+    function transferOwnership(address newOwner) external onlyOwner {
+        require(newOwner != address(0), "Zero address");
+        emit OwnershipTransferred(owner, newOwner);
+        owner = newOwner;
+    }
+}
@@ -0,0 +1,320 @@
+package analytics
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "math"
+    "sort"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+    "yetaXYZ/oracle/store"
+)
+
+// coldStartSample is the interval bootstrap history is resampled at
+const coldStartSample = 5 * time.Minute
+
+// Bootstrap methods
+const (
+    BootstrapProxy  = "proxy"  // returns of another feed's stored rounds
+    BootstrapKlines = "klines" // the pair's own exchange klines
+)
+
+// KlineHistory builds a pair's rounds over lookback ending at now from
+// exchange klines without storing them
+type KlineHistory func(symbol string, pair *common.PairConfig, lookback, interval time.Duration, now time.Time) ([]*common.AggregateResult, error)
+
+// Baseline is the bootstrapped statistics of a pair in cold start
+type Baseline struct {
+    Symbol  string `json:"symbol"`
+    Method  string `json:"method"`
+    Proxy   string `json:"proxy,omitempty"`
+    Samples int    `json:"samples"` // resampled prices estimated from
+    // Volatility is the annualized volatility estimate
+    Volatility float64 `json:"volatility"`
+    // Reference is the price the sanity bounds are placed around: the
+    // pair's previous round, or the last kline close before its first
+    Reference   float64   `json:"reference,omitempty"`
+    ReferenceAt time.Time `json:"referenceAt,omitempty"`
+    // Low and High bound the pair's next round one update interval after
+    // the reference
+    Low        float64   `json:"low,omitempty"`
+    High       float64   `json:"high,omitempty"`
+    Violations int       `json:"violations"` // rounds outside the bounds so far
+    ComputedAt time.Time `json:"computedAt"`
+    Error      string    `json:"error,omitempty"`
+}
+
+// ColdStart bootstraps volatility and sanity bounds for pairs configured
+// with a cold start until their own stored rounds cover the estimation
+// window, so that anomaly checks and volatility feeds work from the pair's
+// first round
+type ColdStart struct {
+    store  store.Store
+    bus    *events.Bus
+    pairs  func() map[string]*common.PairConfig
+    klines KlineHistory
+
+    mu        sync.RWMutex
+    baselines map[string]*Baseline
+}
+
+// NewColdStart creates a cold start estimator for the pairs returned by
+// pairs; klines may be nil when no pair bootstraps from exchange klines
+func NewColdStart(s store.Store, bus *events.Bus, pairs func() map[string]*common.PairConfig, klines KlineHistory) *ColdStart {
+    return &ColdStart{
+        store:     s,
+        bus:       bus,
+        pairs:     pairs,
+        klines:    klines,
+        baselines: make(map[string]*Baseline),
+    }
+}
+
+// Run checks new rounds against their baselines and re-estimates the
+// baselines at interval until ctx is cancelled
+func (c *ColdStart) Run(ctx context.Context, interval time.Duration) {
+    sub := c.bus.SubscribeFunc(256, func(e events.Event) {
+        if result, ok := e.Payload.(*common.AggregateResult); ok {
+            c.Check(result)
+        }
+    }, events.Aggregate).Named("coldstart")
+    defer sub.Close()
+
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    for {
+        c.Refresh(time.Now())
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+// Refresh estimates the baseline of every pair in cold start as of now and
+// drops those of pairs whose own rounds now cover the window
+func (c *ColdStart) Refresh(now time.Time) {
+    pairs := c.pairs()
+    symbols := make([]string, 0, len(pairs))
+    for symbol, pair := range pairs {
+        if pair.ColdStart != nil {
+            symbols = append(symbols, symbol)
+        }
+    }
+    sort.Strings(symbols)
+
+    for _, symbol := range symbols {
+        pair := pairs[symbol]
+        if c.warm(symbol, pair.ColdStart.Window(), now) {
+            c.mu.Lock()
+            _, cold := c.baselines[symbol]
+            delete(c.baselines, symbol)
+            c.mu.Unlock()
+            if cold {
+                log.Printf("%s has %s of its own history; leaving cold start", symbol, pair.ColdStart.Window())
+            }
+            continue
+        }
+
+        baseline := c.estimate(symbol, pair, now)
+        c.mu.Lock()
+        if previous, ok := c.baselines[symbol]; ok {
+            // Keep the live reference and the violation count
+            baseline.Violations = previous.Violations
+            if previous.ReferenceAt.After(baseline.ReferenceAt) {
+                baseline.Reference, baseline.ReferenceAt = previous.Reference, previous.ReferenceAt
+            }
+        }
+        baseline.bound(pair)
+        c.baselines[symbol] = baseline
+        c.mu.Unlock()
+        if baseline.Error != "" {
+            log.Printf("Cold start estimate of %s failed: %s", symbol, baseline.Error)
+        }
+    }
+
+    // Forget pairs no longer configured for cold start
+    c.mu.Lock()
+    for symbol := range c.baselines {
+        if pair, ok := pairs[symbol]; !ok || pair.ColdStart == nil {
+            delete(c.baselines, symbol)
+        }
+    }
+    c.mu.Unlock()
+}
+
+// Check compares a live round of a pair in cold start with its sanity
+// bounds, alerting when it falls outside, and moves the reference to it.
+// It reports whether the round was within bounds or had none to check.
+func (c *ColdStart) Check(result *common.AggregateResult) bool {
+    if result.Backfilled || result.Price <= 0 {
+        return true
+    }
+    pair := c.pairs()[result.Symbol]
+    if pair == nil || pair.ColdStart == nil {
+        return true
+    }
+
+    c.mu.Lock()
+    b, ok := c.baselines[result.Symbol]
+    if !ok || b.Volatility <= 0 {
+        c.mu.Unlock()
+        return true
+    }
+    within := true
+    message := ""
+    if b.Reference > 0 {
+        // A round later than the update interval may move further
+        elapsed := result.Timestamp.Sub(b.ReferenceAt)
+        if elapsed < updateInterval(pair) {
+            elapsed = updateInterval(pair)
+        }
+        low, high := bounds(b.Reference, b.Volatility, pair.ColdStart.Sigma(), elapsed)
+        if result.Price < low || result.Price > high {
+            within = false
+            b.Violations++
+            message = fmt.Sprintf("%s round %d at %v is outside the bootstrapped bounds [%.6g, %.6g] (%.0f%% volatility from %s)", result.Symbol, result.RoundID, result.Price, low, high, b.Volatility*100, b.source())
+        }
+    }
+    b.Reference, b.ReferenceAt = result.Price, result.Timestamp
+    b.bound(pair)
+    c.mu.Unlock()
+
+    if !within {
+        c.bus.Publish(events.Event{
+            Type:   events.Alert,
+            Symbol: result.Symbol,
+            Payload: &events.AlertPayload{
+                Severity: events.SeverityWarning,
+                Kind:     "cold_start_bounds",
+                Message:  message,
+            },
+        })
+    }
+    return within
+}
+
+// Baseline returns the bootstrapped statistics of a pair in cold start
+func (c *ColdStart) Baseline(symbol string) (Baseline, bool) {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    b, ok := c.baselines[symbol]
+    if !ok {
+        return Baseline{}, false
+    }
+    return *b, true
+}
+
+// Baselines returns the statistics of every pair in cold start, by symbol
+func (c *ColdStart) Baselines() []Baseline {
+    c.mu.RLock()
+    out := make([]Baseline, 0, len(c.baselines))
+    for _, b := range c.baselines {
+        out = append(out, *b)
+    }
+    c.mu.RUnlock()
+    sort.Slice(out, func(i, j int) bool { return out[i].Symbol < out[j].Symbol })
+    return out
+}
+
+// Volatility returns the bootstrapped volatility of a pair in cold start
+func (c *ColdStart) Volatility(symbol string) (float64, bool) {
+    b, ok := c.Baseline(symbol)
+    if !ok || b.Volatility <= 0 {
+        return 0, false
+    }
+    return b.Volatility, true
+}
+
+// warm reports whether the pair's own live rounds cover the window
+func (c *ColdStart) warm(symbol string, window time.Duration, now time.Time) bool {
+    rounds, err := c.store.Rounds(symbol, now.Add(-window), now)
+    if err != nil {
+        return false
+    }
+    for _, r := range rounds {
+        if r.Backfilled {
+            continue
+        }
+        return !r.Timestamp.After(now.Add(-window + coldStartSample))
+    }
+    return false
+}
+
+// estimate bootstraps a pair's volatility from its proxy or its klines
+func (c *ColdStart) estimate(symbol string, pair *common.PairConfig, now time.Time) *Baseline {
+    window := pair.ColdStart.Window()
+    from := now.Add(-window)
+    b := &Baseline{Symbol: symbol, ComputedAt: now}
+
+    var history []*common.AggregateResult
+    var err error
+    if proxy := pair.ColdStart.ProxyFeed; proxy != "" {
+        b.Method, b.Proxy = BootstrapProxy, proxy
+        history, err = c.store.Rounds(proxy, from, now)
+    } else {
+        b.Method = BootstrapKlines
+        if c.klines == nil {
+            err = fmt.Errorf("no kline history available")
+        } else {
+            history, err = c.klines(symbol, pair, window, coldStartSample, now)
+        }
+        if err == nil && len(history) > 0 {
+            last := history[len(history)-1]
+            b.Reference, b.ReferenceAt = last.Price, last.Timestamp
+        }
+    }
+    if err != nil {
+        b.Error = err.Error()
+        return b
+    }
+    // A live round of the pair, e.g. from before a restart, is a newer
+    // reference than any kline
+    if latest, err := c.store.Latest(symbol); err == nil && latest != nil && !latest.Backfilled && latest.Timestamp.After(b.ReferenceAt) {
+        b.Reference, b.ReferenceAt = latest.Price, latest.Timestamp
+    }
+
+    prices := Resample(SamplesFromRounds(history), from, now, coldStartSample)
+    b.Samples = len(prices)
+    if b.Volatility, err = RealizedVolatility(prices, coldStartSample); err != nil {
+        b.Error = err.Error()
+    }
+    return b
+}
+
+// bound places the sanity bounds one update interval after the reference
+func (b *Baseline) bound(pair *common.PairConfig) {
+    if b.Reference <= 0 || b.Volatility <= 0 {
+        b.Low, b.High = 0, 0
+        return
+    }
+    b.Low, b.High = bounds(b.Reference, b.Volatility, pair.ColdStart.Sigma(), updateInterval(pair))
+}
+
+// source describes where the baseline was estimated from
+func (b *Baseline) source() string {
+    if b.Method == BootstrapProxy {
+        return "proxy " + b.Proxy
+    }
+    return b.Method
+}
+
+// bounds returns the range of log moves within sigma standard deviations
+// of an annualized volatility over elapsed around reference
+func bounds(reference, volatility, sigma float64, elapsed time.Duration) (float64, float64) {
+    move := sigma * volatility * math.Sqrt(elapsed.Seconds()/secondsPerYear)
+    return reference * math.Exp(-move), reference * math.Exp(move)
+}
+
+// updateInterval returns the pair's update interval, a minute when unset
+func updateInterval(pair *common.PairConfig) time.Duration {
+    if pair.UpdateFrequencySeconds <= 0 {
+        return time.Minute
+    }
+    return time.Duration(pair.UpdateFrequencySeconds) * time.Second
+}
@@ -0,0 +1,156 @@
+package analytics
+
+import (
+    "math"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+    "yetaXYZ/oracle/store"
+)
+
+// zigzag returns rounds alternating 1% up and down every five minutes
+func zigzag(symbol string, from time.Time, n int, backfilled bool) []*common.AggregateResult {
+    rounds := make([]*common.AggregateResult, 0, n)
+    for i := 0; i < n; i++ {
+        price := 100.0
+        if i%2 == 1 {
+            price = 101
+        }
+        rounds = append(rounds, &common.AggregateResult{
+            Symbol:     symbol,
+            PricePoint: common.PricePoint{Price: price, Timestamp: from.Add(time.Duration(i) * coldStartSample)},
+            Backfilled: backfilled,
+        })
+    }
+    return rounds
+}
+
+func TestColdStartFromProxy(t *testing.T) {
+    now := time.Now()
+    s := store.NewMemoryStore()
+    for _, r := range zigzag("ETHUSDT", now.Add(-2*time.Hour), 24, false) {
+        s.SaveRound(r)
+    }
+
+    bus := events.NewBus()
+    alerts := bus.Subscribe(16, events.Alert)
+    pairs := map[string]*common.PairConfig{
+        "NEWUSDT": {UpdateFrequencySeconds: 60, ColdStart: &common.ColdStartConfig{ProxyFeed: "ETHUSDT", WindowHours: 2}},
+    }
+    c := NewColdStart(s, bus, func() map[string]*common.PairConfig { return pairs }, nil)
+    c.Refresh(now)
+
+    b, ok := c.Baseline("NEWUSDT")
+    if !ok || b.Method != BootstrapProxy || b.Error != "" || b.Volatility <= 0 {
+        t.Fatalf("Expected a proxy baseline, got %+v", b)
+    }
+    if b.Reference != 0 {
+        t.Errorf("Expected no reference before the pair's first round, got %v", b.Reference)
+    }
+    if v, ok := c.Volatility("NEWUSDT"); !ok || v != b.Volatility {
+        t.Errorf("Expected the bootstrapped volatility, got %v", v)
+    }
+
+    // The first round sets the reference, an ordinary move stays within
+    // the bounds and a jump falls outside them
+    round := func(id uint64, price float64, at time.Time) *common.AggregateResult {
+        return &common.AggregateResult{Symbol: "NEWUSDT", RoundID: id, PricePoint: common.PricePoint{Price: price, Timestamp: at}}
+    }
+    if !c.Check(round(1, 50, now)) {
+        t.Error("Expected the first round to have nothing to check against")
+    }
+    b, _ = c.Baseline("NEWUSDT")
+    if b.Reference != 50 || b.Low >= 50 || b.High <= 50 {
+        t.Errorf("Expected bounds around the first round, got %+v", b)
+    }
+    if !c.Check(round(2, 50.1, now.Add(time.Minute))) {
+        t.Error("Expected a small move within the bounds")
+    }
+    if c.Check(round(3, 60, now.Add(2*time.Minute))) {
+        t.Error("Expected a 20% jump outside the bounds")
+    }
+    if len(alerts.C) != 1 {
+        t.Fatalf("Expected one alert, got %d", len(alerts.C))
+    }
+    if alert := (<-alerts.C).Payload.(*events.AlertPayload); alert.Kind != "cold_start_bounds" {
+        t.Errorf("Expected a cold start alert, got %+v", alert)
+    }
+
+    // A refresh keeps the live reference and the violations
+    c.Refresh(now.Add(3 * time.Minute))
+    if b, _ = c.Baseline("NEWUSDT"); b.Reference != 60 || b.Violations != 1 {
+        t.Errorf("Expected the refresh to keep the live state, got %+v", b)
+    }
+
+    // Once the pair's own rounds cover the window it leaves cold start
+    for _, r := range zigzag("NEWUSDT", now.Add(-2*time.Hour), 24, false) {
+        s.SaveRound(r)
+    }
+    c.Refresh(now)
+    if _, ok := c.Baseline("NEWUSDT"); ok {
+        t.Error("Expected the pair to leave cold start")
+    }
+}
+
+func TestColdStartFromKlines(t *testing.T) {
+    now := time.Now()
+    pairs := map[string]*common.PairConfig{
+        "NEWUSDT": {ColdStart: &common.ColdStartConfig{WindowHours: 2, BoundsSigma: 3}},
+    }
+    var lookback time.Duration
+    klines := func(symbol string, pair *common.PairConfig, window, interval time.Duration, now time.Time) ([]*common.AggregateResult, error) {
+        lookback = window
+        return zigzag(symbol, now.Add(-2*time.Hour), 24, true), nil
+    }
+    c := NewColdStart(store.NewMemoryStore(), events.NewBus(), func() map[string]*common.PairConfig { return pairs }, klines)
+    c.Refresh(now)
+
+    b, ok := c.Baseline("NEWUSDT")
+    if !ok || b.Method != BootstrapKlines || b.Samples == 0 || lookback != 2*time.Hour {
+        t.Fatalf("Expected a kline baseline over the window, got %+v", b)
+    }
+    // The last close is the reference of the first live round
+    if b.Reference != 101 {
+        t.Errorf("Expected the last kline close as reference, got %v", b.Reference)
+    }
+    move := 3 * b.Volatility * math.Sqrt(60/float64(secondsPerYear))
+    if math.Abs(b.High-101*math.Exp(move)) > 1e-9 || math.Abs(b.Low-101*math.Exp(-move)) > 1e-9 {
+        t.Errorf("Expected bounds %v standard deviations around 101, got [%v, %v]", 3, b.Low, b.High)
+    }
+
+    // Backfilled rounds are not the pair's own history
+    c.Check(&common.AggregateResult{Symbol: "NEWUSDT", Backfilled: true, PricePoint: common.PricePoint{Price: 500, Timestamp: now}})
+    if b, _ = c.Baseline("NEWUSDT"); b.Reference != 101 {
+        t.Errorf("Expected backfilled rounds to be ignored, got %v", b.Reference)
+    }
+}
+
+func TestVolatilityFallsBackToColdStart(t *testing.T) {
+    now := time.Now()
+    s := store.NewMemoryStore()
+    for _, r := range zigzag("ETHUSDT", now.Add(-2*time.Hour), 24, false) {
+        s.SaveRound(r)
+    }
+    pairs := map[string]*common.PairConfig{
+        "NEWUSDT": {ColdStart: &common.ColdStartConfig{ProxyFeed: "ETHUSDT", WindowHours: 2}},
+    }
+    c := NewColdStart(s, events.NewBus(), func() map[string]*common.PairConfig { return pairs }, nil)
+    c.Refresh(now)
+
+    service := NewService(s, events.NewBus(), map[string]*common.StatisticFeedConfig{
+        "NEWUSDT_VOL": {Type: common.StatisticVolatility, Inputs: []string{"NEWUSDT"}, WindowHours: 2},
+    })
+    if _, err := service.compute("NEWUSDT_VOL", service.configs["NEWUSDT_VOL"], now); err == nil {
+        t.Fatal("Expected no volatility without history")
+    }
+    service.SetColdStart(c)
+    result, err := service.compute("NEWUSDT_VOL", service.configs["NEWUSDT_VOL"], now)
+    if err != nil {
+        t.Fatalf("Expected the bootstrapped volatility, got %v", err)
+    }
+    if v, _ := c.Volatility("NEWUSDT"); result.Price != v {
+        t.Errorf("Expected %v, got %v", v, result.Price)
+    }
+}
@@ -0,0 +1,100 @@
+package analytics
+
+import (
+    "math"
+    "sort"
+    "time"
+
+    "yetaXYZ/oracle/common"
+)
+
+// DeviationHeatmap is the average deviation of each source from the final
+// aggregate, bucketed over time
+type DeviationHeatmap struct {
+    Sources []string      `json:"sources"`
+    Buckets []time.Time   `json:"buckets"` // bucket start times
+    Matrix  [][]*float64  `json:"matrix"`  // [source][bucket] mean absolute deviation, nil without samples
+    Summary []SourceStats `json:"summary"` // per source over the whole window
+}
+
+// SourceStats summarizes a source's deviation from the aggregate
+type SourceStats struct {
+    Source string `json:"source"`
+    // Bias is the mean signed deviation; positive means the source quotes high
+    Bias float64 `json:"bias"`
+    // MeanAbsDeviation is the mean absolute deviation, a tracking error
+    MeanAbsDeviation float64 `json:"meanAbsDeviation"`
+    Samples          int     `json:"samples"`
+}
+
+// Deviations computes the heatmap of relative source deviations from each
+// round's aggregate price over [from, to) in buckets of the given width
+func Deviations(rounds []*common.AggregateResult, from, to time.Time, bucket time.Duration) *DeviationHeatmap {
+    heatmap := &DeviationHeatmap{
+        Sources: make([]string, 0),
+        Buckets: make([]time.Time, 0),
+        Matrix:  make([][]*float64, 0),
+        Summary: make([]SourceStats, 0),
+    }
+    if bucket <= 0 || !to.After(from) {
+        return heatmap
+    }
+
+    n := int((to.Sub(from) + bucket - 1) / bucket)
+    for b := 0; b < n; b++ {
+        heatmap.Buckets = append(heatmap.Buckets, from.Add(time.Duration(b)*bucket))
+    }
+
+    type accumulator struct {
+        sums   []float64
+        counts []int
+        signed float64
+        abs    float64
+        total  int
+    }
+    acc := make(map[string]*accumulator)
+
+    for _, round := range rounds {
+        if round.Price == 0 || round.Timestamp.Before(from) || !round.Timestamp.Before(to) {
+            continue
+        }
+        b := int(round.Timestamp.Sub(from) / bucket)
+        for _, source := range round.Sources {
+            a := acc[source.Source]
+            if a == nil {
+                a = &accumulator{sums: make([]float64, n), counts: make([]int, n)}
+                acc[source.Source] = a
+            }
+            d := (source.Price - round.Price) / round.Price
+            a.sums[b] += math.Abs(d)
+            a.counts[b]++
+            a.signed += d
+            a.abs += math.Abs(d)
+            a.total++
+        }
+    }
+
+    for source := range acc {
+        heatmap.Sources = append(heatmap.Sources, source)
+    }
+    sort.Strings(heatmap.Sources)
+
+    for _, source := range heatmap.Sources {
+        a := acc[source]
+        row := make([]*float64, n)
+        for b := range row {
+            if a.counts[b] > 0 {
+                mean := a.sums[b] / float64(a.counts[b])
+                row[b] = &mean
+            }
+        }
+        heatmap.Matrix = append(heatmap.Matrix, row)
+        heatmap.Summary = append(heatmap.Summary, SourceStats{
+            Source:           source,
+            Bias:             a.signed / float64(a.total),
+            MeanAbsDeviation: a.abs / float64(a.total),
+            Samples:          a.total,
+        })
+    }
+    return heatmap
+}
@@ -0,0 +1,48 @@
+package analytics
+
+import (
+    "math"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+)
+
+func TestDeviations(t *testing.T) {
+    from := time.Date(2024, 4, 13, 0, 0, 0, 0, time.UTC)
+    round := func(offset time.Duration, sources map[string]float64) *common.AggregateResult {
+        r := &common.AggregateResult{Symbol: "ETHUSDT", PricePoint: common.PricePoint{Price: 100, Timestamp: from.Add(offset)}}
+        for name, price := range sources {
+            r.Sources = append(r.Sources, common.SourcePrice{Source: name, PricePoint: common.PricePoint{Price: price}})
+        }
+        return r
+    }
+
+    rounds := []*common.AggregateResult{
+        round(10*time.Minute, map[string]float64{"binance": 101, "kraken": 100}),
+        round(20*time.Minute, map[string]float64{"binance": 103, "kraken": 100}),
+        round(70*time.Minute, map[string]float64{"binance": 99}),
+        round(200*time.Minute, map[string]float64{"binance": 150}), // outside the window
+    }
+
+    heatmap := Deviations(rounds, from, from.Add(2*time.Hour), time.Hour)
+    if len(heatmap.Sources) != 2 || heatmap.Sources[0] != "binance" {
+        t.Fatalf("Unexpected sources: %v", heatmap.Sources)
+    }
+    if len(heatmap.Buckets) != 2 {
+        t.Fatalf("Expected 2 buckets, got %d", len(heatmap.Buckets))
+    }
+
+    binance := heatmap.Matrix[0]
+    if binance[0] == nil || math.Abs(*binance[0]-0.02) > 1e-9 {
+        t.Errorf("Expected binance first-hour deviation 0.02, got %v", binance[0])
+    }
+    if kraken := heatmap.Matrix[1]; kraken[1] != nil {
+        t.Errorf("Expected no kraken samples in the second hour, got %v", *kraken[1])
+    }
+
+    summary := heatmap.Summary[0]
+    if summary.Samples != 3 || math.Abs(summary.Bias-0.01) > 1e-9 || math.Abs(summary.MeanAbsDeviation-(0.01+0.03+0.01)/3) > 1e-9 {
+        t.Errorf("Unexpected binance summary: %+v", summary)
+    }
+}
@@ -0,0 +1,305 @@
+package analytics
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "math"
+    "sort"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+    "yetaXYZ/oracle/store"
+)
+
+// Kinds of manipulation findings
+const (
+    // FindingLeadingSource is a single source repeatedly quoting a large move
+    // one round before the other sources and the aggregate follow
+    FindingLeadingSource = "leading_source"
+    // FindingVolumeSpike is a volume spike on a source just before its price
+    // was rejected as an outlier
+    FindingVolumeSpike = "volume_spike_outlier"
+)
+
+// maxExamples bounds the example timestamps kept per finding
+const maxExamples = 5
+
+// ManipulationThresholds tune the manipulation detector
+type ManipulationThresholds struct {
+    // MoveFraction is the round-to-round change of the aggregate that counts
+    // as a large move
+    MoveFraction float64
+    // MinMoves is the number of large moves a source must have quoted in
+    // before it can be flagged as leading
+    MinMoves int
+    // LeadShare is the share of those moves a source must have led alone
+    LeadShare float64
+    // VolumeSpike is the multiple of a source's median volume that counts
+    // as a spike
+    VolumeSpike float64
+}
+
+// DefaultManipulationThresholds are used for unset thresholds
+var DefaultManipulationThresholds = ManipulationThresholds{
+    MoveFraction: 0.005,
+    MinMoves:     5,
+    LeadShare:    0.5,
+    VolumeSpike:  3,
+}
+
+// Finding is one suspicious pattern of a source on a feed
+type Finding struct {
+    Kind   string `json:"kind"`
+    Symbol string `json:"symbol"`
+    Source string `json:"source"`
+    // Count is the number of occurrences within the window
+    Count int `json:"count"`
+    // Share is, for leading sources, the share of large moves led alone
+    Share    float64     `json:"share,omitempty"`
+    Detail   string      `json:"detail"`
+    Examples []time.Time `json:"examples"`
+}
+
+// ManipulationReport lists the suspicious patterns found in stored rounds
+// for security review
+type ManipulationReport struct {
+    GeneratedAt time.Time `json:"generatedAt"`
+    Window      string    `json:"window"`
+    Rounds      int       `json:"rounds"`
+    Findings    []Finding `json:"findings"`
+}
+
+// ManipulationDetector periodically analyzes stored rounds for patterns
+// suggesting price manipulation and raises an alert for each new finding
+type ManipulationDetector struct {
+    store      store.Store
+    bus        *events.Bus
+    pairs      func() map[string]*common.PairConfig
+    window     time.Duration
+    thresholds ManipulationThresholds
+
+    mu     sync.RWMutex
+    latest *ManipulationReport
+}
+
+// NewManipulationDetector creates a detector analyzing the rounds of the
+// pairs returned by pairs over window
+func NewManipulationDetector(s store.Store, bus *events.Bus, pairs func() map[string]*common.PairConfig, window time.Duration, thresholds ManipulationThresholds) *ManipulationDetector {
+    if thresholds.MoveFraction <= 0 {
+        thresholds.MoveFraction = DefaultManipulationThresholds.MoveFraction
+    }
+    if thresholds.MinMoves <= 0 {
+        thresholds.MinMoves = DefaultManipulationThresholds.MinMoves
+    }
+    if thresholds.LeadShare <= 0 {
+        thresholds.LeadShare = DefaultManipulationThresholds.LeadShare
+    }
+    if thresholds.VolumeSpike <= 0 {
+        thresholds.VolumeSpike = DefaultManipulationThresholds.VolumeSpike
+    }
+    return &ManipulationDetector{
+        store:      s,
+        bus:        bus,
+        pairs:      pairs,
+        window:     window,
+        thresholds: thresholds,
+    }
+}
+
+// Run analyzes stored rounds at interval until ctx is cancelled
+func (d *ManipulationDetector) Run(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            d.Compute(time.Now())
+        }
+    }
+}
+
+// Compute builds a report over the window ending at now and alerts on
+// findings not present in the previous report
+func (d *ManipulationDetector) Compute(now time.Time) *ManipulationReport {
+    report := &ManipulationReport{
+        GeneratedAt: now,
+        Window:      d.window.String(),
+        Findings:    make([]Finding, 0),
+    }
+
+    for symbol := range d.pairs() {
+        rounds, err := d.store.Rounds(symbol, now.Add(-d.window), now)
+        if err != nil {
+            log.Printf("Manipulation analysis of %s skipped: %v", symbol, err)
+            continue
+        }
+        report.Rounds += len(rounds)
+        report.Findings = append(report.Findings, d.leadingSources(symbol, rounds)...)
+        report.Findings = append(report.Findings, d.volumeSpikes(symbol, rounds)...)
+    }
+    sort.Slice(report.Findings, func(i, j int) bool {
+        a, b := report.Findings[i], report.Findings[j]
+        if a.Symbol != b.Symbol {
+            return a.Symbol < b.Symbol
+        }
+        if a.Kind != b.Kind {
+            return a.Kind < b.Kind
+        }
+        return a.Source < b.Source
+    })
+
+    d.mu.Lock()
+    previous := d.latest
+    d.latest = report
+    d.mu.Unlock()
+
+    known := make(map[string]bool)
+    if previous != nil {
+        for _, f := range previous.Findings {
+            known[f.Kind+"/"+f.Symbol+"/"+f.Source] = true
+        }
+    }
+    for _, f := range report.Findings {
+        if known[f.Kind+"/"+f.Symbol+"/"+f.Source] {
+            continue
+        }
+        d.bus.Publish(events.Event{
+            Type:   events.Alert,
+            Symbol: f.Symbol,
+            Payload: &events.AlertPayload{
+                Severity: events.SeverityWarning,
+                Kind:     "manipulation_suspected",
+                Message:  fmt.Sprintf("%s %s: %s", f.Source, f.Kind, f.Detail),
+            },
+        })
+    }
+    return report
+}
+
+// leadingSources flags sources that, alone among a feed's sources, already
+// quoted a large move of the aggregate in the round before it happened
+func (d *ManipulationDetector) leadingSources(symbol string, rounds []*common.AggregateResult) []Finding {
+    quoted := make(map[string]int)
+    led := make(map[string][]time.Time)
+    for i := 1; i < len(rounds); i++ {
+        prev, cur := rounds[i-1], rounds[i]
+        if prev.Price == 0 || len(prev.Sources) < 2 {
+            continue
+        }
+        move := (cur.Price - prev.Price) / prev.Price
+        if math.Abs(move) < d.thresholds.MoveFraction {
+            continue
+        }
+
+        leaders := make([]string, 0)
+        for _, s := range prev.Sources {
+            quoted[s.Source]++
+            deviation := (s.Price - prev.Price) / prev.Price
+            if deviation*move > 0 && math.Abs(deviation) >= math.Abs(move)/2 {
+                leaders = append(leaders, s.Source)
+            }
+        }
+        // A move most sources already quoted is the market, not a leader
+        if len(leaders) == 1 {
+            led[leaders[0]] = append(led[leaders[0]], cur.Timestamp)
+        }
+    }
+
+    findings := make([]Finding, 0)
+    for source, times := range led {
+        moves := quoted[source]
+        share := float64(len(times)) / float64(moves)
+        if moves < d.thresholds.MinMoves || share < d.thresholds.LeadShare {
+            continue
+        }
+        findings = append(findings, Finding{
+            Kind:     FindingLeadingSource,
+            Symbol:   symbol,
+            Source:   source,
+            Count:    len(times),
+            Share:    share,
+            Detail:   fmt.Sprintf("alone led %d of %d large moves", len(times), moves),
+            Examples: examples(times),
+        })
+    }
+    return findings
+}
+
+// volumeSpikes flags rejected outliers whose source reported a volume spike
+// in the same or the preceding round
+func (d *ManipulationDetector) volumeSpikes(symbol string, rounds []*common.AggregateResult) []Finding {
+    volumes := make(map[string][]float64)
+    for _, round := range rounds {
+        for _, s := range round.Sources {
+            if s.Volume > 0 {
+                volumes[s.Source] = append(volumes[s.Source], s.Volume)
+            }
+        }
+    }
+    baseline := make(map[string]float64, len(volumes))
+    for source, v := range volumes {
+        sort.Float64s(v)
+        baseline[source] = v[len(v)/2]
+    }
+
+    spikes := make(map[string][]time.Time)
+    peaks := make(map[string]float64)
+    for i, round := range rounds {
+        for _, r := range round.Rejected {
+            base := baseline[r.Source]
+            if base == 0 {
+                continue
+            }
+            volume := r.Volume
+            if i > 0 {
+                for _, s := range rounds[i-1].Sources {
+                    if s.Source == r.Source && s.Volume > volume {
+                        volume = s.Volume
+                    }
+                }
+            }
+            if volume/base < d.thresholds.VolumeSpike {
+                continue
+            }
+            spikes[r.Source] = append(spikes[r.Source], round.Timestamp)
+            if volume/base > peaks[r.Source] {
+                peaks[r.Source] = volume / base
+            }
+        }
+    }
+
+    findings := make([]Finding, 0)
+    for source, times := range spikes {
+        findings = append(findings, Finding{
+            Kind:     FindingVolumeSpike,
+            Symbol:   symbol,
+            Source:   source,
+            Count:    len(times),
+            Detail:   fmt.Sprintf("%d rejected outliers after volume up to %.1fx the median", len(times), peaks[source]),
+            Examples: examples(times),
+        })
+    }
+    return findings
+}
+
+// Latest returns the most recent report, or nil before the first run
+func (d *ManipulationDetector) Latest() *ManipulationReport {
+    d.mu.RLock()
+    defer d.mu.RUnlock()
+    return d.latest
+}
+
+// examples returns the most recent timestamps, newest first
+func examples(times []time.Time) []time.Time {
+    out := make([]time.Time, 0, maxExamples)
+    for i := len(times) - 1; i >= 0 && len(out) < maxExamples; i-- {
+        out = append(out, times[i])
+    }
+    return out
+}
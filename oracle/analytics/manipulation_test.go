@@ -0,0 +1,68 @@
+package analytics
+
+import (
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+    "yetaXYZ/oracle/store"
+)
+
+func quote(source string, price, volume float64) common.SourcePrice {
+    return common.SourcePrice{Source: source, PricePoint: common.PricePoint{Price: price, Volume: volume}}
+}
+
+func TestManipulationDetector(t *testing.T) {
+    s := store.NewMemoryStore()
+    start := time.Now().Add(-time.Hour)
+    save := func(i int, price float64, sources, rejected []common.SourcePrice) {
+        s.SaveRound(&common.AggregateResult{
+            Symbol:     "ETHUSDT",
+            PricePoint: common.PricePoint{Price: price, Timestamp: start.Add(time.Duration(i) * time.Minute)},
+            Sources:    sources,
+            Rejected:   rejected,
+        })
+    }
+
+    // Six times, kraken quotes a 1% jump one round before the aggregate follows
+    i := 0
+    for n := 0; n < 6; n++ {
+        save(i, 100, []common.SourcePrice{quote("binance", 100, 10), quote("coinbase", 100, 10), quote("kraken", 101, 10)}, nil)
+        save(i+1, 101, []common.SourcePrice{quote("binance", 101, 10), quote("coinbase", 101, 10), quote("kraken", 101, 10)}, nil)
+        save(i+2, 100, []common.SourcePrice{quote("binance", 100, 10), quote("coinbase", 100, 10), quote("kraken", 100, 10)}, nil)
+        i += 3
+    }
+    // A volume spike on coinbase precedes its rejection as an outlier
+    save(i, 100, []common.SourcePrice{quote("binance", 100, 10), quote("coinbase", 100, 50), quote("kraken", 100, 10)}, nil)
+    save(i+1, 100, []common.SourcePrice{quote("binance", 100, 10), quote("kraken", 100, 10)}, []common.SourcePrice{quote("coinbase", 90, 12)})
+
+    bus := events.NewBus()
+    alerts := bus.Subscribe(16, events.Alert)
+    pairs := map[string]*common.PairConfig{"ETHUSDT": {}}
+    detector := NewManipulationDetector(s, bus, func() map[string]*common.PairConfig { return pairs }, 2*time.Hour, ManipulationThresholds{})
+    report := detector.Compute(time.Now())
+
+    if len(report.Findings) != 2 {
+        t.Fatalf("Expected 2 findings, got %+v", report.Findings)
+    }
+    lead, spike := report.Findings[0], report.Findings[1]
+    if lead.Kind != FindingLeadingSource || lead.Source != "kraken" || lead.Count != 6 {
+        t.Errorf("Expected kraken to lead 6 moves, got %+v", lead)
+    }
+    if spike.Kind != FindingVolumeSpike || spike.Source != "coinbase" || spike.Count != 1 {
+        t.Errorf("Expected a coinbase volume spike, got %+v", spike)
+    }
+    if len(alerts.C) != 2 {
+        t.Errorf("Expected an alert per finding, got %d", len(alerts.C))
+    }
+
+    // Findings already reported are not alerted again
+    detector.Compute(time.Now())
+    if len(alerts.C) != 2 {
+        t.Errorf("Expected no repeated alerts, got %d", len(alerts.C))
+    }
+    if detector.Latest() == report {
+        t.Error("Expected Latest to return the newest report")
+    }
+}
@@ -0,0 +1,150 @@
+package analytics
+
+import (
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+    "yetaXYZ/oracle/store"
+)
+
+// DefaultRingSize is the number of observations kept per feed when the
+// store config does not set one
+const DefaultRingSize = 4096
+
+// ring is a fixed-size buffer of a feed's latest observations
+type ring struct {
+    samples []Sample
+    next    int // index the next observation is written to
+    full    bool
+}
+
+// Rings keep the last observations of every feed in memory, so that TWAP
+// windows and statistic feeds read recent history without a store query
+type Rings struct {
+    size int
+
+    mu    sync.RWMutex
+    feeds map[string]*ring
+}
+
+// NewRings creates ring buffers of size observations per feed
+func NewRings(size int) *Rings {
+    if size <= 0 {
+        size = DefaultRingSize
+    }
+    return &Rings{size: size, feeds: make(map[string]*ring)}
+}
+
+// Subscribe adds every completed round on the bus to its feed's ring
+func (r *Rings) Subscribe(bus *events.Bus) *events.Subscription {
+    return bus.SubscribeFunc(1024, func(e events.Event) {
+        if result, ok := e.Payload.(*common.AggregateResult); ok {
+            r.Add(result)
+        }
+    }, events.Aggregate).Named("rings")
+}
+
+// Add appends a live round to its feed's ring, overwriting the oldest
+// observation once the ring is full. Backfilled rounds, candles and rounds
+// older than the feed's latest observation are not kept.
+func (r *Rings) Add(result *common.AggregateResult) {
+    if result.Backfilled || result.Candle != nil || result.Price == 0 {
+        return
+    }
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    feed, ok := r.feeds[result.Symbol]
+    if !ok {
+        feed = &ring{samples: make([]Sample, r.size)}
+        r.feeds[result.Symbol] = feed
+    }
+    if latest, ok := feed.latest(); ok && result.Timestamp.Before(latest.Time) {
+        return
+    }
+    feed.samples[feed.next] = Sample{Time: result.Timestamp, Price: result.Price}
+    feed.next = (feed.next + 1) % len(feed.samples)
+    if feed.next == 0 {
+        feed.full = true
+    }
+}
+
+// latest returns the newest observation of the ring
+func (f *ring) latest() (Sample, bool) {
+    if !f.full && f.next == 0 {
+        return Sample{}, false
+    }
+    return f.samples[(f.next+len(f.samples)-1)%len(f.samples)], true
+}
+
+// ordered returns the observations of the ring, oldest first
+func (f *ring) ordered() []Sample {
+    if !f.full {
+        return f.samples[:f.next]
+    }
+    out := make([]Sample, 0, len(f.samples))
+    out = append(out, f.samples[f.next:]...)
+    return append(out, f.samples[:f.next]...)
+}
+
+// Window returns a feed's observations within [from, to], oldest first.
+// complete is false when the ring does not reach back to from, so older
+// observations may exist elsewhere.
+func (r *Rings) Window(symbol string, from, to time.Time) (samples []Sample, complete bool) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    feed, ok := r.feeds[symbol]
+    if !ok {
+        return nil, false
+    }
+    ordered := feed.ordered()
+    samples = make([]Sample, 0, len(ordered))
+    for _, s := range ordered {
+        if !s.Time.Before(from) && !s.Time.After(to) {
+            samples = append(samples, s)
+        }
+    }
+    return samples, len(ordered) > 0 && !ordered[0].Time.After(from)
+}
+
+// Samples returns a feed's observations within [from, to] from its ring
+// when the ring covers the span and from the store otherwise. Either may be
+// nil; without a store the ring's observations are all there is.
+func Samples(r *Rings, s store.Store, symbol string, from, to time.Time) ([]Sample, error) {
+    if r != nil {
+        if samples, complete := r.Window(symbol, from, to); complete || s == nil {
+            return samples, nil
+        }
+    }
+    if s == nil {
+        return nil, nil
+    }
+    rounds, err := s.Rounds(symbol, from, to)
+    if err != nil {
+        return nil, err
+    }
+    return SamplesFromRounds(rounds), nil
+}
+
+// RingStats describes the ring buffers
+type RingStats struct {
+    Size         int `json:"size"` // observations kept per feed
+    Feeds        int `json:"feeds"`
+    Observations int `json:"observations"`
+}
+
+// Stats reports the number of feeds and observations held
+func (r *Rings) Stats() RingStats {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    stats := RingStats{Size: r.size, Feeds: len(r.feeds)}
+    for _, feed := range r.feeds {
+        if feed.full {
+            stats.Observations += len(feed.samples)
+        } else {
+            stats.Observations += feed.next
+        }
+    }
+    return stats
+}
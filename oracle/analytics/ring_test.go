@@ -0,0 +1,61 @@
+package analytics
+
+import (
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/store"
+)
+
+func TestRingsKeepLatestObservations(t *testing.T) {
+    now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+    rings := NewRings(3)
+    for i := 0; i < 5; i++ {
+        rings.Add(&common.AggregateResult{Symbol: "ETHUSD", PricePoint: common.PricePoint{Price: float64(100 + i), Timestamp: now.Add(time.Duration(i) * time.Minute)}})
+    }
+    // Out of order and backfilled rounds are not kept
+    rings.Add(&common.AggregateResult{Symbol: "ETHUSD", PricePoint: common.PricePoint{Price: 1, Timestamp: now}})
+    rings.Add(&common.AggregateResult{Symbol: "ETHUSD", PricePoint: common.PricePoint{Price: 1, Timestamp: now.Add(time.Hour)}, Backfilled: true})
+
+    samples, complete := rings.Window("ETHUSD", now.Add(2*time.Minute), now.Add(time.Hour))
+    if !complete || len(samples) != 3 || samples[0].Price != 102 || samples[2].Price != 104 {
+        t.Errorf("Expected the last three observations, got %v (%v)", samples, complete)
+    }
+    if _, complete := rings.Window("ETHUSD", now, now.Add(time.Hour)); complete {
+        t.Error("Expected a window older than the ring to be incomplete")
+    }
+    if stats := rings.Stats(); stats.Feeds != 1 || stats.Observations != 3 {
+        t.Errorf("Expected one feed of 3 observations, got %+v", stats)
+    }
+}
+
+func TestSamplesFallBackToStore(t *testing.T) {
+    now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+    s := store.NewMemoryStore()
+    rings := NewRings(2)
+    for i := 0; i < 4; i++ {
+        result := &common.AggregateResult{Symbol: "ETHUSD", PricePoint: common.PricePoint{Price: float64(100 + i), Timestamp: now.Add(time.Duration(i) * time.Minute)}}
+        s.SaveRound(result)
+        rings.Add(result)
+    }
+
+    if samples, err := Samples(rings, s, "ETHUSD", now, now.Add(time.Hour)); err != nil || len(samples) != 4 {
+        t.Errorf("Expected the store to serve what the ring no longer holds, got %v, %v", samples, err)
+    }
+    // Without a store the ring is all there is
+    if samples, err := Samples(rings, nil, "ETHUSD", now, now.Add(time.Hour)); err != nil || len(samples) != 2 {
+        t.Errorf("Expected the ring's two observations, got %v, %v", samples, err)
+    }
+    if price, ok := TWAP(mustSamples(t, rings, now.Add(2*time.Minute)), now.Add(2*time.Minute), now.Add(4*time.Minute)); !ok || price != 102.5 {
+        t.Errorf("Expected a TWAP of 102.5 from the ring, got %v (%v)", price, ok)
+    }
+}
+
+func mustSamples(t *testing.T, rings *Rings, from time.Time) []Sample {
+    samples, complete := rings.Window("ETHUSD", from, from.Add(time.Hour))
+    if !complete {
+        t.Fatalf("Expected the ring to cover %v", from)
+    }
+    return samples
+}
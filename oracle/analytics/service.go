@@ -0,0 +1,215 @@
+package analytics
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "sort"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+    "yetaXYZ/oracle/store"
+)
+
+// defaultSampleInterval is used when a statistic does not set SampleSeconds
+const defaultSampleInterval = time.Minute
+
+// Service periodically computes statistic feeds from stored history and
+// publishes them as aggregates so they behave like any other feed
+type Service struct {
+    store   store.Store
+    bus     *events.Bus
+    configs map[string]*common.StatisticFeedConfig
+
+    // coldStart stands in for the history of pairs without enough of it
+    coldStart *ColdStart
+    // rings serve recent history ahead of the store
+    rings *Rings
+
+    mu     sync.RWMutex
+    latest map[string]*common.AggregateResult
+    rounds map[string]uint64
+}
+
+// NewService creates a statistics service for the configured statistic feeds
+func NewService(s store.Store, bus *events.Bus, configs map[string]*common.StatisticFeedConfig) *Service {
+    return &Service{
+        store:   s,
+        bus:     bus,
+        configs: configs,
+        latest:  make(map[string]*common.AggregateResult),
+        rounds:  make(map[string]uint64),
+    }
+}
+
+// SetColdStart makes volatility feeds of pairs in cold start use their
+// bootstrapped volatility until the pairs have enough history
+func (s *Service) SetColdStart(c *ColdStart) {
+    s.coldStart = c
+}
+
+// SetRings makes statistic feeds read history from in-memory rings,
+// falling back to the store for spans the rings do not cover
+func (s *Service) SetRings(r *Rings) {
+    s.rings = r
+}
+
+// Run recomputes every statistic feed at interval until ctx is cancelled
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        s.ComputeAll(time.Now())
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+// ComputeAll recomputes and publishes every statistic feed as of now
+func (s *Service) ComputeAll(now time.Time) {
+    names := make([]string, 0, len(s.configs))
+    for name := range s.configs {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    for _, name := range names {
+        result, err := s.compute(name, s.configs[name], now)
+        if err != nil {
+            log.Printf("Statistic %s not computed: %v", name, err)
+            continue
+        }
+
+        s.mu.Lock()
+        s.latest[name] = result
+        s.mu.Unlock()
+
+        s.bus.Publish(events.Event{
+            Type:    events.Aggregate,
+            Symbol:  name,
+            Payload: result,
+        })
+    }
+}
+
+// IsStatistic reports whether symbol is a statistic feed
+func (s *Service) IsStatistic(symbol string) bool {
+    return s.configs[symbol] != nil
+}
+
+// Latest returns the most recent value of a statistic feed
+func (s *Service) Latest(symbol string) (*common.AggregateResult, bool) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    result, ok := s.latest[symbol]
+    return result, ok
+}
+
+// CorrelationMatrix computes pairwise return correlations for symbols over a
+// window ending at now. Entries that cannot be computed are NaN-free nils.
+func (s *Service) CorrelationMatrix(symbols []string, window, sample time.Duration, now time.Time) ([][]*float64, error) {
+    series := make([][]float64, len(symbols))
+    for i, symbol := range symbols {
+        prices, err := s.history(symbol, window, sample, now)
+        if err != nil {
+            return nil, err
+        }
+        series[i] = prices
+    }
+
+    matrix := make([][]*float64, len(symbols))
+    for i := range symbols {
+        matrix[i] = make([]*float64, len(symbols))
+        for j := range symbols {
+            if i == j {
+                one := 1.0
+                matrix[i][j] = &one
+                continue
+            }
+            if corr, err := Correlation(series[i], series[j]); err == nil {
+                matrix[i][j] = &corr
+            }
+        }
+    }
+    return matrix, nil
+}
+
+// compute evaluates a single statistic feed
+func (s *Service) compute(name string, config *common.StatisticFeedConfig, now time.Time) (*common.AggregateResult, error) {
+    window := time.Duration(config.WindowHours) * time.Hour
+    sample := time.Duration(config.SampleSeconds) * time.Second
+    if sample <= 0 {
+        sample = defaultSampleInterval
+    }
+
+    var value float64
+    switch config.Type {
+    case common.StatisticVolatility:
+        prices, err := s.history(config.Inputs[0], window, sample, now)
+        if err != nil {
+            return nil, err
+        }
+        if value, err = RealizedVolatility(prices, sample); err != nil {
+            bootstrapped, ok := s.bootstrapped(config.Inputs[0])
+            if !ok {
+                return nil, err
+            }
+            value = bootstrapped
+        }
+    case common.StatisticCorrelation:
+        a, err := s.history(config.Inputs[0], window, sample, now)
+        if err != nil {
+            return nil, err
+        }
+        b, err := s.history(config.Inputs[1], window, sample, now)
+        if err != nil {
+            return nil, err
+        }
+        if value, err = Correlation(a, b); err != nil {
+            return nil, err
+        }
+    default:
+        return nil, fmt.Errorf("unknown statistic type %q", config.Type)
+    }
+
+    sources := make([]common.SourcePrice, 0, len(config.Inputs))
+    for _, input := range config.Inputs {
+        sources = append(sources, common.SourcePrice{Source: input})
+    }
+
+    s.mu.Lock()
+    s.rounds[name]++
+    round := s.rounds[name]
+    s.mu.Unlock()
+
+    return &common.AggregateResult{
+        Symbol:     name,
+        PricePoint: common.PricePoint{Price: value, Timestamp: now},
+        Sources:    sources,
+        RoundID:    round,
+    }, nil
+}
+
+// bootstrapped returns the cold start volatility of a pair, if any
+func (s *Service) bootstrapped(symbol string) (float64, bool) {
+    if s.coldStart == nil {
+        return 0, false
+    }
+    return s.coldStart.Volatility(symbol)
+}
+
+// history loads and resamples a feed's recent prices over the window
+func (s *Service) history(symbol string, window, sample time.Duration, now time.Time) ([]float64, error) {
+    from := now.Add(-window)
+    samples, err := Samples(s.rings, s.store, symbol, from, now)
+    if err != nil {
+        return nil, fmt.Errorf("failed to load history of %s: %v", symbol, err)
+    }
+    return Resample(samples, from, now, sample), nil
+}
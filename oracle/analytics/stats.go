@@ -0,0 +1,126 @@
+package analytics
+
+import (
+    "fmt"
+    "math"
+    "time"
+
+    "yetaXYZ/oracle/common"
+)
+
+// secondsPerYear is used to annualize volatility
+const secondsPerYear = 365 * 24 * 60 * 60
+
+// Sample is a single observation of a feed
+type Sample struct {
+    Time  time.Time
+    Price float64
+}
+
+// SamplesFromRounds converts stored rounds into samples
+func SamplesFromRounds(rounds []*common.AggregateResult) []Sample {
+    samples := make([]Sample, 0, len(rounds))
+    for _, r := range rounds {
+        samples = append(samples, Sample{Time: r.Timestamp, Price: r.Price})
+    }
+    return samples
+}
+
+// Resample buckets samples into fixed intervals starting at from, keeping the
+// last observation of each bucket and carrying it forward over empty buckets
+func Resample(samples []Sample, from, to time.Time, interval time.Duration) []float64 {
+    if interval <= 0 || !to.After(from) {
+        return nil
+    }
+
+    buckets := int(to.Sub(from) / interval)
+    out := make([]float64, 0, buckets)
+    i := 0
+    last := math.NaN()
+    for b := 1; b <= buckets; b++ {
+        end := from.Add(time.Duration(b) * interval)
+        for i < len(samples) && !samples[i].Time.After(end) {
+            last = samples[i].Price
+            i++
+        }
+        // Buckets before the first observation are skipped
+        if !math.IsNaN(last) {
+            out = append(out, last)
+        }
+    }
+    return out
+}
+
+// LogReturns returns the log returns between consecutive prices
+func LogReturns(prices []float64) []float64 {
+    if len(prices) < 2 {
+        return nil
+    }
+    returns := make([]float64, 0, len(prices)-1)
+    for i := 1; i < len(prices); i++ {
+        if prices[i-1] <= 0 || prices[i] <= 0 {
+            continue
+        }
+        returns = append(returns, math.Log(prices[i]/prices[i-1]))
+    }
+    return returns
+}
+
+// RealizedVolatility returns the annualized standard deviation of log
+// returns sampled every interval
+func RealizedVolatility(prices []float64, interval time.Duration) (float64, error) {
+    returns := LogReturns(prices)
+    if len(returns) < 2 {
+        return 0, fmt.Errorf("not enough history: %d returns", len(returns))
+    }
+
+    mean := 0.0
+    for _, r := range returns {
+        mean += r
+    }
+    mean /= float64(len(returns))
+
+    variance := 0.0
+    for _, r := range returns {
+        variance += (r - mean) * (r - mean)
+    }
+    variance /= float64(len(returns) - 1)
+
+    periodsPerYear := secondsPerYear / interval.Seconds()
+    return math.Sqrt(variance * periodsPerYear), nil
+}
+
+// Correlation returns the Pearson correlation of two equally sampled series'
+// log returns
+func Correlation(a, b []float64) (float64, error) {
+    n := len(a)
+    if len(b) < n {
+        n = len(b)
+    }
+    // Align the series on their most recent samples
+    ra := LogReturns(a[len(a)-n:])
+    rb := LogReturns(b[len(b)-n:])
+    if len(ra) != len(rb) || len(ra) < 2 {
+        return 0, fmt.Errorf("not enough overlapping history: %d returns", len(ra))
+    }
+
+    meanA, meanB := 0.0, 0.0
+    for i := range ra {
+        meanA += ra[i]
+        meanB += rb[i]
+    }
+    meanA /= float64(len(ra))
+    meanB /= float64(len(rb))
+
+    cov, varA, varB := 0.0, 0.0, 0.0
+    for i := range ra {
+        da, db := ra[i]-meanA, rb[i]-meanB
+        cov += da * db
+        varA += da * da
+        varB += db * db
+    }
+    if varA == 0 || varB == 0 {
+        return 0, fmt.Errorf("series has no variance")
+    }
+    return cov / math.Sqrt(varA*varB), nil
+}
@@ -0,0 +1,65 @@
+package analytics
+
+import (
+    "math"
+    "testing"
+    "time"
+)
+
+func TestResampleCarriesForward(t *testing.T) {
+    from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+    samples := []Sample{
+        {Time: from.Add(90 * time.Second), Price: 101},
+        {Time: from.Add(100 * time.Second), Price: 102},
+        {Time: from.Add(250 * time.Second), Price: 103},
+    }
+
+    got := Resample(samples, from, from.Add(5*time.Minute), time.Minute)
+    want := []float64{102, 102, 102, 103}
+    if len(got) != len(want) {
+        t.Fatalf("Expected %v, got %v", want, got)
+    }
+    for i := range want {
+        if got[i] != want[i] {
+            t.Errorf("Bucket %d: expected %f, got %f", i, want[i], got[i])
+        }
+    }
+}
+
+func TestRealizedVolatility(t *testing.T) {
+    // Alternating +1%/-1% daily moves
+    prices := []float64{100}
+    for i := 0; i < 20; i++ {
+        factor := 1.01
+        if i%2 == 1 {
+            factor = 1 / 1.01
+        }
+        prices = append(prices, prices[len(prices)-1]*factor)
+    }
+
+    vol, err := RealizedVolatility(prices, 24*time.Hour)
+    if err != nil {
+        t.Fatalf("Failed to compute volatility: %v", err)
+    }
+    expected := math.Log(1.01) * math.Sqrt(20.0/19.0) * math.Sqrt(365)
+    if math.Abs(vol-expected) > 1e-9 {
+        t.Errorf("Expected volatility %f, got %f", expected, vol)
+    }
+}
+
+func TestCorrelation(t *testing.T) {
+    a := []float64{100, 101, 99, 102, 100, 103}
+    b := make([]float64, len(a))
+    inverse := make([]float64, len(a))
+    for i, v := range a {
+        b[i] = v * 2
+        inverse[i] = 10000 / v
+    }
+
+    if corr, err := Correlation(a, b); err != nil || math.Abs(corr-1) > 1e-9 {
+        t.Errorf("Expected correlation 1, got %f (%v)", corr, err)
+    }
+    if corr, err := Correlation(a, inverse); err != nil || math.Abs(corr+1) > 1e-9 {
+        t.Errorf("Expected correlation -1, got %f (%v)", corr, err)
+    }
+}
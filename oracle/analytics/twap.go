@@ -0,0 +1,149 @@
+package analytics
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/store"
+)
+
+// WindowSpot names the latest round among price windows
+const WindowSpot = "spot"
+
+// maxWindow bounds TWAP windows to what the store keeps at useful resolution
+const maxWindow = 7 * 24 * time.Hour
+
+// Window is a named time window of a feed; Duration is zero for spot
+type Window struct {
+    Name     string
+    Duration time.Duration
+}
+
+// WindowPrice is the price of a feed over a window
+type WindowPrice struct {
+    Price  float64   `json:"price"`
+    Rounds int       `json:"rounds"`
+    From   time.Time `json:"from"`
+    To     time.Time `json:"to"`
+}
+
+// ParseWindows parses a comma-separated window list such as "spot,1m,1h".
+// Durations use Go syntax plus days ("7d").
+func ParseWindows(s string) ([]Window, error) {
+    windows := make([]Window, 0)
+    seen := make(map[string]bool)
+    for _, name := range strings.Split(s, ",") {
+        name = strings.TrimSpace(name)
+        if name == "" || seen[name] {
+            continue
+        }
+        seen[name] = true
+        if name == WindowSpot {
+            windows = append(windows, Window{Name: name})
+            continue
+        }
+
+        d, err := parseWindowDuration(name)
+        if err != nil {
+            return nil, err
+        }
+        if d <= 0 || d > maxWindow {
+            return nil, fmt.Errorf("window %s must be positive and at most %s", name, maxWindow)
+        }
+        windows = append(windows, Window{Name: name, Duration: d})
+    }
+    if len(windows) == 0 {
+        return nil, fmt.Errorf("no windows given")
+    }
+    return windows, nil
+}
+
+// parseWindowDuration parses a Go duration or a number of days
+func parseWindowDuration(name string) (time.Duration, error) {
+    if days := strings.TrimSuffix(name, "d"); days != name {
+        n, err := strconv.Atoi(days)
+        if err != nil {
+            return 0, fmt.Errorf("invalid window %s", name)
+        }
+        return time.Duration(n) * 24 * time.Hour, nil
+    }
+    d, err := time.ParseDuration(name)
+    if err != nil {
+        return 0, fmt.Errorf("invalid window %s", name)
+    }
+    return d, nil
+}
+
+// TWAP returns the time-weighted average of samples within [from, to]. Each
+// price holds until the next sample, the last one until to; time before the
+// first sample is not covered. ok is false without samples.
+func TWAP(samples []Sample, from, to time.Time) (price float64, ok bool) {
+    var weighted, total float64
+    for i, s := range samples {
+        if s.Time.Before(from) || s.Time.After(to) {
+            continue
+        }
+        end := to
+        if i+1 < len(samples) && samples[i+1].Time.Before(to) {
+            end = samples[i+1].Time
+        }
+        d := end.Sub(s.Time).Seconds()
+        weighted += s.Price * d
+        total += d
+        price, ok = s.Price, true
+    }
+    // A single sample at to, or samples sharing one instant, carry no time
+    if total > 0 {
+        price = weighted / total
+    }
+    return price, ok
+}
+
+// Windows computes the price of a feed over each window from its recent
+// rounds, read from the rings or the store; spot is the given latest round.
+// Windows without rounds are left out.
+func Windows(r *Rings, s store.Store, symbol string, spot *common.AggregateResult, windows []Window, now time.Time) (map[string]*WindowPrice, error) {
+    var longest time.Duration
+    for _, w := range windows {
+        if w.Duration > longest {
+            longest = w.Duration
+        }
+    }
+    var samples []Sample
+    if longest > 0 {
+        var err error
+        if samples, err = Samples(r, s, symbol, now.Add(-longest), now); err != nil {
+            return nil, fmt.Errorf("failed to load rounds: %v", err)
+        }
+    }
+
+    out := make(map[string]*WindowPrice, len(windows))
+    for _, w := range windows {
+        if w.Duration == 0 {
+            if spot != nil {
+                out[w.Name] = &WindowPrice{Price: spot.Price, Rounds: 1, From: spot.Timestamp, To: spot.Timestamp}
+            }
+            continue
+        }
+
+        from := now.Add(-w.Duration)
+        price, ok := TWAP(samples, from, now)
+        if !ok {
+            continue
+        }
+        wp := &WindowPrice{Price: price, To: now}
+        for _, sample := range samples {
+            if !sample.Time.Before(from) {
+                if wp.Rounds == 0 {
+                    wp.From = sample.Time
+                }
+                wp.Rounds++
+            }
+        }
+        out[w.Name] = wp
+    }
+    return out, nil
+}
@@ -0,0 +1,48 @@
+package analytics
+
+import (
+    "math"
+    "testing"
+    "time"
+)
+
+func TestTWAP(t *testing.T) {
+    now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+    samples := []Sample{
+        {Time: now.Add(-90 * time.Second), Price: 50},
+        {Time: now.Add(-60 * time.Second), Price: 100},
+        {Time: now.Add(-15 * time.Second), Price: 200},
+    }
+
+    // 45s at 100 and 15s at 200 over the last minute
+    price, ok := TWAP(samples, now.Add(-time.Minute), now)
+    if !ok || math.Abs(price-125) > 1e-9 {
+        t.Errorf("Expected TWAP 125, got %v (%v)", price, ok)
+    }
+
+    if _, ok := TWAP(samples, now.Add(-10*time.Second), now); ok {
+        t.Error("Expected no TWAP without samples in the window")
+    }
+}
+
+func TestParseWindows(t *testing.T) {
+    windows, err := ParseWindows("spot,1m,1h,1d,1m")
+    if err != nil {
+        t.Fatalf("Failed to parse windows: %v", err)
+    }
+    want := []Window{{WindowSpot, 0}, {"1m", time.Minute}, {"1h", time.Hour}, {"1d", 24 * time.Hour}}
+    if len(windows) != len(want) {
+        t.Fatalf("Expected %v, got %v", want, windows)
+    }
+    for i := range want {
+        if windows[i] != want[i] {
+            t.Errorf("Expected %v, got %v", want[i], windows[i])
+        }
+    }
+
+    for _, invalid := range []string{"", "1x", "-1m", "30d"} {
+        if _, err := ParseWindows(invalid); err == nil {
+            t.Errorf("Expected error for %q, got nil", invalid)
+        }
+    }
+}
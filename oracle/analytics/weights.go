@@ -0,0 +1,168 @@
+package analytics
+
+import (
+    "context"
+    "log"
+    "math"
+    "sort"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/store"
+)
+
+// trackingErrorFloor keeps near-perfect sources from receiving unbounded
+// weight; deviations below one basis point are treated as one basis point
+const trackingErrorFloor = 0.0001
+
+// WeightChange is a proposed change to one source weight
+type WeightChange struct {
+    Source           string  `json:"source"`
+    Current          float64 `json:"current"`
+    Suggested        float64 `json:"suggested"`
+    MeanAbsDeviation float64 `json:"meanAbsDeviation"`
+    Samples          int     `json:"samples"`
+}
+
+// PairSuggestion proposes new source weights for a pair. SourceWeights is
+// the complete suggested value of the pair's sourceWeights setting.
+type PairSuggestion struct {
+    Rounds        int                `json:"rounds"`
+    Changes       []WeightChange     `json:"changes"`
+    SourceWeights map[string]float64 `json:"sourceWeights"`
+}
+
+// WeightSuggestions is a suggested config diff for operator review
+type WeightSuggestions struct {
+    GeneratedAt time.Time                  `json:"generatedAt"`
+    Window      string                     `json:"window"`
+    Pairs       map[string]*PairSuggestion `json:"pairs"`
+}
+
+// SuggestWeights proposes source weights inversely proportional to each
+// source's tracking error against the final price, normalized to a mean
+// of 1 so that unlisted sources keep their default weight of 1. Sources
+// with fewer than minSamples observations are left out.
+func SuggestWeights(stats []SourceStats, minSamples int) map[string]float64 {
+    raw := make(map[string]float64)
+    total := 0.0
+    for _, s := range stats {
+        if s.Samples < minSamples {
+            continue
+        }
+        w := 1 / math.Max(s.MeanAbsDeviation, trackingErrorFloor)
+        raw[s.Source] = w
+        total += w
+    }
+    if len(raw) == 0 {
+        return raw
+    }
+
+    mean := total / float64(len(raw))
+    for source, w := range raw {
+        raw[source] = math.Round(w/mean*100) / 100
+    }
+    return raw
+}
+
+// WeightAdvisor periodically benchmarks sources against stored rounds and
+// keeps the latest weight suggestions. It never changes the live config.
+type WeightAdvisor struct {
+    store      store.Store
+    pairs      func() map[string]*common.PairConfig
+    window     time.Duration
+    minSamples int
+
+    mu     sync.RWMutex
+    latest *WeightSuggestions
+}
+
+// NewWeightAdvisor creates an advisor benchmarking sources over window.
+// pairs returns the current pair configuration to compare against.
+func NewWeightAdvisor(s store.Store, pairs func() map[string]*common.PairConfig, window time.Duration, minSamples int) *WeightAdvisor {
+    return &WeightAdvisor{
+        store:      s,
+        pairs:      pairs,
+        window:     window,
+        minSamples: minSamples,
+    }
+}
+
+// Run recomputes suggestions at interval until ctx is cancelled
+func (a *WeightAdvisor) Run(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            a.Compute(time.Now())
+        }
+    }
+}
+
+// Compute benchmarks every pair's sources over the window ending at now
+func (a *WeightAdvisor) Compute(now time.Time) *WeightSuggestions {
+    suggestions := &WeightSuggestions{
+        GeneratedAt: now,
+        Window:      a.window.String(),
+        Pairs:       make(map[string]*PairSuggestion),
+    }
+
+    from := now.Add(-a.window)
+    for symbol, pair := range a.pairs() {
+        rounds, err := a.store.Rounds(symbol, from, now)
+        if err != nil {
+            log.Printf("Weight benchmark of %s skipped: %v", symbol, err)
+            continue
+        }
+        if len(rounds) == 0 {
+            continue
+        }
+
+        stats := Deviations(rounds, from, now, a.window).Summary
+        weights := SuggestWeights(stats, a.minSamples)
+        if len(weights) == 0 {
+            continue
+        }
+
+        suggestion := &PairSuggestion{Rounds: len(rounds), SourceWeights: weights, Changes: make([]WeightChange, 0)}
+        for _, s := range stats {
+            suggested, ok := weights[s.Source]
+            if !ok {
+                continue
+            }
+            current := 1.0
+            if w, ok := pair.SourceWeights[s.Source]; ok {
+                current = w
+            }
+            if current == suggested {
+                continue
+            }
+            suggestion.Changes = append(suggestion.Changes, WeightChange{
+                Source:           s.Source,
+                Current:          current,
+                Suggested:        suggested,
+                MeanAbsDeviation: s.MeanAbsDeviation,
+                Samples:          s.Samples,
+            })
+        }
+        sort.Slice(suggestion.Changes, func(i, j int) bool { return suggestion.Changes[i].Source < suggestion.Changes[j].Source })
+        suggestions.Pairs[symbol] = suggestion
+    }
+
+    a.mu.Lock()
+    a.latest = suggestions
+    a.mu.Unlock()
+    return suggestions
+}
+
+// Latest returns the most recent suggestions, or nil before the first run
+func (a *WeightAdvisor) Latest() *WeightSuggestions {
+    a.mu.RLock()
+    defer a.mu.RUnlock()
+    return a.latest
+}
@@ -0,0 +1,62 @@
+package analytics
+
+import (
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/store"
+)
+
+func TestSuggestWeights(t *testing.T) {
+    weights := SuggestWeights([]SourceStats{
+        {Source: "binance", MeanAbsDeviation: 0.001, Samples: 100},
+        {Source: "kraken", MeanAbsDeviation: 0.003, Samples: 100},
+        {Source: "newcomer", MeanAbsDeviation: 0.0001, Samples: 3},
+    }, 10)
+
+    if _, ok := weights["newcomer"]; ok {
+        t.Error("Expected sources below the sample minimum to be left out")
+    }
+    if weights["binance"] != 1.5 || weights["kraken"] != 0.5 {
+        t.Errorf("Expected weights 1.5 and 0.5, got %v", weights)
+    }
+}
+
+func TestWeightAdvisor(t *testing.T) {
+    s := store.NewMemoryStore()
+    now := time.Now()
+    for i := 0; i < 20; i++ {
+        s.SaveRound(&common.AggregateResult{
+            Symbol:     "ETHUSDT",
+            PricePoint: common.PricePoint{Price: 100, Timestamp: now.Add(-time.Duration(i) * time.Minute)},
+            Sources: []common.SourcePrice{
+                {Source: "binance", PricePoint: common.PricePoint{Price: 100.1}},
+                {Source: "kraken", PricePoint: common.PricePoint{Price: 100.3}},
+            },
+        })
+    }
+
+    pairs := map[string]*common.PairConfig{
+        "ETHUSDT": {SourceWeights: map[string]float64{"binance": 1.5}},
+        "BTCUSDT": {},
+    }
+    advisor := NewWeightAdvisor(s, func() map[string]*common.PairConfig { return pairs }, time.Hour, 10)
+    suggestions := advisor.Compute(now.Add(time.Second))
+
+    eth := suggestions.Pairs["ETHUSDT"]
+    if eth == nil {
+        t.Fatal("Expected a suggestion for ETHUSDT")
+    }
+    if _, ok := suggestions.Pairs["BTCUSDT"]; ok {
+        t.Error("Expected no suggestion for a pair without history")
+    }
+
+    // binance already has the suggested weight, so only kraken changes
+    if len(eth.Changes) != 1 || eth.Changes[0].Source != "kraken" || eth.Changes[0].Current != 1 || eth.Changes[0].Suggested != 0.5 {
+        t.Errorf("Unexpected changes: %+v", eth.Changes)
+    }
+    if advisor.Latest() != suggestions {
+        t.Error("Expected Latest to return the computed suggestions")
+    }
+}
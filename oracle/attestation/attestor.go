@@ -0,0 +1,480 @@
+package attestation
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "log"
+    "math/big"
+    "os"
+    "sort"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/events"
+    "yetaXYZ/oracle/fetch"
+)
+
+// Attestation statuses
+const (
+    StatusOpen      = "open"      // the question has not closed
+    StatusResolving = "resolving" // closed, waiting for a quorum of resolvers
+    StatusProposed  = "proposed"  // a quorum agreed; disputable until finalizesAt
+    StatusDisputed  = "disputed"  // an operator disputed the proposed outcome
+    StatusFinal     = "final"     // the outcome can no longer change
+)
+
+// resolveTimeout bounds one resolver call
+const resolveTimeout = 15 * time.Second
+
+// Vote is a resolver's latest answer
+type Vote struct {
+    Outcome string    `json:"outcome,omitempty"`
+    Error   string    `json:"error,omitempty"`
+    At      time.Time `json:"at"`
+}
+
+// Dispute is an operator's objection to a proposed outcome
+type Dispute struct {
+    Operator string    `json:"operator"`
+    Reason   string    `json:"reason"`
+    At       time.Time `json:"at"`
+}
+
+// Attestation is the state of a question's resolution
+type Attestation struct {
+    ID          string          `json:"id"`
+    Description string          `json:"description"`
+    Outcomes    []string        `json:"outcomes"`
+    ClosesAt    time.Time       `json:"closesAt"`
+    Quorum      int             `json:"quorum"`
+    Status      string          `json:"status"`
+    Votes       map[string]Vote `json:"votes,omitempty"`
+    Outcome     string          `json:"outcome,omitempty"`
+    ProposedAt  time.Time       `json:"proposedAt,omitempty"`
+    FinalizesAt time.Time       `json:"finalizesAt,omitempty"`
+    Dispute     *Dispute        `json:"dispute,omitempty"`
+    // SettledBy is the operator who settled a dispute
+    SettledBy string    `json:"settledBy,omitempty"`
+    FinalAt   time.Time `json:"finalAt,omitempty"`
+    TxHash    string    `json:"txHash,omitempty"`
+}
+
+// Submitter publishes values on-chain, as publish.Publisher does
+type Submitter interface {
+    Submit(ctx context.Context, symbol string, roundID uint64, value *big.Int) (txHash string, err error)
+}
+
+// Attestor resolves configured questions through their resolvers
+type Attestor struct {
+    config    *Config
+    bus       *events.Bus
+    questions map[string]*Question
+    resolvers map[string]map[string]Resolver // question -> resolver name -> resolver
+    submitter Submitter
+
+    mu           sync.Mutex
+    attestations map[string]*Attestation
+}
+
+// NewAttestor creates an attestor, restoring state from the state file
+func NewAttestor(config *Config, rounds FeedLookup, bus *events.Bus) (*Attestor, error) {
+    a := &Attestor{
+        config:       config,
+        bus:          bus,
+        questions:    make(map[string]*Question),
+        resolvers:    make(map[string]map[string]Resolver),
+        attestations: make(map[string]*Attestation),
+    }
+    deps := Dependencies{Client: fetch.NewClient(resolveTimeout), Rounds: rounds}
+    for i := range config.Questions {
+        q := &config.Questions[i]
+        a.questions[q.ID] = q
+        a.resolvers[q.ID] = make(map[string]Resolver)
+        for _, rc := range q.Resolvers {
+            resolver, err := newResolver(rc, deps)
+            if err != nil {
+                return nil, fmt.Errorf("question %s: %v", q.ID, err)
+            }
+            a.resolvers[q.ID][rc.Name] = resolver
+        }
+        a.attestations[q.ID] = &Attestation{
+            ID:          q.ID,
+            Description: q.Description,
+            Outcomes:    q.Outcomes,
+            ClosesAt:    q.ClosesAt,
+            Quorum:      q.Quorum,
+            Status:      StatusOpen,
+        }
+    }
+    if err := a.load(); err != nil {
+        return nil, err
+    }
+    return a, nil
+}
+
+// SetSubmitter publishes final outcomes of questions marked publish
+func (a *Attestor) SetSubmitter(submitter Submitter) {
+    a.submitter = submitter
+}
+
+// Interval returns the resolution interval
+func (a *Attestor) Interval() time.Duration {
+    return a.config.Interval()
+}
+
+// Run resolves questions every interval until ctx is cancelled
+func (a *Attestor) Run(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        a.Tick(ctx, time.Now())
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+// Tick polls the resolvers of closed questions, proposes outcomes that
+// reach a quorum and finalizes undisputed ones past their window
+func (a *Attestor) Tick(ctx context.Context, now time.Time) {
+    for _, id := range a.ids() {
+        q := a.questions[id]
+        a.mu.Lock()
+        status := a.attestations[id].Status
+        a.mu.Unlock()
+
+        switch status {
+        case StatusOpen, StatusResolving:
+            if now.Before(q.ClosesAt) {
+                continue
+            }
+            votes := a.poll(ctx, q, now)
+            a.mu.Lock()
+            att := a.attestations[id]
+            att.Status = StatusResolving
+            att.Votes = votes
+            if outcome, ok := quorum(votes, q.Quorum); ok {
+                att.Status = StatusProposed
+                att.Outcome = outcome
+                att.ProposedAt = now
+                att.FinalizesAt = now.Add(q.DisputeWindow())
+                a.alert(id, events.SeverityInfo, "attestation_proposed", fmt.Sprintf("outcome %s, disputable until %s", outcome, att.FinalizesAt.Format(time.RFC3339)))
+            }
+            if err := a.persistLocked(); err != nil {
+                log.Printf("Error persisting attestations: %v", err)
+            }
+            a.mu.Unlock()
+        case StatusProposed:
+            a.mu.Lock()
+            att := a.attestations[id]
+            finalized := att.Status == StatusProposed && !now.Before(att.FinalizesAt)
+            if finalized {
+                a.finalizeLocked(att, now)
+            }
+            a.mu.Unlock()
+            if finalized {
+                a.publish(ctx, q)
+            }
+        }
+    }
+}
+
+// Dispute moves a proposed outcome to disputed within its window; disputed
+// outcomes are only final once an operator settles them
+func (a *Attestor) Dispute(operator, id, reason string, now time.Time) (*Attestation, error) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    att, err := a.disputable(id, reason, now)
+    if err != nil {
+        return nil, err
+    }
+    att.Status = StatusDisputed
+    att.Dispute = &Dispute{Operator: operator, Reason: reason, At: now}
+    a.alert(id, events.SeverityCritical, "attestation_disputed", fmt.Sprintf("%s disputed outcome %s: %s", operator, att.Outcome, reason))
+    if err := a.persistLocked(); err != nil {
+        return nil, err
+    }
+    return snapshot(att), nil
+}
+
+// PreviewDispute returns the attestation as Dispute would leave it, without
+// disputing it or raising an alert
+func (a *Attestor) PreviewDispute(operator, id, reason string, now time.Time) (*Attestation, error) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    att, err := a.disputable(id, reason, now)
+    if err != nil {
+        return nil, err
+    }
+    out := snapshot(att)
+    out.Status = StatusDisputed
+    out.Dispute = &Dispute{Operator: operator, Reason: reason, At: now}
+    return out, nil
+}
+
+// disputable returns a question's attestation if its outcome is open to
+// dispute; callers hold mu
+func (a *Attestor) disputable(id, reason string, now time.Time) (*Attestation, error) {
+    att, ok := a.attestations[id]
+    if !ok {
+        return nil, fmt.Errorf("unknown question %s", id)
+    }
+    if att.Status != StatusProposed || !now.Before(att.FinalizesAt) {
+        return nil, fmt.Errorf("question %s has no outcome open to dispute", id)
+    }
+    if reason == "" {
+        return nil, fmt.Errorf("a dispute requires a reason")
+    }
+    return att, nil
+}
+
+// Settle finalizes a disputed question with the outcome an operator decided
+func (a *Attestor) Settle(ctx context.Context, operator, id, outcome string, now time.Time) (*Attestation, error) {
+    a.mu.Lock()
+    att, err := a.settleable(id, outcome)
+    if err != nil {
+        a.mu.Unlock()
+        return nil, err
+    }
+    att.Outcome = outcome
+    att.SettledBy = operator
+    a.finalizeLocked(att, now)
+    a.mu.Unlock()
+
+    a.publish(ctx, a.questions[id])
+    return a.Get(id)
+}
+
+// PreviewSettle returns the attestation as Settle would leave it, without
+// finalizing or publishing it
+func (a *Attestor) PreviewSettle(operator, id, outcome string, now time.Time) (*Attestation, error) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    att, err := a.settleable(id, outcome)
+    if err != nil {
+        return nil, err
+    }
+    out := snapshot(att)
+    out.Outcome = outcome
+    out.SettledBy = operator
+    out.Status = StatusFinal
+    out.FinalAt = now
+    return out, nil
+}
+
+// settleable returns a disputed question's attestation if outcome is one
+// of its outcomes; callers hold mu
+func (a *Attestor) settleable(id, outcome string) (*Attestation, error) {
+    att, ok := a.attestations[id]
+    if !ok {
+        return nil, fmt.Errorf("unknown question %s", id)
+    }
+    if att.Status != StatusDisputed {
+        return nil, fmt.Errorf("question %s is not disputed", id)
+    }
+    if !a.questions[id].hasOutcome(outcome) {
+        return nil, fmt.Errorf("unknown outcome %q", outcome)
+    }
+    return att, nil
+}
+
+// Get returns a question's attestation
+func (a *Attestor) Get(id string) (*Attestation, error) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    att, ok := a.attestations[id]
+    if !ok {
+        return nil, fmt.Errorf("unknown question %s", id)
+    }
+    return snapshot(att), nil
+}
+
+// List returns every attestation ordered by closing time
+func (a *Attestor) List() []*Attestation {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    list := make([]*Attestation, 0, len(a.attestations))
+    for _, att := range a.attestations {
+        list = append(list, snapshot(att))
+    }
+    sort.Slice(list, func(i, j int) bool {
+        if !list[i].ClosesAt.Equal(list[j].ClosesAt) {
+            return list[i].ClosesAt.Before(list[j].ClosesAt)
+        }
+        return list[i].ID < list[j].ID
+    })
+    return list
+}
+
+// Feeds returns the feeds read by a question's feed resolvers
+func (a *Attestor) Feeds(id string) []string {
+    q, ok := a.questions[id]
+    if !ok {
+        return nil
+    }
+    feeds := make([]string, 0)
+    for _, resolver := range q.Resolvers {
+        if resolver.Feed != "" {
+            feeds = append(feeds, resolver.Feed)
+        }
+    }
+    return feeds
+}
+
+// poll asks every resolver of a question for its answer
+func (a *Attestor) poll(ctx context.Context, q *Question, now time.Time) map[string]Vote {
+    votes := make(map[string]Vote, len(q.Resolvers))
+    for name, resolver := range a.resolvers[q.ID] {
+        callCtx, cancel := context.WithTimeout(ctx, resolveTimeout)
+        outcome, err := resolver.Resolve(callCtx, q, now)
+        cancel()
+        vote := Vote{At: now}
+        switch {
+        case err != nil:
+            vote.Error = err.Error()
+        case outcome != "" && !q.hasOutcome(outcome):
+            vote.Error = fmt.Sprintf("unknown outcome %q", outcome)
+        default:
+            vote.Outcome = outcome
+        }
+        votes[name] = vote
+    }
+    return votes
+}
+
+// quorum returns the outcome at least n votes agree on
+func quorum(votes map[string]Vote, n int) (string, bool) {
+    counts := make(map[string]int)
+    for _, vote := range votes {
+        if vote.Outcome != "" {
+            counts[vote.Outcome]++
+        }
+    }
+    for outcome, count := range counts {
+        if count >= n {
+            return outcome, true
+        }
+    }
+    return "", false
+}
+
+// finalizeLocked makes an attestation's outcome final; callers hold mu
+func (a *Attestor) finalizeLocked(att *Attestation, now time.Time) {
+    att.Status = StatusFinal
+    att.FinalAt = now
+    a.alert(att.ID, events.SeverityInfo, "attestation_final", fmt.Sprintf("outcome %s", att.Outcome))
+    if err := a.persistLocked(); err != nil {
+        log.Printf("Error persisting attestations: %v", err)
+    }
+}
+
+// publish submits a final outcome on-chain as its 1-based index
+func (a *Attestor) publish(ctx context.Context, q *Question) {
+    if a.submitter == nil || !q.Publish {
+        return
+    }
+    a.mu.Lock()
+    att := a.attestations[q.ID]
+    index := q.outcomeIndex(att.Outcome)
+    a.mu.Unlock()
+
+    txHash, err := a.submitter.Submit(ctx, q.ID, 1, big.NewInt(int64(index+1)))
+    if err != nil {
+        log.Printf("Error publishing attestation %s: %v", q.ID, err)
+        return
+    }
+    a.mu.Lock()
+    att.TxHash = txHash
+    if err := a.persistLocked(); err != nil {
+        log.Printf("Error persisting attestations: %v", err)
+    }
+    a.mu.Unlock()
+}
+
+// alert publishes an attestation alert
+func (a *Attestor) alert(id, severity, kind, message string) {
+    a.bus.Publish(events.Event{
+        Type:      events.Alert,
+        Symbol:    id,
+        Timestamp: time.Now(),
+        Payload:   &events.AlertPayload{Severity: severity, Kind: kind, Message: message},
+    })
+}
+
+// ids returns the question IDs in a stable order
+func (a *Attestor) ids() []string {
+    ids := make([]string, 0, len(a.questions))
+    for id := range a.questions {
+        ids = append(ids, id)
+    }
+    sort.Strings(ids)
+    return ids
+}
+
+// snapshot returns a copy of an attestation safe to hand out
+func snapshot(att *Attestation) *Attestation {
+    out := *att
+    out.Votes = make(map[string]Vote, len(att.Votes))
+    for name, vote := range att.Votes {
+        out.Votes[name] = vote
+    }
+    if att.Dispute != nil {
+        dispute := *att.Dispute
+        out.Dispute = &dispute
+    }
+    return &out
+}
+
+// load restores the state of configured questions from the state file.
+// State of questions no longer configured is dropped.
+func (a *Attestor) load() error {
+    if a.config.StateFile == "" {
+        return nil
+    }
+    data, err := os.ReadFile(a.config.StateFile)
+    if os.IsNotExist(err) {
+        return nil
+    }
+    if err != nil {
+        return fmt.Errorf("failed to read attestations: %v", err)
+    }
+    var stored []*Attestation
+    if err := json.Unmarshal(data, &stored); err != nil {
+        return fmt.Errorf("failed to parse attestations: %v", err)
+    }
+    for _, att := range stored {
+        q, ok := a.questions[att.ID]
+        if !ok {
+            continue
+        }
+        // The configuration may have changed since; keep it authoritative
+        att.Description, att.Outcomes, att.ClosesAt, att.Quorum = q.Description, q.Outcomes, q.ClosesAt, q.Quorum
+        a.attestations[att.ID] = att
+    }
+    return nil
+}
+
+// persistLocked writes every attestation to the state file, if any;
+// callers hold mu
+func (a *Attestor) persistLocked() error {
+    if a.config.StateFile == "" {
+        return nil
+    }
+    all := make([]*Attestation, 0, len(a.attestations))
+    for _, id := range a.ids() {
+        all = append(all, a.attestations[id])
+    }
+    data, err := json.MarshalIndent(all, "", "    ")
+    if err != nil {
+        return err
+    }
+    tmp := a.config.StateFile + ".tmp"
+    if err := os.WriteFile(tmp, data, 0600); err != nil {
+        return fmt.Errorf("failed to write attestations: %v", err)
+    }
+    return os.Rename(tmp, a.config.StateFile)
+}
@@ -0,0 +1,158 @@
+package attestation
+
+import (
+    "context"
+    "fmt"
+    "math/big"
+    "net/http"
+    "net/http/httptest"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/events"
+    "yetaXYZ/oracle/fetch"
+)
+
+// fixedResolver answers with the outcome named in its URL
+type fixedResolver struct{ outcome string }
+
+func (r *fixedResolver) Resolve(ctx context.Context, q *Question, now time.Time) (string, error) {
+    return r.outcome, nil
+}
+
+// recordingSubmitter records submitted values by feed ID
+type recordingSubmitter struct{ values map[string]int64 }
+
+func (s *recordingSubmitter) Submit(ctx context.Context, symbol string, roundID uint64, value *big.Int) (string, error) {
+    s.values[symbol] = value.Int64()
+    return "0xabc", nil
+}
+
+func init() {
+    RegisterResolver("fixed", func(config ResolverConfig, deps Dependencies) (Resolver, error) {
+        return &fixedResolver{outcome: config.URL}, nil
+    })
+}
+
+func TestQuorumDisputeAndSettle(t *testing.T) {
+    closes := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+    config := &Config{
+        Enabled:   true,
+        StateFile: filepath.Join(t.TempDir(), "attestations.json"),
+        Questions: []Question{{
+            ID: "PROP-42-PASSED", Outcomes: []string{"yes", "no"}, ClosesAt: closes, Quorum: 2, DisputeWindowSeconds: 3600, Publish: true,
+            Resolvers: []ResolverConfig{
+                {Name: "a", Type: "fixed", URL: "yes"},
+                {Name: "b", Type: "fixed", URL: "yes"},
+                {Name: "c", Type: "fixed", URL: "no"},
+            },
+        }},
+    }
+    attestor, err := NewAttestor(config, nil, events.NewBus())
+    if err != nil {
+        t.Fatalf("Failed to create attestor: %v", err)
+    }
+    submitter := &recordingSubmitter{values: map[string]int64{}}
+    attestor.SetSubmitter(submitter)
+    ctx := context.Background()
+
+    attestor.Tick(ctx, closes.Add(-time.Minute))
+    if att, _ := attestor.Get("PROP-42-PASSED"); att.Status != StatusOpen {
+        t.Fatalf("Expected open before closing, got %s", att.Status)
+    }
+
+    attestor.Tick(ctx, closes.Add(time.Minute))
+    att, _ := attestor.Get("PROP-42-PASSED")
+    if att.Status != StatusProposed || att.Outcome != "yes" {
+        t.Fatalf("Expected yes proposed by 2 of 3, got %s %q", att.Status, att.Outcome)
+    }
+
+    preview, err := attestor.PreviewDispute("alice", "PROP-42-PASSED", "vote was recounted", closes.Add(2*time.Minute))
+    if err != nil || preview.Status != StatusDisputed {
+        t.Fatalf("Expected a disputed preview, got %+v (%v)", preview, err)
+    }
+    if att, _ := attestor.Get("PROP-42-PASSED"); att.Status != StatusProposed {
+        t.Fatalf("Expected a preview to leave the outcome proposed, got %s", att.Status)
+    }
+    if _, err := attestor.Dispute("alice", "PROP-42-PASSED", "vote was recounted", closes.Add(2*time.Minute)); err != nil {
+        t.Fatalf("Failed to dispute: %v", err)
+    }
+    attestor.Tick(ctx, closes.Add(2*time.Hour))
+    if att, _ := attestor.Get("PROP-42-PASSED"); att.Status != StatusDisputed {
+        t.Fatalf("Expected a disputed outcome to stay disputed past its window, got %s", att.Status)
+    }
+
+    // Disputes and outcomes survive a restart
+    restarted, err := NewAttestor(config, nil, events.NewBus())
+    if err != nil {
+        t.Fatalf("Failed to restore attestor: %v", err)
+    }
+    restarted.SetSubmitter(submitter)
+    if _, err := restarted.PreviewSettle("bob", "PROP-42-PASSED", "maybe", closes.Add(3*time.Hour)); err == nil {
+        t.Error("Expected an unknown outcome to fail a preview, got nil")
+    }
+    if preview, _ := restarted.PreviewSettle("bob", "PROP-42-PASSED", "no", closes.Add(3*time.Hour)); preview.Status != StatusFinal || submitter.values["PROP-42-PASSED"] != 0 {
+        t.Errorf("Expected a final preview that publishes nothing, got %+v", preview)
+    }
+    att, err = restarted.Settle(ctx, "bob", "PROP-42-PASSED", "no", closes.Add(3*time.Hour))
+    if err != nil {
+        t.Fatalf("Failed to settle: %v", err)
+    }
+    if att.Status != StatusFinal || att.Outcome != "no" || att.SettledBy != "bob" || att.TxHash != "0xabc" {
+        t.Errorf("Expected final no settled by bob and published, got %+v", att)
+    }
+    if submitter.values["PROP-42-PASSED"] != 2 {
+        t.Errorf("Expected outcome index 2 published, got %d", submitter.values["PROP-42-PASSED"])
+    }
+}
+
+func TestHTTPResolverWaitsForKnownValue(t *testing.T) {
+    status := "scheduled"
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        fmt.Fprintf(w, `{"flight": {"status": %q}}`, status)
+    }))
+    defer server.Close()
+
+    closes := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+    config := &Config{Enabled: true, Questions: []Question{{
+        ID: "LH400-ON-TIME", Outcomes: []string{"yes", "no"}, ClosesAt: closes, Quorum: 1, DisputeWindowSeconds: 60,
+        Resolvers: []ResolverConfig{{
+            Name: "flights", Type: "http", URL: server.URL, Field: "flight.status",
+            Values: map[string]string{"departed_on_time": "yes", "delayed": "no"},
+        }},
+    }}}
+    attestor, err := NewAttestor(config, nil, events.NewBus())
+    if err != nil {
+        t.Fatalf("Failed to create attestor: %v", err)
+    }
+
+    attestor.Tick(context.Background(), closes)
+    if att, _ := attestor.Get("LH400-ON-TIME"); att.Status != StatusResolving {
+        t.Fatalf("Expected resolving while the status is unmapped, got %s", att.Status)
+    }
+    status = "delayed"
+    attestor.Tick(context.Background(), closes.Add(time.Minute))
+    attestor.Tick(context.Background(), closes.Add(3*time.Minute))
+    if att, _ := attestor.Get("LH400-ON-TIME"); att.Status != StatusFinal || att.Outcome != "no" {
+        t.Errorf("Expected final no, got %s %q", att.Status, att.Outcome)
+    }
+}
+
+func TestHTTPResolverRefusesOversizedResponse(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        fmt.Fprintf(w, `{"status": "delayed", "padding": %q}`, strings.Repeat("x", fetch.MaxBodyBytes))
+    }))
+    defer server.Close()
+
+    resolver, err := newHTTPResolver(ResolverConfig{
+        Name: "flights", Type: "http", URL: server.URL, Field: "status", Values: map[string]string{"delayed": "no"},
+    }, Dependencies{Client: fetch.NewClient(time.Second)})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, err := resolver.Resolve(context.Background(), &Question{}, time.Now()); err == nil || !strings.Contains(err.Error(), "exceeds") {
+        t.Errorf("Expected the response refused as too large, got %v", err)
+    }
+}
@@ -0,0 +1,146 @@
+package attestation
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "time"
+)
+
+// Config configures event outcome attestation
+type Config struct {
+    Enabled bool `json:"enabled"`
+    // StateFile persists votes, disputes and outcomes across restarts
+    StateFile string `json:"stateFile,omitempty"`
+    // IntervalSeconds is how often closed questions are resolved, default 60
+    IntervalSeconds int        `json:"intervalSeconds,omitempty"`
+    Questions       []Question `json:"questions"`
+}
+
+// Question is an event whose outcome is attested once it closes
+type Question struct {
+    // ID is also the feed ID the outcome is published under, so at most
+    // 32 bytes
+    ID          string    `json:"id"`
+    Description string    `json:"description"`
+    Outcomes    []string  `json:"outcomes"`
+    ClosesAt    time.Time `json:"closesAt"`
+    // Quorum is how many resolvers must agree on an outcome, default a
+    // majority of the resolvers
+    Quorum int `json:"quorum,omitempty"`
+    // DisputeWindowSeconds is how long a proposed outcome can be disputed
+    // before it is final, default 3600
+    DisputeWindowSeconds int              `json:"disputeWindowSeconds,omitempty"`
+    Resolvers            []ResolverConfig `json:"resolvers"`
+    // Publish publishes the final outcome on-chain as its 1-based index
+    Publish bool `json:"publish,omitempty"`
+}
+
+// ResolverConfig configures one resolver of a question. Which fields apply
+// depends on the type.
+type ResolverConfig struct {
+    Name string `json:"name"`
+    Type string `json:"type"`
+
+    // http: the value at Field (dot-separated) of the JSON at URL, mapped
+    // to an outcome by Values; URL and header values may reference
+    // environment variables (${NAME})
+    URL     string            `json:"url,omitempty"`
+    Headers map[string]string `json:"headers,omitempty"`
+    Field   string            `json:"field,omitempty"`
+    Values  map[string]string `json:"values,omitempty"`
+
+    // feed: "yes" if the first round of Feed at or after closing compares
+    // to Threshold with Operator (>, >=, <, <=), else "no"
+    Feed      string  `json:"feed,omitempty"`
+    Operator  string  `json:"operator,omitempty"`
+    Threshold float64 `json:"threshold,omitempty"`
+}
+
+// LoadConfig loads attestation/attestation.json from the config directory.
+// A missing file disables attestation.
+func LoadConfig(configDir string) (*Config, error) {
+    data, err := os.ReadFile(filepath.Join(configDir, "attestation", "attestation.json"))
+    if os.IsNotExist(err) {
+        return &Config{}, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read attestation config: %v", err)
+    }
+
+    var config Config
+    if err := json.Unmarshal(data, &config); err != nil {
+        return nil, fmt.Errorf("failed to parse attestation config: %v", err)
+    }
+    if !config.Enabled {
+        return &config, nil
+    }
+
+    ids := make(map[string]bool, len(config.Questions))
+    for i := range config.Questions {
+        q := &config.Questions[i]
+        if q.ID == "" || len(q.ID) > 32 {
+            return nil, fmt.Errorf("question %d: id must be 1 to 32 bytes", i)
+        }
+        if ids[q.ID] {
+            return nil, fmt.Errorf("duplicate question %s", q.ID)
+        }
+        ids[q.ID] = true
+        if len(q.Outcomes) < 2 {
+            return nil, fmt.Errorf("question %s needs at least two outcomes", q.ID)
+        }
+        if q.ClosesAt.IsZero() {
+            return nil, fmt.Errorf("question %s requires closesAt", q.ID)
+        }
+        if len(q.Resolvers) == 0 {
+            return nil, fmt.Errorf("question %s has no resolvers", q.ID)
+        }
+        if q.Quorum <= 0 {
+            q.Quorum = len(q.Resolvers)/2 + 1
+        }
+        if q.Quorum > len(q.Resolvers) {
+            return nil, fmt.Errorf("question %s: quorum %d exceeds its %d resolvers", q.ID, q.Quorum, len(q.Resolvers))
+        }
+        if q.DisputeWindowSeconds <= 0 {
+            q.DisputeWindowSeconds = 3600
+        }
+        names := make(map[string]bool, len(q.Resolvers))
+        for j := range q.Resolvers {
+            r := &q.Resolvers[j]
+            if r.Name == "" || names[r.Name] {
+                return nil, fmt.Errorf("question %s: resolver %d needs a unique name", q.ID, j)
+            }
+            names[r.Name] = true
+        }
+    }
+    return &config, nil
+}
+
+// Interval returns the resolution interval
+func (c *Config) Interval() time.Duration {
+    if c.IntervalSeconds <= 0 {
+        return time.Minute
+    }
+    return time.Duration(c.IntervalSeconds) * time.Second
+}
+
+// DisputeWindow returns how long a proposed outcome can be disputed
+func (q *Question) DisputeWindow() time.Duration {
+    return time.Duration(q.DisputeWindowSeconds) * time.Second
+}
+
+// hasOutcome reports whether outcome is one of the question's outcomes
+func (q *Question) hasOutcome(outcome string) bool {
+    return q.outcomeIndex(outcome) >= 0
+}
+
+// outcomeIndex returns the index of an outcome, -1 if unknown
+func (q *Question) outcomeIndex(outcome string) int {
+    for i, o := range q.Outcomes {
+        if o == outcome {
+            return i
+        }
+    }
+    return -1
+}
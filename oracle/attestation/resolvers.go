@@ -0,0 +1,157 @@
+package attestation
+
+import (
+    "context"
+    "fmt"
+    "net/http"
+    "strings"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/credentials"
+    "yetaXYZ/oracle/fetch"
+    "yetaXYZ/oracle/redact"
+)
+
+// Resolver answers a closed question. An empty outcome without error means
+// the answer is not known yet.
+type Resolver interface {
+    Resolve(ctx context.Context, q *Question, now time.Time) (outcome string, err error)
+}
+
+// FeedLookup returns the rounds of a feed within [from, to], oldest first
+type FeedLookup func(symbol string, from, to time.Time) ([]*common.AggregateResult, error)
+
+// Dependencies are what resolvers may need from the node
+type Dependencies struct {
+    Client *http.Client
+    Rounds FeedLookup
+}
+
+// ResolverFactory builds a resolver from its configuration
+type ResolverFactory func(config ResolverConfig, deps Dependencies) (Resolver, error)
+
+var (
+    resolverMu    sync.Mutex
+    resolverTypes = map[string]ResolverFactory{
+        "http": newHTTPResolver,
+        "feed": newFeedResolver,
+    }
+)
+
+// RegisterResolver adds a resolver type usable in the config
+func RegisterResolver(kind string, factory ResolverFactory) {
+    resolverMu.Lock()
+    defer resolverMu.Unlock()
+    resolverTypes[kind] = factory
+}
+
+// newResolver builds a configured resolver
+func newResolver(config ResolverConfig, deps Dependencies) (Resolver, error) {
+    resolverMu.Lock()
+    factory, ok := resolverTypes[config.Type]
+    resolverMu.Unlock()
+    if !ok {
+        return nil, fmt.Errorf("unknown resolver type %q", config.Type)
+    }
+    return factory(config, deps)
+}
+
+// httpResolver maps a field of a JSON document to an outcome
+type httpResolver struct {
+    config ResolverConfig
+    client *http.Client
+}
+
+func newHTTPResolver(config ResolverConfig, deps Dependencies) (Resolver, error) {
+    if config.URL == "" || config.Field == "" || len(config.Values) == 0 {
+        return nil, fmt.Errorf("http resolver %s requires url, field and values", config.Name)
+    }
+    return &httpResolver{config: config, client: deps.Client}, nil
+}
+
+func (r *httpResolver) Resolve(ctx context.Context, q *Question, now time.Time) (string, error) {
+    // Credentials in the URL and headers are resolved per request
+    req, err := http.NewRequestWithContext(ctx, "GET", credentials.Expand(r.config.URL), nil)
+    if err != nil {
+        return "", fmt.Errorf("failed to create request: %v", redact.Error(err))
+    }
+    for key, value := range r.config.Headers {
+        req.Header.Set(key, credentials.Expand(value))
+    }
+    resp, err := r.client.Do(req)
+    if err != nil {
+        return "", fmt.Errorf("failed to fetch: %v", redact.Error(err))
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return "", fmt.Errorf("status %d", resp.StatusCode)
+    }
+
+    var doc interface{}
+    if err := fetch.DecodeJSON(resp, &doc); err != nil {
+        return "", fmt.Errorf("failed to parse response: %v", redact.Error(err))
+    }
+    for _, key := range strings.Split(r.config.Field, ".") {
+        object, ok := doc.(map[string]interface{})
+        if !ok {
+            return "", nil
+        }
+        if doc, ok = object[key]; !ok {
+            return "", nil
+        }
+    }
+    if doc == nil {
+        return "", nil
+    }
+    // Values the mapping does not know, e.g. "scheduled", are not answers
+    return r.config.Values[fmt.Sprint(doc)], nil
+}
+
+// feedResolver compares the feed's first round after closing to a threshold
+type feedResolver struct {
+    config ResolverConfig
+    rounds FeedLookup
+}
+
+func newFeedResolver(config ResolverConfig, deps Dependencies) (Resolver, error) {
+    switch config.Operator {
+    case ">", ">=", "<", "<=":
+    default:
+        return nil, fmt.Errorf("feed resolver %s: unknown operator %q", config.Name, config.Operator)
+    }
+    if config.Feed == "" || deps.Rounds == nil {
+        return nil, fmt.Errorf("feed resolver %s requires a feed", config.Name)
+    }
+    return &feedResolver{config: config, rounds: deps.Rounds}, nil
+}
+
+func (r *feedResolver) Resolve(ctx context.Context, q *Question, now time.Time) (string, error) {
+    if !q.hasOutcome("yes") || !q.hasOutcome("no") {
+        return "", fmt.Errorf("feed resolvers answer yes/no questions only")
+    }
+    rounds, err := r.rounds(r.config.Feed, q.ClosesAt, now)
+    if err != nil {
+        return "", err
+    }
+    if len(rounds) == 0 {
+        return "", nil
+    }
+    price := rounds[0].Price
+    var holds bool
+    switch r.config.Operator {
+    case ">":
+        holds = price > r.config.Threshold
+    case ">=":
+        holds = price >= r.config.Threshold
+    case "<":
+        holds = price < r.config.Threshold
+    case "<=":
+        holds = price <= r.config.Threshold
+    }
+    if holds {
+        return "yes", nil
+    }
+    return "no", nil
+}
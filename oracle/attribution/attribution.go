@@ -0,0 +1,111 @@
+// Package attribution collects the attribution requirements of data
+// providers and resolves those owed for a feed, following the feed's inputs
+// down to the exchanges and providers its value comes from
+package attribution
+
+import (
+    "fmt"
+    "sort"
+
+    "yetaXYZ/oracle/common"
+)
+
+// Inputs returns the source names (exchanges or providers) and input feeds
+// a feed's value is computed from
+type Inputs func(symbol string) []string
+
+// Provider is a named source with its attribution requirement
+type Provider struct {
+    Source string `json:"source"`
+    common.Attribution
+}
+
+// Registry holds the attributions declared by sources, keyed by the name
+// sources are configured under
+type Registry struct {
+    sources map[string]common.Attribution
+}
+
+// NewRegistry collects the attributions of the exchanges in the base config
+// and of other providers, such as benchmark rate publishers
+func NewRegistry(base *common.BaseConfig, providers map[string]*common.Attribution) (*Registry, error) {
+    r := &Registry{sources: make(map[string]common.Attribution)}
+    add := func(name string, a *common.Attribution) error {
+        if a == nil {
+            return nil
+        }
+        if a.Provider == "" || a.Text == "" {
+            return fmt.Errorf("attribution of %s needs a provider and text", name)
+        }
+        r.sources[name] = *a
+        return nil
+    }
+    for name, cex := range base.Exchanges.CEX {
+        if err := add(name, cex.Attribution); err != nil {
+            return nil, err
+        }
+    }
+    for name, dex := range base.Exchanges.DEX {
+        if err := add(name, dex.Attribution); err != nil {
+            return nil, err
+        }
+    }
+    for name, a := range providers {
+        if err := add(name, a); err != nil {
+            return nil, err
+        }
+    }
+    return r, nil
+}
+
+// Providers returns every source with an attribution requirement, sorted
+// by source name
+func (r *Registry) Providers() []Provider {
+    providers := make([]Provider, 0, len(r.sources))
+    for name, a := range r.sources {
+        providers = append(providers, Provider{Source: name, Attribution: a})
+    }
+    sort.Slice(providers, func(i, j int) bool { return providers[i].Source < providers[j].Source })
+    return providers
+}
+
+// Resolve returns the attributions owed for the given feeds, deduplicated
+// and sorted by provider. Names that are not sources with an attribution
+// are followed as feeds through inputs.
+func (r *Registry) Resolve(inputs Inputs, symbols ...string) []common.Attribution {
+    if len(r.sources) == 0 {
+        return nil
+    }
+
+    seen := make(map[string]bool)
+    owed := make(map[common.Attribution]bool)
+    var walk func(name string)
+    walk = func(name string) {
+        if seen[name] {
+            return
+        }
+        seen[name] = true
+        if a, ok := r.sources[name]; ok {
+            owed[a] = true
+            return
+        }
+        for _, input := range inputs(name) {
+            walk(input)
+        }
+    }
+    for _, symbol := range symbols {
+        walk(symbol)
+    }
+
+    attributions := make([]common.Attribution, 0, len(owed))
+    for a := range owed {
+        attributions = append(attributions, a)
+    }
+    sort.Slice(attributions, func(i, j int) bool {
+        if attributions[i].Provider != attributions[j].Provider {
+            return attributions[i].Provider < attributions[j].Provider
+        }
+        return attributions[i].Text < attributions[j].Text
+    })
+    return attributions
+}
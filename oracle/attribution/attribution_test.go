@@ -0,0 +1,55 @@
+package attribution
+
+import (
+    "testing"
+
+    "yetaXYZ/oracle/common"
+)
+
+func TestResolveFollowsFeedInputs(t *testing.T) {
+    gecko := &common.Attribution{Provider: "CoinGecko", Text: "Data provided by CoinGecko", URL: "https://www.coingecko.com"}
+    base := &common.BaseConfig{
+        Exchanges: common.ExchangeConfig{
+            CEX: map[string]common.CEXDetails{
+                "binance":   {Name: "Binance"},
+                "coingecko": {Name: "CoinGecko", Attribution: gecko},
+            },
+            DEX: map[string]common.DEXDetails{
+                "uniswap_v3": {Name: "Uniswap V3", Attribution: &common.Attribution{Provider: "The Graph", Text: "Indexed by The Graph"}},
+            },
+        },
+    }
+    registry, err := NewRegistry(base, map[string]*common.Attribution{
+        "fred": {Provider: "FRED", Text: "Source: Federal Reserve Bank of St. Louis"},
+    })
+    if err != nil {
+        t.Fatalf("Failed to build registry: %v", err)
+    }
+    if providers := registry.Providers(); len(providers) != 3 || providers[0].Source != "coingecko" {
+        t.Errorf("Unexpected providers %+v", providers)
+    }
+
+    lineage := map[string][]string{
+        "ETHUSDT": {"binance", "coingecko", "uniswap_v3"},
+        "BTCUSDT": {"binance", "coingecko"},
+        "ETHBTC":  {"ETHUSDT", "BTCUSDT"},
+        "DTB3":    {"fred"},
+        "LOOP":    {"LOOP"},
+    }
+    inputs := func(symbol string) []string { return lineage[symbol] }
+
+    owed := registry.Resolve(inputs, "ETHBTC")
+    if len(owed) != 2 || owed[0].Provider != "CoinGecko" || owed[1].Provider != "The Graph" {
+        t.Errorf("Expected CoinGecko and The Graph once each, got %+v", owed)
+    }
+    if owed := registry.Resolve(inputs, "ETHBTC", "DTB3"); len(owed) != 3 {
+        t.Errorf("Expected attributions across feeds to be merged, got %+v", owed)
+    }
+    if owed := registry.Resolve(inputs, "LOOP"); len(owed) != 0 {
+        t.Errorf("Expected no attributions, got %+v", owed)
+    }
+
+    if _, err := NewRegistry(base, map[string]*common.Attribution{"bls": {Provider: "BLS"}}); err == nil {
+        t.Error("Expected error for an attribution without text, got nil")
+    }
+}
@@ -0,0 +1,253 @@
+package backfill
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "net/http"
+    "sort"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/fetch"
+    "yetaXYZ/oracle/sources/crypto"
+    "yetaXYZ/oracle/sources/dex"
+    "yetaXYZ/oracle/store"
+    "yetaXYZ/oracle/symbols"
+)
+
+// MaxLookback bounds a single backfill request
+const MaxLookback = 30 * 24 * time.Hour
+
+// Report summarizes a backfill run
+type Report struct {
+    Symbol   string            `json:"symbol"`
+    From     time.Time         `json:"from"`
+    To       time.Time         `json:"to"`
+    Interval string            `json:"interval"`
+    Rounds   int               `json:"rounds"`
+    Candles  map[string]int    `json:"candles"` // per exchange
+    Errors   map[string]string `json:"errors,omitempty"`
+    DryRun   bool              `json:"dryRun,omitempty"` // rounds were built but not stored
+}
+
+// Backfiller populates the store with historical rounds built from
+// exchange klines so new feeds have history for change and volatility
+type Backfiller struct {
+    config *common.BaseConfig
+    store  store.Store
+    client *http.Client
+}
+
+// New creates a backfiller writing into s
+func New(config *common.BaseConfig, s store.Store) *Backfiller {
+    return &Backfiller{
+        config: config,
+        store:  s,
+        client: fetch.NewClient(30 * time.Second),
+    }
+}
+
+// Run backfills a pair over lookback ending at now with one round per
+// interval. Each round is the weighted median close of the pair's primary
+// exchanges. Only the period before the pair's earliest stored round is
+// filled, so live rounds are never overwritten or duplicated.
+func (b *Backfiller) Run(symbol string, pair *common.PairConfig, lookback, interval time.Duration, now time.Time) (*Report, error) {
+    return b.run(symbol, pair, lookback, interval, now, false)
+}
+
+// Preview fetches history and builds the rounds Run would store, without
+// storing them
+func (b *Backfiller) Preview(symbol string, pair *common.PairConfig, lookback, interval time.Duration, now time.Time) (*Report, error) {
+    return b.run(symbol, pair, lookback, interval, now, true)
+}
+
+func (b *Backfiller) run(symbol string, pair *common.PairConfig, lookback, interval time.Duration, now time.Time, dryRun bool) (*Report, error) {
+    if lookback <= 0 || lookback > MaxLookback {
+        return nil, fmt.Errorf("lookback must be between 0 and %s", MaxLookback)
+    }
+    if _, ok := binanceIntervals[interval]; !ok {
+        return nil, fmt.Errorf("unsupported interval %s", interval)
+    }
+
+    // Candles are fetched by open time; a round is stamped at candle close
+    from := now.Add(-lookback).Truncate(interval)
+    to := now.Truncate(interval)
+    if earliest, ok := b.earliest(symbol, from, now); ok && earliest.Add(-interval).Before(to) {
+        to = earliest.Add(-interval)
+    }
+    report := &Report{
+        Symbol:   symbol,
+        From:     from,
+        To:       to,
+        Interval: interval.String(),
+        Candles:  make(map[string]int),
+        Errors:   make(map[string]string),
+        DryRun:   dryRun,
+    }
+    if !from.Before(to) {
+        return report, nil
+    }
+
+    rounds, err := b.build(symbol, pair, interval, from, to, report)
+    if err != nil {
+        return report, err
+    }
+    for _, result := range rounds {
+        if dryRun {
+            report.Rounds++
+            continue
+        }
+        if err := b.store.SaveRound(result); err != nil {
+            return report, fmt.Errorf("failed to store backfilled round: %v", err)
+        }
+        report.Rounds++
+    }
+    return report, nil
+}
+
+// History builds the rounds of a pair over lookback ending at now from
+// exchange klines without storing them, regardless of stored history
+func (b *Backfiller) History(symbol string, pair *common.PairConfig, lookback, interval time.Duration, now time.Time) ([]*common.AggregateResult, error) {
+    if lookback <= 0 || lookback > MaxLookback {
+        return nil, fmt.Errorf("lookback must be between 0 and %s", MaxLookback)
+    }
+    if _, ok := binanceIntervals[interval]; !ok {
+        return nil, fmt.Errorf("unsupported interval %s", interval)
+    }
+    report := &Report{Candles: make(map[string]int), Errors: make(map[string]string)}
+    return b.build(symbol, pair, interval, now.Add(-lookback).Truncate(interval), now.Truncate(interval), report)
+}
+
+// build fetches the klines of the pair's primary exchanges, and for hourly
+// rounds the history of its DEX pools, opened in [from, to) and builds a
+// round per candle, noting candle counts and errors in report
+func (b *Backfiller) build(symbol string, pair *common.PairConfig, interval time.Duration, from, to time.Time, report *Report) ([]*common.AggregateResult, error) {
+    // Collect closes per candle open time across exchanges
+    type quote struct {
+        source string
+        candle Candle
+    }
+    buckets := make(map[time.Time][]quote)
+    if pair.Sources.CEX.Enabled {
+        for _, exchange := range pair.Sources.CEX.Exchanges {
+            candles, err := b.candles(exchange, symbol, pair, interval, from, to)
+            if err != nil {
+                log.Printf("Backfill of %s from %s failed: %v", symbol, exchange, err)
+                report.Errors[exchange] = err.Error()
+                continue
+            }
+            report.Candles[exchange] = len(candles)
+            for _, c := range candles {
+                buckets[c.Open] = append(buckets[c.Open], quote{source: exchange, candle: c})
+            }
+        }
+    }
+    // Subgraphs index pools by the hour, so their history only fills
+    // hourly rounds
+    if pair.Sources.DEX.Enabled && interval == time.Hour {
+        for _, pool := range pair.Sources.DEX.Pools {
+            source := crypto.PoolSourceName(pool)
+            candles, err := b.poolCandles(pair, pool, from, to)
+            if err != nil {
+                log.Printf("Backfill of %s from %s failed: %v", symbol, source, err)
+                report.Errors[source] = err.Error()
+                continue
+            }
+            report.Candles[source] = len(candles)
+            for _, c := range candles {
+                buckets[c.Open] = append(buckets[c.Open], quote{source: source, candle: c})
+            }
+        }
+    }
+    if len(buckets) == 0 {
+        return nil, fmt.Errorf("no historical data available for %s", symbol)
+    }
+
+    opens := make([]time.Time, 0, len(buckets))
+    for open := range buckets {
+        opens = append(opens, open)
+    }
+    sort.Slice(opens, func(i, j int) bool { return opens[i].Before(opens[j]) })
+
+    rounds := make([]*common.AggregateResult, 0, len(opens))
+    for _, open := range opens {
+        quotes := buckets[open]
+        closeTime := open.Add(interval)
+        sources := make([]common.SourcePrice, 0, len(quotes))
+        volume := 0.0
+        for _, q := range quotes {
+            point := common.PricePoint{Price: q.candle.Close, Volume: q.candle.Volume, Timestamp: closeTime}
+            sources = append(sources, common.SourcePrice{Source: q.source, PricePoint: point})
+            volume += q.candle.Volume
+        }
+
+        result := &common.AggregateResult{
+            Symbol: symbol,
+            PricePoint: common.PricePoint{
+                Price:     crypto.WeightedMedian(pair, sources),
+                Volume:    volume,
+                Timestamp: closeTime,
+            },
+            Sources:    sources,
+            Backfilled: true,
+        }
+        rounds = append(rounds, result)
+    }
+    return rounds, nil
+}
+
+// earliest returns the timestamp of the first stored round in [from, to]
+func (b *Backfiller) earliest(symbol string, from, to time.Time) (time.Time, bool) {
+    rounds, err := b.store.Rounds(symbol, from, to)
+    if err != nil || len(rounds) == 0 {
+        return time.Time{}, false
+    }
+    return rounds[0].Timestamp, true
+}
+
+// candles fetches klines from a supported exchange
+func (b *Backfiller) candles(exchange, symbol string, pair *common.PairConfig, interval time.Duration, from, to time.Time) ([]Candle, error) {
+    details, ok := b.config.Exchanges.CEX[exchange]
+    if !ok {
+        return nil, fmt.Errorf("unknown exchange %s", exchange)
+    }
+    if pair.BaseCurrency != "" {
+        symbol = symbols.New(b.config).Ticker(exchange, pair.BaseCurrency, pair.QuoteCurrency)
+    }
+    switch exchange {
+    case "binance":
+        return fetchBinanceKlines(b.client, details.BaseURL, symbol, interval, from, to)
+    case "coinbase":
+        return fetchCoinbaseCandles(b.client, details.BaseURL, symbol, interval, from, to)
+    case "kraken":
+        return fetchKrakenOHLC(b.client, details.BaseURL, symbol, interval, from, to)
+    }
+    return nil, fmt.Errorf("no historical data source for %s", exchange)
+}
+
+// poolCandles reads the hourly closes of a pair's DEX pool from the pool's
+// subgraph
+func (b *Backfiller) poolCandles(pair *common.PairConfig, pool common.DEXPool, from, to time.Time) ([]Candle, error) {
+    details, ok := b.config.Exchanges.DEX[pool.Exchange]
+    if !ok {
+        return nil, fmt.Errorf("unknown DEX %s", pool.Exchange)
+    }
+    if pool.Quote != "" && pool.Quote != pair.QuoteCurrency {
+        return nil, fmt.Errorf("pools quoted in %s are not backfilled", pool.Quote)
+    }
+    base, err := symbols.New(b.config).Address(pool.Chain, pair.BaseCurrency)
+    if err != nil {
+        return nil, err
+    }
+
+    hours, err := dex.PoolHours(context.Background(), b.client, pool.Exchange, details, pool.Address, base, from, to)
+    if err != nil {
+        return nil, err
+    }
+    candles := make([]Candle, len(hours))
+    for i, h := range hours {
+        candles[i] = Candle{Open: h.Open, Close: h.Price, Volume: h.Volume}
+    }
+    return candles, nil
+}
@@ -0,0 +1,185 @@
+package backfill
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "strconv"
+    "strings"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/store"
+)
+
+func TestBackfill(t *testing.T) {
+    now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+    from := now.Add(-time.Hour)
+
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "application/json")
+        switch r.URL.Path {
+        case "/binance/klines":
+            rows := make([]string, 0)
+            for open := from; open.Before(now); open = open.Add(15 * time.Minute) {
+                rows = append(rows, fmt.Sprintf(`[%d, "100", "102", "99", "101", "10", 0]`, open.UnixMilli()))
+            }
+            w.Write([]byte("[" + strings.Join(rows, ",") + "]"))
+        case "/kraken/OHLC":
+            rows := make([]string, 0)
+            for open := from.Add(-15 * time.Minute); open.Before(now); open = open.Add(15 * time.Minute) {
+                rows = append(rows, fmt.Sprintf(`[%d, "100", "102", "99", "103", "100.5", "5", 12]`, open.Unix()))
+            }
+            w.Write([]byte(`{"error": [], "result": {"BTCUSDT": [` + strings.Join(rows, ",") + `], "last": 0}}`))
+        default:
+            http.NotFound(w, r)
+        }
+    }))
+    defer srv.Close()
+
+    config := &common.BaseConfig{Exchanges: common.ExchangeConfig{CEX: map[string]common.CEXDetails{
+        "binance": {BaseURL: srv.URL + "/binance"},
+        "kraken":  {BaseURL: srv.URL + "/kraken"},
+    }}}
+    pair := &common.PairConfig{SourceWeights: map[string]float64{"binance": 3}}
+    pair.Sources.CEX.Enabled = true
+    pair.Sources.CEX.Exchanges = []string{"binance", "kraken", "coinbase"}
+
+    s := store.NewMemoryStore()
+    // A live round 25 minutes ago limits the backfill to the time before it
+    s.SaveRound(&common.AggregateResult{Symbol: "BTCUSDT", PricePoint: common.PricePoint{Price: 200, Timestamp: now.Add(-25 * time.Minute)}})
+
+    preview, err := New(config, s).Preview("BTCUSDT", pair, time.Hour, 15*time.Minute, now)
+    if err != nil || preview.Rounds != 2 || !preview.DryRun {
+        t.Errorf("Expected a dry run of 2 rounds, got %+v (%v)", preview, err)
+    }
+    if rounds, _ := s.Rounds("BTCUSDT", from, now); len(rounds) != 1 {
+        t.Fatalf("Expected a dry run to store nothing, got %d rounds", len(rounds))
+    }
+
+    report, err := New(config, s).Run("BTCUSDT", pair, time.Hour, 15*time.Minute, now)
+    if err != nil {
+        t.Fatalf("Backfill failed: %v", err)
+    }
+    if report.Rounds != 2 || report.Candles["binance"] != 2 || report.Candles["kraken"] != 2 {
+        t.Errorf("Expected 2 rounds from 2 candles per exchange, got %+v", report)
+    }
+    if _, ok := report.Errors["coinbase"]; !ok {
+        t.Errorf("Expected an error for coinbase, got %+v", report.Errors)
+    }
+
+    rounds, _ := s.Rounds("BTCUSDT", from, now)
+    if len(rounds) != 3 {
+        t.Fatalf("Expected 2 backfilled rounds before the live one, got %d", len(rounds))
+    }
+    first := rounds[0]
+    if !first.Backfilled || !first.Timestamp.Equal(from.Add(15*time.Minute)) {
+        t.Errorf("Expected a backfilled round at the first candle close, got %+v", first)
+    }
+    // Binance outweighs Kraken, so its close is the weighted median
+    if first.Price != 101 || first.Volume != 15 || len(first.Sources) != 2 {
+        t.Errorf("Expected price 101 and volume 15 from 2 sources, got %+v", first)
+    }
+    if rounds[2].Backfilled {
+        t.Error("Expected the live round to be kept")
+    }
+
+    if _, err := New(config, s).Run("BTCUSDT", pair, 40*24*time.Hour, time.Hour, now); err == nil {
+        t.Error("Expected an error for a lookback above the maximum")
+    }
+    if _, err := New(config, s).Run("BTCUSDT", pair, time.Hour, 2*time.Minute, now); err == nil {
+        t.Error("Expected an error for an unsupported interval")
+    }
+}
+
+func TestCoinbaseCandles(t *testing.T) {
+    now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+    from := now.Add(-400 * time.Minute)
+
+    requests := 0
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path != "/market/products/ETH-USD/candles" || r.URL.Query().Get("granularity") != "ONE_MINUTE" {
+            http.NotFound(w, r)
+            return
+        }
+        requests++
+        start, _ := strconv.ParseInt(r.URL.Query().Get("start"), 10, 64)
+        end, _ := strconv.ParseInt(r.URL.Query().Get("end"), 10, 64)
+        // Newest first, as Coinbase sends them
+        rows := make([]string, 0)
+        for open := end - end%60; open >= start; open -= 60 {
+            rows = append(rows, fmt.Sprintf(`{"start": "%d", "low": "99", "high": "102", "open": "100", "close": "101", "volume": "2.5"}`, open))
+        }
+        w.Write([]byte(`{"candles": [` + strings.Join(rows, ",") + `]}`))
+    }))
+    defer srv.Close()
+
+    candles, err := fetchCoinbaseCandles(srv.Client(), srv.URL, "ETH-USD", time.Minute, from, now)
+    if err != nil {
+        t.Fatalf("Failed to fetch candles: %v", err)
+    }
+    if requests != 2 {
+        t.Errorf("Expected 400 candles in 2 requests, got %d", requests)
+    }
+    if len(candles) != 400 || !candles[0].Open.Equal(from) || !candles[399].Open.Equal(now.Add(-time.Minute)) {
+        t.Fatalf("Expected 400 candles in order from %s, got %d", from, len(candles))
+    }
+    for i := 1; i < len(candles); i++ {
+        if !candles[i].Open.After(candles[i-1].Open) {
+            t.Fatalf("Expected candles in ascending order, got %s after %s", candles[i].Open, candles[i-1].Open)
+        }
+    }
+    if candles[0].Close != 101 || candles[0].Volume != 2.5 {
+        t.Errorf("Expected close 101 and volume 2.5, got %+v", candles[0])
+    }
+}
+
+func TestBackfillPoolHistory(t *testing.T) {
+    now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+    from := now.Add(-2 * time.Hour)
+
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        var req struct {
+            Variables map[string]interface{} `json:"variables"`
+        }
+        json.NewDecoder(r.Body).Decode(&req)
+        if req.Variables["pool"] != "0xpool" {
+            t.Errorf("Expected the pool's hours to be queried, got %v", req.Variables)
+        }
+        rows := make([]string, 0)
+        for open := from; open.Before(now); open = open.Add(time.Hour) {
+            rows = append(rows, fmt.Sprintf(`{"periodStartUnix": %d, "token0Price": "0.0005", "token1Price": "2000", "volumeToken0": "7", "volumeToken1": "14000",
+                "pool": {"token0": {"id": "0xbase"}, "token1": {"id": "0xquote"}}}`, open.Unix()))
+        }
+        w.Write([]byte(`{"data": {"poolHourDatas": [` + strings.Join(rows, ",") + `]}}`))
+    }))
+    defer srv.Close()
+
+    config := &common.BaseConfig{Exchanges: common.ExchangeConfig{DEX: map[string]common.DEXDetails{
+        "uniswap_v3": {Type: "subgraph", Endpoint: srv.URL},
+    }}}
+    pool := common.DEXPool{Chain: "1", Exchange: "uniswap_v3", Address: "0xPool"}
+    pair := &common.PairConfig{BaseCurrency: "0xBase"}
+    pair.Sources.DEX.Enabled = true
+    pair.Sources.DEX.Pools = []common.DEXPool{pool}
+
+    s := store.NewMemoryStore()
+    report, err := New(config, s).Run("ETHUSDC", pair, 2*time.Hour, time.Hour, now)
+    if err != nil {
+        t.Fatalf("Backfill failed: %v", err)
+    }
+    if report.Rounds != 2 || report.Candles["uniswap_v3:0xPool"] != 2 {
+        t.Errorf("Expected 2 rounds from the pool's hours, got %+v", report)
+    }
+    rounds, _ := s.Rounds("ETHUSDC", from, now)
+    if len(rounds) != 2 || rounds[0].Price != 2000 || rounds[0].Volume != 7 || !rounds[0].Timestamp.Equal(from.Add(time.Hour)) {
+        t.Errorf("Expected hourly rounds at 2000 stamped at the hour's close, got %+v", rounds)
+    }
+
+    // Pools keep no history finer than an hour
+    if _, err := New(config, store.NewMemoryStore()).Run("ETHUSDC", pair, 2*time.Hour, 15*time.Minute, now); err == nil {
+        t.Error("Expected no history for 15 minute rounds of a DEX-only pair")
+    }
+}
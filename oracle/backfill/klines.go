@@ -0,0 +1,262 @@
+package backfill
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/url"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "yetaXYZ/oracle/fetch"
+)
+
+// Candle is one exchange kline
+type Candle struct {
+    Open   time.Time
+    Close  float64
+    Volume float64
+}
+
+// binanceIntervals maps supported intervals to Binance kline intervals
+var binanceIntervals = map[time.Duration]string{
+    time.Minute:      "1m",
+    5 * time.Minute:  "5m",
+    15 * time.Minute: "15m",
+    time.Hour:        "1h",
+}
+
+// binanceKlineLimit is the maximum number of klines per Binance request
+const binanceKlineLimit = 1000
+
+// fetchBinanceKlines fetches the klines of a symbol opened in [from, to),
+// paging through Binance's per-request limit
+func fetchBinanceKlines(client *http.Client, baseURL, symbol string, interval time.Duration, from, to time.Time) ([]Candle, error) {
+    name, ok := binanceIntervals[interval]
+    if !ok {
+        return nil, fmt.Errorf("unsupported Binance interval %s", interval)
+    }
+
+    candles := make([]Candle, 0)
+    for start := from; start.Before(to); {
+        params := url.Values{}
+        params.Set("symbol", symbol)
+        params.Set("interval", name)
+        params.Set("startTime", strconv.FormatInt(start.UnixMilli(), 10))
+        params.Set("endTime", strconv.FormatInt(to.UnixMilli()-1, 10))
+        params.Set("limit", strconv.Itoa(binanceKlineLimit))
+
+        resp, err := client.Get(strings.TrimRight(baseURL, "/") + "/klines?" + params.Encode())
+        if err != nil {
+            return nil, err
+        }
+        if resp.StatusCode != http.StatusOK {
+            resp.Body.Close()
+            return nil, fmt.Errorf("unexpected status from Binance: %s", resp.Status)
+        }
+        // Klines are [openTime, open, high, low, close, volume, closeTime, ...]
+        var rows [][]interface{}
+        err = fetch.DecodeJSON(resp, &rows)
+        resp.Body.Close()
+        if err != nil {
+            return nil, err
+        }
+        if len(rows) == 0 {
+            break
+        }
+
+        for _, row := range rows {
+            candle, err := parseBinanceKline(row)
+            if err != nil {
+                return nil, err
+            }
+            if candle.Open.Before(start) || !candle.Open.Before(to) {
+                continue
+            }
+            candles = append(candles, candle)
+        }
+        if len(candles) == 0 {
+            break
+        }
+        start = candles[len(candles)-1].Open.Add(interval)
+        if len(rows) < binanceKlineLimit {
+            break
+        }
+    }
+    return candles, nil
+}
+
+// parseBinanceKline converts one Binance kline row
+func parseBinanceKline(row []interface{}) (Candle, error) {
+    if len(row) < 6 {
+        return Candle{}, fmt.Errorf("short kline row: %v", row)
+    }
+    openMs, ok := row[0].(float64)
+    if !ok {
+        return Candle{}, fmt.Errorf("invalid kline open time: %v", row[0])
+    }
+    closePrice, err := parseNumber(row[4])
+    if err != nil {
+        return Candle{}, err
+    }
+    volume, err := parseNumber(row[5])
+    if err != nil {
+        return Candle{}, err
+    }
+    return Candle{Open: time.UnixMilli(int64(openMs)).UTC(), Close: closePrice, Volume: volume}, nil
+}
+
+// fetchKrakenOHLC fetches the OHLC candles of a pair opened in [from, to).
+// Kraken only serves the most recent 720 candles of an interval.
+func fetchKrakenOHLC(client *http.Client, baseURL, pair string, interval time.Duration, from, to time.Time) ([]Candle, error) {
+    params := url.Values{}
+    params.Set("pair", pair)
+    params.Set("interval", strconv.Itoa(int(interval/time.Minute)))
+    params.Set("since", strconv.FormatInt(from.Unix()-1, 10))
+
+    resp, err := client.Get(strings.TrimRight(baseURL, "/") + "/OHLC?" + params.Encode())
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+
+    // Rows are [time, open, high, low, close, vwap, volume, count]; the
+    // result also holds a "last" cursor next to the pair's rows
+    var data struct {
+        Error  []string                   `json:"error"`
+        Result map[string]json.RawMessage `json:"result"`
+    }
+    if err := fetch.DecodeJSON(resp, &data); err != nil {
+        return nil, err
+    }
+    if len(data.Error) > 0 {
+        return nil, fmt.Errorf("Kraken error: %s", strings.Join(data.Error, ", "))
+    }
+
+    candles := make([]Candle, 0)
+    for key, raw := range data.Result {
+        if key == "last" {
+            continue
+        }
+        var rows [][]interface{}
+        if err := json.Unmarshal(raw, &rows); err != nil {
+            return nil, err
+        }
+        for _, row := range rows {
+            if len(row) < 7 {
+                return nil, fmt.Errorf("short OHLC row: %v", row)
+            }
+            openSec, ok := row[0].(float64)
+            if !ok {
+                return nil, fmt.Errorf("invalid OHLC time: %v", row[0])
+            }
+            open := time.Unix(int64(openSec), 0).UTC()
+            if open.Before(from) || !open.Before(to) {
+                continue
+            }
+            closePrice, err := parseNumber(row[4])
+            if err != nil {
+                return nil, err
+            }
+            volume, err := parseNumber(row[6])
+            if err != nil {
+                return nil, err
+            }
+            candles = append(candles, Candle{Open: open, Close: closePrice, Volume: volume})
+        }
+    }
+    return candles, nil
+}
+
+// coinbaseGranularities maps supported intervals to Coinbase Advanced
+// Trade candle granularities
+var coinbaseGranularities = map[time.Duration]string{
+    time.Minute:      "ONE_MINUTE",
+    5 * time.Minute:  "FIVE_MINUTE",
+    15 * time.Minute: "FIFTEEN_MINUTE",
+    time.Hour:        "ONE_HOUR",
+}
+
+// coinbaseCandleLimit is the maximum number of candles per Coinbase request
+const coinbaseCandleLimit = 350
+
+// fetchCoinbaseCandles fetches the candles of a product opened in
+// [from, to) from the public Advanced Trade endpoint, a window of at most
+// coinbaseCandleLimit candles per request
+func fetchCoinbaseCandles(client *http.Client, baseURL, product string, interval time.Duration, from, to time.Time) ([]Candle, error) {
+    granularity, ok := coinbaseGranularities[interval]
+    if !ok {
+        return nil, fmt.Errorf("unsupported Coinbase interval %s", interval)
+    }
+
+    candles := make([]Candle, 0)
+    for start := from; start.Before(to); start = start.Add(coinbaseCandleLimit * interval) {
+        end := start.Add(coinbaseCandleLimit * interval)
+        if end.After(to) {
+            end = to
+        }
+        params := url.Values{}
+        params.Set("start", strconv.FormatInt(start.Unix(), 10))
+        params.Set("end", strconv.FormatInt(end.Unix()-1, 10))
+        params.Set("granularity", granularity)
+
+        resp, err := client.Get(strings.TrimRight(baseURL, "/") + "/market/products/" + product + "/candles?" + params.Encode())
+        if err != nil {
+            return nil, err
+        }
+        if resp.StatusCode != http.StatusOK {
+            resp.Body.Close()
+            return nil, fmt.Errorf("unexpected status from Coinbase: %s", resp.Status)
+        }
+        // Candles come newest first with every field as a string
+        var data struct {
+            Candles []struct {
+                Start  string `json:"start"`
+                Close  string `json:"close"`
+                Volume string `json:"volume"`
+            } `json:"candles"`
+        }
+        err = fetch.DecodeJSON(resp, &data)
+        resp.Body.Close()
+        if err != nil {
+            return nil, err
+        }
+
+        window := make([]Candle, 0, len(data.Candles))
+        for _, c := range data.Candles {
+            openSec, err := strconv.ParseInt(c.Start, 10, 64)
+            if err != nil {
+                return nil, fmt.Errorf("invalid candle start: %q", c.Start)
+            }
+            open := time.Unix(openSec, 0).UTC()
+            if open.Before(start) || !open.Before(end) {
+                continue
+            }
+            closePrice, err := strconv.ParseFloat(c.Close, 64)
+            if err != nil {
+                return nil, err
+            }
+            volume, err := strconv.ParseFloat(c.Volume, 64)
+            if err != nil {
+                return nil, err
+            }
+            window = append(window, Candle{Open: open, Close: closePrice, Volume: volume})
+        }
+        sort.Slice(window, func(i, j int) bool { return window[i].Open.Before(window[j].Open) })
+        candles = append(candles, window...)
+    }
+    return candles, nil
+}
+
+// parseNumber parses a kline field sent as a string or a number
+func parseNumber(v interface{}) (float64, error) {
+    switch n := v.(type) {
+    case string:
+        return strconv.ParseFloat(n, 64)
+    case float64:
+        return n, nil
+    }
+    return 0, fmt.Errorf("invalid number: %v", v)
+}
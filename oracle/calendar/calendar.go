@@ -0,0 +1,134 @@
+package calendar
+
+import (
+    "time"
+    _ "time/tzdata" // calendars must resolve exchange time zones on minimal hosts
+)
+
+// Calendar decides which dates are business days in a given time zone
+type Calendar struct {
+    Name     string
+    Location *time.Location
+
+    holidays func(year int) []time.Time
+    extra    map[string]bool // additional closures as YYYY-MM-DD
+    sessions []Session       // trading sessions; none means open all business days
+}
+
+// New creates a calendar with weekend closures, rule-based holidays and
+// additional fixed closure dates (YYYY-MM-DD)
+func New(name string, location *time.Location, holidays func(year int) []time.Time, extra []string) *Calendar {
+    c := &Calendar{
+        Name:     name,
+        Location: location,
+        holidays: holidays,
+        extra:    make(map[string]bool, len(extra)),
+    }
+    for _, d := range extra {
+        c.extra[d] = true
+    }
+    return c
+}
+
+// USFederal returns the US federal (Federal Reserve) business-day calendar
+func USFederal() *Calendar {
+    ny, err := time.LoadLocation("America/New_York")
+    if err != nil {
+        ny = time.FixedZone("ET", -5*60*60)
+    }
+    return New("us-federal", ny, usFederalHolidays, nil)
+}
+
+// IsBusinessDay reports whether the calendar date of t (in the calendar's
+// time zone) is neither a weekend nor a holiday
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+    t = t.In(c.Location)
+    if wd := t.Weekday(); wd == time.Saturday || wd == time.Sunday {
+        return false
+    }
+    return !c.IsHoliday(t)
+}
+
+// IsHoliday reports whether the calendar date of t is a holiday or an
+// additional closure
+func (c *Calendar) IsHoliday(t time.Time) bool {
+    t = t.In(c.Location)
+    key := t.Format("2006-01-02")
+    if c.extra[key] {
+        return true
+    }
+    if c.holidays != nil {
+        for _, h := range c.holidays(t.Year()) {
+            if h.Format("2006-01-02") == key {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// AddBusinessDays moves n business days from t (backwards when n < 0)
+func (c *Calendar) AddBusinessDays(t time.Time, n int) time.Time {
+    step := 1
+    if n < 0 {
+        step, n = -1, -n
+    }
+    for n > 0 {
+        t = t.AddDate(0, 0, step)
+        if c.IsBusinessDay(t) {
+            n--
+        }
+    }
+    return t
+}
+
+// Date returns midnight of t's calendar date in the calendar's time zone
+func (c *Calendar) Date(t time.Time) time.Time {
+    t = t.In(c.Location)
+    return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, c.Location)
+}
+
+// usFederalHolidays returns the observed US federal holidays of a year
+func usFederalHolidays(year int) []time.Time {
+    date := func(m time.Month, d int) time.Time {
+        return time.Date(year, m, d, 0, 0, 0, 0, time.UTC)
+    }
+    return []time.Time{
+        observed(date(time.January, 1)),
+        nthWeekday(year, time.January, time.Monday, 3),  // Martin Luther King Jr. Day
+        nthWeekday(year, time.February, time.Monday, 3), // Washington's Birthday
+        lastWeekday(year, time.May, time.Monday),        // Memorial Day
+        observed(date(time.June, 19)),
+        observed(date(time.July, 4)),
+        nthWeekday(year, time.September, time.Monday, 1), // Labor Day
+        nthWeekday(year, time.October, time.Monday, 2),   // Columbus Day
+        observed(date(time.November, 11)),
+        nthWeekday(year, time.November, time.Thursday, 4), // Thanksgiving
+        observed(date(time.December, 25)),
+    }
+}
+
+// observed shifts a fixed-date holiday falling on a weekend to the nearest weekday
+func observed(t time.Time) time.Time {
+    switch t.Weekday() {
+    case time.Saturday:
+        return t.AddDate(0, 0, -1)
+    case time.Sunday:
+        return t.AddDate(0, 0, 1)
+    }
+    return t
+}
+
+// nthWeekday returns the n-th given weekday of a month
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+    t := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+    offset := (int(weekday) - int(t.Weekday()) + 7) % 7
+    return t.AddDate(0, 0, offset+7*(n-1))
+}
+
+// lastWeekday returns the last given weekday of a month
+func lastWeekday(year int, month time.Month, weekday time.Weekday) time.Time {
+    t := time.Date(year, month+1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+    offset := (int(t.Weekday()) - int(weekday) + 7) % 7
+    return t.AddDate(0, 0, -offset)
+}
@@ -0,0 +1,39 @@
+package calendar
+
+import (
+    "testing"
+    "time"
+)
+
+func TestUSFederalBusinessDays(t *testing.T) {
+    cal := USFederal()
+    day := func(s string) time.Time {
+        d, err := time.ParseInLocation("2006-01-02", s, cal.Location)
+        if err != nil {
+            t.Fatalf("Invalid date %s: %v", s, err)
+        }
+        return d
+    }
+
+    closed := []string{
+        "2024-01-01", // New Year's Day
+        "2024-01-15", // MLK Day
+        "2024-05-27", // Memorial Day
+        "2024-11-28", // Thanksgiving
+        "2021-12-24", // Christmas observed on Friday
+        "2024-04-13", // Saturday
+    }
+    for _, d := range closed {
+        if cal.IsBusinessDay(day(d)) {
+            t.Errorf("Expected %s to be closed", d)
+        }
+    }
+    if !cal.IsBusinessDay(day("2024-04-12")) {
+        t.Error("Expected 2024-04-12 to be a business day")
+    }
+
+    // Tuesday after Memorial Day minus one business day is the Friday before
+    if got := cal.AddBusinessDays(day("2024-05-28"), -1); got.Format("2006-01-02") != "2024-05-24" {
+        t.Errorf("Expected 2024-05-24, got %s", got.Format("2006-01-02"))
+    }
+}
@@ -0,0 +1,118 @@
+package calendar
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "time"
+)
+
+// holidayRules are the rule-based holiday sets calendars can reference
+var holidayRules = map[string]func(year int) []time.Time{
+    "us-federal": usFederalHolidays,
+}
+
+// SessionConfig defines a session opening at the same time on several days
+type SessionConfig struct {
+    Days  []string `json:"days"`  // e.g. ["Mon", "Tue"]
+    Open  string   `json:"open"`  // HH:MM local time
+    Close string   `json:"close"` // HH:MM local time, at or before Open for overnight sessions
+}
+
+// CalendarConfig defines a trading calendar
+type CalendarConfig struct {
+    Timezone     string          `json:"timezone"`
+    HolidayRules string          `json:"holidayRules,omitempty"`
+    Holidays     []string        `json:"holidays,omitempty"` // YYYY-MM-DD
+    Sessions     []SessionConfig `json:"sessions"`
+}
+
+// Config maps feed classes to named trading calendars
+type Config struct {
+    Calendars   map[string]*CalendarConfig `json:"calendars"`
+    FeedClasses map[string]string          `json:"feedClasses"` // class -> calendar
+}
+
+// Registry resolves the trading calendar of a feed class
+type Registry struct {
+    calendars map[string]*Calendar
+    classes   map[string]string
+}
+
+// LoadConfig loads calendars/calendars.json from the config directory. A
+// missing file yields an empty registry in which every class trades 24/7.
+func LoadConfig(configDir string) (*Registry, error) {
+    data, err := os.ReadFile(filepath.Join(configDir, "calendars", "calendars.json"))
+    if os.IsNotExist(err) {
+        return NewRegistry(&Config{})
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read calendar config: %v", err)
+    }
+
+    var config Config
+    if err := json.Unmarshal(data, &config); err != nil {
+        return nil, fmt.Errorf("failed to parse calendar config: %v", err)
+    }
+    return NewRegistry(&config)
+}
+
+// NewRegistry builds and validates the calendars of a configuration
+func NewRegistry(config *Config) (*Registry, error) {
+    r := &Registry{
+        calendars: make(map[string]*Calendar, len(config.Calendars)),
+        classes:   make(map[string]string, len(config.FeedClasses)),
+    }
+
+    for name, cc := range config.Calendars {
+        location, err := time.LoadLocation(cc.Timezone)
+        if err != nil {
+            return nil, fmt.Errorf("calendar %s: invalid timezone %q: %v", name, cc.Timezone, err)
+        }
+
+        var rules func(year int) []time.Time
+        if cc.HolidayRules != "" {
+            if rules = holidayRules[cc.HolidayRules]; rules == nil {
+                return nil, fmt.Errorf("calendar %s: unknown holiday rules %q", name, cc.HolidayRules)
+            }
+        }
+        for _, d := range cc.Holidays {
+            if _, err := time.Parse("2006-01-02", d); err != nil {
+                return nil, fmt.Errorf("calendar %s: invalid holiday %q", name, d)
+            }
+        }
+
+        sessions := make([]Session, 0)
+        for _, sc := range cc.Sessions {
+            parsed, err := parseSessions(sc.Days, sc.Open, sc.Close)
+            if err != nil {
+                return nil, fmt.Errorf("calendar %s: %v", name, err)
+            }
+            sessions = append(sessions, parsed...)
+        }
+
+        r.calendars[name] = New(name, location, rules, cc.Holidays).WithSessions(sessions)
+    }
+
+    for class, name := range config.FeedClasses {
+        if r.calendars[name] == nil {
+            return nil, fmt.Errorf("feed class %s references unknown calendar %s", class, name)
+        }
+        r.classes[class] = name
+    }
+    return r, nil
+}
+
+// ForClass returns the trading calendar of a feed class. The empty class
+// trades around the clock and yields nil.
+func (r *Registry) ForClass(class string) (*Calendar, error) {
+    if class == "" {
+        return nil, nil
+    }
+    name, ok := r.classes[class]
+    if !ok {
+        return nil, fmt.Errorf("unknown feed class %s", class)
+    }
+    return r.calendars[name], nil
+}
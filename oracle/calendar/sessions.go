@@ -0,0 +1,116 @@
+package calendar
+
+import (
+    "fmt"
+    "strings"
+    "time"
+)
+
+// Session is a trading window opening on a weekday. A close at or before
+// the open ends on the following day, so FX can be modelled as Sunday to
+// Thursday sessions from 17:00 to 17:00.
+type Session struct {
+    Day   time.Weekday
+    Open  time.Duration // offset from local midnight
+    Close time.Duration // offset from local midnight
+}
+
+// end returns the session's close as an offset from its opening midnight
+func (s Session) end() time.Duration {
+    if s.Close <= s.Open {
+        return s.Close + 24*time.Hour
+    }
+    return s.Close
+}
+
+// WithSessions returns the calendar restricted to the given sessions
+func (c *Calendar) WithSessions(sessions []Session) *Calendar {
+    c.sessions = sessions
+    return c
+}
+
+// IsOpen reports whether the market is trading at t: inside a session and
+// not on a holiday. Calendars without sessions are open on business days.
+func (c *Calendar) IsOpen(t time.Time) bool {
+    local := t.In(c.Location)
+    if len(c.sessions) == 0 {
+        return c.IsBusinessDay(local)
+    }
+    if c.IsHoliday(local) {
+        return false
+    }
+
+    // A session may have opened today or, crossing midnight, yesterday
+    for back := 0; back <= 1; back++ {
+        midnight := c.Date(local).AddDate(0, 0, -back)
+        offset := local.Sub(midnight)
+        for _, s := range c.sessions {
+            if s.Day == midnight.Weekday() && offset >= s.Open && offset < s.end() {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// LastClose returns the most recent session close at or before t, searching
+// at most two weeks back. The zero time is returned when there is none.
+func (c *Calendar) LastClose(t time.Time) time.Time {
+    local := t.In(c.Location)
+    var last time.Time
+    for back := 0; back <= 14; back++ {
+        midnight := c.Date(local).AddDate(0, 0, -back)
+        if c.IsHoliday(midnight) {
+            continue
+        }
+        for _, s := range c.sessions {
+            if s.Day != midnight.Weekday() {
+                continue
+            }
+            close := midnight.Add(s.end())
+            if !close.After(local) && close.After(last) {
+                last = close
+            }
+        }
+        if !last.IsZero() {
+            return last
+        }
+    }
+    return last
+}
+
+var weekdays = map[string]time.Weekday{
+    "sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+    "thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// parseSessions expands a session definition opening on several weekdays
+func parseSessions(days []string, open, close string) ([]Session, error) {
+    openAt, err := parseClock(open)
+    if err != nil {
+        return nil, fmt.Errorf("invalid open %q: %v", open, err)
+    }
+    closeAt, err := parseClock(close)
+    if err != nil {
+        return nil, fmt.Errorf("invalid close %q: %v", close, err)
+    }
+
+    sessions := make([]Session, 0, len(days))
+    for _, d := range days {
+        day, ok := weekdays[strings.ToLower(d)]
+        if !ok {
+            return nil, fmt.Errorf("invalid weekday %q", d)
+        }
+        sessions = append(sessions, Session{Day: day, Open: openAt, Close: closeAt})
+    }
+    return sessions, nil
+}
+
+// parseClock parses HH:MM into an offset from midnight
+func parseClock(s string) (time.Duration, error) {
+    t, err := time.Parse("15:04", s)
+    if err != nil {
+        return 0, err
+    }
+    return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
@@ -0,0 +1,73 @@
+package calendar
+
+import (
+    "testing"
+    "time"
+)
+
+func TestSessions(t *testing.T) {
+    registry, err := NewRegistry(&Config{
+        Calendars: map[string]*CalendarConfig{
+            "fx": {
+                Timezone: "America/New_York",
+                Holidays: []string{"2024-12-25"},
+                Sessions: []SessionConfig{{Days: []string{"Sun", "Mon", "Tue", "Wed", "Thu"}, Open: "17:00", Close: "17:00"}},
+            },
+            "us-equities": {
+                Timezone:     "America/New_York",
+                HolidayRules: "us-federal",
+                Sessions:     []SessionConfig{{Days: []string{"Mon", "Tue", "Wed", "Thu", "Fri"}, Open: "09:30", Close: "16:00"}},
+            },
+        },
+        FeedClasses: map[string]string{"forex": "fx", "stock": "us-equities"},
+    })
+    if err != nil {
+        t.Fatalf("Failed to build registry: %v", err)
+    }
+
+    fx, _ := registry.ForClass("forex")
+    stocks, _ := registry.ForClass("stock")
+    if crypto, err := registry.ForClass(""); crypto != nil || err != nil {
+        t.Error("Expected the empty class to trade around the clock")
+    }
+    if _, err := registry.ForClass("weather"); err == nil {
+        t.Error("Expected an error for an unknown feed class")
+    }
+
+    at := func(s string) time.Time {
+        ts, err := time.ParseInLocation("2006-01-02 15:04", s, fx.Location)
+        if err != nil {
+            t.Fatalf("Invalid time %s: %v", s, err)
+        }
+        return ts
+    }
+
+    tests := []struct {
+        cal  *Calendar
+        at   string
+        open bool
+    }{
+        {fx, "2024-04-14 16:59", false}, // Sunday before the open
+        {fx, "2024-04-14 17:00", true},  // Sunday open
+        {fx, "2024-04-17 03:00", true},  // overnight Wednesday
+        {fx, "2024-04-19 16:59", true},  // Friday before the close
+        {fx, "2024-04-19 17:00", false}, // Friday close
+        {fx, "2024-04-20 12:00", false}, // Saturday
+        {fx, "2024-12-25 10:00", false}, // holiday
+        {stocks, "2024-04-15 10:00", true},
+        {stocks, "2024-04-15 16:00", false},
+        {stocks, "2024-05-27 10:00", false}, // Memorial Day
+    }
+    for _, tt := range tests {
+        if got := tt.cal.IsOpen(at(tt.at)); got != tt.open {
+            t.Errorf("%s at %s: expected open=%v, got %v", tt.cal.Name, tt.at, tt.open, got)
+        }
+    }
+
+    if got := fx.LastClose(at("2024-04-20 12:00")); !got.Equal(at("2024-04-19 17:00")) {
+        t.Errorf("Expected Friday 17:00 as last FX close, got %s", got)
+    }
+    if got := stocks.LastClose(at("2024-05-27 12:00")); !got.Equal(at("2024-05-24 16:00")) {
+        t.Errorf("Expected Friday 16:00 as last close before Memorial Day, got %s", got)
+    }
+}
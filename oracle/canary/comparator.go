@@ -0,0 +1,300 @@
+package canary
+
+import (
+    "context"
+    "fmt"
+    "math"
+    "net/http"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+    "yetaXYZ/oracle/fetch"
+    "yetaXYZ/oracle/replica"
+)
+
+// round is the latest round of a feed seen on one side
+type round struct {
+    price   float64
+    at      time.Time
+    matched bool
+}
+
+// observation is a round counted towards a feed's coverage
+type observation struct {
+    symbol     string
+    production bool
+    at         time.Time
+}
+
+// sample is the difference between a canary and a production round
+type sample struct {
+    symbol  string
+    at      time.Time
+    diffBps float64
+}
+
+// FeedReport compares one feed over the window
+type FeedReport struct {
+    Symbol     string `json:"symbol"`
+    Production int    `json:"production"` // production rounds
+    Canary     int    `json:"canary"`     // canary rounds
+    Matched    int    `json:"matched"`    // pairs of rounds compared
+    // MissingRatio is the fraction of production rounds without a canary
+    // round close enough in time
+    MissingRatio  float64  `json:"missingRatio"`
+    MeanDiffBps   float64  `json:"meanDiffBps"`
+    MedianDiffBps float64  `json:"medianDiffBps"`
+    P95DiffBps    float64  `json:"p95DiffBps"`
+    MaxDiffBps    float64  `json:"maxDiffBps"`
+    Failures      []string `json:"failures,omitempty"`
+}
+
+// Report is the outcome of comparing the canary against production. Pass
+// gates promotion: it requires a full window with every feed within the
+// thresholds.
+type Report struct {
+    Production replica.Status `json:"production"`
+    Started    time.Time      `json:"started"`
+    From       time.Time      `json:"from"`
+    To         time.Time      `json:"to"`
+    Pass       bool           `json:"pass"`
+    Failures   []string       `json:"failures,omitempty"`
+    Feeds      []FeedReport   `json:"feeds"`
+    Thresholds Config         `json:"thresholds"`
+}
+
+// Comparator diffs the rounds aggregated by a canary instance against those
+// of production, followed over production's event stream
+type Comparator struct {
+    config     *Config
+    production *replica.Follower
+    started    time.Time
+
+    mu           sync.Mutex
+    latest       map[bool]map[string]*round // by side, production first
+    observations []observation
+    samples      []sample
+}
+
+// NewComparator compares the rounds published on the local bus against
+// those streamed by the production instance at productionURL
+func NewComparator(config *Config, bus *events.Bus, productionURL string) *Comparator {
+    productionBus := events.NewBus()
+    c := &Comparator{
+        config:     config,
+        production: replica.NewFollower(productionURL, productionBus),
+        started:    time.Now(),
+        latest:     map[bool]map[string]*round{true: {}, false: {}},
+    }
+    productionBus.SubscribeFunc(1000, func(e events.Event) {
+        if result, ok := e.Payload.(*common.AggregateResult); ok {
+            c.observe(true, result)
+        }
+    }, events.Aggregate).Named("canary-production")
+    bus.SubscribeFunc(1000, func(e events.Event) {
+        if result, ok := e.Payload.(*common.AggregateResult); ok {
+            c.observe(false, result)
+        }
+    }, events.Aggregate).Named("canary")
+    return c
+}
+
+// SetAPIKey authenticates the comparator to a metered production instance
+func (c *Comparator) SetAPIKey(key string) {
+    c.production.SetAPIKey(key)
+}
+
+// Run follows production and drops observations older than the window
+// every interval until ctx is cancelled
+func (c *Comparator) Run(ctx context.Context, interval time.Duration) {
+    go c.production.Run(ctx)
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case now := <-ticker.C:
+            c.prune(now.Add(-c.config.Window()))
+        }
+    }
+}
+
+// observe records a round of one side and pairs it with the other side's
+// latest round of the feed when they are close enough in time
+func (c *Comparator) observe(production bool, result *common.AggregateResult) {
+    if result.Backfilled || result.Candle != nil || result.Price <= 0 {
+        return
+    }
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    r := &round{price: result.Price, at: result.Timestamp}
+    c.observations = append(c.observations, observation{symbol: result.Symbol, production: production, at: r.at})
+    other := c.latest[!production][result.Symbol]
+    if other != nil && !other.matched && absDuration(r.at.Sub(other.at)) <= c.config.skew() {
+        other.matched, r.matched = true, true
+        reference, candidate := other.price, r.price
+        if production {
+            reference, candidate = r.price, other.price
+        }
+        c.samples = append(c.samples, sample{symbol: result.Symbol, at: r.at, diffBps: math.Abs(candidate-reference) / reference * 1e4})
+    }
+    c.latest[production][result.Symbol] = r
+}
+
+// prune drops observations and samples from before cutoff
+func (c *Comparator) prune(cutoff time.Time) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    observations := c.observations[:0]
+    for _, o := range c.observations {
+        if !o.at.Before(cutoff) {
+            observations = append(observations, o)
+        }
+    }
+    c.observations = observations
+    samples := c.samples[:0]
+    for _, s := range c.samples {
+        if !s.at.Before(cutoff) {
+            samples = append(samples, s)
+        }
+    }
+    c.samples = samples
+}
+
+// Report compares the canary against production over the window ending now
+func (c *Comparator) Report(now time.Time) *Report {
+    from := now.Add(-c.config.Window())
+    report := &Report{
+        Production: c.production.Status(),
+        Started:    c.started,
+        From:       from,
+        To:         now,
+        Feeds:      make([]FeedReport, 0),
+        Thresholds: *c.config,
+    }
+
+    c.mu.Lock()
+    feeds := make(map[string]*FeedReport)
+    feed := func(symbol string) *FeedReport {
+        if feeds[symbol] == nil {
+            feeds[symbol] = &FeedReport{Symbol: symbol}
+        }
+        return feeds[symbol]
+    }
+    for _, o := range c.observations {
+        if o.at.Before(from) {
+            continue
+        }
+        if o.production {
+            feed(o.symbol).Production++
+        } else {
+            feed(o.symbol).Canary++
+        }
+    }
+    diffs := make(map[string][]float64)
+    for _, s := range c.samples {
+        if !s.at.Before(from) {
+            diffs[s.symbol] = append(diffs[s.symbol], s.diffBps)
+        }
+    }
+    c.mu.Unlock()
+
+    if now.Sub(c.started) < c.config.Window() {
+        report.Failures = append(report.Failures, fmt.Sprintf("compared for %s of the %s window", now.Sub(c.started).Round(time.Second), c.config.Window()))
+    }
+    for symbol, f := range feeds {
+        c.evaluate(f, diffs[symbol])
+        report.Feeds = append(report.Feeds, *f)
+        if len(f.Failures) > 0 {
+            report.Failures = append(report.Failures, fmt.Sprintf("%d failure(s) for %s", len(f.Failures), symbol))
+        }
+    }
+    sort.Slice(report.Feeds, func(i, j int) bool { return report.Feeds[i].Symbol < report.Feeds[j].Symbol })
+    sort.Strings(report.Failures)
+    if len(feeds) == 0 {
+        report.Failures = append(report.Failures, "no rounds to compare")
+    }
+    report.Pass = len(report.Failures) == 0
+    return report
+}
+
+// evaluate summarizes the differences of a feed and checks them against
+// the thresholds. Feeds production does not serve are reported but cannot
+// fail.
+func (c *Comparator) evaluate(f *FeedReport, diffs []float64) {
+    f.Matched = len(diffs)
+    if f.Production > 0 {
+        missing := f.Production - f.Matched
+        if missing < 0 {
+            missing = 0
+        }
+        f.MissingRatio = float64(missing) / float64(f.Production)
+    }
+    if len(diffs) > 0 {
+        sort.Float64s(diffs)
+        var sum float64
+        for _, d := range diffs {
+            sum += d
+        }
+        f.MeanDiffBps = sum / float64(len(diffs))
+        f.MedianDiffBps = percentile(diffs, 0.5)
+        f.P95DiffBps = percentile(diffs, 0.95)
+        f.MaxDiffBps = diffs[len(diffs)-1]
+    }
+    if f.Production == 0 {
+        return
+    }
+    if f.Matched < c.config.MinMatched {
+        f.Failures = append(f.Failures, fmt.Sprintf("%d rounds compared, need %d", f.Matched, c.config.MinMatched))
+    }
+    if f.MissingRatio > c.config.MaxMissingRatio {
+        f.Failures = append(f.Failures, fmt.Sprintf("canary missed %.1f%% of production rounds, at most %.1f%% allowed", f.MissingRatio*100, c.config.MaxMissingRatio*100))
+    }
+    if f.MedianDiffBps > c.config.MaxMedianDiffBps {
+        f.Failures = append(f.Failures, fmt.Sprintf("median difference %.2f bps exceeds %.2f bps", f.MedianDiffBps, c.config.MaxMedianDiffBps))
+    }
+    if f.MaxDiffBps > c.config.MaxDiffBps {
+        f.Failures = append(f.Failures, fmt.Sprintf("maximum difference %.2f bps exceeds %.2f bps", f.MaxDiffBps, c.config.MaxDiffBps))
+    }
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+    i := int(math.Ceil(p*float64(len(sorted)))) - 1
+    if i < 0 {
+        i = 0
+    }
+    return sorted[i]
+}
+
+// absDuration returns the magnitude of d
+func absDuration(d time.Duration) time.Duration {
+    if d < 0 {
+        return -d
+    }
+    return d
+}
+
+// FetchReport reads the report of the canary instance at baseURL
+func FetchReport(client *http.Client, baseURL string) (*Report, error) {
+    resp, err := client.Get(strings.TrimRight(baseURL, "/") + "/api/v1/canary/report")
+    if err != nil {
+        return nil, fmt.Errorf("failed to fetch canary report: %v", err)
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("failed to fetch canary report: unexpected status %s", resp.Status)
+    }
+    var report Report
+    if err := fetch.DecodeJSON(resp, &report); err != nil {
+        return nil, fmt.Errorf("failed to parse canary report: %v", err)
+    }
+    return &report, nil
+}
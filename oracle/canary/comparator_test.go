@@ -0,0 +1,68 @@
+package canary
+
+import (
+    "strings"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+)
+
+func result(symbol string, price float64, at time.Time) *common.AggregateResult {
+    return &common.AggregateResult{Symbol: symbol, PricePoint: common.PricePoint{Price: price, Timestamp: at}}
+}
+
+func TestComparatorReport(t *testing.T) {
+    config := &Config{MinMatched: 3}
+    config.defaults()
+    c := NewComparator(config, events.NewBus(), "http://production.invalid")
+    now := time.Now()
+    c.started = now.Add(-2 * time.Hour)
+
+    start := now.Add(-30 * time.Minute)
+    for i := 0; i < 10; i++ {
+        at := start.Add(time.Duration(i) * time.Minute)
+        c.observe(true, result("ETHUSDT", 3000, at))
+        c.observe(false, result("ETHUSDT", 3000.3, at.Add(2*time.Second))) // 1 bps
+        c.observe(true, result("BTCUSDT", 60000, at))
+        if i%2 == 0 {
+            // The canary misses half of BTCUSDT's rounds
+            c.observe(false, result("BTCUSDT", 60000, at.Add(time.Second)))
+        }
+    }
+    // Too far apart to be compared
+    c.observe(true, result("SOLUSDT", 150, now.Add(-5*time.Minute)))
+    c.observe(false, result("SOLUSDT", 160, now.Add(-time.Minute)))
+
+    report := c.Report(now)
+    if sol := report.Feeds[2]; sol.Matched != 0 || sol.MissingRatio != 1 {
+        t.Errorf("Expected SOLUSDT rounds left unpaired, got %+v", sol)
+    }
+    if report.Pass || len(report.Feeds) != 3 {
+        t.Fatalf("Expected a failing report of three feeds, got %+v", report)
+    }
+    btc, eth := report.Feeds[0], report.Feeds[1]
+    if btc.Matched != 5 || btc.MissingRatio != 0.5 || len(btc.Failures) != 1 || !strings.Contains(btc.Failures[0], "missed") {
+        t.Errorf("Expected BTCUSDT to fail on missing rounds, got %+v", btc)
+    }
+    if eth.Production != 10 || eth.Canary != 10 || eth.Matched != 10 || eth.MaxDiffBps < 0.99 || eth.MaxDiffBps > 1.01 || len(eth.Failures) != 0 {
+        t.Errorf("Expected ETHUSDT within thresholds, got %+v", eth)
+    }
+
+    // A regression beyond the maximum difference fails the feed
+    c.observe(true, result("ETHUSDT", 3000, now))
+    c.observe(false, result("ETHUSDT", 3030, now))
+    report = c.Report(now)
+    if eth := report.Feeds[1]; len(eth.Failures) != 1 || !strings.Contains(eth.Failures[0], "maximum difference") {
+        t.Errorf("Expected ETHUSDT to fail on its maximum difference, got %+v", eth)
+    }
+
+    // Nothing passes before a full window has been compared
+    c.started = now.Add(-10 * time.Minute)
+    c.prune(now.Add(-config.Window()))
+    report = c.Report(now)
+    if report.Pass || !strings.Contains(strings.Join(report.Failures, ";"), "window") {
+        t.Errorf("Expected an incomplete window to fail, got %v", report.Failures)
+    }
+}
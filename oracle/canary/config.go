@@ -0,0 +1,85 @@
+package canary
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "time"
+)
+
+// Config sets the comparison window and the thresholds a canary must stay
+// within to be promoted
+type Config struct {
+    // WindowMinutes is how long the canary is compared before a report can
+    // pass, and how far back the report looks; default 60
+    WindowMinutes int `json:"windowMinutes,omitempty"`
+    // MaxSkewSeconds is how far apart a canary and a production round of a
+    // feed may be to be compared; default 30
+    MaxSkewSeconds int `json:"maxSkewSeconds,omitempty"`
+    // MaxMedianDiffBps bounds the median difference of each feed; default 5
+    MaxMedianDiffBps float64 `json:"maxMedianDiffBps,omitempty"`
+    // MaxDiffBps bounds the difference of any single pair of rounds;
+    // default 50
+    MaxDiffBps float64 `json:"maxDiffBps,omitempty"`
+    // MaxMissingRatio bounds the fraction of production rounds the canary
+    // had no round for; default 0.05
+    MaxMissingRatio float64 `json:"maxMissingRatio,omitempty"`
+    // MinMatched is the number of compared rounds each feed needs; default 10
+    MinMatched int `json:"minMatched,omitempty"`
+}
+
+// LoadConfig loads canary/canary.json from the config directory. A missing
+// file yields the defaults.
+func LoadConfig(configDir string) (*Config, error) {
+    config := &Config{}
+    data, err := os.ReadFile(filepath.Join(configDir, "canary", "canary.json"))
+    if err != nil && !os.IsNotExist(err) {
+        return nil, fmt.Errorf("failed to read canary config: %v", err)
+    }
+    if err == nil {
+        if err := json.Unmarshal(data, config); err != nil {
+            return nil, fmt.Errorf("failed to parse canary config: %v", err)
+        }
+    }
+    if config.WindowMinutes < 0 || config.MaxSkewSeconds < 0 || config.MaxMedianDiffBps < 0 || config.MaxDiffBps < 0 || config.MinMatched < 0 {
+        return nil, fmt.Errorf("canary thresholds must not be negative")
+    }
+    if config.MaxMissingRatio < 0 || config.MaxMissingRatio > 1 {
+        return nil, fmt.Errorf("canary maxMissingRatio must be between 0 and 1")
+    }
+    config.defaults()
+    return config, nil
+}
+
+// defaults fills in unset thresholds
+func (c *Config) defaults() {
+    if c.WindowMinutes == 0 {
+        c.WindowMinutes = 60
+    }
+    if c.MaxSkewSeconds == 0 {
+        c.MaxSkewSeconds = 30
+    }
+    if c.MaxMedianDiffBps == 0 {
+        c.MaxMedianDiffBps = 5
+    }
+    if c.MaxDiffBps == 0 {
+        c.MaxDiffBps = 50
+    }
+    if c.MaxMissingRatio == 0 {
+        c.MaxMissingRatio = 0.05
+    }
+    if c.MinMatched == 0 {
+        c.MinMatched = 10
+    }
+}
+
+// Window returns the comparison window
+func (c *Config) Window() time.Duration {
+    return time.Duration(c.WindowMinutes) * time.Minute
+}
+
+// skew returns the pairing tolerance
+func (c *Config) skew() time.Duration {
+    return time.Duration(c.MaxSkewSeconds) * time.Second
+}
@@ -0,0 +1,342 @@
+package common
+
+import (
+    "encoding/binary"
+    "fmt"
+    "math"
+    "time"
+)
+
+// ProtoPackage is the protobuf package of the schemas in
+// proto/yetaxyz/oracle/v1/oracle.proto implemented by this file
+const ProtoPackage = "yetaxyz.oracle.v1"
+
+// ProtoContentType identifies protobuf-encoded AggregateResult payloads
+const ProtoContentType = "application/x-protobuf; proto=yetaxyz.oracle.v1.AggregateResult"
+
+// Protobuf wire types
+const (
+    wireVarint  = 0
+    wireFixed64 = 1
+    wireBytes   = 2
+    wireFixed32 = 5
+)
+
+// MarshalProto encodes the price point as a yetaxyz.oracle.v1.PricePoint
+func (p PricePoint) MarshalProto() []byte {
+    var b []byte
+    b = appendDouble(b, 1, p.Price)
+    b = appendDouble(b, 2, p.Volume)
+    if !p.Timestamp.IsZero() {
+        b = appendMessage(b, 3, marshalTimestamp(p.Timestamp))
+    }
+    return b
+}
+
+// MarshalProto encodes the source price as a yetaxyz.oracle.v1.SourcePrice
+func (s SourcePrice) MarshalProto() []byte {
+    var b []byte
+    b = appendString(b, 1, s.Source)
+    b = appendString(b, 2, s.Tier)
+    b = appendMessage(b, 3, s.PricePoint.MarshalProto())
+    b = appendString(b, 4, s.Quote)
+    return b
+}
+
+// MarshalProto encodes the candle as a yetaxyz.oracle.v1.Candle
+func (c Candle) MarshalProto() []byte {
+    var b []byte
+    b = appendString(b, 1, c.Interval)
+    b = appendDouble(b, 2, c.Open)
+    b = appendDouble(b, 3, c.High)
+    b = appendDouble(b, 4, c.Low)
+    b = appendDouble(b, 5, c.Close)
+    b = appendVarintField(b, 6, uint64(c.Rounds))
+    return b
+}
+
+// MarshalProto encodes the result as a yetaxyz.oracle.v1.AggregateResult
+func (r *AggregateResult) MarshalProto() []byte {
+    var b []byte
+    b = appendString(b, 1, r.Symbol)
+    b = appendMessage(b, 2, r.PricePoint.MarshalProto())
+    for _, s := range r.Sources {
+        b = appendMessage(b, 3, s.MarshalProto())
+    }
+    b = appendVarintField(b, 4, r.RoundID)
+    b = appendString(b, 5, r.ConfigVersion)
+    b = appendString(b, 6, r.FallbackReason)
+    b = appendBool(b, 7, r.MarketClosed)
+    for _, s := range r.Rejected {
+        b = appendMessage(b, 8, s.MarshalProto())
+    }
+    b = appendBool(b, 9, r.Backfilled)
+    if r.Candle != nil {
+        b = appendMessage(b, 10, r.Candle.MarshalProto())
+    }
+    for _, source := range r.Abandoned {
+        b = appendString(b, 11, source)
+    }
+    b = appendDouble(b, 12, r.RawPrice)
+    if r.Clamped != nil {
+        b = appendMessage(b, 13, r.Clamped.MarshalProto())
+    }
+    b = appendBool(b, 14, r.Frozen)
+    return b
+}
+
+// MarshalProto encodes the clamp as a yetaxyz.oracle.v1.Clamped
+func (c *Clamped) MarshalProto() []byte {
+    var b []byte
+    b = appendString(b, 1, c.Bound)
+    b = appendDouble(b, 2, c.Price)
+    return b
+}
+
+// UnmarshalProto decodes a yetaxyz.oracle.v1.PricePoint. Unknown fields
+// are skipped so that payloads from newer schema revisions still decode.
+func (p *PricePoint) UnmarshalProto(data []byte) error {
+    *p = PricePoint{}
+    return decodeFields(data, func(field int, wire int, v uint64, raw []byte) error {
+        switch {
+        case field == 1 && wire == wireFixed64:
+            p.Price = math.Float64frombits(v)
+        case field == 2 && wire == wireFixed64:
+            p.Volume = math.Float64frombits(v)
+        case field == 3 && wire == wireBytes:
+            t, err := unmarshalTimestamp(raw)
+            if err != nil {
+                return err
+            }
+            p.Timestamp = t
+        }
+        return nil
+    })
+}
+
+// UnmarshalProto decodes a yetaxyz.oracle.v1.SourcePrice
+func (s *SourcePrice) UnmarshalProto(data []byte) error {
+    *s = SourcePrice{}
+    return decodeFields(data, func(field int, wire int, v uint64, raw []byte) error {
+        switch {
+        case field == 1 && wire == wireBytes:
+            s.Source = string(raw)
+        case field == 2 && wire == wireBytes:
+            s.Tier = string(raw)
+        case field == 3 && wire == wireBytes:
+            return s.PricePoint.UnmarshalProto(raw)
+        case field == 4 && wire == wireBytes:
+            s.Quote = string(raw)
+        }
+        return nil
+    })
+}
+
+// UnmarshalProto decodes a yetaxyz.oracle.v1.Candle
+func (c *Candle) UnmarshalProto(data []byte) error {
+    *c = Candle{}
+    return decodeFields(data, func(field int, wire int, v uint64, raw []byte) error {
+        switch {
+        case field == 1 && wire == wireBytes:
+            c.Interval = string(raw)
+        case field == 2 && wire == wireFixed64:
+            c.Open = math.Float64frombits(v)
+        case field == 3 && wire == wireFixed64:
+            c.High = math.Float64frombits(v)
+        case field == 4 && wire == wireFixed64:
+            c.Low = math.Float64frombits(v)
+        case field == 5 && wire == wireFixed64:
+            c.Close = math.Float64frombits(v)
+        case field == 6 && wire == wireVarint:
+            c.Rounds = int(v)
+        }
+        return nil
+    })
+}
+
+// UnmarshalProto decodes a yetaxyz.oracle.v1.AggregateResult
+func (r *AggregateResult) UnmarshalProto(data []byte) error {
+    *r = AggregateResult{}
+    return decodeFields(data, func(field int, wire int, v uint64, raw []byte) error {
+        switch {
+        case field == 1 && wire == wireBytes:
+            r.Symbol = string(raw)
+        case field == 2 && wire == wireBytes:
+            return r.PricePoint.UnmarshalProto(raw)
+        case (field == 3 || field == 8) && wire == wireBytes:
+            var s SourcePrice
+            if err := s.UnmarshalProto(raw); err != nil {
+                return err
+            }
+            if field == 3 {
+                r.Sources = append(r.Sources, s)
+            } else {
+                r.Rejected = append(r.Rejected, s)
+            }
+        case field == 4 && wire == wireVarint:
+            r.RoundID = v
+        case field == 5 && wire == wireBytes:
+            r.ConfigVersion = string(raw)
+        case field == 6 && wire == wireBytes:
+            r.FallbackReason = string(raw)
+        case field == 7 && wire == wireVarint:
+            r.MarketClosed = v != 0
+        case field == 9 && wire == wireVarint:
+            r.Backfilled = v != 0
+        case field == 10 && wire == wireBytes:
+            r.Candle = &Candle{}
+            return r.Candle.UnmarshalProto(raw)
+        case field == 11 && wire == wireBytes:
+            r.Abandoned = append(r.Abandoned, string(raw))
+        case field == 12 && wire == wireFixed64:
+            r.RawPrice = math.Float64frombits(v)
+        case field == 13 && wire == wireBytes:
+            r.Clamped = &Clamped{}
+            return r.Clamped.UnmarshalProto(raw)
+        case field == 14 && wire == wireVarint:
+            r.Frozen = v != 0
+        }
+        return nil
+    })
+}
+
+// UnmarshalProto decodes a yetaxyz.oracle.v1.Clamped
+func (c *Clamped) UnmarshalProto(data []byte) error {
+    *c = Clamped{}
+    return decodeFields(data, func(field int, wire int, v uint64, raw []byte) error {
+        switch {
+        case field == 1 && wire == wireBytes:
+            c.Bound = string(raw)
+        case field == 2 && wire == wireFixed64:
+            c.Price = math.Float64frombits(v)
+        }
+        return nil
+    })
+}
+
+// marshalTimestamp encodes a google.protobuf.Timestamp
+func marshalTimestamp(t time.Time) []byte {
+    var b []byte
+    b = appendVarintField(b, 1, uint64(t.Unix()))
+    b = appendVarintField(b, 2, uint64(t.Nanosecond()))
+    return b
+}
+
+// unmarshalTimestamp decodes a google.protobuf.Timestamp as UTC
+func unmarshalTimestamp(data []byte) (time.Time, error) {
+    var seconds, nanos int64
+    err := decodeFields(data, func(field int, wire int, v uint64, raw []byte) error {
+        switch {
+        case field == 1 && wire == wireVarint:
+            seconds = int64(v)
+        case field == 2 && wire == wireVarint:
+            nanos = int64(int32(v))
+        }
+        return nil
+    })
+    if err != nil {
+        return time.Time{}, err
+    }
+    return time.Unix(seconds, nanos).UTC(), nil
+}
+
+// appendTag appends a field key
+func appendTag(b []byte, field, wire int) []byte {
+    return binary.AppendUvarint(b, uint64(field)<<3|uint64(wire))
+}
+
+// appendVarintField appends a non-zero varint field; proto3 omits defaults
+func appendVarintField(b []byte, field int, v uint64) []byte {
+    if v == 0 {
+        return b
+    }
+    b = appendTag(b, field, wireVarint)
+    return binary.AppendUvarint(b, v)
+}
+
+// appendBool appends a true bool field
+func appendBool(b []byte, field int, v bool) []byte {
+    if !v {
+        return b
+    }
+    return appendVarintField(b, field, 1)
+}
+
+// appendDouble appends a non-zero double field
+func appendDouble(b []byte, field int, v float64) []byte {
+    if v == 0 {
+        return b
+    }
+    b = appendTag(b, field, wireFixed64)
+    return binary.LittleEndian.AppendUint64(b, math.Float64bits(v))
+}
+
+// appendString appends a non-empty string field
+func appendString(b []byte, field int, v string) []byte {
+    if v == "" {
+        return b
+    }
+    b = appendTag(b, field, wireBytes)
+    b = binary.AppendUvarint(b, uint64(len(v)))
+    return append(b, v...)
+}
+
+// appendMessage appends an embedded message field, even when empty
+func appendMessage(b []byte, field int, msg []byte) []byte {
+    b = appendTag(b, field, wireBytes)
+    b = binary.AppendUvarint(b, uint64(len(msg)))
+    return append(b, msg...)
+}
+
+// decodeFields calls visit for each field of a message with its number,
+// wire type and value: v for varint and fixed fields, raw for
+// length-delimited ones
+func decodeFields(data []byte, visit func(field int, wire int, v uint64, raw []byte) error) error {
+    for len(data) > 0 {
+        key, n := binary.Uvarint(data)
+        if n <= 0 {
+            return fmt.Errorf("invalid protobuf field key")
+        }
+        data = data[n:]
+        field, wire := int(key>>3), int(key&7)
+        if field == 0 {
+            return fmt.Errorf("invalid protobuf field number 0")
+        }
+
+        var v uint64
+        var raw []byte
+        switch wire {
+        case wireVarint:
+            v, n = binary.Uvarint(data)
+            if n <= 0 {
+                return fmt.Errorf("invalid varint in field %d", field)
+            }
+            data = data[n:]
+        case wireFixed64:
+            if len(data) < 8 {
+                return fmt.Errorf("truncated fixed64 in field %d", field)
+            }
+            v = binary.LittleEndian.Uint64(data)
+            data = data[8:]
+        case wireFixed32:
+            if len(data) < 4 {
+                return fmt.Errorf("truncated fixed32 in field %d", field)
+            }
+            v = uint64(binary.LittleEndian.Uint32(data))
+            data = data[4:]
+        case wireBytes:
+            length, n := binary.Uvarint(data)
+            if n <= 0 || uint64(len(data)-n) < length {
+                return fmt.Errorf("truncated bytes in field %d", field)
+            }
+            raw = data[n : n+int(length)]
+            data = data[n+int(length):]
+        default:
+            return fmt.Errorf("unsupported wire type %d in field %d", wire, field)
+        }
+        if err := visit(field, wire, v, raw); err != nil {
+            return err
+        }
+    }
+    return nil
+}
@@ -0,0 +1,65 @@
+package common
+
+import (
+    "bytes"
+    "reflect"
+    "testing"
+    "time"
+)
+
+func TestPricePointProtoWireFormat(t *testing.T) {
+    p := PricePoint{Price: 1.5, Timestamp: time.Unix(1, 0)}
+    // price (field 1, fixed64) 1.5, timestamp (field 3) {seconds: 1}
+    want := []byte{0x09, 0, 0, 0, 0, 0, 0, 0xf8, 0x3f, 0x1a, 0x02, 0x08, 0x01}
+    if got := p.MarshalProto(); !bytes.Equal(got, want) {
+        t.Errorf("Expected %x, got %x", want, got)
+    }
+}
+
+func TestAggregateResultProtoRoundTrip(t *testing.T) {
+    ts := time.Date(2024, 6, 1, 12, 0, 0, 123456789, time.UTC)
+    result := &AggregateResult{
+        Symbol:     "BTCUSDT",
+        PricePoint: PricePoint{Price: 65000.25, Volume: 1200, Timestamp: ts},
+        Sources: []SourcePrice{
+            {Source: "binance", PricePoint: PricePoint{Price: 65000, Timestamp: ts}},
+            {Source: "kraken", Tier: "fallback", Quote: "USDT", PricePoint: PricePoint{Price: 65001, Volume: 3, Timestamp: ts}},
+        },
+        RoundID:        42,
+        ConfigVersion:  "abc123",
+        FallbackReason: "insufficient_sources",
+        MarketClosed:   true,
+        Rejected:       []SourcePrice{{Source: "coinbase", PricePoint: PricePoint{Price: 70000, Timestamp: ts}}},
+        Backfilled:     true,
+        Candle:         &Candle{Interval: "1m", Open: 1, High: 3, Low: 0.5, Close: 2, Rounds: 4},
+        Abandoned:      []string{"coinbase"},
+        RawPrice:       65000.5,
+        Clamped:        &Clamped{Bound: BoundCap, Price: 65000.5},
+        Frozen:         true,
+    }
+
+    var decoded AggregateResult
+    if err := decoded.UnmarshalProto(result.MarshalProto()); err != nil {
+        t.Fatalf("Failed to decode: %v", err)
+    }
+    if !reflect.DeepEqual(&decoded, result) {
+        t.Errorf("Expected %+v, got %+v", result, &decoded)
+    }
+}
+
+func TestAggregateResultProtoUnknownFields(t *testing.T) {
+    data := (&AggregateResult{Symbol: "ETHUSDT", RoundID: 7}).MarshalProto()
+    // A varint field 99 and a bytes field 100 from a newer schema revision
+    data = append(data, 0x98, 0x06, 0x01, 0xa2, 0x06, 0x02, 'h', 'i')
+
+    var decoded AggregateResult
+    if err := decoded.UnmarshalProto(data); err != nil {
+        t.Fatalf("Failed to decode: %v", err)
+    }
+    if decoded.Symbol != "ETHUSDT" || decoded.RoundID != 7 {
+        t.Errorf("Expected ETHUSDT round 7, got %+v", decoded)
+    }
+    if err := decoded.UnmarshalProto(data[:len(data)-1]); err == nil {
+        t.Error("Expected an error for a truncated payload")
+    }
+}
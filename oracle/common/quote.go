@@ -0,0 +1,36 @@
+package common
+
+import (
+    "fmt"
+    "math"
+    "time"
+)
+
+// Member returns the member of the class for a quote asset
+func (c QuoteClass) Member(asset string) (QuoteMember, bool) {
+    m, ok := c.Members[asset]
+    return m, ok
+}
+
+// Rate returns the factor converting member-quoted prices into the class
+// unit. feed is the latest price of m.Feed, nil when it has none; it is
+// ignored for members without a feed.
+func (m QuoteMember) Rate(feed *PricePoint, now time.Time) (float64, error) {
+    factor := m.Factor
+    if factor == 0 {
+        factor = 1
+    }
+    if m.Feed != "" {
+        if feed == nil || feed.Price <= 0 {
+            return 0, fmt.Errorf("no price for quote feed %s", m.Feed)
+        }
+        if m.MaxAgeSeconds > 0 && now.Sub(feed.Timestamp) > time.Duration(m.MaxAgeSeconds)*time.Second {
+            return 0, fmt.Errorf("quote feed %s is stale since %s", m.Feed, feed.Timestamp.Format(time.RFC3339))
+        }
+        factor = feed.Price
+    }
+    if m.MaxAdjustment > 0 && math.Abs(factor-1) > m.MaxAdjustment {
+        return 0, fmt.Errorf("quote factor %.6f is beyond the maximum adjustment of %g", factor, m.MaxAdjustment)
+    }
+    return factor, nil
+}
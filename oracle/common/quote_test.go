@@ -0,0 +1,47 @@
+package common
+
+import (
+    "testing"
+    "time"
+)
+
+func TestQuoteMemberRate(t *testing.T) {
+    now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+    fresh := &PricePoint{Price: 0.998, Timestamp: now.Add(-10 * time.Second)}
+    stale := &PricePoint{Price: 0.998, Timestamp: now.Add(-10 * time.Minute)}
+    depegged := &PricePoint{Price: 0.95, Timestamp: now}
+
+    cases := []struct {
+        name    string
+        member  QuoteMember
+        feed    *PricePoint
+        want    float64
+        wantErr bool
+    }{
+        {name: "par", member: QuoteMember{}, want: 1},
+        {name: "fixed factor", member: QuoteMember{Factor: 0.999}, want: 0.999},
+        {name: "feed", member: QuoteMember{Feed: "USDTUSD", MaxAgeSeconds: 60}, feed: fresh, want: 0.998},
+        {name: "feed overrides factor", member: QuoteMember{Factor: 1.01, Feed: "USDTUSD"}, feed: fresh, want: 0.998},
+        {name: "missing feed", member: QuoteMember{Feed: "USDTUSD"}, wantErr: true},
+        {name: "stale feed", member: QuoteMember{Feed: "USDTUSD", MaxAgeSeconds: 60}, feed: stale, wantErr: true},
+        {name: "depeg beyond adjustment", member: QuoteMember{Feed: "USDTUSD", MaxAdjustment: 0.02}, feed: depegged, wantErr: true},
+        {name: "depeg within adjustment", member: QuoteMember{Feed: "USDTUSD", MaxAdjustment: 0.1}, feed: depegged, want: 0.95},
+    }
+    for _, tc := range cases {
+        t.Run(tc.name, func(t *testing.T) {
+            got, err := tc.member.Rate(tc.feed, now)
+            if tc.wantErr {
+                if err == nil {
+                    t.Fatalf("expected error, got %v", got)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("unexpected error: %v", err)
+            }
+            if got != tc.want {
+                t.Errorf("rate = %v, want %v", got, tc.want)
+            }
+        })
+    }
+}
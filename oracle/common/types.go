@@ -7,6 +7,70 @@ type BaseConfig struct {
     Exchanges ExchangeConfig `json:"exchanges"`
     Chains    ChainConfig   `json:"chains"`
     Assets    AssetConfig   `json:"assets"`
+    // HTTP identifies the oracle's traffic to every upstream
+    HTTP      HTTPIdentity  `json:"http,omitempty"`
+    // QuoteClasses group quote assets that feeds quoted in the class unit
+    // may combine, keyed by the unit (e.g. "USD")
+    QuoteClasses map[string]QuoteClass `json:"quoteClasses,omitempty"`
+}
+
+// QuoteClass is a set of quote assets treated as the same unit, e.g. USDT,
+// USDC and USD for "USD"
+type QuoteClass struct {
+    Members map[string]QuoteMember `json:"members"`
+}
+
+// QuoteMember converts prices quoted in a member asset into the class unit
+type QuoteMember struct {
+    // Factor multiplies member-quoted prices; 0 means 1
+    Factor        float64 `json:"factor,omitempty"`
+    // Feed is a feed pricing the member in the class unit (e.g. "USDTUSD");
+    // its latest price replaces Factor, so depegs carry into the conversion
+    Feed          string  `json:"feed,omitempty"`
+    // MaxAgeSeconds rejects Feed prices older than this; 0 accepts any age
+    MaxAgeSeconds int     `json:"maxAgeSeconds,omitempty"`
+    // MaxAdjustment excludes member-quoted sources while the factor is
+    // further than this fraction from 1; 0 never excludes them
+    MaxAdjustment float64 `json:"maxAdjustment,omitempty"`
+}
+
+// HTTPIdentity is the User-Agent and extra headers sent with upstream requests
+type HTTPIdentity struct {
+    UserAgent string            `json:"userAgent,omitempty"`
+    Headers   map[string]string `json:"headers,omitempty"`
+    // CacheMs shares identical successful responses among the requests made
+    // within this many milliseconds, e.g. by pairs fetched in the same tick;
+    // 0 leaves responses uncached
+    CacheMs   int               `json:"cacheMs,omitempty"`
+    // TLS is the client certificate of a source requiring mutual TLS
+    TLS       *ClientTLS        `json:"tls,omitempty"`
+}
+
+// ClientTLS is the client certificate presented to a source requiring
+// mutual TLS. The certificate and key are each read from a file or from a
+// credential holding the PEM, and are loaded again when credentials rotate.
+type ClientTLS struct {
+    CertFile string `json:"certFile,omitempty"`
+    // CertEnv names the credential holding the PEM certificate
+    CertEnv  string `json:"certEnv,omitempty"`
+    KeyFile  string `json:"keyFile,omitempty"`
+    // KeyEnv names the credential holding the PEM private key
+    KeyEnv   string `json:"keyEnv,omitempty"`
+    // CAFile verifies the source against a private CA instead of the
+    // system roots
+    CAFile   string `json:"caFile,omitempty"`
+}
+
+// Attribution is the credit and terms a data provider requires of anyone
+// redistributing its data
+type Attribution struct {
+    Provider string `json:"provider"`
+    // Text is the notice to display, e.g. "Data provided by CoinGecko"
+    Text     string `json:"text"`
+    URL      string `json:"url,omitempty"`
+    License  string `json:"license,omitempty"`
+    // Terms links the provider's terms of use
+    Terms    string `json:"terms,omitempty"`
 }
 
 // ExchangeConfig holds both CEX and DEX configurations
@@ -22,16 +86,238 @@ type CEXDetails struct {
     RequiresKey bool   `json:"requiresKey"`
     RateLimit   int    `json:"rateLimit"`
     Timeout     int    `json:"timeout"`
+    // StatusPage is the exchange's Statuspage URL announcing maintenance
+    StatusPage  string `json:"statusPage,omitempty"`
+    // StatusComponents restricts status page maintenance to components whose
+    // names contain one of these; empty counts every announced maintenance
+    StatusComponents []string `json:"statusComponents,omitempty"`
+    // HTTP overrides the global User-Agent and headers for this exchange
+    HTTP        HTTPIdentity `json:"http,omitempty"`
+    // Attribution is the credit the exchange requires of redistributors
+    Attribution *Attribution `json:"attribution,omitempty"`
+    // OrderBook prices the exchange's sources from local order books kept
+    // from WebSocket deltas instead of REST tickers
+    OrderBook   *OrderBookStreamConfig `json:"orderBook,omitempty"`
+    // ClockSkew is how far the exchange's own timestamps may stray from the
+    // local clock before its prices are rejected
+    ClockSkew   *ClockSkewConfig `json:"clockSkew,omitempty"`
+    // Volume declares what the exchange's ticker volume measures, overriding
+    // the built-in semantics of known exchanges
+    Volume      *VolumeSemantics `json:"volume,omitempty"`
+    // LatencySLO deprioritizes the exchange while its fetches are
+    // chronically slower than the objective
+    LatencySLO  *LatencySLOConfig `json:"latencySlo,omitempty"`
+    // Symbols maps canonical asset symbols to the ones the exchange lists
+    // them under, e.g. {"BTC": "XBT"}
+    Symbols     map[string]string `json:"symbols,omitempty"`
+}
+
+// Volume bases, the period a reported volume covers
+const (
+    VolumeRolling24h    = "rolling24h"    // the trailing 24 hours
+    VolumeSinceMidnight = "sinceMidnight" // the current UTC day so far
+    VolumeLifetime      = "lifetime"      // a counter that only grows
+)
+
+// Volume units
+const (
+    VolumeUnitBase  = "base"  // in the base asset
+    VolumeUnitQuote = "quote" // in the quote asset
+)
+
+// VolumeSemantics tags what a source's volume measures so that volumes are
+// converted to rolling 24h base volume before they weight sources
+type VolumeSemantics struct {
+    Basis string `json:"basis"`          // default rolling24h
+    Unit  string `json:"unit,omitempty"` // default base
+}
+
+// ClockSkewConfig sets the clock skew tolerated of an exchange's timestamps
+type ClockSkewConfig struct {
+    // AheadMs accepts timestamps up to this far ahead of the local clock,
+    // as of now, default 1000; further ahead they are rejected as future
+    AheadMs       int `json:"aheadMs,omitempty"`
+    // BehindMs extends MaxAgeSeconds for an exchange whose clock lags,
+    // default 1000
+    BehindMs      int `json:"behindMs,omitempty"`
+    // MaxAgeSeconds rejects prices whose timestamp is older than this,
+    // default 60
+    MaxAgeSeconds int `json:"maxAgeSeconds,omitempty"`
+}
+
+// Ahead returns how far ahead of the local clock timestamps are accepted
+func (c *ClockSkewConfig) Ahead() time.Duration {
+    if c.AheadMs <= 0 {
+        return time.Second
+    }
+    return time.Duration(c.AheadMs) * time.Millisecond
+}
+
+// Behind returns the allowance for a lagging exchange clock
+func (c *ClockSkewConfig) Behind() time.Duration {
+    if c.BehindMs <= 0 {
+        return time.Second
+    }
+    return time.Duration(c.BehindMs) * time.Millisecond
+}
+
+// MaxAge returns the age beyond which a price is stale
+func (c *ClockSkewConfig) MaxAge() time.Duration {
+    if c.MaxAgeSeconds <= 0 {
+        return time.Minute
+    }
+    return time.Duration(c.MaxAgeSeconds) * time.Second
+}
+
+// Actions taken on an exchange whose p95 latency exceeds its SLO
+const (
+    SLOShortenTimeout = "shorten_timeout" // fetch it with a shorter timeout
+    SLOFallback       = "fallback"        // fetch it only after the primary tier
+)
+
+// LatencySLOConfig is the p95 response latency an exchange is expected to
+// keep over its recent fetches
+type LatencySLOConfig struct {
+    P95Ms     int    `json:"p95Ms"`
+    // Action is shorten_timeout (default) or fallback
+    Action    string `json:"action,omitempty"`
+    // TimeoutMs bounds fetches of the exchange while shorten_timeout
+    // applies, default P95Ms
+    TimeoutMs int    `json:"timeoutMs,omitempty"`
+    // Window is the number of recent fetches the p95 is computed over,
+    // default 100
+    Window    int    `json:"window,omitempty"`
+    // CooldownSeconds is how long a slow exchange stays deprioritized
+    // before it is measured again, default 300
+    CooldownSeconds int `json:"cooldownSeconds,omitempty"`
+}
+
+// Objective returns the p95 latency objective
+func (c *LatencySLOConfig) Objective() time.Duration {
+    return time.Duration(c.P95Ms) * time.Millisecond
+}
+
+// SlowAction returns the action taken while the objective is missed
+func (c *LatencySLOConfig) SlowAction() string {
+    if c.Action == "" {
+        return SLOShortenTimeout
+    }
+    return c.Action
+}
+
+// Timeout returns the fetch timeout of a deprioritized exchange under
+// shorten_timeout
+func (c *LatencySLOConfig) Timeout() time.Duration {
+    if c.TimeoutMs <= 0 {
+        return c.Objective()
+    }
+    return time.Duration(c.TimeoutMs) * time.Millisecond
+}
+
+// Samples returns the number of fetches the p95 is computed over
+func (c *LatencySLOConfig) Samples() int {
+    if c.Window <= 0 {
+        return 100
+    }
+    return c.Window
+}
+
+// Cooldown returns how long a slow exchange stays deprioritized
+func (c *LatencySLOConfig) Cooldown() time.Duration {
+    if c.CooldownSeconds <= 0 {
+        return 5 * time.Minute
+    }
+    return time.Duration(c.CooldownSeconds) * time.Second
+}
+
+// Order book price modes
+const (
+    BookPriceMid        = "mid"        // midpoint of the best bid and ask
+    BookPriceMicroprice = "microprice" // best prices weighted by the opposite side's size
+    BookPriceTrade      = "trade"      // last trade print within the book's bounds
+)
+
+// OrderBookStreamConfig configures an exchange's order book stream
+type OrderBookStreamConfig struct {
+    // URL is the WebSocket endpoint, the exchange's public one when empty
+    URL           string `json:"url,omitempty"`
+    // Price selects mid, microprice or trade, default microprice
+    Price         string `json:"price,omitempty"`
+    // MaxAgeSeconds is how long after its last update a book is still
+    // used, default 30; staler books fall back to REST
+    MaxAgeSeconds int    `json:"maxAgeSeconds,omitempty"`
+    // Depth is the number of levels kept per side, default 25
+    Depth         int    `json:"depth,omitempty"`
+    // MaxTradeDeviation is how far outside the best bid and ask a trade
+    // print may be, relative to the touch, under the trade price; prints
+    // further off are dropped as bad ticks. Default 0.005.
+    MaxTradeDeviation float64 `json:"maxTradeDeviation,omitempty"`
+}
+
+// MaxAge returns how long a book is used after its last update
+func (c *OrderBookStreamConfig) MaxAge() time.Duration {
+    if c.MaxAgeSeconds <= 0 {
+        return 30 * time.Second
+    }
+    return time.Duration(c.MaxAgeSeconds) * time.Second
+}
+
+// Levels returns the number of levels kept per side
+func (c *OrderBookStreamConfig) Levels() int {
+    if c.Depth <= 0 {
+        return 25
+    }
+    return c.Depth
+}
+
+// TradeTolerance returns how far outside the touch a trade print is kept
+func (c *OrderBookStreamConfig) TradeTolerance() float64 {
+    if c.MaxTradeDeviation <= 0 {
+        return 0.005
+    }
+    return c.MaxTradeDeviation
+}
+
+// PriceMode returns the price derived from the book
+func (c *OrderBookStreamConfig) PriceMode() string {
+    if c.Price == "" {
+        return BookPriceMicroprice
+    }
+    return c.Price
 }
 
 // DEXDetails represents a decentralized exchange configuration
 type DEXDetails struct {
     Name         string `json:"name"`
     Type         string `json:"type"`
+    Protocol     string `json:"protocol,omitempty"` // uniswap_v2 or uniswap_v3
+    Chain        string `json:"chain,omitempty"`
     Endpoint     string `json:"endpoint"`
     RequiresKey  bool   `json:"requiresKey"`
     MinLiquidity int64  `json:"minLiquidity"`
     Timeout      int    `json:"timeout"`
+    // HTTP overrides the global User-Agent and headers for this subgraph
+    HTTP         HTTPIdentity `json:"http,omitempty"`
+    // Auth sends this subgraph's API key in a request header instead of
+    // embedding a key in the endpoint path
+    Auth         *SubgraphAuth `json:"auth,omitempty"`
+    // Attribution is the credit the data provider requires of redistributors
+    Attribution  *Attribution  `json:"attribution,omitempty"`
+}
+
+// SubgraphAuth is the API key a GraphQL source authenticates with. The key is
+// read on every request, so replacing the file (or the variable, for a
+// re-exec'd process) rotates it without touching the endpoint.
+type SubgraphAuth struct {
+    // Header carries the key; defaults to Authorization
+    Header  string `json:"header,omitempty"`
+    // Scheme prefixes the key in the header; defaults to "Bearer" for the
+    // Authorization header and to none for any other header
+    Scheme  string `json:"scheme,omitempty"`
+    // KeyEnv names the environment variable holding the key
+    KeyEnv  string `json:"keyEnv,omitempty"`
+    // KeyFile is a file holding the key, e.g. a mounted secret
+    KeyFile string `json:"keyFile,omitempty"`
 }
 
 // ChainConfig represents blockchain network configurations
@@ -58,12 +344,24 @@ type Asset struct {
     Name     string                     `json:"name"`
     Decimals int                        `json:"decimals"`
     Chains   map[string]ChainAssetInfo `json:"chains"`
+    // CoinGeckoID identifies the asset on CoinGecko, set when onboarded from
+    // token lists
+    CoinGeckoID string `json:"coingeckoId,omitempty"`
 }
 
 // ChainAssetInfo represents token information on a specific chain
 type ChainAssetInfo struct {
     Type    string `json:"type"`    // native, wrapped, token
-    Address string `json:"address"`
+    Address string `json:"address"` // for native assets, the wrapped token address used by DEX pools
+}
+
+// AddressOn returns the asset's contract address on the given chain
+func (a Asset) AddressOn(chainID string) (string, bool) {
+    info, ok := a.Chains[chainID]
+    if !ok || info.Address == "" {
+        return "", false
+    }
+    return info.Address, true
 }
 
 // PairConfig represents trading pair configurations
@@ -73,6 +371,203 @@ type PairConfig struct {
     MinimumSources        int            `json:"minimumSources"`
     UpdateFrequencySeconds int            `json:"updateFrequencySeconds"`
     Sources              SourcesConfig   `json:"sources"`
+    // FallbackTiers are consulted in order only while the sources fetched so
+    // far fall short of MinimumSources or trip the deviation guard
+    FallbackTiers        []SourcesConfig `json:"fallbackTiers,omitempty"`
+    MaxSourceDeviation   float64         `json:"maxSourceDeviation,omitempty"` // fraction of the median
+    // FeedClass selects the trading calendar (e.g. "forex", "stock"); empty trades 24/7
+    FeedClass            string          `json:"feedClass,omitempty"`
+    // Groups tag the pair for bulk admin operations and list filters,
+    // e.g. "lending-protocol-a"
+    Groups               []string        `json:"groups,omitempty"`
+    // SourceWeights are relative weights of individual sources in the
+    // weighted median; sources without an entry weigh 1
+    SourceWeights        map[string]float64 `json:"sourceWeights,omitempty"`
+    // Profile names a tuning profile of pairs.json whose aggregation
+    // parameters apply where Aggregation leaves a field unset
+    Profile              string             `json:"profile,omitempty"`
+    Aggregation          AggregationParams  `json:"aggregation,omitempty"`
+    // LatencyBudgetMs bounds how long a round waits for slow sources once
+    // MinimumSources have responded; 0 waits for every source
+    LatencyBudgetMs      int                `json:"latencyBudgetMs,omitempty"`
+    // QuoteAssets fetches individual exchanges in another member of the
+    // quote currency's class (e.g. {"binance": "USDT"} for a USD pair) and
+    // converts their prices into the quote currency
+    QuoteAssets          map[string]string  `json:"quoteAssets,omitempty"`
+    // Transform is applied to the aggregated price before it is stored or
+    // published, e.g. "price * 1e8" or "1 / price"; it may reference
+    // "price", TransformVariables and other pair or derived feeds
+    Transform            string             `json:"transform,omitempty"`
+    TransformVariables   map[string]float64 `json:"transformVariables,omitempty"`
+    // Bounds clamp the published price after aggregation and transform,
+    // as lending protocols require for some collateral
+    Bounds               *PriceBounds       `json:"bounds,omitempty"`
+    // ColdStart bootstraps volatility and sanity bounds for a pair that has
+    // no history of its own yet
+    ColdStart            *ColdStartConfig   `json:"coldStart,omitempty"`
+    // OnDemand aggregates a rarely queried pair only when it is queried,
+    // instead of on a schedule
+    OnDemand             *OnDemandConfig    `json:"onDemand,omitempty"`
+    // BidAsk also aggregates the sources' best bid and ask into the
+    // sub-feeds SYMBOL.bid, SYMBOL.mid and SYMBOL.ask
+    BidAsk               *BidAskConfig      `json:"bidAsk,omitempty"`
+    // Freeze holds the pair at its last good value when a round trips a
+    // hard divergence or jump threshold, until an operator acknowledges it
+    Freeze               *FreezeConfig      `json:"freeze,omitempty"`
+}
+
+// FreezeConfig sets the hard thresholds at which a pair freezes. Either
+// may be zero to leave it unchecked, but not both.
+type FreezeConfig struct {
+    // MaxDivergence is the largest relative distance of any kept source
+    // price from their median, e.g. 0.1
+    MaxDivergence float64 `json:"maxDivergence,omitempty"`
+    // MaxJump is the largest relative move from the last good round, e.g. 0.3
+    MaxJump       float64 `json:"maxJump,omitempty"`
+}
+
+// BidAskConfig aggregates the two sides of a pair's market across the
+// sources quoting both, for protocols that need conservative two-sided
+// prices
+type BidAskConfig struct {
+    // Method is BidAskMedian (the default) or BidAskWidest
+    Method         string `json:"method,omitempty"`
+    // MinimumSources quoting both sides a round needs to update the
+    // sub-feeds; default 1
+    MinimumSources int    `json:"minimumSources,omitempty"`
+}
+
+// Bid/ask aggregation methods. The mid is the weighted median of the
+// sources' mids under either.
+const (
+    // BidAskMedian takes the weighted median of the bids and of the asks
+    BidAskMedian = "median"
+    // BidAskWidest takes the lowest bid and the highest ask
+    BidAskWidest = "widest"
+)
+
+// OnDemandConfig caches the rounds of an on-demand pair and caps what its
+// queries may cost upstream
+type OnDemandConfig struct {
+    // TTLSeconds is how long a round is served before a query aggregates
+    // afresh; default 60
+    TTLSeconds        int `json:"ttlSeconds,omitempty"`
+    // MaxRoundsPerHour caps the rounds queries may trigger in any hour;
+    // beyond it the last round is served however old. Zero is unlimited.
+    MaxRoundsPerHour  int `json:"maxRoundsPerHour,omitempty"`
+}
+
+// TTL returns how long an on-demand round is served
+func (c *OnDemandConfig) TTL() time.Duration {
+    if c.TTLSeconds <= 0 {
+        return time.Minute
+    }
+    return time.Duration(c.TTLSeconds) * time.Second
+}
+
+// PriceBounds are the floor and cap of a range feed; either may be unset
+type PriceBounds struct {
+    Floor *float64 `json:"floor,omitempty"`
+    Cap   *float64 `json:"cap,omitempty"`
+}
+
+// Clamp applies the bounds to price, returning the bound that applied:
+// BoundFloor, BoundCap or empty
+func (b *PriceBounds) Clamp(price float64) (float64, string) {
+    switch {
+    case b == nil:
+        return price, ""
+    case b.Floor != nil && price < *b.Floor:
+        return *b.Floor, BoundFloor
+    case b.Cap != nil && price > *b.Cap:
+        return *b.Cap, BoundCap
+    }
+    return price, ""
+}
+
+// Bounds that clamp a range feed's price
+const (
+    BoundFloor = "floor"
+    BoundCap   = "cap"
+)
+
+// Clamped records that a round's price was clamped to a bound
+type Clamped struct {
+    Bound string  `json:"bound"` // BoundFloor or BoundCap
+    Price float64 `json:"price"` // the price before clamping
+}
+
+// ColdStartConfig estimates the statistics of a new pair from a proxy
+// feed's stored rounds or, without a proxy, from its exchanges' klines
+type ColdStartConfig struct {
+    // ProxyFeed is a feed whose returns stand in for the pair's
+    ProxyFeed   string  `json:"proxyFeed,omitempty"`
+    // WindowHours is the history estimated from, default 24; the pair
+    // leaves cold start once its own rounds cover it
+    WindowHours int     `json:"windowHours,omitempty"`
+    // BoundsSigma places the sanity bounds this many standard deviations
+    // of a round's expected move around the previous price, default 4
+    BoundsSigma float64 `json:"boundsSigma,omitempty"`
+}
+
+// Window returns the estimation window
+func (c *ColdStartConfig) Window() time.Duration {
+    if c.WindowHours <= 0 {
+        return 24 * time.Hour
+    }
+    return time.Duration(c.WindowHours) * time.Hour
+}
+
+// Sigma returns the width of the sanity bounds in standard deviations
+func (c *ColdStartConfig) Sigma() float64 {
+    if c.BoundsSigma <= 0 {
+        return 4
+    }
+    return c.BoundsSigma
+}
+
+// LatencyBudget returns the round latency budget, 0 for none
+func (p *PairConfig) LatencyBudget() time.Duration {
+    return time.Duration(p.LatencyBudgetMs) * time.Millisecond
+}
+
+// Volume boost modes
+const (
+    VolumeBoostNone   = "none"   // weights ignore reported volume
+    VolumeBoostLinear = "linear" // weight * (1 + volumeShare)
+    VolumeBoostSqrt   = "sqrt"   // weight * (1 + sqrt(volumeShare))
+)
+
+// AggregationParams tunes how the source prices of a pair are combined
+type AggregationParams struct {
+    // VolumeBoost scales source weights by each source's share of the
+    // reported volume; empty means none
+    VolumeBoost         string  `json:"volumeBoost,omitempty"`
+    // MaxVolumeMultiplier caps the boost multiplier; 0 leaves it uncapped
+    MaxVolumeMultiplier float64 `json:"maxVolumeMultiplier,omitempty"`
+    // IQRMultiplier rejects prices beyond this many weighted interquartile
+    // ranges outside the quartiles; 0 disables outlier rejection
+    IQRMultiplier       float64 `json:"iqrMultiplier,omitempty"`
+    // SamplingWindowMs fetches each source at randomized offsets within
+    // this window instead of all sources at once; 0 fetches simultaneously
+    SamplingWindowMs    int     `json:"samplingWindowMs,omitempty"`
+    // SamplesPerSource is how many times each source is fetched within the
+    // sampling window, default 1; the source's median sample is used
+    SamplesPerSource    int     `json:"samplesPerSource,omitempty"`
+}
+
+// SamplingWindow returns the sampling window, 0 for simultaneous fetches
+func (p AggregationParams) SamplingWindow() time.Duration {
+    return time.Duration(p.SamplingWindowMs) * time.Millisecond
+}
+
+// Samples returns the number of fetches per source within the sampling
+// window
+func (p AggregationParams) Samples() int {
+    if p.SamplesPerSource <= 0 {
+        return 1
+    }
+    return p.SamplesPerSource
 }
 
 // SourcesConfig represents available price sources for a pair
@@ -92,7 +587,69 @@ type CEXSourceConfig struct {
 type DEXSourceConfig struct {
     Enabled   bool                    `json:"enabled"`
     Weight    float64                 `json:"weight"`
-    Exchanges map[string][]string    `json:"exchanges"` // chain -> DEX list
+    Exchanges map[string][]string    `json:"exchanges,omitempty"` // chain -> DEX list
+    Pools     []DEXPool               `json:"pools,omitempty"`
+    // Subgraphs are sources priced across several pools of a subgraph DEX
+    Subgraphs []SubgraphPoolSource    `json:"subgraphs,omitempty"`
+}
+
+// SubgraphPoolSource prices one source as the liquidity-weighted median of
+// several pools read from a subgraph DEX, so that no single imbalanced pool
+// sets it
+type SubgraphPoolSource struct {
+    Exchange string   `json:"exchange"` // a subgraph DEX with a chain
+    // Pools are the addresses of the pools priced; TopPools prices the
+    // deepest pools trading the pair instead, as ranked on every fetch
+    Pools    []string `json:"pools,omitempty"`
+    TopPools int      `json:"topPools,omitempty"`
+    // Quote is the asset the pools trade the base against when it is
+    // another member of the pair's quote class, e.g. USDC for a USD pair
+    Quote    string   `json:"quote,omitempty"`
+}
+
+// DEXPool identifies a specific liquidity pool used as a price source
+type DEXPool struct {
+    Chain    string `json:"chain"`
+    Exchange string `json:"exchange"`
+    Address  string `json:"address"`
+    Token0   string `json:"token0"`
+    Token1   string `json:"token1"`
+    // Quote is the asset the pool trades the base against when it is
+    // another member of the pair's quote class, e.g. USDC for a USD pair
+    Quote    string `json:"quote,omitempty"`
+}
+
+// Derived feed types
+const (
+    DerivedInverse = "inverse" // 1 / inputs[0]
+    DerivedCross   = "cross"   // inputs[0] / inputs[1]
+    DerivedProduct = "product" // inputs[0] * inputs[1]
+    DerivedBasket  = "basket"  // sum of weights[i] * inputs[i]
+    DerivedReal    = "real"    // inputs[0] * baseIndex / inputs[1], a price index such as CPI
+)
+
+// DerivedFeedConfig represents a feed computed from other feeds rather than fetched
+type DerivedFeedConfig struct {
+    Type    string    `json:"type"`
+    Inputs  []string  `json:"inputs"`
+    Weights []float64 `json:"weights,omitempty"` // basket only
+    // BaseIndex is the price index level of the base period, e.g. CPI of
+    // January 2024 to express prices in January 2024 dollars; real only
+    BaseIndex float64 `json:"baseIndex,omitempty"`
+}
+
+// Statistic feed types
+const (
+    StatisticVolatility  = "volatility"  // annualized realized volatility of inputs[0]
+    StatisticCorrelation = "correlation" // correlation of inputs[0] and inputs[1] returns
+)
+
+// StatisticFeedConfig represents a feed computed from the stored history of other feeds
+type StatisticFeedConfig struct {
+    Type          string   `json:"type"`
+    Inputs        []string `json:"inputs"`
+    WindowHours   int      `json:"windowHours"`
+    SampleSeconds int      `json:"sampleSeconds,omitempty"` // resampling interval for returns
 }
 
 // PricePoint represents a price data point from any source
@@ -100,4 +657,84 @@ type PricePoint struct {
     Price     float64   `json:"price"`
     Volume    float64   `json:"volume"`
     Timestamp time.Time `json:"timestamp"`
-} 
\ No newline at end of file
+    // Bid and Ask are the best bid and ask of a source quoting both sides,
+    // or of a round aggregating them; zero otherwise
+    Bid       float64   `json:"bid,omitempty"`
+    Ask       float64   `json:"ask,omitempty"`
+} 
+
+// ExecutionEstimate represents the expected fill for a trade of a given size
+type ExecutionEstimate struct {
+    Side           string    `json:"side"`           // buy or sell
+    Size           float64   `json:"size"`           // notional in quote currency
+    MidPrice       float64   `json:"midPrice"`
+    ExecutionPrice float64   `json:"executionPrice"`
+    SlippageBps    float64   `json:"slippageBps"`
+    Sources        []string  `json:"sources"`
+    Timestamp      time.Time `json:"timestamp"`
+}
+
+
+// SourcePrice is a price point attributed to the source that produced it
+type SourcePrice struct {
+    Source string `json:"source"`
+    Tier   string `json:"tier,omitempty"` // empty for primary sources
+    // Quote is the asset the source was fetched in when it differs from the
+    // pair's quote currency; the price is already converted
+    Quote  string `json:"quote,omitempty"`
+    // QuoteRate is the rate the price was converted at, e.g. the USDT/USD
+    // peg rate for a USDT-quoted source of a USD pair
+    QuoteRate float64 `json:"quoteRate,omitempty"`
+    PricePoint
+}
+
+// SourceFailure is why a source contributed no price to a round
+type SourceFailure struct {
+    Source string `json:"source"`
+    Tier   string `json:"tier,omitempty"` // empty for primary sources
+    Reason string `json:"reason"`
+}
+
+// AggregateResult is the outcome of an aggregation round for a trading pair
+type AggregateResult struct {
+    Symbol string `json:"symbol"`
+    PricePoint
+    Sources       []SourcePrice `json:"sources"`
+    RoundID       uint64        `json:"roundId"`
+    ConfigVersion string        `json:"configVersion"` // hash of the resolved config used for the round
+    FallbackReason string       `json:"fallbackReason,omitempty"`
+    // MarketClosed marks a carried last-close value of a feed whose market
+    // is outside its trading session, as opposed to a stale feed
+    MarketClosed  bool          `json:"marketClosed,omitempty"`
+    // Rejected are source prices dropped as outliers before the median
+    Rejected      []SourcePrice `json:"rejected,omitempty"`
+    // Backfilled marks a round reconstructed from exchange history rather
+    // than aggregated live
+    Backfilled    bool          `json:"backfilled,omitempty"`
+    // Candle summarizes the rounds a downsampled result replaced; nil for
+    // rounds kept at full resolution
+    Candle        *Candle       `json:"candle,omitempty"`
+    // Abandoned are sources left out because they exceeded the round's
+    // latency budget after the others reached quorum
+    Abandoned     []string      `json:"abandoned,omitempty"`
+    // RawPrice is the aggregated price before the pair's transform and
+    // bounds; zero when neither changed it
+    RawPrice      float64       `json:"rawPrice,omitempty"`
+    // Clamped is set when the pair's bounds replaced the price
+    Clamped       *Clamped      `json:"clamped,omitempty"`
+    // Mid is the aggregated mid of a pair with BidAsk, set with Bid and Ask
+    Mid           float64       `json:"mid,omitempty"`
+    // Frozen marks the last good round of a pair frozen on divergence,
+    // served until an operator acknowledges the freeze
+    Frozen        bool          `json:"frozen,omitempty"`
+}
+
+// Candle is the OHLC summary of the rounds within one downsampling interval
+type Candle struct {
+    Interval string  `json:"interval"` // e.g. "1m" or "1h"
+    Open     float64 `json:"open"`
+    High     float64 `json:"high"`
+    Low      float64 `json:"low"`
+    Close    float64 `json:"close"`
+    Rounds   int     `json:"rounds"` // number of raw rounds summarized
+}
@@ -0,0 +1,188 @@
+package consistency
+
+import (
+    "context"
+    "fmt"
+    "math"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/events"
+    "yetaXYZ/oracle/store"
+)
+
+// Defaults applied when the config leaves them unset
+const (
+    defaultToleranceBps = 50
+    defaultInterval     = time.Minute
+)
+
+// Result is the outcome of checking one triangle
+type Result struct {
+    Name         string    `json:"name"`
+    Feed         string    `json:"feed"`
+    Price        float64   `json:"price,omitempty"`
+    Implied      float64   `json:"implied,omitempty"`
+    DeviationBps float64   `json:"deviationBps"`
+    ToleranceBps float64   `json:"toleranceBps"`
+    Breached     bool      `json:"breached"`
+    Skipped      string    `json:"skipped,omitempty"` // why the triangle could not be checked
+    CheckedAt    time.Time `json:"checkedAt"`
+}
+
+// Checker periodically verifies that related feeds agree with each other
+// and alerts when a triangle's implied price deviates beyond tolerance
+type Checker struct {
+    config *Config
+    store  store.Store
+    bus    *events.Bus
+
+    mu      sync.RWMutex
+    results map[string]*Result
+}
+
+// NewChecker creates a checker reading feed values from the store
+func NewChecker(config *Config, s store.Store, bus *events.Bus) *Checker {
+    return &Checker{
+        config:  config,
+        store:   s,
+        bus:     bus,
+        results: make(map[string]*Result),
+    }
+}
+
+// Interval returns the configured check interval
+func (c *Checker) Interval() time.Duration {
+    if c.config.IntervalSeconds > 0 {
+        return time.Duration(c.config.IntervalSeconds) * time.Second
+    }
+    return defaultInterval
+}
+
+// Run checks all triangles at interval until ctx is cancelled
+func (c *Checker) Run(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            c.CheckAll(time.Now())
+        }
+    }
+}
+
+// CheckAll checks every triangle and raises an alert for each triangle
+// that newly breaches its tolerance
+func (c *Checker) CheckAll(now time.Time) []*Result {
+    names := make([]string, 0, len(c.config.Triangles))
+    for name := range c.config.Triangles {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    results := make([]*Result, 0, len(names))
+    for _, name := range names {
+        t := c.config.Triangles[name]
+        result, legs := c.check(name, t, now)
+
+        c.mu.Lock()
+        previous := c.results[name]
+        c.results[name] = result
+        c.mu.Unlock()
+
+        if result.Breached && (previous == nil || !previous.Breached) {
+            c.bus.Publish(events.Event{
+                Type:   events.Alert,
+                Symbol: t.Feed,
+                Payload: &events.AlertPayload{
+                    Severity: events.SeverityWarning,
+                    Kind:     "triangle_inconsistent",
+                    Message: fmt.Sprintf("%s: %s at %g vs %g implied by %s (%.1fbp, tolerance %.1fbp)",
+                        name, t.Feed, result.Price, result.Implied, legs, result.DeviationBps, result.ToleranceBps),
+                },
+            })
+        }
+        results = append(results, result)
+    }
+    return results
+}
+
+// check computes the deviation of a triangle's feed from its implied price
+func (c *Checker) check(name string, t *Triangle, now time.Time) (*Result, string) {
+    result := &Result{Name: name, Feed: t.Feed, ToleranceBps: c.tolerance(t), CheckedAt: now}
+
+    price, err := c.fresh(t.Feed, now)
+    if err != nil {
+        result.Skipped = err.Error()
+        return result, ""
+    }
+
+    implied := 1.0
+    legs := make([]string, 0, len(t.Legs))
+    for _, leg := range t.Legs {
+        value, err := c.fresh(leg.Feed, now)
+        if err != nil {
+            result.Skipped = err.Error()
+            return result, ""
+        }
+        if leg.Invert {
+            implied /= value
+            legs = append(legs, fmt.Sprintf("1/%s(%g)", leg.Feed, value))
+        } else {
+            implied *= value
+            legs = append(legs, fmt.Sprintf("%s(%g)", leg.Feed, value))
+        }
+    }
+
+    result.Price = price
+    result.Implied = implied
+    result.DeviationBps = math.Abs(price/implied-1) * 10000
+    result.Breached = result.DeviationBps > result.ToleranceBps
+    return result, strings.Join(legs, " * ")
+}
+
+// fresh returns the latest positive price of a feed no older than the max age
+func (c *Checker) fresh(symbol string, now time.Time) (float64, error) {
+    return store.Fresh(c.store, symbol, time.Duration(c.config.MaxAgeSeconds)*time.Second, now)
+}
+
+// tolerance returns the tolerance of a triangle in basis points
+func (c *Checker) tolerance(t *Triangle) float64 {
+    if t.ToleranceBps > 0 {
+        return t.ToleranceBps
+    }
+    if c.config.ToleranceBps > 0 {
+        return c.config.ToleranceBps
+    }
+    return defaultToleranceBps
+}
+
+// Results returns the latest result of every triangle sorted by name
+func (c *Checker) Results() []*Result {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    out := make([]*Result, 0, len(c.results))
+    for _, r := range c.results {
+        out = append(out, r)
+    }
+    sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+    return out
+}
+
+// Feeds returns the feed and leg feeds of a triangle
+func (c *Checker) Feeds(name string) []string {
+    t, ok := c.config.Triangles[name]
+    if !ok {
+        return nil
+    }
+    feeds := []string{t.Feed}
+    for _, leg := range t.Legs {
+        feeds = append(feeds, leg.Feed)
+    }
+    return feeds
+}
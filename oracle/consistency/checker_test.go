@@ -0,0 +1,73 @@
+package consistency
+
+import (
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+    "yetaXYZ/oracle/store"
+)
+
+func TestTriangleBreachAlertsOnce(t *testing.T) {
+    now := time.Now()
+    s := store.NewMemoryStore()
+    save := func(symbol string, price float64, at time.Time) {
+        s.SaveRound(&common.AggregateResult{Symbol: symbol, PricePoint: common.PricePoint{Price: price, Timestamp: at}})
+    }
+    save("ETHUSD", 3000, now)
+    save("ETHUSDC", 3000, now)
+    save("USDCUSD", 1.0001, now)
+    save("BTCUSD", 60000, now.Add(-time.Hour))
+    save("BTCUSDC", 60000, now)
+
+    bus := events.NewBus()
+    alerts := bus.Subscribe(10, events.Alert)
+    checker := NewChecker(&Config{
+        ToleranceBps: 50,
+        Triangles: map[string]*Triangle{
+            "eth": {Feed: "ETHUSD", Legs: []Leg{{Feed: "ETHUSDC"}, {Feed: "USDCUSD"}}},
+            "btc": {Feed: "BTCUSD", Legs: []Leg{{Feed: "BTCUSDC"}, {Feed: "USDCUSD"}}},
+        },
+    }, s, bus)
+
+    results := checker.CheckAll(now)
+    if results[0].Name != "btc" || results[0].Skipped == "" {
+        t.Errorf("Expected stale BTCUSD to skip its triangle, got %+v", results[0])
+    }
+    if results[1].Breached || results[1].DeviationBps > 1.01 {
+        t.Errorf("Expected consistent ETH triangle, got %+v", results[1])
+    }
+
+    // A depegged USDC leg breaks the triangle
+    save("USDCUSD", 0.98, now)
+    checker.CheckAll(now)
+    checker.CheckAll(now)
+
+    select {
+    case e := <-alerts.C:
+        if e.Symbol != "ETHUSD" {
+            t.Errorf("Expected alert for ETHUSD, got %s", e.Symbol)
+        }
+    case <-time.After(time.Second):
+        t.Fatal("Expected a triangle_inconsistent alert")
+    }
+    select {
+    case e := <-alerts.C:
+        t.Errorf("Expected a single alert while the breach persists, got %+v", e)
+    case <-time.After(50 * time.Millisecond):
+    }
+
+    if got := checker.Results(); len(got) != 2 || !got[1].Breached {
+        t.Errorf("Expected breached ETH triangle in results, got %+v", got)
+    }
+}
+
+func TestValidateRejectsUnknownFeeds(t *testing.T) {
+    config := &Config{Triangles: map[string]*Triangle{
+        "eth": {Feed: "ETHUSD", Legs: []Leg{{Feed: "ETHUSDC"}, {Feed: "USDCUSD"}}},
+    }}
+    if err := config.Validate(map[string]bool{"ETHUSD": true, "ETHUSDC": true}); err == nil {
+        t.Error("Expected unknown leg feed to be rejected")
+    }
+}
@@ -0,0 +1,82 @@
+package consistency
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+)
+
+// Leg is one feed in the product implying a triangle's feed
+type Leg struct {
+    Feed string `json:"feed"`
+    // Invert uses 1/price, e.g. USDTUSDC for a USDC-quoted leg
+    Invert bool `json:"invert,omitempty"`
+}
+
+// Triangle states that Feed should equal the product of its legs, e.g.
+// ETHUSD = ETHUSDC * USDCUSD
+type Triangle struct {
+    Feed string `json:"feed"`
+    Legs []Leg  `json:"legs"`
+    // ToleranceBps overrides the default tolerance for this triangle
+    ToleranceBps float64 `json:"toleranceBps,omitempty"`
+}
+
+// Config holds the consistency check configuration
+type Config struct {
+    IntervalSeconds int     `json:"intervalSeconds"`
+    ToleranceBps    float64 `json:"toleranceBps"`
+    // MaxAgeSeconds skips triangles with a feed older than this, so that a
+    // lagging feed is not mistaken for a corrupted one
+    MaxAgeSeconds int                  `json:"maxAgeSeconds"`
+    Triangles     map[string]*Triangle `json:"triangles"`
+}
+
+// LoadConfig loads consistency/consistency.json from the config directory.
+// A missing file yields a configuration without triangles.
+func LoadConfig(configDir string) (*Config, error) {
+    data, err := os.ReadFile(filepath.Join(configDir, "consistency", "consistency.json"))
+    if os.IsNotExist(err) {
+        return &Config{Triangles: map[string]*Triangle{}}, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read consistency config: %v", err)
+    }
+
+    var config Config
+    if err := json.Unmarshal(data, &config); err != nil {
+        return nil, fmt.Errorf("failed to parse consistency config: %v", err)
+    }
+    if config.Triangles == nil {
+        config.Triangles = map[string]*Triangle{}
+    }
+    return &config, nil
+}
+
+// Validate checks every triangle against the set of known feeds
+func (c *Config) Validate(feeds map[string]bool) error {
+    if c.ToleranceBps < 0 || c.MaxAgeSeconds < 0 {
+        return fmt.Errorf("consistency tolerance and max age must not be negative")
+    }
+    for name, t := range c.Triangles {
+        if !feeds[t.Feed] {
+            return fmt.Errorf("triangle %s references unknown feed %s", name, t.Feed)
+        }
+        if len(t.Legs) < 2 {
+            return fmt.Errorf("triangle %s needs at least two legs", name)
+        }
+        for _, leg := range t.Legs {
+            if !feeds[leg.Feed] {
+                return fmt.Errorf("triangle %s references unknown feed %s", name, leg.Feed)
+            }
+            if leg.Feed == t.Feed {
+                return fmt.Errorf("triangle %s uses its own feed as a leg", name)
+            }
+        }
+        if t.ToleranceBps < 0 {
+            return fmt.Errorf("triangle %s has a negative tolerance", name)
+        }
+    }
+    return nil
+}
@@ -0,0 +1,105 @@
+package costs
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+)
+
+// Cost models
+const (
+    // ModelRequest bills a fixed cost per request
+    ModelRequest = "request"
+    // ModelCredits bills per credit, reading the credits each request used
+    // from a response header
+    ModelCredits = "credits"
+)
+
+// SourceCost is the cost model and budgets of one paid source
+type SourceCost struct {
+    // Hosts are the API hosts billed to the source; default the hosts of
+    // the configured exchange or subgraph of the same name
+    Hosts []string `json:"hosts,omitempty"`
+    Model string   `json:"model"`
+    // CostPerRequest is the cost of each request under the request model
+    CostPerRequest float64 `json:"costPerRequest,omitempty"`
+    // CreditHeader is the response header reporting the credits a request
+    // used, e.g. "X-Credits-Used"; CreditsPerRequest applies without it
+    CreditHeader      string  `json:"creditHeader,omitempty"`
+    CreditsPerRequest float64 `json:"creditsPerRequest,omitempty"` // default 1
+    CostPerCredit     float64 `json:"costPerCredit,omitempty"`
+    // Budgets per UTC day and calendar month; zero for none
+    DailyBudget   float64 `json:"dailyBudget,omitempty"`
+    MonthlyBudget float64 `json:"monthlyBudget,omitempty"`
+}
+
+// Config holds the cost models of paid sources
+type Config struct {
+    Currency string                 `json:"currency,omitempty"` // default USD
+    Sources  map[string]*SourceCost `json:"sources"`
+    // WarnAt is the fraction of a budget that raises a warning before the
+    // critical alert at 100%; default 0.8
+    WarnAt float64 `json:"warnAt,omitempty"`
+    // StateFile persists usage across restarts so monthly budgets hold
+    StateFile       string `json:"stateFile,omitempty"`
+    IntervalSeconds int    `json:"intervalSeconds,omitempty"` // default 60
+}
+
+// LoadConfig loads costs/costs.json from the config directory. A missing
+// file yields a configuration without paid sources.
+func LoadConfig(configDir string) (*Config, error) {
+    data, err := os.ReadFile(filepath.Join(configDir, "costs", "costs.json"))
+    if os.IsNotExist(err) {
+        return &Config{Currency: "USD", Sources: map[string]*SourceCost{}, WarnAt: 0.8}, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read costs config: %v", err)
+    }
+
+    var config Config
+    if err := json.Unmarshal(data, &config); err != nil {
+        return nil, fmt.Errorf("failed to parse costs config: %v", err)
+    }
+    if config.Sources == nil {
+        config.Sources = map[string]*SourceCost{}
+    }
+    if config.Currency == "" {
+        config.Currency = "USD"
+    }
+    if config.WarnAt == 0 {
+        config.WarnAt = 0.8
+    }
+    return &config, config.Validate()
+}
+
+// Validate checks every cost model
+func (c *Config) Validate() error {
+    if c.WarnAt <= 0 || c.WarnAt > 1 {
+        return fmt.Errorf("costs warnAt must be in (0, 1]")
+    }
+    if c.IntervalSeconds < 0 {
+        return fmt.Errorf("costs intervalSeconds must not be negative")
+    }
+    for name, s := range c.Sources {
+        switch s.Model {
+        case ModelRequest:
+            if s.CostPerRequest < 0 {
+                return fmt.Errorf("cost of %s must not be negative", name)
+            }
+        case ModelCredits:
+            if s.CostPerCredit < 0 || s.CreditsPerRequest < 0 {
+                return fmt.Errorf("credits of %s must not be negative", name)
+            }
+            if s.CreditsPerRequest == 0 {
+                s.CreditsPerRequest = 1
+            }
+        default:
+            return fmt.Errorf("unknown cost model %q of %s, want %s or %s", s.Model, name, ModelRequest, ModelCredits)
+        }
+        if s.DailyBudget < 0 || s.MonthlyBudget < 0 {
+            return fmt.Errorf("budgets of %s must not be negative", name)
+        }
+    }
+    return nil
+}
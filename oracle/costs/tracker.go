@@ -0,0 +1,344 @@
+package costs
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "log"
+    "net/http"
+    "os"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/events"
+)
+
+// retentionDays keeps a little over a year of daily usage for monthly
+// roll-ups
+const retentionDays = 400
+
+// Period layouts; usage is keyed by UTC calendar day and month
+const (
+    dayLayout   = "2006-01-02"
+    monthLayout = "2006-01"
+)
+
+// Usage is the consumption of one source over a day or month
+type Usage struct {
+    Source   string  `json:"source"`
+    Period   string  `json:"period"` // e.g. 2024-06-01 or 2024-06
+    Requests uint64  `json:"requests"`
+    Credits  float64 `json:"credits,omitempty"`
+    Cost     float64 `json:"cost"`
+    Budget   float64 `json:"budget,omitempty"`
+}
+
+// state is what the state file persists
+type state struct {
+    Days    map[string]map[string]*Usage `json:"days"`
+    Alerted map[string]bool              `json:"alerted,omitempty"`
+}
+
+// Tracker accounts for the estimated cost of requests to paid sources and
+// raises alerts as they approach their budgets
+type Tracker struct {
+    config *Config
+    bus    *events.Bus
+    // hosts are the explicitly configured hosts of each source; others are
+    // matched through sources, the configured source names by host
+    hosts   map[string]string
+    sources func() map[string][]string
+
+    mu sync.Mutex
+    state
+}
+
+// NewTracker creates a tracker, restoring usage from the state file.
+// sources returns the names of the configured sources served by each host.
+func NewTracker(config *Config, bus *events.Bus, sources func() map[string][]string) (*Tracker, error) {
+    t := &Tracker{
+        config:  config,
+        bus:     bus,
+        hosts:   make(map[string]string),
+        sources: sources,
+        state:   state{Days: make(map[string]map[string]*Usage), Alerted: make(map[string]bool)},
+    }
+    for name, s := range config.Sources {
+        for _, host := range s.Hosts {
+            t.hosts[host] = name
+        }
+    }
+    if err := t.load(); err != nil {
+        return nil, err
+    }
+    return t, nil
+}
+
+// Currency returns the currency costs and budgets are given in
+func (t *Tracker) Currency() string {
+    return t.config.Currency
+}
+
+// Interval returns how often usage is pruned and persisted
+func (t *Tracker) Interval() time.Duration {
+    if t.config.IntervalSeconds > 0 {
+        return time.Duration(t.config.IntervalSeconds) * time.Second
+    }
+    return time.Minute
+}
+
+// source returns the paid source billed for requests to host, if any
+func (t *Tracker) source(host string) (string, *SourceCost) {
+    if name, ok := t.hosts[host]; ok {
+        return name, t.config.Sources[name]
+    }
+    if t.sources == nil {
+        return "", nil
+    }
+    for _, name := range t.sources()[host] {
+        if s, ok := t.config.Sources[name]; ok && len(s.Hosts) == 0 {
+            return name, s
+        }
+    }
+    return "", nil
+}
+
+// Observe accounts for one response from host. Every response counts,
+// successful or not, since providers bill them alike; credits reported in
+// the response header take precedence over the configured estimate.
+func (t *Tracker) Observe(host string, header http.Header, now time.Time) {
+    name, s := t.source(host)
+    if s == nil {
+        return
+    }
+
+    var credits, cost float64
+    switch s.Model {
+    case ModelRequest:
+        cost = s.CostPerRequest
+    case ModelCredits:
+        credits = s.CreditsPerRequest
+        if s.CreditHeader != "" {
+            if used, err := strconv.ParseFloat(strings.TrimSpace(header.Get(s.CreditHeader)), 64); err == nil && used >= 0 {
+                credits = used
+            }
+        }
+        cost = credits * s.CostPerCredit
+    }
+
+    now = now.UTC()
+    day, month := now.Format(dayLayout), now.Format(monthLayout)
+    t.mu.Lock()
+    if t.Days[day] == nil {
+        t.Days[day] = make(map[string]*Usage)
+    }
+    u := t.Days[day][name]
+    if u == nil {
+        u = &Usage{Source: name, Period: day}
+        t.Days[day][name] = u
+    }
+    u.Requests++
+    u.Credits += credits
+    u.Cost += cost
+
+    alerts := t.checkBudget(name, day, u.Cost, s.DailyBudget)
+    alerts = append(alerts, t.checkBudget(name, month, t.monthCost(name, month), s.MonthlyBudget)...)
+    t.mu.Unlock()
+
+    for _, alert := range alerts {
+        t.bus.Publish(events.Event{Type: events.Alert, Timestamp: now, Payload: alert})
+    }
+}
+
+// monthCost sums a source's daily cost over a month; callers hold mu
+func (t *Tracker) monthCost(name, month string) float64 {
+    var total float64
+    for day, sources := range t.Days {
+        if strings.HasPrefix(day, month) && sources[name] != nil {
+            total += sources[name].Cost
+        }
+    }
+    return total
+}
+
+// checkBudget returns the alerts a source's spend over a period raises,
+// each at most once per period; callers hold mu
+func (t *Tracker) checkBudget(name, period string, spent, budget float64) []*events.AlertPayload {
+    if budget <= 0 {
+        return nil
+    }
+    var alerts []*events.AlertPayload
+    for _, level := range []struct {
+        severity string
+        fraction float64
+    }{{events.SeverityWarning, t.config.WarnAt}, {events.SeverityCritical, 1}} {
+        key := name + "/" + period + "/" + level.severity
+        if spent < budget*level.fraction || t.Alerted[key] {
+            continue
+        }
+        t.Alerted[key] = true
+        alerts = append(alerts, &events.AlertPayload{
+            Severity: level.severity,
+            Kind:     "source_budget",
+            Message:  fmt.Sprintf("%s spent %.2f %s of its %.2f %s budget for %s (%.0f%%)", name, spent, t.config.Currency, budget, t.config.Currency, period, spent/budget*100),
+        })
+    }
+    return alerts
+}
+
+// Daily returns the usage of each source per day from from to to, oldest
+// first
+func (t *Tracker) Daily(from, to time.Time) []Usage {
+    first, last := from.UTC().Format(dayLayout), to.UTC().Format(dayLayout)
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    out := make([]Usage, 0)
+    for day, sources := range t.Days {
+        if day < first || day > last {
+            continue
+        }
+        for name, u := range sources {
+            usage := *u
+            if s := t.config.Sources[name]; s != nil {
+                usage.Budget = s.DailyBudget
+            }
+            out = append(out, usage)
+        }
+    }
+    sortUsage(out)
+    return out
+}
+
+// Monthly rolls the daily usage of each source up by calendar month, from
+// the month of from to that of to, oldest first
+func (t *Tracker) Monthly(from, to time.Time) []Usage {
+    first, last := from.UTC().Format(monthLayout), to.UTC().Format(monthLayout)
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    months := make(map[string]*Usage)
+    for day, sources := range t.Days {
+        month := day[:len(monthLayout)]
+        if month < first || month > last {
+            continue
+        }
+        for name, u := range sources {
+            key := month + "/" + name
+            m := months[key]
+            if m == nil {
+                m = &Usage{Source: name, Period: month}
+                if s := t.config.Sources[name]; s != nil {
+                    m.Budget = s.MonthlyBudget
+                }
+                months[key] = m
+            }
+            m.Requests += u.Requests
+            m.Credits += u.Credits
+            m.Cost += u.Cost
+        }
+    }
+    out := make([]Usage, 0, len(months))
+    for _, m := range months {
+        out = append(out, *m)
+    }
+    sortUsage(out)
+    return out
+}
+
+// sortUsage orders usage by period, then source
+func sortUsage(usage []Usage) {
+    sort.Slice(usage, func(i, j int) bool {
+        if usage[i].Period != usage[j].Period {
+            return usage[i].Period < usage[j].Period
+        }
+        return usage[i].Source < usage[j].Source
+    })
+}
+
+// Run prunes usage beyond the retention and persists it every interval
+// until ctx is cancelled, persisting once more on the way out
+func (t *Tracker) Run(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            if err := t.persist(); err != nil {
+                log.Printf("Failed to persist source costs: %v", err)
+            }
+            return
+        case now := <-ticker.C:
+            t.prune(now)
+            if err := t.persist(); err != nil {
+                log.Printf("Failed to persist source costs: %v", err)
+            }
+        }
+    }
+}
+
+// prune drops days beyond the retention and alerts of past periods
+func (t *Tracker) prune(now time.Time) {
+    oldest := now.UTC().AddDate(0, 0, -retentionDays).Format(dayLayout)
+    day, month := now.UTC().Format(dayLayout), now.UTC().Format(monthLayout)
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    for d := range t.Days {
+        if d < oldest {
+            delete(t.Days, d)
+        }
+    }
+    for key := range t.Alerted {
+        parts := strings.Split(key, "/")
+        period := parts[len(parts)-2]
+        if period != day && period != month {
+            delete(t.Alerted, key)
+        }
+    }
+}
+
+// load restores usage from the state file, if any
+func (t *Tracker) load() error {
+    if t.config.StateFile == "" {
+        return nil
+    }
+    data, err := os.ReadFile(t.config.StateFile)
+    if os.IsNotExist(err) {
+        return nil
+    }
+    if err != nil {
+        return fmt.Errorf("failed to read source costs: %v", err)
+    }
+    var stored state
+    if err := json.Unmarshal(data, &stored); err != nil {
+        return fmt.Errorf("failed to parse source costs: %v", err)
+    }
+    if stored.Days != nil {
+        t.Days = stored.Days
+    }
+    if stored.Alerted != nil {
+        t.Alerted = stored.Alerted
+    }
+    return nil
+}
+
+// persist writes usage to the state file, if any
+func (t *Tracker) persist() error {
+    if t.config.StateFile == "" {
+        return nil
+    }
+    t.mu.Lock()
+    data, err := json.Marshal(t.state)
+    t.mu.Unlock()
+    if err != nil {
+        return err
+    }
+    tmp := t.config.StateFile + ".tmp"
+    if err := os.WriteFile(tmp, data, 0600); err != nil {
+        return fmt.Errorf("failed to write source costs: %v", err)
+    }
+    return os.Rename(tmp, t.config.StateFile)
+}
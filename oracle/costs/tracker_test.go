@@ -0,0 +1,82 @@
+package costs
+
+import (
+    "net/http"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/events"
+)
+
+func TestTracker(t *testing.T) {
+    config := &Config{
+        Currency: "USD",
+        WarnAt:   0.8,
+        Sources: map[string]*SourceCost{
+            "coinmarketcap": {Hosts: []string{"pro-api.coinmarketcap.com"}, Model: ModelCredits, CreditHeader: "X-Credits-Used", CostPerCredit: 0.01, DailyBudget: 0.1},
+            "polygon":       {Model: ModelRequest, CostPerRequest: 0.002, MonthlyBudget: 1},
+        },
+        StateFile: filepath.Join(t.TempDir(), "costs.json"),
+    }
+    if err := config.Validate(); err != nil {
+        t.Fatal(err)
+    }
+    bus := events.NewBus()
+    alerts := bus.Subscribe(10, events.Alert)
+    defer alerts.Close()
+    sources := func() map[string][]string {
+        return map[string][]string{"api.polygon.io": {"polygon"}, "api.binance.com": {"binance"}}
+    }
+    tracker, err := NewTracker(config, bus, sources)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    day := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+    credits := http.Header{}
+    credits.Set("X-Credits-Used", "3")
+    tracker.Observe("pro-api.coinmarketcap.com", credits, day)
+    tracker.Observe("pro-api.coinmarketcap.com", http.Header{}, day) // one credit without the header
+    tracker.Observe("api.binance.com", http.Header{}, day)           // free
+    tracker.Observe("api.polygon.io", http.Header{}, day)
+    tracker.Observe("api.polygon.io", http.Header{}, day.AddDate(0, 0, 1))
+
+    daily := tracker.Daily(day, day.AddDate(0, 0, 1))
+    if len(daily) != 3 {
+        t.Fatalf("Expected three daily entries, got %+v", daily)
+    }
+    cmc := daily[0]
+    if cmc.Source != "coinmarketcap" || cmc.Requests != 2 || cmc.Credits != 4 || cmc.Cost < 0.0399 || cmc.Cost > 0.0401 || cmc.Budget != 0.1 {
+        t.Errorf("Unexpected CoinMarketCap usage %+v", cmc)
+    }
+    monthly := tracker.Monthly(day, day)
+    if len(monthly) != 2 || monthly[1].Source != "polygon" || monthly[1].Requests != 2 || monthly[1].Period != "2024-06" || monthly[1].Budget != 1 {
+        t.Errorf("Unexpected monthly roll-up %+v", monthly)
+    }
+
+    // 80% of the daily budget warns, 100% is critical, each once per day
+    for i := 0; i < 7; i++ {
+        tracker.Observe("pro-api.coinmarketcap.com", http.Header{}, day)
+    }
+    var severities []string
+    for len(alerts.C) > 0 {
+        e := <-alerts.C
+        severities = append(severities, e.Payload.(*events.AlertPayload).Severity)
+    }
+    if len(severities) != 2 || severities[0] != events.SeverityWarning || severities[1] != events.SeverityCritical {
+        t.Errorf("Expected a warning then a critical alert, got %v", severities)
+    }
+
+    // Usage survives a restart through the state file
+    if err := tracker.persist(); err != nil {
+        t.Fatal(err)
+    }
+    restored, err := NewTracker(config, bus, sources)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got := restored.Monthly(day, day); len(got) != 2 || got[0].Requests != 9 {
+        t.Errorf("Expected usage restored, got %+v", got)
+    }
+}
@@ -0,0 +1,366 @@
+// Package credentials resolves named secrets such as API and signer keys.
+// Values come from an optional credentials file, falling back to the
+// environment, and the file can be reloaded at runtime to rotate keys
+// without a restart. A replaced value keeps being accepted for a drain
+// period so clients still presenting it are not cut off mid-rotation.
+package credentials
+
+import (
+    "bufio"
+    "bytes"
+    "context"
+    "fmt"
+    "log"
+    "os"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/redact"
+)
+
+// DefaultDrain is how long replaced values stay accepted
+const DefaultDrain = 10 * time.Minute
+
+// retiredValue is a replaced value accepted until its drain ends
+type retiredValue struct {
+    value string
+    until time.Time
+}
+
+// Store holds the credentials loaded from a file
+type Store struct {
+    path  string
+    drain time.Duration
+
+    mu       sync.RWMutex
+    values   map[string]string
+    retired  map[string][]retiredValue
+    rotated  map[string]time.Time
+    modTime  time.Time
+    handlers []func(changed []string)
+}
+
+// NewStore loads the credentials file at path; an empty path resolves
+// every name from the environment
+func NewStore(path string, drain time.Duration) (*Store, error) {
+    s := &Store{
+        path:    path,
+        drain:   drain,
+        values:  make(map[string]string),
+        retired: make(map[string][]retiredValue),
+        rotated: make(map[string]time.Time),
+    }
+    if path == "" {
+        return s, nil
+    }
+    if _, err := s.Reload(time.Now()); err != nil {
+        return nil, err
+    }
+    // Values loaded at startup replace nothing that needs draining
+    s.retired = make(map[string][]retiredValue)
+    s.rotated = make(map[string]time.Time)
+    return s, nil
+}
+
+// Path returns the credentials file, empty when there is none
+func (s *Store) Path() string {
+    return s.path
+}
+
+// Get returns the current value of name, from the file when it defines
+// name and otherwise from the environment
+func (s *Store) Get(name string) string {
+    s.mu.RLock()
+    value, ok := s.values[name]
+    s.mu.RUnlock()
+    if ok {
+        return value
+    }
+    return os.Getenv(name)
+}
+
+// Values returns the current value of name followed by replaced values
+// that are still draining, for checking credentials presented by clients
+func (s *Store) Values(name string) []string {
+    var values []string
+    if current := s.Get(name); current != "" {
+        values = append(values, current)
+    }
+
+    now := time.Now()
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    for _, r := range s.retired[name] {
+        if now.Before(r.until) {
+            values = append(values, r.value)
+        }
+    }
+    return values
+}
+
+// Expand replaces ${NAME} and $NAME references in text with credentials
+func (s *Store) Expand(text string) string {
+    return os.Expand(text, s.Get)
+}
+
+// OnChange registers fn to be called with the names whose values a reload
+// changed
+func (s *Store) OnChange(fn func(changed []string)) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.handlers = append(s.handlers, fn)
+}
+
+// Reload re-reads the credentials file and returns the names whose values
+// changed. Replaced values drain until now plus the drain period. A file
+// that fails to parse leaves the current credentials in place.
+func (s *Store) Reload(now time.Time) ([]string, error) {
+    values, modTime, err := s.read()
+    if err != nil {
+        return nil, err
+    }
+    for _, value := range values {
+        redact.Register(value)
+    }
+
+    s.mu.Lock()
+    changed := s.changed(values)
+    for _, name := range changed {
+        if previous, ok := s.values[name]; ok {
+            s.retire(name, previous, now)
+        } else if previous := os.Getenv(name); previous != "" && previous != values[name] {
+            // The environment value the file now overrides drains too
+            s.retire(name, previous, now)
+        }
+        s.rotated[name] = now
+    }
+    s.values = values
+    s.modTime = modTime
+    handlers := append([]func([]string){}, s.handlers...)
+    s.mu.Unlock()
+
+    if len(changed) > 0 {
+        for _, fn := range handlers {
+            fn(changed)
+        }
+    }
+    return changed, nil
+}
+
+// Pending re-reads the credentials file and returns the names a reload
+// would change, without applying them
+func (s *Store) Pending() ([]string, error) {
+    values, _, err := s.read()
+    if err != nil {
+        return nil, err
+    }
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    return s.changed(values), nil
+}
+
+// read parses the credentials file
+func (s *Store) read() (map[string]string, time.Time, error) {
+    if s.path == "" {
+        return nil, time.Time{}, fmt.Errorf("no credentials file configured")
+    }
+    info, err := os.Stat(s.path)
+    if err != nil {
+        return nil, time.Time{}, fmt.Errorf("failed to read credentials: %v", err)
+    }
+    data, err := os.ReadFile(s.path)
+    if err != nil {
+        return nil, time.Time{}, fmt.Errorf("failed to read credentials: %v", err)
+    }
+    values, err := parse(data)
+    if err != nil {
+        return nil, time.Time{}, fmt.Errorf("invalid credentials file %s: %v", s.path, err)
+    }
+    return values, info.ModTime(), nil
+}
+
+// changed returns the sorted names whose values differ between the loaded
+// file and values; callers hold mu
+func (s *Store) changed(values map[string]string) []string {
+    var changed []string
+    for name, previous := range s.values {
+        if values[name] != previous {
+            changed = append(changed, name)
+        }
+    }
+    for name := range values {
+        if _, ok := s.values[name]; !ok {
+            changed = append(changed, name)
+        }
+    }
+    sort.Strings(changed)
+    return changed
+}
+
+// retire keeps a replaced value accepted for the drain period, dropping
+// values whose drain has ended; callers hold mu
+func (s *Store) retire(name, value string, now time.Time) {
+    kept := []retiredValue{{value: value, until: now.Add(s.drain)}}
+    for _, r := range s.retired[name] {
+        if now.Before(r.until) && r.value != value {
+            kept = append(kept, r)
+        }
+    }
+    s.retired[name] = kept
+}
+
+// Status is the rotation state of one credential, without its value
+type Status struct {
+    Name      string     `json:"name"`
+    Source    string     `json:"source"` // file or env
+    RotatedAt *time.Time `json:"rotatedAt,omitempty"`
+    Draining  int        `json:"draining"`
+}
+
+// Status reports the credentials defined in the file and those rotated
+// since startup
+func (s *Store) Status(now time.Time) []Status {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    names := make(map[string]bool)
+    for name := range s.values {
+        names[name] = true
+    }
+    for name := range s.rotated {
+        names[name] = true
+    }
+
+    statuses := make([]Status, 0, len(names))
+    for name := range names {
+        status := Status{Name: name, Source: "env"}
+        if rotated, ok := s.rotated[name]; ok {
+            status.RotatedAt = &rotated
+        }
+        if _, ok := s.values[name]; ok {
+            status.Source = "file"
+        }
+        for _, r := range s.retired[name] {
+            if now.Before(r.until) {
+                status.Draining++
+            }
+        }
+        statuses = append(statuses, status)
+    }
+    sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+    return statuses
+}
+
+// Interval returns how often the credentials file is checked for changes
+func (s *Store) Interval() time.Duration {
+    return 10 * time.Second
+}
+
+// Run reloads the credentials file whenever its modification time changes
+// until ctx is cancelled
+func (s *Store) Run(ctx context.Context, interval time.Duration) {
+    if s.path == "" {
+        return
+    }
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+
+        info, err := os.Stat(s.path)
+        if err != nil {
+            log.Printf("Error checking credentials file: %v", err)
+            continue
+        }
+        s.mu.RLock()
+        unchanged := info.ModTime().Equal(s.modTime)
+        s.mu.RUnlock()
+        if unchanged {
+            continue
+        }
+        changed, err := s.Reload(time.Now())
+        if err != nil {
+            log.Printf("Error reloading credentials: %v", err)
+            continue
+        }
+        if len(changed) > 0 {
+            log.Printf("Reloaded credentials: %s", strings.Join(changed, ", "))
+        }
+    }
+}
+
+// parse reads NAME=value lines. Blank lines and lines starting with # are
+// skipped, an "export " prefix is allowed and values may be quoted.
+func parse(data []byte) (map[string]string, error) {
+    values := make(map[string]string)
+    scanner := bufio.NewScanner(bytes.NewReader(data))
+    line := 0
+    for scanner.Scan() {
+        line++
+        text := strings.TrimSpace(scanner.Text())
+        if text == "" || strings.HasPrefix(text, "#") {
+            continue
+        }
+        text = strings.TrimPrefix(text, "export ")
+        name, value, ok := strings.Cut(text, "=")
+        name = strings.TrimSpace(name)
+        if !ok || name == "" || strings.ContainsAny(name, " \t") {
+            return nil, fmt.Errorf("line %d: expected NAME=value", line)
+        }
+        value = strings.TrimSpace(value)
+        if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+            value = value[1 : len(value)-1]
+        }
+        if _, exists := values[name]; exists {
+            return nil, fmt.Errorf("line %d: duplicate %s", line, name)
+        }
+        values[name] = value
+    }
+    return values, scanner.Err()
+}
+
+var (
+    defaultMu    sync.RWMutex
+    defaultStore = &Store{
+        values:  make(map[string]string),
+        retired: make(map[string][]retiredValue),
+        rotated: make(map[string]time.Time),
+    }
+)
+
+// SetDefault makes s the store behind the package-level functions
+func SetDefault(s *Store) {
+    defaultMu.Lock()
+    defaultStore = s
+    defaultMu.Unlock()
+}
+
+// Default returns the store behind the package-level functions, which
+// reads the environment only until SetDefault is called
+func Default() *Store {
+    defaultMu.RLock()
+    defer defaultMu.RUnlock()
+    return defaultStore
+}
+
+// Get returns the current value of name from the default store
+func Get(name string) string {
+    return Default().Get(name)
+}
+
+// Values returns the accepted values of name from the default store
+func Values(name string) []string {
+    return Default().Values(name)
+}
+
+// Expand replaces credential references in text using the default store
+func Expand(text string) string {
+    return Default().Expand(text)
+}
@@ -0,0 +1,83 @@
+package credentials
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+)
+
+func TestStoreRotatesAndDrains(t *testing.T) {
+    t.Setenv("TEST_ENV_ONLY", "from-env")
+    t.Setenv("TEST_GRAPH_KEY", "env-graph")
+    path := filepath.Join(t.TempDir(), "credentials.env")
+    write := func(content string) {
+        if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+            t.Fatal(err)
+        }
+    }
+    write("# rotated by the secrets agent\nexport TEST_GRAPH_KEY=\"graph-one\"\nTEST_ADMIN_TOKEN=admin-one\n")
+
+    store, err := NewStore(path, time.Minute)
+    if err != nil {
+        t.Fatalf("Failed to load credentials: %v", err)
+    }
+    if got := store.Get("TEST_GRAPH_KEY"); got != "graph-one" {
+        t.Errorf("Expected the file to override the environment, got %q", got)
+    }
+    if got := store.Get("TEST_ENV_ONLY"); got != "from-env" {
+        t.Errorf("Expected the environment fallback, got %q", got)
+    }
+    if got := store.Expand("https://gateway/api/${TEST_GRAPH_KEY}/subgraphs"); got != "https://gateway/api/graph-one/subgraphs" {
+        t.Errorf("Unexpected expansion %q", got)
+    }
+    if values := store.Values("TEST_GRAPH_KEY"); len(values) != 1 {
+        t.Errorf("Expected no draining values after startup, got %v", values)
+    }
+
+    var notified []string
+    store.OnChange(func(changed []string) { notified = changed })
+
+    write("TEST_GRAPH_KEY=graph-two\nTEST_ADMIN_TOKEN=admin-one\n")
+    if pending, err := store.Pending(); err != nil || len(pending) != 1 || len(notified) != 0 {
+        t.Fatalf("Expected TEST_GRAPH_KEY to be pending without notifying, got %v (%v)", pending, err)
+    }
+    if got := store.Get("TEST_GRAPH_KEY"); got != "graph-one" {
+        t.Errorf("Expected Pending to leave the current value, got %q", got)
+    }
+    changed, err := store.Reload(time.Now())
+    if err != nil {
+        t.Fatalf("Failed to reload: %v", err)
+    }
+    if len(changed) != 1 || changed[0] != "TEST_GRAPH_KEY" || len(notified) != 1 {
+        t.Fatalf("Expected only TEST_GRAPH_KEY to change, got %v (notified %v)", changed, notified)
+    }
+    values := store.Values("TEST_GRAPH_KEY")
+    if len(values) != 2 || values[0] != "graph-two" || values[1] != "graph-one" {
+        t.Errorf("Expected the new key followed by the draining one, got %v", values)
+    }
+
+    // Keys whose drain has ended are dropped at the next rotation
+    write("TEST_GRAPH_KEY=graph-three\nTEST_ADMIN_TOKEN=admin-one\n")
+    if _, err := store.Reload(time.Now().Add(2 * time.Minute)); err != nil {
+        t.Fatalf("Failed to reload: %v", err)
+    }
+    values = store.Values("TEST_GRAPH_KEY")
+    if len(values) != 2 || values[0] != "graph-three" || values[1] != "graph-two" {
+        t.Errorf("Expected graph-one to have drained, got %v", values)
+    }
+
+    // A broken file keeps the current credentials
+    write("not a credential\n")
+    if _, err := store.Reload(time.Now()); err == nil {
+        t.Error("Expected an error for an invalid line, got nil")
+    }
+    if got := store.Get("TEST_ADMIN_TOKEN"); got != "admin-one" {
+        t.Errorf("Expected credentials to survive a failed reload, got %q", got)
+    }
+
+    statuses := store.Status(time.Now())
+    if len(statuses) != 2 || statuses[1].Name != "TEST_GRAPH_KEY" || statuses[1].RotatedAt == nil {
+        t.Errorf("Unexpected status %+v", statuses)
+    }
+}
@@ -0,0 +1,41 @@
+package credentials
+
+import (
+    "fmt"
+    "log"
+    "os"
+    "time"
+
+    "yetaXYZ/oracle/redact"
+)
+
+// FromEnv loads ORACLE_CREDENTIALS_FILE, if set, keeping replaced values
+// accepted for ORACLE_CREDENTIALS_DRAIN (a duration, default 10m)
+func FromEnv() (*Store, error) {
+    drain := DefaultDrain
+    if value := os.Getenv("ORACLE_CREDENTIALS_DRAIN"); value != "" {
+        d, err := time.ParseDuration(value)
+        if err != nil || d < 0 {
+            return nil, fmt.Errorf("invalid ORACLE_CREDENTIALS_DRAIN: %q", value)
+        }
+        drain = d
+    }
+    return NewStore(os.Getenv("ORACLE_CREDENTIALS_FILE"), drain)
+}
+
+// Setup prepares a process's handling of secrets: secret-looking
+// environment variables and the values of the credentials file are
+// redacted from its log, and the store FromEnv loads becomes the default.
+// Every process fetching from sources calls it first.
+func Setup() (*Store, error) {
+    // Keep API keys embedded in endpoint URLs out of the logs
+    redact.RegisterEnv()
+    log.SetOutput(redact.NewWriter(os.Stderr))
+
+    s, err := FromEnv()
+    if err != nil {
+        return nil, err
+    }
+    SetDefault(s)
+    return s, nil
+}
@@ -0,0 +1,136 @@
+package derived
+
+import (
+    "fmt"
+    "log"
+    "sync"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+)
+
+// Engine recomputes derived feeds as soon as any of their inputs update
+type Engine struct {
+    graph *Graph
+    bus   *events.Bus
+    sub   *events.Subscription
+
+    mu     sync.RWMutex
+    latest map[string]*common.AggregateResult
+    rounds map[string]uint64
+}
+
+// NewEngine creates an engine for the graph that listens for aggregates on bus
+func NewEngine(graph *Graph, bus *events.Bus) *Engine {
+    return &Engine{
+        graph:  graph,
+        bus:    bus,
+        latest: make(map[string]*common.AggregateResult),
+        rounds: make(map[string]uint64),
+    }
+}
+
+// Start subscribes the engine to aggregate events
+func (e *Engine) Start() {
+    e.sub = e.bus.SubscribeFunc(256, e.handle, events.Aggregate).Named("derived")
+}
+
+// Stop unsubscribes the engine
+func (e *Engine) Stop() {
+    if e.sub != nil {
+        e.sub.Close()
+    }
+}
+
+// IsDerived reports whether symbol is a derived feed
+func (e *Engine) IsDerived(symbol string) bool {
+    return e.graph.IsDerived(symbol)
+}
+
+// Latest returns the most recent value of a derived feed
+func (e *Engine) Latest(symbol string) (*common.AggregateResult, bool) {
+    e.mu.RLock()
+    defer e.mu.RUnlock()
+    result, ok := e.latest[symbol]
+    if !ok || !e.graph.IsDerived(symbol) {
+        return nil, false
+    }
+    return result, true
+}
+
+// handle records an input update and recomputes its dependents in order
+func (e *Engine) handle(event events.Event) {
+    result, ok := event.Payload.(*common.AggregateResult)
+    if !ok {
+        return
+    }
+
+    // Derived aggregates published by the engine itself are already recorded
+    if e.graph.IsDerived(event.Symbol) {
+        return
+    }
+
+    e.mu.Lock()
+    e.latest[event.Symbol] = result
+    e.mu.Unlock()
+
+    for _, symbol := range e.graph.Affected(event.Symbol) {
+        derived, err := e.recompute(symbol, result.ConfigVersion)
+        if err != nil {
+            // Inputs not yet available are expected while feeds prime
+            continue
+        }
+        e.bus.Publish(events.Event{
+            Type:    events.Aggregate,
+            Symbol:  symbol,
+            Payload: derived,
+        })
+    }
+}
+
+// recompute evaluates a derived feed from the latest values of its inputs
+func (e *Engine) recompute(symbol, configVersion string) (*common.AggregateResult, error) {
+    feed := e.graph.feeds[symbol]
+
+    e.mu.Lock()
+    defer e.mu.Unlock()
+
+    values := make([]float64, 0, len(feed.Inputs))
+    sources := make([]common.SourcePrice, 0, len(feed.Inputs))
+    var oldest common.PricePoint
+    for i, input := range feed.Inputs {
+        latest, ok := e.latest[input]
+        if !ok {
+            return nil, fmt.Errorf("no value yet for input %s", input)
+        }
+        values = append(values, latest.Price)
+        sources = append(sources, common.SourcePrice{Source: input, PricePoint: latest.PricePoint})
+        // A price index is dated by its reference period, not its freshness
+        if feed.Type == common.DerivedReal && i > 0 {
+            continue
+        }
+        if i == 0 || latest.Timestamp.Before(oldest.Timestamp) {
+            oldest = latest.PricePoint
+        }
+    }
+
+    price, err := Compute(feed, values)
+    if err != nil {
+        log.Printf("Failed to compute derived feed %s: %v", symbol, err)
+        return nil, err
+    }
+
+    e.rounds[symbol]++
+    result := &common.AggregateResult{
+        Symbol: symbol,
+        PricePoint: common.PricePoint{
+            Price:     price,
+            Timestamp: oldest.Timestamp, // a derived value is only as fresh as its stalest input
+        },
+        Sources:       sources,
+        RoundID:       e.rounds[symbol],
+        ConfigVersion: configVersion,
+    }
+    e.latest[symbol] = result
+    return result, nil
+}
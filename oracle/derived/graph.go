@@ -0,0 +1,178 @@
+package derived
+
+import (
+    "fmt"
+    "sort"
+
+    "yetaXYZ/oracle/common"
+)
+
+// Graph is the validated dependency graph of derived feeds
+type Graph struct {
+    feeds      map[string]*common.DerivedFeedConfig
+    dependents map[string][]string // input -> derived feeds using it
+    order      []string            // derived feeds in topological order
+}
+
+// NewGraph validates derived feed definitions against the known base feeds
+// and builds the dependency graph. Missing inputs and cycles are rejected.
+func NewGraph(feeds map[string]*common.DerivedFeedConfig, baseFeeds map[string]bool) (*Graph, error) {
+    g := &Graph{
+        feeds:      feeds,
+        dependents: make(map[string][]string),
+    }
+
+    symbols := make([]string, 0, len(feeds))
+    for symbol := range feeds {
+        symbols = append(symbols, symbol)
+    }
+    sort.Strings(symbols)
+
+    for _, symbol := range symbols {
+        feed := feeds[symbol]
+        if baseFeeds[symbol] {
+            return nil, fmt.Errorf("%s is defined both as a pair and a derived feed", symbol)
+        }
+        if err := validateShape(symbol, feed); err != nil {
+            return nil, err
+        }
+        for _, input := range feed.Inputs {
+            if !baseFeeds[input] && feeds[input] == nil {
+                return nil, fmt.Errorf("%s depends on unknown feed %s", symbol, input)
+            }
+            g.dependents[input] = append(g.dependents[input], symbol)
+        }
+    }
+
+    // Depth-first topological sort with cycle detection
+    const (
+        unvisited = iota
+        visiting
+        done
+    )
+    state := make(map[string]int, len(feeds))
+    var visit func(symbol string, path []string) error
+    visit = func(symbol string, path []string) error {
+        switch state[symbol] {
+        case visiting:
+            return fmt.Errorf("dependency cycle: %v", append(path, symbol))
+        case done:
+            return nil
+        }
+        state[symbol] = visiting
+        for _, input := range feeds[symbol].Inputs {
+            if feeds[input] == nil {
+                continue
+            }
+            if err := visit(input, append(path, symbol)); err != nil {
+                return err
+            }
+        }
+        state[symbol] = done
+        g.order = append(g.order, symbol)
+        return nil
+    }
+    for _, symbol := range symbols {
+        if err := visit(symbol, nil); err != nil {
+            return nil, err
+        }
+    }
+
+    return g, nil
+}
+
+// validateShape checks that a derived feed has the inputs its type needs
+func validateShape(symbol string, feed *common.DerivedFeedConfig) error {
+    switch feed.Type {
+    case common.DerivedInverse:
+        if len(feed.Inputs) != 1 {
+            return fmt.Errorf("%s: inverse feeds take exactly 1 input", symbol)
+        }
+    case common.DerivedCross, common.DerivedProduct:
+        if len(feed.Inputs) != 2 {
+            return fmt.Errorf("%s: %s feeds take exactly 2 inputs", symbol, feed.Type)
+        }
+    case common.DerivedReal:
+        if len(feed.Inputs) != 2 {
+            return fmt.Errorf("%s: real feeds take a price feed and a price index", symbol)
+        }
+        if feed.BaseIndex <= 0 {
+            return fmt.Errorf("%s: real feeds need a positive baseIndex", symbol)
+        }
+    case common.DerivedBasket:
+        if len(feed.Inputs) == 0 || len(feed.Weights) != len(feed.Inputs) {
+            return fmt.Errorf("%s: basket feeds need one weight per input", symbol)
+        }
+    default:
+        return fmt.Errorf("%s: unknown derived feed type %q", symbol, feed.Type)
+    }
+    return nil
+}
+
+// IsDerived reports whether symbol is a derived feed
+func (g *Graph) IsDerived(symbol string) bool {
+    return g.feeds[symbol] != nil
+}
+
+// Inputs returns the direct inputs of a derived feed
+func (g *Graph) Inputs(symbol string) []string {
+    if feed := g.feeds[symbol]; feed != nil {
+        return feed.Inputs
+    }
+    return nil
+}
+
+// Affected returns every derived feed that transitively depends on symbol,
+// in the order they must be recomputed
+func (g *Graph) Affected(symbol string) []string {
+    affected := make(map[string]bool)
+    queue := []string{symbol}
+    for len(queue) > 0 {
+        current := queue[0]
+        queue = queue[1:]
+        for _, dependent := range g.dependents[current] {
+            if !affected[dependent] {
+                affected[dependent] = true
+                queue = append(queue, dependent)
+            }
+        }
+    }
+
+    out := make([]string, 0, len(affected))
+    for _, derived := range g.order {
+        if affected[derived] {
+            out = append(out, derived)
+        }
+    }
+    return out
+}
+
+// Compute evaluates a derived feed from its input values
+func Compute(feed *common.DerivedFeedConfig, inputs []float64) (float64, error) {
+    switch feed.Type {
+    case common.DerivedInverse:
+        if inputs[0] == 0 {
+            return 0, fmt.Errorf("cannot invert a zero price")
+        }
+        return 1 / inputs[0], nil
+    case common.DerivedCross:
+        if inputs[1] == 0 {
+            return 0, fmt.Errorf("cannot cross with a zero price")
+        }
+        return inputs[0] / inputs[1], nil
+    case common.DerivedProduct:
+        return inputs[0] * inputs[1], nil
+    case common.DerivedBasket:
+        total := 0.0
+        for i, v := range inputs {
+            total += feed.Weights[i] * v
+        }
+        return total, nil
+    case common.DerivedReal:
+        if inputs[1] <= 0 {
+            return 0, fmt.Errorf("cannot deflate by a non-positive price index")
+        }
+        return inputs[0] * feed.BaseIndex / inputs[1], nil
+    }
+    return 0, fmt.Errorf("unknown derived feed type %q", feed.Type)
+}
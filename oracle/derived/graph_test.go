@@ -0,0 +1,112 @@
+package derived
+
+import (
+    "math"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+)
+
+func TestNewGraphRejectsInvalidFeeds(t *testing.T) {
+    base := map[string]bool{"ETHUSDT": true, "BTCUSDT": true}
+
+    missing := map[string]*common.DerivedFeedConfig{
+        "ETHEUR": {Type: common.DerivedCross, Inputs: []string{"ETHUSDT", "EURUSDT"}},
+    }
+    if _, err := NewGraph(missing, base); err == nil {
+        t.Error("Expected error for missing dependency, got nil")
+    }
+
+    cycle := map[string]*common.DerivedFeedConfig{
+        "A": {Type: common.DerivedInverse, Inputs: []string{"B"}},
+        "B": {Type: common.DerivedInverse, Inputs: []string{"A"}},
+    }
+    if _, err := NewGraph(cycle, base); err == nil {
+        t.Error("Expected error for dependency cycle, got nil")
+    }
+
+    badShape := map[string]*common.DerivedFeedConfig{
+        "BASKET": {Type: common.DerivedBasket, Inputs: []string{"ETHUSDT", "BTCUSDT"}, Weights: []float64{1}},
+    }
+    if _, err := NewGraph(badShape, base); err == nil {
+        t.Error("Expected error for basket without weights, got nil")
+    }
+}
+
+func TestEngineRecomputesDependents(t *testing.T) {
+    feeds := map[string]*common.DerivedFeedConfig{
+        "ETHBTC": {Type: common.DerivedCross, Inputs: []string{"ETHUSDT", "BTCUSDT"}},
+        "BTCETH": {Type: common.DerivedInverse, Inputs: []string{"ETHBTC"}},
+    }
+    graph, err := NewGraph(feeds, map[string]bool{"ETHUSDT": true, "BTCUSDT": true})
+    if err != nil {
+        t.Fatalf("Failed to build graph: %v", err)
+    }
+    if affected := graph.Affected("BTCUSDT"); len(affected) != 2 || affected[0] != "ETHBTC" {
+        t.Fatalf("Expected ETHBTC then BTCETH to be affected, got %v", affected)
+    }
+
+    bus := events.NewBus()
+    engine := NewEngine(graph, bus)
+    engine.Start()
+    defer engine.Stop()
+
+    publish := func(symbol string, price float64) {
+        bus.Publish(events.Event{
+            Type:    events.Aggregate,
+            Symbol:  symbol,
+            Payload: &common.AggregateResult{Symbol: symbol, PricePoint: common.PricePoint{Price: price, Timestamp: time.Now()}},
+        })
+    }
+    publish("ETHUSDT", 3000)
+    publish("BTCUSDT", 60000)
+
+    deadline := time.Now().Add(time.Second)
+    for {
+        if result, ok := engine.Latest("BTCETH"); ok {
+            if math.Abs(result.Price-20) > 1e-9 {
+                t.Errorf("Expected BTCETH 20, got %f", result.Price)
+            }
+            break
+        }
+        if time.Now().After(deadline) {
+            t.Fatal("Derived feed was not recomputed")
+        }
+        time.Sleep(5 * time.Millisecond)
+    }
+}
+
+func TestRealFeedDeflatesByIndex(t *testing.T) {
+    base := map[string]bool{"ETHUSDT": true, "CPI": true}
+    if _, err := NewGraph(map[string]*common.DerivedFeedConfig{
+        "ETHUSDT_REAL": {Type: common.DerivedReal, Inputs: []string{"ETHUSDT", "CPI"}},
+    }, base); err == nil {
+        t.Error("Expected error for real feed without baseIndex, got nil")
+    }
+
+    feeds := map[string]*common.DerivedFeedConfig{
+        "ETHUSDT_REAL": {Type: common.DerivedReal, Inputs: []string{"ETHUSDT", "CPI"}, BaseIndex: 300},
+    }
+    graph, err := NewGraph(feeds, base)
+    if err != nil {
+        t.Fatalf("Failed to build graph: %v", err)
+    }
+    engine := NewEngine(graph, events.NewBus())
+
+    priced := time.Now()
+    engine.handle(events.Event{Symbol: "CPI", Payload: &common.AggregateResult{Symbol: "CPI", PricePoint: common.PricePoint{Price: 315, Timestamp: priced.AddDate(0, -2, 0)}}})
+    engine.handle(events.Event{Symbol: "ETHUSDT", Payload: &common.AggregateResult{Symbol: "ETHUSDT", PricePoint: common.PricePoint{Price: 3150, Timestamp: priced}}})
+
+    result, ok := engine.Latest("ETHUSDT_REAL")
+    if !ok {
+        t.Fatal("Real feed was not computed")
+    }
+    if math.Abs(result.Price-3000) > 1e-9 {
+        t.Errorf("Expected 3000 in base-period dollars, got %f", result.Price)
+    }
+    if !result.Timestamp.Equal(priced) {
+        t.Errorf("Expected the price's timestamp, not the index period's, got %s", result.Timestamp)
+    }
+}
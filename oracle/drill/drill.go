@@ -0,0 +1,287 @@
+package drill
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/hex"
+    "fmt"
+    "log"
+    "sort"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/events"
+)
+
+// Drill kinds
+const (
+    // KindSourceOpen fails every fetch of a source as if its circuit were
+    // open, without sending it upstream
+    KindSourceOpen = "source_open"
+    // KindFeedDegraded reports a feed's quality as degraded
+    KindFeedDegraded = "feed_degraded"
+    // KindPublishDelay holds back a feed's on-chain publications, as a
+    // congested chain or a slow publisher would
+    KindPublishDelay = "publish_delay"
+)
+
+const (
+    // DefaultDuration is how long a drill lasts unless given a duration
+    DefaultDuration = 15 * time.Minute
+    // MaxDuration bounds drills so that a forgotten one ends by itself
+    MaxDuration = 4 * time.Hour
+    // MaxDelay bounds the publication delay of a publish_delay drill
+    MaxDelay = time.Hour
+)
+
+// Spec describes a drill to start
+type Spec struct {
+    Kind     string
+    Target   string        // source for source_open, feed symbol otherwise
+    Duration time.Duration // 0 means DefaultDuration
+    Delay    time.Duration // publish_delay only
+    Operator string
+    Reason   string
+}
+
+// Drill is a failure injected on an operator's request for a game-day
+// drill, until it expires or is stopped
+type Drill struct {
+    ID        string    `json:"id"`
+    Kind      string    `json:"kind"`
+    Target    string    `json:"target"`
+    Delay     string    `json:"delay,omitempty"`
+    Operator  string    `json:"operator"`
+    Reason    string    `json:"reason,omitempty"`
+    StartedAt time.Time `json:"startedAt"`
+    ExpiresAt time.Time `json:"expiresAt"`
+
+    delay time.Duration
+}
+
+// activeAt reports whether the drill is in effect at t
+func (d *Drill) activeAt(t time.Time) bool {
+    return t.Before(d.ExpiresAt)
+}
+
+// OpenError is returned for fetches from a source whose circuit a drill
+// forced open
+type OpenError struct {
+    Source string
+}
+
+func (e *OpenError) Error() string {
+    return fmt.Sprintf("drill: circuit of %s forced open", e.Source)
+}
+
+// Drills holds the active drills. A nil *Drills has none, so components
+// may consult it unconditionally.
+type Drills struct {
+    bus *events.Bus
+
+    mu     sync.Mutex
+    active map[string]*Drill
+}
+
+// New creates an empty drill registry alerting on bus when drills start
+// and end
+func New(bus *events.Bus) *Drills {
+    return &Drills{bus: bus, active: make(map[string]*Drill)}
+}
+
+// Plan validates a spec and returns the drill it would start at now,
+// without starting it
+func Plan(spec Spec, now time.Time) (*Drill, error) {
+    switch spec.Kind {
+    case KindSourceOpen, KindFeedDegraded:
+        if spec.Delay != 0 {
+            return nil, fmt.Errorf("delay only applies to %s drills", KindPublishDelay)
+        }
+    case KindPublishDelay:
+        if spec.Delay <= 0 || spec.Delay > MaxDelay {
+            return nil, fmt.Errorf("delay must be positive and at most %s", MaxDelay)
+        }
+    default:
+        return nil, fmt.Errorf("unknown drill kind %q", spec.Kind)
+    }
+    if spec.Target == "" {
+        return nil, fmt.Errorf("target is required")
+    }
+    duration := spec.Duration
+    if duration == 0 {
+        duration = DefaultDuration
+    }
+    if duration < 0 || duration > MaxDuration {
+        return nil, fmt.Errorf("duration must be positive and at most %s", MaxDuration)
+    }
+
+    d := &Drill{
+        Kind:      spec.Kind,
+        Target:    spec.Target,
+        Operator:  spec.Operator,
+        Reason:    spec.Reason,
+        StartedAt: now,
+        ExpiresAt: now.Add(duration),
+        delay:     spec.Delay,
+    }
+    if spec.Delay > 0 {
+        d.Delay = spec.Delay.String()
+    }
+    return d, nil
+}
+
+// Start starts a drill
+func (d *Drills) Start(spec Spec, now time.Time) (*Drill, error) {
+    drill, err := Plan(spec, now)
+    if err != nil {
+        return nil, err
+    }
+    b := make([]byte, 8)
+    if _, err := rand.Read(b); err != nil {
+        return nil, err
+    }
+    drill.ID = hex.EncodeToString(b)
+
+    d.mu.Lock()
+    d.active[drill.ID] = drill
+    d.mu.Unlock()
+    d.alert(drill.Target, fmt.Sprintf("%s started drill %s: %s on %s until %s", drill.Operator, drill.ID, drill.Kind, drill.Target, drill.ExpiresAt.Format(time.RFC3339)))
+    started := *drill
+    return &started, nil
+}
+
+// Stop ends a drill before it expires
+func (d *Drills) Stop(id, operator string) (*Drill, error) {
+    d.mu.Lock()
+    drill, ok := d.active[id]
+    if ok {
+        delete(d.active, id)
+    }
+    d.mu.Unlock()
+    if !ok {
+        return nil, fmt.Errorf("no active drill %s", id)
+    }
+    d.alert(drill.Target, fmt.Sprintf("%s stopped drill %s: %s on %s", operator, drill.ID, drill.Kind, drill.Target))
+    stopped := *drill
+    return &stopped, nil
+}
+
+// Active returns the drills in effect at now, oldest first
+func (d *Drills) Active(now time.Time) []Drill {
+    if d == nil {
+        return nil
+    }
+    d.mu.Lock()
+    defer d.mu.Unlock()
+    drills := make([]Drill, 0, len(d.active))
+    for _, drill := range d.active {
+        if drill.activeAt(now) {
+            drills = append(drills, *drill)
+        }
+    }
+    sort.Slice(drills, func(i, j int) bool { return drills[i].StartedAt.Before(drills[j].StartedAt) })
+    return drills
+}
+
+// Get returns the active drill with an ID
+func (d *Drills) Get(id string) (*Drill, bool) {
+    d.mu.Lock()
+    defer d.mu.Unlock()
+    drill, ok := d.active[id]
+    if !ok {
+        return nil, false
+    }
+    found := *drill
+    return &found, true
+}
+
+// SourceOpen reports whether a drill forces the circuit of a source open
+func (d *Drills) SourceOpen(source string, now time.Time) bool {
+    return d.find(KindSourceOpen, source, now) != nil
+}
+
+// FeedDegraded reports whether a drill marks a feed degraded
+func (d *Drills) FeedDegraded(symbol string, now time.Time) bool {
+    return d.find(KindFeedDegraded, symbol, now) != nil
+}
+
+// PublishDelay returns how long a drill holds back publications of a feed,
+// the longest delay when several apply
+func (d *Drills) PublishDelay(symbol string, now time.Time) time.Duration {
+    if d == nil {
+        return 0
+    }
+    d.mu.Lock()
+    defer d.mu.Unlock()
+    var delay time.Duration
+    for _, drill := range d.active {
+        if drill.Kind == KindPublishDelay && drill.Target == symbol && drill.activeAt(now) && drill.delay > delay {
+            delay = drill.delay
+        }
+    }
+    return delay
+}
+
+// find returns a drill of a kind on target in effect at now, if any
+func (d *Drills) find(kind, target string, now time.Time) *Drill {
+    if d == nil {
+        return nil
+    }
+    d.mu.Lock()
+    defer d.mu.Unlock()
+    for _, drill := range d.active {
+        if drill.Kind == kind && drill.Target == target && drill.activeAt(now) {
+            return drill
+        }
+    }
+    return nil
+}
+
+// Run ends expired drills at interval until ctx is cancelled
+func (d *Drills) Run(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            d.Expire(time.Now())
+        }
+    }
+}
+
+// Expire ends the drills that expired by now and alerts on each
+func (d *Drills) Expire(now time.Time) {
+    d.mu.Lock()
+    expired := make([]*Drill, 0)
+    for id, drill := range d.active {
+        if !drill.activeAt(now) {
+            delete(d.active, id)
+            expired = append(expired, drill)
+        }
+    }
+    d.mu.Unlock()
+    for _, drill := range expired {
+        d.alert(drill.Target, fmt.Sprintf("drill %s ended: %s on %s expired", drill.ID, drill.Kind, drill.Target))
+    }
+}
+
+// alert logs a drill change and publishes it as an alert, so that drills
+// show up alongside the alerts they are meant to set off
+func (d *Drills) alert(target, message string) {
+    log.Printf("Drill: %s", message)
+    if d.bus == nil {
+        return
+    }
+    d.bus.Publish(events.Event{
+        Type:   events.Alert,
+        Symbol: target,
+        Payload: &events.AlertPayload{
+            Severity: events.SeverityWarning,
+            Kind:     "drill",
+            Message:  message,
+        },
+    })
+}
@@ -0,0 +1,108 @@
+package drill
+
+import (
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/events"
+)
+
+func TestDrillsApplyUntilExpiry(t *testing.T) {
+    bus := events.NewBus()
+    alerts := bus.Subscribe(10, events.Alert)
+    drills := New(bus)
+    now := time.Now()
+
+    open, err := drills.Start(Spec{Kind: KindSourceOpen, Target: "binance", Duration: time.Minute, Operator: "alice"}, now)
+    if err != nil {
+        t.Fatalf("Failed to start drill: %v", err)
+    }
+    if _, err := drills.Start(Spec{Kind: KindPublishDelay, Target: "ETHUSDT", Delay: 30 * time.Second, Operator: "alice"}, now); err != nil {
+        t.Fatalf("Failed to start drill: %v", err)
+    }
+    if _, err := drills.Start(Spec{Kind: KindPublishDelay, Target: "ETHUSDT", Delay: time.Minute, Operator: "bob"}, now); err != nil {
+        t.Fatalf("Failed to start drill: %v", err)
+    }
+
+    if !drills.SourceOpen("binance", now) || drills.SourceOpen("kraken", now) {
+        t.Error("Expected only binance to be forced open")
+    }
+    if got := drills.PublishDelay("ETHUSDT", now); got != time.Minute {
+        t.Errorf("Expected the longest delay, got %s", got)
+    }
+    if drills.FeedDegraded("ETHUSDT", now) {
+        t.Error("Expected no degraded feed")
+    }
+    if len(drills.Active(now)) != 3 {
+        t.Errorf("Expected 3 active drills, got %+v", drills.Active(now))
+    }
+
+    later := now.Add(DefaultDuration)
+    if drills.SourceOpen("binance", later) {
+        t.Error("Expected the drill to lapse after its duration")
+    }
+    drills.Expire(later)
+    if _, ok := drills.Get(open.ID); ok {
+        t.Error("Expected the expired drill to be removed")
+    }
+    if len(drills.Active(now)) != 0 {
+        t.Errorf("Expected no active drills, got %+v", drills.Active(now))
+    }
+
+    for i := 0; i < 6; i++ {
+        e := <-alerts.C
+        if payload := e.Payload.(*events.AlertPayload); payload.Kind != "drill" {
+            t.Errorf("Expected drill alerts, got %+v", payload)
+        }
+    }
+}
+
+func TestStopDrill(t *testing.T) {
+    drills := New(nil)
+    now := time.Now()
+    drill, err := drills.Start(Spec{Kind: KindFeedDegraded, Target: "BTCUSDT", Operator: "alice"}, now)
+    if err != nil {
+        t.Fatalf("Failed to start drill: %v", err)
+    }
+    if !drills.FeedDegraded("BTCUSDT", now) {
+        t.Fatal("Expected the feed to be degraded")
+    }
+    if _, err := drills.Stop(drill.ID, "alice"); err != nil {
+        t.Fatalf("Failed to stop drill: %v", err)
+    }
+    if drills.FeedDegraded("BTCUSDT", now) {
+        t.Error("Expected the stopped drill to no longer apply")
+    }
+    if _, err := drills.Stop(drill.ID, "alice"); err == nil {
+        t.Error("Expected stopping twice to fail")
+    }
+}
+
+func TestPlanValidatesSpec(t *testing.T) {
+    now := time.Now()
+    invalid := []Spec{
+        {Kind: "flood", Target: "binance"},
+        {Kind: KindSourceOpen},
+        {Kind: KindSourceOpen, Target: "binance", Delay: time.Second},
+        {Kind: KindPublishDelay, Target: "ETHUSDT"},
+        {Kind: KindPublishDelay, Target: "ETHUSDT", Delay: 2 * MaxDelay},
+        {Kind: KindFeedDegraded, Target: "ETHUSDT", Duration: MaxDuration + time.Second},
+    }
+    for _, spec := range invalid {
+        if _, err := Plan(spec, now); err == nil {
+            t.Errorf("Expected %+v to be rejected", spec)
+        }
+    }
+
+    drill, err := Plan(Spec{Kind: KindFeedDegraded, Target: "ETHUSDT"}, now)
+    if err != nil {
+        t.Fatalf("Failed to plan drill: %v", err)
+    }
+    if !drill.ExpiresAt.Equal(now.Add(DefaultDuration)) {
+        t.Errorf("Expected the default duration, got %s", drill.ExpiresAt.Sub(now))
+    }
+    var drills *Drills
+    if drills.SourceOpen("binance", now) || drills.PublishDelay("ETHUSDT", now) != 0 || drills.Active(now) != nil {
+        t.Error("Expected a nil registry to hold no drills")
+    }
+}
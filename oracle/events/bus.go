@@ -0,0 +1,358 @@
+package events
+
+import (
+    "context"
+    "fmt"
+    "sort"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "time"
+)
+
+// Type identifies the kind of event carried on the bus
+type Type string
+
+const (
+    // FetchResult is emitted for every individual source fetch; Payload is *FetchResultPayload
+    FetchResult Type = "fetch_result"
+    // Aggregate is emitted when a round completes; Payload is *common.AggregateResult
+    Aggregate Type = "aggregate"
+    // Alert is emitted for conditions operators should know about; Payload is *AlertPayload
+    Alert Type = "alert"
+    // PublishReceipt is emitted when a value has been published to a consumer channel
+    PublishReceipt Type = "publish_receipt"
+)
+
+// Event is a single message published on the bus
+type Event struct {
+    Type      Type
+    Symbol    string
+    Timestamp time.Time
+    Payload   interface{}
+}
+
+// Subscription receives events of the requested types until closed
+type Subscription struct {
+    // C is nil for subscriptions made with SubscribeQueue
+    C <-chan Event
+
+    ch      chan Event
+    types   map[Type]bool
+    bus     *Bus
+    name    string
+    dropped uint64
+    once    sync.Once
+
+    // queued subscriptions keep every event in queue until handled
+    queued bool
+    mu     sync.Mutex
+    cond   *sync.Cond
+    queue  []Event
+    closed bool
+}
+
+// SubscriberStats reports how a subscriber keeps up with the bus
+type SubscriberStats struct {
+    Name      string `json:"name"`
+    Types     []Type `json:"types,omitempty"`
+    Buffer    int    `json:"buffer"`
+    Unbounded bool   `json:"unbounded,omitempty"`
+    Pending   int    `json:"pending"`
+    Dropped   uint64 `json:"dropped"`
+}
+
+// Bus is an in-process publish/subscribe broker. Publishing never blocks:
+// events are dropped for subscribers whose buffers are full, except for
+// subscribers of SubscribeQueue, which queue without limit.
+type Bus struct {
+    mu   sync.RWMutex
+    subs map[*Subscription]struct{}
+    // handlers tracks SubscribeFunc goroutines still delivering
+    handlers sync.WaitGroup
+}
+
+// NewBus creates a new event bus
+func NewBus() *Bus {
+    return &Bus{
+        subs: make(map[*Subscription]struct{}),
+    }
+}
+
+// Subscribe registers a subscriber with the given channel buffer size.
+// When no types are given the subscriber receives every event.
+func (b *Bus) Subscribe(buffer int, types ...Type) *Subscription {
+    ch := make(chan Event, buffer)
+    sub := &Subscription{
+        C:   ch,
+        ch:  ch,
+        bus: b,
+    }
+    if len(types) > 0 {
+        sub.types = make(map[Type]bool, len(types))
+        for _, t := range types {
+            sub.types[t] = true
+        }
+    }
+
+    b.mu.Lock()
+    b.subs[sub] = struct{}{}
+    b.mu.Unlock()
+    return sub
+}
+
+// SubscribeFunc registers handler to be called sequentially, on its own
+// goroutine, for each matching event until the subscription is closed
+func (b *Bus) SubscribeFunc(buffer int, handler func(Event), types ...Type) *Subscription {
+    sub := b.Subscribe(buffer, types...)
+    b.handlers.Add(1)
+    go func() {
+        defer b.handlers.Done()
+        for e := range sub.C {
+            handler(e)
+        }
+    }()
+    return sub
+}
+
+// SubscribeQueue registers handler like SubscribeFunc, but queues events
+// without limit instead of dropping them while handler is behind. It is
+// for subscribers that must see every event, such as those persisting or
+// publishing rounds; name identifies it in Stats.
+func (b *Bus) SubscribeQueue(name string, handler func(Event), types ...Type) *Subscription {
+    sub := &Subscription{bus: b, name: name, queued: true}
+    sub.cond = sync.NewCond(&sub.mu)
+    if len(types) > 0 {
+        sub.types = make(map[Type]bool, len(types))
+        for _, t := range types {
+            sub.types[t] = true
+        }
+    }
+
+    b.mu.Lock()
+    b.subs[sub] = struct{}{}
+    b.mu.Unlock()
+
+    b.handlers.Add(1)
+    go func() {
+        defer b.handlers.Done()
+        for {
+            e, ok := sub.next()
+            if !ok {
+                return
+            }
+            handler(e)
+        }
+    }()
+    return sub
+}
+
+// next waits for the next queued event; ok is false once the subscription
+// is closed and its queue drained
+func (s *Subscription) next() (e Event, ok bool) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    for len(s.queue) == 0 && !s.closed {
+        s.cond.Wait()
+    }
+    if len(s.queue) == 0 {
+        return Event{}, false
+    }
+    e = s.queue[0]
+    s.queue[0] = Event{}
+    s.queue = s.queue[1:]
+    return e, true
+}
+
+// Publish delivers an event to all matching subscribers without blocking
+func (b *Bus) Publish(e Event) {
+    if b == nil {
+        return
+    }
+    if e.Timestamp.IsZero() {
+        e.Timestamp = time.Now()
+    }
+
+    b.mu.RLock()
+    defer b.mu.RUnlock()
+    for sub := range b.subs {
+        if sub.types != nil && !sub.types[e.Type] {
+            continue
+        }
+        if sub.queued {
+            sub.mu.Lock()
+            if !sub.closed {
+                sub.queue = append(sub.queue, e)
+                sub.cond.Signal()
+            }
+            sub.mu.Unlock()
+            continue
+        }
+        select {
+        case sub.ch <- e:
+        default:
+            atomic.AddUint64(&sub.dropped, 1)
+        }
+    }
+}
+
+// Close stops accepting events and closes every subscription. Handlers
+// registered with SubscribeFunc finish the events already buffered for
+// them; Close waits for them until ctx is done and then returns an error
+// with the number of events left undelivered.
+func (b *Bus) Close(ctx context.Context) error {
+    b.mu.RLock()
+    subs := make([]*Subscription, 0, len(b.subs))
+    for sub := range b.subs {
+        subs = append(subs, sub)
+    }
+    b.mu.RUnlock()
+    for _, sub := range subs {
+        sub.Close()
+    }
+
+    done := make(chan struct{})
+    go func() {
+        b.handlers.Wait()
+        close(done)
+    }()
+    select {
+    case <-done:
+        return nil
+    case <-ctx.Done():
+        pending := 0
+        for _, sub := range subs {
+            pending += sub.pending()
+        }
+        return fmt.Errorf("%d events undelivered at shutdown", pending)
+    }
+}
+
+// Close unsubscribes and closes the subscription channel
+func (s *Subscription) Close() {
+    s.once.Do(func() {
+        s.bus.mu.Lock()
+        delete(s.bus.subs, s)
+        s.bus.mu.Unlock()
+        if s.queued {
+            s.mu.Lock()
+            s.closed = true
+            s.cond.Broadcast()
+            s.mu.Unlock()
+            return
+        }
+        close(s.ch)
+    })
+}
+
+// Named sets the name identifying the subscription in Stats
+func (s *Subscription) Named(name string) *Subscription {
+    s.bus.mu.Lock()
+    s.name = name
+    s.bus.mu.Unlock()
+    return s
+}
+
+// Dropped returns the number of events dropped because the buffer was full
+func (s *Subscription) Dropped() uint64 {
+    return atomic.LoadUint64(&s.dropped)
+}
+
+// pending returns the number of events waiting for the subscriber
+func (s *Subscription) pending() int {
+    if !s.queued {
+        return len(s.ch)
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return len(s.queue)
+}
+
+// stats reports the subscription; callers hold the bus lock
+func (s *Subscription) stats() SubscriberStats {
+    stats := SubscriberStats{
+        Name:      s.name,
+        Buffer:    cap(s.ch),
+        Unbounded: s.queued,
+        Pending:   s.pending(),
+        Dropped:   s.Dropped(),
+    }
+    for t := range s.types {
+        stats.Types = append(stats.Types, t)
+    }
+    sort.Slice(stats.Types, func(i, j int) bool { return stats.Types[i] < stats.Types[j] })
+    if stats.Name == "" {
+        // Unnamed subscribers are told apart by what they receive
+        names := make([]string, len(stats.Types))
+        for i, t := range stats.Types {
+            names[i] = string(t)
+        }
+        stats.Name = strings.Join(names, ",")
+    }
+    return stats
+}
+
+// Stats reports every subscriber, ordered by name
+func (b *Bus) Stats() []SubscriberStats {
+    b.mu.RLock()
+    stats := make([]SubscriberStats, 0, len(b.subs))
+    for sub := range b.subs {
+        stats = append(stats, sub.stats())
+    }
+    b.mu.RUnlock()
+    sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+    return stats
+}
+
+// WatchDrops raises a warning alert every interval for each subscriber that
+// dropped events since the last check, until ctx is cancelled
+func (b *Bus) WatchDrops(ctx context.Context, interval time.Duration) {
+    seen := make(map[*Subscription]uint64)
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            b.checkDrops(seen)
+        }
+    }
+}
+
+// checkDrops alerts on subscribers whose drop count grew past seen
+func (b *Bus) checkDrops(seen map[*Subscription]uint64) {
+    type drop struct {
+        stats SubscriberStats
+        count uint64
+    }
+    drops := make([]drop, 0)
+    b.mu.RLock()
+    for sub := range b.subs {
+        dropped := sub.Dropped()
+        if dropped > seen[sub] {
+            drops = append(drops, drop{stats: sub.stats(), count: dropped - seen[sub]})
+        }
+        seen[sub] = dropped
+    }
+    for sub := range seen {
+        if _, ok := b.subs[sub]; !ok {
+            delete(seen, sub)
+        }
+    }
+    b.mu.RUnlock()
+
+    // Published outside the lock; a full alert subscriber only counts
+    // another drop
+    for _, d := range drops {
+        message := fmt.Sprintf("subscriber %s dropped %d events with its buffer of %d full", d.stats.Name, d.count, d.stats.Buffer)
+        b.Publish(Event{
+            Type: Alert,
+            Payload: &AlertPayload{
+                Severity: SeverityWarning,
+                Kind:     "events_dropped",
+                Message:  message,
+            },
+        })
+    }
+}
@@ -0,0 +1,147 @@
+package events
+
+import (
+    "context"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+func TestBusFiltersByType(t *testing.T) {
+    bus := NewBus()
+    all := bus.Subscribe(10)
+    alerts := bus.Subscribe(10, Alert)
+    defer all.Close()
+    defer alerts.Close()
+
+    bus.Publish(Event{Type: Aggregate, Symbol: "BTCUSDT"})
+    bus.Publish(Event{Type: Alert, Symbol: "BTCUSDT"})
+
+    if len(all.C) != 2 {
+        t.Errorf("Expected 2 events for catch-all subscriber, got %d", len(all.C))
+    }
+    if len(alerts.C) != 1 {
+        t.Fatalf("Expected 1 event for alert subscriber, got %d", len(alerts.C))
+    }
+    if e := <-alerts.C; e.Type != Alert || e.Timestamp.IsZero() {
+        t.Errorf("Unexpected alert event: %+v", e)
+    }
+}
+
+func TestBusDropsWhenFull(t *testing.T) {
+    bus := NewBus()
+    sub := bus.Subscribe(1)
+    defer sub.Close()
+
+    bus.Publish(Event{Type: Aggregate})
+    bus.Publish(Event{Type: Aggregate})
+
+    if sub.Dropped() != 1 {
+        t.Errorf("Expected 1 dropped event, got %d", sub.Dropped())
+    }
+}
+
+func TestSubscribeFunc(t *testing.T) {
+    bus := NewBus()
+    received := make(chan Event, 1)
+    sub := bus.SubscribeFunc(1, func(e Event) { received <- e }, FetchResult)
+    defer sub.Close()
+
+    bus.Publish(Event{Type: FetchResult, Symbol: "ETHUSDT"})
+
+    select {
+    case e := <-received:
+        if e.Symbol != "ETHUSDT" {
+            t.Errorf("Expected ETHUSDT, got %s", e.Symbol)
+        }
+    case <-time.After(time.Second):
+        t.Fatal("Handler was not called")
+    }
+}
+
+func TestCloseFlushesHandlers(t *testing.T) {
+    bus := NewBus()
+    release := make(chan struct{})
+    var delivered int32
+    bus.SubscribeFunc(10, func(e Event) {
+        <-release
+        atomic.AddInt32(&delivered, 1)
+    })
+
+    for i := 0; i < 3; i++ {
+        bus.Publish(Event{Type: Aggregate})
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+    err := bus.Close(ctx)
+    cancel()
+    if err == nil {
+        t.Fatal("Expected undelivered events while the handler is blocked")
+    }
+
+    close(release)
+    if err := bus.Close(context.Background()); err != nil {
+        t.Fatalf("Close failed: %v", err)
+    }
+    if n := atomic.LoadInt32(&delivered); n != 3 {
+        t.Errorf("Expected 3 buffered events delivered, got %d", n)
+    }
+
+    // Events published after close are discarded
+    bus.Publish(Event{Type: Aggregate})
+}
+
+func TestSubscribeQueueNeverDrops(t *testing.T) {
+    bus := NewBus()
+    release := make(chan struct{})
+    var delivered int32
+    bus.SubscribeQueue("store", func(e Event) {
+        <-release
+        atomic.AddInt32(&delivered, 1)
+    }, Aggregate)
+
+    for i := 0; i < 2000; i++ {
+        bus.Publish(Event{Type: Aggregate})
+    }
+    stats := bus.Stats()
+    if len(stats) != 1 || stats[0].Name != "store" || !stats[0].Unbounded || stats[0].Dropped != 0 {
+        t.Errorf("Expected the queued subscriber to drop nothing, got %+v", stats)
+    }
+
+    close(release)
+    ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+    defer cancel()
+    if err := bus.Close(ctx); err != nil {
+        t.Fatalf("Close failed: %v", err)
+    }
+    if delivered != 2000 {
+        t.Errorf("Expected every queued event delivered, got %d", delivered)
+    }
+}
+
+func TestCheckDropsAlerts(t *testing.T) {
+    bus := NewBus()
+    slow := bus.Subscribe(1, Aggregate).Named("slow")
+    alerts := bus.Subscribe(10, Alert)
+    defer slow.Close()
+    defer alerts.Close()
+
+    seen := make(map[*Subscription]uint64)
+    bus.checkDrops(seen)
+    if len(alerts.C) != 0 {
+        t.Fatal("Expected no alert before any drop")
+    }
+
+    for i := 0; i < 3; i++ {
+        bus.Publish(Event{Type: Aggregate})
+    }
+    bus.checkDrops(seen)
+    bus.checkDrops(seen)
+    if len(alerts.C) != 1 {
+        t.Fatalf("Expected a single alert for the drops, got %d", len(alerts.C))
+    }
+    alert := (<-alerts.C).Payload.(*AlertPayload)
+    if alert.Kind != "events_dropped" || alert.Message != "subscriber slow dropped 2 events with its buffer of 1 full" {
+        t.Errorf("Unexpected alert: %+v", alert)
+    }
+}
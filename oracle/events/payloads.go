@@ -0,0 +1,29 @@
+package events
+
+import (
+    "time"
+
+    "yetaXYZ/oracle/common"
+)
+
+// Alert severities
+const (
+    SeverityInfo     = "info"
+    SeverityWarning  = "warning"
+    SeverityCritical = "critical"
+)
+
+// FetchResultPayload describes the outcome of fetching a single source
+type FetchResultPayload struct {
+    Source  string
+    Price   *common.PricePoint
+    Err     error
+    Latency time.Duration
+}
+
+// AlertPayload describes a condition raised for operators
+type AlertPayload struct {
+    Severity string `json:"severity"`
+    Kind     string `json:"kind"`
+    Message  string `json:"message"`
+}
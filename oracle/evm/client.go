@@ -0,0 +1,177 @@
+package evm
+
+import (
+    "bytes"
+    "context"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "math/big"
+    "net/http"
+    "strings"
+    "sync/atomic"
+    "time"
+
+    "yetaXYZ/oracle/credentials"
+    "yetaXYZ/oracle/fetch"
+    "yetaXYZ/oracle/redact"
+)
+
+// Client is a minimal Ethereum JSON-RPC client for read-only contract calls
+type Client struct {
+    endpoints *endpointPool
+    http      *http.Client
+    nextID    uint64
+}
+
+// NewClient creates a JSON-RPC client for the given endpoint
+func NewClient(endpoint string, httpClient *http.Client) *Client {
+    return NewChainClient("", []string{endpoint}, httpClient)
+}
+
+// NewChainClient creates a JSON-RPC client rotating calls across the RPC
+// endpoints of a chain. Reads fail over to the next endpoint when one
+// fails, and failing endpoints are quarantined; health is shared by every
+// client using the same endpoint.
+func NewChainClient(chainID string, endpoints []string, httpClient *http.Client) *Client {
+    if httpClient == nil {
+        httpClient = http.DefaultClient
+    }
+    return &Client{
+        endpoints: newEndpointPool(chainID, endpoints),
+        http:      httpClient,
+    }
+}
+
+// Endpoint returns the first RPC endpoint the client talks to
+func (c *Client) Endpoint() string {
+    return c.endpoints.states[0].url
+}
+
+// rpcError is a JSON-RPC error object
+type rpcError struct {
+    Code    int    `json:"code"`
+    Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+    return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// rpcLimitExceeded is the JSON-RPC error code of providers rate limiting
+// the caller, a fault of the endpoint rather than of the call
+const rpcLimitExceeded = -32005
+
+// Do performs a JSON-RPC request and decodes the result into out. Calls
+// rotate across the client's endpoints; reads that an endpoint fails are
+// retried on the next one, while transactions are sent to one endpoint
+// only since a failed response does not mean the node dropped them.
+func (c *Client) Do(ctx context.Context, method string, params []interface{}, out interface{}) error {
+    payload, err := json.Marshal(map[string]interface{}{
+        "jsonrpc": "2.0",
+        "id":      atomic.AddUint64(&c.nextID, 1),
+        "method":  method,
+        "params":  params,
+    })
+    if err != nil {
+        return err
+    }
+
+    var lastErr error
+    for i, endpoint := range c.endpoints.order(time.Now()) {
+        if i > 0 && strings.HasPrefix(method, "eth_send") {
+            break
+        }
+        start := time.Now()
+        result, err := c.do(ctx, endpoint.url, payload)
+        if rpcErr, ok := err.(*rpcError); err == nil || (ok && rpcErr.Code != rpcLimitExceeded) {
+            endpoint.succeeded(time.Since(start))
+            if err != nil {
+                return err
+            }
+            return json.Unmarshal(result, out)
+        }
+        // A call abandoned by the caller says nothing about the endpoint
+        if ctx.Err() == context.Canceled {
+            return err
+        }
+        endpoint.failed(err, time.Since(start), time.Now())
+        lastErr = err
+        if ctx.Err() != nil {
+            break
+        }
+    }
+    return lastErr
+}
+
+// do sends a JSON-RPC payload to one endpoint and returns the raw result
+func (c *Client) do(ctx context.Context, endpoint string, payload []byte) (json.RawMessage, error) {
+    // Keys referenced in the URL are resolved per call, so rotations apply
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, credentials.Expand(endpoint), bytes.NewReader(payload))
+    if err != nil {
+        return nil, redact.Error(err)
+    }
+    req.Header.Set("Content-Type", "application/json")
+
+    // RPC URLs of hosted providers embed the project key
+    resp, err := c.http.Do(req)
+    if err != nil {
+        return nil, redact.Error(err)
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("rpc endpoint returned %s", resp.Status)
+    }
+
+    var envelope struct {
+        Result json.RawMessage `json:"result"`
+        Error  *rpcError       `json:"error"`
+    }
+    if err := fetch.DecodeJSON(resp, &envelope); err != nil {
+        return nil, err
+    }
+    if envelope.Error != nil {
+        return nil, envelope.Error
+    }
+    return envelope.Result, nil
+}
+
+// Call executes eth_call against the latest block and returns the raw return data
+func (c *Client) Call(ctx context.Context, to, data string) ([]byte, error) {
+    var result string
+    params := []interface{}{
+        map[string]string{"to": to, "data": data},
+        "latest",
+    }
+    if err := c.Do(ctx, "eth_call", params, &result); err != nil {
+        return nil, err
+    }
+    return hex.DecodeString(strings.TrimPrefix(result, "0x"))
+}
+
+// word returns the i-th 32-byte word of ABI-encoded return data
+func word(data []byte, i int) ([]byte, error) {
+    if len(data) < (i+1)*32 {
+        return nil, fmt.Errorf("return data too short: %d bytes", len(data))
+    }
+    return data[i*32 : (i+1)*32], nil
+}
+
+// wordInt decodes the i-th word as an unsigned integer
+func wordInt(data []byte, i int) (*big.Int, error) {
+    w, err := word(data, i)
+    if err != nil {
+        return nil, err
+    }
+    return new(big.Int).SetBytes(w), nil
+}
+
+// wordAddress decodes the i-th word as an address
+func wordAddress(data []byte, i int) (string, error) {
+    w, err := word(data, i)
+    if err != nil {
+        return "", err
+    }
+    return "0x" + hex.EncodeToString(w[12:]), nil
+}
@@ -0,0 +1,95 @@
+package evm
+
+import (
+    "context"
+    "fmt"
+    "math"
+    "math/big"
+    "strings"
+    "sync"
+)
+
+// Function selectors for the read-only calls used by on-chain fetchers
+const (
+    selectorDecimals    = "0x313ce567" // decimals()
+    selectorToken0      = "0x0dfe1681" // token0()
+    selectorToken1      = "0xd21220a7" // token1()
+    selectorGetReserves = "0x0902f1ac" // getReserves()
+    selectorSlot0       = "0x3850c7bd" // slot0()
+)
+
+// DecimalsCache reads ERC-20 decimals on first use and caches them, so
+// normalization follows the token contract rather than static config
+type DecimalsCache struct {
+    client *Client
+
+    mu       sync.RWMutex
+    decimals map[string]int
+}
+
+// NewDecimalsCache creates a decimals cache backed by client
+func NewDecimalsCache(client *Client) *DecimalsCache {
+    return &DecimalsCache{
+        client:   client,
+        decimals: make(map[string]int),
+    }
+}
+
+// Decimals returns the token's decimals, reading them from the chain once
+func (d *DecimalsCache) Decimals(ctx context.Context, token string) (int, error) {
+    key := strings.ToLower(token)
+
+    d.mu.RLock()
+    decimals, ok := d.decimals[key]
+    d.mu.RUnlock()
+    if ok {
+        return decimals, nil
+    }
+
+    data, err := d.client.Call(ctx, token, selectorDecimals)
+    if err != nil {
+        return 0, fmt.Errorf("failed to read decimals of %s: %v", token, err)
+    }
+    value, err := wordInt(data, 0)
+    if err != nil {
+        return 0, fmt.Errorf("failed to decode decimals of %s: %v", token, err)
+    }
+    if !value.IsInt64() || value.Int64() > 77 {
+        return 0, fmt.Errorf("implausible decimals for %s: %s", token, value)
+    }
+    decimals = int(value.Int64())
+
+    d.mu.Lock()
+    d.decimals[key] = decimals
+    d.mu.Unlock()
+    return decimals, nil
+}
+
+// Normalize converts a raw integer token amount into units using decimals
+func Normalize(raw *big.Int, decimals int) float64 {
+    scale := new(big.Float).SetFloat64(math.Pow10(decimals))
+    value, _ := new(big.Float).Quo(new(big.Float).SetInt(raw), scale).Float64()
+    return value
+}
+
+// PriceFromReserves returns the price of token0 in token1 units from
+// Uniswap V2 style raw reserves
+func PriceFromReserves(reserve0, reserve1 *big.Int, decimals0, decimals1 int) (float64, error) {
+    amount0 := Normalize(reserve0, decimals0)
+    if amount0 == 0 {
+        return 0, fmt.Errorf("pool has no token0 reserves")
+    }
+    return Normalize(reserve1, decimals1) / amount0, nil
+}
+
+// PriceFromSqrtPriceX96 returns the price of token0 in token1 units from a
+// Uniswap V3 sqrtPriceX96 value
+func PriceFromSqrtPriceX96(sqrtPriceX96 *big.Int, decimals0, decimals1 int) (float64, error) {
+    if sqrtPriceX96.Sign() == 0 {
+        return 0, fmt.Errorf("pool is not initialized")
+    }
+    q96 := new(big.Float).SetInt(new(big.Int).Lsh(big.NewInt(1), 96))
+    ratio := new(big.Float).Quo(new(big.Float).SetInt(sqrtPriceX96), q96)
+    raw, _ := new(big.Float).Mul(ratio, ratio).Float64()
+    return raw * math.Pow10(decimals0-decimals1), nil
+}
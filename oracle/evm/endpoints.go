@@ -0,0 +1,198 @@
+package evm
+
+import (
+    "sort"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/redact"
+)
+
+// Endpoint health policy. An endpoint is quarantined after consecutive
+// failures or a sustained error rate; each quarantine in a row doubles the
+// period, and a success after release resets it.
+const (
+    quarantineAfter     = 3
+    quarantineErrorRate = 0.5
+    quarantineMinCalls  = 10
+    quarantineBase      = 30 * time.Second
+    quarantineMax       = 10 * time.Minute
+    // healthDecay is the weight of each new call in the error rate and
+    // latency averages
+    healthDecay = 0.2
+)
+
+// EndpointStats is the health of one RPC endpoint
+type EndpointStats struct {
+    Chain    string `json:"chain,omitempty"`
+    Endpoint string `json:"endpoint"` // provider keys redacted
+    Requests uint64 `json:"requests"`
+    Errors   uint64 `json:"errors"`
+    // ErrorRate and LatencyMs are moving averages over recent calls
+    ErrorRate        float64    `json:"errorRate"`
+    LatencyMs        float64    `json:"latencyMs"`
+    Quarantined      bool       `json:"quarantined"`
+    QuarantinedUntil *time.Time `json:"quarantinedUntil,omitempty"`
+    Quarantines      uint64     `json:"quarantines"` // total so far
+    LastError        string     `json:"lastError,omitempty"`
+}
+
+// endpointState tracks the health of an endpoint across every client
+// using it; fields are guarded by endpointsMu
+type endpointState struct {
+    chain string
+    url   string
+
+    requests    uint64
+    errors      uint64
+    consecutive int
+    errorRate   float64
+    latency     float64 // milliseconds
+    // backoff is the number of quarantines in a row, doubling the period
+    backoff     int
+    quarantines uint64
+    until       time.Time
+    lastError   string
+}
+
+var (
+    endpointsMu sync.Mutex
+    endpoints   = make(map[string]*endpointState)
+)
+
+// endpointFor returns the shared state of an endpoint URL, labelled with
+// the chain of the first client registering it
+func endpointFor(chain, url string) *endpointState {
+    endpointsMu.Lock()
+    defer endpointsMu.Unlock()
+    e, ok := endpoints[url]
+    if !ok {
+        e = &endpointState{chain: chain, url: url}
+        endpoints[url] = e
+    } else if e.chain == "" {
+        e.chain = chain
+    }
+    return e
+}
+
+// succeeded records a call answered by the endpoint
+func (e *endpointState) succeeded(latency time.Duration) {
+    endpointsMu.Lock()
+    defer endpointsMu.Unlock()
+    e.requests++
+    e.consecutive = 0
+    e.backoff = 0
+    e.observe(0, latency)
+}
+
+// failed records a call the endpoint failed and quarantines it when its
+// failures persist
+func (e *endpointState) failed(err error, latency time.Duration, now time.Time) {
+    endpointsMu.Lock()
+    defer endpointsMu.Unlock()
+    e.requests++
+    e.errors++
+    e.consecutive++
+    e.lastError = redact.String(err.Error())
+    e.observe(1, latency)
+
+    if now.Before(e.until) {
+        return
+    }
+    if e.consecutive >= quarantineAfter || (e.requests >= quarantineMinCalls && e.errorRate >= quarantineErrorRate) {
+        period := quarantineBase << uint(e.backoff)
+        if period > quarantineMax || period <= 0 {
+            period = quarantineMax
+        }
+        e.until = now.Add(period)
+        e.backoff++
+        e.quarantines++
+        e.consecutive = 0
+    }
+}
+
+// observe folds a call into the moving averages
+func (e *endpointState) observe(failure float64, latency time.Duration) {
+    ms := float64(latency) / float64(time.Millisecond)
+    if e.requests == 1 {
+        e.errorRate, e.latency = failure, ms
+        return
+    }
+    e.errorRate += healthDecay * (failure - e.errorRate)
+    e.latency += healthDecay * (ms - e.latency)
+}
+
+// endpointPool rotates calls across the endpoints of one chain
+type endpointPool struct {
+    states []*endpointState
+
+    mu   sync.Mutex
+    next int
+}
+
+func newEndpointPool(chain string, urls []string) *endpointPool {
+    p := &endpointPool{}
+    for _, url := range urls {
+        p.states = append(p.states, endpointFor(chain, url))
+    }
+    return p
+}
+
+// order returns the endpoints to try for a call: the available ones in
+// rotation, then the quarantined ones by earliest release so that a call
+// is never refused while every endpoint is quarantined
+func (p *endpointPool) order(now time.Time) []*endpointState {
+    p.mu.Lock()
+    start := p.next
+    p.next = (p.next + 1) % len(p.states)
+    p.mu.Unlock()
+
+    endpointsMu.Lock()
+    defer endpointsMu.Unlock()
+    var available, quarantined []*endpointState
+    for i := range p.states {
+        e := p.states[(start+i)%len(p.states)]
+        if now.Before(e.until) {
+            quarantined = append(quarantined, e)
+        } else {
+            available = append(available, e)
+        }
+    }
+    sort.SliceStable(quarantined, func(i, j int) bool { return quarantined[i].until.Before(quarantined[j].until) })
+    return append(available, quarantined...)
+}
+
+// EndpointHealth returns the health of every RPC endpoint in use, by chain
+// and endpoint
+func EndpointHealth() []EndpointStats {
+    now := time.Now()
+    endpointsMu.Lock()
+    out := make([]EndpointStats, 0, len(endpoints))
+    for _, e := range endpoints {
+        stats := EndpointStats{
+            Chain:       e.chain,
+            Endpoint:    redact.String(e.url),
+            Requests:    e.requests,
+            Errors:      e.errors,
+            ErrorRate:   e.errorRate,
+            LatencyMs:   e.latency,
+            Quarantines: e.quarantines,
+            LastError:   e.lastError,
+        }
+        if now.Before(e.until) {
+            until := e.until
+            stats.Quarantined = true
+            stats.QuarantinedUntil = &until
+        }
+        out = append(out, stats)
+    }
+    endpointsMu.Unlock()
+
+    sort.Slice(out, func(i, j int) bool {
+        if out[i].Chain != out[j].Chain {
+            return out[i].Chain < out[j].Chain
+        }
+        return out[i].Endpoint < out[j].Endpoint
+    })
+    return out
+}
@@ -0,0 +1,94 @@
+package evm
+
+import (
+    "context"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "sync/atomic"
+    "testing"
+)
+
+func TestChainClientQuarantinesFailingEndpoints(t *testing.T) {
+    var healthyCalls, failingCalls int64
+    healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        atomic.AddInt64(&healthyCalls, 1)
+        fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"result":"0x10"}`)
+    }))
+    defer healthy.Close()
+    failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        atomic.AddInt64(&failingCalls, 1)
+        http.Error(w, "upstream unavailable", http.StatusBadGateway)
+    }))
+    defer failing.Close()
+
+    client := NewChainClient("1", []string{failing.URL, healthy.URL}, nil)
+    for i := 0; i < 10; i++ {
+        n, err := client.BlockNumber(context.Background())
+        if err != nil || n != 16 {
+            t.Fatalf("Call %d: expected block 16 through failover, got %d, %v", i, n, err)
+        }
+    }
+    if failingCalls != quarantineAfter {
+        t.Errorf("Expected the failing endpoint to be quarantined after %d calls, got %d", quarantineAfter, failingCalls)
+    }
+    if healthyCalls != 10 {
+        t.Errorf("Expected every call to be answered by the healthy endpoint, got %d", healthyCalls)
+    }
+
+    var found bool
+    for _, s := range EndpointHealth() {
+        if s.Endpoint != failing.URL {
+            continue
+        }
+        found = true
+        if !s.Quarantined || s.Chain != "1" || s.Errors != quarantineAfter || s.Quarantines != 1 {
+            t.Errorf("Expected the failing endpoint to be reported quarantined, got %+v", s)
+        }
+    }
+    if !found {
+        t.Error("Expected the failing endpoint in the health report")
+    }
+
+    // Transactions are never resent to another endpoint
+    var sends int64
+    rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        atomic.AddInt64(&sends, 1)
+        http.Error(w, "upstream unavailable", http.StatusBadGateway)
+    }))
+    defer rejecting.Close()
+    sender := NewChainClient("1", []string{rejecting.URL, healthy.URL}, nil)
+    if _, err := sender.SendTransaction(context.Background(), "0x01", "0x02", nil); err == nil {
+        t.Error("Expected the send to fail")
+    }
+    if sends != 1 || healthyCalls != 10 {
+        t.Errorf("Expected a single send attempt, got %d sends and %d failovers", sends, healthyCalls-10)
+    }
+}
+
+func TestChainClientKeepsCallErrors(t *testing.T) {
+    var calls int64
+    rpc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        atomic.AddInt64(&calls, 1)
+        fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"error":{"code":3,"message":"execution reverted"}}`)
+    }))
+    defer rpc.Close()
+    other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        atomic.AddInt64(&calls, 1)
+        fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"error":{"code":3,"message":"execution reverted"}}`)
+    }))
+    defer other.Close()
+
+    client := NewChainClient("1", []string{rpc.URL, other.URL}, nil)
+    if _, err := client.Call(context.Background(), "0x02", "0x"); err == nil {
+        t.Fatal("Expected the revert to be returned")
+    }
+    if calls != 1 {
+        t.Errorf("Expected a reverted call not to fail over, got %d calls", calls)
+    }
+    for _, s := range EndpointHealth() {
+        if s.Endpoint == rpc.URL && s.Errors != 0 {
+            t.Errorf("Expected a revert not to count against the endpoint, got %+v", s)
+        }
+    }
+}
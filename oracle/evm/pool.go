@@ -0,0 +1,138 @@
+package evm
+
+import (
+    "context"
+    "fmt"
+    "strings"
+)
+
+// PoolReader reads decimals-normalized prices directly from pool contracts
+type PoolReader struct {
+    client   *Client
+    decimals *DecimalsCache
+}
+
+// NewPoolReader creates a pool reader backed by client
+func NewPoolReader(client *Client) *PoolReader {
+    return &PoolReader{
+        client:   client,
+        decimals: NewDecimalsCache(client),
+    }
+}
+
+// Decimals returns the reader's token decimals cache
+func (r *PoolReader) Decimals() *DecimalsCache {
+    return r.decimals
+}
+
+// V2Price returns the price of baseToken in the pool's other token for a
+// Uniswap V2 style pair contract
+func (r *PoolReader) V2Price(ctx context.Context, pool, baseToken string) (float64, error) {
+    token0, token1, err := r.tokens(ctx, pool)
+    if err != nil {
+        return 0, err
+    }
+
+    data, err := r.client.Call(ctx, pool, selectorGetReserves)
+    if err != nil {
+        return 0, fmt.Errorf("failed to read reserves of %s: %v", pool, err)
+    }
+    reserve0, err := wordInt(data, 0)
+    if err != nil {
+        return 0, err
+    }
+    reserve1, err := wordInt(data, 1)
+    if err != nil {
+        return 0, err
+    }
+
+    decimals0, decimals1, err := r.tokenDecimals(ctx, token0, token1)
+    if err != nil {
+        return 0, err
+    }
+
+    price, err := PriceFromReserves(reserve0, reserve1, decimals0, decimals1)
+    if err != nil {
+        return 0, err
+    }
+    return orient(price, token0, token1, baseToken)
+}
+
+// V3Price returns the price of baseToken in the pool's other token for a
+// Uniswap V3 style pool contract
+func (r *PoolReader) V3Price(ctx context.Context, pool, baseToken string) (float64, error) {
+    token0, token1, err := r.tokens(ctx, pool)
+    if err != nil {
+        return 0, err
+    }
+
+    data, err := r.client.Call(ctx, pool, selectorSlot0)
+    if err != nil {
+        return 0, fmt.Errorf("failed to read slot0 of %s: %v", pool, err)
+    }
+    sqrtPriceX96, err := wordInt(data, 0)
+    if err != nil {
+        return 0, err
+    }
+
+    decimals0, decimals1, err := r.tokenDecimals(ctx, token0, token1)
+    if err != nil {
+        return 0, err
+    }
+
+    price, err := PriceFromSqrtPriceX96(sqrtPriceX96, decimals0, decimals1)
+    if err != nil {
+        return 0, err
+    }
+    return orient(price, token0, token1, baseToken)
+}
+
+// tokens reads the pool's token0 and token1 addresses
+func (r *PoolReader) tokens(ctx context.Context, pool string) (string, string, error) {
+    data, err := r.client.Call(ctx, pool, selectorToken0)
+    if err != nil {
+        return "", "", fmt.Errorf("failed to read token0 of %s: %v", pool, err)
+    }
+    token0, err := wordAddress(data, 0)
+    if err != nil {
+        return "", "", err
+    }
+
+    data, err = r.client.Call(ctx, pool, selectorToken1)
+    if err != nil {
+        return "", "", fmt.Errorf("failed to read token1 of %s: %v", pool, err)
+    }
+    token1, err := wordAddress(data, 0)
+    if err != nil {
+        return "", "", err
+    }
+    return token0, token1, nil
+}
+
+// tokenDecimals reads the decimals of both pool tokens
+func (r *PoolReader) tokenDecimals(ctx context.Context, token0, token1 string) (int, int, error) {
+    decimals0, err := r.decimals.Decimals(ctx, token0)
+    if err != nil {
+        return 0, 0, err
+    }
+    decimals1, err := r.decimals.Decimals(ctx, token1)
+    if err != nil {
+        return 0, 0, err
+    }
+    return decimals0, decimals1, nil
+}
+
+// orient converts a token0-in-token1 price into a baseToken price
+func orient(price float64, token0, token1, baseToken string) (float64, error) {
+    switch {
+    case strings.EqualFold(baseToken, token0):
+        return price, nil
+    case strings.EqualFold(baseToken, token1):
+        if price == 0 {
+            return 0, fmt.Errorf("pool price is zero")
+        }
+        return 1 / price, nil
+    default:
+        return 0, fmt.Errorf("token %s is not in pool (%s, %s)", baseToken, token0, token1)
+    }
+}
@@ -0,0 +1,102 @@
+package evm
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "math"
+    "math/big"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+)
+
+const (
+    testUSDC = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+    testWETH = "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"
+    testPool = "0xb4e16d0168e52d35cacd2c6185b44281ec28c9dc"
+)
+
+// encodeWords ABI-encodes unsigned integers as consecutive 32-byte words
+func encodeWords(values ...*big.Int) string {
+    var sb strings.Builder
+    sb.WriteString("0x")
+    for _, v := range values {
+        sb.WriteString(fmt.Sprintf("%064x", v))
+    }
+    return sb.String()
+}
+
+func addressWord(address string) *big.Int {
+    v, _ := new(big.Int).SetString(strings.TrimPrefix(address, "0x"), 16)
+    return v
+}
+
+func TestV2PriceNormalizesDecimals(t *testing.T) {
+    decimalsCalls := 0
+    rpc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        var req struct {
+            Params []json.RawMessage `json:"params"`
+        }
+        json.NewDecoder(r.Body).Decode(&req)
+        var call struct {
+            To   string `json:"to"`
+            Data string `json:"data"`
+        }
+        json.Unmarshal(req.Params[0], &call)
+
+        var result string
+        switch call.Data {
+        case selectorToken0:
+            result = encodeWords(addressWord(testUSDC))
+        case selectorToken1:
+            result = encodeWords(addressWord(testWETH))
+        case selectorGetReserves:
+            usdc, _ := new(big.Int).SetString("3000000000000", 10)          // 3,000,000 USDC
+            weth, _ := new(big.Int).SetString("1000000000000000000000", 10) // 1,000 WETH
+            result = encodeWords(usdc, weth, big.NewInt(0))
+        case selectorDecimals:
+            decimalsCalls++
+            if strings.EqualFold(call.To, testUSDC) {
+                result = encodeWords(big.NewInt(6))
+            } else {
+                result = encodeWords(big.NewInt(18))
+            }
+        }
+        fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":"%s"}`, result)
+    }))
+    defer rpc.Close()
+
+    reader := NewPoolReader(NewClient(rpc.URL, nil))
+
+    for i := 0; i < 2; i++ {
+        price, err := reader.V2Price(context.Background(), testPool, testWETH)
+        if err != nil {
+            t.Fatalf("Failed to read pool price: %v", err)
+        }
+        if math.Abs(price-3000) > 1e-6 {
+            t.Errorf("Expected WETH price 3000, got %f", price)
+        }
+    }
+
+    if decimalsCalls != 2 {
+        t.Errorf("Expected decimals to be read once per token, got %d calls", decimalsCalls)
+    }
+}
+
+func TestPriceFromSqrtPriceX96(t *testing.T) {
+    // sqrt(1e-12 * 3000) * 2^96 for a USDC(6)/WETH(18) pool at 3000 USDC per WETH
+    // expressed as token0 (USDC) priced in token1 (WETH)
+    sqrtPrice := new(big.Float).SetFloat64(math.Sqrt(1e12 / 3000))
+    sqrtPrice.Mul(sqrtPrice, new(big.Float).SetInt(new(big.Int).Lsh(big.NewInt(1), 96)))
+    sqrtPriceX96, _ := sqrtPrice.Int(nil)
+
+    price, err := PriceFromSqrtPriceX96(sqrtPriceX96, 6, 18)
+    if err != nil {
+        t.Fatalf("Failed to convert sqrtPriceX96: %v", err)
+    }
+    if math.Abs(1/price-3000) > 1e-6 {
+        t.Errorf("Expected 3000 USDC per WETH, got %f", 1/price)
+    }
+}
@@ -0,0 +1,239 @@
+package evm
+
+import (
+    "context"
+    "encoding/hex"
+    "fmt"
+    "math/big"
+    "strings"
+)
+
+// Selectors of the reference PriceFeed contract (contracts/PriceFeed.sol)
+const (
+    selectorPriceFeedUpdate = "0x5d8251e6" // updateFeed(bytes32,uint64,uint256)
+    selectorUpdateComposed  = "0xa48fd210" // updateFeedWithComposition(bytes32,uint64,uint256,uint256,bytes32)
+    selectorLatestRoundData = "0x427aac35" // latestRoundData(bytes32)
+    selectorGetRoundData    = "0x4efecfb4" // getRoundData(bytes32,uint64)
+    selectorRoundComposed   = "0x99d56a19" // getRoundComposition(bytes32,uint64)
+    selectorSetPublisher    = "0x618bb079" // setPublisher(address,bool)
+    selectorPublishers      = "0x0a4d85cd" // publishers(address)
+)
+
+// FeedRound is a round read from a PriceFeed contract
+type FeedRound struct {
+    RoundID   uint64
+    Value     *big.Int
+    UpdatedAt uint64
+}
+
+// PriceFeed binds the reference PriceFeed contract
+type PriceFeed struct {
+    client  *Client
+    address string
+}
+
+// NewPriceFeed binds the PriceFeed contract at address
+func NewPriceFeed(client *Client, address string) *PriceFeed {
+    return &PriceFeed{client: client, address: address}
+}
+
+// Address returns the contract address
+func (f *PriceFeed) Address() string {
+    return f.address
+}
+
+// UpdateFeed sends updateFeed(feedID, roundID, value) from a publisher
+func (f *PriceFeed) UpdateFeed(ctx context.Context, from, feedID string, roundID uint64, value *big.Int) (string, error) {
+    id, err := EncodeBytes32(feedID)
+    if err != nil {
+        return "", err
+    }
+    amount, err := EncodeUint256(value)
+    if err != nil {
+        return "", err
+    }
+    round, _ := EncodeUint256(new(big.Int).SetUint64(roundID))
+    return f.client.SendTransaction(ctx, from, f.address, calldata(selectorPriceFeedUpdate, id, round, amount))
+}
+
+// UpdateFeedWithComposition sends updateFeedWithComposition(feedID,
+// roundID, value, sources, hash) from a publisher, where sources is the
+// bitmap of contributing sources and hash the 32-byte composition hash
+func (f *PriceFeed) UpdateFeedWithComposition(ctx context.Context, from, feedID string, roundID uint64, value, sources *big.Int, hash []byte) (string, error) {
+    id, err := EncodeBytes32(feedID)
+    if err != nil {
+        return "", err
+    }
+    amount, err := EncodeUint256(value)
+    if err != nil {
+        return "", err
+    }
+    bitmap, err := EncodeUint256(sources)
+    if err != nil {
+        return "", err
+    }
+    if len(hash) != 32 {
+        return "", fmt.Errorf("composition hash must be 32 bytes, got %d", len(hash))
+    }
+    round, _ := EncodeUint256(new(big.Int).SetUint64(roundID))
+    return f.client.SendTransaction(ctx, from, f.address, calldata(selectorUpdateComposed, id, round, amount, bitmap, hash))
+}
+
+// SetPublisher sends setPublisher(publisher, allowed) from the owner
+func (f *PriceFeed) SetPublisher(ctx context.Context, from, publisher string, allowed bool) (string, error) {
+    account, err := EncodeAddress(publisher)
+    if err != nil {
+        return "", err
+    }
+    return f.client.SendTransaction(ctx, from, f.address, calldata(selectorSetPublisher, account, encodeBool(allowed)))
+}
+
+// LatestRoundData returns the latest round of a feed
+func (f *PriceFeed) LatestRoundData(ctx context.Context, feedID string) (*FeedRound, error) {
+    id, err := EncodeBytes32(feedID)
+    if err != nil {
+        return nil, err
+    }
+    data, err := f.call(ctx, calldata(selectorLatestRoundData, id))
+    if err != nil {
+        return nil, err
+    }
+    return decodeRound(data, true)
+}
+
+// GetRoundData returns a published round of a feed
+func (f *PriceFeed) GetRoundData(ctx context.Context, feedID string, roundID uint64) (*FeedRound, error) {
+    id, err := EncodeBytes32(feedID)
+    if err != nil {
+        return nil, err
+    }
+    round, _ := EncodeUint256(new(big.Int).SetUint64(roundID))
+    data, err := f.call(ctx, calldata(selectorGetRoundData, id, round))
+    if err != nil {
+        return nil, err
+    }
+    r, err := decodeRound(data, false)
+    if err != nil {
+        return nil, err
+    }
+    r.RoundID = roundID
+    return r, nil
+}
+
+// GetRoundComposition returns the source bitmap and composition hash of a
+// published round, both zero for rounds published without one
+func (f *PriceFeed) GetRoundComposition(ctx context.Context, feedID string, roundID uint64) (*big.Int, []byte, error) {
+    id, err := EncodeBytes32(feedID)
+    if err != nil {
+        return nil, nil, err
+    }
+    round, _ := EncodeUint256(new(big.Int).SetUint64(roundID))
+    data, err := f.call(ctx, calldata(selectorRoundComposed, id, round))
+    if err != nil {
+        return nil, nil, err
+    }
+    sources, err := wordInt(data, 0)
+    if err != nil {
+        return nil, nil, err
+    }
+    hash, err := word(data, 1)
+    if err != nil {
+        return nil, nil, err
+    }
+    return sources, hash, nil
+}
+
+// IsPublisher reports whether an account may publish
+func (f *PriceFeed) IsPublisher(ctx context.Context, account string) (bool, error) {
+    word, err := EncodeAddress(account)
+    if err != nil {
+        return false, err
+    }
+    data, err := f.call(ctx, calldata(selectorPublishers, word))
+    if err != nil {
+        return false, err
+    }
+    n, err := wordInt(data, 0)
+    if err != nil {
+        return false, err
+    }
+    return n.Sign() != 0, nil
+}
+
+// Decimals returns the fixed-point decimals of the feed values
+func (f *PriceFeed) Decimals(ctx context.Context) (int, error) {
+    data, err := f.call(ctx, calldata(selectorDecimals))
+    if err != nil {
+        return 0, err
+    }
+    n, err := wordInt(data, 0)
+    if err != nil {
+        return 0, err
+    }
+    return int(n.Int64()), nil
+}
+
+// call performs an eth_call against the contract
+func (f *PriceFeed) call(ctx context.Context, data []byte) ([]byte, error) {
+    return f.client.Call(ctx, f.address, "0x"+hex.EncodeToString(data))
+}
+
+// decodeRound decodes (roundId, value, updatedAt), or (value, updatedAt)
+// without a round ID
+func decodeRound(data []byte, withID bool) (*FeedRound, error) {
+    r := &FeedRound{}
+    i := 0
+    if withID {
+        id, err := wordInt(data, 0)
+        if err != nil {
+            return nil, err
+        }
+        r.RoundID = id.Uint64()
+        i = 1
+    }
+    value, err := wordInt(data, i)
+    if err != nil {
+        return nil, err
+    }
+    updatedAt, err := wordInt(data, i+1)
+    if err != nil {
+        return nil, err
+    }
+    r.Value, r.UpdatedAt = value, updatedAt.Uint64()
+    return r, nil
+}
+
+// PriceFeedConstructorArgs ABI-encodes the constructor arguments
+// (uint8 decimals, string description) appended to the deployment bytecode
+func PriceFeedConstructorArgs(decimals int, description string) ([]byte, error) {
+    if decimals < 0 || decimals > 255 {
+        return nil, fmt.Errorf("decimals out of range: %d", decimals)
+    }
+    head, _ := EncodeUint256(big.NewInt(int64(decimals)))
+    offset, _ := EncodeUint256(big.NewInt(64))
+    length, _ := EncodeUint256(big.NewInt(int64(len(description))))
+    padded := make([]byte, (len(description)+31)/32*32)
+    copy(padded, description)
+
+    args := append(head, offset...)
+    args = append(args, length...)
+    return append(args, padded...), nil
+}
+
+// calldata concatenates a hex selector and encoded words
+func calldata(selector string, words ...[]byte) []byte {
+    data, _ := hex.DecodeString(strings.TrimPrefix(selector, "0x"))
+    for _, w := range words {
+        data = append(data, w...)
+    }
+    return data
+}
+
+// encodeBool encodes a bool as a 32-byte word
+func encodeBool(b bool) []byte {
+    w := make([]byte, 32)
+    if b {
+        w[31] = 1
+    }
+    return w
+}
@@ -0,0 +1,62 @@
+package evm
+
+import (
+    "context"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "math/big"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+)
+
+func TestPriceFeedLatestRoundData(t *testing.T) {
+    feedID, _ := EncodeBytes32("ETHUSDT")
+    rpc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        var req struct {
+            Params []json.RawMessage `json:"params"`
+        }
+        json.NewDecoder(r.Body).Decode(&req)
+        var call struct {
+            Data string `json:"data"`
+        }
+        json.Unmarshal(req.Params[0], &call)
+
+        if call.Data != selectorLatestRoundData+hex.EncodeToString(feedID) {
+            t.Errorf("Unexpected call data %s", call.Data)
+        }
+        value := big.NewInt(312345000000)
+        fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":"%s"}`, encodeWords(big.NewInt(42), value, big.NewInt(1717243200)))
+    }))
+    defer rpc.Close()
+
+    round, err := NewPriceFeed(NewClient(rpc.URL, nil), testPool).LatestRoundData(context.Background(), "ETHUSDT")
+    if err != nil {
+        t.Fatalf("Failed to read latest round: %v", err)
+    }
+    if round.RoundID != 42 || round.Value.Int64() != 312345000000 || round.UpdatedAt != 1717243200 {
+        t.Errorf("Unexpected round %+v", round)
+    }
+}
+
+func TestPriceFeedConstructorArgs(t *testing.T) {
+    args, err := PriceFeedConstructorArgs(8, "oracle feeds")
+    if err != nil {
+        t.Fatalf("Failed to encode constructor args: %v", err)
+    }
+    want := strings.Join([]string{
+        fmt.Sprintf("%064x", 8),
+        fmt.Sprintf("%064x", 64),
+        fmt.Sprintf("%064x", len("oracle feeds")),
+        hex.EncodeToString([]byte("oracle feeds")) + strings.Repeat("0", 64-2*len("oracle feeds")),
+    }, "")
+    if got := hex.EncodeToString(args); got != want {
+        t.Errorf("Unexpected encoding\n got %s\nwant %s", got, want)
+    }
+
+    if _, err := PriceFeedConstructorArgs(300, ""); err == nil {
+        t.Error("Expected error for decimals out of range, got nil")
+    }
+}
@@ -0,0 +1,253 @@
+package evm
+
+import (
+    "context"
+    "encoding/hex"
+    "fmt"
+    "math/big"
+    "strings"
+)
+
+// TxReceipt is the subset of a transaction receipt the oracle tracks
+type TxReceipt struct {
+    BlockNumber uint64
+    GasUsed     uint64
+    Success     bool
+    // ContractAddress is set on receipts of contract deployments
+    ContractAddress string
+}
+
+// SendTransaction submits a transaction through eth_sendTransaction. The
+// node or a remote signer attached to it holds the key for from.
+func (c *Client) SendTransaction(ctx context.Context, from, to string, data []byte) (string, error) {
+    var hash string
+    tx := map[string]string{
+        "from": from,
+        "to":   to,
+        "data": "0x" + hex.EncodeToString(data),
+    }
+    if err := c.Do(ctx, "eth_sendTransaction", []interface{}{tx}, &hash); err != nil {
+        return "", err
+    }
+    return hash, nil
+}
+
+// Deploy submits a contract creation transaction with the given bytecode
+// and encoded constructor arguments through eth_sendTransaction
+func (c *Client) Deploy(ctx context.Context, from string, code []byte) (string, error) {
+    var hash string
+    tx := map[string]string{
+        "from": from,
+        "data": "0x" + hex.EncodeToString(code),
+    }
+    if err := c.Do(ctx, "eth_sendTransaction", []interface{}{tx}, &hash); err != nil {
+        return "", err
+    }
+    return hash, nil
+}
+
+// SendValue transfers value wei from from to to through eth_sendTransaction
+func (c *Client) SendValue(ctx context.Context, from, to string, value *big.Int) (string, error) {
+    var hash string
+    tx := map[string]string{
+        "from":  from,
+        "to":    to,
+        "value": "0x" + value.Text(16),
+    }
+    if err := c.Do(ctx, "eth_sendTransaction", []interface{}{tx}, &hash); err != nil {
+        return "", err
+    }
+    return hash, nil
+}
+
+// SendTransactionAt submits a transaction with an explicit nonce and gas
+// price, replacing any pending transaction of from with the same nonce
+func (c *Client) SendTransactionAt(ctx context.Context, from, to string, data []byte, value *big.Int, nonce uint64, gasPrice *big.Int) (string, error) {
+    var hash string
+    tx := map[string]string{
+        "from":     from,
+        "to":       to,
+        "nonce":    fmt.Sprintf("0x%x", nonce),
+        "gasPrice": "0x" + gasPrice.Text(16),
+    }
+    if len(data) > 0 {
+        tx["data"] = "0x" + hex.EncodeToString(data)
+    }
+    if value != nil && value.Sign() > 0 {
+        tx["value"] = "0x" + value.Text(16)
+    }
+    if err := c.Do(ctx, "eth_sendTransaction", []interface{}{tx}, &hash); err != nil {
+        return "", err
+    }
+    return hash, nil
+}
+
+// Tx is the subset of a transaction the oracle inspects
+type Tx struct {
+    Hash     string
+    From     string
+    To       string
+    Nonce    uint64
+    GasPrice *big.Int
+    Value    *big.Int
+    Input    []byte
+    // BlockNumber is zero while the transaction is pending
+    BlockNumber uint64
+}
+
+// Transaction returns a transaction known to the node, mined or pending,
+// or nil when the node does not know it, e.g. after it was dropped
+func (c *Client) Transaction(ctx context.Context, hash string) (*Tx, error) {
+    var raw *struct {
+        Hash        string  `json:"hash"`
+        From        string  `json:"from"`
+        To          string  `json:"to"`
+        Nonce       string  `json:"nonce"`
+        GasPrice    string  `json:"gasPrice"`
+        Value       string  `json:"value"`
+        Input       string  `json:"input"`
+        BlockNumber *string `json:"blockNumber"`
+    }
+    if err := c.Do(ctx, "eth_getTransactionByHash", []interface{}{hash}, &raw); err != nil {
+        return nil, err
+    }
+    if raw == nil {
+        return nil, nil
+    }
+
+    nonce, err := parseQuantity(raw.Nonce)
+    if err != nil {
+        return nil, fmt.Errorf("invalid nonce: %v", err)
+    }
+    gasPrice, ok := new(big.Int).SetString(strings.TrimPrefix(raw.GasPrice, "0x"), 16)
+    if !ok {
+        return nil, fmt.Errorf("invalid gas price %q", raw.GasPrice)
+    }
+    value, ok := new(big.Int).SetString(strings.TrimPrefix(raw.Value, "0x"), 16)
+    if !ok {
+        return nil, fmt.Errorf("invalid value %q", raw.Value)
+    }
+    input, err := hex.DecodeString(strings.TrimPrefix(raw.Input, "0x"))
+    if err != nil {
+        return nil, fmt.Errorf("invalid input: %v", err)
+    }
+    tx := &Tx{Hash: raw.Hash, From: raw.From, To: raw.To, Nonce: nonce, GasPrice: gasPrice, Value: value, Input: input}
+    if raw.BlockNumber != nil {
+        if tx.BlockNumber, err = parseQuantity(*raw.BlockNumber); err != nil {
+            return nil, fmt.Errorf("invalid block number: %v", err)
+        }
+    }
+    return tx, nil
+}
+
+// TransactionCount returns the next nonce of an account at block, "latest"
+// for mined transactions or "pending" to include the node's mempool
+func (c *Client) TransactionCount(ctx context.Context, address, block string) (uint64, error) {
+    var result string
+    if err := c.Do(ctx, "eth_getTransactionCount", []interface{}{address, block}, &result); err != nil {
+        return 0, err
+    }
+    return parseQuantity(result)
+}
+
+// GasPrice returns the node's suggested gas price in wei
+func (c *Client) GasPrice(ctx context.Context) (*big.Int, error) {
+    var result string
+    if err := c.Do(ctx, "eth_gasPrice", []interface{}{}, &result); err != nil {
+        return nil, err
+    }
+    price, ok := new(big.Int).SetString(strings.TrimPrefix(result, "0x"), 16)
+    if !ok {
+        return nil, fmt.Errorf("invalid gas price %q", result)
+    }
+    return price, nil
+}
+
+// Balance returns the balance of an account in wei at the latest block
+func (c *Client) Balance(ctx context.Context, address string) (*big.Int, error) {
+    var result string
+    if err := c.Do(ctx, "eth_getBalance", []interface{}{address, "latest"}, &result); err != nil {
+        return nil, err
+    }
+    balance, ok := new(big.Int).SetString(strings.TrimPrefix(result, "0x"), 16)
+    if !ok {
+        return nil, fmt.Errorf("invalid balance %q", result)
+    }
+    return balance, nil
+}
+
+// TransactionReceipt returns the receipt of a mined transaction, or nil
+// while it is still pending
+func (c *Client) TransactionReceipt(ctx context.Context, hash string) (*TxReceipt, error) {
+    var raw *struct {
+        BlockNumber     string `json:"blockNumber"`
+        GasUsed         string `json:"gasUsed"`
+        Status          string `json:"status"`
+        ContractAddress string `json:"contractAddress"`
+    }
+    if err := c.Do(ctx, "eth_getTransactionReceipt", []interface{}{hash}, &raw); err != nil {
+        return nil, err
+    }
+    if raw == nil {
+        return nil, nil
+    }
+
+    block, err := parseQuantity(raw.BlockNumber)
+    if err != nil {
+        return nil, fmt.Errorf("invalid block number: %v", err)
+    }
+    gas, err := parseQuantity(raw.GasUsed)
+    if err != nil {
+        return nil, fmt.Errorf("invalid gas used: %v", err)
+    }
+    return &TxReceipt{BlockNumber: block, GasUsed: gas, Success: raw.Status == "0x1", ContractAddress: raw.ContractAddress}, nil
+}
+
+// BlockNumber returns the latest block number
+func (c *Client) BlockNumber(ctx context.Context) (uint64, error) {
+    var result string
+    if err := c.Do(ctx, "eth_blockNumber", []interface{}{}, &result); err != nil {
+        return 0, err
+    }
+    return parseQuantity(result)
+}
+
+// parseQuantity decodes a hex-encoded JSON-RPC quantity
+func parseQuantity(s string) (uint64, error) {
+    n, ok := new(big.Int).SetString(strings.TrimPrefix(s, "0x"), 16)
+    if !ok || !n.IsUint64() {
+        return 0, fmt.Errorf("invalid quantity %q", s)
+    }
+    return n.Uint64(), nil
+}
+
+// EncodeBytes32 left-aligns a short string into a 32-byte word
+func EncodeBytes32(s string) ([]byte, error) {
+    if len(s) > 32 {
+        return nil, fmt.Errorf("%q does not fit in bytes32", s)
+    }
+    w := make([]byte, 32)
+    copy(w, s)
+    return w, nil
+}
+
+// EncodeUint256 encodes a non-negative integer as a 32-byte word
+func EncodeUint256(n *big.Int) ([]byte, error) {
+    if n.Sign() < 0 || n.BitLen() > 256 {
+        return nil, fmt.Errorf("%s does not fit in uint256", n)
+    }
+    w := make([]byte, 32)
+    n.FillBytes(w)
+    return w, nil
+}
+
+// EncodeAddress encodes a hex address as a 32-byte word
+func EncodeAddress(address string) ([]byte, error) {
+    raw, err := hex.DecodeString(strings.TrimPrefix(address, "0x"))
+    if err != nil || len(raw) != 20 {
+        return nil, fmt.Errorf("invalid address %q", address)
+    }
+    w := make([]byte, 32)
+    copy(w[12:], raw)
+    return w, nil
+}
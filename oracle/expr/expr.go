@@ -0,0 +1,338 @@
+// Package expr evaluates the arithmetic expressions of feed transforms, such
+// as "price * 1e8", "1 / price" or "price - fundingAdjustment".
+//
+// Expressions combine numbers, identifiers and parentheses with + - * /
+// and unary minus, and may call abs, min and max.
+package expr
+
+import (
+    "fmt"
+    "math"
+    "sort"
+    "strconv"
+    "unicode"
+)
+
+// Expr is a parsed expression
+type Expr struct {
+    source string
+    root   node
+}
+
+// Resolver returns the value of an identifier
+type Resolver func(name string) (float64, error)
+
+// Parse parses an expression
+func Parse(source string) (*Expr, error) {
+    p := &parser{source: source}
+    p.next()
+    root, err := p.expression()
+    if err != nil {
+        return nil, err
+    }
+    if p.tok.kind != tokEOF {
+        return nil, p.errorf("unexpected %q", p.tok.text)
+    }
+    return &Expr{source: source, root: root}, nil
+}
+
+// String returns the source of the expression
+func (e *Expr) String() string {
+    return e.source
+}
+
+// Identifiers returns the identifiers the expression references, sorted
+func (e *Expr) Identifiers() []string {
+    seen := make(map[string]bool)
+    e.root.identifiers(seen)
+    names := make([]string, 0, len(seen))
+    for name := range seen {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+// Eval evaluates the expression; results that are not finite numbers, such
+// as divisions by zero, are errors
+func (e *Expr) Eval(resolve Resolver) (float64, error) {
+    v, err := e.root.eval(resolve)
+    if err != nil {
+        return 0, err
+    }
+    if math.IsNaN(v) || math.IsInf(v, 0) {
+        return 0, fmt.Errorf("%s is not a finite number", e.source)
+    }
+    return v, nil
+}
+
+// node is an expression tree node
+type node interface {
+    eval(resolve Resolver) (float64, error)
+    identifiers(seen map[string]bool)
+}
+
+type number float64
+
+func (n number) eval(Resolver) (float64, error) { return float64(n), nil }
+func (n number) identifiers(map[string]bool)    {}
+
+type identifier string
+
+func (id identifier) eval(resolve Resolver) (float64, error) {
+    return resolve(string(id))
+}
+func (id identifier) identifiers(seen map[string]bool) { seen[string(id)] = true }
+
+type negation struct{ operand node }
+
+func (n negation) eval(resolve Resolver) (float64, error) {
+    v, err := n.operand.eval(resolve)
+    return -v, err
+}
+func (n negation) identifiers(seen map[string]bool) { n.operand.identifiers(seen) }
+
+type binary struct {
+    op          byte
+    left, right node
+}
+
+func (b binary) eval(resolve Resolver) (float64, error) {
+    l, err := b.left.eval(resolve)
+    if err != nil {
+        return 0, err
+    }
+    r, err := b.right.eval(resolve)
+    if err != nil {
+        return 0, err
+    }
+    switch b.op {
+    case '+':
+        return l + r, nil
+    case '-':
+        return l - r, nil
+    case '*':
+        return l * r, nil
+    default:
+        if r == 0 {
+            return 0, fmt.Errorf("division by zero")
+        }
+        return l / r, nil
+    }
+}
+func (b binary) identifiers(seen map[string]bool) {
+    b.left.identifiers(seen)
+    b.right.identifiers(seen)
+}
+
+// functions are the callable functions and their arities, -1 for at
+// least one argument
+var functions = map[string]int{"abs": 1, "min": -1, "max": -1}
+
+type call struct {
+    name string
+    args []node
+}
+
+func (c call) eval(resolve Resolver) (float64, error) {
+    values := make([]float64, len(c.args))
+    for i, arg := range c.args {
+        v, err := arg.eval(resolve)
+        if err != nil {
+            return 0, err
+        }
+        values[i] = v
+    }
+    result := values[0]
+    switch c.name {
+    case "abs":
+        result = math.Abs(result)
+    case "min":
+        for _, v := range values[1:] {
+            result = math.Min(result, v)
+        }
+    case "max":
+        for _, v := range values[1:] {
+            result = math.Max(result, v)
+        }
+    }
+    return result, nil
+}
+func (c call) identifiers(seen map[string]bool) {
+    for _, arg := range c.args {
+        arg.identifiers(seen)
+    }
+}
+
+// Token kinds
+const (
+    tokEOF = iota
+    tokNumber
+    tokIdent
+    tokOp // + - * / ( ) , or an unknown character
+)
+
+type token struct {
+    kind int
+    text string
+    pos  int
+}
+
+// parser is a recursive descent parser:
+//
+//	expression = term { ("+" | "-") term }
+//	term       = unary { ("*" | "/") unary }
+//	unary      = "-" unary | primary
+//	primary    = number | identifier | identifier "(" expression { "," expression } ")" | "(" expression ")"
+type parser struct {
+    source string
+    pos    int
+    tok    token
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+    return fmt.Errorf("invalid expression %q at %d: %s", p.source, p.tok.pos, fmt.Sprintf(format, args...))
+}
+
+// next scans the next token
+func (p *parser) next() {
+    for p.pos < len(p.source) && p.source[p.pos] == ' ' {
+        p.pos++
+    }
+    start := p.pos
+    if p.pos >= len(p.source) {
+        p.tok = token{kind: tokEOF, pos: start}
+        return
+    }
+    c := rune(p.source[p.pos])
+    switch {
+    case unicode.IsDigit(c) || c == '.':
+        for p.pos < len(p.source) && (unicode.IsDigit(rune(p.source[p.pos])) || p.source[p.pos] == '.') {
+            p.pos++
+        }
+        // Exponent, e.g. 1e8 or 2.5E-3
+        if p.pos < len(p.source) && (p.source[p.pos] == 'e' || p.source[p.pos] == 'E') {
+            p.pos++
+            if p.pos < len(p.source) && (p.source[p.pos] == '+' || p.source[p.pos] == '-') {
+                p.pos++
+            }
+            for p.pos < len(p.source) && unicode.IsDigit(rune(p.source[p.pos])) {
+                p.pos++
+            }
+        }
+        p.tok = token{kind: tokNumber, text: p.source[start:p.pos], pos: start}
+    case unicode.IsLetter(c) || c == '_':
+        for p.pos < len(p.source) && (unicode.IsLetter(rune(p.source[p.pos])) || unicode.IsDigit(rune(p.source[p.pos])) || p.source[p.pos] == '_') {
+            p.pos++
+        }
+        p.tok = token{kind: tokIdent, text: p.source[start:p.pos], pos: start}
+    default:
+        // Anything else is an operator; unknown ones fail to parse
+        p.pos++
+        p.tok = token{kind: tokOp, text: string(c), pos: start}
+    }
+}
+
+func (p *parser) expression() (node, error) {
+    left, err := p.term()
+    if err != nil {
+        return nil, err
+    }
+    for p.tok.kind == tokOp && (p.tok.text == "+" || p.tok.text == "-") {
+        op := p.tok.text[0]
+        p.next()
+        right, err := p.term()
+        if err != nil {
+            return nil, err
+        }
+        left = binary{op: op, left: left, right: right}
+    }
+    return left, nil
+}
+
+func (p *parser) term() (node, error) {
+    left, err := p.unary()
+    if err != nil {
+        return nil, err
+    }
+    for p.tok.kind == tokOp && (p.tok.text == "*" || p.tok.text == "/") {
+        op := p.tok.text[0]
+        p.next()
+        right, err := p.unary()
+        if err != nil {
+            return nil, err
+        }
+        left = binary{op: op, left: left, right: right}
+    }
+    return left, nil
+}
+
+func (p *parser) unary() (node, error) {
+    if p.tok.kind == tokOp && p.tok.text == "-" {
+        p.next()
+        operand, err := p.unary()
+        if err != nil {
+            return nil, err
+        }
+        return negation{operand: operand}, nil
+    }
+    return p.primary()
+}
+
+func (p *parser) primary() (node, error) {
+    tok := p.tok
+    switch {
+    case tok.kind == tokNumber:
+        v, err := strconv.ParseFloat(tok.text, 64)
+        if err != nil {
+            return nil, p.errorf("invalid number %q", tok.text)
+        }
+        p.next()
+        return number(v), nil
+    case tok.kind == tokIdent:
+        p.next()
+        if p.tok.kind != tokOp || p.tok.text != "(" {
+            return identifier(tok.text), nil
+        }
+        arity, ok := functions[tok.text]
+        if !ok {
+            return nil, p.errorf("unknown function %s", tok.text)
+        }
+        p.next()
+        var args []node
+        for {
+            arg, err := p.expression()
+            if err != nil {
+                return nil, err
+            }
+            args = append(args, arg)
+            if p.tok.kind == tokOp && p.tok.text == "," {
+                p.next()
+                continue
+            }
+            break
+        }
+        if p.tok.kind != tokOp || p.tok.text != ")" {
+            return nil, p.errorf("expected )")
+        }
+        p.next()
+        if arity > 0 && len(args) != arity {
+            return nil, p.errorf("%s takes %d argument(s)", tok.text, arity)
+        }
+        return call{name: tok.text, args: args}, nil
+    case tok.kind == tokOp && tok.text == "(":
+        p.next()
+        inner, err := p.expression()
+        if err != nil {
+            return nil, err
+        }
+        if p.tok.kind != tokOp || p.tok.text != ")" {
+            return nil, p.errorf("expected )")
+        }
+        p.next()
+        return inner, nil
+    case tok.kind == tokEOF:
+        return nil, p.errorf("unexpected end")
+    }
+    return nil, p.errorf("unexpected %q", tok.text)
+}
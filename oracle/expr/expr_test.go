@@ -0,0 +1,55 @@
+package expr
+
+import (
+    "fmt"
+    "math"
+    "reflect"
+    "testing"
+)
+
+func TestEval(t *testing.T) {
+    vars := map[string]float64{"price": 2500, "fundingAdjustment": 12.5, "ETHUSDT_30D_VOL": 0.5}
+    resolve := func(name string) (float64, error) {
+        if v, ok := vars[name]; ok {
+            return v, nil
+        }
+        return 0, fmt.Errorf("unknown %s", name)
+    }
+
+    cases := map[string]float64{
+        "price * 1e8":                   2.5e11,
+        "1/price":                       0.0004,
+        "price - fundingAdjustment":     2487.5,
+        "-(price + 500) / 2 * 3":        -4500,
+        "2 - 3 - 4":                     -5,
+        "max(price, 3000) * 2.5E-1":     750,
+        "abs(-price) + ETHUSDT_30D_VOL": 2500.5,
+    }
+    for source, want := range cases {
+        e, err := Parse(source)
+        if err != nil {
+            t.Errorf("Failed to parse %q: %v", source, err)
+            continue
+        }
+        got, err := e.Eval(resolve)
+        if err != nil || math.Abs(got-want) > 1e-9*math.Abs(want) {
+            t.Errorf("%s: expected %g, got %g, %v", source, want, got, err)
+        }
+    }
+
+    e, _ := Parse("price / (fundingAdjustment - 12.5)")
+    if _, err := e.Eval(resolve); err == nil {
+        t.Error("Expected error for division by zero, got nil")
+    }
+    if ids := e.Identifiers(); !reflect.DeepEqual(ids, []string{"fundingAdjustment", "price"}) {
+        t.Errorf("Expected identifiers fundingAdjustment and price, got %v", ids)
+    }
+}
+
+func TestParseErrors(t *testing.T) {
+    for _, source := range []string{"", "price *", "(price", "price price", "sqrt(price)", "abs(1, 2)", "price % 2", "1..2"} {
+        if _, err := Parse(source); err == nil {
+            t.Errorf("Expected error parsing %q, got nil", source)
+        }
+    }
+}
@@ -0,0 +1,99 @@
+package fetch
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "mime"
+    "net/http"
+    "strings"
+    "time"
+)
+
+// MaxBodyBytes caps the response bodies decoded by DecodeJSON
+const MaxBodyBytes = 4 << 20
+
+// snippetBytes is how much of an unexpected body is kept for diagnostics
+const snippetBytes = 120
+
+// ResponseTooLargeError reports a response body exceeding the size limit
+type ResponseTooLargeError struct {
+    Host  string
+    Limit int64
+}
+
+func (e *ResponseTooLargeError) Error() string {
+    return fmt.Sprintf("response from %s exceeds %d bytes", e.Host, e.Limit)
+}
+
+// ContentTypeError reports a response that is not JSON, typically an HTML
+// error page served by a CDN or gateway in front of the API
+type ContentTypeError struct {
+    Host        string
+    Status      int
+    ContentType string
+    Snippet     string
+}
+
+func (e *ContentTypeError) Error() string {
+    return fmt.Sprintf("unexpected %s response from %s (status %d): %q", e.ContentType, e.Host, e.Status, e.Snippet)
+}
+
+// DecodeJSON decodes a JSON response body into out, limited to MaxBodyBytes
+func DecodeJSON(resp *http.Response, out interface{}) error {
+    return DecodeJSONLimit(resp, MaxBodyBytes, out)
+}
+
+// DecodeJSONLimit checks the content type of resp and decodes at most limit
+// bytes of its body into out. Oversized and non-JSON responses are returned
+// as *ResponseTooLargeError and *ContentTypeError without being unmarshalled.
+// The shape of successful responses to requests labelled with WithSchema is
+// recorded.
+func DecodeJSONLimit(resp *http.Response, limit int64, out interface{}) error {
+    host := ""
+    if resp.Request != nil && resp.Request.URL != nil {
+        host = resp.Request.URL.Host
+    }
+
+    if contentType := resp.Header.Get("Content-Type"); !jsonCompatible(contentType) {
+        snippet, _ := io.ReadAll(io.LimitReader(resp.Body, snippetBytes))
+        return &ContentTypeError{
+            Host:        host,
+            Status:      resp.StatusCode,
+            ContentType: contentType,
+            Snippet:     strings.TrimSpace(string(snippet)),
+        }
+    }
+
+    // Read one byte past the limit to tell a full body from a truncated one
+    body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+    if err != nil {
+        return err
+    }
+    if int64(len(body)) > limit {
+        return &ResponseTooLargeError{Host: host, Limit: limit}
+    }
+    if resp.Request != nil && resp.StatusCode/100 == 2 {
+        observeSchema(resp.Request.Context(), body, time.Now())
+    }
+    return json.Unmarshal(body, out)
+}
+
+// jsonCompatible reports whether a Content-Type may carry a JSON body.
+// Missing and text/plain types are accepted since several APIs send them.
+func jsonCompatible(contentType string) bool {
+    if contentType == "" {
+        return true
+    }
+    mediaType, _, err := mime.ParseMediaType(contentType)
+    if err != nil {
+        return false
+    }
+    switch {
+    case mediaType == "application/json", strings.HasSuffix(mediaType, "+json"):
+        return true
+    case mediaType == "text/plain", mediaType == "text/javascript", mediaType == "application/javascript":
+        return true
+    }
+    return false
+}
@@ -0,0 +1,64 @@
+package fetch
+
+import (
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+)
+
+func get(t *testing.T, contentType, body string) *http.Response {
+    t.Helper()
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if contentType != "" {
+            w.Header().Set("Content-Type", contentType)
+        }
+        w.Write([]byte(body))
+    }))
+    t.Cleanup(srv.Close)
+
+    resp, err := http.Get(srv.URL)
+    if err != nil {
+        t.Fatalf("Request failed: %v", err)
+    }
+    t.Cleanup(func() { resp.Body.Close() })
+    return resp
+}
+
+func TestDecodeJSON(t *testing.T) {
+    var out struct {
+        Price string `json:"price"`
+    }
+    if err := DecodeJSON(get(t, "application/json; charset=utf-8", `{"price":"1.5"}`), &out); err != nil {
+        t.Fatalf("Unexpected error: %v", err)
+    }
+    if out.Price != "1.5" {
+        t.Errorf("Expected price 1.5, got %q", out.Price)
+    }
+}
+
+func TestDecodeJSONRejectsHTML(t *testing.T) {
+    var out map[string]interface{}
+    err := DecodeJSON(get(t, "text/html", "<html><body>502 Bad Gateway</body></html>"), &out)
+    var ctErr *ContentTypeError
+    if !errors.As(err, &ctErr) {
+        t.Fatalf("Expected ContentTypeError, got %v", err)
+    }
+    if !strings.Contains(ctErr.Snippet, "502 Bad Gateway") {
+        t.Errorf("Expected snippet of the error page, got %q", ctErr.Snippet)
+    }
+}
+
+func TestDecodeJSONLimit(t *testing.T) {
+    var out map[string]interface{}
+    err := DecodeJSONLimit(get(t, "application/json", `{"data":"`+strings.Repeat("x", 100)+`"}`), 64, &out)
+    var sizeErr *ResponseTooLargeError
+    if !errors.As(err, &sizeErr) || sizeErr.Limit != 64 {
+        t.Fatalf("Expected ResponseTooLargeError, got %v", err)
+    }
+
+    if err := DecodeJSONLimit(get(t, "", `{"a":1}`), 7, &out); err != nil {
+        t.Errorf("Expected body exactly at the limit to decode, got %v", err)
+    }
+}
@@ -0,0 +1,170 @@
+package fetch
+
+import (
+    "bytes"
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "io"
+    "net/http"
+    "sync"
+    "time"
+)
+
+// cachedResponse is a successful response shared by identical requests
+type cachedResponse struct {
+    status  string
+    code    int
+    proto   string
+    major   int
+    minor   int
+    header  http.Header
+    body    []byte
+    expires time.Time
+}
+
+// response returns a fresh copy of the shared response for req
+func (c *cachedResponse) response(req *http.Request) *http.Response {
+    return &http.Response{
+        Status:        c.status,
+        StatusCode:    c.code,
+        Proto:         c.proto,
+        ProtoMajor:    c.major,
+        ProtoMinor:    c.minor,
+        Header:        c.header.Clone(),
+        Body:          io.NopCloser(bytes.NewReader(c.body)),
+        ContentLength: int64(len(c.body)),
+        Request:       req,
+    }
+}
+
+// cacheCall is an upstream request whose response identical requests
+// arriving meanwhile wait for
+type cacheCall struct {
+    done   chan struct{}
+    cached *cachedResponse // nil unless the response can be shared
+}
+
+type cacheTTLKey struct{}
+
+// WithCache shares the responses to requests made with ctx among identical
+// requests for at least ttl, also on hosts configured without caching
+func WithCache(ctx context.Context, ttl time.Duration) context.Context {
+    return context.WithValue(ctx, cacheTTLKey{}, ttl)
+}
+
+// requestTTL returns how long the response to req is shared: the host's
+// cacheMs or the request's own, whichever is longer
+func requestTTL(req *http.Request) time.Duration {
+    ttl := cacheTTL(req.URL.Host)
+    if own, ok := req.Context().Value(cacheTTLKey{}).(time.Duration); ok && own > ttl {
+        return own
+    }
+    return ttl
+}
+
+var (
+    cacheMu  sync.Mutex
+    cache    = make(map[string]*cachedResponse)
+    inflight = make(map[string]*cacheCall)
+)
+
+// cacheKey identifies a request by method, URL and body, restoring the body
+// it reads
+func cacheKey(req *http.Request) (string, error) {
+    h := sha256.New()
+    io.WriteString(h, req.Method+" "+req.URL.String()+"\n")
+    if req.Body != nil && req.Body != http.NoBody {
+        body, err := io.ReadAll(req.Body)
+        req.Body.Close()
+        if err != nil {
+            return "", err
+        }
+        h.Write(body)
+        req.Body = io.NopCloser(bytes.NewReader(body))
+        req.GetBody = func() (io.ReadCloser, error) {
+            return io.NopCloser(bytes.NewReader(body)), nil
+        }
+    }
+    return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// cachedRoundTrip answers req with a response shared with identical
+// requests made within ttl, or that one of them is still waiting for.
+// Only 200 responses are shared; requests that find none go upstream
+// through send.
+func cachedRoundTrip(req *http.Request, ttl time.Duration, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+    key, err := cacheKey(req)
+    if err != nil {
+        return nil, err
+    }
+    host := req.URL.Host
+
+    cacheMu.Lock()
+    if c := cache[key]; c != nil && time.Now().Before(c.expires) {
+        cacheMu.Unlock()
+        record(host, func(s *HostStats) { s.CacheHits++ })
+        return c.response(req), nil
+    }
+    if call := inflight[key]; call != nil {
+        cacheMu.Unlock()
+        select {
+        case <-call.done:
+        case <-req.Context().Done():
+            return nil, req.Context().Err()
+        }
+        if call.cached == nil {
+            // Nothing to share, e.g. the first request failed or was
+            // cancelled; this one goes upstream itself
+            return send(req)
+        }
+        record(host, func(s *HostStats) { s.CacheHits++ })
+        return call.cached.response(req), nil
+    }
+    call := &cacheCall{done: make(chan struct{})}
+    inflight[key] = call
+    cacheMu.Unlock()
+
+    resp, err := send(req)
+    var cached *cachedResponse
+    if err == nil && resp.StatusCode == http.StatusOK {
+        body, readErr := io.ReadAll(io.LimitReader(resp.Body, MaxBodyBytes+1))
+        resp.Body.Close()
+        resp.Body = io.NopCloser(bytes.NewReader(body))
+        switch {
+        case readErr != nil:
+            resp, err = nil, readErr
+        case len(body) <= MaxBodyBytes:
+            cached = &cachedResponse{
+                status:  resp.Status,
+                code:    resp.StatusCode,
+                proto:   resp.Proto,
+                major:   resp.ProtoMajor,
+                minor:   resp.ProtoMinor,
+                header:  resp.Header.Clone(),
+                body:    body,
+                expires: time.Now().Add(ttl),
+            }
+        }
+    }
+
+    cacheMu.Lock()
+    delete(inflight, key)
+    now := time.Now()
+    for k, c := range cache {
+        if !now.Before(c.expires) {
+            delete(cache, k)
+        }
+    }
+    if cached != nil {
+        cache[key] = cached
+    }
+    cacheMu.Unlock()
+    call.cached = cached
+    close(call.done)
+
+    if cached != nil {
+        return cached.response(req), nil
+    }
+    return resp, err
+}
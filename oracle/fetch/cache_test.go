@@ -0,0 +1,122 @@
+package fetch
+
+import (
+    "context"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+)
+
+func TestResponseCache(t *testing.T) {
+    var upstream int32
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        atomic.AddInt32(&upstream, 1)
+        time.Sleep(20 * time.Millisecond)
+        if r.URL.Query().Get("fail") != "" {
+            http.Error(w, "down", http.StatusBadGateway)
+            return
+        }
+        body, _ := io.ReadAll(r.Body)
+        w.Write([]byte(`{"echo":"` + string(body) + `"}`))
+    }))
+    defer srv.Close()
+    host := mustHost(t, srv.URL)
+
+    base := &common.BaseConfig{}
+    base.Exchanges.CEX = map[string]common.CEXDetails{
+        "graph": {BaseURL: srv.URL, HTTP: common.HTTPIdentity{CacheMs: 200}},
+    }
+    Configure(base, "")
+    defer Configure(&common.BaseConfig{}, "")
+
+    client := NewClient(time.Second)
+    get := func(path, body string) string {
+        resp, err := client.Post(srv.URL+path, "application/json", strings.NewReader(body))
+        if err != nil {
+            t.Fatalf("Request failed: %v", err)
+        }
+        defer resp.Body.Close()
+        data, _ := io.ReadAll(resp.Body)
+        return string(data)
+    }
+
+    // Concurrent identical requests share one upstream call
+    var wg sync.WaitGroup
+    for i := 0; i < 5; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            if got := get("/bundle", "eth"); got != `{"echo":"eth"}` {
+                t.Errorf("Unexpected shared body %s", got)
+            }
+        }()
+    }
+    wg.Wait()
+    if n := atomic.LoadInt32(&upstream); n != 1 {
+        t.Errorf("Expected one upstream call, got %d", n)
+    }
+
+    // A different body is a different request
+    if got := get("/bundle", "btc"); got != `{"echo":"btc"}` {
+        t.Errorf("Unexpected body %s", got)
+    }
+    // Failures are not shared
+    get("/bundle?fail=1", "")
+    get("/bundle?fail=1", "")
+    if n := atomic.LoadInt32(&upstream); n != 4 {
+        t.Errorf("Expected failures to go upstream every time, got %d calls", n)
+    }
+    // Past the TTL the response is fetched again
+    time.Sleep(250 * time.Millisecond)
+    get("/bundle", "eth")
+    if n := atomic.LoadInt32(&upstream); n != 5 {
+        t.Errorf("Expected an expired response to be refetched, got %d calls", n)
+    }
+
+    for _, s := range Stats().Hosts {
+        if s.Host == host && (s.Requests != 5 || s.CacheHits != 4) {
+            t.Errorf("Expected 5 requests and 4 cache hits, got %+v", s)
+        }
+    }
+}
+
+func TestWithCache(t *testing.T) {
+    var upstream int32
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        atomic.AddInt32(&upstream, 1)
+        w.Write([]byte(`{}`))
+    }))
+    defer srv.Close()
+    Configure(&common.BaseConfig{}, "")
+
+    client := NewClient(time.Second)
+    get := func(ctx context.Context) {
+        req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/depth", nil)
+        resp, err := client.Do(req)
+        if err != nil {
+            t.Fatalf("Request failed: %v", err)
+        }
+        resp.Body.Close()
+    }
+
+    // Hosts without cacheMs go upstream every time
+    get(context.Background())
+    get(context.Background())
+    if n := atomic.LoadInt32(&upstream); n != 2 {
+        t.Fatalf("Expected 2 upstream calls without caching, got %d", n)
+    }
+
+    ctx := WithCache(context.Background(), time.Minute)
+    get(ctx)
+    get(ctx)
+    if n := atomic.LoadInt32(&upstream); n != 3 {
+        t.Errorf("Expected the request's own TTL to share the response, got %d upstream calls", n)
+    }
+}
@@ -0,0 +1,276 @@
+package fetch
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "io"
+    "log"
+    "math/rand"
+    "net/http"
+    "os"
+    "path/filepath"
+    "regexp"
+    "strconv"
+    "sync"
+    "time"
+)
+
+// Chaos fault kinds
+const (
+    FaultDelay   = "delay"   // hold the request before sending it
+    FaultError   = "error"   // fail the request without sending it
+    FaultCorrupt = "corrupt" // scale every decimal number in the response body
+)
+
+// ChaosConfig enables injecting faults into a fraction of upstream
+// responses, for verifying outlier rejection, fallbacks and alerting in
+// staging. It must never be enabled in production.
+type ChaosConfig struct {
+    Enabled bool `json:"enabled"`
+    // Fraction of requests that get a fault, 0 to 1
+    Fraction float64 `json:"fraction"`
+    // Hosts limits faults to these upstream hosts; empty means the hosts of
+    // the configured sources, sparing RPC, standby and replica traffic
+    Hosts []string `json:"hosts,omitempty"`
+    // Faults are relative weights of the fault kinds; empty weighs all equally
+    Faults     map[string]float64 `json:"faults,omitempty"`
+    MaxDelayMs int                `json:"maxDelayMs,omitempty"` // default 3000
+    // MaxCorruption is the largest relative change of corrupted numbers,
+    // default 0.5
+    MaxCorruption float64 `json:"maxCorruption,omitempty"`
+    Seed          int64   `json:"seed,omitempty"` // 0 seeds from the clock
+}
+
+// LoadChaosConfig loads chaos/chaos.json from the config directory. A
+// missing file leaves chaos mode disabled.
+func LoadChaosConfig(configDir string) (*ChaosConfig, error) {
+    data, err := os.ReadFile(filepath.Join(configDir, "chaos", "chaos.json"))
+    if os.IsNotExist(err) {
+        return &ChaosConfig{}, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read chaos config: %v", err)
+    }
+
+    var config ChaosConfig
+    if err := json.Unmarshal(data, &config); err != nil {
+        return nil, fmt.Errorf("failed to parse chaos config: %v", err)
+    }
+    if err := config.Validate(); err != nil {
+        return nil, err
+    }
+    return &config, nil
+}
+
+// Validate checks the fraction and fault weights
+func (c *ChaosConfig) Validate() error {
+    if c.Fraction < 0 || c.Fraction > 1 {
+        return fmt.Errorf("chaos fraction must be between 0 and 1")
+    }
+    for kind, weight := range c.Faults {
+        switch kind {
+        case FaultDelay, FaultError, FaultCorrupt:
+        default:
+            return fmt.Errorf("unknown chaos fault %q", kind)
+        }
+        if weight < 0 {
+            return fmt.Errorf("chaos fault %s has a negative weight", kind)
+        }
+    }
+    if c.MaxDelayMs < 0 || c.MaxCorruption < 0 {
+        return fmt.Errorf("chaos maxDelayMs and maxCorruption must not be negative")
+    }
+    return nil
+}
+
+// ChaosError is returned for requests failed by an injected fault
+type ChaosError struct {
+    Host string
+}
+
+func (e *ChaosError) Error() string {
+    return fmt.Sprintf("chaos: injected failure of request to %s", e.Host)
+}
+
+// chaosInjector applies a chaos configuration
+type chaosInjector struct {
+    config ChaosConfig
+    hosts  map[string]bool
+
+    mu       sync.Mutex
+    rng      *rand.Rand
+    injected map[string]uint64 // by fault kind
+}
+
+var (
+    chaosMu sync.RWMutex
+    chaos   *chaosInjector
+)
+
+// EnableChaos starts injecting faults as configured; a disabled config
+// turns chaos mode off
+func EnableChaos(config *ChaosConfig) {
+    chaosMu.Lock()
+    defer chaosMu.Unlock()
+    if config == nil || !config.Enabled || config.Fraction == 0 {
+        chaos = nil
+        return
+    }
+
+    seed := config.Seed
+    if seed == 0 {
+        seed = time.Now().UnixNano()
+    }
+    injector := &chaosInjector{
+        config:   *config,
+        rng:      rand.New(rand.NewSource(seed)),
+        injected: make(map[string]uint64),
+    }
+    if len(config.Hosts) > 0 {
+        injector.hosts = make(map[string]bool, len(config.Hosts))
+        for _, host := range config.Hosts {
+            injector.hosts[host] = true
+        }
+    }
+    chaos = injector
+    log.Printf("WARNING: chaos mode enabled, faults injected into %.0f%% of source requests", config.Fraction*100)
+}
+
+// ChaosEnabled reports whether faults are being injected
+func ChaosEnabled() bool {
+    chaosMu.RLock()
+    defer chaosMu.RUnlock()
+    return chaos != nil
+}
+
+// currentChaos returns the active injector, nil when chaos mode is off
+func currentChaos() *chaosInjector {
+    chaosMu.RLock()
+    defer chaosMu.RUnlock()
+    return chaos
+}
+
+// targets reports whether requests to host get faults
+func (c *chaosInjector) targets(host string) bool {
+    if c.hosts != nil {
+        return c.hosts[host]
+    }
+    _, ok := HostSources()[host]
+    return ok
+}
+
+// pick returns the fault to inject into a request to host, if any
+func (c *chaosInjector) pick(host string) string {
+    if !c.targets(host) {
+        return ""
+    }
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if c.rng.Float64() >= c.config.Fraction {
+        return ""
+    }
+
+    kinds := []string{FaultDelay, FaultError, FaultCorrupt}
+    weights := make([]float64, len(kinds))
+    total := 0.0
+    for i, kind := range kinds {
+        weights[i] = 1
+        if len(c.config.Faults) > 0 {
+            weights[i] = c.config.Faults[kind]
+        }
+        total += weights[i]
+    }
+    if total == 0 {
+        return ""
+    }
+    r := c.rng.Float64() * total
+    for i, kind := range kinds {
+        if r < weights[i] {
+            c.injected[kind]++
+            return kind
+        }
+        r -= weights[i]
+    }
+    c.injected[kinds[len(kinds)-1]]++
+    return kinds[len(kinds)-1]
+}
+
+// random returns a uniform value in [0, 1)
+func (c *chaosInjector) random() float64 {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    return c.rng.Float64()
+}
+
+// roundTrip sends req with send, injecting a fault into it if picked
+func (c *chaosInjector) roundTrip(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+    switch c.pick(req.URL.Host) {
+    case FaultDelay:
+        maxDelay := c.config.MaxDelayMs
+        if maxDelay == 0 {
+            maxDelay = 3000
+        }
+        delay := time.Duration(c.random() * float64(maxDelay) * float64(time.Millisecond))
+        select {
+        case <-time.After(delay):
+        case <-req.Context().Done():
+            return nil, req.Context().Err()
+        }
+    case FaultError:
+        return nil, &ChaosError{Host: req.URL.Host}
+    case FaultCorrupt:
+        resp, err := send(req)
+        if err != nil {
+            return nil, err
+        }
+        return c.corrupt(resp)
+    }
+    return send(req)
+}
+
+// decimalPattern matches decimal numbers, quoted or not
+var decimalPattern = regexp.MustCompile(`\d+\.\d+`)
+
+// corrupt scales every decimal number in the body by the same random
+// factor, turning the source into an outlier while keeping it parseable
+func (c *chaosInjector) corrupt(resp *http.Response) (*http.Response, error) {
+    body, err := io.ReadAll(io.LimitReader(resp.Body, MaxBodyBytes))
+    resp.Body.Close()
+    if err != nil {
+        return nil, err
+    }
+
+    maxCorruption := c.config.MaxCorruption
+    if maxCorruption == 0 {
+        maxCorruption = 0.5
+    }
+    factor := 1 + (2*c.random()-1)*maxCorruption
+    body = decimalPattern.ReplaceAllFunc(body, func(number []byte) []byte {
+        v, err := strconv.ParseFloat(string(number), 64)
+        if err != nil {
+            return number
+        }
+        return []byte(strconv.FormatFloat(v*factor, 'f', -1, 64))
+    })
+
+    resp.Body = io.NopCloser(bytes.NewReader(body))
+    resp.ContentLength = int64(len(body))
+    resp.Header.Del("Content-Length")
+    return resp, nil
+}
+
+// chaosStats returns the number of injected faults by kind
+func chaosStats() map[string]uint64 {
+    c := currentChaos()
+    if c == nil {
+        return nil
+    }
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    out := make(map[string]uint64, len(c.injected))
+    for kind, n := range c.injected {
+        out[kind] = n
+    }
+    return out
+}
@@ -0,0 +1,117 @@
+package fetch
+
+import (
+    "compress/gzip"
+    "errors"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+)
+
+func TestChaosFaults(t *testing.T) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "application/json")
+        w.Write([]byte(`{"price": "100.00", "count": 3}`))
+    }))
+    defer srv.Close()
+    host := mustHost(t, srv.URL)
+    defer EnableChaos(nil)
+    client := NewClient(time.Second)
+
+    EnableChaos(&ChaosConfig{Enabled: true, Fraction: 1, Hosts: []string{host}, Faults: map[string]float64{FaultError: 1}, Seed: 1})
+    _, err := client.Get(srv.URL)
+    var chaosErr *ChaosError
+    if !errors.As(err, &chaosErr) {
+        t.Errorf("Expected an injected error, got %v", err)
+    }
+
+    // Without hosts, faults go to the configured sources only
+    other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+    defer other.Close()
+    Configure(&common.BaseConfig{Exchanges: common.ExchangeConfig{CEX: map[string]common.CEXDetails{
+        "test": {BaseURL: srv.URL},
+    }}}, "")
+    defer Configure(&common.BaseConfig{}, "")
+    EnableChaos(&ChaosConfig{Enabled: true, Fraction: 1, Faults: map[string]float64{FaultCorrupt: 1}, MaxCorruption: 0.2, Seed: 1})
+    if resp, err := client.Get(other.URL); err != nil {
+        t.Errorf("Expected a request to a host other than a source unaffected, got %v", err)
+    } else {
+        resp.Body.Close()
+    }
+    if Stats().Chaos[FaultCorrupt] != 0 {
+        t.Errorf("Expected no fault outside the sources, got %+v", Stats().Chaos)
+    }
+    resp, err := client.Get(srv.URL)
+    if err != nil {
+        t.Fatalf("Request failed: %v", err)
+    }
+    var body struct {
+        Price float64 `json:"price,string"`
+        Count int     `json:"count"`
+    }
+    if err := DecodeJSON(resp, &body); err != nil {
+        t.Fatalf("Expected a parseable corrupted body, got %v", err)
+    }
+    resp.Body.Close()
+    if body.Price == 100 || body.Price < 80 || body.Price > 120 || body.Count != 3 {
+        t.Errorf("Expected the price scaled by at most 20%% and integers kept, got %+v", body)
+    }
+    if Stats().Chaos[FaultCorrupt] != 1 {
+        t.Errorf("Expected one corruption counted, got %+v", Stats().Chaos)
+    }
+
+    // Hosts outside the list are never affected
+    EnableChaos(&ChaosConfig{Enabled: true, Fraction: 1, Hosts: []string{"example.invalid"}, Faults: map[string]float64{FaultError: 1}})
+    resp, err = client.Get(srv.URL)
+    if err != nil {
+        t.Fatalf("Expected an unaffected request, got %v", err)
+    }
+    io.Copy(io.Discard, resp.Body)
+    resp.Body.Close()
+
+    EnableChaos(&ChaosConfig{})
+    if ChaosEnabled() || Stats().Chaos != nil {
+        t.Error("Expected chaos mode disabled")
+    }
+}
+
+func TestChaosCorruptsCompressedResponses(t *testing.T) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "application/json")
+        w.Header().Set("Content-Encoding", "gzip")
+        gz := gzip.NewWriter(w)
+        gz.Write([]byte(`{"price": "100.00"}`))
+        gz.Close()
+    }))
+    defer srv.Close()
+    defer EnableChaos(nil)
+
+    EnableChaos(&ChaosConfig{Enabled: true, Fraction: 1, Hosts: []string{mustHost(t, srv.URL)}, Faults: map[string]float64{FaultCorrupt: 1}, MaxCorruption: 0.2, Seed: 1})
+    resp, err := NewClient(time.Second).Get(srv.URL)
+    if err != nil {
+        t.Fatalf("Request failed: %v", err)
+    }
+    defer resp.Body.Close()
+    var body struct {
+        Price float64 `json:"price,string"`
+    }
+    if err := DecodeJSON(resp, &body); err != nil {
+        t.Fatalf("Expected the decoded body corrupted, got %v", err)
+    }
+    if body.Price == 100 || body.Price < 80 || body.Price > 120 {
+        t.Errorf("Expected the price scaled by at most 20%%, got %v", body.Price)
+    }
+}
+
+func TestChaosConfigValidate(t *testing.T) {
+    if err := (&ChaosConfig{Fraction: 1.5}).Validate(); err == nil {
+        t.Error("Expected an error for a fraction above 1")
+    }
+    if err := (&ChaosConfig{Fraction: 0.1, Faults: map[string]float64{"explode": 1}}).Validate(); err == nil {
+        t.Error("Expected an error for an unknown fault")
+    }
+}
@@ -0,0 +1,124 @@
+package fetch
+
+import (
+    "bufio"
+    "compress/flate"
+    "compress/gzip"
+    "compress/zlib"
+    "io"
+    "net/http"
+    "strings"
+)
+
+// acceptEncoding is offered to upstreams on requests that do not choose
+// their own encodings. Setting it disables the transport's transparent gzip
+// handling, so responses are decoded by decompress instead.
+const acceptEncoding = "gzip, deflate"
+
+// negotiate returns req offering the supported encodings, or req itself
+// when the caller already set Accept-Encoding
+func negotiate(req *http.Request) *http.Request {
+    if req.Header.Get("Accept-Encoding") != "" {
+        return req
+    }
+    out := req.Clone(req.Context())
+    out.Header.Set("Accept-Encoding", acceptEncoding)
+    return out
+}
+
+// decompress replaces the body of a compressed response with its decoded
+// stream and counts the bytes read off the wire and after decoding. It
+// returns the response's content coding, "identity" when uncompressed.
+func decompress(host string, resp *http.Response) string {
+    wire := &countingReader{r: resp.Body}
+    encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+
+    var decoded io.Reader = wire
+    switch encoding {
+    case "", "identity":
+        encoding = "identity"
+    case "gzip", "x-gzip":
+        encoding = "gzip"
+        decoded = &lazyReader{open: func() (io.Reader, error) { return gzip.NewReader(wire) }}
+    case "deflate":
+        decoded = &lazyReader{open: func() (io.Reader, error) { return newDeflateReader(wire) }}
+    default:
+        // An encoding we did not offer: leave the body to the caller
+        return encoding
+    }
+    if encoding != "identity" {
+        resp.Header.Del("Content-Encoding")
+        resp.Header.Del("Content-Length")
+        resp.ContentLength = -1
+        resp.Uncompressed = true
+    }
+    resp.Body = &decodedBody{
+        countingReader: &countingReader{r: decoded},
+        wire:           wire,
+        closer:         resp.Body,
+        host:           host,
+    }
+    return encoding
+}
+
+// newDeflateReader decodes a deflate body. The coding is zlib-wrapped per
+// RFC 9110, but some servers send raw deflate streams instead.
+func newDeflateReader(r io.Reader) (io.Reader, error) {
+    buffered := bufio.NewReader(r)
+    header, err := buffered.Peek(2)
+    if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+        return zlib.NewReader(buffered)
+    }
+    return flate.NewReader(buffered), nil
+}
+
+// lazyReader opens its decoder on the first read, so that an empty body
+// of a HEAD or 204 response is not an error
+type lazyReader struct {
+    open func() (io.Reader, error)
+    r    io.Reader
+    err  error
+}
+
+func (l *lazyReader) Read(p []byte) (int, error) {
+    if l.r == nil && l.err == nil {
+        l.r, l.err = l.open()
+    }
+    if l.err != nil {
+        return 0, l.err
+    }
+    return l.r.Read(p)
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+    r io.Reader
+    n uint64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+    n, err := c.r.Read(p)
+    c.n += uint64(n)
+    return n, err
+}
+
+// decodedBody is a response body read through its decoder, recording the
+// wire and decoded byte counts of the host when closed
+type decodedBody struct {
+    *countingReader
+    wire   *countingReader
+    closer io.Closer
+    host   string
+    closed bool
+}
+
+func (b *decodedBody) Close() error {
+    if !b.closed {
+        b.closed = true
+        record(b.host, func(s *HostStats) {
+            s.WireBytes += b.wire.n
+            s.DecodedBytes += b.countingReader.n
+        })
+    }
+    return b.closer.Close()
+}
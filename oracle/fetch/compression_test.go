@@ -0,0 +1,84 @@
+package fetch
+
+import (
+    "bytes"
+    "compress/flate"
+    "compress/gzip"
+    "compress/zlib"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+)
+
+func TestTransportNegotiatesCompression(t *testing.T) {
+    payload := `{"result":"` + strings.Repeat("XETHZUSD", 512) + `"}`
+    encode := map[string]func(w io.Writer) io.WriteCloser{
+        "/gzip":    func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+        "/zlib":    func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+        "/deflate": func(w io.Writer) io.WriteCloser { fw, _ := flate.NewWriter(w, flate.DefaultCompression); return fw },
+    }
+    srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.Header.Get("Accept-Encoding") != acceptEncoding {
+            t.Errorf("Expected Accept-Encoding %q, got %q", acceptEncoding, r.Header.Get("Accept-Encoding"))
+        }
+        w.Header().Set("Content-Type", "application/json")
+        newWriter, ok := encode[r.URL.Path]
+        if !ok {
+            w.Write([]byte(payload))
+            return
+        }
+        var buf bytes.Buffer
+        enc := newWriter(&buf)
+        enc.Write([]byte(payload))
+        enc.Close()
+        if r.URL.Path == "/gzip" {
+            w.Header().Set("Content-Encoding", "gzip")
+        } else {
+            w.Header().Set("Content-Encoding", "deflate")
+        }
+        w.Write(buf.Bytes())
+    }))
+    srv.EnableHTTP2 = true
+    srv.StartTLS()
+    defer srv.Close()
+    host := mustHost(t, srv.URL)
+
+    client := &http.Client{Transport: &instrumentedTransport{base: srv.Client().Transport}}
+    for _, path := range []string{"/gzip", "/zlib", "/deflate", "/plain"} {
+        resp, err := client.Get(srv.URL + path)
+        if err != nil {
+            t.Fatalf("%s: request failed: %v", path, err)
+        }
+        var out struct {
+            Result string `json:"result"`
+        }
+        if err := DecodeJSON(resp, &out); err != nil {
+            t.Errorf("%s: failed to decode: %v", path, err)
+        }
+        resp.Body.Close()
+        if len(out.Result) != 8*512 {
+            t.Errorf("%s: expected the decoded payload, got %d bytes", path, len(out.Result))
+        }
+        if resp.Header.Get("Content-Encoding") != "" {
+            t.Errorf("%s: expected Content-Encoding to be removed once decoded", path)
+        }
+    }
+
+    var got HostStats
+    for _, s := range Stats().Hosts {
+        if s.Host == host {
+            got = s
+        }
+    }
+    if got.Requests != 4 || got.HTTP2 != 4 || got.HTTP1 != 0 {
+        t.Errorf("Expected 4 HTTP/2 requests, got %+v", got)
+    }
+    if got.Gzip != 1 || got.Deflate != 2 || got.Uncompressed != 1 {
+        t.Errorf("Expected 1 gzip, 2 deflate and 1 uncompressed response, got %+v", got)
+    }
+    if got.DecodedBytes != 4*uint64(len(payload)) || got.WireBytes >= got.DecodedBytes/2 {
+        t.Errorf("Expected compressed transfers to be much smaller than decoded, got %d wire and %d decoded bytes", got.WireBytes, got.DecodedBytes)
+    }
+}
@@ -0,0 +1,179 @@
+package fetch
+
+import (
+    "log"
+    "net/http"
+    "net/url"
+    "os"
+    "sort"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/credentials"
+)
+
+// InstanceHeader carries the instance ID of the oracle node sending a request
+const InstanceHeader = "X-Oracle-Instance"
+
+// identity is the set of headers added to outgoing requests
+type identity struct {
+    headers map[string]string            // applied to every request
+    hosts   map[string]map[string]string // per-source overrides by host
+    sources map[string][]string          // configured source names by host
+    // cache is how long responses are shared, by host and by default
+    cache        map[string]time.Duration
+    defaultCache time.Duration
+    // transports present client certificates, by host
+    transports map[string]*http.Transport
+}
+
+var (
+    identityMu sync.RWMutex
+    current    = identity{}
+)
+
+// Configure sets the headers identifying the oracle's traffic: the global
+// User-Agent and headers of the base config, per-source overrides matched
+// by the host of each source's URL, and the instance ID header. Sources
+// requiring mutual TLS get a transport presenting their client certificate;
+// a certificate that fails to load keeps the host's previous one, if any.
+func Configure(base *common.BaseConfig, instanceID string) {
+    next := identity{
+        headers: merge(nil, base.HTTP),
+        hosts:   make(map[string]map[string]string),
+        sources: make(map[string][]string),
+        cache:   make(map[string]time.Duration),
+        // The global block sets the default of every host
+        defaultCache: time.Duration(base.HTTP.CacheMs) * time.Millisecond,
+        transports:   make(map[string]*http.Transport),
+    }
+    identityMu.RLock()
+    previous := current.transports
+    identityMu.RUnlock()
+    if instanceID != "" {
+        next.headers[InstanceHeader] = instanceID
+    }
+
+    addHost := func(name, rawURL string, source common.HTTPIdentity) {
+        u, err := url.Parse(credentials.Expand(rawURL))
+        if err != nil || u.Host == "" {
+            return
+        }
+        if !contains(next.sources[u.Host], name) {
+            next.sources[u.Host] = append(next.sources[u.Host], name)
+        }
+        if source.CacheMs > 0 {
+            next.cache[u.Host] = time.Duration(source.CacheMs) * time.Millisecond
+        }
+        if source.TLS != nil && next.transports[u.Host] == nil {
+            t, err := clientTransport(source.TLS)
+            if err != nil {
+                log.Printf("Client certificate of %s: %v", name, err)
+                t = previous[u.Host]
+            }
+            if t != nil {
+                next.transports[u.Host] = t
+            }
+        }
+        if source.UserAgent == "" && len(source.Headers) == 0 {
+            return
+        }
+        next.hosts[u.Host] = merge(next.hosts[u.Host], source)
+    }
+    for name, cex := range base.Exchanges.CEX {
+        addHost(name, cex.BaseURL, cex.HTTP)
+        addHost(name, cex.StatusPage, cex.HTTP)
+    }
+    for name, dex := range base.Exchanges.DEX {
+        addHost(name, dex.Endpoint, dex.HTTP)
+    }
+    for _, names := range next.sources {
+        sort.Strings(names)
+    }
+
+    identityMu.Lock()
+    current = next
+    identityMu.Unlock()
+
+    // Replaced transports finish their requests in flight
+    for host, t := range previous {
+        if next.transports[host] != t {
+            t.CloseIdleConnections()
+        }
+    }
+}
+
+// cacheTTL returns how long responses of host are shared
+func cacheTTL(host string) time.Duration {
+    identityMu.RLock()
+    defer identityMu.RUnlock()
+    if ttl, ok := current.cache[host]; ok {
+        return ttl
+    }
+    return current.defaultCache
+}
+
+// HostSources returns the configured source names of each upstream host
+func HostSources() map[string][]string {
+    identityMu.RLock()
+    defer identityMu.RUnlock()
+    return current.sources
+}
+
+func contains(values []string, value string) bool {
+    for _, v := range values {
+        if v == value {
+            return true
+        }
+    }
+    return false
+}
+
+// InstanceID returns ORACLE_INSTANCE_ID, falling back to the host name
+func InstanceID() string {
+    if id := os.Getenv("ORACLE_INSTANCE_ID"); id != "" {
+        return id
+    }
+    host, _ := os.Hostname()
+    return host
+}
+
+// merge adds the User-Agent and headers of an identity to headers
+func merge(headers map[string]string, id common.HTTPIdentity) map[string]string {
+    if headers == nil {
+        headers = make(map[string]string)
+    }
+    for k, v := range id.Headers {
+        // Values may reference credentials such as ${SUBGRAPH_TOKEN}
+        headers[http.CanonicalHeaderKey(k)] = credentials.Expand(v)
+    }
+    if id.UserAgent != "" {
+        headers["User-Agent"] = id.UserAgent
+    }
+    return headers
+}
+
+// identify returns req with the configured identity headers added. Headers
+// already set on the request take precedence; req itself is not modified.
+func identify(req *http.Request) *http.Request {
+    identityMu.RLock()
+    id := current
+    identityMu.RUnlock()
+    if len(id.headers) == 0 && len(id.hosts[req.URL.Host]) == 0 {
+        return req
+    }
+
+    out := req.Clone(req.Context())
+    apply := func(headers map[string]string) {
+        for k, v := range headers {
+            if out.Header.Get(k) == "" {
+                out.Header.Set(k, v)
+            }
+        }
+    }
+    // Per-source headers override the global ones
+    apply(id.hosts[req.URL.Host])
+    apply(id.headers)
+    return out
+}
@@ -0,0 +1,50 @@
+package fetch
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+)
+
+func TestIdentityHeaders(t *testing.T) {
+    received := make(chan http.Header, 1)
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        received <- r.Header.Clone()
+    }))
+    defer srv.Close()
+    u, _ := url.Parse(srv.URL)
+
+    Configure(&common.BaseConfig{
+        HTTP: common.HTTPIdentity{UserAgent: "oracle/1.0", Headers: map[string]string{"x-contact": "ops@example.com"}},
+        Exchanges: common.ExchangeConfig{CEX: map[string]common.CEXDetails{
+            "local": {BaseURL: "http://" + u.Host + "/api", HTTP: common.HTTPIdentity{UserAgent: "oracle-local/1.0"}},
+        }},
+    }, "node-1")
+    defer Configure(&common.BaseConfig{}, "")
+
+    req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+    req.Header.Set("X-Contact", "override@example.com")
+    resp, err := NewClient(time.Second).Do(req)
+    if err != nil {
+        t.Fatalf("Request failed: %v", err)
+    }
+    resp.Body.Close()
+
+    h := <-received
+    if got := h.Get("User-Agent"); got != "oracle-local/1.0" {
+        t.Errorf("Expected per-source User-Agent, got %q", got)
+    }
+    if got := h.Get("X-Contact"); got != "override@example.com" {
+        t.Errorf("Expected request header to take precedence, got %q", got)
+    }
+    if got := h.Get(InstanceHeader); got != "node-1" {
+        t.Errorf("Expected instance ID header, got %q", got)
+    }
+    if req.Header.Get(InstanceHeader) != "" {
+        t.Error("Expected the caller's request to be left unmodified")
+    }
+}
@@ -0,0 +1,228 @@
+package fetch
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+)
+
+// schemaConfirmations is how many consecutive responses must share a new
+// shape before it replaces the recorded one, so that a one-off error
+// envelope does not raise an alert
+const schemaConfirmations = 3
+
+// SchemaChange describes a change in the shape of a source's responses
+type SchemaChange struct {
+    Source      string    `json:"source"`
+    Endpoint    string    `json:"endpoint"`
+    At          time.Time `json:"at"`
+    Previous    string    `json:"previous"`
+    Fingerprint string    `json:"fingerprint"`
+    Added       []string  `json:"added"`
+    Removed     []string  `json:"removed"`
+}
+
+// SchemaStatus is the recorded response shape of one source endpoint
+type SchemaStatus struct {
+    Source      string `json:"source"`
+    Endpoint    string `json:"endpoint"`
+    Fingerprint string `json:"fingerprint"`
+    // Fields are the field paths of the responses, array elements as []
+    Fields     []string      `json:"fields"`
+    FirstSeen  time.Time     `json:"firstSeen"`
+    LastSeen   time.Time     `json:"lastSeen"`
+    Responses  uint64        `json:"responses"`
+    Changes    uint64        `json:"changes"`
+    LastChange *SchemaChange `json:"lastChange,omitempty"`
+}
+
+// schema tracks one endpoint, along with a differing shape awaiting
+// confirmation
+type schema struct {
+    status    SchemaStatus
+    candidate string
+    seen      int
+}
+
+// schemaLabel marks a request whose response shape is tracked
+type schemaLabel struct {
+    source   string
+    endpoint string
+    // dynamic are the paths of objects keyed by data, such as pair names,
+    // whose keys are not part of the shape
+    dynamic map[string]bool
+}
+
+type schemaKey struct{}
+
+var (
+    schemasMu sync.Mutex
+    schemas   = make(map[string]*schema)
+    onSchema  func(SchemaChange)
+)
+
+// WithSchema labels requests made with ctx so that DecodeJSON records the
+// shape of their responses under source and endpoint. dynamic lists the
+// paths of objects keyed by data, e.g. "result" when a response is keyed by
+// pair name.
+func WithSchema(ctx context.Context, source, endpoint string, dynamic ...string) context.Context {
+    label := &schemaLabel{source: source, endpoint: endpoint, dynamic: make(map[string]bool, len(dynamic))}
+    for _, path := range dynamic {
+        label.dynamic[path] = true
+    }
+    return context.WithValue(ctx, schemaKey{}, label)
+}
+
+// OnSchemaChange registers fn to be called when the shape of a source's
+// responses changes
+func OnSchemaChange(fn func(SchemaChange)) {
+    schemasMu.Lock()
+    defer schemasMu.Unlock()
+    onSchema = fn
+}
+
+// Schemas returns the recorded response shapes, sorted by source and
+// endpoint
+func Schemas() []SchemaStatus {
+    schemasMu.Lock()
+    defer schemasMu.Unlock()
+    out := make([]SchemaStatus, 0, len(schemas))
+    for _, s := range schemas {
+        out = append(out, s.status)
+    }
+    sort.Slice(out, func(i, j int) bool {
+        if out[i].Source != out[j].Source {
+            return out[i].Source < out[j].Source
+        }
+        return out[i].Endpoint < out[j].Endpoint
+    })
+    return out
+}
+
+// observeSchema records the shape of a labelled response body
+func observeSchema(ctx context.Context, body []byte, now time.Time) {
+    label, ok := ctx.Value(schemaKey{}).(*schemaLabel)
+    if !ok {
+        return
+    }
+    var value interface{}
+    if err := json.Unmarshal(body, &value); err != nil {
+        return
+    }
+    fields := make(map[string]bool)
+    shape(value, "", label.dynamic, fields)
+    paths := make([]string, 0, len(fields))
+    for path := range fields {
+        paths = append(paths, path)
+    }
+    sort.Strings(paths)
+    fingerprint := fingerprintOf(paths)
+
+    schemasMu.Lock()
+    key := label.source + " " + label.endpoint
+    s, ok := schemas[key]
+    if !ok {
+        schemas[key] = &schema{status: SchemaStatus{
+            Source:      label.source,
+            Endpoint:    label.endpoint,
+            Fingerprint: fingerprint,
+            Fields:      paths,
+            FirstSeen:   now,
+            LastSeen:    now,
+            Responses:   1,
+        }}
+        schemasMu.Unlock()
+        return
+    }
+    s.status.Responses++
+    s.status.LastSeen = now
+    change := s.observe(fingerprint, paths, now)
+    notify := onSchema
+    schemasMu.Unlock()
+
+    if change != nil && notify != nil {
+        notify(*change)
+    }
+}
+
+// observe compares a response's shape to the recorded one and adopts it
+// once confirmed, returning the change; callers hold schemasMu
+func (s *schema) observe(fingerprint string, paths []string, now time.Time) *SchemaChange {
+    if fingerprint == s.status.Fingerprint {
+        s.candidate, s.seen = "", 0
+        return nil
+    }
+    if fingerprint != s.candidate {
+        s.candidate, s.seen = fingerprint, 0
+    }
+    s.seen++
+    if s.seen < schemaConfirmations {
+        return nil
+    }
+
+    change := &SchemaChange{
+        Source:      s.status.Source,
+        Endpoint:    s.status.Endpoint,
+        At:          now,
+        Previous:    s.status.Fingerprint,
+        Fingerprint: fingerprint,
+        Added:       difference(paths, s.status.Fields),
+        Removed:     difference(s.status.Fields, paths),
+    }
+    s.status.Fingerprint = fingerprint
+    s.status.Fields = paths
+    s.status.Changes++
+    s.status.LastChange = change
+    s.candidate, s.seen = "", 0
+    return change
+}
+
+// shape adds the field paths of a decoded JSON value to fields. Object
+// fields are joined with dots, array elements share the path []; the keys
+// of dynamic objects are replaced by *.
+func shape(value interface{}, path string, dynamic map[string]bool, fields map[string]bool) {
+    switch v := value.(type) {
+    case map[string]interface{}:
+        for key, child := range v {
+            if dynamic[path] {
+                key = "*"
+            }
+            childPath := key
+            if path != "" {
+                childPath = path + "." + key
+            }
+            fields[childPath] = true
+            shape(child, childPath, dynamic, fields)
+        }
+    case []interface{}:
+        for _, child := range v {
+            shape(child, path+"[]", dynamic, fields)
+        }
+    }
+}
+
+// fingerprintOf hashes sorted field paths
+func fingerprintOf(paths []string) string {
+    sum := sha256.Sum256([]byte(strings.Join(paths, "\n")))
+    return hex.EncodeToString(sum[:8])
+}
+
+// difference returns the sorted paths of a missing from b
+func difference(a, b []string) []string {
+    in := make(map[string]bool, len(b))
+    for _, path := range b {
+        in[path] = true
+    }
+    out := make([]string, 0)
+    for _, path := range a {
+        if !in[path] {
+            out = append(out, path)
+        }
+    }
+    return out
+}
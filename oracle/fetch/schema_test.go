@@ -0,0 +1,65 @@
+package fetch
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+)
+
+func TestSchemaChangeDetection(t *testing.T) {
+    body := `{"error":[],"result":{"XETHZUSD":{"c":["100","1"],"v":["5","6"]}}}`
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte(body))
+    }))
+    defer srv.Close()
+
+    var changes []SchemaChange
+    OnSchemaChange(func(c SchemaChange) { changes = append(changes, c) })
+    defer OnSchemaChange(nil)
+    fetchOnce := func(pair string) {
+        ctx := WithSchema(context.Background(), "kraken", "test-ticker", "result")
+        req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"?pair="+pair, nil)
+        resp, err := http.DefaultClient.Do(req)
+        if err != nil {
+            t.Fatal(err)
+        }
+        defer resp.Body.Close()
+        var out interface{}
+        if err := DecodeJSON(resp, &out); err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    fetchOnce("ETHUSD")
+    // Other pairs key the result differently without changing its shape
+    body = `{"error":[],"result":{"XXBTZUSD":{"c":["100","1"],"v":["5","6"]}}}`
+    fetchOnce("BTCUSD")
+    // A one-off different body is not adopted
+    body = `{"error":["EGeneral:Temporary lockout"]}`
+    fetchOnce("BTCUSD")
+    body = `{"error":[],"result":{"XXBTZUSD":{"c":["100","1"],"v":["5","6"]}}}`
+    fetchOnce("BTCUSD")
+    if len(changes) != 0 {
+        t.Fatalf("Expected no change, got %+v", changes)
+    }
+
+    body = `{"error":[],"result":{"XXBTZUSD":{"last":"100","v":["5","6"]}}}`
+    for i := 0; i < schemaConfirmations; i++ {
+        fetchOnce("BTCUSD")
+    }
+    if len(changes) != 1 {
+        t.Fatalf("Expected one change, got %+v", changes)
+    }
+    c := changes[0]
+    if len(c.Added) != 1 || c.Added[0] != "result.*.last" || len(c.Removed) != 1 || c.Removed[0] != "result.*.c" {
+        t.Errorf("Expected c replaced by last, got %+v", c)
+    }
+
+    for _, s := range Schemas() {
+        if s.Endpoint == "test-ticker" && (s.Responses != 7 || s.Changes != 1 || s.LastChange == nil || s.LastSeen.After(time.Now())) {
+            t.Errorf("Unexpected status %+v", s)
+        }
+    }
+}
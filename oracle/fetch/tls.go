@@ -0,0 +1,73 @@
+package fetch
+
+import (
+    "crypto/tls"
+    "crypto/x509"
+    "fmt"
+    "net/http"
+    "os"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/credentials"
+)
+
+// ClientTLSConfig loads the client certificate, and the private CA if any,
+// of a source requiring mutual TLS
+func ClientTLSConfig(c *common.ClientTLS) (*tls.Config, error) {
+    cert, err := readPEM(c.CertFile, c.CertEnv)
+    if err != nil {
+        return nil, fmt.Errorf("certificate: %v", err)
+    }
+    key, err := readPEM(c.KeyFile, c.KeyEnv)
+    if err != nil {
+        return nil, fmt.Errorf("key: %v", err)
+    }
+    pair, err := tls.X509KeyPair(cert, key)
+    if err != nil {
+        return nil, err
+    }
+    config := &tls.Config{Certificates: []tls.Certificate{pair}}
+    if c.CAFile != "" {
+        ca, err := os.ReadFile(c.CAFile)
+        if err != nil {
+            return nil, fmt.Errorf("CA: %v", err)
+        }
+        config.RootCAs = x509.NewCertPool()
+        if !config.RootCAs.AppendCertsFromPEM(ca) {
+            return nil, fmt.Errorf("CA: no certificates in %s", c.CAFile)
+        }
+    }
+    return config, nil
+}
+
+// readPEM reads a file, or the credential named env when file is empty
+func readPEM(file, env string) ([]byte, error) {
+    if file != "" {
+        return os.ReadFile(file)
+    }
+    value := credentials.Get(env)
+    if value == "" {
+        return nil, fmt.Errorf("credential %s is not set", env)
+    }
+    return []byte(value), nil
+}
+
+// clientTransport returns a transport like the shared one presenting the
+// client certificate of c
+func clientTransport(c *common.ClientTLS) (*http.Transport, error) {
+    config, err := ClientTLSConfig(c)
+    if err != nil {
+        return nil, err
+    }
+    t := sharedTransport.Clone()
+    t.TLSClientConfig = config
+    return t, nil
+}
+
+// hostTransport returns the transport of a host whose source requires a
+// client certificate, or nil for hosts using the shared transport
+func hostTransport(host string) *http.Transport {
+    identityMu.RLock()
+    defer identityMu.RUnlock()
+    return current.transports[host]
+}
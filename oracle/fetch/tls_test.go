@@ -0,0 +1,99 @@
+package fetch
+
+import (
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rand"
+    "crypto/tls"
+    "crypto/x509"
+    "crypto/x509/pkix"
+    "encoding/pem"
+    "math/big"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+)
+
+// clientCert returns a self-signed client certificate and key as PEM
+func clientCert(t *testing.T) (certPEM, keyPEM []byte) {
+    key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil {
+        t.Fatal(err)
+    }
+    template := &x509.Certificate{
+        SerialNumber: big.NewInt(1),
+        Subject:      pkix.Name{CommonName: "oracle"},
+        NotBefore:    time.Now().Add(-time.Hour),
+        NotAfter:     time.Now().Add(time.Hour),
+        ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+    }
+    der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+    if err != nil {
+        t.Fatal(err)
+    }
+    keyDER, err := x509.MarshalECPrivateKey(key)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+        pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestClientCertificates(t *testing.T) {
+    certPEM, keyPEM := clientCert(t)
+    clients := x509.NewCertPool()
+    clients.AppendCertsFromPEM(certPEM)
+
+    srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+    }))
+    srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clients}
+    srv.StartTLS()
+    defer srv.Close()
+
+    // The server's certificate stands in for a venue's private CA
+    dir := t.TempDir()
+    caFile := filepath.Join(dir, "ca.pem")
+    os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0600)
+    keyFile := filepath.Join(dir, "key.pem")
+    os.WriteFile(keyFile, keyPEM, 0600)
+    t.Setenv("VENUE_TLS_CERT", string(certPEM))
+
+    configure := func(c *common.ClientTLS) {
+        Configure(&common.BaseConfig{Exchanges: common.ExchangeConfig{CEX: map[string]common.CEXDetails{
+            "venue": {BaseURL: srv.URL + "/api", HTTP: common.HTTPIdentity{TLS: c}},
+        }}}, "")
+    }
+    get := func() (string, error) {
+        resp, err := NewClient(time.Second).Get(srv.URL)
+        if err != nil {
+            return "", err
+        }
+        defer resp.Body.Close()
+        buf := make([]byte, 64)
+        n, _ := resp.Body.Read(buf)
+        return string(buf[:n]), nil
+    }
+    defer Configure(&common.BaseConfig{}, "")
+
+    configure(&common.ClientTLS{CertEnv: "VENUE_TLS_CERT", KeyFile: keyFile, CAFile: caFile})
+    if name, err := get(); err != nil || name != "oracle" {
+        t.Fatalf("Expected the client certificate to be presented, got %q, %v", name, err)
+    }
+
+    // A rotation to a certificate that fails to load keeps the working one
+    configure(&common.ClientTLS{CertEnv: "VENUE_TLS_MISSING", KeyFile: keyFile, CAFile: caFile})
+    if _, err := get(); err != nil {
+        t.Errorf("Expected the previous certificate to be kept, got %v", err)
+    }
+
+    Configure(&common.BaseConfig{}, "")
+    if _, err := get(); err == nil {
+        t.Error("Expected requests to fail without the venue's TLS settings")
+    }
+}
@@ -0,0 +1,205 @@
+package fetch
+
+import (
+    "net"
+    "net/http"
+    "net/http/httptrace"
+    "sort"
+    "sync"
+    "time"
+)
+
+// HostStats counts the requests made to one upstream host
+type HostStats struct {
+    Host string `json:"host"`
+    // Sources are the configured exchanges and DEXes served by the host
+    Sources     []string `json:"sources,omitempty"`
+    Requests    uint64   `json:"requests"`
+    Errors      uint64   `json:"errors"`
+    NewConns    uint64   `json:"newConns"`
+    ReusedConns uint64   `json:"reusedConns"`
+    HTTP1       uint64   `json:"http1"` // responses served over HTTP/1.x
+    HTTP2       uint64   `json:"http2"` // responses served over HTTP/2
+    // Responses by content coding
+    Gzip         uint64 `json:"gzip"`
+    Deflate      uint64 `json:"deflate"`
+    Uncompressed uint64 `json:"uncompressed"`
+    // Body bytes as transferred and after decoding, for bodies closed so far
+    WireBytes    uint64 `json:"wireBytes"`
+    DecodedBytes uint64 `json:"decodedBytes"`
+    // CacheHits are requests answered with a shared response instead of
+    // being sent upstream; they are not counted in Requests
+    CacheHits uint64 `json:"cacheHits"`
+}
+
+// TransportStats is a snapshot of the shared transport's counters
+type TransportStats struct {
+    Totals HostStats   `json:"totals"`
+    Hosts  []HostStats `json:"hosts"`
+    // Chaos counts injected faults by kind while chaos mode is enabled
+    Chaos map[string]uint64 `json:"chaos,omitempty"`
+}
+
+// sharedTransport keeps connections alive across fetchers and pairs; the
+// per-host idle pool is sized for many pairs polling the same exchange
+var sharedTransport = &http.Transport{
+    Proxy: http.ProxyFromEnvironment,
+    DialContext: (&net.Dialer{
+        Timeout:   5 * time.Second,
+        KeepAlive: 30 * time.Second,
+    }).DialContext,
+    ForceAttemptHTTP2:     true,
+    MaxIdleConns:          256,
+    MaxIdleConnsPerHost:   32,
+    IdleConnTimeout:       90 * time.Second,
+    TLSHandshakeTimeout:   5 * time.Second,
+    ExpectContinueTimeout: time.Second,
+}
+
+var (
+    statsMu sync.Mutex
+    stats   = make(map[string]*HostStats)
+
+    responseMu sync.RWMutex
+    onResponse func(host string, status int, header http.Header)
+)
+
+// OnResponse registers fn to be called with the status and headers of
+// every upstream response, e.g. to account for usage billed per request
+func OnResponse(fn func(host string, status int, header http.Header)) {
+    responseMu.Lock()
+    defer responseMu.Unlock()
+    onResponse = fn
+}
+
+// Transport is the instrumented round tripper shared by all fetchers
+var Transport http.RoundTripper = &instrumentedTransport{base: sharedTransport}
+
+// NewClient returns a client with the given timeout on the shared transport
+func NewClient(timeout time.Duration) *http.Client {
+    return &http.Client{Timeout: timeout, Transport: Transport}
+}
+
+// instrumentedTransport adds identity headers, negotiates compression and
+// records per-host request, connection, protocol and encoding counters
+type instrumentedTransport struct {
+    base http.RoundTripper
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+    if ttl := requestTTL(req); ttl > 0 && (req.Method == http.MethodGet || req.Method == http.MethodPost) {
+        return cachedRoundTrip(req, ttl, t.send)
+    }
+    return t.send(req)
+}
+
+// send makes an upstream request
+func (t *instrumentedTransport) send(req *http.Request) (*http.Response, error) {
+    req = negotiate(identify(req))
+    host := req.URL.Host
+    trace := &httptrace.ClientTrace{
+        GotConn: func(info httptrace.GotConnInfo) {
+            record(host, func(s *HostStats) {
+                if info.Reused {
+                    s.ReusedConns++
+                } else {
+                    s.NewConns++
+                }
+            })
+        },
+    }
+    req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+    // Sources requiring a client certificate have a transport of their own
+    var base http.RoundTripper = t.base
+    if ht := hostTransport(host); ht != nil {
+        base = ht
+    }
+    // Responses are decoded before chaos sees them, so corruption edits
+    // the numbers rather than compressed bytes
+    encoding := ""
+    roundTrip := func(req *http.Request) (*http.Response, error) {
+        resp, err := base.RoundTrip(req)
+        if err == nil {
+            encoding = decompress(host, resp)
+        }
+        return resp, err
+    }
+    var resp *http.Response
+    var err error
+    if c := currentChaos(); c != nil {
+        resp, err = c.roundTrip(req, roundTrip)
+    } else {
+        resp, err = roundTrip(req)
+    }
+    record(host, func(s *HostStats) {
+        s.Requests++
+        if err != nil {
+            s.Errors++
+            return
+        }
+        if resp.ProtoMajor == 2 {
+            s.HTTP2++
+        } else {
+            s.HTTP1++
+        }
+        switch encoding {
+        case "gzip":
+            s.Gzip++
+        case "deflate":
+            s.Deflate++
+        case "identity":
+            s.Uncompressed++
+        }
+    })
+    if err == nil {
+        responseMu.RLock()
+        fn := onResponse
+        responseMu.RUnlock()
+        if fn != nil {
+            fn(host, resp.StatusCode, resp.Header)
+        }
+    }
+    return resp, err
+}
+
+// record applies update to the counters of host
+func record(host string, update func(*HostStats)) {
+    statsMu.Lock()
+    defer statsMu.Unlock()
+    s, ok := stats[host]
+    if !ok {
+        s = &HostStats{Host: host}
+        stats[host] = s
+    }
+    update(s)
+}
+
+// Stats returns the shared transport's counters, hosts sorted by name
+func Stats() TransportStats {
+    statsMu.Lock()
+    defer statsMu.Unlock()
+
+    out := TransportStats{Hosts: make([]HostStats, 0, len(stats))}
+    sources := HostSources()
+    for _, s := range stats {
+        host := *s
+        host.Sources = sources[s.Host]
+        out.Hosts = append(out.Hosts, host)
+        out.Totals.Requests += s.Requests
+        out.Totals.Errors += s.Errors
+        out.Totals.NewConns += s.NewConns
+        out.Totals.ReusedConns += s.ReusedConns
+        out.Totals.HTTP1 += s.HTTP1
+        out.Totals.HTTP2 += s.HTTP2
+        out.Totals.Gzip += s.Gzip
+        out.Totals.Deflate += s.Deflate
+        out.Totals.Uncompressed += s.Uncompressed
+        out.Totals.WireBytes += s.WireBytes
+        out.Totals.DecodedBytes += s.DecodedBytes
+        out.Totals.CacheHits += s.CacheHits
+    }
+    sort.Slice(out.Hosts, func(i, j int) bool { return out.Hosts[i].Host < out.Hosts[j].Host })
+    out.Chaos = chaosStats()
+    return out
+}
@@ -0,0 +1,50 @@
+package fetch
+
+import (
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "testing"
+    "time"
+)
+
+func TestSharedTransportReusesConnections(t *testing.T) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte(`{}`))
+    }))
+    defer srv.Close()
+    host := mustHost(t, srv.URL)
+
+    client := NewClient(time.Second)
+    for i := 0; i < 3; i++ {
+        resp, err := client.Get(srv.URL)
+        if err != nil {
+            t.Fatalf("Request failed: %v", err)
+        }
+        io.Copy(io.Discard, resp.Body)
+        resp.Body.Close()
+    }
+
+    var got HostStats
+    for _, s := range Stats().Hosts {
+        if s.Host == host {
+            got = s
+        }
+    }
+    if got.Requests != 3 || got.Errors != 0 {
+        t.Errorf("Expected 3 successful requests, got %+v", got)
+    }
+    if got.NewConns != 1 || got.ReusedConns != 2 {
+        t.Errorf("Expected one connection reused twice, got %+v", got)
+    }
+}
+
+func mustHost(t *testing.T, raw string) string {
+    t.Helper()
+    u, err := url.Parse(raw)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return u.Host
+}
@@ -0,0 +1,271 @@
+package fetch
+
+import (
+    "bufio"
+    "context"
+    "crypto/rand"
+    "crypto/sha1"
+    "crypto/tls"
+    "encoding/base64"
+    "encoding/binary"
+    "fmt"
+    "io"
+    "net"
+    "net/http"
+    "net/url"
+    "strings"
+    "sync"
+    "time"
+)
+
+// WebSocket opcodes
+const (
+    wsContinuation = 0x0
+    wsText         = 0x1
+    wsBinary       = 0x2
+    wsClose        = 0x8
+    wsPing         = 0x9
+    wsPong         = 0xa
+)
+
+// wsAcceptGUID is appended to the handshake key to derive the accept key
+const wsAcceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// maxWebSocketMessage bounds a reassembled upstream message
+const maxWebSocketMessage = 16 << 20
+
+// WebSocket is a client connection to an upstream streaming API. It
+// answers pings itself; reads must come from a single goroutine, writes
+// may come from any.
+type WebSocket struct {
+    conn net.Conn
+    r    *bufio.Reader
+
+    writeMu sync.Mutex
+    closed  bool
+}
+
+// DialWebSocket opens a WebSocket to a ws:// or wss:// URL, sending the
+// configured identity headers with the handshake
+func DialWebSocket(ctx context.Context, rawURL string) (*WebSocket, error) {
+    u, err := url.Parse(rawURL)
+    if err != nil {
+        return nil, err
+    }
+    port := "80"
+    switch u.Scheme {
+    case "ws":
+    case "wss":
+        port = "443"
+    default:
+        return nil, fmt.Errorf("unsupported WebSocket scheme %q", u.Scheme)
+    }
+    addr := u.Host
+    if u.Port() == "" {
+        addr = net.JoinHostPort(u.Hostname(), port)
+    }
+
+    dialer := &net.Dialer{Timeout: 10 * time.Second}
+    var conn net.Conn
+    if u.Scheme == "wss" {
+        config := &tls.Config{}
+        if ht := hostTransport(u.Host); ht != nil {
+            config = ht.TLSClientConfig.Clone()
+        }
+        config.ServerName = u.Hostname()
+        conn, err = (&tls.Dialer{NetDialer: dialer, Config: config}).DialContext(ctx, "tcp", addr)
+    } else {
+        conn, err = dialer.DialContext(ctx, "tcp", addr)
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    ws, err := handshake(ctx, conn, u)
+    if err != nil {
+        conn.Close()
+        return nil, err
+    }
+    return ws, nil
+}
+
+// handshake upgrades conn to a WebSocket
+func handshake(ctx context.Context, conn net.Conn, u *url.URL) (*WebSocket, error) {
+    if deadline, ok := ctx.Deadline(); ok {
+        conn.SetDeadline(deadline)
+        defer conn.SetDeadline(time.Time{})
+    }
+
+    nonce := make([]byte, 16)
+    if _, err := rand.Read(nonce); err != nil {
+        return nil, err
+    }
+    key := base64.StdEncoding.EncodeToString(nonce)
+
+    // The request carries the same identity headers as REST fetches
+    target := *u
+    target.Scheme = strings.Replace(u.Scheme, "ws", "http", 1)
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+    if err != nil {
+        return nil, err
+    }
+    req = identify(req)
+    req.Header.Set("Upgrade", "websocket")
+    req.Header.Set("Connection", "Upgrade")
+    req.Header.Set("Sec-WebSocket-Key", key)
+    req.Header.Set("Sec-WebSocket-Version", "13")
+    if err := req.Write(conn); err != nil {
+        return nil, err
+    }
+
+    r := bufio.NewReader(conn)
+    resp, err := http.ReadResponse(r, req)
+    if err != nil {
+        return nil, err
+    }
+    resp.Body.Close()
+    if resp.StatusCode != http.StatusSwitchingProtocols {
+        return nil, fmt.Errorf("WebSocket handshake refused: %s", resp.Status)
+    }
+    if resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+        return nil, fmt.Errorf("WebSocket handshake returned an invalid accept key")
+    }
+    return &WebSocket{conn: conn, r: r}, nil
+}
+
+// acceptKey derives the Sec-WebSocket-Accept value for a handshake key
+func acceptKey(key string) string {
+    sum := sha1.Sum([]byte(key + wsAcceptGUID))
+    return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// ReadMessage returns the next text or binary message, reassembling
+// fragments and answering pings. It returns io.EOF once the upstream
+// closes the stream.
+func (ws *WebSocket) ReadMessage() ([]byte, error) {
+    var message []byte
+    for {
+        fin, opcode, payload, err := ws.readFrame()
+        if err != nil {
+            return nil, err
+        }
+        switch opcode {
+        case wsPing:
+            if err := ws.writeFrame(wsPong, payload); err != nil {
+                return nil, err
+            }
+            continue
+        case wsPong:
+            continue
+        case wsClose:
+            ws.writeFrame(wsClose, payload)
+            return nil, io.EOF
+        case wsText, wsBinary, wsContinuation:
+        default:
+            return nil, fmt.Errorf("unknown WebSocket opcode %d", opcode)
+        }
+
+        if len(message)+len(payload) > maxWebSocketMessage {
+            return nil, fmt.Errorf("WebSocket message exceeds %d bytes", maxWebSocketMessage)
+        }
+        message = append(message, payload...)
+        if fin {
+            return message, nil
+        }
+    }
+}
+
+// readFrame reads one frame
+func (ws *WebSocket) readFrame() (bool, byte, []byte, error) {
+    var header [2]byte
+    if _, err := io.ReadFull(ws.r, header[:]); err != nil {
+        return false, 0, nil, err
+    }
+    fin, opcode := header[0]&0x80 != 0, header[0]&0x0f
+    masked := header[1]&0x80 != 0
+    length := uint64(header[1] & 0x7f)
+    switch length {
+    case 126:
+        var ext [2]byte
+        if _, err := io.ReadFull(ws.r, ext[:]); err != nil {
+            return false, 0, nil, err
+        }
+        length = uint64(binary.BigEndian.Uint16(ext[:]))
+    case 127:
+        var ext [8]byte
+        if _, err := io.ReadFull(ws.r, ext[:]); err != nil {
+            return false, 0, nil, err
+        }
+        length = binary.BigEndian.Uint64(ext[:])
+    }
+    if length > maxWebSocketMessage {
+        return false, 0, nil, fmt.Errorf("WebSocket frame exceeds %d bytes", maxWebSocketMessage)
+    }
+
+    var mask [4]byte
+    if masked {
+        if _, err := io.ReadFull(ws.r, mask[:]); err != nil {
+            return false, 0, nil, err
+        }
+    }
+    payload := make([]byte, length)
+    if _, err := io.ReadFull(ws.r, payload); err != nil {
+        return false, 0, nil, err
+    }
+    if masked {
+        for i := range payload {
+            payload[i] ^= mask[i%4]
+        }
+    }
+    return fin, opcode, payload, nil
+}
+
+// WriteText sends a text message
+func (ws *WebSocket) WriteText(p []byte) error {
+    return ws.writeFrame(wsText, p)
+}
+
+// writeFrame sends a single masked frame, as clients must
+func (ws *WebSocket) writeFrame(opcode byte, payload []byte) error {
+    ws.writeMu.Lock()
+    defer ws.writeMu.Unlock()
+    if ws.closed {
+        return fmt.Errorf("WebSocket is closed")
+    }
+
+    frame := []byte{0x80 | opcode}
+    switch n := len(payload); {
+    case n < 126:
+        frame = append(frame, 0x80|byte(n))
+    case n <= 0xffff:
+        frame = append(frame, 0x80|126, byte(n>>8), byte(n))
+    default:
+        frame = append(frame, 0x80|127)
+        frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+    }
+    var mask [4]byte
+    if _, err := rand.Read(mask[:]); err != nil {
+        return err
+    }
+    frame = append(frame, mask[:]...)
+    for i, b := range payload {
+        frame = append(frame, b^mask[i%4])
+    }
+    _, err := ws.conn.Write(frame)
+    return err
+}
+
+// SetReadDeadline bounds the wait for the next frame, so that a silent
+// stream is noticed
+func (ws *WebSocket) SetReadDeadline(t time.Time) error {
+    return ws.conn.SetReadDeadline(t)
+}
+
+// Close sends a close frame and closes the connection
+func (ws *WebSocket) Close() error {
+    ws.writeFrame(wsClose, nil)
+    ws.writeMu.Lock()
+    ws.closed = true
+    ws.writeMu.Unlock()
+    return ws.conn.Close()
+}
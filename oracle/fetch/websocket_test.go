@@ -0,0 +1,124 @@
+package fetch
+
+import (
+    "bufio"
+    "context"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+)
+
+// serveWebSocket upgrades requests and hands the raw connection to handle
+func serveWebSocket(t *testing.T, handle func(rw *bufio.ReadWriter)) *httptest.Server {
+    return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.Header.Get("Upgrade") != "websocket" {
+            http.Error(w, "upgrade required", http.StatusUpgradeRequired)
+            return
+        }
+        conn, rw, err := w.(http.Hijacker).Hijack()
+        if err != nil {
+            t.Errorf("hijack: %v", err)
+            return
+        }
+        defer conn.Close()
+        rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+        rw.WriteString("Sec-WebSocket-Accept: " + acceptKey(r.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n")
+        rw.Flush()
+        handle(rw)
+    }))
+}
+
+// serverFrame encodes an unmasked frame as servers send them
+func serverFrame(fin bool, opcode byte, payload string) []byte {
+    first := opcode
+    if fin {
+        first |= 0x80
+    }
+    return append([]byte{first, byte(len(payload))}, payload...)
+}
+
+// readClientFrame reads a masked client frame
+func readClientFrame(r *bufio.Reader) (byte, string, error) {
+    header := make([]byte, 2)
+    if _, err := io.ReadFull(r, header); err != nil {
+        return 0, "", err
+    }
+    if header[1]&0x80 == 0 {
+        return 0, "", io.ErrUnexpectedEOF
+    }
+    mask := make([]byte, 4)
+    io.ReadFull(r, mask)
+    payload := make([]byte, header[1]&0x7f)
+    io.ReadFull(r, payload)
+    for i := range payload {
+        payload[i] ^= mask[i%4]
+    }
+    return header[0] & 0x0f, string(payload), nil
+}
+
+func TestWebSocketMessages(t *testing.T) {
+    pong := make(chan string, 1)
+    server := serveWebSocket(t, func(rw *bufio.ReadWriter) {
+        // Echo the subscription, fragmented around a ping
+        _, sub, err := readClientFrame(rw.Reader)
+        if err != nil {
+            t.Errorf("read subscription: %v", err)
+            return
+        }
+        rw.Write(serverFrame(false, wsText, sub[:5]))
+        rw.Write(serverFrame(true, wsPing, "hb"))
+        rw.Write(serverFrame(true, wsContinuation, sub[5:]))
+        rw.Flush()
+        if opcode, payload, err := readClientFrame(rw.Reader); err == nil && opcode == wsPong {
+            pong <- payload
+        }
+        rw.Write(serverFrame(true, wsClose, ""))
+        rw.Flush()
+        readClientFrame(rw.Reader)
+    })
+    defer server.Close()
+
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+    ws, err := DialWebSocket(ctx, strings.Replace(server.URL, "http", "ws", 1)+"/stream")
+    if err != nil {
+        t.Fatalf("Expected the handshake to succeed, got %v", err)
+    }
+    defer ws.Close()
+
+    if err := ws.WriteText([]byte(`{"subscribe":"book"}`)); err != nil {
+        t.Fatal(err)
+    }
+    message, err := ws.ReadMessage()
+    if err != nil || string(message) != `{"subscribe":"book"}` {
+        t.Fatalf("Expected the reassembled echo, got %q, %v", message, err)
+    }
+    select {
+    case payload := <-pong:
+        if payload != "hb" {
+            t.Errorf("Expected the ping payload echoed, got %q", payload)
+        }
+    case <-ctx.Done():
+        t.Fatal("Expected a pong")
+    }
+    if _, err := ws.ReadMessage(); err != io.EOF {
+        t.Errorf("Expected EOF after a close frame, got %v", err)
+    }
+}
+
+func TestWebSocketHandshakeRefused(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        http.Error(w, "forbidden", http.StatusForbidden)
+    }))
+    defer server.Close()
+
+    if _, err := DialWebSocket(context.Background(), strings.Replace(server.URL, "http", "ws", 1)); err == nil {
+        t.Error("Expected a refused handshake to fail")
+    }
+    if _, err := DialWebSocket(context.Background(), server.URL); err == nil {
+        t.Error("Expected an http URL to be rejected")
+    }
+}
@@ -0,0 +1,90 @@
+package health
+
+import (
+    "math"
+    "time"
+)
+
+// StaleIntervals is how many update intervals a feed's value may age before
+// its freshness, and so its score, reaches zero
+const StaleIntervals = 3
+
+// Feed is the state of one feed a score is computed from
+type Feed struct {
+    Symbol    string
+    Available bool // whether the feed has a value to serve
+    Age       time.Duration
+    Interval  time.Duration
+    // Sources is the number of sources in the value; MinimumSources is the
+    // number the feed is configured to require, zero for computed feeds
+    Sources        int
+    MinimumSources int
+    Failures       int  // consecutive failed rounds
+    Fallback       bool // whether the value came from a fallback tier
+    // Paused and MarketClosed feeds serve their last value on purpose, so
+    // its age is not held against them
+    Paused       bool
+    MarketClosed bool
+}
+
+// FeedScore is the score of one feed and what it is made of, each in [0, 1]
+type FeedScore struct {
+    Symbol    string  `json:"symbol"`
+    Score     float64 `json:"score"` // 0 to 100
+    Freshness float64 `json:"freshness"`
+    Coverage  float64 `json:"coverage"`
+    Stability float64 `json:"stability"`
+}
+
+// Score rates how well an instance serves a feed from 0 to 100 as the
+// product of its freshness, source coverage and round stability.
+// Freshness is full within one update interval and falls linearly to zero
+// at StaleIntervals; coverage is the share of the required sources present;
+// stability halves with each consecutive failure, and a fallback value
+// loses a quarter.
+func Score(f Feed) FeedScore {
+    score := FeedScore{Symbol: f.Symbol}
+    if !f.Available {
+        return score
+    }
+
+    score.Freshness = 1
+    if !f.Paused && !f.MarketClosed && f.Interval > 0 && f.Age > f.Interval {
+        score.Freshness = 1 - float64(f.Age-f.Interval)/float64((StaleIntervals-1)*f.Interval)
+        if score.Freshness < 0 {
+            score.Freshness = 0
+        }
+    }
+
+    score.Coverage = 1
+    if f.MinimumSources > 0 && f.Sources < f.MinimumSources {
+        score.Coverage = float64(f.Sources) / float64(f.MinimumSources)
+    }
+
+    score.Stability = math.Pow(0.5, float64(f.Failures))
+    if f.Fallback {
+        score.Stability *= 0.75
+    }
+
+    score.Score = round(100 * score.Freshness * score.Coverage * score.Stability)
+    return score
+}
+
+// Instance rates an instance from 0 to 100 as the mean score of its feeds.
+// An instance that is not ready, still warming up or a replica cut off from
+// its primary, scores zero so that load balancers drain it.
+func Instance(feeds []FeedScore, ready bool) float64 {
+    if !ready || len(feeds) == 0 {
+        return 0
+    }
+    var sum float64
+    for _, f := range feeds {
+        sum += f.Score
+    }
+    return round(sum / float64(len(feeds)))
+}
+
+// round rounds a score to one decimal
+func round(score float64) float64 {
+    return math.Round(score*10) / 10
+}
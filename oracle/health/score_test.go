@@ -0,0 +1,46 @@
+package health
+
+import (
+    "testing"
+    "time"
+)
+
+func TestScore(t *testing.T) {
+    fresh := Feed{Symbol: "ETHUSDT", Available: true, Age: 3 * time.Second, Interval: 5 * time.Second, Sources: 3, MinimumSources: 3}
+    if s := Score(fresh); s.Score != 100 {
+        t.Errorf("Expected a fresh feed to score 100, got %+v", s)
+    }
+
+    // Two intervals old is halfway to stale
+    aged := fresh
+    aged.Age = 10 * time.Second
+    if s := Score(aged); s.Freshness != 0.5 || s.Score != 50 {
+        t.Errorf("Expected half freshness, got %+v", s)
+    }
+    aged.Age = time.Minute
+    if s := Score(aged); s.Score != 0 {
+        t.Errorf("Expected a stale feed to score 0, got %+v", s)
+    }
+    aged.MarketClosed = true
+    if s := Score(aged); s.Score != 100 {
+        t.Errorf("Expected a closed market's last value not to age, got %+v", s)
+    }
+
+    degraded := fresh
+    degraded.Sources, degraded.Failures, degraded.Fallback = 2, 1, true
+    if s := Score(degraded); s.Score != 25 {
+        t.Errorf("Expected 2/3 coverage at 0.375 stability to score 25, got %+v", s)
+    }
+
+    if s := Score(Feed{Symbol: "BTCUSDT"}); s.Score != 0 {
+        t.Errorf("Expected an unavailable feed to score 0, got %+v", s)
+    }
+
+    feeds := []FeedScore{{Score: 100}, {Score: 50}}
+    if score := Instance(feeds, true); score != 75 {
+        t.Errorf("Expected an instance score of 75, got %v", score)
+    }
+    if score := Instance(feeds, false); score != 0 {
+        t.Errorf("Expected an instance that is not ready to score 0, got %v", score)
+    }
+}
@@ -0,0 +1,173 @@
+package metering
+
+import (
+    "bufio"
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "log"
+    "os"
+    "time"
+)
+
+// journalCompactLines is how many appended lines make the journal be
+// rewritten with one line per day, consumer and feed
+const journalCompactLines = 100000
+
+// usageKey identifies the usage of one feed by one consumer on one day
+type usageKey struct {
+    day, consumer, feed string
+}
+
+// openJournal replays the usage journal at path, which holds Record lines
+// adding to the usage, and compacts it
+func (m *Meter) openJournal(path string, now time.Time) error {
+    file, err := os.Open(path)
+    switch {
+    case os.IsNotExist(err):
+    case err != nil:
+        return fmt.Errorf("failed to open usage journal: %v", err)
+    default:
+        scanner := bufio.NewScanner(file)
+        for scanner.Scan() {
+            var r Record
+            if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+                // A crash mid-write leaves at most one torn trailing line
+                continue
+            }
+            m.add(r.Day, r.Consumer, r.Feed, r.Usage)
+        }
+        err = scanner.Err()
+        file.Close()
+        if err != nil {
+            return fmt.Errorf("failed to replay usage journal: %v", err)
+        }
+    }
+    m.prune(now)
+
+    m.journalPath = path
+    return m.compact(m.records("", "", now.UTC().Format(dayLayout)))
+}
+
+// compact replaces the journal with records; callers hold journalMu
+func (m *Meter) compact(records []Record) error {
+    data, err := marshalRecords(records)
+    if err != nil {
+        return err
+    }
+    tmp := m.journalPath + ".tmp"
+    if err := os.WriteFile(tmp, data, 0600); err != nil {
+        return fmt.Errorf("failed to write usage journal: %v", err)
+    }
+    if err := os.Rename(tmp, m.journalPath); err != nil {
+        return fmt.Errorf("failed to write usage journal: %v", err)
+    }
+    file, err := os.OpenFile(m.journalPath, os.O_WRONLY|os.O_APPEND, 0600)
+    if err != nil {
+        return fmt.Errorf("failed to open usage journal: %v", err)
+    }
+    if m.journal != nil {
+        m.journal.Close()
+    }
+    m.journal = file
+    m.appended = len(records)
+    return nil
+}
+
+// Flush appends the usage counted since the last flush to the journal,
+// compacting it once it has grown long
+func (m *Meter) Flush() error {
+    m.journalMu.Lock()
+    defer m.journalMu.Unlock()
+    if m.journal == nil {
+        return nil
+    }
+
+    m.mu.Lock()
+    records := make([]Record, 0, len(m.pending))
+    for key, u := range m.pending {
+        records = append(records, Record{Day: key.day, Consumer: key.consumer, Feed: key.feed, Usage: *u})
+    }
+    m.pending = make(map[usageKey]*Usage)
+    if m.appended+len(records) > journalCompactLines {
+        // The usage already holds the pending records
+        all := m.records("", "", "9999-12-31")
+        m.mu.Unlock()
+        return m.compact(all)
+    }
+    m.mu.Unlock()
+    if len(records) == 0 {
+        return nil
+    }
+
+    err := m.append(records)
+    if err != nil {
+        // Keep the records for the next flush
+        m.mu.Lock()
+        for _, r := range records {
+            key := usageKey{day: r.Day, consumer: r.Consumer, feed: r.Feed}
+            if m.pending[key] == nil {
+                m.pending[key] = &Usage{}
+            }
+            m.pending[key].add(r.Usage)
+        }
+        m.mu.Unlock()
+    }
+    return err
+}
+
+// append durably writes records to the journal; callers hold journalMu
+func (m *Meter) append(records []Record) error {
+    data, err := marshalRecords(records)
+    if err != nil {
+        return err
+    }
+    if _, err := m.journal.Write(data); err != nil {
+        return fmt.Errorf("failed to write usage journal: %v", err)
+    }
+    if err := m.journal.Sync(); err != nil {
+        return fmt.Errorf("failed to sync usage journal: %v", err)
+    }
+    m.appended += len(records)
+    return nil
+}
+
+// marshalRecords encodes records as journal lines
+func marshalRecords(records []Record) ([]byte, error) {
+    var buf bytes.Buffer
+    for _, r := range records {
+        line, err := json.Marshal(r)
+        if err != nil {
+            return nil, err
+        }
+        buf.Write(line)
+        buf.WriteByte('\n')
+    }
+    return buf.Bytes(), nil
+}
+
+// Interval returns how often usage is flushed to the journal
+func (m *Meter) Interval() time.Duration {
+    return m.flushInterval
+}
+
+// Run flushes usage to the journal every interval until ctx is done, then
+// flushes once more
+func (m *Meter) Run(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            if err := m.Flush(); err != nil {
+                log.Printf("Failed to flush usage: %v", err)
+            }
+            return
+        case <-ticker.C:
+            if err := m.Flush(); err != nil {
+                log.Printf("Failed to flush usage: %v", err)
+            }
+        }
+    }
+}
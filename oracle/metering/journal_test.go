@@ -0,0 +1,57 @@
+package metering
+
+import (
+    "bytes"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+)
+
+func TestJournalSurvivesRestart(t *testing.T) {
+    t.Setenv("TEST_FREE_KEY", "free-key")
+    config := &Config{
+        Enabled:   true,
+        Consumers: []*Consumer{{Name: "free", KeyEnv: "TEST_FREE_KEY", Quota: Quota{RequestsPerDay: 3}}},
+        Journal:   filepath.Join(t.TempDir(), "usage.journal"),
+    }
+    meter, err := NewMeter(config)
+    if err != nil {
+        t.Fatalf("Failed to create meter: %v", err)
+    }
+    free := meter.Authenticate("free-key")
+    now := time.Now()
+    for _, feed := range []string{"ETHUSDT", "ETHUSDT", "BTCUSDT"} {
+        if err := meter.Request(free, feed, now); err != nil {
+            t.Fatal(err)
+        }
+    }
+    if err := meter.Flush(); err != nil {
+        t.Fatalf("Failed to flush: %v", err)
+    }
+    // Flushed twice, the usage is journalled once
+    if err := meter.Flush(); err != nil {
+        t.Fatalf("Failed to flush: %v", err)
+    }
+
+    restarted, err := NewMeter(config)
+    if err != nil {
+        t.Fatalf("Failed to reopen the journal: %v", err)
+    }
+    records := restarted.Records("", now, now)
+    if len(records) != 2 || records[0].Feed != "BTCUSDT" || records[0].Requests != 1 || records[1].Feed != "ETHUSDT" || records[1].Requests != 2 {
+        t.Errorf("Expected the usage replayed, got %+v", records)
+    }
+    if _, ok := restarted.Request(restarted.Authenticate("free-key"), "ETHUSDT", now).(*QuotaError); !ok {
+        t.Error("Expected the quota used up before the restart to hold")
+    }
+
+    // Replaying compacts the journal to a line per day, consumer and feed
+    data, err := os.ReadFile(config.Journal)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if lines := bytes.Count(data, []byte("\n")); lines != 2 {
+        t.Errorf("Expected 2 journal lines after compaction, got %d", lines)
+    }
+}
@@ -0,0 +1,497 @@
+package metering
+
+import (
+    "crypto/subtle"
+    "encoding/csv"
+    "encoding/json"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "sort"
+    "strconv"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/credentials"
+)
+
+// retentionDays bounds how many days of usage are kept in memory
+const retentionDays = 90
+
+// dayLayout keys usage by UTC calendar day
+const dayLayout = "2006-01-02"
+
+// AllFeeds attributes stream messages that belong to no feed, such as
+// alerts without a symbol
+const AllFeeds = "*"
+
+// Quota limits a consumer's daily usage; zero means unlimited
+type Quota struct {
+    RequestsPerDay uint64 `json:"requestsPerDay,omitempty"`
+    MessagesPerDay uint64 `json:"messagesPerDay,omitempty"`
+}
+
+// Consumer is an API client identified by its key
+type Consumer struct {
+    Name string `json:"name"`
+    // KeyEnv names the credential holding the consumer's API key
+    KeyEnv string `json:"keyEnv"`
+    // Feeds the consumer is subscribed to; empty subscribes to every feed
+    Feeds []string `json:"feeds,omitempty"`
+    Quota Quota    `json:"quota"`
+
+    feeds map[string]bool
+}
+
+// Subscribed reports whether the consumer may read feed
+func (c *Consumer) Subscribed(feed string) bool {
+    return len(c.feeds) == 0 || feed == AllFeeds || c.feeds[feed]
+}
+
+// Config holds the API consumers. When enabled, feed endpoints require an
+// API key and are metered per consumer.
+type Config struct {
+    Enabled   bool        `json:"enabled"`
+    Consumers []*Consumer `json:"consumers"`
+    // PrivateFeeds restricts feeds to the named consumers whether or not
+    // metering is enabled, along with the feeds computed from them
+    PrivateFeeds map[string][]string `json:"privateFeeds,omitempty"`
+    // Journal keeps usage across restarts, so quotas and exports hold
+    Journal      string `json:"journal,omitempty"`
+    FlushSeconds int    `json:"flushSeconds,omitempty"` // default 10
+}
+
+// LoadConfig loads metering/metering.json from the config directory. A
+// missing file leaves metering disabled.
+func LoadConfig(configDir string) (*Config, error) {
+    data, err := os.ReadFile(filepath.Join(configDir, "metering", "metering.json"))
+    if os.IsNotExist(err) {
+        return &Config{}, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read metering config: %v", err)
+    }
+
+    var config Config
+    if err := json.Unmarshal(data, &config); err != nil {
+        return nil, fmt.Errorf("failed to parse metering config: %v", err)
+    }
+    return &config, config.Validate()
+}
+
+// Validate checks that consumers are named uniquely and have a key
+func (c *Config) Validate() error {
+    names := make(map[string]bool)
+    for _, consumer := range c.Consumers {
+        if consumer.Name == "" {
+            return fmt.Errorf("consumer without a name")
+        }
+        if names[consumer.Name] {
+            return fmt.Errorf("duplicate consumer %s", consumer.Name)
+        }
+        names[consumer.Name] = true
+        if consumer.KeyEnv == "" {
+            return fmt.Errorf("consumer %s has no keyEnv", consumer.Name)
+        }
+    }
+    if c.FlushSeconds < 0 {
+        return fmt.Errorf("metering flushSeconds must not be negative")
+    }
+    for feed, consumers := range c.PrivateFeeds {
+        if feed == AllFeeds {
+            return fmt.Errorf("private feed %s is not a feed", feed)
+        }
+        if len(consumers) == 0 {
+            return fmt.Errorf("private feed %s has no consumers", feed)
+        }
+        for _, name := range consumers {
+            if !names[name] {
+                return fmt.Errorf("private feed %s: unknown consumer %s", feed, name)
+            }
+        }
+    }
+    return nil
+}
+
+// PrivateFeedError is returned for private feeds the consumer may not read
+type PrivateFeedError struct {
+    Consumer string
+    Feed     string
+}
+
+func (e *PrivateFeedError) Error() string {
+    if e.Consumer == "" {
+        return fmt.Sprintf("feed %s is private; an API key is required", e.Feed)
+    }
+    return fmt.Sprintf("consumer %s may not read private feed %s", e.Consumer, e.Feed)
+}
+
+// NotSubscribedError is returned for feeds outside a consumer's subscription
+type NotSubscribedError struct {
+    Consumer string
+    Feed     string
+}
+
+func (e *NotSubscribedError) Error() string {
+    return fmt.Sprintf("consumer %s is not subscribed to %s", e.Consumer, e.Feed)
+}
+
+// QuotaError is returned once a consumer has used up a daily quota
+type QuotaError struct {
+    Consumer string
+    Kind     string // "requests" or "messages"
+    Limit    uint64
+}
+
+func (e *QuotaError) Error() string {
+    return fmt.Sprintf("consumer %s exceeded its quota of %d %s per day", e.Consumer, e.Limit, e.Kind)
+}
+
+// Usage counts a consumer's use of one feed
+type Usage struct {
+    Requests uint64 `json:"requests"`
+    Messages uint64 `json:"messages"`
+}
+
+func (u *Usage) add(other Usage) {
+    u.Requests += other.Requests
+    u.Messages += other.Messages
+}
+
+// Record is the usage of one feed by one consumer on one UTC day, the unit
+// of usage exports
+type Record struct {
+    Day      string `json:"day"`
+    Consumer string `json:"consumer"`
+    Feed     string `json:"feed"`
+    Usage
+}
+
+// Meter authenticates consumers and counts their usage per feed and day
+type Meter struct {
+    enabled   bool
+    consumers []*Consumer
+    // private holds the consumers allowed to read each private feed
+    private map[string]map[string]bool
+    // inputs returns the feeds a feed is computed from, which pass their
+    // restrictions on to it
+    inputs func(feed string) []string
+
+    mu sync.Mutex
+    // usage by day, consumer and feed
+    usage map[string]map[string]map[string]*Usage
+    // totals by day and consumer, for quota checks
+    totals map[string]map[string]*Usage
+    // pending is the usage not yet in the journal
+    pending map[usageKey]*Usage
+
+    flushInterval time.Duration
+    journalMu     sync.Mutex
+    journal       *os.File
+    journalPath   string
+    appended      int // lines since the journal was compacted
+}
+
+// NewMeter creates a meter for the configured consumers, checking that
+// their keys are set. Keys are resolved through oracle/credentials on every
+// request, so rotated keys apply at once and replaced ones drain.
+func NewMeter(config *Config) (*Meter, error) {
+    m := &Meter{
+        enabled: config.Enabled,
+        usage:   make(map[string]map[string]map[string]*Usage),
+        totals:  make(map[string]map[string]*Usage),
+        pending: make(map[usageKey]*Usage),
+        // Usage counted since the last flush is lost in a crash
+        flushInterval: 10 * time.Second,
+    }
+    if config.FlushSeconds > 0 {
+        m.flushInterval = time.Duration(config.FlushSeconds) * time.Second
+    }
+    // Consumers of private feeds authenticate even without metering
+    if !config.Enabled && len(config.PrivateFeeds) == 0 {
+        return m, nil
+    }
+    if len(config.PrivateFeeds) > 0 {
+        m.private = make(map[string]map[string]bool, len(config.PrivateFeeds))
+        for feed, consumers := range config.PrivateFeeds {
+            m.private[feed] = make(map[string]bool, len(consumers))
+            for _, name := range consumers {
+                m.private[feed][name] = true
+            }
+        }
+    }
+    for _, c := range config.Consumers {
+        consumer := *c
+        if credentials.Get(c.KeyEnv) == "" {
+            return nil, fmt.Errorf("API key of consumer %s not set in %s", c.Name, c.KeyEnv)
+        }
+        if len(c.Feeds) > 0 {
+            consumer.feeds = make(map[string]bool, len(c.Feeds))
+            for _, feed := range c.Feeds {
+                consumer.feeds[feed] = true
+            }
+        }
+        m.consumers = append(m.consumers, &consumer)
+    }
+    if config.Journal != "" {
+        if err := m.openJournal(config.Journal, time.Now()); err != nil {
+            return nil, err
+        }
+    }
+    return m, nil
+}
+
+// Enabled reports whether feed endpoints are metered
+func (m *Meter) Enabled() bool {
+    return m.enabled
+}
+
+// Authenticate returns the consumer holding key, or nil
+func (m *Meter) Authenticate(key string) *Consumer {
+    if key == "" {
+        return nil
+    }
+    var found *Consumer
+    for _, c := range m.consumers {
+        for _, accepted := range credentials.Values(c.KeyEnv) {
+            if subtle.ConstantTimeCompare([]byte(key), []byte(accepted)) == 1 {
+                found = c
+            }
+        }
+    }
+    return found
+}
+
+// SetInputs makes feeds computed from private feeds, such as derived and
+// statistic feeds, readable only by consumers allowed to read every one of
+// their inputs. inputs may also return names that are not feeds.
+func (m *Meter) SetInputs(inputs func(feed string) []string) {
+    m.inputs = inputs
+}
+
+// Private reports whether feed, or a feed it is computed from, is
+// restricted to specific consumers
+func (m *Meter) Private(feed string) bool {
+    return !m.Allowed(nil, feed)
+}
+
+// PrivateFeeds returns the private feeds, sorted
+func (m *Meter) PrivateFeeds() []string {
+    feeds := make([]string, 0, len(m.private))
+    for feed := range m.private {
+        feeds = append(feeds, feed)
+    }
+    sort.Strings(feeds)
+    return feeds
+}
+
+// Allowed reports whether consumer, nil for anonymous callers, may read
+// feed and the feeds it is computed from under the private feed ACLs;
+// subscriptions are checked separately
+func (m *Meter) Allowed(c *Consumer, feed string) bool {
+    if len(m.private) == 0 {
+        return true
+    }
+    return m.allowed(c, feed, make(map[string]bool))
+}
+
+// allowed walks feed's inputs, visiting each feed once
+func (m *Meter) allowed(c *Consumer, feed string, seen map[string]bool) bool {
+    if seen[feed] {
+        return true
+    }
+    seen[feed] = true
+    if allowed, private := m.private[feed]; private && (c == nil || !allowed[c.Name]) {
+        return false
+    }
+    if m.inputs == nil {
+        return true
+    }
+    for _, input := range m.inputs(feed) {
+        if !m.allowed(c, input, seen) {
+            return false
+        }
+    }
+    return true
+}
+
+// Authorize checks a caller's access to a private feed without metering it
+func (m *Meter) Authorize(c *Consumer, feed string) error {
+    if m.Allowed(c, feed) {
+        return nil
+    }
+    if c == nil {
+        return &PrivateFeedError{Feed: feed}
+    }
+    return &PrivateFeedError{Consumer: c.Name, Feed: feed}
+}
+
+// Request checks a request by consumer for feed against its subscription
+// and quota, and counts it if allowed
+func (m *Meter) Request(c *Consumer, feed string, now time.Time) error {
+    return m.count(c, feed, now, false)
+}
+
+// Message checks a streamed message of feed to consumer against its
+// subscription and quota, and counts it if allowed
+func (m *Meter) Message(c *Consumer, feed string, now time.Time) error {
+    return m.count(c, feed, now, true)
+}
+
+// count records one request or message
+func (m *Meter) count(c *Consumer, feed string, now time.Time, message bool) error {
+    if !c.Subscribed(feed) {
+        return &NotSubscribedError{Consumer: c.Name, Feed: feed}
+    }
+    if err := m.Authorize(c, feed); err != nil {
+        return err
+    }
+
+    day := now.UTC().Format(dayLayout)
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    if m.totals[day] == nil {
+        m.prune(now)
+    }
+    used := Usage{}
+    if total := m.totals[day][c.Name]; total != nil {
+        used = *total
+    }
+    if message && c.Quota.MessagesPerDay > 0 && used.Messages >= c.Quota.MessagesPerDay {
+        return &QuotaError{Consumer: c.Name, Kind: "messages", Limit: c.Quota.MessagesPerDay}
+    }
+    if !message && c.Quota.RequestsPerDay > 0 && used.Requests >= c.Quota.RequestsPerDay {
+        return &QuotaError{Consumer: c.Name, Kind: "requests", Limit: c.Quota.RequestsPerDay}
+    }
+
+    delta := Usage{Requests: 1}
+    if message {
+        delta = Usage{Messages: 1}
+    }
+    m.add(day, c.Name, feed, delta)
+    if m.journalPath != "" {
+        key := usageKey{day: day, consumer: c.Name, feed: feed}
+        if m.pending[key] == nil {
+            m.pending[key] = &Usage{}
+        }
+        m.pending[key].add(delta)
+    }
+    return nil
+}
+
+// add counts u towards consumer's usage of feed on day; callers hold mu
+func (m *Meter) add(day, consumer, feed string, u Usage) {
+    if m.totals[day] == nil {
+        m.totals[day] = make(map[string]*Usage)
+        m.usage[day] = make(map[string]map[string]*Usage)
+    }
+    total := m.totals[day][consumer]
+    if total == nil {
+        total = &Usage{}
+        m.totals[day][consumer] = total
+    }
+    feeds := m.usage[day][consumer]
+    if feeds == nil {
+        feeds = make(map[string]*Usage)
+        m.usage[day][consumer] = feeds
+    }
+    usage := feeds[feed]
+    if usage == nil {
+        usage = &Usage{}
+        feeds[feed] = usage
+    }
+    total.add(u)
+    usage.add(u)
+}
+
+// prune drops days beyond the retention; callers hold mu
+func (m *Meter) prune(now time.Time) {
+    oldest := now.UTC().AddDate(0, 0, -retentionDays).Format(dayLayout)
+    for day := range m.totals {
+        if day < oldest {
+            delete(m.totals, day)
+            delete(m.usage, day)
+        }
+    }
+}
+
+// Remaining returns how much of its daily quota consumer has left; zero
+// limits are reported as zero
+func (m *Meter) Remaining(c *Consumer, now time.Time) Quota {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    used := Usage{}
+    if total := m.totals[now.UTC().Format(dayLayout)][c.Name]; total != nil {
+        used = *total
+    }
+    var left Quota
+    if c.Quota.RequestsPerDay > used.Requests {
+        left.RequestsPerDay = c.Quota.RequestsPerDay - used.Requests
+    }
+    if c.Quota.MessagesPerDay > used.Messages {
+        left.MessagesPerDay = c.Quota.MessagesPerDay - used.Messages
+    }
+    return left
+}
+
+// Records returns the usage between the UTC days of from and to inclusive,
+// optionally only of one consumer, ordered by day, consumer and feed
+func (m *Meter) Records(consumer string, from, to time.Time) []Record {
+    first, last := from.UTC().Format(dayLayout), to.UTC().Format(dayLayout)
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    return m.records(consumer, first, last)
+}
+
+// records returns the usage between two days; callers hold mu
+func (m *Meter) records(consumer, first, last string) []Record {
+    records := make([]Record, 0)
+    for day, consumers := range m.usage {
+        if day < first || day > last {
+            continue
+        }
+        for name, feeds := range consumers {
+            if consumer != "" && name != consumer {
+                continue
+            }
+            for feed, usage := range feeds {
+                records = append(records, Record{Day: day, Consumer: name, Feed: feed, Usage: *usage})
+            }
+        }
+    }
+    sort.Slice(records, func(i, j int) bool {
+        a, b := records[i], records[j]
+        if a.Day != b.Day {
+            return a.Day < b.Day
+        }
+        if a.Consumer != b.Consumer {
+            return a.Consumer < b.Consumer
+        }
+        return a.Feed < b.Feed
+    })
+    return records
+}
+
+// WriteCSV exports usage records for billing, with a header row
+func WriteCSV(w io.Writer, records []Record) error {
+    out := csv.NewWriter(w)
+    if err := out.Write([]string{"day", "consumer", "feed", "requests", "messages"}); err != nil {
+        return err
+    }
+    for _, r := range records {
+        row := []string{
+            r.Day,
+            r.Consumer,
+            r.Feed,
+            strconv.FormatUint(r.Requests, 10),
+            strconv.FormatUint(r.Messages, 10),
+        }
+        if err := out.Write(row); err != nil {
+            return err
+        }
+    }
+    out.Flush()
+    return out.Error()
+}
@@ -0,0 +1,143 @@
+package metering
+
+import (
+    "bytes"
+    "testing"
+    "time"
+)
+
+func TestMeter(t *testing.T) {
+    t.Setenv("TEST_ACME_KEY", "acme-key")
+    t.Setenv("TEST_FREE_KEY", "free-key")
+    meter, err := NewMeter(&Config{
+        Enabled: true,
+        Consumers: []*Consumer{
+            {Name: "acme", KeyEnv: "TEST_ACME_KEY"},
+            {Name: "free", KeyEnv: "TEST_FREE_KEY", Feeds: []string{"ETHUSDT"}, Quota: Quota{RequestsPerDay: 2, MessagesPerDay: 1}},
+        },
+    })
+    if err != nil {
+        t.Fatalf("Failed to create meter: %v", err)
+    }
+
+    if meter.Authenticate("wrong") != nil || meter.Authenticate("") != nil {
+        t.Error("Expected unknown keys to be rejected")
+    }
+    acme, free := meter.Authenticate("acme-key"), meter.Authenticate("free-key")
+    if acme == nil || acme.Name != "acme" || free == nil || free.Name != "free" {
+        t.Fatalf("Expected keys to identify their consumers, got %v and %v", acme, free)
+    }
+
+    day := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+    if err := meter.Request(free, "BTCUSDT", day); err == nil {
+        t.Error("Expected requests outside the subscription to be refused")
+    } else if _, ok := err.(*NotSubscribedError); !ok {
+        t.Errorf("Expected a NotSubscribedError, got %v", err)
+    }
+    for i := 0; i < 2; i++ {
+        if err := meter.Request(free, "ETHUSDT", day); err != nil {
+            t.Fatalf("Expected request %d within quota, got %v", i, err)
+        }
+    }
+    if _, ok := meter.Request(free, "ETHUSDT", day).(*QuotaError); !ok {
+        t.Error("Expected the third request to exceed the quota")
+    }
+    if err := meter.Message(free, AllFeeds, day); err != nil {
+        t.Errorf("Expected messages to be metered separately, got %v", err)
+    }
+    // Quotas reset at UTC midnight
+    if err := meter.Request(free, "ETHUSDT", day.Add(12*time.Hour)); err != nil {
+        t.Errorf("Expected the quota to reset the next day, got %v", err)
+    }
+    if err := meter.Request(acme, "BTCUSDT", day); err != nil {
+        t.Errorf("Expected unlimited consumers to be allowed, got %v", err)
+    }
+
+    records := meter.Records("free", day, day)
+    if len(records) != 2 || records[0].Feed != "*" || records[0].Messages != 1 || records[1].Feed != "ETHUSDT" || records[1].Requests != 2 {
+        t.Errorf("Unexpected records: %+v", records)
+    }
+    if left := meter.Remaining(free, day); left.RequestsPerDay != 0 || left.MessagesPerDay != 0 {
+        t.Errorf("Expected no quota left, got %+v", left)
+    }
+
+    var buf bytes.Buffer
+    if err := WriteCSV(&buf, meter.Records("", day, day)); err != nil {
+        t.Fatalf("Failed to export: %v", err)
+    }
+    want := "day,consumer,feed,requests,messages\n2024-03-01,acme,BTCUSDT,1,0\n2024-03-01,free,*,0,1\n2024-03-01,free,ETHUSDT,2,0\n"
+    if buf.String() != want {
+        t.Errorf("Unexpected export:\n%s", buf.String())
+    }
+}
+
+func TestNewMeterRequiresKeys(t *testing.T) {
+    config := &Config{Enabled: true, Consumers: []*Consumer{{Name: "acme", KeyEnv: "TEST_UNSET_KEY"}}}
+    if _, err := NewMeter(config); err == nil {
+        t.Error("Expected an error for a consumer without a key")
+    }
+}
+
+func TestPrivateFeeds(t *testing.T) {
+    t.Setenv("TEST_ACME_KEY", "acme-key")
+    t.Setenv("TEST_FREE_KEY", "free-key")
+    config := &Config{
+        Consumers: []*Consumer{
+            {Name: "acme", KeyEnv: "TEST_ACME_KEY"},
+            {Name: "free", KeyEnv: "TEST_FREE_KEY"},
+        },
+        PrivateFeeds: map[string][]string{"ACMEINDEX": {"acme"}},
+    }
+    if err := config.Validate(); err != nil {
+        t.Fatalf("Expected valid config, got %v", err)
+    }
+    meter, err := NewMeter(config)
+    if err != nil {
+        t.Fatalf("Failed to create meter: %v", err)
+    }
+    if meter.Enabled() {
+        t.Error("Expected private feeds not to enable metering")
+    }
+
+    // Keys authenticate for private feeds even without metering
+    acme, free := meter.Authenticate("acme-key"), meter.Authenticate("free-key")
+    if acme == nil || free == nil {
+        t.Fatal("Expected consumers of private feeds to authenticate")
+    }
+    if !meter.Private("ACMEINDEX") || meter.Private("ETHUSDT") {
+        t.Error("Expected only ACMEINDEX to be private")
+    }
+    if err := meter.Authorize(acme, "ACMEINDEX"); err != nil {
+        t.Errorf("Expected acme to read its feed, got %v", err)
+    }
+    if _, ok := meter.Authorize(free, "ACMEINDEX").(*PrivateFeedError); !ok {
+        t.Error("Expected other consumers to be refused")
+    }
+    if _, ok := meter.Authorize(nil, "ACMEINDEX").(*PrivateFeedError); !ok {
+        t.Error("Expected anonymous callers to be refused")
+    }
+    if err := meter.Authorize(nil, "ETHUSDT"); err != nil {
+        t.Errorf("Expected public feeds to stay open, got %v", err)
+    }
+
+    // Metered requests are checked against the ACL too
+    if _, ok := meter.Request(free, "ACMEINDEX", time.Now()).(*PrivateFeedError); !ok {
+        t.Error("Expected a metered request for another consumer's feed to be refused")
+    }
+
+    // Feeds computed from a private feed inherit its restriction
+    meter.SetInputs(func(feed string) []string {
+        return map[string][]string{"ACMEINDEXUSD": {"ACMEINDEX", "USDTUSD"}, "ACMEVOL": {"ACMEINDEXUSD"}}[feed]
+    })
+    if !meter.Private("ACMEVOL") || meter.Private("USDTUSD") {
+        t.Error("Expected only feeds computed from ACMEINDEX to inherit its restriction")
+    }
+    if !meter.Allowed(acme, "ACMEVOL") || meter.Allowed(free, "ACMEVOL") || meter.Allowed(nil, "ACMEINDEXUSD") {
+        t.Error("Expected feeds computed from ACMEINDEX to be readable by acme only")
+    }
+
+    config.PrivateFeeds["OTHER"] = []string{"nobody"}
+    if err := config.Validate(); err == nil {
+        t.Error("Expected error for an unknown consumer, got nil")
+    }
+}
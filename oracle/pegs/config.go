@@ -0,0 +1,101 @@
+package pegs
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/symbols"
+)
+
+// Peg is a wrapped or bridged asset whose chain-local DEX price is compared
+// to its canonical price. The peg's rate is
+//
+//	pool price * counterFeed / canonical
+//
+// and is 1 while the asset holds its peg.
+type Peg struct {
+    Chain    string `json:"chain"`
+    Exchange string `json:"exchange"` // DEX in the base config, for its protocol
+    Pool     string `json:"pool"`
+    // Asset is the wrapped asset, a symbol of the address book or a token
+    // address; the pool price is of Asset in the pool's other token
+    Asset string `json:"asset"`
+    // CounterFeed prices the pool's other token in the canonical feed's
+    // quote, e.g. ETHUSDT for a WBTC/WETH pool; empty when they match
+    CounterFeed string `json:"counterFeed,omitempty"`
+    // Canonical is the feed of the underlying asset, e.g. BTCUSDT; empty
+    // when the asset is pegged to the pool's other token, as bridged
+    // USDC variants are to native USDC
+    Canonical string `json:"canonical,omitempty"`
+    // ToleranceBps overrides the default tolerance for this peg
+    ToleranceBps float64 `json:"toleranceBps,omitempty"`
+}
+
+// Config configures peg monitoring
+type Config struct {
+    Enabled         bool    `json:"enabled"`
+    IntervalSeconds int     `json:"intervalSeconds,omitempty"`
+    ToleranceBps    float64 `json:"toleranceBps,omitempty"`
+    // MaxAgeSeconds skips pegs whose feeds are older than this
+    MaxAgeSeconds int `json:"maxAgeSeconds,omitempty"`
+    // Pegs are keyed by the symbol of their peg-rate feed
+    Pegs map[string]*Peg `json:"pegs"`
+}
+
+// LoadConfig loads pegs/pegs.json from the config directory. A missing or
+// disabled file monitors no pegs.
+func LoadConfig(configDir string) (*Config, error) {
+    data, err := os.ReadFile(filepath.Join(configDir, "pegs", "pegs.json"))
+    if os.IsNotExist(err) {
+        return &Config{Pegs: map[string]*Peg{}}, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read pegs config: %v", err)
+    }
+
+    var config Config
+    if err := json.Unmarshal(data, &config); err != nil {
+        return nil, fmt.Errorf("failed to parse pegs config: %v", err)
+    }
+    if config.Pegs == nil || !config.Enabled {
+        config.Pegs = map[string]*Peg{}
+    }
+    return &config, nil
+}
+
+// Validate checks every peg against the base config and the known feeds;
+// peg symbols must not clash with them
+func (c *Config) Validate(base *common.BaseConfig, feeds map[string]bool) error {
+    if c.ToleranceBps < 0 || c.MaxAgeSeconds < 0 {
+        return fmt.Errorf("peg tolerance and max age must not be negative")
+    }
+    for symbol, peg := range c.Pegs {
+        if feeds[symbol] {
+            return fmt.Errorf("peg %s clashes with an existing feed", symbol)
+        }
+        if _, ok := base.Chains[peg.Chain]; !ok {
+            return fmt.Errorf("peg %s references unknown chain %s", symbol, peg.Chain)
+        }
+        if _, ok := base.Exchanges.DEX[peg.Exchange]; !ok {
+            return fmt.Errorf("peg %s references unknown DEX %s", symbol, peg.Exchange)
+        }
+        if peg.Pool == "" {
+            return fmt.Errorf("peg %s requires a pool", symbol)
+        }
+        if _, err := symbols.New(base).Address(peg.Chain, peg.Asset); err != nil {
+            return fmt.Errorf("peg %s: %v", symbol, err)
+        }
+        for _, feed := range []string{peg.CounterFeed, peg.Canonical} {
+            if feed != "" && !feeds[feed] {
+                return fmt.Errorf("peg %s references unknown feed %s", symbol, feed)
+            }
+        }
+        if peg.ToleranceBps < 0 {
+            return fmt.Errorf("peg %s has a negative tolerance", symbol)
+        }
+    }
+    return nil
+}
@@ -0,0 +1,295 @@
+package pegs
+
+import (
+    "context"
+    "fmt"
+    "math"
+    "net/http"
+    "sort"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+    "yetaXYZ/oracle/evm"
+    "yetaXYZ/oracle/sources/dex"
+    "yetaXYZ/oracle/store"
+    "yetaXYZ/oracle/symbols"
+)
+
+// Defaults applied when the config leaves them unset
+const (
+    defaultToleranceBps = 100
+    defaultInterval     = 30 * time.Second
+    poolTimeout         = 10 * time.Second
+)
+
+// Result is the latest check of one peg
+type Result struct {
+    Symbol    string  `json:"symbol"`
+    Chain     string  `json:"chain"`
+    Asset     string  `json:"asset"`
+    PoolPrice float64 `json:"poolPrice,omitempty"`
+    Rate      float64 `json:"rate,omitempty"`
+    // DeviationBps is signed: negative when the asset trades below its peg
+    DeviationBps float64   `json:"deviationBps"`
+    ToleranceBps float64   `json:"toleranceBps"`
+    Breached     bool      `json:"breached"`
+    Skipped      string    `json:"skipped,omitempty"` // why the peg could not be checked
+    CheckedAt    time.Time `json:"checkedAt"`
+}
+
+// PoolPriceFunc reads the price of a peg's asset from its pool
+type PoolPriceFunc func(ctx context.Context, peg *Peg) (float64, error)
+
+// Monitor compares wrapped assets' DEX prices on each chain with their
+// canonical feeds, publishing the rates as feeds and alerting on depegs
+type Monitor struct {
+    config    *Config
+    base      *common.BaseConfig
+    store     store.Store
+    bus       *events.Bus
+    client    *http.Client
+    poolPrice PoolPriceFunc
+
+    readersMu sync.Mutex
+    readers   map[string]*evm.PoolReader
+
+    mu      sync.RWMutex
+    results map[string]*Result
+    latest  map[string]*common.AggregateResult
+}
+
+// NewMonitor creates a peg monitor reading pools over the chains' RPC
+// endpoints and feed values from the store
+func NewMonitor(config *Config, base *common.BaseConfig, s store.Store, bus *events.Bus, client *http.Client) *Monitor {
+    m := &Monitor{
+        config:  config,
+        base:    base,
+        store:   s,
+        bus:     bus,
+        client:  client,
+        readers: make(map[string]*evm.PoolReader),
+        results: make(map[string]*Result),
+        latest:  make(map[string]*common.AggregateResult),
+    }
+    m.poolPrice = m.readPool
+    return m
+}
+
+// Interval returns the configured check interval
+func (m *Monitor) Interval() time.Duration {
+    if m.config.IntervalSeconds > 0 {
+        return time.Duration(m.config.IntervalSeconds) * time.Second
+    }
+    return defaultInterval
+}
+
+// Run checks all pegs at interval until ctx is cancelled
+func (m *Monitor) Run(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            m.CheckAll(ctx, time.Now())
+        }
+    }
+}
+
+// CheckAll checks every peg, publishes each rate as a round of the peg's
+// feed and alerts when a peg newly breaches or recovers its tolerance
+func (m *Monitor) CheckAll(ctx context.Context, now time.Time) []*Result {
+    results := make([]*Result, 0, len(m.config.Pegs))
+    for _, symbol := range m.Symbols() {
+        peg := m.config.Pegs[symbol]
+        result := m.check(ctx, symbol, peg, now)
+
+        m.mu.Lock()
+        previous := m.results[symbol]
+        m.results[symbol] = result
+        var round *common.AggregateResult
+        if result.Skipped == "" {
+            var roundID uint64 = 1
+            if last := m.latest[symbol]; last != nil {
+                roundID = last.RoundID + 1
+            }
+            round = &common.AggregateResult{
+                Symbol:     symbol,
+                PricePoint: common.PricePoint{Price: result.Rate, Timestamp: now},
+                Sources: []common.SourcePrice{{
+                    Source:     peg.Exchange + ":" + peg.Pool,
+                    PricePoint: common.PricePoint{Price: result.PoolPrice, Timestamp: now},
+                }},
+                RoundID: roundID,
+            }
+            m.latest[symbol] = round
+        }
+        m.mu.Unlock()
+
+        if round != nil {
+            m.bus.Publish(events.Event{Type: events.Aggregate, Symbol: symbol, Timestamp: now, Payload: round})
+        }
+        wasBreached := previous != nil && previous.Breached
+        switch {
+        case result.Breached && !wasBreached:
+            m.alert(symbol, events.SeverityWarning, "peg_deviation",
+                fmt.Sprintf("%s on chain %s at %.6f of its peg (%+.1fbp, tolerance %.1fbp)", peg.Asset, peg.Chain, result.Rate, result.DeviationBps, result.ToleranceBps))
+        case wasBreached && result.Skipped == "" && !result.Breached:
+            m.alert(symbol, events.SeverityInfo, "peg_restored",
+                fmt.Sprintf("%s on chain %s back within %.1fbp of its peg", peg.Asset, peg.Chain, result.ToleranceBps))
+        }
+        results = append(results, result)
+    }
+    return results
+}
+
+// check computes the rate of a peg
+func (m *Monitor) check(ctx context.Context, symbol string, peg *Peg, now time.Time) *Result {
+    result := &Result{Symbol: symbol, Chain: peg.Chain, Asset: peg.Asset, ToleranceBps: m.tolerance(peg), CheckedAt: now}
+
+    counter, canonical := 1.0, 1.0
+    var err error
+    if peg.CounterFeed != "" {
+        if counter, err = m.fresh(peg.CounterFeed, now); err != nil {
+            result.Skipped = err.Error()
+            return result
+        }
+    }
+    if peg.Canonical != "" {
+        if canonical, err = m.fresh(peg.Canonical, now); err != nil {
+            result.Skipped = err.Error()
+            return result
+        }
+    }
+
+    poolCtx, cancel := context.WithTimeout(ctx, poolTimeout)
+    defer cancel()
+    price, err := m.poolPrice(poolCtx, peg)
+    if err != nil {
+        result.Skipped = fmt.Sprintf("pool read failed: %v", err)
+        return result
+    }
+    if price <= 0 {
+        result.Skipped = "pool has no positive price"
+        return result
+    }
+
+    result.PoolPrice = price
+    result.Rate = price * counter / canonical
+    result.DeviationBps = (result.Rate - 1) * 10000
+    result.Breached = math.Abs(result.DeviationBps) > result.ToleranceBps
+    return result
+}
+
+// readPool reads a peg's pool on its chain, oriented to the peg's asset
+func (m *Monitor) readPool(ctx context.Context, peg *Peg) (float64, error) {
+    asset, err := symbols.New(m.base).Address(peg.Chain, peg.Asset)
+    if err != nil {
+        return 0, err
+    }
+    reader, err := m.reader(peg.Chain)
+    if err != nil {
+        return 0, err
+    }
+    if dex.Protocol(peg.Exchange, m.base.Exchanges.DEX[peg.Exchange]) == dex.ProtocolUniswapV2 {
+        return reader.V2Price(ctx, peg.Pool, asset)
+    }
+    return reader.V3Price(ctx, peg.Pool, asset)
+}
+
+// reader returns the pool reader of a chain, creating it on first use
+func (m *Monitor) reader(chainID string) (*evm.PoolReader, error) {
+    m.readersMu.Lock()
+    defer m.readersMu.Unlock()
+    if reader, ok := m.readers[chainID]; ok {
+        return reader, nil
+    }
+    chain, ok := m.base.Chains[chainID]
+    if !ok || len(chain.RPCUrls) == 0 {
+        return nil, fmt.Errorf("no RPC endpoint configured for chain %s", chainID)
+    }
+    reader := evm.NewPoolReader(evm.NewChainClient(chainID, chain.RPCUrls, m.client))
+    m.readers[chainID] = reader
+    return reader, nil
+}
+
+// fresh returns the latest positive price of a feed no older than the max age
+func (m *Monitor) fresh(symbol string, now time.Time) (float64, error) {
+    return store.Fresh(m.store, symbol, time.Duration(m.config.MaxAgeSeconds)*time.Second, now)
+}
+
+// tolerance returns the tolerance of a peg in basis points
+func (m *Monitor) tolerance(peg *Peg) float64 {
+    if peg.ToleranceBps > 0 {
+        return peg.ToleranceBps
+    }
+    if m.config.ToleranceBps > 0 {
+        return m.config.ToleranceBps
+    }
+    return defaultToleranceBps
+}
+
+// alert publishes a peg alert
+func (m *Monitor) alert(symbol, severity, kind, message string) {
+    m.bus.Publish(events.Event{
+        Type:      events.Alert,
+        Symbol:    symbol,
+        Timestamp: time.Now(),
+        Payload:   &events.AlertPayload{Severity: severity, Kind: kind, Message: message},
+    })
+}
+
+// Symbols returns the peg feed symbols, sorted
+func (m *Monitor) Symbols() []string {
+    symbols := make([]string, 0, len(m.config.Pegs))
+    for symbol := range m.config.Pegs {
+        symbols = append(symbols, symbol)
+    }
+    sort.Strings(symbols)
+    return symbols
+}
+
+// IsPeg reports whether symbol is a peg-rate feed
+func (m *Monitor) IsPeg(symbol string) bool {
+    _, ok := m.config.Pegs[symbol]
+    return ok
+}
+
+// Inputs returns the DEX and the feeds a peg's rate is computed from
+func (m *Monitor) Inputs(symbol string) []string {
+    peg, ok := m.config.Pegs[symbol]
+    if !ok {
+        return nil
+    }
+    inputs := []string{peg.Exchange}
+    for _, feed := range []string{peg.CounterFeed, peg.Canonical} {
+        if feed != "" {
+            inputs = append(inputs, feed)
+        }
+    }
+    return inputs
+}
+
+// Latest returns the latest rate round of a peg
+func (m *Monitor) Latest(symbol string) (*common.AggregateResult, bool) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+    result, ok := m.latest[symbol]
+    return result, ok
+}
+
+// Results returns the latest check of every peg sorted by symbol
+func (m *Monitor) Results() []*Result {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+    out := make([]*Result, 0, len(m.results))
+    for _, r := range m.results {
+        out = append(out, r)
+    }
+    sort.Slice(out, func(i, j int) bool { return out[i].Symbol < out[j].Symbol })
+    return out
+}
@@ -0,0 +1,57 @@
+package pegs
+
+import (
+    "context"
+    "math"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+    "yetaXYZ/oracle/store"
+)
+
+func TestPegDeviationAlertsAndRecovers(t *testing.T) {
+    now := time.Now()
+    s := store.NewMemoryStore()
+    s.SaveRound(&common.AggregateResult{Symbol: "BTCUSDT", PricePoint: common.PricePoint{Price: 60000, Timestamp: now}})
+    s.SaveRound(&common.AggregateResult{Symbol: "ETHUSDT", PricePoint: common.PricePoint{Price: 3000, Timestamp: now}})
+
+    bus := events.NewBus()
+    alerts := bus.Subscribe(10, events.Alert)
+    monitor := NewMonitor(&Config{
+        ToleranceBps: 100,
+        Pegs: map[string]*Peg{
+            // WBTC priced in WETH, converted with ETHUSDT
+            "WBTCBTC": {Chain: "1", Exchange: "uniswap_v3", Pool: "0xpool", Asset: "WBTC", CounterFeed: "ETHUSDT", Canonical: "BTCUSDT"},
+        },
+    }, &common.BaseConfig{}, s, bus, nil)
+
+    poolPrice := 19.9 // WETH per WBTC: 0.995 of peg
+    monitor.poolPrice = func(ctx context.Context, peg *Peg) (float64, error) { return poolPrice, nil }
+
+    result := monitor.CheckAll(context.Background(), now)[0]
+    if math.Abs(result.Rate-0.995) > 1e-9 || result.Breached {
+        t.Fatalf("Expected rate 0.995 within tolerance, got %+v", result)
+    }
+    if round, ok := monitor.Latest("WBTCBTC"); !ok || round.RoundID != 1 || math.Abs(round.Price-0.995) > 1e-9 {
+        t.Errorf("Expected the rate as round 1 of the peg feed, got %+v", round)
+    }
+
+    poolPrice = 19.5 // 0.975: 250bp below peg
+    result = monitor.CheckAll(context.Background(), now)[0]
+    if !result.Breached || result.DeviationBps > -249 {
+        t.Fatalf("Expected a breach 250bp below peg, got %+v", result)
+    }
+    monitor.CheckAll(context.Background(), now)
+    poolPrice = 20
+    monitor.CheckAll(context.Background(), now)
+
+    var kinds []string
+    for len(alerts.C) > 0 {
+        kinds = append(kinds, (<-alerts.C).Payload.(*events.AlertPayload).Kind)
+    }
+    if len(kinds) != 2 || kinds[0] != "peg_deviation" || kinds[1] != "peg_restored" {
+        t.Errorf("Expected one deviation then one restored alert, got %v", kinds)
+    }
+}
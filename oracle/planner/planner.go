@@ -0,0 +1,444 @@
+package planner
+
+import (
+    "fmt"
+    "math"
+    "net/url"
+    "sort"
+    "unsafe"
+
+    "yetaXYZ/oracle/analytics"
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/costs"
+    "yetaXYZ/oracle/credentials"
+    "yetaXYZ/oracle/scheduler"
+    "yetaXYZ/oracle/sources/crypto"
+    "yetaXYZ/oracle/store"
+)
+
+// Estimates of what a plan cannot read from the configuration
+const (
+    // poolCalls are the eth_calls of a pool read: token0, token1 and the
+    // reserves or slot0; token decimals are cached after the first round
+    poolCalls = 3
+    // stringBytes is the assumed size of the symbol, source names and
+    // config version strings a stored round holds
+    stringBytes = 32
+    // headroomWarn is the rate limit headroom below which a host is
+    // reported
+    headroomWarn = 0.2
+    // daysPerMonth converts daily spend to monthly
+    daysPerMonth = 30
+)
+
+// Input is the configuration a plan is made for
+type Input struct {
+    Base  *common.BaseConfig
+    Pairs map[string]*common.PairConfig
+    // Costs and Store may be nil for deployments without them
+    Costs *costs.Config
+    Store *store.Config
+}
+
+// Plan is the expected load and footprint of a configuration. Rates assume
+// feeds run around the clock and that no upstream response is shared
+// through the response cache, so they are upper bounds of steady state.
+type Plan struct {
+    Pairs     int        `json:"pairs"`
+    Scheduled int        `json:"scheduled"`
+    OnDemand  int        `json:"onDemand"`
+    Hosts     []HostLoad `json:"hosts"`
+    Spend     []Spend    `json:"spend,omitempty"`
+    Currency  string     `json:"currency,omitempty"`
+    Memory    Memory     `json:"memory"`
+    Warnings  []string   `json:"warnings,omitempty"`
+}
+
+// HostLoad is the outbound request rate to one upstream host
+type HostLoad struct {
+    Host    string   `json:"host"`
+    Sources []string `json:"sources"`
+    Pairs   int      `json:"pairs"`
+    // RequestsPerMinute is the steady rate of the primary sources;
+    // PeakPerMinute adds fallback tiers and REST fetches of exchanges
+    // streaming order books, as while every fallback is in use
+    RequestsPerMinute float64 `json:"requestsPerMinute"`
+    PeakPerMinute     float64 `json:"peakPerMinute"`
+    // RateLimit is the configured requests per minute; zero when unknown
+    RateLimit int `json:"rateLimit,omitempty"`
+    // Headroom is the fraction of the rate limit left at peak; negative
+    // when the peak exceeds it
+    Headroom *float64 `json:"headroom,omitempty"`
+}
+
+// Spend is the estimated consumption of a paid source
+type Spend struct {
+    Source         string  `json:"source"`
+    RequestsPerDay float64 `json:"requestsPerDay"`
+    CreditsPerDay  float64 `json:"creditsPerDay,omitempty"`
+    CostPerDay     float64 `json:"costPerDay"`
+    CostPerMonth   float64 `json:"costPerMonth"`
+    DailyBudget    float64 `json:"dailyBudget,omitempty"`
+    MonthlyBudget  float64 `json:"monthlyBudget,omitempty"`
+}
+
+// Memory is the estimated memory held by the history of the feeds
+type Memory struct {
+    Feeds int `json:"feeds"` // pairs and their bid/ask sub-feeds
+    // RoundsPerDay are the rounds all feeds aggregate in a day
+    RoundsPerDay float64 `json:"roundsPerDay"`
+    // StoredRounds are the rounds and candles held once retention is
+    // reached
+    StoredRounds float64 `json:"storedRounds"`
+    StoreBytes   float64 `json:"storeBytes"`
+    RingBytes    float64 `json:"ringBytes"`
+    TotalBytes   float64 `json:"totalBytes"`
+    // Unbounded is set when a resolution is kept indefinitely, and
+    // GrowthBytesPerDay is then how much the store grows every day
+    Unbounded         bool    `json:"unbounded,omitempty"`
+    GrowthBytesPerDay float64 `json:"growthBytesPerDay,omitempty"`
+}
+
+// hostLoad accumulates the load of one host
+type hostLoad struct {
+    sources map[string]bool
+    pairs   map[string]bool
+    steady  float64
+    peak    float64
+}
+
+// WithCopies returns the input with n more pairs configured like symbol,
+// named SYMBOL#1 to SYMBOL#n, to plan for onboarding pairs of its kind
+func (in Input) WithCopies(symbol string, n int) (Input, error) {
+    pair, ok := in.Pairs[symbol]
+    if !ok {
+        return in, fmt.Errorf("unknown pair %s", symbol)
+    }
+    if n < 0 {
+        return in, fmt.Errorf("the number of copies must not be negative")
+    }
+    pairs := make(map[string]*common.PairConfig, len(in.Pairs)+n)
+    for s, p := range in.Pairs {
+        pairs[s] = p
+    }
+    for i := 1; i <= n; i++ {
+        pairs[fmt.Sprintf("%s#%d", symbol, i)] = pair
+    }
+    in.Pairs = pairs
+    return in, nil
+}
+
+// Build estimates the load and footprint of the configuration
+func Build(in Input) *Plan {
+    plan := &Plan{Pairs: len(in.Pairs)}
+    hosts := make(map[string]*hostLoad)
+    add := func(rawURL, source, symbol string, perMinute float64, steady bool) {
+        host := hostOf(rawURL)
+        if host == "" {
+            plan.Warnings = append(plan.Warnings, fmt.Sprintf("pair %s: no URL for %s", symbol, source))
+            return
+        }
+        load, ok := hosts[host]
+        if !ok {
+            load = &hostLoad{sources: make(map[string]bool), pairs: make(map[string]bool)}
+            hosts[host] = load
+        }
+        load.sources[source] = true
+        load.pairs[symbol] = true
+        load.peak += perMinute
+        if steady {
+            load.steady += perMinute
+        }
+    }
+
+    symbols := make([]string, 0, len(in.Pairs))
+    for symbol := range in.Pairs {
+        symbols = append(symbols, symbol)
+    }
+    sort.Strings(symbols)
+
+    var feeds, stored, growth, perDay float64
+    unbounded := false
+    for _, symbol := range symbols {
+        pair := in.Pairs[symbol]
+        if pair.OnDemand != nil {
+            plan.OnDemand++
+        } else {
+            plan.Scheduled++
+        }
+        rounds := roundsPerMinute(pair)
+        samples := float64(pair.Aggregation.Samples())
+        addTier(in.Base, symbol, pair.Sources, rounds*samples, true, add)
+        for _, tier := range pair.FallbackTiers {
+            addTier(in.Base, symbol, tier, rounds*samples, false, add)
+        }
+
+        // The bid, mid and ask sub-feeds are stored like the pair
+        n := 1.0
+        if pair.BidAsk != nil {
+            n += 3
+        }
+        feeds += n
+        history, grows, open := retained(in.Store, rounds*24*60, roundBytes(symbol, sourceCount(pair.Sources)), candleBytes(symbol))
+        stored += n * history.rounds
+        plan.Memory.StoreBytes += n * history.bytes
+        growth += n * grows
+        perDay += n * rounds * 24 * 60
+        unbounded = unbounded || open
+    }
+
+    ordered := make([]string, 0, len(hosts))
+    for host := range hosts {
+        ordered = append(ordered, host)
+    }
+    sort.Strings(ordered)
+    plan.Hosts = make([]HostLoad, 0, len(hosts))
+    for _, host := range ordered {
+        load := hosts[host]
+        h := HostLoad{
+            Host:              host,
+            Sources:           names(load.sources),
+            Pairs:             len(load.pairs),
+            RequestsPerMinute: round2(load.steady),
+            PeakPerMinute:     round2(load.peak),
+            RateLimit:         rateLimit(in.Base, host),
+        }
+        if h.RateLimit > 0 {
+            headroom := round2(1 - load.peak/float64(h.RateLimit))
+            h.Headroom = &headroom
+            switch {
+            case load.steady > float64(h.RateLimit):
+                plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s: %.0f requests/min exceeds its rate limit of %d", host, load.steady, h.RateLimit))
+            case headroom < 0:
+                plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s: %.0f requests/min at peak exceeds its rate limit of %d", host, load.peak, h.RateLimit))
+            case headroom < headroomWarn:
+                plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s: only %.0f%% of its rate limit left at peak", host, headroom*100))
+            }
+        }
+        plan.Hosts = append(plan.Hosts, h)
+    }
+
+    if in.Costs != nil {
+        plan.Currency = in.Costs.Currency
+        plan.Spend = spend(in.Base, in.Costs, hosts)
+        for _, s := range plan.Spend {
+            if s.DailyBudget > 0 && s.CostPerDay > s.DailyBudget {
+                plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s: %.2f %s a day exceeds its daily budget of %.2f", s.Source, s.CostPerDay, plan.Currency, s.DailyBudget))
+            }
+            if s.MonthlyBudget > 0 && s.CostPerMonth > s.MonthlyBudget {
+                plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s: %.2f %s a month exceeds its monthly budget of %.2f", s.Source, s.CostPerMonth, plan.Currency, s.MonthlyBudget))
+            }
+        }
+    }
+
+    ringSize := analytics.DefaultRingSize
+    if in.Store != nil && in.Store.RingSize > 0 {
+        ringSize = in.Store.RingSize
+    }
+    plan.Memory.Feeds = int(feeds)
+    plan.Memory.RoundsPerDay = math.Round(perDay)
+    plan.Memory.StoredRounds = math.Round(stored)
+    plan.Memory.StoreBytes = math.Round(plan.Memory.StoreBytes)
+    plan.Memory.RingBytes = feeds * float64(ringSize) * float64(unsafe.Sizeof(analytics.Sample{}))
+    plan.Memory.TotalBytes = plan.Memory.StoreBytes + plan.Memory.RingBytes
+    if unbounded {
+        plan.Memory.Unbounded = true
+        plan.Memory.GrowthBytesPerDay = math.Round(growth)
+        plan.Warnings = append(plan.Warnings, fmt.Sprintf("store retention keeps history indefinitely, growing about %.0f bytes a day", growth))
+    }
+    return plan
+}
+
+// roundsPerMinute is how often a pair aggregates: its schedule, or for an
+// on-demand pair queried continuously, once per TTL within its hourly cap
+func roundsPerMinute(pair *common.PairConfig) float64 {
+    if pair.OnDemand == nil {
+        return 60 / scheduler.Interval(pair).Seconds()
+    }
+    rate := 60 / pair.OnDemand.TTL().Seconds()
+    if capped := float64(pair.OnDemand.MaxRoundsPerHour) / 60; pair.OnDemand.MaxRoundsPerHour > 0 && capped < rate {
+        rate = capped
+    }
+    return rate
+}
+
+// addTier adds the requests of a tier's sources fetched perMinute times a
+// minute. Exchanges streaming order books are fetched over REST only while
+// their books are stale, so they count toward the peak alone.
+func addTier(base *common.BaseConfig, symbol string, sources common.SourcesConfig, perMinute float64, steady bool, add func(rawURL, source, symbol string, perMinute float64, steady bool)) {
+    if sources.CEX.Enabled {
+        for _, exchange := range sources.CEX.Exchanges {
+            streamed := base.Exchanges.CEX[exchange].OrderBook != nil
+            add(crypto.ExchangeURL(base, exchange), exchange, symbol, perMinute, steady && !streamed)
+        }
+    }
+    if !sources.DEX.Enabled {
+        return
+    }
+    for _, pool := range sources.DEX.Pools {
+        // Calls rotate across the chain's RPC endpoints
+        rpcs := base.Chains[pool.Chain].RPCUrls
+        if len(rpcs) == 0 {
+            add("", "chain "+pool.Chain, symbol, 0, steady)
+            continue
+        }
+        for _, rpc := range rpcs {
+            add(rpc, "chain "+pool.Chain, symbol, perMinute*poolCalls/float64(len(rpcs)), steady)
+        }
+    }
+    for _, subgraph := range sources.DEX.Subgraphs {
+        add(base.Exchanges.DEX[subgraph.Exchange].Endpoint, subgraph.Exchange, symbol, perMinute, steady)
+    }
+}
+
+// sourceCount is the number of primary sources a round records
+func sourceCount(sources common.SourcesConfig) int {
+    n := 0
+    if sources.CEX.Enabled {
+        n += len(sources.CEX.Exchanges)
+    }
+    if sources.DEX.Enabled {
+        n += len(sources.DEX.Pools) + len(sources.DEX.Subgraphs)
+    }
+    return n
+}
+
+// roundBytes estimates the memory of a raw round recording sources prices
+func roundBytes(symbol string, sources int) float64 {
+    round := unsafe.Sizeof(common.AggregateResult{}) + uintptr(len(symbol)) + stringBytes
+    source := unsafe.Sizeof(common.SourcePrice{}) + stringBytes
+    return float64(round) + float64(sources)*float64(source)
+}
+
+// candleBytes estimates the memory of a candle, which drops source prices
+func candleBytes(symbol string) float64 {
+    return float64(unsafe.Sizeof(common.AggregateResult{}) + unsafe.Sizeof(common.Candle{}) + uintptr(len(symbol)) + stringBytes)
+}
+
+// held are the rounds and bytes of one feed's history
+type held struct {
+    rounds float64
+    bytes  float64
+}
+
+// retained returns the history one feed aggregating perDay rounds a day
+// holds once retention is reached, with raw and candle the bytes of a
+// round and of a candle. A resolution kept indefinitely holds one day of
+// history, returned with what it adds every day.
+func retained(config *store.Config, perDay, raw, candle float64) (held, float64, bool) {
+    var r store.Retention
+    if config != nil {
+        r = config.Retention
+    }
+    levels := []struct {
+        until  int // days of history held once this level is passed
+        perDay float64
+        bytes  float64
+    }{
+        {r.RawDays, perDay, raw},
+        {r.MinuteDays, math.Min(perDay, 24*60), candle},
+        {r.HourDays, math.Min(perDay, 24), candle},
+    }
+    var h held
+    from := 0
+    for _, level := range levels {
+        if level.until == 0 {
+            h.rounds += level.perDay
+            h.bytes += level.perDay * level.bytes
+            return h, level.perDay * level.bytes, true
+        }
+        days := float64(level.until - from)
+        h.rounds += days * level.perDay
+        h.bytes += days * level.perDay * level.bytes
+        from = level.until
+    }
+    return h, 0, false
+}
+
+// spend estimates the consumption of every paid source from the steady
+// load of its hosts
+func spend(base *common.BaseConfig, config *costs.Config, hosts map[string]*hostLoad) []Spend {
+    paid := make([]string, 0, len(config.Sources))
+    for name := range config.Sources {
+        paid = append(paid, name)
+    }
+    sort.Strings(paid)
+    out := make([]Spend, 0, len(paid))
+    for _, name := range paid {
+        cost := config.Sources[name]
+        billed := cost.Hosts
+        if len(billed) == 0 {
+            billed = sourceHosts(base, name)
+        }
+        var perMinute float64
+        for _, host := range billed {
+            if load, ok := hosts[host]; ok {
+                perMinute += load.steady
+            }
+        }
+        s := Spend{
+            Source:         name,
+            RequestsPerDay: math.Round(perMinute * 24 * 60),
+            DailyBudget:    cost.DailyBudget,
+            MonthlyBudget:  cost.MonthlyBudget,
+        }
+        switch cost.Model {
+        case costs.ModelRequest:
+            s.CostPerDay = s.RequestsPerDay * cost.CostPerRequest
+        case costs.ModelCredits:
+            s.CreditsPerDay = s.RequestsPerDay * cost.CreditsPerRequest
+            s.CostPerDay = s.CreditsPerDay * cost.CostPerCredit
+        }
+        s.CostPerDay = round2(s.CostPerDay)
+        s.CostPerMonth = round2(s.CostPerDay * daysPerMonth)
+        out = append(out, s)
+    }
+    return out
+}
+
+// sourceHosts returns the hosts of the exchange or subgraph of that name,
+// the hosts a paid source is billed for by default
+func sourceHosts(base *common.BaseConfig, name string) []string {
+    var out []string
+    if _, ok := base.Exchanges.CEX[name]; ok {
+        out = append(out, hostOf(crypto.ExchangeURL(base, name)))
+    }
+    if dex, ok := base.Exchanges.DEX[name]; ok {
+        out = append(out, hostOf(dex.Endpoint))
+    }
+    return out
+}
+
+// rateLimit returns the rate limit of the exchange served by host
+func rateLimit(base *common.BaseConfig, host string) int {
+    for name, cex := range base.Exchanges.CEX {
+        if cex.RateLimit > 0 && hostOf(crypto.ExchangeURL(base, name)) == host {
+            return cex.RateLimit
+        }
+    }
+    return 0
+}
+
+// hostOf returns the host of a configured URL, expanding credential
+// references as fetches do
+func hostOf(rawURL string) string {
+    u, err := url.Parse(credentials.Expand(rawURL))
+    if err != nil {
+        return ""
+    }
+    return u.Host
+}
+
+// names returns the keys of a set in order
+func names(m map[string]bool) []string {
+    out := make([]string, 0, len(m))
+    for k := range m {
+        out = append(out, k)
+    }
+    sort.Strings(out)
+    return out
+}
+
+func round2(v float64) float64 {
+    return math.Round(v*100) / 100
+}
@@ -0,0 +1,141 @@
+package planner
+
+import (
+    "strings"
+    "testing"
+    "unsafe"
+
+    "yetaXYZ/oracle/analytics"
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/costs"
+    "yetaXYZ/oracle/store"
+)
+
+func testInput() Input {
+    base := &common.BaseConfig{
+        Exchanges: common.ExchangeConfig{
+            CEX: map[string]common.CEXDetails{
+                "binance": {BaseURL: "https://api.binance.com/api/v3", RateLimit: 1200},
+                "kraken":  {BaseURL: "https://api.kraken.com/0/public", RateLimit: 60, OrderBook: &common.OrderBookStreamConfig{}},
+            },
+            DEX: map[string]common.DEXDetails{
+                "uniswap_v3": {Endpoint: "https://gateway.thegraph.com/api/subgraphs/id/abc"},
+            },
+        },
+        Chains: common.ChainConfig{"1": {RPCUrls: []string{"https://rpc-a.example.com", "https://rpc-b.example.com"}}},
+    }
+    pairs := map[string]*common.PairConfig{
+        "ETHUSD": {
+            UpdateFrequencySeconds: 10,
+            Sources: common.SourcesConfig{
+                CEX: common.CEXSourceConfig{Enabled: true, Exchanges: []string{"binance", "kraken"}},
+                DEX: common.DEXSourceConfig{
+                    Enabled:   true,
+                    Pools:     []common.DEXPool{{Chain: "1", Exchange: "uniswap_v3", Address: "0xpool"}},
+                    Subgraphs: []common.SubgraphPoolSource{{Exchange: "uniswap_v3", TopPools: 3}},
+                },
+            },
+            Aggregation: common.AggregationParams{SamplesPerSource: 2},
+            BidAsk:      &common.BidAskConfig{},
+        },
+        "LINKUSD": {
+            Sources:       common.SourcesConfig{CEX: common.CEXSourceConfig{Enabled: true, Exchanges: []string{"binance"}}},
+            FallbackTiers: []common.SourcesConfig{{CEX: common.CEXSourceConfig{Enabled: true, Exchanges: []string{"kraken"}}}},
+            OnDemand:      &common.OnDemandConfig{TTLSeconds: 30, MaxRoundsPerHour: 60},
+        },
+    }
+    return Input{
+        Base:  base,
+        Pairs: pairs,
+        Costs: &costs.Config{Currency: "USD", Sources: map[string]*costs.SourceCost{
+            "uniswap_v3": {Model: costs.ModelCredits, CreditsPerRequest: 2, CostPerCredit: 0.0001, DailyBudget: 1},
+        }},
+        Store: &store.Config{Retention: store.Retention{RawDays: 1, MinuteDays: 2, HourDays: 3}, RingSize: 10},
+    }
+}
+
+func TestBuild(t *testing.T) {
+    plan := Build(testInput())
+
+    if plan.Pairs != 2 || plan.Scheduled != 1 || plan.OnDemand != 1 {
+        t.Errorf("Expected 1 scheduled and 1 on-demand pair, got %+v", plan)
+    }
+    hosts := make(map[string]HostLoad)
+    for _, h := range plan.Hosts {
+        hosts[h.Host] = h
+    }
+
+    // ETHUSD: 6 rounds a minute with 2 samples; LINKUSD capped at 1 a minute
+    binance := hosts["api.binance.com"]
+    if binance.RequestsPerMinute != 13 || binance.Pairs != 2 || binance.RateLimit != 1200 {
+        t.Errorf("Expected 13 requests/min to binance from 2 pairs, got %+v", binance)
+    }
+    // Kraken streams books and is LINKUSD's fallback: peak only
+    kraken := hosts["api.kraken.com"]
+    if kraken.RequestsPerMinute != 0 || kraken.PeakPerMinute != 13 || kraken.Headroom == nil || *kraken.Headroom != 0.78 {
+        t.Errorf("Expected 13 requests/min to kraken at peak only, got %+v", kraken)
+    }
+    // 3 calls per pool read rotate across both RPC endpoints
+    if rpc := hosts["rpc-a.example.com"]; rpc.RequestsPerMinute != 18 || rpc.Sources[0] != "chain 1" {
+        t.Errorf("Expected half of 36 calls/min on each RPC endpoint, got %+v", rpc)
+    }
+    if subgraph := hosts["gateway.thegraph.com"]; subgraph.RequestsPerMinute != 12 {
+        t.Errorf("Expected 12 subgraph queries/min, got %+v", subgraph)
+    }
+
+    if len(plan.Spend) != 1 {
+        t.Fatalf("Expected the subgraph's spend, got %+v", plan.Spend)
+    }
+    spend := plan.Spend[0]
+    if spend.RequestsPerDay != 12*1440 || spend.CreditsPerDay != 2*12*1440 || spend.CostPerDay != 3.46 || spend.CostPerMonth != 103.8 {
+        t.Errorf("Expected 17280 queries a day costing 3.46, got %+v", spend)
+    }
+    if !hasWarning(plan, "uniswap_v3: 3.46 USD a day exceeds its daily budget") {
+        t.Errorf("Expected the daily budget exceeded, got %v", plan.Warnings)
+    }
+
+    // ETHUSD and its 3 sub-feeds hold a day of raw rounds, a day of 1m and
+    // a day of 1h candles; LINKUSD a day at 1440 rounds a day
+    memory := plan.Memory
+    if memory.Feeds != 5 || memory.StoredRounds != 4*(8640+1440+24)+(1440+1440+24) {
+        t.Errorf("Expected 5 feeds holding their retention, got %+v", memory)
+    }
+    if want := 5 * 10 * float64(unsafe.Sizeof(analytics.Sample{})); memory.RingBytes != want {
+        t.Errorf("Expected %v bytes of rings, got %v", want, memory.RingBytes)
+    }
+    if memory.Unbounded {
+        t.Error("Expected bounded retention")
+    }
+}
+
+func TestBuildWarnings(t *testing.T) {
+    in, err := testInput().WithCopies("ETHUSD", 10)
+    if err != nil {
+        t.Fatal(err)
+    }
+    in.Store = nil
+    plan := Build(in)
+
+    if plan.Pairs != 12 {
+        t.Errorf("Expected 12 pairs with the copies, got %d", plan.Pairs)
+    }
+    if !hasWarning(plan, "api.kraken.com: 133 requests/min at peak exceeds its rate limit of 60") {
+        t.Errorf("Expected kraken's rate limit exceeded at peak, got %v", plan.Warnings)
+    }
+    if !plan.Memory.Unbounded || plan.Memory.GrowthBytesPerDay <= 0 || !hasWarning(plan, "keeps history indefinitely") {
+        t.Errorf("Expected history without retention to grow, got %+v", plan.Memory)
+    }
+
+    if _, err := testInput().WithCopies("BTCUSD", 1); err == nil {
+        t.Error("Expected copies of an unknown pair to be refused")
+    }
+}
+
+func hasWarning(plan *Plan, text string) bool {
+    for _, w := range plan.Warnings {
+        if strings.Contains(w, text) {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,372 @@
+package proposals
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "log"
+    "os"
+    "sort"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/common"
+)
+
+// Proposal statuses
+const (
+    StatusPending    = "pending"    // waiting for approvals or the timelock
+    StatusActive     = "active"     // applied to the running configuration
+    StatusCancelled  = "cancelled"  // withdrawn by an operator
+    StatusExpired    = "expired"    // not activated within the expiry window
+    StatusConflicted = "conflicted" // pair configuration changed since proposed
+    StatusFailed     = "failed"     // rejected when applied
+)
+
+// Proposal is a pending change to one pair's configuration
+type Proposal struct {
+    ID          string             `json:"id"`
+    Symbol      string             `json:"symbol"`
+    Pair        *common.PairConfig `json:"pair"`
+    Reason      string             `json:"reason,omitempty"`
+    Proposer    string             `json:"proposer"`
+    Approvals   []string           `json:"approvals"`
+    BasedOn     string             `json:"basedOn"` // version of the pair's config the change was proposed against
+    Status      string             `json:"status"`
+    Error       string             `json:"error,omitempty"`
+    CreatedAt   time.Time          `json:"createdAt"`
+    ActivatesAt time.Time          `json:"activatesAt"` // earliest activation under the timelock
+    ResolvedAt  time.Time          `json:"resolvedAt,omitempty"`
+}
+
+// Policy decides when a proposal may be activated. Both conditions must
+// hold when both are set.
+type Policy struct {
+    // Approvals is the number of distinct operators other than the
+    // proposer that must approve
+    Approvals int
+    // Timelock is the minimum delay between proposal and activation
+    Timelock time.Duration
+    // Expiry discards pending proposals after this long
+    Expiry time.Duration
+}
+
+// Applier applies an activated proposal to the running configuration
+type Applier interface {
+    // PairVersion returns the version of a pair's current configuration
+    PairVersion(symbol string) string
+    // Apply writes and activates the proposed pair configuration
+    Apply(symbol string, pair *common.PairConfig) error
+}
+
+// Manager holds proposals and activates them according to its policy
+type Manager struct {
+    policy  Policy
+    applier Applier
+    path    string // optional persistence file
+
+    mu        sync.Mutex
+    proposals map[string]*Proposal
+}
+
+// NewManager creates a proposal manager. When path is set proposals are
+// persisted there and reloaded on start.
+func NewManager(policy Policy, applier Applier, path string) (*Manager, error) {
+    m := &Manager{
+        policy:    policy,
+        applier:   applier,
+        path:      path,
+        proposals: make(map[string]*Proposal),
+    }
+    if path == "" {
+        return m, nil
+    }
+
+    data, err := os.ReadFile(path)
+    if os.IsNotExist(err) {
+        return m, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read proposals: %v", err)
+    }
+    var stored []*Proposal
+    if err := json.Unmarshal(data, &stored); err != nil {
+        return nil, fmt.Errorf("failed to parse proposals: %v", err)
+    }
+    for _, p := range stored {
+        m.proposals[p.ID] = p
+    }
+    return m, nil
+}
+
+// Propose records a new pending proposal
+func (m *Manager) Propose(operator, symbol string, pair *common.PairConfig, reason string, now time.Time) (*Proposal, error) {
+    if symbol == "" || pair == nil {
+        return nil, fmt.Errorf("symbol and pair are required")
+    }
+    id, err := newID()
+    if err != nil {
+        return nil, err
+    }
+
+    p := m.newProposal(id, operator, symbol, pair, reason, now)
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.proposals[id] = p
+    m.activateIfReady(p, now)
+    return m.snapshot(p), m.persist()
+}
+
+// PreviewPropose returns the proposal Propose would record, without an ID,
+// and with the status it would have once recorded. Nothing is applied.
+func (m *Manager) PreviewPropose(operator, symbol string, pair *common.PairConfig, reason string, now time.Time) (*Proposal, error) {
+    if symbol == "" || pair == nil {
+        return nil, fmt.Errorf("symbol and pair are required")
+    }
+    p := m.newProposal("", operator, symbol, pair, reason, now)
+    m.preview(p, now)
+    return p, nil
+}
+
+// newProposal builds a pending proposal against the pair's current version
+func (m *Manager) newProposal(id, operator, symbol string, pair *common.PairConfig, reason string, now time.Time) *Proposal {
+    return &Proposal{
+        ID:          id,
+        Symbol:      symbol,
+        Pair:        pair,
+        Reason:      reason,
+        Proposer:    operator,
+        Approvals:   []string{},
+        BasedOn:     m.applier.PairVersion(symbol),
+        Status:      StatusPending,
+        CreatedAt:   now,
+        ActivatesAt: now.Add(m.policy.Timelock),
+    }
+}
+
+// Approve records an operator's approval and activates the proposal once
+// the policy is satisfied
+func (m *Manager) Approve(operator, id string, now time.Time) (*Proposal, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    p, err := m.approvable(operator, id)
+    if err != nil {
+        return nil, err
+    }
+    p.Approvals = append(p.Approvals, operator)
+    m.activateIfReady(p, now)
+    return m.snapshot(p), m.persist()
+}
+
+// PreviewApprove returns the proposal as Approve would leave it, with the
+// status it would move to, without recording the approval or applying it
+func (m *Manager) PreviewApprove(operator, id string, now time.Time) (*Proposal, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    p, err := m.approvable(operator, id)
+    if err != nil {
+        return nil, err
+    }
+    out := m.snapshot(p)
+    out.Approvals = append(out.Approvals, operator)
+    m.preview(out, now)
+    return out, nil
+}
+
+// approvable returns a pending proposal the operator may approve; callers
+// hold mu
+func (m *Manager) approvable(operator, id string) (*Proposal, error) {
+    p, err := m.pending(id)
+    if err != nil {
+        return nil, err
+    }
+    if operator == p.Proposer {
+        return nil, fmt.Errorf("proposers cannot approve their own proposals")
+    }
+    for _, approver := range p.Approvals {
+        if approver == operator {
+            return nil, fmt.Errorf("%s has already approved proposal %s", operator, id)
+        }
+    }
+    return p, nil
+}
+
+// Cancel withdraws a pending proposal
+func (m *Manager) Cancel(operator, id string, now time.Time) (*Proposal, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    p, err := m.pending(id)
+    if err != nil {
+        return nil, err
+    }
+    m.resolve(p, StatusCancelled, "cancelled by "+operator, now)
+    return m.snapshot(p), m.persist()
+}
+
+// PreviewCancel returns the proposal as Cancel would leave it, without
+// withdrawing it
+func (m *Manager) PreviewCancel(operator, id string, now time.Time) (*Proposal, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    p, err := m.pending(id)
+    if err != nil {
+        return nil, err
+    }
+    out := m.snapshot(p)
+    m.resolve(out, StatusCancelled, "cancelled by "+operator, now)
+    return out, nil
+}
+
+// pending returns a proposal still open to approval or cancellation;
+// callers hold mu
+func (m *Manager) pending(id string) (*Proposal, error) {
+    p, ok := m.proposals[id]
+    if !ok {
+        return nil, fmt.Errorf("proposal %s not found", id)
+    }
+    if p.Status != StatusPending {
+        return nil, fmt.Errorf("proposal %s is %s", id, p.Status)
+    }
+    return p, nil
+}
+
+// List returns proposals with the given status (all when empty), newest first
+func (m *Manager) List(status string) []*Proposal {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    out := make([]*Proposal, 0)
+    for _, p := range m.proposals {
+        if status == "" || p.Status == status {
+            out = append(out, m.snapshot(p))
+        }
+    }
+    sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+    return out
+}
+
+// Run activates timelocked proposals and expires stale ones every interval
+// until ctx is cancelled
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case now := <-ticker.C:
+            m.Tick(now)
+        }
+    }
+}
+
+// Tick activates or expires pending proposals as of now
+func (m *Manager) Tick(now time.Time) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    changed := false
+    for _, p := range m.proposals {
+        if p.Status != StatusPending {
+            continue
+        }
+        if m.policy.Expiry > 0 && now.Sub(p.CreatedAt) > m.policy.Expiry {
+            m.resolve(p, StatusExpired, "", now)
+            changed = true
+            continue
+        }
+        changed = m.activateIfReady(p, now) || changed
+    }
+    if changed {
+        if err := m.persist(); err != nil {
+            log.Printf("Failed to persist proposals: %v", err)
+        }
+    }
+}
+
+// activateIfReady applies a pending proposal once its approvals and
+// timelock are satisfied, reporting whether its status changed
+func (m *Manager) activateIfReady(p *Proposal, now time.Time) bool {
+    if len(p.Approvals) < m.policy.Approvals || now.Before(p.ActivatesAt) {
+        return false
+    }
+
+    // Changes approved against an older pair configuration must be re-proposed
+    if version := m.applier.PairVersion(p.Symbol); version != p.BasedOn {
+        m.resolve(p, StatusConflicted, fmt.Sprintf("%s configuration changed since proposed", p.Symbol), now)
+        return true
+    }
+    if err := m.applier.Apply(p.Symbol, p.Pair); err != nil {
+        m.resolve(p, StatusFailed, err.Error(), now)
+        return true
+    }
+    log.Printf("Activated config proposal %s for %s (proposer %s, approvals %v)", p.ID, p.Symbol, p.Proposer, p.Approvals)
+    m.resolve(p, StatusActive, "", now)
+    return true
+}
+
+// preview sets the status a proposal would move to now, short of applying
+// it: active once the policy is satisfied, or conflicted when the pair's
+// configuration has changed since it was proposed
+func (m *Manager) preview(p *Proposal, now time.Time) {
+    if len(p.Approvals) < m.policy.Approvals || now.Before(p.ActivatesAt) {
+        return
+    }
+    if version := m.applier.PairVersion(p.Symbol); version != p.BasedOn {
+        m.resolve(p, StatusConflicted, fmt.Sprintf("%s configuration changed since proposed", p.Symbol), now)
+        return
+    }
+    m.resolve(p, StatusActive, "", now)
+}
+
+// resolve moves a proposal to a final status
+func (m *Manager) resolve(p *Proposal, status, reason string, now time.Time) {
+    p.Status = status
+    p.Error = reason
+    p.ResolvedAt = now
+}
+
+// snapshot returns a copy of a proposal safe to hand out
+func (m *Manager) snapshot(p *Proposal) *Proposal {
+    out := *p
+    out.Approvals = append([]string{}, p.Approvals...)
+    return &out
+}
+
+// persist writes all proposals to the persistence file, if any
+func (m *Manager) persist() error {
+    if m.path == "" {
+        return nil
+    }
+    all := make([]*Proposal, 0, len(m.proposals))
+    for _, p := range m.proposals {
+        all = append(all, p)
+    }
+    sort.Slice(all, func(i, j int) bool { return all[i].CreatedAt.Before(all[j].CreatedAt) })
+
+    data, err := json.MarshalIndent(all, "", "    ")
+    if err != nil {
+        return err
+    }
+    tmp := m.path + ".tmp"
+    if err := os.WriteFile(tmp, data, 0600); err != nil {
+        return fmt.Errorf("failed to write proposals: %v", err)
+    }
+    return os.Rename(tmp, m.path)
+}
+
+// newID returns a random proposal identifier
+func newID() (string, error) {
+    b := make([]byte, 8)
+    if _, err := rand.Read(b); err != nil {
+        return "", err
+    }
+    return hex.EncodeToString(b), nil
+}
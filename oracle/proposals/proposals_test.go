@@ -0,0 +1,132 @@
+package proposals
+
+import (
+    "fmt"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+)
+
+type fakeApplier struct {
+    versions map[string]string
+    applied  []string
+    fail     bool
+}
+
+func (f *fakeApplier) PairVersion(symbol string) string {
+    return f.versions[symbol]
+}
+
+func (f *fakeApplier) Apply(symbol string, pair *common.PairConfig) error {
+    if f.fail {
+        return fmt.Errorf("invalid configuration")
+    }
+    f.applied = append(f.applied, symbol)
+    f.versions[symbol] = fmt.Sprintf("v%d", len(f.applied)+1)
+    return nil
+}
+
+func TestApprovalAndTimelock(t *testing.T) {
+    applier := &fakeApplier{versions: map[string]string{"ETHUSDT": "v1"}}
+    path := filepath.Join(t.TempDir(), "proposals.json")
+    m, err := NewManager(Policy{Approvals: 1, Timelock: time.Hour}, applier, path)
+    if err != nil {
+        t.Fatalf("Failed to create manager: %v", err)
+    }
+
+    now := time.Date(2024, 4, 13, 12, 0, 0, 0, time.UTC)
+    p, err := m.Propose("alice", "ETHUSDT", &common.PairConfig{MinimumSources: 3}, "tighten sources", now)
+    if err != nil {
+        t.Fatalf("Failed to propose: %v", err)
+    }
+
+    if _, err := m.Approve("alice", p.ID, now); err == nil {
+        t.Error("Expected self-approval to be rejected")
+    }
+    if p, _ = m.Approve("bob", p.ID, now.Add(time.Minute)); p.Status != StatusPending {
+        t.Errorf("Expected approved proposal to wait for the timelock, got %s", p.Status)
+    }
+
+    m.Tick(now.Add(time.Hour))
+    if pending := m.List(StatusPending); len(pending) != 0 {
+        t.Errorf("Expected no pending proposals after the timelock, got %d", len(pending))
+    }
+    if len(applier.applied) != 1 {
+        t.Fatalf("Expected the proposal to be applied once, got %v", applier.applied)
+    }
+
+    // Proposals survive a restart
+    reloaded, err := NewManager(Policy{}, applier, path)
+    if err != nil {
+        t.Fatalf("Failed to reload proposals: %v", err)
+    }
+    if active := reloaded.List(StatusActive); len(active) != 1 || active[0].Approvals[0] != "bob" {
+        t.Errorf("Expected the active proposal to be reloaded, got %+v", active)
+    }
+}
+
+func TestConflictsAndExpiry(t *testing.T) {
+    applier := &fakeApplier{versions: map[string]string{"ETHUSDT": "v1", "BTCUSDT": "v1"}}
+    m, _ := NewManager(Policy{Approvals: 1, Expiry: 24 * time.Hour}, applier, "")
+    now := time.Now()
+
+    first, _ := m.Propose("alice", "ETHUSDT", &common.PairConfig{MinimumSources: 3}, "", now)
+    second, _ := m.Propose("alice", "ETHUSDT", &common.PairConfig{MinimumSources: 4}, "", now)
+    stale, _ := m.Propose("alice", "BTCUSDT", &common.PairConfig{}, "", now)
+
+    if p, _ := m.Approve("bob", first.ID, now); p.Status != StatusActive {
+        t.Fatalf("Expected first proposal to activate, got %s", p.Status)
+    }
+    if p, _ := m.Approve("bob", second.ID, now); p.Status != StatusConflicted {
+        t.Errorf("Expected proposal against the old ETHUSDT config to conflict, got %s", p.Status)
+    }
+
+    m.Tick(now.Add(25 * time.Hour))
+    if p := m.List(StatusExpired); len(p) != 1 || p[0].ID != stale.ID {
+        t.Errorf("Expected the unapproved proposal to expire, got %+v", p)
+    }
+}
+
+func TestPreviewsDoNotApply(t *testing.T) {
+    applier := &fakeApplier{versions: map[string]string{"ETHUSDT": "v1"}}
+    m, err := NewManager(Policy{Approvals: 1}, applier, "")
+    if err != nil {
+        t.Fatalf("Failed to create manager: %v", err)
+    }
+    now := time.Date(2024, 4, 13, 12, 0, 0, 0, time.UTC)
+    pair := &common.PairConfig{MinimumSources: 3}
+
+    preview, err := m.PreviewPropose("alice", "ETHUSDT", pair, "", now)
+    if err != nil || preview.Status != StatusPending || preview.ID != "" {
+        t.Errorf("Expected an unrecorded pending proposal, got %+v (%v)", preview, err)
+    }
+    if len(m.List("")) != 0 {
+        t.Error("Expected the preview not to be recorded")
+    }
+
+    p, _ := m.Propose("alice", "ETHUSDT", pair, "", now)
+    if _, err := m.PreviewApprove("alice", p.ID, now); err == nil {
+        t.Error("Expected self-approval to be rejected in a preview too")
+    }
+    preview, err = m.PreviewApprove("bob", p.ID, now)
+    if err != nil || preview.Status != StatusActive || len(preview.Approvals) != 1 {
+        t.Errorf("Expected the approval to activate the proposal, got %+v (%v)", preview, err)
+    }
+    if preview, _ := m.PreviewCancel("bob", p.ID, now); preview.Status != StatusCancelled {
+        t.Errorf("Expected a cancelled preview, got %s", preview.Status)
+    }
+
+    applier.versions["ETHUSDT"] = "v2"
+    if preview, _ := m.PreviewApprove("bob", p.ID, now); preview.Status != StatusConflicted {
+        t.Errorf("Expected a conflicted preview, got %s", preview.Status)
+    }
+
+    if len(applier.applied) != 0 {
+        t.Errorf("Expected nothing to be applied, got %v", applier.applied)
+    }
+    if pending := m.List(StatusPending); len(pending) != 1 || len(pending[0].Approvals) != 0 {
+        t.Errorf("Expected the proposal to stay pending and unapproved, got %+v", pending)
+    }
+}
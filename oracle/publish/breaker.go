@@ -0,0 +1,242 @@
+package publish
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "math"
+    "math/big"
+    "sort"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+)
+
+// BreakerConfig holds publication of rounds that move a feed by more than
+// MaxChange from its last published value until a confirmation round or an
+// operator override
+type BreakerConfig struct {
+    MaxChange float64 `json:"maxChange"` // fraction of the last published value, e.g. 0.2
+    // ConfirmationRounds is the number of later rounds that must stay at
+    // the held level before it is published, default 1
+    ConfirmationRounds int `json:"confirmationRounds,omitempty"`
+}
+
+// validate checks the cap and applies the default confirmation count
+func (b *BreakerConfig) validate() error {
+    if b.MaxChange <= 0 || math.IsNaN(b.MaxChange) || math.IsInf(b.MaxChange, 0) {
+        return fmt.Errorf("publish breaker maxChange must be positive, got %v", b.MaxChange)
+    }
+    if b.ConfirmationRounds < 0 {
+        return fmt.Errorf("publish breaker confirmationRounds must not be negative, got %d", b.ConfirmationRounds)
+    }
+    if b.ConfirmationRounds == 0 {
+        b.ConfirmationRounds = 1
+    }
+    return nil
+}
+
+// Hold is a round withheld from publication by the breaker
+type Hold struct {
+    Symbol    string  `json:"symbol"`
+    RoundID   uint64  `json:"roundId"`
+    Price     float64 `json:"price"`
+    Published float64 `json:"published"` // last published value
+    Change    float64 `json:"change"`    // fraction of the published value
+    // Confirmations are the later rounds seen at the held level
+    Confirmations int       `json:"confirmations"`
+    HeldAt        time.Time `json:"heldAt"`
+
+    result *common.AggregateResult
+}
+
+// Holds returns the rounds currently withheld, by symbol
+func (p *Pipeline) Holds() []Hold {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    holds := make([]Hold, 0, len(p.holds))
+    for _, h := range p.holds {
+        holds = append(holds, *h)
+    }
+    sort.Slice(holds, func(i, j int) bool { return holds[i].Symbol < holds[j].Symbol })
+    return holds
+}
+
+// PreviewOverride returns the hold an override of symbol would publish
+// without publishing it
+func (p *Pipeline) PreviewOverride(symbol string) (*Hold, error) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    h, ok := p.holds[symbol]
+    if !ok {
+        return nil, fmt.Errorf("no held round for %s", symbol)
+    }
+    held := *h
+    return &held, nil
+}
+
+// Override publishes the held round of symbol on an operator's authority
+func (p *Pipeline) Override(ctx context.Context, operator, symbol string) (*Hold, error) {
+    p.mu.Lock()
+    if p.stopped {
+        p.mu.Unlock()
+        return nil, fmt.Errorf("publishing is stopped")
+    }
+    h, ok := p.holds[symbol]
+    if !ok {
+        p.mu.Unlock()
+        return nil, fmt.Errorf("no held round for %s", symbol)
+    }
+    if h.RoundID < p.latest[symbol] {
+        p.mu.Unlock()
+        return nil, fmt.Errorf("held round %d of %s is older than published round %d", h.RoundID, symbol, p.latest[symbol])
+    }
+    delete(p.holds, symbol)
+
+    log.Printf("Publishing held %s round %d on override by %s", symbol, h.RoundID, operator)
+    p.alert(events.SeverityWarning, symbol, fmt.Sprintf("%s overrode the publish breaker for %s round %d (%.2f%% move to %v)", operator, symbol, h.RoundID, h.Change*100, h.Price))
+    send := p.record(ctx, h.result)
+    held := *h
+    p.mu.Unlock()
+    if send {
+        p.sendRecorded(ctx)
+    }
+    return &held, nil
+}
+
+// hold reports whether the breaker withholds a round, tracking the feed's
+// hold: a round back within the cap releases it, later rounds at the held
+// level confirm it and a round at another level replaces it. Callers hold mu.
+func (p *Pipeline) hold(result *common.AggregateResult) bool {
+    breaker := p.config.Breaker
+    published, ok := p.published[result.Symbol]
+    if breaker == nil || !ok || published <= 0 {
+        return false
+    }
+
+    symbol := result.Symbol
+    change := math.Abs(result.Price-published) / published
+    h := p.holds[symbol]
+    if change <= breaker.MaxChange {
+        if h != nil {
+            delete(p.holds, symbol)
+            log.Printf("Released publish hold of %s round %d: round %d is back within %.2f%% of %v", symbol, h.RoundID, result.RoundID, breaker.MaxChange*100, published)
+            p.alert(events.SeverityInfo, symbol, fmt.Sprintf("%s round %d returned within the publish cap; held round %d discarded", symbol, result.RoundID, h.RoundID))
+        }
+        return false
+    }
+
+    if h != nil && math.Abs(result.Price-h.Price)/h.Price <= breaker.MaxChange {
+        h.Confirmations++
+        if h.Confirmations >= breaker.ConfirmationRounds {
+            delete(p.holds, symbol)
+            log.Printf("Publishing %s round %d: the %.2f%% move was confirmed", symbol, result.RoundID, change*100)
+            p.alert(events.SeverityInfo, symbol, fmt.Sprintf("%s move to %v confirmed by round %d; publishing resumed", symbol, result.Price, result.RoundID))
+            return false
+        }
+        return true
+    }
+
+    p.holds[symbol] = &Hold{
+        Symbol:    symbol,
+        RoundID:   result.RoundID,
+        Price:     result.Price,
+        Published: published,
+        Change:    change,
+        HeldAt:    time.Now(),
+        result:    result,
+    }
+    log.Printf("Holding %s round %d: %.2f%% from the published %v exceeds the %.2f%% cap", symbol, result.RoundID, change*100, published, breaker.MaxChange*100)
+    p.alert(events.SeverityCritical, symbol, fmt.Sprintf("publication of %s held: round %d moves %.2f%% from the published %v, above the %.2f%% cap; awaiting confirmation or operator override", symbol, result.RoundID, change*100, published, breaker.MaxChange*100))
+    return true
+}
+
+// BreakerState is the breaker's reference values and holds, as mirrored by
+// a warm standby
+type BreakerState struct {
+    // Published is the last value recorded for publication of each feed
+    Published map[string]float64 `json:"published"`
+    Holds     []HeldRound        `json:"holds,omitempty"`
+}
+
+// HeldRound is a hold together with the round it withholds
+type HeldRound struct {
+    Hold
+    Round *common.AggregateResult `json:"round"`
+}
+
+// BreakerState returns the breaker's current state
+func (p *Pipeline) BreakerState() BreakerState {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    state := BreakerState{Published: make(map[string]float64, len(p.published))}
+    for symbol, value := range p.published {
+        state.Published[symbol] = value
+    }
+    for _, h := range p.holds {
+        state.Holds = append(state.Holds, HeldRound{Hold: *h, Round: h.result})
+    }
+    sort.Slice(state.Holds, func(i, j int) bool { return state.Holds[i].Symbol < state.Holds[j].Symbol })
+    return state
+}
+
+// RestoreBreaker takes over the breaker state of another instance, such as
+// the leader a standby mirrored, in place of its own holds. Call before
+// Start.
+func (p *Pipeline) RestoreBreaker(state BreakerState) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    for symbol, value := range state.Published {
+        p.published[symbol] = value
+    }
+    p.holds = make(map[string]*Hold, len(state.Holds))
+    for _, held := range state.Holds {
+        if held.Round == nil {
+            continue
+        }
+        h := held.Hold
+        h.result = held.Round
+        p.holds[h.Symbol] = &h
+    }
+}
+
+// alert publishes a breaker alert
+func (p *Pipeline) alert(severity, symbol, message string) {
+    p.bus.Publish(events.Event{
+        Type:   events.Alert,
+        Symbol: symbol,
+        Payload: &events.AlertPayload{
+            Severity: severity,
+            Kind:     "publish_breaker",
+            Message:  message,
+        },
+    })
+}
+
+// publishedValues returns the value of the newest round of each feed
+// recorded for publication, the reference of the breaker
+func publishedValues(journal *Journal, decimals int) map[string]float64 {
+    values := make(map[string]float64)
+    for _, r := range journal.List("") {
+        if _, seen := values[r.Symbol]; seen || r.Status == StatusSuperseded || r.Status == StatusFailed {
+            continue
+        }
+        if value, ok := unscale(r.Value, decimals); ok {
+            values[r.Symbol] = value
+        }
+    }
+    return values
+}
+
+// unscale converts a published fixed-point integer back into a price
+func unscale(value string, decimals int) (float64, bool) {
+    n, ok := new(big.Int).SetString(value, 10)
+    if !ok {
+        return 0, false
+    }
+    f := new(big.Float).SetInt(n)
+    f.Quo(f, new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+    price, _ := f.Float64()
+    return price, true
+}
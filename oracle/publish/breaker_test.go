@@ -0,0 +1,89 @@
+package publish
+
+import (
+    "context"
+    "path/filepath"
+    "testing"
+
+    "yetaXYZ/oracle/events"
+)
+
+func TestBreakerHoldsLargeMoves(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "publish.journal")
+    journal, err := OpenJournal(path)
+    if err != nil {
+        t.Fatalf("Failed to open journal: %v", err)
+    }
+
+    bus := events.NewBus()
+    alerts := bus.Subscribe(10, events.Alert)
+    publisher := &fakePublisher{}
+    config := &Config{Decimals: 2, MaxAttempts: 3, Feeds: []string{"ETHUSDT"}, Breaker: &BreakerConfig{MaxChange: 0.2}}
+    if err := config.Breaker.validate(); err != nil {
+        t.Fatal(err)
+    }
+    p := NewPipeline(config, journal, publisher, bus)
+    ctx := context.Background()
+
+    p.Publish(ctx, round("ETHUSDT", 1, 3000))
+    p.Publish(ctx, round("ETHUSDT", 2, 3500))
+    // A spike is held, then discarded when the next round is back in range
+    p.Publish(ctx, round("ETHUSDT", 3, 4500))
+    if holds := p.Holds(); len(holds) != 1 || holds[0].RoundID != 3 || holds[0].Published != 3500 {
+        t.Fatalf("Expected round 3 to be held against 3500, got %+v", holds)
+    }
+    if e := <-alerts.C; e.Payload.(*events.AlertPayload).Severity != events.SeverityCritical {
+        t.Errorf("Expected a critical alert for the hold, got %+v", e.Payload)
+    }
+    p.Publish(ctx, round("ETHUSDT", 4, 3510))
+    if len(p.Holds()) != 0 || len(publisher.sent) != 3 || publisher.sent[2] != "ETHUSDT=351000" {
+        t.Fatalf("Expected the hold to be released and round 4 published, got %v %+v", publisher.sent, p.Holds())
+    }
+
+    // A lasting move is published once a later round confirms it
+    p.Publish(ctx, round("ETHUSDT", 5, 5000))
+    p.Publish(ctx, round("ETHUSDT", 6, 5050))
+    if len(p.Holds()) != 0 || publisher.sent[len(publisher.sent)-1] != "ETHUSDT=505000" {
+        t.Fatalf("Expected the confirming round to be published, got %v", publisher.sent)
+    }
+
+    // An operator can publish a held round without waiting
+    p.Publish(ctx, round("ETHUSDT", 7, 2000))
+    if _, err := p.Override(ctx, "alice", "BTCUSDT"); err == nil {
+        t.Error("Expected an override without a hold to fail")
+    }
+    if h, err := p.Override(ctx, "alice", "ETHUSDT"); err != nil || h.RoundID != 7 {
+        t.Fatalf("Expected round 7 to be published on override, got %+v, %v", h, err)
+    }
+    if publisher.sent[len(publisher.sent)-1] != "ETHUSDT=200000" {
+        t.Errorf("Expected the overridden round to be published, got %v", publisher.sent)
+    }
+    journal.Close()
+
+    // The reference value survives a restart
+    journal, err = OpenJournal(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer journal.Close()
+    p = NewPipeline(config, journal, publisher, bus)
+    p.Publish(ctx, round("ETHUSDT", 8, 3000))
+    if holds := p.Holds(); len(holds) != 1 || holds[0].Published != 2000 {
+        t.Errorf("Expected round 8 to be held against the journaled 2000, got %+v", holds)
+    }
+
+    // A standby taking over the breaker state can publish the held round
+    standbyJournal, err := OpenJournal(filepath.Join(t.TempDir(), "standby.journal"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer standbyJournal.Close()
+    standby := NewPipeline(config, standbyJournal, publisher, bus)
+    standby.RestoreBreaker(p.BreakerState())
+    if holds := standby.Holds(); len(holds) != 1 || holds[0].RoundID != 8 || holds[0].Published != 2000 {
+        t.Fatalf("Expected the restored hold of round 8, got %+v", holds)
+    }
+    if _, err := standby.Override(ctx, "alice", "ETHUSDT"); err != nil || publisher.sent[len(publisher.sent)-1] != "ETHUSDT=300000" {
+        t.Errorf("Expected the restored hold to be published on override, got %v, %v", publisher.sent, err)
+    }
+}
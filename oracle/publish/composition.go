@@ -0,0 +1,196 @@
+package publish
+
+import (
+    "bytes"
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "math/big"
+    "sort"
+    "strings"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/evm"
+)
+
+// maxCompositionSources is the width of the on-chain source bitmap
+const maxCompositionSources = 256
+
+// SourceList returns the configured sources of a feed in a stable order,
+// which assigns each source its bit in the published bitmap
+type SourceList func(symbol string) []string
+
+// ComposedPublisher is implemented by publishers whose contract records the
+// source composition of a round alongside its value
+type ComposedPublisher interface {
+    SubmitComposed(ctx context.Context, symbol string, roundID uint64, value *big.Int, c *Composition) (txHash string, err error)
+    // RoundComposition reads back the bitmap and hash recorded for a round
+    RoundComposition(ctx context.Context, symbol string, roundID uint64) (sources *big.Int, hash string, err error)
+}
+
+// Contribution is a source's price in a published round, scaled like the
+// published value
+type Contribution struct {
+    Source string `json:"source"`
+    Price  string `json:"price"`
+}
+
+// Composition is the breakdown of the sources behind a published round.
+// Only its bitmap and hash go on-chain; the breakdown is kept in the
+// receipt journal so anyone holding it can check it against the hash.
+type Composition struct {
+    // Sources are the feed's configured sources when the round was
+    // published; bit i of Bitmap is set when Sources[i] contributed
+    Sources []string `json:"sources"`
+    Bitmap  string   `json:"bitmap"` // hex uint256
+    // Contributions are the contributing sources, sorted by name
+    Contributions []Contribution `json:"contributions"`
+    Hash          string         `json:"hash"` // hex bytes32
+}
+
+// NewComposition builds the composition of a round from the source prices
+// it aggregated. Sources beyond the width of the bitmap, or no longer
+// configured, get no bit but are still part of the hashed breakdown.
+func NewComposition(result *common.AggregateResult, value *big.Int, sources []string, decimals int) (*Composition, error) {
+    c := &Composition{Sources: sources, Contributions: make([]Contribution, 0, len(result.Sources))}
+    if c.Sources == nil {
+        c.Sources = []string{}
+    }
+    bits := make(map[string]int, len(sources))
+    for i, source := range sources {
+        if i < maxCompositionSources {
+            bits[source] = i
+        }
+    }
+
+    bitmap := new(big.Int)
+    for _, sp := range result.Sources {
+        price, err := scale(sp.Price, decimals)
+        if err != nil {
+            return nil, fmt.Errorf("source %s: %v", sp.Source, err)
+        }
+        c.Contributions = append(c.Contributions, Contribution{Source: sp.Source, Price: price.String()})
+        if i, ok := bits[sp.Source]; ok {
+            bitmap.SetBit(bitmap, i, 1)
+        }
+    }
+    sort.Slice(c.Contributions, func(i, j int) bool { return c.Contributions[i].Source < c.Contributions[j].Source })
+    c.Bitmap = "0x" + bitmap.Text(16)
+
+    hash, err := c.digest(result.Symbol, result.RoundID, value)
+    if err != nil {
+        return nil, err
+    }
+    c.Hash = "0x" + hex.EncodeToString(hash)
+    return c, nil
+}
+
+// digest hashes the breakdown of a round: sha256 over the words feedId,
+// roundId, value and bitmap followed by sha256(source) and price for each
+// contribution in order. The EVM's sha256 precompile can reproduce it.
+func (c *Composition) digest(symbol string, roundID uint64, value *big.Int) ([]byte, error) {
+    feedID, err := evm.EncodeBytes32(symbol)
+    if err != nil {
+        return nil, err
+    }
+    amount, err := evm.EncodeUint256(value)
+    if err != nil {
+        return nil, err
+    }
+    bitmap, ok := new(big.Int).SetString(strings.TrimPrefix(c.Bitmap, "0x"), 16)
+    if !ok {
+        return nil, fmt.Errorf("invalid composition bitmap %q", c.Bitmap)
+    }
+    sources, err := evm.EncodeUint256(bitmap)
+    if err != nil {
+        return nil, err
+    }
+    round, _ := evm.EncodeUint256(new(big.Int).SetUint64(roundID))
+
+    h := sha256.New()
+    h.Write(feedID)
+    h.Write(round)
+    h.Write(amount)
+    h.Write(sources)
+    for _, contribution := range c.Contributions {
+        price, ok := new(big.Int).SetString(contribution.Price, 10)
+        if !ok {
+            return nil, fmt.Errorf("invalid price %q of %s", contribution.Price, contribution.Source)
+        }
+        word, err := evm.EncodeUint256(price)
+        if err != nil {
+            return nil, err
+        }
+        name := sha256.Sum256([]byte(contribution.Source))
+        h.Write(name[:])
+        h.Write(word)
+    }
+    return h.Sum(nil), nil
+}
+
+// Verify recomputes the hash of the breakdown for the published round and
+// checks it against the recorded one
+func (c *Composition) Verify(symbol string, roundID uint64, value *big.Int) error {
+    hash, err := c.digest(symbol, roundID, value)
+    if err != nil {
+        return err
+    }
+    recorded, err := hex.DecodeString(strings.TrimPrefix(c.Hash, "0x"))
+    if err != nil || !bytes.Equal(hash, recorded) {
+        return fmt.Errorf("composition hash mismatch: recorded %s, computed 0x%x", c.Hash, hash)
+    }
+    return nil
+}
+
+// SetSourceList sets how the pipeline orders a feed's sources in the bitmap
+// of compositions; without it compositions carry the hash only
+func (p *Pipeline) SetSourceList(sources SourceList) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    p.sources = sources
+}
+
+// composition builds the composition of a round of a feed configured to
+// publish one; callers hold mu
+func (p *Pipeline) composition(result *common.AggregateResult, value *big.Int) (*Composition, error) {
+    if !p.composed[result.Symbol] {
+        return nil, nil
+    }
+    var sources []string
+    if p.sources != nil {
+        sources = p.sources(result.Symbol)
+    }
+    return NewComposition(result, value, sources, p.config.Decimals)
+}
+
+// VerifyComposition checks the breakdown recorded for a published round
+// against its own hash and, when the contract can be read, against the
+// bitmap and hash recorded on-chain
+func (p *Pipeline) VerifyComposition(ctx context.Context, r *Receipt) (onChain bool, err error) {
+    if r.Composition == nil {
+        return false, fmt.Errorf("round %d of %s was published without a composition", r.RoundID, r.Symbol)
+    }
+    value, ok := new(big.Int).SetString(r.Value, 10)
+    if !ok {
+        return false, fmt.Errorf("invalid value %q", r.Value)
+    }
+    if err := r.Composition.Verify(r.Symbol, r.RoundID, value); err != nil {
+        return false, err
+    }
+
+    reader, ok := p.publisher.(ComposedPublisher)
+    if !ok || r.Status != StatusConfirmed {
+        return false, nil
+    }
+    callCtx, cancel := context.WithTimeout(ctx, rpcTimeout)
+    bitmap, hash, err := reader.RoundComposition(callCtx, r.Symbol, r.RoundID)
+    cancel()
+    if err != nil {
+        return false, fmt.Errorf("failed to read composition on-chain: %v", err)
+    }
+    if "0x"+bitmap.Text(16) != r.Composition.Bitmap || hash != r.Composition.Hash {
+        return true, fmt.Errorf("on-chain composition 0x%s/%s differs from recorded %s/%s", bitmap.Text(16), hash, r.Composition.Bitmap, r.Composition.Hash)
+    }
+    return true, nil
+}
@@ -0,0 +1,115 @@
+package publish
+
+import (
+    "context"
+    "math/big"
+    "path/filepath"
+    "testing"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+    "yetaXYZ/oracle/evm"
+)
+
+type fakeComposedPublisher struct {
+    fakePublisher
+    composed map[uint64]*Composition
+}
+
+func (f *fakeComposedPublisher) SubmitComposed(ctx context.Context, symbol string, roundID uint64, value *big.Int, c *Composition) (string, error) {
+    f.composed[roundID] = c
+    return f.Submit(ctx, symbol, roundID, value)
+}
+
+func (f *fakeComposedPublisher) RoundComposition(ctx context.Context, symbol string, roundID uint64) (*big.Int, string, error) {
+    c := f.composed[roundID]
+    bitmap, _ := new(big.Int).SetString(c.Bitmap[2:], 16)
+    return bitmap, c.Hash, nil
+}
+
+func composedRound(id uint64) *common.AggregateResult {
+    result := round("ETHUSDT", id, 3000)
+    result.Sources = []common.SourcePrice{
+        {Source: "kraken", PricePoint: common.PricePoint{Price: 3001}},
+        {Source: "binance", PricePoint: common.PricePoint{Price: 2999}},
+        {Source: "uniswap-v3:0x88e6", PricePoint: common.PricePoint{Price: 3000}},
+    }
+    return result
+}
+
+func TestComposition(t *testing.T) {
+    value := big.NewInt(300000000000)
+    sources := []string{"binance", "coinbase", "kraken"}
+    c, err := NewComposition(composedRound(7), value, sources, 8)
+    if err != nil {
+        t.Fatal(err)
+    }
+    // binance and kraken contributed; the pool is no longer configured
+    if c.Bitmap != "0x5" || len(c.Contributions) != 3 || c.Contributions[0].Source != "binance" || c.Contributions[0].Price != "299900000000" {
+        t.Errorf("Unexpected composition %+v", c)
+    }
+    if len(c.Hash) != 66 {
+        t.Errorf("Expected a bytes32 hash, got %s", c.Hash)
+    }
+    if err := c.Verify("ETHUSDT", 7, value); err != nil {
+        t.Errorf("Expected the breakdown to verify, got %v", err)
+    }
+
+    // Any change to the round or the breakdown changes the hash
+    if err := c.Verify("ETHUSDT", 8, value); err == nil {
+        t.Error("Expected another round to fail verification")
+    }
+    c.Contributions[1].Price = "300200000000"
+    if err := c.Verify("ETHUSDT", 7, value); err == nil {
+        t.Error("Expected a tampered price to fail verification")
+    }
+
+    config := &Config{Feeds: []string{"ETHUSDT"}, Composition: []string{"ETHUSDT"}}
+    if err := config.validateComposition(); err == nil {
+        t.Error("Expected compositions to require the priceFeed contract")
+    }
+    config.ContractType = ContractPriceFeed
+    if err := config.validateComposition(); err != nil {
+        t.Errorf("Unexpected error: %v", err)
+    }
+    config.Composition = []string{"BTCUSDT"}
+    if err := config.validateComposition(); err == nil {
+        t.Error("Expected a composition feed that is not published to be rejected")
+    }
+}
+
+func TestPipelinePublishesComposition(t *testing.T) {
+    journal, err := OpenJournal(filepath.Join(t.TempDir(), "publish.journal"))
+    if err != nil {
+        t.Fatalf("Failed to open journal: %v", err)
+    }
+    defer journal.Close()
+
+    publisher := &fakeComposedPublisher{fakePublisher: fakePublisher{receipts: map[string]*evm.TxReceipt{}}, composed: map[uint64]*Composition{}}
+    config := &Config{Decimals: 8, Confirmations: 1, MaxAttempts: 3, Feeds: []string{"ETHUSDT", "BTCUSDT"}, Composition: []string{"ETHUSDT"}}
+    p := NewPipeline(config, journal, publisher, events.NewBus())
+    p.SetSourceList(func(symbol string) []string { return []string{"binance", "kraken"} })
+    ctx := context.Background()
+
+    p.Publish(ctx, composedRound(1))
+    p.Publish(ctx, round("BTCUSDT", 1, 60000))
+    if len(publisher.composed) != 1 || len(publisher.sent) != 2 {
+        t.Fatalf("Expected only ETHUSDT published with a composition, got %v", publisher.sent)
+    }
+    r, _ := journal.Get("ETHUSDT", 1)
+    if r.Composition == nil || r.Composition.Bitmap != "0x3" {
+        t.Fatalf("Expected the composition journaled, got %+v", r.Composition)
+    }
+    if other, _ := journal.Get("BTCUSDT", 1); other.Composition != nil {
+        t.Error("Expected no composition for BTCUSDT")
+    }
+
+    publisher.receipts["0x01"] = &evm.TxReceipt{BlockNumber: 10, Success: true}
+    publisher.head = 10
+    p.Poll(ctx)
+    r, _ = journal.Get("ETHUSDT", 1)
+    onChain, err := p.VerifyComposition(ctx, r)
+    if err != nil || !onChain {
+        t.Errorf("Expected the confirmed composition verified on-chain, got %v %v", onChain, err)
+    }
+}
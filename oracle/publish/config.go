@@ -0,0 +1,220 @@
+package publish
+
+import (
+    "encoding/json"
+    "fmt"
+    "math/big"
+    "os"
+    "path/filepath"
+
+    "yetaXYZ/oracle/common"
+)
+
+// ChainTypeTestnet marks chains in the base config whose funds are worthless
+const ChainTypeTestnet = "testnet"
+
+// Contract types
+const (
+    ContractModernOracle = "modernOracle" // contracts/ModernOracle.sol
+    ContractPriceFeed    = "priceFeed"    // contracts/PriceFeed.sol
+)
+
+// Config configures on-chain publication of feeds
+type Config struct {
+    Enabled bool `json:"enabled"`
+    // Chain is the key of the target chain in the base config; its first
+    // RPC URL is used when RPCUrl is empty
+    Chain    string `json:"chain,omitempty"`
+    RPCUrl   string `json:"rpcUrl"`
+    Contract string `json:"contract"`
+    // ContractType selects the contract interface, default modernOracle
+    ContractType  string   `json:"contractType,omitempty"`
+    From          string   `json:"from"`    // account the node or its signer sends from
+    Journal       string   `json:"journal"` // receipt journal path
+    Decimals      int      `json:"decimals"`
+    Confirmations uint64   `json:"confirmations"`
+    MaxAttempts   int      `json:"maxAttempts"`
+    Feeds         []string `json:"feeds"`
+    // Composition lists feeds that also publish which sources contributed
+    // to each round; requires the priceFeed contract
+    Composition []string `json:"composition,omitempty"`
+    // Funding keeps the From account topped up; testnet chains only
+    Funding *FundingConfig `json:"funding,omitempty"`
+    // Breaker holds rounds that move a feed too far in one update
+    Breaker *BreakerConfig `json:"breaker,omitempty"`
+    // Nonces tunes the replacement of stuck transactions
+    Nonces NonceConfig `json:"nonces,omitempty"`
+    // Profiles override the target per environment (e.g. "staging"),
+    // selected with --env; the top-level values are used without one
+    Profiles map[string]Profile `json:"profiles,omitempty"`
+
+    // Env is the selected profile, empty for the top-level values
+    Env string `json:"-"`
+}
+
+// Profile is the publication target of one environment. Empty fields keep
+// the top-level values.
+type Profile struct {
+    Chain        string         `json:"chain,omitempty"`
+    RPCUrl       string         `json:"rpcUrl,omitempty"`
+    Contract     string         `json:"contract,omitempty"`
+    ContractType string         `json:"contractType,omitempty"`
+    From         string         `json:"from,omitempty"`
+    Journal      string         `json:"journal,omitempty"`
+    Funding      *FundingConfig `json:"funding,omitempty"`
+}
+
+// FundingConfig tops up the publishing account from a funding wallet held
+// by the same node or signer. Amounts are decimal wei.
+type FundingConfig struct {
+    From            string `json:"from"`
+    MinBalance      string `json:"minBalance"`
+    TopUp           string `json:"topUp"`
+    IntervalSeconds int    `json:"intervalSeconds,omitempty"` // default 300
+}
+
+// LoadConfig loads publish/publish.json from the config directory and
+// applies the profile of env, if any. A missing file disables publishing.
+// RPC URLs may reference environment variables (${NAME}) so that provider
+// keys stay out of the file.
+func LoadConfig(configDir, env string) (*Config, error) {
+    data, err := os.ReadFile(filepath.Join(configDir, "publish", "publish.json"))
+    if os.IsNotExist(err) {
+        return &Config{Env: env}, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read publish config: %v", err)
+    }
+
+    var config Config
+    if err := json.Unmarshal(data, &config); err != nil {
+        return nil, fmt.Errorf("failed to parse publish config: %v", err)
+    }
+    if env != "" {
+        profile, ok := config.Profiles[env]
+        if !ok {
+            return nil, fmt.Errorf("no publish profile for environment %s", env)
+        }
+        config.apply(profile)
+        config.Env = env
+    }
+
+    if config.Enabled {
+        if (config.RPCUrl == "" && config.Chain == "") || config.Contract == "" || config.From == "" || config.Journal == "" {
+            return nil, fmt.Errorf("publish config requires rpcUrl or chain, contract, from and journal")
+        }
+        if config.ContractType != "" && config.ContractType != ContractModernOracle && config.ContractType != ContractPriceFeed {
+            return nil, fmt.Errorf("unknown publish contract type %q", config.ContractType)
+        }
+        if config.Decimals < 0 || config.Decimals > 36 {
+            return nil, fmt.Errorf("publish decimals out of range: %d", config.Decimals)
+        }
+        if err := config.validateComposition(); err != nil {
+            return nil, err
+        }
+        if config.Funding != nil {
+            if err := config.Funding.validate(); err != nil {
+                return nil, err
+            }
+        }
+        if config.Breaker != nil {
+            if err := config.Breaker.validate(); err != nil {
+                return nil, err
+            }
+        }
+    }
+    if config.MaxAttempts <= 0 {
+        config.MaxAttempts = 3
+    }
+    config.Nonces.defaults()
+    return &config, nil
+}
+
+// apply overrides the target with the non-empty fields of a profile
+func (c *Config) apply(p Profile) {
+    if p.Chain != "" {
+        c.Chain = p.Chain
+        // A different chain must not inherit the top-level chain's RPC
+        c.RPCUrl = ""
+    }
+    if p.RPCUrl != "" {
+        c.RPCUrl = p.RPCUrl
+    }
+    if p.Contract != "" {
+        c.Contract = p.Contract
+    }
+    if p.ContractType != "" {
+        c.ContractType = p.ContractType
+    }
+    if p.From != "" {
+        c.From = p.From
+    }
+    if p.Journal != "" {
+        c.Journal = p.Journal
+    }
+    if p.Funding != nil {
+        c.Funding = p.Funding
+    }
+}
+
+// ResolveChain checks the target chain against the base config and fills
+// in its RPC URL. Funding is refused on chains not marked as testnets.
+func (c *Config) ResolveChain(chains common.ChainConfig) error {
+    if c.Chain == "" {
+        if c.Funding != nil {
+            return fmt.Errorf("publish funding requires a testnet chain")
+        }
+        return nil
+    }
+    chain, ok := chains[c.Chain]
+    if !ok {
+        return fmt.Errorf("publish chain %s not configured", c.Chain)
+    }
+    if c.Funding != nil && chain.Type != ChainTypeTestnet {
+        return fmt.Errorf("publish funding is only allowed on testnets, %s is %s", chain.Name, chain.Type)
+    }
+    if c.RPCUrl == "" {
+        if len(chain.RPCUrls) == 0 {
+            return fmt.Errorf("publish chain %s has no RPC URL", c.Chain)
+        }
+        c.RPCUrl = chain.RPCUrls[0]
+    }
+    return nil
+}
+
+// validateComposition checks that composition feeds are published to a
+// contract that can record them
+func (c *Config) validateComposition() error {
+    if len(c.Composition) == 0 {
+        return nil
+    }
+    if c.ContractType != ContractPriceFeed {
+        return fmt.Errorf("publishing source compositions requires the %s contract", ContractPriceFeed)
+    }
+    feeds := make(map[string]bool, len(c.Feeds))
+    for _, symbol := range c.Feeds {
+        feeds[symbol] = true
+    }
+    for _, symbol := range c.Composition {
+        if !feeds[symbol] {
+            return fmt.Errorf("composition feed %s is not published", symbol)
+        }
+    }
+    return nil
+}
+
+// validate checks the funding account and amounts
+func (f *FundingConfig) validate() error {
+    if f.From == "" {
+        return fmt.Errorf("publish funding requires from")
+    }
+    min, ok := new(big.Int).SetString(f.MinBalance, 10)
+    if !ok || min.Sign() <= 0 {
+        return fmt.Errorf("invalid publish funding minBalance %q", f.MinBalance)
+    }
+    topUp, ok := new(big.Int).SetString(f.TopUp, 10)
+    if !ok || topUp.Sign() <= 0 {
+        return fmt.Errorf("invalid publish funding topUp %q", f.TopUp)
+    }
+    return nil
+}
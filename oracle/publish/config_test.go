@@ -0,0 +1,67 @@
+package publish
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/credentials"
+)
+
+func TestLoadConfigProfiles(t *testing.T) {
+    dir := t.TempDir()
+    os.MkdirAll(filepath.Join(dir, "publish"), 0755)
+    os.WriteFile(filepath.Join(dir, "publish", "publish.json"), []byte(`{
+        "enabled": true,
+        "chain": "1",
+        "contract": "0xprod",
+        "from": "0xprodfrom",
+        "journal": "publish.journal",
+        "decimals": 8,
+        "profiles": {
+            "staging": {
+                "chain": "11155111",
+                "rpcUrl": "https://sepolia.example/${TEST_RPC_KEY}",
+                "contract": "0xstaging",
+                "journal": "publish-staging.journal",
+                "funding": {"from": "0xfaucet", "minBalance": "1000", "topUp": "5000"}
+            }
+        }
+    }`), 0644)
+    t.Setenv("TEST_RPC_KEY", "secret")
+
+    chains := common.ChainConfig{
+        "1":        {Name: "Ethereum", Type: "mainnet", RPCUrls: []string{"https://eth.example"}},
+        "11155111": {Name: "Sepolia", Type: ChainTypeTestnet},
+    }
+
+    prod, err := LoadConfig(dir, "")
+    if err != nil {
+        t.Fatalf("Failed to load prod config: %v", err)
+    }
+    if err := prod.ResolveChain(chains); err != nil || prod.RPCUrl != "https://eth.example" {
+        t.Fatalf("Expected chain RPC URL, got %q, %v", prod.RPCUrl, err)
+    }
+
+    staging, err := LoadConfig(dir, "staging")
+    if err != nil {
+        t.Fatalf("Failed to load staging config: %v", err)
+    }
+    if err := staging.ResolveChain(chains); err != nil {
+        t.Fatalf("Failed to resolve staging chain: %v", err)
+    }
+    if staging.Contract != "0xstaging" || staging.From != "0xprodfrom" || credentials.Expand(staging.RPCUrl) != "https://sepolia.example/secret" || staging.Funding == nil {
+        t.Errorf("Unexpected staging config: %+v", staging)
+    }
+
+    if _, err := LoadConfig(dir, "qa"); err == nil {
+        t.Error("Expected error for unknown profile, got nil")
+    }
+
+    // Funding is refused on mainnet
+    staging.Chain = "1"
+    if err := staging.ResolveChain(chains); err == nil {
+        t.Error("Expected error for funding on mainnet, got nil")
+    }
+}
@@ -0,0 +1,146 @@
+package publish
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "math/big"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/events"
+)
+
+// Wallet reads balances and transfers native funds between accounts held
+// by the node or its signer
+type Wallet interface {
+    Balance(ctx context.Context, address string) (*big.Int, error)
+    SendValue(ctx context.Context, from, to string, value *big.Int) (string, error)
+}
+
+// FundingStatus describes the publishing account's balance and top-ups
+type FundingStatus struct {
+    Account   string    `json:"account"`
+    Funder    string    `json:"funder"`
+    Balance   string    `json:"balance,omitempty"` // wei
+    CheckedAt time.Time `json:"checkedAt,omitempty"`
+    TopUps    int       `json:"topUps"`
+    LastTopUp string    `json:"lastTopUp,omitempty"` // transaction hash
+    LastError string    `json:"lastError,omitempty"`
+}
+
+// Funder keeps the publishing account of a test network above a minimum
+// balance by transferring from a funding wallet, so that a long-running
+// staging pipeline does not stall on an empty faucet balance
+type Funder struct {
+    wallet     Wallet
+    account    string
+    funder     string
+    minBalance *big.Int
+    topUp      *big.Int
+    interval   time.Duration
+    bus        *events.Bus
+
+    mu     sync.Mutex
+    status FundingStatus
+}
+
+// NewFunder creates a funder for the publishing account of config, which
+// must have been loaded with funding
+func NewFunder(config *Config, wallet Wallet, bus *events.Bus) *Funder {
+    minBalance, _ := new(big.Int).SetString(config.Funding.MinBalance, 10)
+    topUp, _ := new(big.Int).SetString(config.Funding.TopUp, 10)
+    interval := time.Duration(config.Funding.IntervalSeconds) * time.Second
+    if interval <= 0 {
+        interval = 5 * time.Minute
+    }
+    return &Funder{
+        wallet:     wallet,
+        account:    config.From,
+        funder:     config.Funding.From,
+        minBalance: minBalance,
+        topUp:      topUp,
+        interval:   interval,
+        bus:        bus,
+        status:     FundingStatus{Account: config.From, Funder: config.Funding.From},
+    }
+}
+
+// Check tops up the publishing account if its balance is below the minimum
+// and returns the transfer's hash, empty when no top-up was needed
+func (f *Funder) Check(ctx context.Context) (string, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    callCtx, cancel := context.WithTimeout(ctx, rpcTimeout)
+    balance, err := f.wallet.Balance(callCtx, f.account)
+    cancel()
+    if err != nil {
+        f.status.LastError = err.Error()
+        return "", fmt.Errorf("failed to read balance of %s: %v", f.account, err)
+    }
+    f.status.Balance = balance.String()
+    f.status.CheckedAt = time.Now()
+    if balance.Cmp(f.minBalance) >= 0 {
+        f.status.LastError = ""
+        return "", nil
+    }
+
+    callCtx, cancel = context.WithTimeout(ctx, rpcTimeout)
+    hash, err := f.wallet.SendValue(callCtx, f.funder, f.account, f.topUp)
+    cancel()
+    if err != nil {
+        f.status.LastError = err.Error()
+        f.alert(events.SeverityWarning, fmt.Sprintf("failed to top up publishing account %s from %s: %v", f.account, f.funder, err))
+        return "", fmt.Errorf("failed to top up %s: %v", f.account, err)
+    }
+    f.status.TopUps++
+    f.status.LastTopUp = hash
+    f.status.LastError = ""
+    f.alert(events.SeverityInfo, fmt.Sprintf("topped up publishing account %s with %s wei from %s (balance %s)", f.account, f.topUp, f.funder, balance))
+    return hash, nil
+}
+
+// alert publishes a funding alert
+func (f *Funder) alert(severity, message string) {
+    if f.bus == nil {
+        return
+    }
+    f.bus.Publish(events.Event{
+        Type: events.Alert,
+        Payload: &events.AlertPayload{
+            Severity: severity,
+            Kind:     "publisher_funding",
+            Message:  message,
+        },
+    })
+}
+
+// Status reports the last balance check and top-ups
+func (f *Funder) Status() FundingStatus {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    return f.status
+}
+
+// Interval returns the configured check interval
+func (f *Funder) Interval() time.Duration {
+    return f.interval
+}
+
+// Run checks the balance at interval until ctx is cancelled
+func (f *Funder) Run(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        if _, err := f.Check(ctx); err != nil {
+            log.Printf("Publisher funding: %v", err)
+        }
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
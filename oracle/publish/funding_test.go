@@ -0,0 +1,48 @@
+package publish
+
+import (
+    "context"
+    "math/big"
+    "testing"
+)
+
+type fakeWallet struct {
+    balances  map[string]*big.Int
+    transfers int
+}
+
+func (w *fakeWallet) Balance(ctx context.Context, address string) (*big.Int, error) {
+    return new(big.Int).Set(w.balances[address]), nil
+}
+
+func (w *fakeWallet) SendValue(ctx context.Context, from, to string, value *big.Int) (string, error) {
+    w.balances[from].Sub(w.balances[from], value)
+    w.balances[to].Add(w.balances[to], value)
+    w.transfers++
+    return "0xfund", nil
+}
+
+func TestFunderTopsUpBelowMinimum(t *testing.T) {
+    wallet := &fakeWallet{balances: map[string]*big.Int{
+        "0xpublisher": big.NewInt(40),
+        "0xfunder":    big.NewInt(1000),
+    }}
+    config := &Config{From: "0xpublisher", Funding: &FundingConfig{From: "0xfunder", MinBalance: "50", TopUp: "100"}}
+    f := NewFunder(config, wallet, nil)
+
+    hash, err := f.Check(context.Background())
+    if err != nil || hash != "0xfund" {
+        t.Fatalf("Expected top-up, got %q, %v", hash, err)
+    }
+    if got := wallet.balances["0xpublisher"].Int64(); got != 140 {
+        t.Errorf("Expected balance 140, got %d", got)
+    }
+
+    // Above the minimum nothing is sent
+    if hash, err := f.Check(context.Background()); err != nil || hash != "" {
+        t.Fatalf("Expected no top-up, got %q, %v", hash, err)
+    }
+    if wallet.transfers != 1 || f.Status().TopUps != 1 {
+        t.Errorf("Expected one transfer, got %d", wallet.transfers)
+    }
+}
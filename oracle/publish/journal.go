@@ -0,0 +1,152 @@
+package publish
+
+import (
+    "bufio"
+    "encoding/json"
+    "fmt"
+    "os"
+    "sort"
+    "sync"
+    "time"
+)
+
+// Receipt statuses
+const (
+    StatusPending    = "pending"    // recorded, not yet accepted by the node
+    StatusSubmitted  = "submitted"  // accepted, waiting for confirmations
+    StatusConfirmed  = "confirmed"  // mined successfully with enough confirmations
+    StatusFailed     = "failed"     // submission or execution failed
+    StatusSuperseded = "superseded" // abandoned in favour of a newer round
+)
+
+// Receipt records the publication of one round of a feed
+type Receipt struct {
+    Symbol  string `json:"symbol"`
+    RoundID uint64 `json:"roundId"`
+    Value   string `json:"value"` // scaled integer as published
+    // Composition is the source breakdown published with the value, for
+    // feeds configured to publish one
+    Composition *Composition `json:"composition,omitempty"`
+    Status      string       `json:"status"`
+    TxHash      string       `json:"txHash,omitempty"`
+    GasUsed     uint64       `json:"gasUsed,omitempty"`
+    BlockNumber uint64       `json:"blockNumber,omitempty"`
+    Attempts    int          `json:"attempts"`
+    // Nonce of the sending account, once known; Replacements counts resends
+    // of a stuck transaction at a higher gas price under the same nonce
+    Nonce        *uint64   `json:"nonce,omitempty"`
+    Replacements int       `json:"replacements,omitempty"`
+    LastError    string    `json:"lastError,omitempty"`
+    CreatedAt    time.Time `json:"createdAt"`
+    UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// key identifies a receipt; publishing is idempotent per key
+func (r *Receipt) key() string {
+    return fmt.Sprintf("%s/%d", r.Symbol, r.RoundID)
+}
+
+// Journal is an append-only, fsynced log of receipts. Replaying it on
+// startup restores every receipt's latest state after a crash.
+type Journal struct {
+    mu       sync.RWMutex
+    file     *os.File
+    receipts map[string]*Receipt
+}
+
+// OpenJournal opens or creates the journal at path and replays it
+func OpenJournal(path string) (*Journal, error) {
+    file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+    if err != nil {
+        return nil, fmt.Errorf("failed to open publish journal: %v", err)
+    }
+
+    j := &Journal{file: file, receipts: make(map[string]*Receipt)}
+    scanner := bufio.NewScanner(file)
+    for scanner.Scan() {
+        var r Receipt
+        if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+            // A crash mid-write leaves at most one torn trailing line
+            continue
+        }
+        j.receipts[r.key()] = &r
+    }
+    if err := scanner.Err(); err != nil {
+        file.Close()
+        return nil, fmt.Errorf("failed to replay publish journal: %v", err)
+    }
+    return j, nil
+}
+
+// Put durably records the latest state of a receipt
+func (j *Journal) Put(r *Receipt) error {
+    r.UpdatedAt = time.Now()
+    data, err := json.Marshal(r)
+    if err != nil {
+        return err
+    }
+
+    j.mu.Lock()
+    defer j.mu.Unlock()
+    if _, err := j.file.Write(append(data, '\n')); err != nil {
+        return fmt.Errorf("failed to write publish journal: %v", err)
+    }
+    if err := j.file.Sync(); err != nil {
+        return fmt.Errorf("failed to sync publish journal: %v", err)
+    }
+    stored := *r
+    j.receipts[r.key()] = &stored
+    return nil
+}
+
+// Get returns a copy of the receipt for a round
+func (j *Journal) Get(symbol string, roundID uint64) (*Receipt, bool) {
+    j.mu.RLock()
+    defer j.mu.RUnlock()
+    r, ok := j.receipts[fmt.Sprintf("%s/%d", symbol, roundID)]
+    if !ok {
+        return nil, false
+    }
+    out := *r
+    return &out, true
+}
+
+// List returns copies of a feed's receipts, newest round first; an empty
+// symbol lists every feed
+func (j *Journal) List(symbol string) []*Receipt {
+    j.mu.RLock()
+    out := make([]*Receipt, 0)
+    for _, r := range j.receipts {
+        if symbol == "" || r.Symbol == symbol {
+            copied := *r
+            out = append(out, &copied)
+        }
+    }
+    j.mu.RUnlock()
+
+    sort.Slice(out, func(a, b int) bool {
+        if out[a].Symbol != out[b].Symbol {
+            return out[a].Symbol < out[b].Symbol
+        }
+        return out[a].RoundID > out[b].RoundID
+    })
+    return out
+}
+
+// LastRounds returns the highest recorded round of every feed
+func (j *Journal) LastRounds() map[string]uint64 {
+    j.mu.RLock()
+    defer j.mu.RUnlock()
+    last := make(map[string]uint64)
+    for _, r := range j.receipts {
+        if r.RoundID > last[r.Symbol] {
+            last[r.Symbol] = r.RoundID
+        }
+    }
+    return last
+}
+
+// Close closes the journal file
+func (j *Journal) Close() error {
+    return j.file.Close()
+}
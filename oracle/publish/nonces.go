@@ -0,0 +1,260 @@
+package publish
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "math/big"
+    "time"
+
+    "yetaXYZ/oracle/events"
+    "yetaXYZ/oracle/evm"
+)
+
+// NonceConfig tunes the detection and replacement of stuck transactions
+type NonceConfig struct {
+    // StuckAfterSeconds is how long the account's next transaction may stay
+    // unmined before it is replaced, default 300
+    StuckAfterSeconds int `json:"stuckAfterSeconds,omitempty"`
+    // GasBumpPercent raises the gas price of replacements, default 25;
+    // nodes require at least 10 to accept a replacement
+    GasBumpPercent int `json:"gasBumpPercent,omitempty"`
+    // MaxReplacements caps the replacements of one publication, default 3
+    MaxReplacements int `json:"maxReplacements,omitempty"`
+    // ReportOnly detects stuck transactions and gaps without replacing or
+    // cancelling anything
+    ReportOnly bool `json:"reportOnly,omitempty"`
+}
+
+// defaults fills in unset values
+func (n *NonceConfig) defaults() {
+    if n.StuckAfterSeconds <= 0 {
+        n.StuckAfterSeconds = 300
+    }
+    if n.GasBumpPercent <= 0 {
+        n.GasBumpPercent = 25
+    }
+    if n.MaxReplacements <= 0 {
+        n.MaxReplacements = 3
+    }
+}
+
+// NonceStatus compares the publishing account's nonces with the
+// transactions the pipeline expects to be pending
+type NonceStatus struct {
+    Chain   string `json:"chain,omitempty"`
+    Account string `json:"account"`
+    // Mined and Pending are the account's next nonces after mined
+    // transactions and after those in the node's mempool; Expected follows
+    // the pipeline's highest unconfirmed nonce
+    Mined    uint64 `json:"mined"`
+    Pending  uint64 `json:"pending"`
+    Expected uint64 `json:"expected"`
+    // Gaps are nonces below Expected that no transaction known to the node
+    // holds; transactions after a gap cannot be mined
+    Gaps         []uint64  `json:"gaps"`
+    Stuck        []StuckTx `json:"stuck"`
+    Replacements uint64    `json:"replacements"` // total since start
+    Cancels      uint64    `json:"cancels"`      // total since start
+    CheckedAt    time.Time `json:"checkedAt"`
+    LastError    string    `json:"lastError,omitempty"`
+}
+
+// StuckTx is a publication whose transaction is holding up the account
+type StuckTx struct {
+    Symbol       string  `json:"symbol"`
+    RoundID      uint64  `json:"roundId"`
+    TxHash       string  `json:"txHash"`
+    Nonce        uint64  `json:"nonce"`
+    PendingSecs  float64 `json:"pendingSeconds"`
+    Replacements int     `json:"replacements"`
+}
+
+// NonceStatus returns the last nonce check of the publishing account;
+// ok is false for publishers without account nonces
+func (p *Pipeline) NonceStatus() (NonceStatus, bool) {
+    if _, ok := p.publisher.(NonceManager); !ok {
+        return NonceStatus{}, false
+    }
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    status := p.nonces
+    status.Gaps = append([]uint64{}, status.Gaps...)
+    status.Stuck = append([]StuckTx{}, status.Stuck...)
+    return status, true
+}
+
+// recordNonce notes the nonce of a just submitted transaction; it stays
+// unknown when the node cannot report it yet
+func (p *Pipeline) recordNonce(r *Receipt) {
+    nm, ok := p.publisher.(NonceManager)
+    if !ok {
+        return
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), rpcTimeout)
+    tx, err := nm.Transaction(ctx, r.TxHash)
+    cancel()
+    if err != nil || tx == nil {
+        return
+    }
+    nonce := tx.Nonce
+    r.Nonce = &nonce
+}
+
+// checkNonces compares the account's nonces with the pipeline's
+// unconfirmed transactions, replacing the one holding up the account when
+// it has been pending too long and cancelling nonces left in gaps. Callers
+// hold sending.
+func (p *Pipeline) checkNonces(ctx context.Context, now time.Time) {
+    nm, ok := p.publisher.(NonceManager)
+    if !ok {
+        return
+    }
+    config := p.config.Nonces
+    p.mu.Lock()
+    status := NonceStatus{
+        Chain:        p.config.Chain,
+        Account:      nm.Account(),
+        Gaps:         []uint64{},
+        Stuck:        []StuckTx{},
+        Replacements: p.nonces.Replacements,
+        Cancels:      p.nonces.Cancels,
+        CheckedAt:    now,
+    }
+    p.mu.Unlock()
+    defer func() {
+        p.mu.Lock()
+        p.nonces = status
+        p.mu.Unlock()
+    }()
+
+    callCtx, cancel := context.WithTimeout(ctx, rpcTimeout)
+    defer cancel()
+    mined, pending, err := nm.Nonces(callCtx)
+    if err != nil {
+        status.LastError = err.Error()
+        return
+    }
+    status.Mined, status.Pending, status.Expected = mined, pending, pending
+
+    unconfirmed := make(map[uint64]*Receipt)
+    for _, r := range p.journal.List("") {
+        if r.Status != StatusSubmitted || r.Nonce == nil {
+            continue
+        }
+        unconfirmed[*r.Nonce] = r
+        if *r.Nonce+1 > status.Expected {
+            status.Expected = *r.Nonce + 1
+        }
+    }
+
+    for nonce := mined; nonce < status.Expected; nonce++ {
+        r, ours := unconfirmed[nonce]
+        var tx *evm.Tx
+        if ours {
+            if tx, err = nm.Transaction(callCtx, r.TxHash); err != nil {
+                status.LastError = fmt.Sprintf("failed to look up %s: %v", r.TxHash, err)
+                return
+            }
+        }
+        if tx == nil && nonce >= pending {
+            // Neither a transaction of ours nor one in the mempool: a gap
+            status.Gaps = append(status.Gaps, nonce)
+            if ours {
+                p.transition(r, StatusFailed, fmt.Sprintf("transaction dropped, nonce %d left unused", nonce))
+            }
+            if !config.ReportOnly && p.cancelNonce(callCtx, nm, nonce) {
+                status.Cancels++
+            }
+            continue
+        }
+        if !ours || tx == nil || nonce != mined {
+            continue
+        }
+
+        // The account's next transaction is ours and still unmined
+        pendingFor := now.Sub(r.UpdatedAt)
+        if pendingFor < time.Duration(config.StuckAfterSeconds)*time.Second {
+            continue
+        }
+        status.Stuck = append(status.Stuck, StuckTx{
+            Symbol:       r.Symbol,
+            RoundID:      r.RoundID,
+            TxHash:       r.TxHash,
+            Nonce:        nonce,
+            PendingSecs:  pendingFor.Seconds(),
+            Replacements: r.Replacements,
+        })
+        if !config.ReportOnly && r.Replacements < config.MaxReplacements && p.replace(callCtx, nm, r, tx) {
+            status.Replacements++
+        }
+    }
+}
+
+// replace resends a stuck publication at a bumped gas price, keeping its
+// nonce, and tracks the replacement instead of the original
+func (p *Pipeline) replace(ctx context.Context, nm NonceManager, r *Receipt, tx *evm.Tx) bool {
+    gasPrice, err := p.bumpedGasPrice(ctx, nm, tx.GasPrice)
+    if err != nil {
+        log.Printf("Not replacing stuck publish of %s round %d: %v", r.Symbol, r.RoundID, err)
+        return false
+    }
+    hash, err := nm.Resend(ctx, tx, gasPrice)
+    if err != nil {
+        log.Printf("Failed to replace stuck publish of %s round %d: %v", r.Symbol, r.RoundID, err)
+        return false
+    }
+
+    log.Printf("Replaced stuck publish of %s round %d (nonce %d): %s -> %s at %s wei", r.Symbol, r.RoundID, tx.Nonce, r.TxHash, hash, gasPrice)
+    p.nonceAlert(events.SeverityWarning, fmt.Sprintf("publish of %s round %d stuck at nonce %d; replaced %s with %s at gas price %s", r.Symbol, r.RoundID, tx.Nonce, r.TxHash, hash, gasPrice))
+    r.TxHash = hash
+    r.Replacements++
+    p.transition(r, StatusSubmitted, "")
+    return true
+}
+
+// cancelNonce fills a nonce gap with an empty transfer to the account
+func (p *Pipeline) cancelNonce(ctx context.Context, nm NonceManager, nonce uint64) bool {
+    gasPrice, err := p.bumpedGasPrice(ctx, nm, nil)
+    if err != nil {
+        log.Printf("Not filling nonce gap %d: %v", nonce, err)
+        return false
+    }
+    hash, err := nm.Cancel(ctx, nonce, gasPrice)
+    if err != nil {
+        log.Printf("Failed to fill nonce gap %d: %v", nonce, err)
+        return false
+    }
+    log.Printf("Filled nonce gap %d of %s with %s", nonce, nm.Account(), hash)
+    p.nonceAlert(events.SeverityWarning, fmt.Sprintf("nonce %d of publishing account %s was unused, blocking later transactions; cancelled with %s", nonce, nm.Account(), hash))
+    return true
+}
+
+// bumpedGasPrice returns the higher of the node's price and the bumped
+// price of the transaction being replaced
+func (p *Pipeline) bumpedGasPrice(ctx context.Context, nm NonceManager, previous *big.Int) (*big.Int, error) {
+    price, err := nm.GasPrice(ctx)
+    if err != nil {
+        return nil, err
+    }
+    if previous != nil {
+        bumped := new(big.Int).Mul(previous, big.NewInt(int64(100+p.config.Nonces.GasBumpPercent)))
+        bumped.Div(bumped, big.NewInt(100))
+        if bumped.Cmp(price) > 0 {
+            price = bumped
+        }
+    }
+    return price, nil
+}
+
+// nonceAlert publishes a publisher nonce alert
+func (p *Pipeline) nonceAlert(severity, message string) {
+    p.bus.Publish(events.Event{
+        Type: events.Alert,
+        Payload: &events.AlertPayload{
+            Severity: severity,
+            Kind:     "publisher_nonce",
+            Message:  message,
+        },
+    })
+}
@@ -0,0 +1,134 @@
+package publish
+
+import (
+    "context"
+    "fmt"
+    "math/big"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/events"
+    "yetaXYZ/oracle/evm"
+)
+
+// fakeAccount is a publisher whose node keeps a mempool keyed by nonce
+type fakeAccount struct {
+    fakePublisher
+    mined    uint64
+    mempool  map[string]*evm.Tx
+    resent   []string
+    canceled []uint64
+}
+
+func (f *fakeAccount) Submit(ctx context.Context, symbol string, roundID uint64, value *big.Int) (string, error) {
+    hash, err := f.fakePublisher.Submit(ctx, symbol, roundID, value)
+    if err == nil {
+        _, pending, _ := f.Nonces(ctx)
+        f.mempool[hash] = &evm.Tx{Hash: hash, Nonce: pending, GasPrice: big.NewInt(100)}
+    }
+    return hash, err
+}
+
+func (f *fakeAccount) Account() string { return "0xpublisher" }
+
+func (f *fakeAccount) Nonces(ctx context.Context) (uint64, uint64, error) {
+    pending := f.mined
+    for {
+        found := false
+        for _, tx := range f.mempool {
+            if tx.Nonce == pending {
+                found = true
+            }
+        }
+        if !found {
+            return f.mined, pending, nil
+        }
+        pending++
+    }
+}
+
+func (f *fakeAccount) Transaction(ctx context.Context, hash string) (*evm.Tx, error) {
+    return f.mempool[hash], nil
+}
+
+func (f *fakeAccount) GasPrice(ctx context.Context) (*big.Int, error) {
+    return big.NewInt(110), nil
+}
+
+func (f *fakeAccount) Resend(ctx context.Context, tx *evm.Tx, gasPrice *big.Int) (string, error) {
+    hash := fmt.Sprintf("0xr%d", len(f.resent)+1)
+    delete(f.mempool, tx.Hash)
+    f.mempool[hash] = &evm.Tx{Hash: hash, Nonce: tx.Nonce, GasPrice: gasPrice}
+    f.resent = append(f.resent, hash+"@"+gasPrice.String())
+    return hash, nil
+}
+
+func (f *fakeAccount) Cancel(ctx context.Context, nonce uint64, gasPrice *big.Int) (string, error) {
+    hash := fmt.Sprintf("0xc%d", nonce)
+    f.mempool[hash] = &evm.Tx{Hash: hash, Nonce: nonce, GasPrice: gasPrice}
+    f.canceled = append(f.canceled, nonce)
+    return hash, nil
+}
+
+func TestNonceCheckReplacesStuckAndFillsGaps(t *testing.T) {
+    journal, err := OpenJournal(filepath.Join(t.TempDir(), "publish.journal"))
+    if err != nil {
+        t.Fatalf("Failed to open journal: %v", err)
+    }
+    defer journal.Close()
+
+    account := &fakeAccount{fakePublisher: fakePublisher{receipts: map[string]*evm.TxReceipt{}}, mined: 7, mempool: map[string]*evm.Tx{}}
+    config := &Config{Chain: "1", Decimals: 8, MaxAttempts: 3, Feeds: []string{"ETHUSDT", "BTCUSDT"}}
+    config.Nonces.defaults()
+    p := NewPipeline(config, journal, account, events.NewBus())
+    ctx := context.Background()
+
+    p.Publish(ctx, round("ETHUSDT", 1, 3000))
+    p.Publish(ctx, round("BTCUSDT", 1, 60000))
+    if r, _ := journal.Get("BTCUSDT", 1); r.Nonce == nil || *r.Nonce != 8 {
+        t.Fatalf("Expected the nonce of the submitted transaction to be recorded, got %+v", r)
+    }
+
+    // Fresh transactions are not stuck yet
+    p.checkNonces(ctx, time.Now())
+    if status, _ := p.NonceStatus(); status.Mined != 7 || status.Pending != 9 || status.Expected != 9 || len(status.Stuck) != 0 || len(status.Gaps) != 0 {
+        t.Fatalf("Unexpected status of a healthy account: %+v", status)
+    }
+
+    // The head transaction stays unmined: replaced at a bumped price
+    p.checkNonces(ctx, time.Now().Add(10*time.Minute))
+    status, _ := p.NonceStatus()
+    if len(status.Stuck) != 1 || status.Stuck[0].Nonce != 7 || status.Replacements != 1 {
+        t.Fatalf("Expected the nonce 7 transaction to be reported and replaced, got %+v", status)
+    }
+    if len(account.resent) != 1 || account.resent[0] != "0xr1@125" {
+        t.Errorf("Expected a resend at 125 wei, got %v", account.resent)
+    }
+    if r, _ := journal.Get("ETHUSDT", 1); r.TxHash != "0xr1" || r.Replacements != 1 {
+        t.Errorf("Expected the receipt to track the replacement, got %+v", r)
+    }
+
+    // The node drops nonce 7: a gap in front of nonce 8
+    delete(account.mempool, "0xr1")
+    p.checkNonces(ctx, time.Now())
+    status, _ = p.NonceStatus()
+    if len(status.Gaps) != 1 || status.Gaps[0] != 7 || len(account.canceled) != 1 || status.Cancels != 1 {
+        t.Fatalf("Expected nonce 7 to be reported as a gap and cancelled, got %+v", status)
+    }
+    if r, _ := journal.Get("ETHUSDT", 1); r.Status != StatusFailed {
+        t.Errorf("Expected the dropped publication to be marked failed for retry, got %s", r.Status)
+    }
+}
+
+func TestNonceStatusWithoutAccount(t *testing.T) {
+    journal, err := OpenJournal(filepath.Join(t.TempDir(), "publish.journal"))
+    if err != nil {
+        t.Fatalf("Failed to open journal: %v", err)
+    }
+    defer journal.Close()
+    p := NewPipeline(&Config{Decimals: 8, MaxAttempts: 3}, journal, &fakePublisher{}, events.NewBus())
+    if _, ok := p.NonceStatus(); ok {
+        t.Error("Expected no nonce status for a publisher without account nonces")
+    }
+}
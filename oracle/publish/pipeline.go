@@ -0,0 +1,479 @@
+package publish
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "math"
+    "math/big"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/drill"
+    "yetaXYZ/oracle/events"
+)
+
+// rpcTimeout bounds every call to the publisher
+const rpcTimeout = 15 * time.Second
+
+// Pipeline publishes completed rounds of configured feeds on-chain, at most
+// once per round, and tracks each publication until it is confirmed
+type Pipeline struct {
+    config    *Config
+    journal   *Journal
+    publisher Publisher
+    bus       *events.Bus
+    feeds     map[string]bool
+    // composed are the feeds publishing their source composition
+    composed map[string]bool
+
+    // sending serializes the calls that send and track transactions, so the
+    // account's transactions go out one at a time; mu is never held across
+    // those calls
+    sending sync.Mutex
+    // wake tells the sender that rounds were recorded for publication
+    wake chan struct{}
+
+    mu      sync.Mutex // serializes publication state changes
+    latest  map[string]uint64
+    stopped bool
+    // published is the last value recorded for publication of each feed and
+    // holds the rounds withheld by the breaker
+    published map[string]float64
+    holds     map[string]*Hold
+    // nonces is the last check of the publishing account's nonces
+    nonces NonceStatus
+    // sources orders each feed's sources in composition bitmaps
+    sources SourceList
+    // drills may delay publications for operational drills
+    drills *drill.Drills
+}
+
+// NewPipeline creates a publish pipeline
+func NewPipeline(config *Config, journal *Journal, publisher Publisher, bus *events.Bus) *Pipeline {
+    feeds := make(map[string]bool, len(config.Feeds))
+    for _, symbol := range config.Feeds {
+        feeds[symbol] = true
+    }
+    composed := make(map[string]bool, len(config.Composition))
+    for _, symbol := range config.Composition {
+        composed[symbol] = true
+    }
+    return &Pipeline{
+        config:    config,
+        journal:   journal,
+        publisher: publisher,
+        bus:       bus,
+        feeds:     feeds,
+        composed:  composed,
+        latest:    journal.LastRounds(),
+        published: publishedValues(journal, config.Decimals),
+        holds:     make(map[string]*Hold),
+        wake:      make(chan struct{}, 1),
+    }
+}
+
+// Start resumes publications interrupted by a crash, then publishes new
+// rounds and polls for confirmations every interval until ctx is cancelled.
+// Rounds from the bus are recorded as they arrive and sent from a goroutine
+// of their own, so a slow chain never holds up the bus.
+func (p *Pipeline) Start(ctx context.Context, interval time.Duration) {
+    p.recover(ctx)
+
+    sub := p.bus.SubscribeQueue("publish", func(e events.Event) {
+        if result, ok := e.Payload.(*common.AggregateResult); ok {
+            p.schedule(ctx, result, p.signal)
+        }
+    }, events.Aggregate)
+
+    go func() {
+        defer sub.Close()
+        ticker := time.NewTicker(interval)
+        defer ticker.Stop()
+        for {
+            select {
+            case <-ctx.Done():
+                return
+            case <-p.wake:
+                p.sendRecorded(ctx)
+            case <-ticker.C:
+                p.Poll(ctx)
+            }
+        }
+    }()
+}
+
+// SetDrills sets the drills that may delay publications; call before Start
+func (p *Pipeline) SetDrills(d *drill.Drills) {
+    p.drills = d
+}
+
+// Publish submits a round unless it has already been recorded or the
+// breaker holds it. A drill delaying the feed's publications submits it
+// only once the delay has passed.
+func (p *Pipeline) Publish(ctx context.Context, result *common.AggregateResult) {
+    p.schedule(ctx, result, p.sendRecorded)
+}
+
+// schedule records a round for publication, after any drill delay, and
+// hands it to send
+func (p *Pipeline) schedule(ctx context.Context, result *common.AggregateResult, send func(ctx context.Context)) {
+    if !p.feeds[result.Symbol] {
+        return
+    }
+    if delay := p.drills.PublishDelay(result.Symbol, time.Now()); delay > 0 {
+        log.Printf("Delaying publication of %s round %d by %s for a drill", result.Symbol, result.RoundID, delay)
+        time.AfterFunc(delay, func() {
+            if ctx.Err() == nil && p.publish(ctx, result) {
+                send(ctx)
+            }
+        })
+        return
+    }
+    if p.publish(ctx, result) {
+        send(ctx)
+    }
+}
+
+// signal wakes the sender started by Start
+func (p *Pipeline) signal(ctx context.Context) {
+    select {
+    case p.wake <- struct{}{}:
+    default:
+    }
+}
+
+// publish records a round unless it has already been recorded or the
+// breaker holds it, and reports whether it is to be sent
+func (p *Pipeline) publish(ctx context.Context, result *common.AggregateResult) bool {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+
+    if p.stopped {
+        return false
+    }
+    if _, exists := p.journal.Get(result.Symbol, result.RoundID); exists {
+        return false
+    }
+    if result.RoundID < p.latest[result.Symbol] {
+        return false
+    }
+    if p.hold(result) {
+        return false
+    }
+    return p.record(ctx, result)
+}
+
+// record journals a round for sending, abandoning older unconfirmed ones,
+// and reports whether it is to be sent now; callers hold mu
+func (p *Pipeline) record(ctx context.Context, result *common.AggregateResult) bool {
+    p.supersede(result.Symbol, result.RoundID)
+    p.latest[result.Symbol] = result.RoundID
+
+    value, err := scale(result.Price, p.config.Decimals)
+    if err != nil {
+        log.Printf("Not publishing %s round %d: %v", result.Symbol, result.RoundID, err)
+        return false
+    }
+    composition, err := p.composition(result, value)
+    if err != nil {
+        log.Printf("Not publishing %s round %d: %v", result.Symbol, result.RoundID, err)
+        return false
+    }
+
+    // Record intent before sending so a crash mid-publish is recoverable
+    receipt := &Receipt{
+        Symbol:      result.Symbol,
+        RoundID:     result.RoundID,
+        Value:       value.String(),
+        Composition: composition,
+        Status:      StatusPending,
+        CreatedAt:   time.Now(),
+    }
+    if err := p.journal.Put(receipt); err != nil {
+        log.Printf("Not publishing %s round %d: %v", result.Symbol, result.RoundID, err)
+        return false
+    }
+    p.published[result.Symbol] = result.Price
+    // Shutting down: the pending receipt is resumed on restart
+    return ctx.Err() == nil
+}
+
+// sendRecorded sends the recorded rounds not sent yet
+func (p *Pipeline) sendRecorded(ctx context.Context) {
+    p.sending.Lock()
+    defer p.sending.Unlock()
+    p.sendUnsent(ctx)
+}
+
+// sendUnsent sends every recorded round that was never sent and is still
+// its feed's latest; callers hold sending
+func (p *Pipeline) sendUnsent(ctx context.Context) {
+    for _, r := range p.journal.List("") {
+        if r.Status != StatusPending || r.Attempts > 0 {
+            continue
+        }
+        if ctx.Err() != nil {
+            return // resumed on restart
+        }
+        p.submit(r)
+    }
+}
+
+// Poll sends rounds recorded but not sent yet, confirms submitted
+// publications, retries failed ones of the latest round of each feed when
+// the contract refuses duplicates and checks the account's nonces for
+// stuck transactions
+func (p *Pipeline) Poll(ctx context.Context) {
+    p.sending.Lock()
+    defer p.sending.Unlock()
+
+    if p.isStopped() {
+        return
+    }
+    p.sendUnsent(ctx)
+    var head uint64
+    for _, r := range p.journal.List("") {
+        switch {
+        case r.Status == StatusSubmitted:
+            if head == 0 {
+                callCtx, cancel := context.WithTimeout(ctx, rpcTimeout)
+                n, err := p.publisher.BlockNumber(callCtx)
+                cancel()
+                if err != nil {
+                    log.Printf("Failed to read block number: %v", err)
+                    return
+                }
+                head = n
+            }
+            p.confirm(ctx, r, head)
+        case r.Status == StatusFailed && r.Attempts < p.config.MaxAttempts && p.current(r):
+            // A failed send may still have been broadcast
+            if p.resendable(ctx, r, "not retried") {
+                p.submit(r)
+            }
+        }
+    }
+    p.checkNonces(ctx, time.Now())
+}
+
+// isStopped reports whether Stop was called
+func (p *Pipeline) isStopped() bool {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    return p.stopped
+}
+
+// current reports whether r may be sent: publishing is not stopped and r is
+// its feed's latest round
+func (p *Pipeline) current(r *Receipt) bool {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    return !p.stopped && r.RoundID == p.latest[r.Symbol]
+}
+
+// resendable reports whether a round whose earlier send may have been
+// broadcast can be sent again, which is only safe when the contract
+// refuses a duplicate. A round found on-chain is confirmed instead, and
+// without a guard the round is abandoned with reason.
+func (p *Pipeline) resendable(ctx context.Context, r *Receipt, reason string) bool {
+    guarded, ok := p.publisher.(RoundGuarded)
+    if !ok {
+        log.Printf("Not resending publish of %s round %d: the contract cannot refuse a duplicate", r.Symbol, r.RoundID)
+        p.transition(r, StatusSuperseded, reason+": the contract cannot refuse a duplicate")
+        return false
+    }
+    callCtx, cancel := context.WithTimeout(ctx, rpcTimeout)
+    onChain, err := guarded.LatestRound(callCtx, r.Symbol)
+    cancel()
+    // Without an answer the contract still refuses a duplicate
+    if err == nil && onChain >= r.RoundID {
+        log.Printf("Publish of %s round %d reached the chain after all", r.Symbol, r.RoundID)
+        p.transition(r, StatusConfirmed, "")
+        return false
+    }
+    return true
+}
+
+// recover resumes publications that were interrupted: rounds recorded but
+// possibly never sent are resubmitted if still the latest and the contract
+// guards against publishing them twice, others are abandoned; submitted
+// transactions are left to the confirmation poll
+func (p *Pipeline) recover(ctx context.Context) {
+    p.sending.Lock()
+    defer p.sending.Unlock()
+
+    for _, r := range p.journal.List("") {
+        if r.Status != StatusPending {
+            continue
+        }
+        if !p.current(r) {
+            p.transition(r, StatusSuperseded, "")
+            continue
+        }
+        if r.Attempts == 0 {
+            log.Printf("Resuming unsent publish of %s round %d", r.Symbol, r.RoundID)
+            p.submit(r)
+            continue
+        }
+        // A send interrupted after broadcast left no transaction hash to
+        // check, and a contract without round IDs would take a second copy
+        if p.resendable(ctx, r, "interrupted before its transaction was recorded") {
+            log.Printf("Resuming interrupted publish of %s round %d", r.Symbol, r.RoundID)
+            p.submit(r)
+        }
+    }
+}
+
+// supersede abandons unconfirmed publications of rounds older than roundID
+func (p *Pipeline) supersede(symbol string, roundID uint64) {
+    for _, r := range p.journal.List(symbol) {
+        if r.RoundID < roundID && (r.Status == StatusPending || r.Status == StatusFailed) {
+            p.transition(r, StatusSuperseded, "")
+        }
+    }
+}
+
+// submit sends a receipt's value and records the outcome, unless a newer
+// round superseded it or publishing stopped. It is not cancelled by
+// shutdown: a send interrupted after broadcast could not be told apart from
+// one never made. Callers hold sending.
+func (p *Pipeline) submit(r *Receipt) {
+    value, ok := new(big.Int).SetString(r.Value, 10)
+    if !ok {
+        p.transition(r, StatusFailed, fmt.Sprintf("invalid value %q", r.Value))
+        return
+    }
+
+    // The attempt is journalled first, so a restart knows the round may
+    // have been broadcast; under mu, so no newer round supersedes it first
+    p.mu.Lock()
+    if p.stopped || r.RoundID != p.latest[r.Symbol] {
+        p.mu.Unlock()
+        return
+    }
+    r.Attempts++
+    r.Status = StatusPending
+    err := p.journal.Put(r)
+    p.mu.Unlock()
+    if err != nil {
+        log.Printf("Not publishing %s round %d: %v", r.Symbol, r.RoundID, err)
+        return
+    }
+    callCtx, cancel := context.WithTimeout(context.Background(), rpcTimeout)
+    var hash string
+    if r.Composition != nil {
+        composed, ok := p.publisher.(ComposedPublisher)
+        if !ok {
+            cancel()
+            p.transition(r, StatusFailed, "publisher cannot record source compositions")
+            return
+        }
+        hash, err = composed.SubmitComposed(callCtx, r.Symbol, r.RoundID, value, r.Composition)
+    } else {
+        hash, err = p.publisher.Submit(callCtx, r.Symbol, r.RoundID, value)
+    }
+    cancel()
+    if err != nil {
+        log.Printf("Publish of %s round %d failed (attempt %d): %v", r.Symbol, r.RoundID, r.Attempts, err)
+        if !p.current(r) {
+            // A newer round was recorded during the send
+            p.transition(r, StatusSuperseded, err.Error())
+            return
+        }
+        p.transition(r, StatusFailed, err.Error())
+        return
+    }
+    r.TxHash = hash
+    p.recordNonce(r)
+    p.transition(r, StatusSubmitted, "")
+}
+
+// confirm checks a submitted transaction against the chain head
+func (p *Pipeline) confirm(ctx context.Context, r *Receipt, head uint64) {
+    callCtx, cancel := context.WithTimeout(ctx, rpcTimeout)
+    tx, err := p.publisher.Receipt(callCtx, r.TxHash)
+    cancel()
+    if err != nil {
+        log.Printf("Failed to fetch receipt of %s: %v", r.TxHash, err)
+        return
+    }
+    if tx == nil {
+        return // not mined yet
+    }
+
+    r.BlockNumber = tx.BlockNumber
+    r.GasUsed = tx.GasUsed
+    if !tx.Success {
+        p.transition(r, StatusFailed, "transaction reverted")
+        return
+    }
+    if head+1 >= tx.BlockNumber+p.config.Confirmations {
+        p.transition(r, StatusConfirmed, "")
+    }
+}
+
+// Stop waits for the publication in progress, if any, and closes the
+// journal; nothing is published afterwards. Rounds recorded but not sent
+// and transactions broadcast but not yet confirmed stay in the journal and
+// are resumed by the next Start. ctx of Start should already be cancelled.
+func (p *Pipeline) Stop(ctx context.Context) error {
+    done := make(chan struct{})
+    go func() {
+        p.mu.Lock()
+        p.stopped = true
+        p.mu.Unlock()
+        // Wait for the transaction being sent, if any
+        p.sending.Lock()
+        p.sending.Unlock()
+        close(done)
+    }()
+    select {
+    case <-done:
+    case <-ctx.Done():
+        return fmt.Errorf("publication still in progress at shutdown")
+    }
+
+    var pending, unconfirmed int
+    for _, r := range p.journal.List("") {
+        switch r.Status {
+        case StatusPending:
+            pending++
+        case StatusSubmitted:
+            unconfirmed++
+        }
+    }
+    if pending > 0 || unconfirmed > 0 {
+        log.Printf("Publishing stopped with %d unsent and %d unconfirmed publications, resumed on restart", pending, unconfirmed)
+    }
+    return p.journal.Close()
+}
+
+// transition records a receipt's new status and announces it
+func (p *Pipeline) transition(r *Receipt, status, lastError string) {
+    r.Status = status
+    r.LastError = lastError
+    if err := p.journal.Put(r); err != nil {
+        log.Printf("Failed to record publish receipt of %s round %d: %v", r.Symbol, r.RoundID, err)
+    }
+
+    copied := *r
+    p.bus.Publish(events.Event{
+        Type:    events.PublishReceipt,
+        Symbol:  r.Symbol,
+        Payload: &copied,
+    })
+}
+
+// scale converts a price into the contract's fixed-point integer
+func scale(price float64, decimals int) (*big.Int, error) {
+    if price <= 0 || math.IsNaN(price) || math.IsInf(price, 0) {
+        return nil, fmt.Errorf("price %v cannot be published", price)
+    }
+    f := new(big.Float).SetFloat64(price)
+    f.Mul(f, new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+    f.Add(f, big.NewFloat(0.5))
+    n, _ := f.Int(nil)
+    return n, nil
+}
@@ -0,0 +1,344 @@
+package publish
+
+import (
+    "context"
+    "fmt"
+    "math/big"
+    "path/filepath"
+    "sync"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/drill"
+    "yetaXYZ/oracle/events"
+    "yetaXYZ/oracle/evm"
+)
+
+type fakePublisher struct {
+    mu       sync.Mutex
+    sent     []string
+    fail     bool
+    receipts map[string]*evm.TxReceipt
+    head     uint64
+}
+
+func (f *fakePublisher) Submit(ctx context.Context, symbol string, roundID uint64, value *big.Int) (string, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    if f.fail {
+        return "", fmt.Errorf("nonce too low")
+    }
+    hash := fmt.Sprintf("0x%02d", len(f.sent)+1)
+    f.sent = append(f.sent, symbol+"="+value.String())
+    return hash, nil
+}
+
+func (f *fakePublisher) Receipt(ctx context.Context, hash string) (*evm.TxReceipt, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    return f.receipts[hash], nil
+}
+
+func (f *fakePublisher) BlockNumber(ctx context.Context) (uint64, error) {
+    return f.head, nil
+}
+
+// guardedPublisher stands in for a contract that records round IDs
+type guardedPublisher struct {
+    fakePublisher
+    latest uint64
+}
+
+func (g *guardedPublisher) LatestRound(ctx context.Context, symbol string) (uint64, error) {
+    return g.latest, nil
+}
+
+func round(symbol string, id uint64, price float64) *common.AggregateResult {
+    return &common.AggregateResult{Symbol: symbol, RoundID: id, PricePoint: common.PricePoint{Price: price, Timestamp: time.Now()}}
+}
+
+func TestPipelineIdempotentAndConfirms(t *testing.T) {
+    journal, err := OpenJournal(filepath.Join(t.TempDir(), "publish.journal"))
+    if err != nil {
+        t.Fatalf("Failed to open journal: %v", err)
+    }
+    defer journal.Close()
+
+    publisher := &fakePublisher{receipts: map[string]*evm.TxReceipt{}}
+    config := &Config{Decimals: 8, Confirmations: 2, MaxAttempts: 3, Feeds: []string{"ETHUSDT"}}
+    p := NewPipeline(config, journal, publisher, events.NewBus())
+    ctx := context.Background()
+
+    p.Publish(ctx, round("ETHUSDT", 1, 3000.5))
+    p.Publish(ctx, round("ETHUSDT", 1, 3000.5))
+    p.Publish(ctx, round("BTCUSDT", 1, 60000))
+    if len(publisher.sent) != 1 || publisher.sent[0] != "ETHUSDT=300050000000" {
+        t.Fatalf("Expected a single scaled publication, got %v", publisher.sent)
+    }
+
+    // Mined but not yet confirmed, then confirmed one block later
+    publisher.receipts["0x01"] = &evm.TxReceipt{BlockNumber: 100, GasUsed: 52000, Success: true}
+    publisher.head = 100
+    p.Poll(ctx)
+    if r, _ := journal.Get("ETHUSDT", 1); r.Status != StatusSubmitted {
+        t.Errorf("Expected submitted before enough confirmations, got %s", r.Status)
+    }
+    publisher.head = 101
+    p.Poll(ctx)
+    r, _ := journal.Get("ETHUSDT", 1)
+    if r.Status != StatusConfirmed || r.GasUsed != 52000 || r.TxHash != "0x01" {
+        t.Errorf("Expected confirmed receipt with gas, got %+v", r)
+    }
+}
+
+func TestPipelineRecoversAfterCrash(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "publish.journal")
+    journal, err := OpenJournal(path)
+    if err != nil {
+        t.Fatalf("Failed to open journal: %v", err)
+    }
+
+    // Simulate a crash while sending the latest of two rounds
+    journal.Put(&Receipt{Symbol: "ETHUSDT", RoundID: 4, Value: "1", Status: StatusPending})
+    journal.Put(&Receipt{Symbol: "ETHUSDT", RoundID: 5, Value: "2", Status: StatusPending, Attempts: 1})
+    journal.Close()
+
+    journal, err = OpenJournal(path)
+    if err != nil {
+        t.Fatalf("Failed to reopen journal: %v", err)
+    }
+    defer journal.Close()
+    if last := journal.LastRounds()["ETHUSDT"]; last != 5 {
+        t.Fatalf("Expected last round 5 after replay, got %d", last)
+    }
+
+    publisher := &guardedPublisher{latest: 4}
+    p := NewPipeline(&Config{MaxAttempts: 2, Feeds: []string{"ETHUSDT"}}, journal, publisher, events.NewBus())
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    p.Start(ctx, time.Hour)
+
+    if len(publisher.sent) != 1 || publisher.sent[0] != "ETHUSDT=2" {
+        t.Errorf("Expected only the latest interrupted round to be resubmitted, got %v", publisher.sent)
+    }
+    if r, _ := journal.Get("ETHUSDT", 4); r.Status != StatusSuperseded {
+        t.Errorf("Expected older interrupted round superseded, got %s", r.Status)
+    }
+
+    // Failed submissions are retried up to MaxAttempts
+    publisher.fail = true
+    p.Publish(ctx, round("ETHUSDT", 6, 1))
+    p.Poll(ctx)
+    p.Poll(ctx)
+    if r, _ := journal.Get("ETHUSDT", 6); r.Status != StatusFailed || r.Attempts != 2 {
+        t.Errorf("Expected failed receipt after 2 attempts, got %+v", r)
+    }
+}
+
+func TestPipelineRecoverNeverPublishesTwice(t *testing.T) {
+    for _, tc := range []struct {
+        name      string
+        publisher Publisher
+        status    string
+    }{
+        {"published before the crash", &guardedPublisher{latest: 5}, StatusConfirmed},
+        {"contract without round IDs", &fakePublisher{}, StatusSuperseded},
+    } {
+        journal, err := OpenJournal(filepath.Join(t.TempDir(), "publish.journal"))
+        if err != nil {
+            t.Fatalf("Failed to open journal: %v", err)
+        }
+        journal.Put(&Receipt{Symbol: "ETHUSDT", RoundID: 5, Value: "2", Status: StatusPending, Attempts: 1})
+
+        p := NewPipeline(&Config{MaxAttempts: 2, Feeds: []string{"ETHUSDT"}}, journal, tc.publisher, events.NewBus())
+        ctx, cancel := context.WithCancel(context.Background())
+        p.Start(ctx, time.Hour)
+        cancel()
+
+        var sent []string
+        switch pub := tc.publisher.(type) {
+        case *guardedPublisher:
+            sent = pub.sent
+        case *fakePublisher:
+            sent = pub.sent
+        }
+        if len(sent) != 0 {
+            t.Errorf("%s: expected nothing resent, got %v", tc.name, sent)
+        }
+        if r, _ := journal.Get("ETHUSDT", 5); r.Status != tc.status {
+            t.Errorf("%s: expected %s, got %s", tc.name, tc.status, r.Status)
+        }
+        journal.Close()
+    }
+}
+
+func TestPipelineStopLeavesUnsentForRestart(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "publish.journal")
+    journal, err := OpenJournal(path)
+    if err != nil {
+        t.Fatalf("Failed to open journal: %v", err)
+    }
+
+    publisher := &fakePublisher{receipts: map[string]*evm.TxReceipt{}}
+    config := &Config{MaxAttempts: 2, Feeds: []string{"ETHUSDT"}}
+    p := NewPipeline(config, journal, publisher, events.NewBus())
+    ctx, cancel := context.WithCancel(context.Background())
+    p.Start(ctx, time.Hour)
+    p.Publish(ctx, round("ETHUSDT", 1, 1))
+
+    // Rounds delivered after shutdown began are recorded but not sent
+    cancel()
+    p.Publish(ctx, round("ETHUSDT", 2, 1))
+    if err := p.Stop(context.Background()); err != nil {
+        t.Fatalf("Stop failed: %v", err)
+    }
+    p.Publish(context.Background(), round("ETHUSDT", 3, 1))
+    if len(publisher.sent) != 1 {
+        t.Fatalf("Expected only the round before shutdown sent, got %v", publisher.sent)
+    }
+
+    journal, err = OpenJournal(path)
+    if err != nil {
+        t.Fatalf("Failed to reopen journal: %v", err)
+    }
+    defer journal.Close()
+    if r, _ := journal.Get("ETHUSDT", 1); r.Status != StatusSubmitted {
+        t.Errorf("Expected unconfirmed round 1 kept as submitted, got %s", r.Status)
+    }
+    if _, ok := journal.Get("ETHUSDT", 3); ok {
+        t.Error("Expected nothing recorded after Stop")
+    }
+
+    p = NewPipeline(config, journal, publisher, events.NewBus())
+    ctx, cancel = context.WithCancel(context.Background())
+    defer cancel()
+    p.Start(ctx, time.Hour)
+    if len(publisher.sent) != 2 {
+        t.Errorf("Expected pending round 2 resumed on restart, got %v", publisher.sent)
+    }
+}
+
+func TestPipelineDelaysPublicationDuringDrill(t *testing.T) {
+    journal, err := OpenJournal(filepath.Join(t.TempDir(), "publish.journal"))
+    if err != nil {
+        t.Fatalf("Failed to open journal: %v", err)
+    }
+    defer journal.Close()
+
+    publisher := &fakePublisher{receipts: map[string]*evm.TxReceipt{}}
+    config := &Config{Decimals: 8, Confirmations: 1, MaxAttempts: 3, Feeds: []string{"ETHUSDT"}}
+    p := NewPipeline(config, journal, publisher, events.NewBus())
+    drills := drill.New(nil)
+    p.SetDrills(drills)
+    if _, err := drills.Start(drill.Spec{Kind: drill.KindPublishDelay, Target: "ETHUSDT", Delay: 50 * time.Millisecond}, time.Now()); err != nil {
+        t.Fatalf("Failed to start drill: %v", err)
+    }
+
+    p.Publish(context.Background(), round("ETHUSDT", 1, 3000))
+    publisher.mu.Lock()
+    sent := len(publisher.sent)
+    publisher.mu.Unlock()
+    if sent != 0 {
+        t.Fatal("Expected the publication to be held back during the drill")
+    }
+    time.Sleep(200 * time.Millisecond)
+    publisher.mu.Lock()
+    defer publisher.mu.Unlock()
+    if len(publisher.sent) != 1 {
+        t.Errorf("Expected the round published once the delay passed, got %v", publisher.sent)
+    }
+}
+
+// blockingPublisher holds every send until released
+type blockingPublisher struct {
+    fakePublisher
+    release chan struct{}
+}
+
+func (b *blockingPublisher) Submit(ctx context.Context, symbol string, roundID uint64, value *big.Int) (string, error) {
+    <-b.release
+    return b.fakePublisher.Submit(ctx, symbol, roundID, value)
+}
+
+func TestPipelineRecordsRoundsWhileSending(t *testing.T) {
+    journal, err := OpenJournal(filepath.Join(t.TempDir(), "publish.journal"))
+    if err != nil {
+        t.Fatalf("Failed to open journal: %v", err)
+    }
+    defer journal.Close()
+
+    publisher := &blockingPublisher{release: make(chan struct{})}
+    bus := events.NewBus()
+    p := NewPipeline(&Config{MaxAttempts: 2, Feeds: []string{"ETHUSDT"}}, journal, publisher, bus)
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    p.Start(ctx, time.Hour)
+
+    // More rounds than the subscription buffers arrive while the first send
+    // is stuck on the chain
+    deadline := time.Now().Add(5 * time.Second)
+    for id := uint64(1); id <= 150; id++ {
+        bus.Publish(events.Event{Type: events.Aggregate, Symbol: "ETHUSDT", Payload: round("ETHUSDT", id, float64(id))})
+        for {
+            if _, ok := journal.Get("ETHUSDT", id); ok {
+                break
+            }
+            if time.Now().After(deadline) {
+                t.Fatalf("Expected round %d recorded while a send was in progress", id)
+            }
+            time.Sleep(time.Millisecond)
+        }
+    }
+    close(publisher.release)
+
+    for {
+        publisher.mu.Lock()
+        sent := append([]string{}, publisher.sent...)
+        publisher.mu.Unlock()
+        if len(sent) > 0 && sent[len(sent)-1] == "ETHUSDT=150" {
+            break
+        }
+        if time.Now().After(deadline) {
+            t.Fatalf("Expected the last round sent once the chain answered, got %v", sent)
+        }
+        time.Sleep(10 * time.Millisecond)
+    }
+}
+
+func TestPipelinePollResendsOnlyWhatTheChainRefuses(t *testing.T) {
+    for _, tc := range []struct {
+        name      string
+        publisher Publisher
+        status    string
+        resent    bool
+    }{
+        {"published after all", &guardedPublisher{latest: 5}, StatusConfirmed, false},
+        {"not on the chain", &guardedPublisher{latest: 4}, StatusSubmitted, true},
+        {"contract without round IDs", &fakePublisher{}, StatusSuperseded, false},
+    } {
+        journal, err := OpenJournal(filepath.Join(t.TempDir(), "publish.journal"))
+        if err != nil {
+            t.Fatalf("Failed to open journal: %v", err)
+        }
+        journal.Put(&Receipt{Symbol: "ETHUSDT", RoundID: 5, Value: "2", Status: StatusFailed, Attempts: 1})
+
+        p := NewPipeline(&Config{MaxAttempts: 2, Feeds: []string{"ETHUSDT"}}, journal, tc.publisher, events.NewBus())
+        p.Poll(context.Background())
+
+        var sent []string
+        switch pub := tc.publisher.(type) {
+        case *guardedPublisher:
+            sent = pub.sent
+        case *fakePublisher:
+            sent = pub.sent
+        }
+        if resent := len(sent) > 0; resent != tc.resent {
+            t.Errorf("%s: expected resent %v, got %v", tc.name, tc.resent, sent)
+        }
+        if r, _ := journal.Get("ETHUSDT", 5); r.Status != tc.status {
+            t.Errorf("%s: expected %s, got %s", tc.name, tc.status, r.Status)
+        }
+        journal.Close()
+    }
+}
@@ -0,0 +1,203 @@
+package publish
+
+import (
+    "context"
+    "encoding/hex"
+    "fmt"
+    "math/big"
+    "strings"
+
+    "yetaXYZ/oracle/evm"
+)
+
+// selectorUpdateFeed is updateFeed(bytes32,uint256,address) on ModernOracle
+const selectorUpdateFeed = "55713371"
+
+// Publisher submits feed values on-chain and reports their receipts
+type Publisher interface {
+    Submit(ctx context.Context, symbol string, roundID uint64, value *big.Int) (txHash string, err error)
+    Receipt(ctx context.Context, txHash string) (*evm.TxReceipt, error)
+    BlockNumber(ctx context.Context) (uint64, error)
+}
+
+// RoundGuarded is implemented by publishers whose contract refuses rounds
+// older than its latest, so an interrupted publication can be resent
+// without publishing the round twice
+type RoundGuarded interface {
+    // LatestRound returns the feed's latest round recorded on-chain
+    LatestRound(ctx context.Context, symbol string) (uint64, error)
+}
+
+// NonceManager is implemented by publishers sending from an account whose
+// nonces can be inspected, so that stuck transactions can be replaced and
+// nonce gaps filled
+type NonceManager interface {
+    Account() string
+    // Nonces returns the account's next nonce after mined transactions and
+    // after those pending in the node's mempool
+    Nonces(ctx context.Context) (mined, pending uint64, err error)
+    Transaction(ctx context.Context, txHash string) (*evm.Tx, error)
+    GasPrice(ctx context.Context) (*big.Int, error)
+    // Resend replaces a pending transaction with a copy at gasPrice
+    Resend(ctx context.Context, tx *evm.Tx, gasPrice *big.Int) (txHash string, err error)
+    // Cancel uses up a nonce with an empty transfer to the account itself
+    Cancel(ctx context.Context, nonce uint64, gasPrice *big.Int) (txHash string, err error)
+}
+
+// account is the sending account of a publisher, implementing NonceManager
+type account struct {
+    client *evm.Client
+    from   string
+}
+
+// Account returns the sending address
+func (a account) Account() string {
+    return a.from
+}
+
+// Nonces returns the next nonces of the account, mined and pending
+func (a account) Nonces(ctx context.Context) (uint64, uint64, error) {
+    mined, err := a.client.TransactionCount(ctx, a.from, "latest")
+    if err != nil {
+        return 0, 0, err
+    }
+    pending, err := a.client.TransactionCount(ctx, a.from, "pending")
+    if err != nil {
+        return 0, 0, err
+    }
+    return mined, pending, nil
+}
+
+// Transaction returns a transaction known to the node, nil once dropped
+func (a account) Transaction(ctx context.Context, txHash string) (*evm.Tx, error) {
+    return a.client.Transaction(ctx, txHash)
+}
+
+// GasPrice returns the node's suggested gas price
+func (a account) GasPrice(ctx context.Context) (*big.Int, error) {
+    return a.client.GasPrice(ctx)
+}
+
+// Resend sends a copy of tx with its nonce at gasPrice
+func (a account) Resend(ctx context.Context, tx *evm.Tx, gasPrice *big.Int) (string, error) {
+    return a.client.SendTransactionAt(ctx, a.from, tx.To, tx.Input, tx.Value, tx.Nonce, gasPrice)
+}
+
+// Cancel sends an empty transfer to the account itself at nonce
+func (a account) Cancel(ctx context.Context, nonce uint64, gasPrice *big.Int) (string, error) {
+    return a.client.SendTransactionAt(ctx, a.from, a.from, nil, nil, nonce, gasPrice)
+}
+
+// EVMPublisher publishes to the ModernOracle contract over JSON-RPC
+type EVMPublisher struct {
+    account
+    contract string
+}
+
+// NewEVMPublisher creates a publisher for the configured contract
+func NewEVMPublisher(client *evm.Client, contract, from string) *EVMPublisher {
+    return &EVMPublisher{account: account{client: client, from: from}, contract: contract}
+}
+
+// Submit sends updateFeed(symbol, value, from); ModernOracle keeps no round IDs
+func (p *EVMPublisher) Submit(ctx context.Context, symbol string, roundID uint64, value *big.Int) (string, error) {
+    feedID, err := evm.EncodeBytes32(symbol)
+    if err != nil {
+        return "", err
+    }
+    amount, err := evm.EncodeUint256(value)
+    if err != nil {
+        return "", err
+    }
+    source, err := evm.EncodeAddress(p.from)
+    if err != nil {
+        return "", err
+    }
+
+    selector, _ := hex.DecodeString(selectorUpdateFeed)
+    data := append(selector, feedID...)
+    data = append(data, amount...)
+    data = append(data, source...)
+    return p.client.SendTransaction(ctx, p.from, p.contract, data)
+}
+
+// Receipt returns the receipt of a transaction, nil while pending
+func (p *EVMPublisher) Receipt(ctx context.Context, txHash string) (*evm.TxReceipt, error) {
+    return p.client.TransactionReceipt(ctx, txHash)
+}
+
+// BlockNumber returns the latest block number
+func (p *EVMPublisher) BlockNumber(ctx context.Context) (uint64, error) {
+    return p.client.BlockNumber(ctx)
+}
+
+// PriceFeedPublisher publishes to the reference PriceFeed contract
+// (contracts/PriceFeed.sol), which records the oracle's round IDs
+type PriceFeedPublisher struct {
+    account
+    feed *evm.PriceFeed
+}
+
+// NewPriceFeedPublisher creates a publisher for a PriceFeed contract; from
+// must be an authorized publisher of the contract
+func NewPriceFeedPublisher(client *evm.Client, contract, from string) *PriceFeedPublisher {
+    return &PriceFeedPublisher{account: account{client: client, from: from}, feed: evm.NewPriceFeed(client, contract)}
+}
+
+// Submit sends updateFeed(symbol, roundID, value)
+func (p *PriceFeedPublisher) Submit(ctx context.Context, symbol string, roundID uint64, value *big.Int) (string, error) {
+    return p.feed.UpdateFeed(ctx, p.from, symbol, roundID, value)
+}
+
+// SubmitComposed sends updateFeedWithComposition(symbol, roundID, value,
+// bitmap, hash)
+func (p *PriceFeedPublisher) SubmitComposed(ctx context.Context, symbol string, roundID uint64, value *big.Int, c *Composition) (string, error) {
+    bitmap, ok := new(big.Int).SetString(strings.TrimPrefix(c.Bitmap, "0x"), 16)
+    if !ok {
+        return "", fmt.Errorf("invalid composition bitmap %q", c.Bitmap)
+    }
+    hash, err := hex.DecodeString(strings.TrimPrefix(c.Hash, "0x"))
+    if err != nil {
+        return "", fmt.Errorf("invalid composition hash %q", c.Hash)
+    }
+    return p.feed.UpdateFeedWithComposition(ctx, p.from, symbol, roundID, value, bitmap, hash)
+}
+
+// LatestRound returns the feed's latest published round
+func (p *PriceFeedPublisher) LatestRound(ctx context.Context, symbol string) (uint64, error) {
+    round, err := p.feed.LatestRoundData(ctx, symbol)
+    if err != nil {
+        return 0, err
+    }
+    return round.RoundID, nil
+}
+
+// RoundComposition reads the bitmap and hash recorded for a round
+func (p *PriceFeedPublisher) RoundComposition(ctx context.Context, symbol string, roundID uint64) (*big.Int, string, error) {
+    bitmap, hash, err := p.feed.GetRoundComposition(ctx, symbol, roundID)
+    if err != nil {
+        return nil, "", err
+    }
+    return bitmap, "0x" + hex.EncodeToString(hash), nil
+}
+
+// Receipt returns the receipt of a transaction, nil while pending
+func (p *PriceFeedPublisher) Receipt(ctx context.Context, txHash string) (*evm.TxReceipt, error) {
+    return p.client.TransactionReceipt(ctx, txHash)
+}
+
+// BlockNumber returns the latest block number
+func (p *PriceFeedPublisher) BlockNumber(ctx context.Context) (uint64, error) {
+    return p.client.BlockNumber(ctx)
+}
+
+// NewPublisher creates the publisher for the configured contract type
+func NewPublisher(config *Config, client *evm.Client) (Publisher, error) {
+    switch config.ContractType {
+    case "", ContractModernOracle:
+        return NewEVMPublisher(client, config.Contract, config.From), nil
+    case ContractPriceFeed:
+        return NewPriceFeedPublisher(client, config.Contract, config.From), nil
+    }
+    return nil, fmt.Errorf("unknown publish contract type %q", config.ContractType)
+}
@@ -0,0 +1,458 @@
+package randomness
+
+import (
+    "bytes"
+    "context"
+    "encoding/binary"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "log"
+    "math/big"
+    "net/http"
+    "os"
+    "sort"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/credentials"
+    "yetaXYZ/oracle/events"
+    "yetaXYZ/oracle/fetch"
+)
+
+// Domain prefixes keep beacon inputs and requested seeds apart, so no
+// requested proof reveals a future beacon round
+const (
+    beaconDomain  = "yetaxyz/beacon/v1:"
+    requestDomain = "yetaxyz/request/v1:"
+)
+
+// Round is a beacon round. Output is the random value; Proof proves it was
+// derived from Input by the beacon's key (vrf) or network (drand).
+type Round struct {
+    Round     uint64    `json:"round"`
+    Mode      string    `json:"mode"`
+    Timestamp time.Time `json:"timestamp"`
+    Input     string    `json:"input,omitempty"`
+    Output    string    `json:"output"`
+    Proof     string    `json:"proof"`
+    // PublicKey verifies a vrf round; it changes when the key is rotated
+    PublicKey string `json:"publicKey,omitempty"`
+    // PreviousSignature chains drand rounds
+    PreviousSignature string `json:"previousSignature,omitempty"`
+    // TxHash is the publication of the round, when published
+    TxHash string `json:"txHash,omitempty"`
+}
+
+// Proof is a VRF proof of a requested seed
+type Proof struct {
+    Suite     string `json:"suite"`
+    PublicKey string `json:"publicKey"`
+    Seed      string `json:"seed"`
+    Input     string `json:"input"`
+    Output    string `json:"output"`
+    Proof     string `json:"proof"`
+}
+
+// Submitter publishes values on-chain, as publish.Publisher does
+type Submitter interface {
+    Submit(ctx context.Context, symbol string, roundID uint64, value *big.Int) (txHash string, err error)
+}
+
+// RoundError is returned for rounds the beacon cannot serve
+type RoundError struct {
+    Round  uint64
+    Future bool
+}
+
+func (e *RoundError) Error() string {
+    if e.Future {
+        return fmt.Sprintf("round %d has not started", e.Round)
+    }
+    return fmt.Sprintf("round %d is not available", e.Round)
+}
+
+// retiredKey is a rotated-out vrf key and the last round it signed
+type retiredKey struct {
+    until uint64
+    key   *PrivateKey
+}
+
+// keyEpoch is a vrf public key and the first round it signs, as recorded
+// in the key state file
+type keyEpoch struct {
+    PublicKey string `json:"publicKey"`
+    From      uint64 `json:"from"`
+}
+
+// Beacon produces a verifiable random value every period
+type Beacon struct {
+    config    *Config
+    bus       *events.Bus
+    client    *http.Client
+    submitter Submitter
+
+    mu  sync.Mutex
+    key *PrivateKey // vrf mode only
+    // retired keys, in rotation order, keep recomputed rounds unchanged
+    retired []retiredKey
+    // epochs are the recorded keys in rotation order; first is the
+    // earliest round signed by a key still held
+    epochs []keyEpoch
+    first  uint64
+    rounds map[uint64]*Round
+    latest *Round
+}
+
+// NewBeacon creates a beacon; in vrf mode the operator key is read from the
+// configured credential
+func NewBeacon(config *Config, bus *events.Bus) (*Beacon, error) {
+    b := &Beacon{
+        config: config,
+        bus:    bus,
+        client: fetch.NewClient(10 * time.Second),
+        rounds: make(map[uint64]*Round),
+    }
+    if config.Mode == ModeVRF {
+        key, err := ParsePrivateKey(credentials.Get(config.KeyEnv))
+        if err != nil {
+            return nil, fmt.Errorf("invalid %s: %v", config.KeyEnv, err)
+        }
+        b.key = key
+        if err := b.loadEpochs(time.Now()); err != nil {
+            return nil, err
+        }
+    }
+    return b, nil
+}
+
+// loadEpochs reads the key state file and finds the first round of the
+// current key. A key not recorded last was changed while the beacon was
+// down, so it signs from the next round on.
+func (b *Beacon) loadEpochs(now time.Time) error {
+    if b.config.KeyState == "" {
+        b.first = 1
+        return nil
+    }
+    data, err := os.ReadFile(b.config.KeyState)
+    if err != nil && !os.IsNotExist(err) {
+        return fmt.Errorf("failed to read randomness key state: %v", err)
+    }
+    if err == nil {
+        if err := json.Unmarshal(data, &b.epochs); err != nil {
+            return fmt.Errorf("failed to parse randomness key state: %v", err)
+        }
+    }
+
+    public := hex.EncodeToString(b.key.PublicKey())
+    if n := len(b.epochs); n > 0 && b.epochs[n-1].PublicKey == public {
+        b.first = b.epochs[n-1].From
+        return nil
+    }
+    b.first = 1
+    if len(b.epochs) > 0 {
+        b.first = b.currentRound(now) + 1
+    }
+    b.epochs = append(b.epochs, keyEpoch{PublicKey: public, From: b.first})
+    return b.saveEpochs()
+}
+
+// saveEpochs writes the key state file; callers hold mu or own b
+func (b *Beacon) saveEpochs() error {
+    if b.config.KeyState == "" {
+        return nil
+    }
+    data, err := json.MarshalIndent(b.epochs, "", "    ")
+    if err != nil {
+        return err
+    }
+    tmp := b.config.KeyState + ".tmp"
+    if err := os.WriteFile(tmp, data, 0600); err != nil {
+        return fmt.Errorf("failed to write randomness key state: %v", err)
+    }
+    return os.Rename(tmp, b.config.KeyState)
+}
+
+// SetSubmitter publishes rounds on-chain under the configured feed
+func (b *Beacon) SetSubmitter(submitter Submitter) {
+    b.submitter = submitter
+}
+
+// Mode returns the beacon mode
+func (b *Beacon) Mode() string {
+    return b.config.Mode
+}
+
+// PublicKey returns the hex-encoded current VRF public key, empty in drand
+// mode
+func (b *Beacon) PublicKey() string {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if b.key == nil {
+        return ""
+    }
+    return hex.EncodeToString(b.key.PublicKey())
+}
+
+// Rekey rotates the vrf key. Rounds up to the current one stay under the
+// old key, which is kept so recomputing them yields the same outputs; the
+// new key signs from the next round on. Retired keys live in memory only;
+// the key state file records the rotation, so after a restart rounds
+// before it are refused instead of recomputed under the new key.
+func (b *Beacon) Rekey(hexKey string, now time.Time) error {
+    if b.config.Mode != ModeVRF {
+        return fmt.Errorf("only vrf beacons have a key")
+    }
+    key, err := ParsePrivateKey(hexKey)
+    if err != nil {
+        return fmt.Errorf("invalid %s: %v", b.config.KeyEnv, err)
+    }
+
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if bytes.Equal(key.PublicKey(), b.key.PublicKey()) {
+        return nil
+    }
+    // The rotation is recorded before the new key signs anything
+    until := b.currentRound(now)
+    b.epochs = append(b.epochs, keyEpoch{PublicKey: hex.EncodeToString(key.PublicKey()), From: until + 1})
+    if err := b.saveEpochs(); err != nil {
+        b.epochs = b.epochs[:len(b.epochs)-1]
+        return err
+    }
+    b.retired = append(b.retired, retiredKey{until: until, key: b.key})
+    b.key = key
+    return nil
+}
+
+// keyFor returns the key that signs round n, nil for rounds signed by a
+// key no longer held
+func (b *Beacon) keyFor(n uint64) *PrivateKey {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if n < b.first {
+        return nil
+    }
+    for _, r := range b.retired {
+        if n <= r.until {
+            return r.key
+        }
+    }
+    return b.key
+}
+
+// KeyEnv returns the credential holding the vrf key
+func (b *Beacon) KeyEnv() string {
+    return b.config.KeyEnv
+}
+
+// Interval returns the round period
+func (b *Beacon) Interval() time.Duration {
+    return b.config.Period()
+}
+
+// Run produces a round every interval until ctx is cancelled
+func (b *Beacon) Run(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        if err := b.Tick(ctx, time.Now()); err != nil {
+            log.Printf("Error producing randomness: %v", err)
+            b.bus.Publish(events.Event{
+                Type:      events.Alert,
+                Timestamp: time.Now(),
+                Payload: &events.AlertPayload{
+                    Severity: events.SeverityWarning,
+                    Kind:     "randomness_unavailable",
+                    Message:  err.Error(),
+                },
+            })
+        }
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+// Tick produces the current round unless it already exists
+func (b *Beacon) Tick(ctx context.Context, now time.Time) error {
+    var round *Round
+    switch b.config.Mode {
+    case ModeVRF:
+        current := b.currentRound(now)
+        if current == 0 || b.keyFor(current) == nil {
+            return nil // before genesis or the current key's first round
+        }
+        var err error
+        if round, err = b.vrfRound(current); err != nil {
+            return err
+        }
+    case ModeDrand:
+        beacon, err := fetchDrand(ctx, b.client, b.config.Drand)
+        if err != nil {
+            return err
+        }
+        round = &Round{
+            Round:             beacon.Round,
+            Mode:              ModeDrand,
+            Timestamp:         now,
+            Output:            beacon.Randomness,
+            Proof:             beacon.Signature,
+            PreviousSignature: beacon.PreviousSignature,
+        }
+    }
+
+    b.mu.Lock()
+    if _, seen := b.rounds[round.Round]; seen {
+        b.mu.Unlock()
+        return nil
+    }
+    b.record(round)
+    b.mu.Unlock()
+
+    if b.submitter != nil && b.config.PublishFeed != "" {
+        b.publish(ctx, round)
+    }
+    return nil
+}
+
+// record keeps a round, evicting the oldest beyond the history; callers
+// hold mu
+func (b *Beacon) record(round *Round) {
+    b.rounds[round.Round] = round
+    if b.latest == nil || round.Round > b.latest.Round {
+        b.latest = round
+    }
+    if len(b.rounds) > b.config.History {
+        numbers := make([]uint64, 0, len(b.rounds))
+        for n := range b.rounds {
+            numbers = append(numbers, n)
+        }
+        sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+        for _, n := range numbers[:len(numbers)-b.config.History] {
+            delete(b.rounds, n)
+        }
+    }
+}
+
+// publish submits a round's output on-chain and records the transaction
+func (b *Beacon) publish(ctx context.Context, round *Round) {
+    output, err := hex.DecodeString(round.Output)
+    if err != nil {
+        return
+    }
+    txHash, err := b.submitter.Submit(ctx, b.config.PublishFeed, round.Round, new(big.Int).SetBytes(output))
+    if err != nil {
+        log.Printf("Error publishing randomness round %d: %v", round.Round, err)
+        return
+    }
+    b.mu.Lock()
+    round.TxHash = txHash
+    b.mu.Unlock()
+}
+
+// Latest returns the most recent round
+func (b *Beacon) Latest() (*Round, bool) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if b.latest == nil {
+        return nil, false
+    }
+    round := *b.latest
+    return &round, true
+}
+
+// Round returns round n. In vrf mode any started round signed by a key
+// still held can be recomputed; in drand mode only relayed rounds within
+// the history are available.
+func (b *Beacon) Round(n uint64, now time.Time) (*Round, error) {
+    b.mu.Lock()
+    if round, ok := b.rounds[n]; ok {
+        copied := *round
+        b.mu.Unlock()
+        return &copied, nil
+    }
+    b.mu.Unlock()
+
+    if b.config.Mode != ModeVRF || n == 0 {
+        return nil, &RoundError{Round: n}
+    }
+    if n > b.currentRound(now) {
+        return nil, &RoundError{Round: n, Future: true}
+    }
+    return b.vrfRound(n)
+}
+
+// Prove returns a VRF proof of a consumer's seed, vrf mode only. Seeds
+// should be committed before they are known to the operator, e.g. a future
+// block hash, since anyone holding the key can compute any seed's output.
+func (b *Beacon) Prove(seed []byte) (*Proof, error) {
+    if b.config.Mode != ModeVRF {
+        return nil, fmt.Errorf("requested proofs require vrf mode")
+    }
+    b.mu.Lock()
+    key := b.key
+    b.mu.Unlock()
+    alpha := append([]byte(requestDomain), seed...)
+    proof, err := key.Prove(alpha)
+    if err != nil {
+        return nil, err
+    }
+    output, err := ProofToHash(proof)
+    if err != nil {
+        return nil, err
+    }
+    return &Proof{
+        Suite:     Suite,
+        PublicKey: hex.EncodeToString(key.PublicKey()),
+        Seed:      hex.EncodeToString(seed),
+        Input:     hex.EncodeToString(alpha),
+        Output:    hex.EncodeToString(output),
+        Proof:     hex.EncodeToString(proof),
+    }, nil
+}
+
+// currentRound returns the round in progress at now, 0 before genesis
+func (b *Beacon) currentRound(now time.Time) uint64 {
+    if now.Before(b.config.Genesis) {
+        return 0
+    }
+    return uint64(now.Sub(b.config.Genesis)/b.config.Period()) + 1
+}
+
+// vrfRound computes round n; its input depends only on n, so every round
+// has exactly one valid output under the key that signs it
+func (b *Beacon) vrfRound(n uint64) (*Round, error) {
+    key := b.keyFor(n)
+    if key == nil {
+        return nil, &RoundError{Round: n}
+    }
+    alpha := BeaconInput(n)
+    proof, err := key.Prove(alpha)
+    if err != nil {
+        return nil, err
+    }
+    output, err := ProofToHash(proof)
+    if err != nil {
+        return nil, err
+    }
+    return &Round{
+        Round:     n,
+        Mode:      ModeVRF,
+        Timestamp: b.config.Genesis.Add(time.Duration(n-1) * b.config.Period()),
+        Input:     hex.EncodeToString(alpha),
+        Output:    hex.EncodeToString(output),
+        Proof:     hex.EncodeToString(proof),
+        PublicKey: hex.EncodeToString(key.PublicKey()),
+    }, nil
+}
+
+// BeaconInput returns the VRF input of beacon round n
+func BeaconInput(n uint64) []byte {
+    var number [8]byte
+    binary.BigEndian.PutUint64(number[:], n)
+    return append([]byte(beaconDomain), number[:]...)
+}
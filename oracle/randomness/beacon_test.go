@@ -0,0 +1,139 @@
+package randomness
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/events"
+)
+
+func TestVRFRoundsAreVerifiable(t *testing.T) {
+    t.Setenv("TEST_VRF_KEY", vectorKey)
+    genesis := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+    config := &Config{Enabled: true, Mode: ModeVRF, KeyEnv: "TEST_VRF_KEY", Genesis: genesis, PeriodSeconds: 30, History: 10}
+    beacon, err := NewBeacon(config, events.NewBus())
+    if err != nil {
+        t.Fatalf("Failed to create beacon: %v", err)
+    }
+
+    now := genesis.Add(95 * time.Second) // round 4
+    if err := beacon.Tick(context.Background(), now); err != nil {
+        t.Fatalf("Failed to tick: %v", err)
+    }
+    latest, ok := beacon.Latest()
+    if !ok || latest.Round != 4 {
+        t.Fatalf("Expected round 4, got %+v", latest)
+    }
+
+    proof, _ := hex.DecodeString(latest.Proof)
+    output, err := Verify(beacon.key.PublicKey(), BeaconInput(4), proof)
+    if err != nil {
+        t.Fatalf("Round does not verify: %v", err)
+    }
+    if hex.EncodeToString(output) != latest.Output {
+        t.Errorf("Expected output %x, got %s", output, latest.Output)
+    }
+
+    // Earlier rounds are recomputed identically; later ones are refused
+    if round, err := beacon.Round(2, now); err != nil || !round.Timestamp.Equal(genesis.Add(30*time.Second)) {
+        t.Errorf("Expected round 2 at genesis+30s, got %+v, %v", round, err)
+    }
+    if _, err := beacon.Round(5, now); err == nil || !err.(*RoundError).Future {
+        t.Errorf("Expected future round error, got %v", err)
+    }
+
+    // A requested seed equal to a beacon input yields a different output
+    requested, err := beacon.Prove(BeaconInput(5))
+    if err != nil {
+        t.Fatalf("Failed to prove seed: %v", err)
+    }
+    future, _ := beacon.vrfRound(5)
+    if requested.Output == future.Output {
+        t.Error("Requested proof revealed a future round")
+    }
+}
+
+func TestRekeyKeepsEarlierRounds(t *testing.T) {
+    t.Setenv("TEST_VRF_KEY", vectorKey)
+    genesis := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+    state := filepath.Join(t.TempDir(), "randomness-keys.json")
+    config := &Config{Enabled: true, Mode: ModeVRF, KeyEnv: "TEST_VRF_KEY", KeyState: state, Genesis: genesis, PeriodSeconds: 30, History: 10}
+    beacon, err := NewBeacon(config, events.NewBus())
+    if err != nil {
+        t.Fatalf("Failed to create beacon: %v", err)
+    }
+
+    now := genesis.Add(95 * time.Second) // round 4
+    before, _ := beacon.Round(4, now)
+    if err := beacon.Rekey("not a key", now); err == nil {
+        t.Error("Expected an error for an invalid key, got nil")
+    }
+    next := "0000000000000000000000000000000000000000000000000000000000000001"
+    if err := beacon.Rekey(next, now); err != nil {
+        t.Fatalf("Failed to rekey: %v", err)
+    }
+
+    later := now.Add(30 * time.Second)
+    after, _ := beacon.Round(4, later)
+    if after.Output != before.Output || after.PublicKey != vectorPublic {
+        t.Errorf("Expected round 4 to stay under the old key, got %+v", after)
+    }
+    rotated, _ := beacon.Round(5, later)
+    if rotated.PublicKey == vectorPublic || rotated.PublicKey != beacon.PublicKey() {
+        t.Fatalf("Expected round 5 under the new key, got %s", rotated.PublicKey)
+    }
+    public, _ := hex.DecodeString(rotated.PublicKey)
+    proof, _ := hex.DecodeString(rotated.Proof)
+    if _, err := Verify(public, BeaconInput(5), proof); err != nil {
+        t.Errorf("Rotated round does not verify: %v", err)
+    }
+
+    // After a restart the old key is gone, so its rounds are refused
+    // rather than recomputed under the new key
+    t.Setenv("TEST_VRF_KEY", next)
+    restarted, err := NewBeacon(config, events.NewBus())
+    if err != nil {
+        t.Fatalf("Failed to restart beacon: %v", err)
+    }
+    if _, err := restarted.Round(4, later); err == nil {
+        t.Error("Expected round 4 to be refused after the restart, got nil")
+    }
+    if round, err := restarted.Round(5, later); err != nil || round.Output != rotated.Output {
+        t.Errorf("Expected round 5 unchanged after the restart, got %+v, %v", round, err)
+    }
+}
+
+func TestDrandRelayChecksRandomness(t *testing.T) {
+    signature := []byte("signature of round 7")
+    digest := sha256.Sum256(signature)
+    randomness := hex.EncodeToString(digest[:])
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path != "/chain/public/latest" {
+            http.NotFound(w, r)
+            return
+        }
+        fmt.Fprintf(w, `{"round":7,"randomness":%q,"signature":%q}`, randomness, hex.EncodeToString(signature))
+    }))
+    defer server.Close()
+
+    config := &Config{Enabled: true, Mode: ModeDrand, Drand: &DrandConfig{URL: server.URL, ChainHash: "chain"}, History: 10}
+    beacon, _ := NewBeacon(config, events.NewBus())
+    if err := beacon.Tick(context.Background(), time.Now()); err != nil {
+        t.Fatalf("Failed to relay: %v", err)
+    }
+    if round, err := beacon.Round(7, time.Now()); err != nil || round.Output != randomness {
+        t.Errorf("Expected relayed round 7, got %+v, %v", round, err)
+    }
+
+    randomness = hex.EncodeToString(make([]byte, 32))
+    if err := beacon.Tick(context.Background(), time.Now()); err == nil {
+        t.Error("Expected error for randomness not matching its signature, got nil")
+    }
+}
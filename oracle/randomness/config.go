@@ -0,0 +1,95 @@
+package randomness
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "time"
+)
+
+// Beacon modes
+const (
+    ModeVRF   = "vrf"   // rounds are VRF proofs with the operator key
+    ModeDrand = "drand" // rounds are relayed from a drand network
+)
+
+// Config configures the randomness beacon
+type Config struct {
+    Enabled bool   `json:"enabled"`
+    Mode    string `json:"mode"`
+    // KeyEnv names the environment variable holding the operator's
+    // hex-encoded P-256 VRF key, vrf mode only
+    KeyEnv string `json:"keyEnv,omitempty"`
+    // KeyState is a file recording the round from which each vrf public key
+    // signs, vrf mode only, so that rounds signed by a key rotated out
+    // before a restart are refused rather than recomputed under a new one
+    KeyState string `json:"keyState,omitempty"`
+    // Genesis is the start of round 1 (RFC 3339), vrf mode only; round n
+    // covers genesis + (n-1) * period
+    Genesis time.Time `json:"genesis,omitempty"`
+    // PeriodSeconds is the round period, default 30
+    PeriodSeconds int `json:"periodSeconds,omitempty"`
+    // Drand is the relayed network, drand mode only
+    Drand *DrandConfig `json:"drand,omitempty"`
+    // History is how many rounds are kept for lookup, default 1000
+    History int `json:"history,omitempty"`
+    // PublishFeed publishes each round's output on-chain under this feed
+    // ID when publishing is enabled; empty disables it
+    PublishFeed string `json:"publishFeed,omitempty"`
+}
+
+// DrandConfig selects a drand network to relay
+type DrandConfig struct {
+    URL string `json:"url"`
+    // ChainHash selects the chain; empty uses the network's default chain
+    ChainHash string `json:"chainHash,omitempty"`
+}
+
+// LoadConfig loads randomness/randomness.json from the config directory. A
+// missing file disables the beacon.
+func LoadConfig(configDir string) (*Config, error) {
+    data, err := os.ReadFile(filepath.Join(configDir, "randomness", "randomness.json"))
+    if os.IsNotExist(err) {
+        return &Config{}, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read randomness config: %v", err)
+    }
+
+    var config Config
+    if err := json.Unmarshal(data, &config); err != nil {
+        return nil, fmt.Errorf("failed to parse randomness config: %v", err)
+    }
+    if config.History <= 0 {
+        config.History = 1000
+    }
+    if !config.Enabled {
+        return &config, nil
+    }
+
+    switch config.Mode {
+    case ModeVRF:
+        if config.KeyEnv == "" || config.KeyState == "" {
+            return nil, fmt.Errorf("vrf mode requires keyEnv and keyState")
+        }
+        if config.Genesis.IsZero() {
+            return nil, fmt.Errorf("vrf mode requires genesis")
+        }
+    case ModeDrand:
+        if config.Drand == nil || config.Drand.URL == "" {
+            return nil, fmt.Errorf("drand mode requires drand.url")
+        }
+    default:
+        return nil, fmt.Errorf("unknown randomness mode %q", config.Mode)
+    }
+    return &config, nil
+}
+
+// Period returns the round period
+func (c *Config) Period() time.Duration {
+    if c.PeriodSeconds <= 0 {
+        return 30 * time.Second
+    }
+    return time.Duration(c.PeriodSeconds) * time.Second
+}
@@ -0,0 +1,61 @@
+package randomness
+
+import (
+    "bytes"
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "net/http"
+    "strings"
+
+    "yetaXYZ/oracle/fetch"
+    "yetaXYZ/oracle/redact"
+)
+
+// drandBeacon is a round of the drand HTTP API
+type drandBeacon struct {
+    Round             uint64 `json:"round"`
+    Randomness        string `json:"randomness"`
+    Signature         string `json:"signature"`
+    PreviousSignature string `json:"previous_signature,omitempty"`
+}
+
+// fetchDrand fetches the latest round of a drand chain and checks that its
+// randomness is the hash of its signature. The BLS signature itself is not
+// verified here; consumers verify it against the chain's public key.
+func fetchDrand(ctx context.Context, client *http.Client, config *DrandConfig) (*drandBeacon, error) {
+    url := strings.TrimSuffix(config.URL, "/")
+    if config.ChainHash != "" {
+        url += "/" + config.ChainHash
+    }
+    url += "/public/latest"
+
+    req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+    if err != nil {
+        return nil, fmt.Errorf("failed to create drand request: %v", err)
+    }
+    resp, err := client.Do(req)
+    if err != nil {
+        return nil, fmt.Errorf("failed to fetch drand round: %v", redact.Error(err))
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("drand returned status %d", resp.StatusCode)
+    }
+
+    var beacon drandBeacon
+    if err := fetch.DecodeJSON(resp, &beacon); err != nil {
+        return nil, fmt.Errorf("failed to parse drand round: %v", redact.Error(err))
+    }
+    signature, err := hex.DecodeString(beacon.Signature)
+    if err != nil || len(signature) == 0 {
+        return nil, fmt.Errorf("drand round %d has an invalid signature", beacon.Round)
+    }
+    randomness, err := hex.DecodeString(beacon.Randomness)
+    digest := sha256.Sum256(signature)
+    if err != nil || !bytes.Equal(randomness, digest[:]) {
+        return nil, fmt.Errorf("drand round %d randomness does not match its signature", beacon.Round)
+    }
+    return &beacon, nil
+}
@@ -0,0 +1,242 @@
+package randomness
+
+import (
+    "bytes"
+    "crypto/elliptic"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "math/big"
+    "strings"
+)
+
+// Suite is the ECVRF ciphersuite of RFC 9381 implemented here
+const Suite = "ECVRF-P256-SHA256-TAI"
+
+// ECVRF-P256-SHA256-TAI parameters
+const (
+    suiteString = 0x01
+    ptLen       = 33 // compressed point
+    cLen        = 16 // challenge
+    qLen        = 32 // scalar
+    proofLen    = ptLen + cLen + qLen
+)
+
+// ProofError is returned for proofs that do not verify
+type ProofError struct {
+    Reason string
+}
+
+func (e *ProofError) Error() string {
+    return fmt.Sprintf("invalid VRF proof: %s", e.Reason)
+}
+
+// PrivateKey is a VRF secret key on P-256
+type PrivateKey struct {
+    x         *big.Int
+    publicKey []byte // compressed
+}
+
+// ParsePrivateKey parses a hex-encoded 32-byte P-256 scalar
+func ParsePrivateKey(s string) (*PrivateKey, error) {
+    raw, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(s), "0x"))
+    if err != nil || len(raw) != qLen {
+        return nil, fmt.Errorf("VRF key must be 32 hex-encoded bytes")
+    }
+    x := new(big.Int).SetBytes(raw)
+    if x.Sign() == 0 || x.Cmp(elliptic.P256().Params().N) >= 0 {
+        return nil, fmt.Errorf("VRF key out of range")
+    }
+    curve := elliptic.P256()
+    px, py := curve.ScalarBaseMult(raw)
+    return &PrivateKey{x: x, publicKey: elliptic.MarshalCompressed(curve, px, py)}, nil
+}
+
+// PublicKey returns the compressed SEC1 encoding of the public key
+func (k *PrivateKey) PublicKey() []byte {
+    return append([]byte{}, k.publicKey...)
+}
+
+// Prove computes the proof of alpha; the proof's output is ProofToHash(proof)
+func (k *PrivateKey) Prove(alpha []byte) ([]byte, error) {
+    curve := elliptic.P256()
+    n := curve.Params().N
+
+    hx, hy, err := encodeToCurve(k.publicKey, alpha)
+    if err != nil {
+        return nil, err
+    }
+    hString := elliptic.MarshalCompressed(curve, hx, hy)
+    gx, gy := curve.ScalarMult(hx, hy, scalarBytes(k.x))
+
+    nonce := nonceRFC6979(k.x, hString)
+    ux, uy := curve.ScalarBaseMult(scalarBytes(nonce))
+    vx, vy := curve.ScalarMult(hx, hy, scalarBytes(nonce))
+    c := challenge(k.publicKey, hString,
+        elliptic.MarshalCompressed(curve, gx, gy),
+        elliptic.MarshalCompressed(curve, ux, uy),
+        elliptic.MarshalCompressed(curve, vx, vy))
+
+    s := new(big.Int).Mul(c, k.x)
+    s.Add(s, nonce)
+    s.Mod(s, n)
+
+    proof := make([]byte, 0, proofLen)
+    proof = append(proof, elliptic.MarshalCompressed(curve, gx, gy)...)
+    proof = append(proof, leftPad(c.Bytes(), cLen)...)
+    proof = append(proof, scalarBytes(s)...)
+    return proof, nil
+}
+
+// Verify checks a proof of alpha against a compressed public key and
+// returns its output
+func Verify(publicKey, alpha, proof []byte) ([]byte, error) {
+    curve := elliptic.P256()
+    n := curve.Params().N
+
+    yx, yy := elliptic.UnmarshalCompressed(curve, publicKey)
+    if yx == nil {
+        return nil, &ProofError{Reason: "invalid public key"}
+    }
+    gx, gy, c, s, err := decodeProof(proof)
+    if err != nil {
+        return nil, err
+    }
+    hx, hy, err := encodeToCurve(publicKey, alpha)
+    if err != nil {
+        return nil, err
+    }
+
+    // U = s*B - c*Y, V = s*H - c*Gamma
+    negC := scalarBytes(new(big.Int).Sub(n, c))
+    sbx, sby := curve.ScalarBaseMult(scalarBytes(s))
+    cyx, cyy := curve.ScalarMult(yx, yy, negC)
+    ux, uy := curve.Add(sbx, sby, cyx, cyy)
+    shx, shy := curve.ScalarMult(hx, hy, scalarBytes(s))
+    cgx, cgy := curve.ScalarMult(gx, gy, negC)
+    vx, vy := curve.Add(shx, shy, cgx, cgy)
+    if isInfinity(ux, uy) || isInfinity(vx, vy) {
+        return nil, &ProofError{Reason: "degenerate proof"}
+    }
+
+    expected := challenge(publicKey,
+        elliptic.MarshalCompressed(curve, hx, hy),
+        proof[:ptLen],
+        elliptic.MarshalCompressed(curve, ux, uy),
+        elliptic.MarshalCompressed(curve, vx, vy))
+    if expected.Cmp(c) != 0 {
+        return nil, &ProofError{Reason: "challenge mismatch"}
+    }
+    return ProofToHash(proof)
+}
+
+// ProofToHash returns the 32-byte VRF output of a proof
+func ProofToHash(proof []byte) ([]byte, error) {
+    if _, _, _, _, err := decodeProof(proof); err != nil {
+        return nil, err
+    }
+    // The cofactor of P-256 is 1, so Gamma is hashed as encoded
+    h := sha256.New()
+    h.Write([]byte{suiteString, 0x03})
+    h.Write(proof[:ptLen])
+    h.Write([]byte{0x00})
+    return h.Sum(nil), nil
+}
+
+// decodeProof splits a proof into Gamma, c and s
+func decodeProof(proof []byte) (gx, gy, c, s *big.Int, err error) {
+    if len(proof) != proofLen {
+        return nil, nil, nil, nil, &ProofError{Reason: fmt.Sprintf("length %d, want %d", len(proof), proofLen)}
+    }
+    gx, gy = elliptic.UnmarshalCompressed(elliptic.P256(), proof[:ptLen])
+    if gx == nil {
+        return nil, nil, nil, nil, &ProofError{Reason: "invalid gamma"}
+    }
+    c = new(big.Int).SetBytes(proof[ptLen : ptLen+cLen])
+    s = new(big.Int).SetBytes(proof[ptLen+cLen:])
+    if s.Cmp(elliptic.P256().Params().N) >= 0 {
+        return nil, nil, nil, nil, &ProofError{Reason: "scalar out of range"}
+    }
+    return gx, gy, c, s, nil
+}
+
+// encodeToCurve hashes alpha to a point with try-and-increment
+func encodeToCurve(publicKey, alpha []byte) (*big.Int, *big.Int, error) {
+    curve := elliptic.P256()
+    for ctr := 0; ctr < 256; ctr++ {
+        h := sha256.New()
+        h.Write([]byte{suiteString, 0x01})
+        h.Write(publicKey)
+        h.Write(alpha)
+        h.Write([]byte{byte(ctr), 0x00})
+        x, y := elliptic.UnmarshalCompressed(curve, append([]byte{0x02}, h.Sum(nil)...))
+        if x != nil {
+            return x, y, nil
+        }
+    }
+    return nil, nil, fmt.Errorf("failed to hash to curve")
+}
+
+// challenge hashes the points of a proof into the challenge scalar
+func challenge(points ...[]byte) *big.Int {
+    h := sha256.New()
+    h.Write([]byte{suiteString, 0x02})
+    for _, p := range points {
+        h.Write(p)
+    }
+    h.Write([]byte{0x00})
+    return new(big.Int).SetBytes(h.Sum(nil)[:cLen])
+}
+
+// nonceRFC6979 derives the deterministic nonce of RFC 6979 section 3.2
+// for the message m with SHA-256
+func nonceRFC6979(x *big.Int, m []byte) *big.Int {
+    n := elliptic.P256().Params().N
+    digest := sha256.Sum256(m)
+    h1 := new(big.Int).SetBytes(digest[:])
+    h1.Mod(h1, n)
+    seed := append(scalarBytes(x), scalarBytes(h1)...)
+
+    mac := func(key []byte, parts ...[]byte) []byte {
+        h := hmac.New(sha256.New, key)
+        for _, p := range parts {
+            h.Write(p)
+        }
+        return h.Sum(nil)
+    }
+    v := bytes.Repeat([]byte{0x01}, 32)
+    k := make([]byte, 32)
+    k = mac(k, v, []byte{0x00}, seed)
+    v = mac(k, v)
+    k = mac(k, v, []byte{0x01}, seed)
+    v = mac(k, v)
+    for {
+        v = mac(k, v)
+        nonce := new(big.Int).SetBytes(v)
+        if nonce.Sign() > 0 && nonce.Cmp(n) < 0 {
+            return nonce
+        }
+        k = mac(k, v, []byte{0x00})
+        v = mac(k, v)
+    }
+}
+
+// scalarBytes encodes a scalar as 32 big-endian bytes
+func scalarBytes(s *big.Int) []byte {
+    return leftPad(s.Bytes(), qLen)
+}
+
+// leftPad zero-pads b to n bytes
+func leftPad(b []byte, n int) []byte {
+    if len(b) >= n {
+        return b
+    }
+    return append(make([]byte, n-len(b)), b...)
+}
+
+// isInfinity reports whether a point is the identity, which crypto/elliptic
+// represents as (0, 0)
+func isInfinity(x, y *big.Int) bool {
+    return x.Sign() == 0 && y.Sign() == 0
+}
@@ -0,0 +1,57 @@
+package randomness
+
+import (
+    "encoding/hex"
+    "testing"
+)
+
+// RFC 9381 appendix B.1, example 10
+const (
+    vectorKey    = "c9afa9d845ba75166b5c215767b1d6934e50c3db36e89b127b8a622b120f6721"
+    vectorPublic = "0360fed4ba255a9d31c961eb74c6356d68c049b8923b61fa6ce669622e60f29fb6"
+    vectorProof  = "035b5c726e8c0e2c488a107c600578ee75cb702343c153cb1eb8dec77f4b5071b4a53f0a46f018bc2c56e58d383f2305e0975972c26feea0eb122fe7893c15af376b33edf7de17c6ea056d4d82de6bc02f"
+    vectorOutput = "a3ad7b0ef73d8fc6655053ea22f9bede8c743f08bbed3d38821f0e16474b505e"
+)
+
+func TestProveMatchesRFCVector(t *testing.T) {
+    key, err := ParsePrivateKey(vectorKey)
+    if err != nil {
+        t.Fatalf("Failed to parse key: %v", err)
+    }
+    if got := hex.EncodeToString(key.PublicKey()); got != vectorPublic {
+        t.Errorf("Expected public key %s, got %s", vectorPublic, got)
+    }
+    proof, err := key.Prove([]byte("sample"))
+    if err != nil {
+        t.Fatalf("Failed to prove: %v", err)
+    }
+    if got := hex.EncodeToString(proof); got != vectorProof {
+        t.Errorf("Expected proof %s, got %s", vectorProof, got)
+    }
+
+    output, err := Verify(key.PublicKey(), []byte("sample"), proof)
+    if err != nil {
+        t.Fatalf("Failed to verify: %v", err)
+    }
+    if got := hex.EncodeToString(output); got != vectorOutput {
+        t.Errorf("Expected output %s, got %s", vectorOutput, got)
+    }
+}
+
+func TestVerifyRejectsTamperedProofs(t *testing.T) {
+    key, _ := ParsePrivateKey(vectorKey)
+    proof, _ := key.Prove([]byte("sample"))
+
+    if _, err := Verify(key.PublicKey(), []byte("other"), proof); err == nil {
+        t.Error("Expected error for a different input, got nil")
+    }
+    tampered := append([]byte{}, proof...)
+    tampered[len(tampered)-1] ^= 1
+    if _, err := Verify(key.PublicKey(), []byte("sample"), tampered); err == nil {
+        t.Error("Expected error for a tampered proof, got nil")
+    }
+    other, _ := ParsePrivateKey("0000000000000000000000000000000000000000000000000000000000000001")
+    if _, err := Verify(other.PublicKey(), []byte("sample"), proof); err == nil {
+        t.Error("Expected error for another key, got nil")
+    }
+}
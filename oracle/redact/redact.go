@@ -0,0 +1,127 @@
+package redact
+
+import (
+    "io"
+    "os"
+    "regexp"
+    "strings"
+    "sync"
+)
+
+// Placeholder replaces every redacted secret
+const Placeholder = "REDACTED"
+
+// minSecretLength guards against registering short values such as "1" that
+// would mangle unrelated text when replaced
+const minSecretLength = 8
+
+// secretEnvMarkers identify environment variables holding credentials
+var secretEnvMarkers = []string{"KEY", "TOKEN", "SECRET", "PASSWORD", "PRIVATE"}
+
+// patterns match secrets by shape when their value is not registered
+var patterns = []struct {
+    re   *regexp.Regexp
+    repl string
+}{
+    // Query parameters carrying credentials or signatures
+    {regexp.MustCompile(`(?i)([?&](?:api[_-]?key|registration[_-]?key|key|token|access[_-]?token|auth|secret|signature|sig|password)=)[^&\s"']+`), "${1}" + Placeholder},
+    // Passwords in URL userinfo
+    {regexp.MustCompile(`(://[^/\s:@]+:)[^/\s@]+@`), "${1}" + Placeholder + "@"},
+    // The Graph gateway keys embedded in the path
+    {regexp.MustCompile(`(/api/)[0-9a-fA-F]{32}(/subgraphs)`), "${1}" + Placeholder + "${2}"},
+    // RPC provider keys embedded in the path (Infura /v3/, Alchemy /v2/);
+    // both are 32 characters, long enough not to match ordinary path words
+    {regexp.MustCompile(`(/v[23]/)[A-Za-z0-9_-]{32,}`), "${1}" + Placeholder},
+    // Bearer tokens in echoed headers
+    {regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`), "${1}" + Placeholder},
+}
+
+var (
+    mu      sync.RWMutex
+    secrets = make(map[string]bool)
+)
+
+// Register adds literal secret values that are always redacted
+func Register(values ...string) {
+    mu.Lock()
+    defer mu.Unlock()
+    for _, v := range values {
+        if len(v) >= minSecretLength {
+            secrets[v] = true
+        }
+    }
+}
+
+// RegisterEnv registers the values of environment variables whose names
+// suggest credentials, e.g. THE_GRAPH_API_KEY or ORACLE_ADMIN_TOKEN
+func RegisterEnv() {
+    for _, kv := range os.Environ() {
+        name, value, ok := strings.Cut(kv, "=")
+        if !ok {
+            continue
+        }
+        upper := strings.ToUpper(name)
+        for _, marker := range secretEnvMarkers {
+            if strings.Contains(upper, marker) {
+                Register(value)
+                break
+            }
+        }
+    }
+}
+
+// String returns s with registered secrets and credential-shaped
+// URL components replaced by Placeholder
+func String(s string) string {
+    mu.RLock()
+    for secret := range secrets {
+        s = strings.ReplaceAll(s, secret, Placeholder)
+    }
+    mu.RUnlock()
+
+    for _, p := range patterns {
+        s = p.re.ReplaceAllString(s, p.repl)
+    }
+    return s
+}
+
+// redactedError carries a redacted message while keeping the original
+// error reachable through errors.Is and errors.As
+type redactedError struct {
+    msg string
+    err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+
+func (e *redactedError) Unwrap() error { return e.err }
+
+// Error returns err with secrets redacted from its message; nil stays nil
+func Error(err error) error {
+    if err == nil {
+        return nil
+    }
+    msg := String(err.Error())
+    if msg == err.Error() {
+        return err
+    }
+    return &redactedError{msg: msg, err: err}
+}
+
+// writer redacts everything written through it
+type writer struct {
+    w io.Writer
+}
+
+// NewWriter wraps w so that every write is redacted, for use with log.SetOutput
+func NewWriter(w io.Writer) io.Writer {
+    return &writer{w: w}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+    if _, err := io.WriteString(w.w, String(string(p))); err != nil {
+        return 0, err
+    }
+    // Report the original length so callers do not see a short write
+    return len(p), nil
+}
@@ -0,0 +1,236 @@
+package registry
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "os"
+    "sort"
+    "strings"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/fetch"
+    "yetaXYZ/oracle/symbols"
+)
+
+// Supported registries
+const (
+    Chainlink = "chainlink"
+    Pyth      = "pyth"
+)
+
+// Default registry locations
+const (
+    DefaultChainlinkURL = "https://reference-data-directory.vercel.app/feeds-mainnet.json"
+    DefaultPythURL      = "https://hermes.pyth.network/v2/price_feeds?asset_type=crypto"
+)
+
+// defaultUpdateFrequency is the update interval of imported pairs when
+// none is given
+const defaultUpdateFrequency = 5
+
+// maxRegistryBytes bounds registry downloads, which list thousands of feeds
+const maxRegistryBytes = 32 << 20
+
+// Feed is a price feed listed by a registry
+type Feed struct {
+    Registry string `json:"registry"`
+    // ID identifies the feed within its registry: the proxy address of a
+    // Chainlink feed or the price feed ID of a Pyth feed
+    ID    string `json:"id"`
+    Name  string `json:"name"`
+    Base  string `json:"base"`
+    Quote string `json:"quote"`
+}
+
+// chainlinkFeed is an entry of Chainlink's feed directory
+type chainlinkFeed struct {
+    Name         string `json:"name"`
+    ProxyAddress string `json:"proxyAddress"`
+    FeedCategory string `json:"feedCategory"`
+}
+
+// pythFeed is an entry of Pyth's price feed list
+type pythFeed struct {
+    ID         string `json:"id"`
+    Attributes struct {
+        AssetType string `json:"asset_type"`
+        Base      string `json:"base"`
+        Quote     string `json:"quote_currency"`
+        Symbol    string `json:"symbol"`
+    } `json:"attributes"`
+}
+
+// Load reads the feeds of a registry from a URL or a local file
+func Load(client *http.Client, registry, location string) ([]Feed, error) {
+    var data []byte
+    if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+        resp, err := client.Get(location)
+        if err != nil {
+            return nil, fmt.Errorf("failed to fetch %s registry: %v", registry, err)
+        }
+        defer resp.Body.Close()
+        if resp.StatusCode != http.StatusOK {
+            return nil, fmt.Errorf("failed to fetch %s registry: unexpected status %s", registry, resp.Status)
+        }
+        var raw json.RawMessage
+        if err := fetch.DecodeJSONLimit(resp, maxRegistryBytes, &raw); err != nil {
+            return nil, fmt.Errorf("failed to fetch %s registry: %v", registry, err)
+        }
+        data = raw
+    } else {
+        var err error
+        if data, err = os.ReadFile(location); err != nil {
+            return nil, fmt.Errorf("failed to read %s registry: %v", registry, err)
+        }
+    }
+    return Parse(registry, data)
+}
+
+// Parse decodes a registry document into its feeds. Chainlink entries that
+// are not "BASE / QUOTE" pairs, such as indices, and non-crypto Pyth feeds
+// are left out.
+func Parse(registry string, data []byte) ([]Feed, error) {
+    feeds := make([]Feed, 0)
+    switch registry {
+    case Chainlink:
+        var entries []chainlinkFeed
+        if err := json.Unmarshal(data, &entries); err != nil {
+            return nil, fmt.Errorf("failed to parse chainlink registry: %v", err)
+        }
+        for _, e := range entries {
+            parts := strings.Split(e.Name, " / ")
+            if len(parts) != 2 || strings.Contains(parts[0], " ") || strings.Contains(parts[1], " ") {
+                continue
+            }
+            feeds = append(feeds, Feed{Registry: Chainlink, ID: e.ProxyAddress, Name: e.Name, Base: symbols.Canonical(parts[0]), Quote: symbols.Canonical(parts[1])})
+        }
+    case Pyth:
+        var entries []pythFeed
+        if err := json.Unmarshal(data, &entries); err != nil {
+            return nil, fmt.Errorf("failed to parse pyth registry: %v", err)
+        }
+        for _, e := range entries {
+            a := e.Attributes
+            if !strings.EqualFold(a.AssetType, "crypto") || a.Base == "" || a.Quote == "" {
+                continue
+            }
+            feeds = append(feeds, Feed{Registry: Pyth, ID: e.ID, Name: a.Symbol, Base: symbols.Canonical(a.Base), Quote: symbols.Canonical(a.Quote)})
+        }
+    default:
+        return nil, fmt.Errorf("unknown registry %q, want %s or %s", registry, Chainlink, Pyth)
+    }
+    return feeds, nil
+}
+
+// Options shape the pairs generated from registry feeds
+type Options struct {
+    // Exchanges are the CEX sources of every pair, default all configured
+    Exchanges []string
+    // Quotes maps registry quote currencies to configured quote assets,
+    // e.g. {"USD": "USDT"}
+    Quotes map[string]string
+    // Only restricts the import to these base assets; empty imports all
+    Only map[string]bool
+    // UpdateFrequencySeconds of the pairs, default 5
+    UpdateFrequencySeconds int
+    // Group tags the imported pairs, default "<registry>-import"
+    Group string
+}
+
+// Imported is a pair generated from a registry feed
+type Imported struct {
+    Symbol string             `json:"symbol"`
+    Feed   Feed               `json:"feed"`
+    Pair   *common.PairConfig `json:"pair"`
+}
+
+// Plan is the outcome of matching registry feeds against the config
+type Plan struct {
+    Pairs []Imported `json:"pairs"`
+    // Skipped gives the reason each feed was not imported, by feed name
+    Skipped map[string]string `json:"skipped"`
+}
+
+// Build generates a pair config for each registry feed whose assets are in
+// the base config and that is not configured yet. Feeds that cannot be
+// imported are listed with the reason.
+func Build(feeds []Feed, base *common.BaseConfig, existing map[string]*common.PairConfig, opts Options) (*Plan, error) {
+    exchanges := opts.Exchanges
+    if len(exchanges) == 0 {
+        for name := range base.Exchanges.CEX {
+            exchanges = append(exchanges, name)
+        }
+    }
+    sort.Strings(exchanges)
+    for _, exchange := range exchanges {
+        if _, ok := base.Exchanges.CEX[exchange]; !ok {
+            return nil, fmt.Errorf("unknown exchange %s", exchange)
+        }
+    }
+    if len(exchanges) == 0 {
+        return nil, fmt.Errorf("no exchanges configured")
+    }
+    frequency := opts.UpdateFrequencySeconds
+    if frequency <= 0 {
+        frequency = defaultUpdateFrequency
+    }
+    minimum := 2
+    if len(exchanges) < minimum {
+        minimum = len(exchanges)
+    }
+
+    plan := &Plan{Pairs: make([]Imported, 0), Skipped: make(map[string]string)}
+    seen := make(map[string]bool)
+    for _, feed := range feeds {
+        if len(opts.Only) > 0 && !opts.Only[feed.Base] {
+            continue
+        }
+        quote := feed.Quote
+        if mapped, ok := opts.Quotes[quote]; ok {
+            quote = mapped
+        }
+        symbol := feed.Base + quote
+        group := opts.Group
+        if group == "" {
+            group = feed.Registry + "-import"
+        }
+
+        switch {
+        case existing[symbol] != nil:
+            plan.Skipped[feed.Name] = fmt.Sprintf("pair %s is already configured", symbol)
+        case seen[symbol]:
+            plan.Skipped[feed.Name] = fmt.Sprintf("pair %s is imported from another feed", symbol)
+        case !hasAsset(base, feed.Base):
+            plan.Skipped[feed.Name] = fmt.Sprintf("asset %s is not configured", feed.Base)
+        case !hasAsset(base, quote):
+            plan.Skipped[feed.Name] = fmt.Sprintf("quote asset %s is not configured", quote)
+        default:
+            seen[symbol] = true
+            plan.Pairs = append(plan.Pairs, Imported{
+                Symbol: symbol,
+                Feed:   feed,
+                Pair: &common.PairConfig{
+                    BaseCurrency:           feed.Base,
+                    QuoteCurrency:          quote,
+                    MinimumSources:         minimum,
+                    UpdateFrequencySeconds: frequency,
+                    Sources: common.SourcesConfig{CEX: common.CEXSourceConfig{
+                        Enabled:   true,
+                        Weight:    1.0,
+                        Exchanges: append([]string(nil), exchanges...),
+                    }},
+                    Groups: []string{group},
+                },
+            })
+        }
+    }
+    sort.Slice(plan.Pairs, func(i, j int) bool { return plan.Pairs[i].Symbol < plan.Pairs[j].Symbol })
+    return plan, nil
+}
+
+// hasAsset reports whether the base config's address book has an asset
+func hasAsset(base *common.BaseConfig, symbol string) bool {
+    _, ok := base.Assets[symbol]
+    return ok
+}
@@ -0,0 +1,72 @@
+package registry
+
+import (
+    "testing"
+
+    "yetaXYZ/oracle/common"
+)
+
+const chainlinkDoc = `[
+    {"name": "ETH / USD", "proxyAddress": "0x5f4eC3Df9cbd43714FE2740f5E3616155c5b8419", "feedCategory": "low"},
+    {"name": "BTC / USD", "proxyAddress": "0xF4030086522a5bEEa4988F8cA5B36dbC97BeE88c", "feedCategory": "low"},
+    {"name": "Total Marketcap USD", "proxyAddress": "0xEC8761a0A73c34329CA5B1D3Dc7eD07F30e836e2"},
+    {"name": "DOGE / USD", "proxyAddress": "0x2465CefD3b488BE410b941b1d4b2767088e2A028"}
+]`
+
+const pythDoc = `[
+    {"id": "ff61491a", "attributes": {"asset_type": "Crypto", "base": "ETH", "quote_currency": "USD", "symbol": "Crypto.ETH/USD"}},
+    {"id": "84c2dde9", "attributes": {"asset_type": "FX", "base": "EUR", "quote_currency": "USD", "symbol": "FX.EUR/USD"}}
+]`
+
+func TestParse(t *testing.T) {
+    feeds, err := Parse(Chainlink, []byte(chainlinkDoc))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(feeds) != 3 || feeds[0].Base != "ETH" || feeds[0].Quote != "USD" || feeds[0].ID != "0x5f4eC3Df9cbd43714FE2740f5E3616155c5b8419" {
+        t.Errorf("Expected three pairs without the index, got %+v", feeds)
+    }
+
+    feeds, err = Parse(Pyth, []byte(pythDoc))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(feeds) != 1 || feeds[0].ID != "ff61491a" || feeds[0].Name != "Crypto.ETH/USD" {
+        t.Errorf("Expected the crypto feed only, got %+v", feeds)
+    }
+
+    if _, err := Parse("band", []byte("[]")); err == nil {
+        t.Error("Expected an unknown registry to be rejected")
+    }
+}
+
+func TestBuild(t *testing.T) {
+    base := &common.BaseConfig{}
+    base.Exchanges.CEX = map[string]common.CEXDetails{"binance": {}, "kraken": {}}
+    base.Assets = map[string]common.Asset{"BTC": {}, "ETH": {}, "USDT": {}}
+    existing := map[string]*common.PairConfig{"BTCUSDT": {}}
+    feeds, _ := Parse(Chainlink, []byte(chainlinkDoc))
+
+    plan, err := Build(feeds, base, existing, Options{Quotes: map[string]string{"USD": "USDT"}})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(plan.Pairs) != 1 || plan.Pairs[0].Symbol != "ETHUSDT" {
+        t.Fatalf("Expected ETHUSDT imported, got %+v", plan.Pairs)
+    }
+    pair := plan.Pairs[0].Pair
+    if pair.MinimumSources != 2 || pair.UpdateFrequencySeconds != 5 || len(pair.Sources.CEX.Exchanges) != 2 || pair.Groups[0] != "chainlink-import" {
+        t.Errorf("Unexpected pair %+v", pair)
+    }
+    if len(plan.Skipped) != 2 || plan.Skipped["BTC / USD"] == "" || plan.Skipped["DOGE / USD"] == "" {
+        t.Errorf("Expected BTC configured and DOGE unknown skipped, got %v", plan.Skipped)
+    }
+
+    // Without a quote mapping USD is not a configured asset
+    if plan, _ := Build(feeds, base, existing, Options{}); len(plan.Pairs) != 0 {
+        t.Errorf("Expected nothing imported without USD, got %+v", plan.Pairs)
+    }
+    if _, err := Build(feeds, base, existing, Options{Exchanges: []string{"okx"}}); err == nil {
+        t.Error("Expected an unknown exchange to be rejected")
+    }
+}
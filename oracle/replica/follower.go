@@ -0,0 +1,208 @@
+package replica
+
+import (
+    "bufio"
+    "context"
+    "encoding/json"
+    "fmt"
+    "log"
+    "net/http"
+    "strings"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+    "yetaXYZ/oracle/fetch"
+)
+
+// maxEventBytes bounds a single server-sent event line
+const maxEventBytes = 1 << 20
+
+// idleTimeout drops a connection on which not even the primary's 15 second
+// keep-alives arrive
+const idleTimeout = 45 * time.Second
+
+// Reconnect backoff after the primary's stream ends or fails
+const (
+    minBackoff = time.Second
+    maxBackoff = 30 * time.Second
+)
+
+// Status describes the replication link to the primary
+type Status struct {
+    Primary    string    `json:"primary"`
+    Connected  bool      `json:"connected"`
+    Since      time.Time `json:"since,omitempty"`     // when the current connection was made
+    LastEvent  time.Time `json:"lastEvent,omitempty"` // when the last event was received
+    Events     uint64    `json:"events"`
+    Reconnects uint64    `json:"reconnects"`
+    LastError  string    `json:"lastError,omitempty"`
+}
+
+// Follower replicates a primary oracle's completed rounds and alerts from
+// its event stream onto the local bus, so that a query-only instance can
+// serve them without fetching from sources
+type Follower struct {
+    primary string
+    apiKey  string
+    bus     *events.Bus
+    client  *http.Client
+
+    mu     sync.RWMutex
+    status Status
+}
+
+// NewFollower creates a follower of the primary at the given base URL
+func NewFollower(primary string, bus *events.Bus) *Follower {
+    primary = strings.TrimRight(primary, "/")
+    return &Follower{
+        primary: primary,
+        bus:     bus,
+        // The stream is long-lived; stalls are detected by idleTimeout
+        client: &http.Client{Transport: fetch.Transport},
+        status: Status{Primary: primary},
+    }
+}
+
+// SetAPIKey authenticates the follower to a primary that meters its
+// consumers
+func (f *Follower) SetAPIKey(key string) {
+    f.mu.Lock()
+    f.apiKey = key
+    f.mu.Unlock()
+}
+
+// Run follows the primary's stream, reconnecting with backoff, until ctx
+// is cancelled
+func (f *Follower) Run(ctx context.Context) {
+    backoff := minBackoff
+    for {
+        err := f.follow(ctx)
+        if ctx.Err() != nil {
+            return
+        }
+
+        f.mu.Lock()
+        wasConnected := f.status.Connected
+        f.status.Connected = false
+        f.status.Reconnects++
+        if err != nil {
+            f.status.LastError = err.Error()
+        }
+        f.mu.Unlock()
+
+        if wasConnected {
+            backoff = minBackoff
+        }
+        log.Printf("Replication stream from %s ended: %v; reconnecting in %s", f.primary, err, backoff)
+        select {
+        case <-ctx.Done():
+            return
+        case <-time.After(backoff):
+        }
+        if backoff *= 2; backoff > maxBackoff {
+            backoff = maxBackoff
+        }
+    }
+}
+
+// follow reads one connection to the primary's stream until it ends
+func (f *Follower) follow(ctx context.Context) error {
+    req, err := http.NewRequest("GET", f.primary+"/api/v1/stream", nil)
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Accept", "text/event-stream")
+    f.mu.RLock()
+    apiKey := f.apiKey
+    f.mu.RUnlock()
+    if apiKey != "" {
+        req.Header.Set("X-API-Key", apiKey)
+    }
+
+    streamCtx, cancel := context.WithCancel(ctx)
+    defer cancel()
+    idle := time.AfterFunc(idleTimeout, cancel)
+    defer idle.Stop()
+
+    resp, err := f.client.Do(req.WithContext(streamCtx))
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("unexpected status from primary: %s", resp.Status)
+    }
+
+    f.mu.Lock()
+    f.status.Connected = true
+    f.status.Since = time.Now()
+    f.status.LastError = ""
+    f.mu.Unlock()
+    log.Printf("Replicating from %s", f.primary)
+
+    scanner := bufio.NewScanner(resp.Body)
+    scanner.Buffer(make([]byte, 64*1024), maxEventBytes)
+    var eventType, data string
+    for scanner.Scan() {
+        idle.Reset(idleTimeout)
+        line := scanner.Text()
+        switch {
+        case line == "":
+            if eventType != "" && data != "" {
+                f.dispatch(events.Type(eventType), data)
+            }
+            eventType, data = "", ""
+        case strings.HasPrefix(line, "event:"):
+            eventType = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+        case strings.HasPrefix(line, "data:"):
+            data += strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+        }
+    }
+    if err := scanner.Err(); err != nil {
+        return err
+    }
+    return fmt.Errorf("stream closed by primary")
+}
+
+// dispatch republishes one replicated event on the local bus
+func (f *Follower) dispatch(eventType events.Type, data string) {
+    var e events.Event
+    switch eventType {
+    case events.Aggregate:
+        var result common.AggregateResult
+        if err := json.Unmarshal([]byte(data), &result); err != nil {
+            log.Printf("Invalid replicated round: %v", err)
+            return
+        }
+        e = events.Event{Type: events.Aggregate, Symbol: result.Symbol, Timestamp: result.Timestamp, Payload: &result}
+    case events.Alert:
+        var alert struct {
+            Symbol    string    `json:"symbol"`
+            Timestamp time.Time `json:"timestamp"`
+            events.AlertPayload
+        }
+        if err := json.Unmarshal([]byte(data), &alert); err != nil {
+            log.Printf("Invalid replicated alert: %v", err)
+            return
+        }
+        payload := alert.AlertPayload
+        e = events.Event{Type: events.Alert, Symbol: alert.Symbol, Timestamp: alert.Timestamp, Payload: &payload}
+    default:
+        return
+    }
+    f.bus.Publish(e)
+
+    f.mu.Lock()
+    f.status.Events++
+    f.status.LastEvent = time.Now()
+    f.mu.Unlock()
+}
+
+// Status returns the current state of the replication link
+func (f *Follower) Status() Status {
+    f.mu.RLock()
+    defer f.mu.RUnlock()
+    return f.status
+}
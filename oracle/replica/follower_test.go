@@ -0,0 +1,61 @@
+package replica
+
+import (
+    "context"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+)
+
+func TestFollower(t *testing.T) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path != "/api/v1/stream" {
+            http.NotFound(w, r)
+            return
+        }
+        w.Header().Set("Content-Type", "text/event-stream")
+        fmt.Fprint(w, ": keep-alive\n\n")
+        fmt.Fprint(w, "event: aggregate\ndata: {\"symbol\":\"BTCUSDT\",\"price\":65000.5,\"timestamp\":\"2024-06-01T12:00:00Z\",\"roundId\":42}\n\n")
+        fmt.Fprint(w, "event: alert\ndata: {\"symbol\":\"ETHUSDT\",\"timestamp\":\"2024-06-01T12:00:01Z\",\"severity\":\"warning\",\"kind\":\"stale\",\"message\":\"no update\"}\n\n")
+        fmt.Fprint(w, "event: fetch_result\ndata: {}\n\n")
+    }))
+    defer srv.Close()
+
+    bus := events.NewBus()
+    sub := bus.Subscribe(10)
+    defer sub.Close()
+
+    f := NewFollower(srv.URL+"/", bus)
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    go f.Run(ctx)
+
+    received := make([]events.Event, 0, 2)
+    for len(received) < 2 {
+        select {
+        case e := <-sub.C:
+            received = append(received, e)
+        case <-time.After(5 * time.Second):
+            t.Fatalf("Timed out waiting for replicated events, got %+v", received)
+        }
+    }
+
+    result, ok := received[0].Payload.(*common.AggregateResult)
+    if received[0].Type != events.Aggregate || !ok || result.Price != 65000.5 || result.RoundID != 42 || received[0].Symbol != "BTCUSDT" {
+        t.Errorf("Expected the replicated BTCUSDT round, got %+v", received[0])
+    }
+    alert, ok := received[1].Payload.(*events.AlertPayload)
+    if received[1].Type != events.Alert || !ok || alert.Kind != "stale" || received[1].Symbol != "ETHUSDT" {
+        t.Errorf("Expected the replicated ETHUSDT alert, got %+v", received[1])
+    }
+
+    status := f.Status()
+    if status.Primary != srv.URL || status.Events < 2 {
+        t.Errorf("Expected 2 replicated events from %s, got %+v", srv.URL, status)
+    }
+}
@@ -0,0 +1,82 @@
+package rewards
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "time"
+)
+
+// Config configures source participation accounting
+type Config struct {
+    Enabled bool `json:"enabled"`
+    // EpochHours is the length of a reward epoch, default 24; epochs are
+    // aligned to midnight UTC
+    EpochHours int `json:"epochHours,omitempty"`
+    // ToleranceBps is how far from the round price a source may be and
+    // still count as accurate, default 50
+    ToleranceBps float64 `json:"toleranceBps,omitempty"`
+    // RewardPerEpoch is split among sources in proportion to their score;
+    // 0 reports scores only
+    RewardPerEpoch float64 `json:"rewardPerEpoch,omitempty"`
+    // RetainEpochs is how many epochs are kept, default 90
+    RetainEpochs int `json:"retainEpochs,omitempty"`
+    // Ledger is the file the tallies are persisted to across restarts;
+    // empty keeps them in memory only
+    Ledger string `json:"ledger,omitempty"`
+}
+
+// LoadConfig loads rewards/rewards.json from the config directory. A
+// missing file leaves accounting disabled.
+func LoadConfig(configDir string) (*Config, error) {
+    data, err := os.ReadFile(filepath.Join(configDir, "rewards", "rewards.json"))
+    if os.IsNotExist(err) {
+        return &Config{}, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read rewards config: %v", err)
+    }
+
+    var config Config
+    if err := json.Unmarshal(data, &config); err != nil {
+        return nil, fmt.Errorf("failed to parse rewards config: %v", err)
+    }
+    return &config, config.Validate()
+}
+
+// Validate checks that the settings are not negative and that epochs
+// divide a day or are whole days
+func (c *Config) Validate() error {
+    if c.EpochHours < 0 || c.ToleranceBps < 0 || c.RewardPerEpoch < 0 || c.RetainEpochs < 0 {
+        return fmt.Errorf("rewards settings must not be negative")
+    }
+    if h := c.Epoch() / time.Hour; h < 24 && 24%h != 0 || h > 24 && h%24 != 0 {
+        return fmt.Errorf("rewards epochHours must divide a day or be whole days, got %d", h)
+    }
+    return nil
+}
+
+// Epoch returns the length of a reward epoch
+func (c *Config) Epoch() time.Duration {
+    if c.EpochHours <= 0 {
+        return 24 * time.Hour
+    }
+    return time.Duration(c.EpochHours) * time.Hour
+}
+
+// Tolerance returns the accuracy tolerance as a fraction of the price
+func (c *Config) Tolerance() float64 {
+    if c.ToleranceBps <= 0 {
+        return 0.005
+    }
+    return c.ToleranceBps / 10000
+}
+
+// Retain returns how many epochs are kept
+func (c *Config) Retain() int {
+    if c.RetainEpochs <= 0 {
+        return 90
+    }
+    return c.RetainEpochs
+}
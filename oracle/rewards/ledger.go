@@ -0,0 +1,348 @@
+package rewards
+
+import (
+    "context"
+    "encoding/csv"
+    "encoding/json"
+    "fmt"
+    "io"
+    "log"
+    "math"
+    "os"
+    "sort"
+    "strconv"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+)
+
+// Tally is the participation of one source within an epoch
+type Tally struct {
+    // Expected counts the rounds the source was fetched for: it priced, it
+    // failed or it was abandoned
+    Expected uint64 `json:"expected"`
+    Priced   uint64 `json:"priced"`   // rounds it returned a price in, kept or rejected
+    Kept     uint64 `json:"kept"`     // rounds its price entered the median
+    Accurate uint64 `json:"accurate"` // priced rounds within tolerance of the round price
+    // DeviationBps sums the priced rounds' distance to the round price
+    DeviationBps float64 `json:"deviationBps"`
+}
+
+// epoch holds the tallies of one epoch by source
+type epoch struct {
+    Start   time.Time         `json:"start"`
+    Sources map[string]*Tally `json:"sources"`
+}
+
+// SourceReport is the participation and reward of one source in an epoch
+type SourceReport struct {
+    Source string `json:"source"`
+    Tally
+    Participation    float64 `json:"participation"` // priced / expected
+    Accuracy         float64 `json:"accuracy"`      // accurate / priced
+    MeanDeviationBps float64 `json:"meanDeviationBps"`
+    // Score is the number of accurate rounds; Share is the source's
+    // fraction of the epoch's total score
+    Score  float64 `json:"score"`
+    Share  float64 `json:"share"`
+    Reward float64 `json:"reward"`
+}
+
+// Report is the reward report of one epoch
+type Report struct {
+    Start   time.Time      `json:"start"`
+    End     time.Time      `json:"end"`
+    Rounds  uint64         `json:"rounds"`
+    Sources []SourceReport `json:"sources"`
+}
+
+// Ledger records how each source took part in every live round of the
+// configured pairs, by epoch, so that operator networks can compensate data
+// providers for reliable and accurate prices
+type Ledger struct {
+    config *Config
+    bus    *events.Bus
+    isPair func(symbol string) bool
+
+    mu     sync.Mutex
+    epochs map[int64]*epoch // by start, Unix seconds
+    rounds map[int64]uint64
+    // failed are the sources whose fetch failed since each pair's last round
+    failed map[string]map[string]bool
+    dirty  bool
+}
+
+// NewLedger creates a ledger counting the rounds of symbols isPair accepts,
+// reloading the tallies persisted in the config's ledger file
+func NewLedger(config *Config, bus *events.Bus, isPair func(symbol string) bool) (*Ledger, error) {
+    l := &Ledger{
+        config: config,
+        bus:    bus,
+        isPair: isPair,
+        epochs: make(map[int64]*epoch),
+        rounds: make(map[int64]uint64),
+        failed: make(map[string]map[string]bool),
+    }
+    if config.Ledger == "" {
+        return l, nil
+    }
+    data, err := os.ReadFile(config.Ledger)
+    if os.IsNotExist(err) {
+        return l, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read rewards ledger: %v", err)
+    }
+    var stored persisted
+    if err := json.Unmarshal(data, &stored); err != nil {
+        return nil, fmt.Errorf("failed to parse rewards ledger: %v", err)
+    }
+    for _, e := range stored.Epochs {
+        key := e.Start.Unix()
+        l.epochs[key] = e
+        l.rounds[key] = stored.Rounds[key]
+    }
+    return l, nil
+}
+
+// persisted is the layout of the ledger file
+type persisted struct {
+    Epochs []*epoch         `json:"epochs"`
+    Rounds map[int64]uint64 `json:"rounds"`
+}
+
+// Run records rounds and fetch failures from the bus, persisting the
+// ledger every interval and when ctx is cancelled
+func (l *Ledger) Run(ctx context.Context, interval time.Duration) {
+    // One subscription keeps a round's fetch results ahead of the round; it
+    // queues rather than drops, as a missed event would misstate rewards
+    sub := l.bus.SubscribeQueue("rewards", func(e events.Event) {
+        switch payload := e.Payload.(type) {
+        case *events.FetchResultPayload:
+            if payload.Err != nil {
+                l.fetchFailed(e.Symbol, payload.Source)
+            }
+        case *common.AggregateResult:
+            l.Record(payload, time.Now())
+        }
+    }, events.FetchResult, events.Aggregate)
+    defer sub.Close()
+
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            if err := l.persist(); err != nil {
+                log.Printf("Failed to persist rewards ledger: %v", err)
+            }
+            return
+        case <-ticker.C:
+            if err := l.persist(); err != nil {
+                log.Printf("Failed to persist rewards ledger: %v", err)
+            }
+        }
+    }
+}
+
+// fetchFailed notes a failed fetch of a source for a pair's next round
+func (l *Ledger) fetchFailed(symbol, source string) {
+    if !l.isPair(symbol) {
+        return
+    }
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    if l.failed[symbol] == nil {
+        l.failed[symbol] = make(map[string]bool)
+    }
+    l.failed[symbol][source] = true
+}
+
+// Record tallies a live round of a pair. Sources that failed since the
+// pair's previous round count as expected without a price.
+func (l *Ledger) Record(result *common.AggregateResult, now time.Time) {
+    if result.Backfilled || result.Candle != nil || len(result.Sources) == 0 || !l.isPair(result.Symbol) {
+        return
+    }
+    // Source prices are before the pair's transform
+    price := result.Price
+    if result.RawPrice != 0 {
+        price = result.RawPrice
+    }
+
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    e := l.epoch(now)
+    l.rounds[e.Start.Unix()]++
+    l.dirty = true
+
+    expected := l.failed[result.Symbol]
+    delete(l.failed, result.Symbol)
+    if expected == nil {
+        expected = make(map[string]bool)
+    }
+    for _, source := range result.Abandoned {
+        expected[source] = true
+    }
+
+    priced := func(sp common.SourcePrice, kept bool) {
+        tally := e.tally(sp.Source)
+        tally.Priced++
+        if kept {
+            tally.Kept++
+        }
+        if price > 0 {
+            deviation := math.Abs(sp.Price-price) / price
+            tally.DeviationBps += deviation * 10000
+            if deviation <= l.config.Tolerance() {
+                tally.Accurate++
+            }
+        }
+        expected[sp.Source] = true
+    }
+    for _, sp := range result.Sources {
+        priced(sp, true)
+    }
+    for _, sp := range result.Rejected {
+        priced(sp, false)
+    }
+    for source := range expected {
+        e.tally(source).Expected++
+    }
+}
+
+// epoch returns the epoch containing now, creating it and dropping epochs
+// past retention; callers hold mu
+func (l *Ledger) epoch(now time.Time) *epoch {
+    start := now.UTC().Truncate(l.config.Epoch())
+    key := start.Unix()
+    if e, ok := l.epochs[key]; ok {
+        return e
+    }
+    e := &epoch{Start: start, Sources: make(map[string]*Tally)}
+    l.epochs[key] = e
+
+    cutoff := start.Add(-time.Duration(l.config.Retain()-1) * l.config.Epoch()).Unix()
+    for k := range l.epochs {
+        if k < cutoff {
+            delete(l.epochs, k)
+            delete(l.rounds, k)
+        }
+    }
+    return e
+}
+
+// tally returns a source's tally, creating it
+func (e *epoch) tally(source string) *Tally {
+    t, ok := e.Sources[source]
+    if !ok {
+        t = &Tally{}
+        e.Sources[source] = t
+    }
+    return t
+}
+
+// Reports returns the reports of the epochs starting within [from, to],
+// oldest first; the current epoch's report is provisional
+func (l *Ledger) Reports(from, to time.Time) []Report {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    reports := make([]Report, 0)
+    for key, e := range l.epochs {
+        if e.Start.Before(from) || e.Start.After(to) {
+            continue
+        }
+        reports = append(reports, l.report(e, l.rounds[key]))
+    }
+    sort.Slice(reports, func(i, j int) bool { return reports[i].Start.Before(reports[j].Start) })
+    return reports
+}
+
+// report scores an epoch's sources and splits its reward by score
+func (l *Ledger) report(e *epoch, rounds uint64) Report {
+    r := Report{Start: e.Start, End: e.Start.Add(l.config.Epoch()), Rounds: rounds, Sources: make([]SourceReport, 0, len(e.Sources))}
+    total := 0.0
+    for source, tally := range e.Sources {
+        s := SourceReport{Source: source, Tally: *tally, Score: float64(tally.Accurate)}
+        if tally.Expected > 0 {
+            s.Participation = float64(tally.Priced) / float64(tally.Expected)
+        }
+        if tally.Priced > 0 {
+            s.Accuracy = float64(tally.Accurate) / float64(tally.Priced)
+            s.MeanDeviationBps = tally.DeviationBps / float64(tally.Priced)
+        }
+        total += s.Score
+        r.Sources = append(r.Sources, s)
+    }
+    for i := range r.Sources {
+        if total > 0 {
+            r.Sources[i].Share = r.Sources[i].Score / total
+            r.Sources[i].Reward = r.Sources[i].Share * l.config.RewardPerEpoch
+        }
+    }
+    sort.Slice(r.Sources, func(i, j int) bool { return r.Sources[i].Source < r.Sources[j].Source })
+    return r
+}
+
+// persist writes the ledger to its file, if any, when it changed
+func (l *Ledger) persist() error {
+    l.mu.Lock()
+    if l.config.Ledger == "" || !l.dirty {
+        l.mu.Unlock()
+        return nil
+    }
+    stored := persisted{Epochs: make([]*epoch, 0, len(l.epochs)), Rounds: make(map[int64]uint64, len(l.rounds))}
+    for key, e := range l.epochs {
+        stored.Epochs = append(stored.Epochs, e)
+        stored.Rounds[key] = l.rounds[key]
+    }
+    sort.Slice(stored.Epochs, func(i, j int) bool { return stored.Epochs[i].Start.Before(stored.Epochs[j].Start) })
+    data, err := json.MarshalIndent(stored, "", "    ")
+    l.dirty = false
+    l.mu.Unlock()
+    if err != nil {
+        return err
+    }
+
+    tmp := l.config.Ledger + ".tmp"
+    if err := os.WriteFile(tmp, data, 0600); err != nil {
+        return fmt.Errorf("failed to write rewards ledger: %v", err)
+    }
+    return os.Rename(tmp, l.config.Ledger)
+}
+
+// WriteCSV writes reports as CSV, one row per epoch and source
+func WriteCSV(w io.Writer, reports []Report) error {
+    out := csv.NewWriter(w)
+    header := []string{"epoch_start", "epoch_end", "source", "expected", "priced", "kept", "accurate", "participation", "accuracy", "mean_deviation_bps", "score", "share", "reward"}
+    if err := out.Write(header); err != nil {
+        return err
+    }
+    float := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+    for _, r := range reports {
+        for _, s := range r.Sources {
+            row := []string{
+                r.Start.Format(time.RFC3339),
+                r.End.Format(time.RFC3339),
+                s.Source,
+                strconv.FormatUint(s.Expected, 10),
+                strconv.FormatUint(s.Priced, 10),
+                strconv.FormatUint(s.Kept, 10),
+                strconv.FormatUint(s.Accurate, 10),
+                float(s.Participation),
+                float(s.Accuracy),
+                float(s.MeanDeviationBps),
+                float(s.Score),
+                float(s.Share),
+                float(s.Reward),
+            }
+            if err := out.Write(row); err != nil {
+                return err
+            }
+        }
+    }
+    out.Flush()
+    return out.Error()
+}
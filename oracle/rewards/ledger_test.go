@@ -0,0 +1,109 @@
+package rewards
+
+import (
+    "bytes"
+    "encoding/csv"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+)
+
+func round(symbol string, price float64, kept, rejected map[string]float64, abandoned ...string) *common.AggregateResult {
+    result := &common.AggregateResult{Symbol: symbol, PricePoint: common.PricePoint{Price: price}, Abandoned: abandoned}
+    for source, p := range kept {
+        result.Sources = append(result.Sources, common.SourcePrice{Source: source, PricePoint: common.PricePoint{Price: p}})
+    }
+    for source, p := range rejected {
+        result.Rejected = append(result.Rejected, common.SourcePrice{Source: source, PricePoint: common.PricePoint{Price: p}})
+    }
+    return result
+}
+
+func TestLedgerTalliesRounds(t *testing.T) {
+    config := &Config{EpochHours: 1, RewardPerEpoch: 100}
+    l, err := NewLedger(config, events.NewBus(), func(symbol string) bool { return symbol == "ETHUSD" })
+    if err != nil {
+        t.Fatal(err)
+    }
+    now := time.Date(2026, 3, 1, 10, 15, 0, 0, time.UTC)
+
+    // Coinbase failed before the first round and kraken was abandoned
+    l.fetchFailed("ETHUSD", "coinbase")
+    l.Record(round("ETHUSD", 100, map[string]float64{"binance": 100.2}, map[string]float64{"bitstamp": 103}, "kraken"), now)
+    l.Record(round("ETHUSD", 100, map[string]float64{"binance": 100, "coinbase": 100.1, "kraken": 99.9}, nil), now.Add(time.Minute))
+    // Derived feeds and backfilled rounds are not counted
+    l.Record(round("ETHBTC", 0.05, map[string]float64{"ETHUSD": 100}, nil), now)
+    backfilled := round("ETHUSD", 100, map[string]float64{"binance": 100}, nil)
+    backfilled.Backfilled = true
+    l.Record(backfilled, now)
+
+    reports := l.Reports(now.Add(-time.Hour), now)
+    if len(reports) != 1 || reports[0].Rounds != 2 || !reports[0].Start.Equal(time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)) {
+        t.Fatalf("Expected one epoch of 2 rounds from 10:00, got %+v", reports)
+    }
+    sources := make(map[string]SourceReport)
+    for _, s := range reports[0].Sources {
+        sources[s.Source] = s
+    }
+    if s := sources["binance"]; s.Expected != 2 || s.Priced != 2 || s.Kept != 2 || s.Accurate != 2 || s.Participation != 1 {
+        t.Errorf("Expected binance accurate in both rounds, got %+v", s)
+    }
+    if s := sources["coinbase"]; s.Expected != 2 || s.Priced != 1 || s.Participation != 0.5 {
+        t.Errorf("Expected coinbase to miss the failed round, got %+v", s)
+    }
+    if s := sources["bitstamp"]; s.Kept != 0 || s.Accurate != 0 || s.Accuracy != 0 || s.MeanDeviationBps != 300 {
+        t.Errorf("Expected the rejected bitstamp inaccurate, got %+v", s)
+    }
+    // 4 accurate rounds in all: binance 2, coinbase 1, kraken 1
+    if s := sources["binance"]; s.Share != 2.0/4 || s.Reward != 50 {
+        t.Errorf("Expected binance half of the reward, got %+v", s)
+    }
+
+    var buf bytes.Buffer
+    if err := WriteCSV(&buf, reports); err != nil {
+        t.Fatal(err)
+    }
+    rows, err := csv.NewReader(&buf).ReadAll()
+    if err != nil || len(rows) != 5 || rows[1][2] != "binance" {
+        t.Errorf("Expected a header and four sources, got %v, %v", rows, err)
+    }
+}
+
+func TestLedgerPersistsAndRetains(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "rewards.json")
+    config := &Config{EpochHours: 24, RetainEpochs: 2, Ledger: path}
+    all := func(string) bool { return true }
+    l, _ := NewLedger(config, events.NewBus(), all)
+    day := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+    for i := 0; i < 3; i++ {
+        l.Record(round("ETHUSD", 100, map[string]float64{"binance": 100}, nil), day.AddDate(0, 0, i))
+    }
+    if err := l.persist(); err != nil {
+        t.Fatal(err)
+    }
+
+    reloaded, err := NewLedger(config, events.NewBus(), all)
+    if err != nil {
+        t.Fatal(err)
+    }
+    reports := reloaded.Reports(day.AddDate(0, 0, -1), day.AddDate(0, 0, 3))
+    if len(reports) != 2 || reports[0].Start.Day() != 2 || reports[1].Rounds != 1 {
+        t.Errorf("Expected the last two epochs reloaded, got %+v", reports)
+    }
+}
+
+func TestConfigValidate(t *testing.T) {
+    for _, hours := range []int{0, 1, 6, 24, 168} {
+        if err := (&Config{EpochHours: hours}).Validate(); err != nil {
+            t.Errorf("Expected %dh epochs to validate, got %v", hours, err)
+        }
+    }
+    for _, config := range []*Config{{EpochHours: 5}, {EpochHours: 36}, {ToleranceBps: -1}} {
+        if err := config.Validate(); err == nil {
+            t.Errorf("Expected %+v to be rejected", config)
+        }
+    }
+}
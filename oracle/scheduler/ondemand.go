@@ -0,0 +1,170 @@
+package scheduler
+
+import (
+    "fmt"
+    "sort"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/common"
+)
+
+// CapError is returned for an on-demand feed that has used up its rounds
+// for the hour before it had a round to serve
+type CapError struct {
+    Symbol string
+}
+
+func (e *CapError) Error() string {
+    return fmt.Sprintf("on-demand feed %s reached its hourly round cap", e.Symbol)
+}
+
+// OnDemandState is the cached view of an on-demand feed
+type OnDemandState struct {
+    Symbol    string    `json:"symbol"`
+    Cached    bool      `json:"cached"` // whether a round is cached
+    FetchedAt time.Time `json:"fetchedAt,omitempty"`
+    ExpiresAt time.Time `json:"expiresAt,omitempty"`
+    Queries   uint64    `json:"queries"`
+    Rounds    uint64    `json:"rounds"`
+    // RoundsLastHour counts against MaxRoundsPerHour
+    RoundsLastHour   int    `json:"roundsLastHour"`
+    MaxRoundsPerHour int    `json:"maxRoundsPerHour,omitempty"`
+    LastError        string `json:"lastError,omitempty"`
+}
+
+// demand is the cache entry of one on-demand feed
+type demand struct {
+    config    *common.OnDemandConfig
+    result    *common.AggregateResult
+    fetchedAt time.Time
+    rounds    []time.Time // started within the last hour
+    queries   uint64
+    total     uint64
+    lastError string
+    // inflight is the running round; queries arriving meanwhile wait for
+    // it instead of starting their own
+    inflight *round
+}
+
+// round is an on-demand aggregation round shared by concurrent queries
+type round struct {
+    done   chan struct{}
+    result *common.AggregateResult
+    err    error
+}
+
+// OnDemand aggregates pairs configured onDemand only when they are queried,
+// serving each round until its TTL expires
+type OnDemand struct {
+    agg Aggregator
+
+    mu    sync.Mutex
+    feeds map[string]*demand
+}
+
+// NewOnDemand creates the cache of the pairs configured onDemand
+func NewOnDemand(agg Aggregator, pairs map[string]*common.PairConfig) *OnDemand {
+    o := &OnDemand{agg: agg, feeds: make(map[string]*demand)}
+    for symbol, pair := range pairs {
+        if pair.OnDemand != nil {
+            o.feeds[symbol] = &demand{config: pair.OnDemand}
+        }
+    }
+    return o
+}
+
+// Serves reports whether a feed is aggregated on demand
+func (o *OnDemand) Serves(symbol string) bool {
+    _, ok := o.feeds[symbol]
+    return ok
+}
+
+// Get returns the round of an on-demand feed, aggregating afresh once the
+// cached round has expired. Concurrent queries share one round, and once
+// the hourly cap is reached the cached round is served however old.
+func (o *OnDemand) Get(symbol string, now time.Time) (*common.AggregateResult, error) {
+    o.mu.Lock()
+    d, ok := o.feeds[symbol]
+    if !ok {
+        o.mu.Unlock()
+        return nil, fmt.Errorf("%s is not an on-demand feed", symbol)
+    }
+    d.queries++
+    if d.result != nil && now.Sub(d.fetchedAt) < d.config.TTL() {
+        result := d.result
+        o.mu.Unlock()
+        return result, nil
+    }
+    if r := d.inflight; r != nil {
+        o.mu.Unlock()
+        <-r.done
+        return r.result, r.err
+    }
+
+    d.prune(now)
+    if limit := d.config.MaxRoundsPerHour; limit > 0 && len(d.rounds) >= limit {
+        result := d.result
+        o.mu.Unlock()
+        if result == nil {
+            return nil, &CapError{Symbol: symbol}
+        }
+        return result, nil
+    }
+    d.rounds = append(d.rounds, now)
+    d.total++
+    r := &round{done: make(chan struct{})}
+    d.inflight = r
+    o.mu.Unlock()
+
+    r.result, r.err = o.agg.Aggregate(symbol)
+
+    o.mu.Lock()
+    defer o.mu.Unlock()
+    d.inflight = nil
+    close(r.done)
+    if r.err != nil {
+        d.lastError = r.err.Error()
+        return nil, r.err
+    }
+    d.result, d.fetchedAt, d.lastError = r.result, now, ""
+    return r.result, nil
+}
+
+// prune drops rounds started over an hour before now; callers hold mu
+func (d *demand) prune(now time.Time) {
+    cutoff := now.Add(-time.Hour)
+    kept := d.rounds[:0]
+    for _, at := range d.rounds {
+        if at.After(cutoff) {
+            kept = append(kept, at)
+        }
+    }
+    d.rounds = kept
+}
+
+// States returns the state of every on-demand feed, ordered by symbol
+func (o *OnDemand) States(now time.Time) []OnDemandState {
+    o.mu.Lock()
+    defer o.mu.Unlock()
+    states := make([]OnDemandState, 0, len(o.feeds))
+    for symbol, d := range o.feeds {
+        d.prune(now)
+        state := OnDemandState{
+            Symbol:           symbol,
+            Cached:           d.result != nil,
+            Queries:          d.queries,
+            Rounds:           d.total,
+            RoundsLastHour:   len(d.rounds),
+            MaxRoundsPerHour: d.config.MaxRoundsPerHour,
+            LastError:        d.lastError,
+        }
+        if d.result != nil {
+            state.FetchedAt = d.fetchedAt
+            state.ExpiresAt = d.fetchedAt.Add(d.config.TTL())
+        }
+        states = append(states, state)
+    }
+    sort.Slice(states, func(i, j int) bool { return states[i].Symbol < states[j].Symbol })
+    return states
+}
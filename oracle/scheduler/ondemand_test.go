@@ -0,0 +1,84 @@
+package scheduler
+
+import (
+    "sync"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+)
+
+// slowAggregator blocks rounds until released
+type slowAggregator struct {
+    recordingAggregator
+    release chan struct{}
+}
+
+func (s *slowAggregator) Aggregate(symbol string) (*common.AggregateResult, error) {
+    <-s.release
+    return s.recordingAggregator.Aggregate(symbol)
+}
+
+func TestOnDemand(t *testing.T) {
+    agg := &recordingAggregator{}
+    pairs := map[string]*common.PairConfig{
+        "ETHUSDT":  {},
+        "PEPEUSDT": {OnDemand: &common.OnDemandConfig{TTLSeconds: 30, MaxRoundsPerHour: 2}},
+    }
+    feeds, err := FeedsFromConfig(nil, pairs, nil)
+    if err != nil || len(feeds) != 1 || feeds[0].Symbol != "ETHUSDT" {
+        t.Fatalf("Expected only ETHUSDT to be scheduled, got %v (%v)", feeds, err)
+    }
+
+    o := NewOnDemand(agg, pairs)
+    if o.Serves("ETHUSDT") || !o.Serves("PEPEUSDT") {
+        t.Fatal("Expected only PEPEUSDT to be served on demand")
+    }
+    now := time.Now()
+    for _, at := range []time.Time{now, now.Add(10 * time.Second)} {
+        if _, err := o.Get("PEPEUSDT", at); err != nil {
+            t.Fatalf("Failed to get PEPEUSDT: %v", err)
+        }
+    }
+    if len(agg.calls) != 1 {
+        t.Errorf("Expected the second query served from the cache, got %d rounds", len(agg.calls))
+    }
+
+    // Past the TTL a query aggregates again, until the hourly cap
+    o.Get("PEPEUSDT", now.Add(time.Minute))
+    o.Get("PEPEUSDT", now.Add(2*time.Minute))
+    if len(agg.calls) != 2 {
+        t.Errorf("Expected the cap to hold rounds at 2, got %d", len(agg.calls))
+    }
+    state := o.States(now.Add(2 * time.Minute))[0]
+    if state.Queries != 4 || state.Rounds != 2 || state.RoundsLastHour != 2 || !state.ExpiresAt.Equal(now.Add(90*time.Second)) {
+        t.Errorf("Unexpected state %+v", state)
+    }
+    // An hour on the cap no longer applies
+    o.Get("PEPEUSDT", now.Add(61*time.Minute))
+    if len(agg.calls) != 3 {
+        t.Errorf("Expected a round once the hour passed, got %d", len(agg.calls))
+    }
+}
+
+func TestOnDemandSharesRounds(t *testing.T) {
+    agg := &slowAggregator{release: make(chan struct{})}
+    o := NewOnDemand(agg, map[string]*common.PairConfig{"PEPEUSDT": {OnDemand: &common.OnDemandConfig{}}})
+
+    var wg sync.WaitGroup
+    for i := 0; i < 5; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            if result, err := o.Get("PEPEUSDT", time.Now()); err != nil || result == nil {
+                t.Errorf("Expected a shared round, got %v (%v)", result, err)
+            }
+        }()
+    }
+    time.Sleep(20 * time.Millisecond)
+    close(agg.release)
+    wg.Wait()
+    if len(agg.calls) != 1 {
+        t.Errorf("Expected concurrent queries to share one round, got %d", len(agg.calls))
+    }
+}
@@ -0,0 +1,556 @@
+package scheduler
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "sort"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/calendar"
+    "yetaXYZ/oracle/common"
+)
+
+// Aggregator is the subset of the aggregator driven by the scheduler
+type Aggregator interface {
+    Aggregate(symbol string) (*common.AggregateResult, error)
+}
+
+// Feed is a single scheduled aggregation
+type Feed struct {
+    Symbol    string
+    Interval  time.Duration
+    DependsOn []string // feeds that must be primed before this one
+    // Calendar restricts fetches to trading sessions; nil trades 24/7
+    Calendar *calendar.Calendar
+}
+
+// FeedState is the scheduler's cached view of a feed
+type FeedState struct {
+    Result      *common.AggregateResult `json:"result,omitempty"`
+    LastError   string                  `json:"lastError,omitempty"`
+    LastAttempt time.Time               `json:"lastAttempt"`
+    Failures    int                     `json:"consecutiveFailures"`
+    // MarketClosed is set while the feed's market is outside its sessions;
+    // Result then holds the last value fetched before the close
+    MarketClosed bool `json:"marketClosed"`
+    // Running is set while an aggregation round of the feed is in flight
+    Running bool `json:"running,omitempty"`
+    // Paused is set while an operator has stopped the feed's rounds;
+    // Result then holds the last value fetched before the pause
+    Paused bool `json:"paused,omitempty"`
+}
+
+// PrimingStatus reports the progress of the startup warm-up
+type PrimingStatus struct {
+    Total       int       `json:"total"`
+    Primed      int       `json:"primed"`
+    Failed      int       `json:"failed"`
+    Done        bool      `json:"done"`
+    StartedAt   time.Time `json:"startedAt"`
+    CompletedAt time.Time `json:"completedAt,omitempty"`
+}
+
+// Options tunes scheduler behaviour
+type Options struct {
+    // StaggerWindow spreads the first fetches of each priming level over
+    // this window to avoid a thundering herd against upstream sources
+    StaggerWindow time.Duration
+}
+
+// Scheduler primes feeds in dependency order and then keeps them updated
+// at their configured intervals, caching the latest result of each feed
+type Scheduler struct {
+    agg     Aggregator
+    feeds   map[string]Feed
+    options Options
+
+    mu      sync.RWMutex
+    states  map[string]*FeedState
+    priming PrimingStatus
+    // rounds tracks in-flight aggregation rounds for Drain
+    rounds   sync.WaitGroup
+    draining bool
+    // wake tells a feed's run loop that its interval changed
+    wake map[string]chan struct{}
+}
+
+// New creates a scheduler for the given feeds
+func New(agg Aggregator, feeds []Feed, options Options) *Scheduler {
+    s := &Scheduler{
+        agg:     agg,
+        feeds:   make(map[string]Feed, len(feeds)),
+        options: options,
+        states:  make(map[string]*FeedState, len(feeds)),
+        wake:    make(map[string]chan struct{}, len(feeds)),
+    }
+    for _, f := range feeds {
+        s.feeds[f.Symbol] = f
+        s.states[f.Symbol] = &FeedState{}
+        s.wake[f.Symbol] = make(chan struct{}, 1)
+    }
+    s.priming.Total = len(feeds)
+    return s
+}
+
+// FeedsFromConfig builds scheduled feeds from the pair configuration,
+// attaching the trading calendar of each pair's feed class. A pair depends
+// on the scheduled feeds converting the other members of its quote class,
+// so they are primed first. On-demand pairs are left to OnDemand.
+func FeedsFromConfig(base *common.BaseConfig, pairs map[string]*common.PairConfig, calendars *calendar.Registry) ([]Feed, error) {
+    feeds := make([]Feed, 0, len(pairs))
+    for symbol, pair := range pairs {
+        if pair.OnDemand != nil {
+            continue
+        }
+        cal, err := calendars.ForClass(pair.FeedClass)
+        if err != nil {
+            return nil, fmt.Errorf("pair %s: %v", symbol, err)
+        }
+        feeds = append(feeds, Feed{Symbol: symbol, Interval: Interval(pair), Calendar: cal, DependsOn: quoteFeeds(base, pairs, symbol)})
+    }
+    sort.Slice(feeds, func(i, j int) bool { return feeds[i].Symbol < feeds[j].Symbol })
+    return feeds, nil
+}
+
+// quoteFeeds returns the scheduled feeds converting the members of the quote
+// class of symbol, sorted. The conversion feeds themselves depend on none,
+// which keeps pegs of one class from depending on each other.
+func quoteFeeds(base *common.BaseConfig, pairs map[string]*common.PairConfig, symbol string) []string {
+    if base == nil {
+        return nil
+    }
+    members := base.QuoteClasses[pairs[symbol].QuoteCurrency].Members
+    for _, member := range members {
+        if member.Feed == symbol {
+            return nil
+        }
+    }
+    var deps []string
+    for _, member := range members {
+        if feed, ok := pairs[member.Feed]; ok && feed.OnDemand == nil {
+            deps = append(deps, member.Feed)
+        }
+    }
+    sort.Strings(deps)
+    return deps
+}
+
+// Interval returns the update interval of a pair, default 5 seconds
+func Interval(pair *common.PairConfig) time.Duration {
+    if pair.UpdateFrequencySeconds <= 0 {
+        return 5 * time.Second
+    }
+    return time.Duration(pair.UpdateFrequencySeconds) * time.Second
+}
+
+// Start primes all feeds and then runs them until ctx is cancelled
+func (s *Scheduler) Start(ctx context.Context) error {
+    levels, err := primingLevels(s.feeds)
+    if err != nil {
+        return err
+    }
+
+    go func() {
+        s.prime(ctx, levels)
+        for _, feed := range s.Feeds() {
+            go s.run(ctx, feed)
+        }
+    }()
+    return nil
+}
+
+// prime fetches every feed once, level by level, staggering starts within a level
+func (s *Scheduler) prime(ctx context.Context, levels [][]string) {
+    s.mu.Lock()
+    s.priming.StartedAt = time.Now()
+    s.mu.Unlock()
+
+    for _, level := range levels {
+        // Feeds warmed with another instance's results need no priming round
+        level = s.unwarmed(level)
+        var wg sync.WaitGroup
+        step := time.Duration(0)
+        if len(level) > 1 {
+            step = s.options.StaggerWindow / time.Duration(len(level))
+        }
+
+        for i, symbol := range level {
+            wg.Add(1)
+            go func(symbol string, delay time.Duration) {
+                defer wg.Done()
+                select {
+                case <-time.After(delay):
+                case <-ctx.Done():
+                    return
+                }
+
+                // Prime closed markets too so their last close is available
+                err := s.update(symbol)
+                s.mu.RLock()
+                feed := s.feeds[symbol]
+                s.mu.RUnlock()
+                s.setMarketClosed(symbol, !s.isOpen(feed, time.Now()))
+                s.mu.Lock()
+                if err != nil {
+                    s.priming.Failed++
+                } else {
+                    s.priming.Primed++
+                }
+                s.mu.Unlock()
+            }(symbol, time.Duration(i)*step)
+        }
+        wg.Wait()
+
+        if ctx.Err() != nil {
+            return
+        }
+    }
+
+    s.mu.Lock()
+    s.priming.Done = true
+    s.priming.CompletedAt = time.Now()
+    status := s.priming
+    s.mu.Unlock()
+    log.Printf("Priming complete: %d primed, %d failed in %s", status.Primed, status.Failed, status.CompletedAt.Sub(status.StartedAt))
+}
+
+// unwarmed returns the feeds of a priming level without a result yet,
+// counting the others as primed
+func (s *Scheduler) unwarmed(level []string) []string {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    cold := make([]string, 0, len(level))
+    for _, symbol := range level {
+        if s.states[symbol].Result != nil {
+            s.priming.Primed++
+            continue
+        }
+        cold = append(cold, symbol)
+    }
+    return cold
+}
+
+// Warm caches results taken over from another instance, such as the leader
+// a standby mirrored, so that Start does not prime those feeds again. Call
+// before Start.
+func (s *Scheduler) Warm(results []*common.AggregateResult) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    now := time.Now()
+    for _, result := range results {
+        state, ok := s.states[result.Symbol]
+        if !ok {
+            continue
+        }
+        state.Result = result
+        state.MarketClosed = !s.isOpen(s.feeds[result.Symbol], now)
+    }
+}
+
+// run updates a feed at its interval until ctx is cancelled
+func (s *Scheduler) run(ctx context.Context, feed Feed) {
+    ticker := time.NewTicker(feed.Interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-s.wake[feed.Symbol]:
+            s.mu.RLock()
+            feed = s.feeds[feed.Symbol]
+            s.mu.RUnlock()
+            ticker.Reset(feed.Interval)
+        case <-ticker.C:
+            if s.paused(feed.Symbol) {
+                continue
+            }
+            // Outside sessions keep the last close rather than fetching
+            // prices that cannot move
+            if !s.isOpen(feed, time.Now()) {
+                s.setMarketClosed(feed.Symbol, true)
+                continue
+            }
+            s.update(feed.Symbol)
+            s.setMarketClosed(feed.Symbol, false)
+        }
+    }
+}
+
+// isOpen reports whether a feed's market is trading at t
+func (s *Scheduler) isOpen(feed Feed, t time.Time) bool {
+    return feed.Calendar == nil || feed.Calendar.IsOpen(t)
+}
+
+// setMarketClosed records whether a feed's market is closed
+func (s *Scheduler) setMarketClosed(symbol string, closed bool) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.states[symbol].MarketClosed = closed
+}
+
+// paused reports whether an operator has paused a feed
+func (s *Scheduler) paused(symbol string) bool {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    return s.states[symbol].Paused
+}
+
+// update runs one aggregation round for a feed and caches the outcome
+func (s *Scheduler) update(symbol string) error {
+    s.mu.Lock()
+    if s.draining {
+        s.mu.Unlock()
+        return errDraining
+    }
+    if s.states[symbol].Running {
+        s.mu.Unlock()
+        return errInFlight
+    }
+    s.rounds.Add(1)
+    s.states[symbol].Running = true
+    s.mu.Unlock()
+    defer s.rounds.Done()
+
+    result, err := s.agg.Aggregate(symbol)
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    state := s.states[symbol]
+    if !state.Running {
+        return errDraining // recorded as aborted by Drain
+    }
+    state.Running = false
+    state.LastAttempt = time.Now()
+    if err != nil {
+        state.LastError = err.Error()
+        state.Failures++
+        log.Printf("Scheduled update of %s failed: %v", symbol, err)
+        return err
+    }
+    state.Result = result
+    state.LastError = ""
+    state.Failures = 0
+    return nil
+}
+
+// errDraining is returned for rounds not started because of shutdown
+var errDraining = fmt.Errorf("scheduler is shutting down")
+
+// errInFlight is returned for rounds not started because the feed already
+// has one running
+var errInFlight = fmt.Errorf("a round is already in flight")
+
+// SetPaused stops or resumes a feed's scheduled rounds. A paused feed keeps
+// serving its last result.
+func (s *Scheduler) SetPaused(symbol string, paused bool) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    state, ok := s.states[symbol]
+    if !ok {
+        return fmt.Errorf("unknown feed %s", symbol)
+    }
+    state.Paused = paused
+    return nil
+}
+
+// SetInterval changes a feed's update interval, restarting its schedule
+func (s *Scheduler) SetInterval(symbol string, interval time.Duration) error {
+    if interval <= 0 {
+        return fmt.Errorf("interval must be positive")
+    }
+    s.mu.Lock()
+    feed, ok := s.feeds[symbol]
+    if !ok {
+        s.mu.Unlock()
+        return fmt.Errorf("unknown feed %s", symbol)
+    }
+    changed := feed.Interval != interval
+    feed.Interval = interval
+    s.feeds[symbol] = feed
+    s.mu.Unlock()
+
+    if changed {
+        select {
+        case s.wake[symbol] <- struct{}{}:
+        default: // the run loop has yet to pick up an earlier change
+        }
+    }
+    return nil
+}
+
+// Trigger starts a round of a feed now, outside its schedule, e.g. to
+// re-publish it. Paused feeds and closed markets are refused; a round
+// already in flight is not doubled.
+func (s *Scheduler) Trigger(symbol string) error {
+    s.mu.RLock()
+    feed, ok := s.feeds[symbol]
+    var paused bool
+    if ok {
+        paused = s.states[symbol].Paused
+    }
+    draining := s.draining
+    s.mu.RUnlock()
+    if !ok {
+        return fmt.Errorf("unknown feed %s", symbol)
+    }
+    switch {
+    case draining:
+        return errDraining
+    case paused:
+        return fmt.Errorf("feed %s is paused", symbol)
+    case !s.isOpen(feed, time.Now()):
+        return fmt.Errorf("market of %s is closed", symbol)
+    }
+    go s.update(symbol)
+    return nil
+}
+
+// Drain stops new rounds from starting and waits for those in flight to
+// complete, until ctx is done. Rounds still running then are recorded as
+// aborted in their feed's state and returned; their results, should they
+// arrive later, are not cached. ctx of Start should already be cancelled.
+func (s *Scheduler) Drain(ctx context.Context) []string {
+    s.mu.Lock()
+    s.draining = true
+    s.mu.Unlock()
+
+    done := make(chan struct{})
+    go func() {
+        s.rounds.Wait()
+        close(done)
+    }()
+    select {
+    case <-done:
+        return nil
+    case <-ctx.Done():
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    aborted := make([]string, 0)
+    for symbol, state := range s.states {
+        if !state.Running {
+            continue
+        }
+        state.Running = false
+        state.LastError = "round aborted at shutdown"
+        state.LastAttempt = time.Now()
+        aborted = append(aborted, symbol)
+        log.Printf("Aggregation round of %s aborted at shutdown", symbol)
+    }
+    sort.Strings(aborted)
+    return aborted
+}
+
+// Latest returns the most recent cached result for a feed. While the
+// feed's market is closed the last close is returned flagged MarketClosed.
+func (s *Scheduler) Latest(symbol string) (*common.AggregateResult, bool) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    state, ok := s.states[symbol]
+    if !ok || state.Result == nil {
+        return nil, false
+    }
+    if state.MarketClosed {
+        closed := *state.Result
+        closed.MarketClosed = true
+        return &closed, true
+    }
+    return state.Result, true
+}
+
+// MarketClosed reports whether a scheduled feed's market is outside its sessions
+func (s *Scheduler) MarketClosed(symbol string) bool {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    state, ok := s.states[symbol]
+    return ok && state.MarketClosed
+}
+
+// States returns a copy of the cached state of every feed
+func (s *Scheduler) States() map[string]FeedState {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    out := make(map[string]FeedState, len(s.states))
+    for symbol, state := range s.states {
+        out[symbol] = *state
+    }
+    return out
+}
+
+// Feeds returns the scheduled feeds sorted by symbol
+func (s *Scheduler) Feeds() []Feed {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    feeds := make([]Feed, 0, len(s.feeds))
+    for _, feed := range s.feeds {
+        feeds = append(feeds, feed)
+    }
+    sort.Slice(feeds, func(i, j int) bool { return feeds[i].Symbol < feeds[j].Symbol })
+    return feeds
+}
+
+// Priming returns the current priming progress
+func (s *Scheduler) Priming() PrimingStatus {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    return s.priming
+}
+
+// primingLevels groups feeds into levels so that every feed appears after
+// all the feeds it depends on
+func primingLevels(feeds map[string]Feed) ([][]string, error) {
+    level := make(map[string]int, len(feeds))
+    visiting := make(map[string]bool)
+
+    var visit func(symbol string) (int, error)
+    visit = func(symbol string) (int, error) {
+        if l, ok := level[symbol]; ok {
+            return l, nil
+        }
+        if visiting[symbol] {
+            return 0, fmt.Errorf("dependency cycle involving %s", symbol)
+        }
+        feed, ok := feeds[symbol]
+        if !ok {
+            return 0, fmt.Errorf("unknown feed dependency: %s", symbol)
+        }
+
+        visiting[symbol] = true
+        l := 0
+        for _, dep := range feed.DependsOn {
+            depLevel, err := visit(dep)
+            if err != nil {
+                return 0, err
+            }
+            if depLevel+1 > l {
+                l = depLevel + 1
+            }
+        }
+        visiting[symbol] = false
+        level[symbol] = l
+        return l, nil
+    }
+
+    symbols := make([]string, 0, len(feeds))
+    for symbol := range feeds {
+        symbols = append(symbols, symbol)
+    }
+    sort.Strings(symbols)
+
+    levels := make([][]string, 0)
+    for _, symbol := range symbols {
+        l, err := visit(symbol)
+        if err != nil {
+            return nil, err
+        }
+        for len(levels) <= l {
+            levels = append(levels, nil)
+        }
+        levels[l] = append(levels[l], symbol)
+    }
+    return levels, nil
+}
@@ -0,0 +1,305 @@
+package scheduler
+
+import (
+    "context"
+    "fmt"
+    "sync"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/calendar"
+    "yetaXYZ/oracle/common"
+)
+
+type recordingAggregator struct {
+    mu    sync.Mutex
+    calls []string
+    fail  map[string]bool
+}
+
+func (r *recordingAggregator) Aggregate(symbol string) (*common.AggregateResult, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.calls = append(r.calls, symbol)
+    if r.fail[symbol] {
+        return nil, fmt.Errorf("upstream down")
+    }
+    return &common.AggregateResult{Symbol: symbol, PricePoint: common.PricePoint{Price: 1}}, nil
+}
+
+func TestPrimingRespectsDependencies(t *testing.T) {
+    agg := &recordingAggregator{fail: map[string]bool{"BTCUSDT": true}}
+    feeds := []Feed{
+        {Symbol: "ETHBTC", Interval: time.Hour, DependsOn: []string{"ETHUSDT", "BTCUSDT"}},
+        {Symbol: "ETHUSDT", Interval: time.Hour},
+        {Symbol: "BTCUSDT", Interval: time.Hour},
+    }
+    s := New(agg, feeds, Options{StaggerWindow: 10 * time.Millisecond})
+
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    if err := s.Start(ctx); err != nil {
+        t.Fatalf("Failed to start scheduler: %v", err)
+    }
+
+    deadline := time.Now().Add(2 * time.Second)
+    for !s.Priming().Done {
+        if time.Now().After(deadline) {
+            t.Fatal("Priming did not complete")
+        }
+        time.Sleep(5 * time.Millisecond)
+    }
+
+    agg.mu.Lock()
+    if last := agg.calls[len(agg.calls)-1]; last != "ETHBTC" {
+        t.Errorf("Expected derived feed to be primed last, got order %v", agg.calls)
+    }
+    agg.mu.Unlock()
+
+    status := s.Priming()
+    if status.Primed != 2 || status.Failed != 1 {
+        t.Errorf("Expected 2 primed and 1 failed, got %+v", status)
+    }
+    if _, ok := s.Latest("ETHUSDT"); !ok {
+        t.Error("Expected ETHUSDT to be cached after priming")
+    }
+    if state := s.States()["BTCUSDT"]; state.Failures != 1 || state.LastError == "" {
+        t.Errorf("Expected BTCUSDT failure to be recorded, got %+v", state)
+    }
+}
+
+func TestWarmFeedsSkipPriming(t *testing.T) {
+    agg := &recordingAggregator{}
+    feeds := []Feed{
+        {Symbol: "ETHUSDT", Interval: time.Hour},
+        {Symbol: "BTCUSDT", Interval: time.Hour},
+    }
+    s := New(agg, feeds, Options{})
+    s.Warm([]*common.AggregateResult{
+        {Symbol: "ETHUSDT", PricePoint: common.PricePoint{Price: 3000}, RoundID: 41},
+        {Symbol: "SOLUSDT", PricePoint: common.PricePoint{Price: 150}},
+    })
+
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    if err := s.Start(ctx); err != nil {
+        t.Fatalf("Failed to start scheduler: %v", err)
+    }
+    deadline := time.Now().Add(2 * time.Second)
+    for !s.Priming().Done {
+        if time.Now().After(deadline) {
+            t.Fatal("Priming did not complete")
+        }
+        time.Sleep(5 * time.Millisecond)
+    }
+
+    agg.mu.Lock()
+    if len(agg.calls) != 1 || agg.calls[0] != "BTCUSDT" {
+        t.Errorf("Expected only the cold feed to be primed, got %v", agg.calls)
+    }
+    agg.mu.Unlock()
+    if status := s.Priming(); status.Primed != 2 {
+        t.Errorf("Expected both feeds primed, got %+v", status)
+    }
+    if result, ok := s.Latest("ETHUSDT"); !ok || result.RoundID != 41 {
+        t.Errorf("Expected the warmed ETHUSDT round, got %+v", result)
+    }
+    if _, ok := s.Latest("SOLUSDT"); ok {
+        t.Error("Expected results of unscheduled feeds to be ignored")
+    }
+}
+
+func TestPrimingRejectsCycles(t *testing.T) {
+    feeds := []Feed{
+        {Symbol: "A", Interval: time.Hour, DependsOn: []string{"B"}},
+        {Symbol: "B", Interval: time.Hour, DependsOn: []string{"A"}},
+    }
+    s := New(&recordingAggregator{}, feeds, Options{})
+    if err := s.Start(context.Background()); err == nil {
+        t.Error("Expected error for dependency cycle, got nil")
+    }
+}
+
+func TestClosedMarketCarriesLastClose(t *testing.T) {
+    now := time.Now().UTC()
+    closed := calendar.New("closed", time.UTC, nil, []string{
+        now.Format("2006-01-02"),
+        now.AddDate(0, 0, 1).Format("2006-01-02"),
+    })
+
+    agg := &recordingAggregator{}
+    feeds := []Feed{{Symbol: "EURUSD", Interval: 10 * time.Millisecond, Calendar: closed}}
+    s := New(agg, feeds, Options{})
+
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    if err := s.Start(ctx); err != nil {
+        t.Fatalf("Failed to start scheduler: %v", err)
+    }
+
+    deadline := time.Now().Add(2 * time.Second)
+    for !s.Priming().Done {
+        if time.Now().After(deadline) {
+            t.Fatal("Priming did not complete")
+        }
+        time.Sleep(5 * time.Millisecond)
+    }
+    time.Sleep(50 * time.Millisecond)
+
+    result, ok := s.Latest("EURUSD")
+    if !ok || !result.MarketClosed {
+        t.Errorf("Expected last close flagged marketClosed, got %+v", result)
+    }
+
+    agg.mu.Lock()
+    defer agg.mu.Unlock()
+    if len(agg.calls) != 1 {
+        t.Errorf("Expected only the priming fetch while closed, got %d calls", len(agg.calls))
+    }
+}
+
+type blockingAggregator struct {
+    block   string
+    release chan struct{}
+}
+
+func (b *blockingAggregator) Aggregate(symbol string) (*common.AggregateResult, error) {
+    if symbol == b.block {
+        <-b.release
+    }
+    return &common.AggregateResult{Symbol: symbol}, nil
+}
+
+func TestDrainRecordsAbortedRounds(t *testing.T) {
+    agg := &blockingAggregator{block: "SLOW", release: make(chan struct{})}
+    defer close(agg.release)
+    s := New(agg, []Feed{{Symbol: "FAST", Interval: time.Hour}, {Symbol: "SLOW", Interval: time.Hour}}, Options{})
+
+    ctx, cancel := context.WithCancel(context.Background())
+    if err := s.Start(ctx); err != nil {
+        t.Fatalf("Start failed: %v", err)
+    }
+    deadline := time.Now().Add(time.Second)
+    for !s.States()["SLOW"].Running || s.States()["FAST"].Result == nil {
+        if time.Now().After(deadline) {
+            t.Fatal("Rounds did not start")
+        }
+        time.Sleep(time.Millisecond)
+    }
+    cancel()
+
+    drainCtx, stop := context.WithTimeout(context.Background(), 20*time.Millisecond)
+    aborted := s.Drain(drainCtx)
+    stop()
+    if len(aborted) != 1 || aborted[0] != "SLOW" {
+        t.Fatalf("Expected SLOW aborted, got %v", aborted)
+    }
+    state := s.States()["SLOW"]
+    if state.Running || state.LastError == "" {
+        t.Errorf("Expected aborted round recorded, got %+v", state)
+    }
+    if err := s.update("FAST"); err != errDraining {
+        t.Errorf("Expected no new rounds while draining, got %v", err)
+    }
+}
+
+func TestPauseIntervalAndTrigger(t *testing.T) {
+    agg := &recordingAggregator{}
+    s := New(agg, []Feed{{Symbol: "ETHUSD", Interval: time.Hour}}, Options{})
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    if err := s.Start(ctx); err != nil {
+        t.Fatalf("Start failed: %v", err)
+    }
+    calls := func() int {
+        agg.mu.Lock()
+        defer agg.mu.Unlock()
+        return len(agg.calls)
+    }
+    waitCalls := func(n int) {
+        deadline := time.Now().Add(2 * time.Second)
+        for calls() < n {
+            if time.Now().After(deadline) {
+                t.Fatalf("Expected %d rounds, got %d", n, calls())
+            }
+            time.Sleep(time.Millisecond)
+        }
+    }
+    waitCalls(1)
+
+    // A shorter interval takes effect without waiting out the hour
+    if err := s.SetInterval("ETHUSD", 5*time.Millisecond); err != nil {
+        t.Fatal(err)
+    }
+    waitCalls(3)
+
+    // Paused feeds keep their last result and refuse triggers
+    if err := s.SetPaused("ETHUSD", true); err != nil {
+        t.Fatal(err)
+    }
+    time.Sleep(20 * time.Millisecond)
+    paused := calls()
+    time.Sleep(30 * time.Millisecond)
+    if calls() != paused {
+        t.Errorf("Expected no rounds while paused, got %d more", calls()-paused)
+    }
+    if _, ok := s.Latest("ETHUSD"); !ok || !s.States()["ETHUSD"].Paused {
+        t.Error("Expected the paused feed to keep its result")
+    }
+    if err := s.Trigger("ETHUSD"); err == nil {
+        t.Error("Expected a paused feed to refuse a trigger")
+    }
+
+    s.SetPaused("ETHUSD", false)
+    s.SetInterval("ETHUSD", time.Hour)
+    time.Sleep(20 * time.Millisecond)
+    before := calls()
+    if err := s.Trigger("ETHUSD"); err != nil {
+        t.Fatal(err)
+    }
+    waitCalls(before + 1)
+
+    if err := s.SetPaused("BTCUSD", true); err == nil {
+        t.Error("Expected an unknown feed to fail")
+    }
+}
+
+func TestFeedsFromConfigDependOnQuoteFeeds(t *testing.T) {
+    base := &common.BaseConfig{QuoteClasses: map[string]common.QuoteClass{
+        "USD": {Members: map[string]common.QuoteMember{
+            "USDT": {Feed: "USDTUSD"},
+            "USDC": {Feed: "USDCUSD"},
+            "DAI":  {Factor: 1},
+        }},
+    }}
+    pairs := map[string]*common.PairConfig{
+        "ETHUSD":  {QuoteCurrency: "USD"},
+        "USDTUSD": {QuoteCurrency: "USD"},
+        "USDCUSD": {QuoteCurrency: "USD"},
+        "BTCUSDT": {QuoteCurrency: "USDT"},
+    }
+    feeds, err := FeedsFromConfig(base, pairs, nil)
+    if err != nil {
+        t.Fatalf("Failed to build feeds: %v", err)
+    }
+
+    deps := make(map[string][]string)
+    for _, feed := range feeds {
+        deps[feed.Symbol] = feed.DependsOn
+    }
+    if got := deps["ETHUSD"]; len(got) != 2 || got[0] != "USDCUSD" || got[1] != "USDTUSD" {
+        t.Errorf("Expected ETHUSD to depend on both peg feeds, got %v", got)
+    }
+    // Peg feeds depend on neither each other nor themselves
+    if len(deps["USDTUSD"]) != 0 || len(deps["USDCUSD"]) != 0 || len(deps["BTCUSDT"]) != 0 {
+        t.Errorf("Expected no other dependencies, got %v", deps)
+    }
+
+    byName := make(map[string]Feed)
+    for _, feed := range feeds {
+        byName[feed.Symbol] = feed
+    }
+    if _, err := primingLevels(byName); err != nil {
+        t.Errorf("Expected the feeds to be primed in order, got %v", err)
+    }
+}
@@ -0,0 +1,189 @@
+// Package sdk is a Go client of the oracle's HTTP API for consumers of its
+// feeds. It handles retries, the v2 response envelope and reconnecting to
+// the event stream, returning the oracle's own result types.
+package sdk
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "net/url"
+    "strings"
+    "time"
+
+    "yetaXYZ/oracle/common"
+)
+
+// Retry backoff of failed requests
+const (
+    defaultRetries = 3
+    minBackoff     = 500 * time.Millisecond
+    maxBackoff     = 30 * time.Second
+)
+
+// defaultTimeout bounds a single non-streaming request unless SetTimeout
+// changes it
+const defaultTimeout = 10 * time.Second
+
+// maxResponseBytes bounds the body of a non-streaming response
+const maxResponseBytes = 10 << 20
+
+// APIError is an error response from the oracle
+type APIError struct {
+    Status  int
+    Code    string // e.g. not_found, unavailable; see the API's v2 error codes
+    Message string
+    // Sources gives why each source failed when a round fell short of
+    // sources (code insufficient_sources)
+    Sources []common.SourceFailure
+}
+
+func (e *APIError) Error() string {
+    return fmt.Sprintf("oracle returned %d %s: %s", e.Status, e.Code, e.Message)
+}
+
+// Temporary reports whether the request may succeed if retried
+func (e *APIError) Temporary() bool {
+    return e.Status >= 500
+}
+
+// Client reads feeds from an oracle. It is safe for concurrent use.
+type Client struct {
+    baseURL string
+    apiKey  string
+    retries int
+    http    *http.Client
+}
+
+// NewClient creates a client of the oracle at the given base URL, e.g.
+// https://oracle.example.com
+func NewClient(baseURL string) *Client {
+    return &Client{
+        baseURL: strings.TrimRight(baseURL, "/"),
+        retries: defaultRetries,
+        // Streams are long-lived and not bound by the timeout
+        http: &http.Client{Timeout: defaultTimeout},
+    }
+}
+
+// SetAPIKey authenticates the client to an oracle that meters its consumers
+func (c *Client) SetAPIKey(key string) {
+    c.apiKey = key
+}
+
+// SetRetries sets how often a failed request is retried, default 3. Only
+// network errors and 5xx responses are retried.
+func (c *Client) SetRetries(n int) {
+    c.retries = n
+}
+
+// SetTimeout sets how long a request may take, default 10 seconds; 0
+// leaves requests unbounded. Subscriptions are not bound by it.
+func (c *Client) SetTimeout(timeout time.Duration) {
+    c.http.Timeout = timeout
+}
+
+// SetHTTPClient replaces the HTTP client, e.g. to configure TLS or proxies.
+// Its Timeout replaces the client's timeout.
+func (c *Client) SetHTTPClient(client *http.Client) {
+    c.http = client
+}
+
+// Symbol converts a pair written with a separator, e.g. "ETH/USDT" or
+// "eth-usdt", into the oracle's feed symbol "ETHUSDT". Feed symbols are
+// returned unchanged.
+func Symbol(feed string) string {
+    return strings.ToUpper(strings.NewReplacer("/", "", "-", "").Replace(feed))
+}
+
+// Price returns the current round of a feed, e.g. "ETH/USDT". Derived and
+// statistic feeds are addressed by their symbol, e.g. "ETHUSDT_30D_VOL".
+func (c *Client) Price(ctx context.Context, feed string) (*common.AggregateResult, error) {
+    var result common.AggregateResult
+    if err := c.get(ctx, "/api/v2/feeds/"+url.PathEscape(Symbol(feed)), &result); err != nil {
+        return nil, err
+    }
+    return &result, nil
+}
+
+// get fetches a v2 endpoint into data, retrying temporary failures with
+// backoff
+func (c *Client) get(ctx context.Context, path string, data interface{}) error {
+    backoff := minBackoff
+    for attempt := 0; ; attempt++ {
+        err := c.getOnce(ctx, path, data)
+        if err == nil {
+            return nil
+        }
+        if apiErr, ok := err.(*APIError); ok && !apiErr.Temporary() {
+            return err
+        }
+        if attempt >= c.retries || ctx.Err() != nil {
+            return err
+        }
+        select {
+        case <-ctx.Done():
+            return err
+        case <-time.After(backoff):
+        }
+        if backoff *= 2; backoff > maxBackoff {
+            backoff = maxBackoff
+        }
+    }
+}
+
+// getOnce makes a single request and unwraps the v2 envelope
+func (c *Client) getOnce(ctx context.Context, path string, data interface{}) error {
+    req, err := c.newRequest(ctx, path)
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Accept", "application/json")
+    resp, err := c.http.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+
+    var envelope struct {
+        Data  json.RawMessage `json:"data"`
+        Error *struct {
+            Code    string                 `json:"code"`
+            Message string                 `json:"message"`
+            Sources []common.SourceFailure `json:"sources"`
+        } `json:"error"`
+    }
+    if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(&envelope); err != nil {
+        if resp.StatusCode != http.StatusOK {
+            return &APIError{Status: resp.StatusCode, Message: resp.Status}
+        }
+        return fmt.Errorf("invalid response from oracle: %v", err)
+    }
+    if resp.StatusCode != http.StatusOK || envelope.Error != nil {
+        apiErr := &APIError{Status: resp.StatusCode}
+        if envelope.Error != nil {
+            apiErr.Code = envelope.Error.Code
+            apiErr.Message = envelope.Error.Message
+            apiErr.Sources = envelope.Error.Sources
+        }
+        return apiErr
+    }
+    if err := json.Unmarshal(envelope.Data, data); err != nil {
+        return fmt.Errorf("invalid response from oracle: %v", err)
+    }
+    return nil
+}
+
+// newRequest creates an authenticated GET request
+func (c *Client) newRequest(ctx context.Context, path string) (*http.Request, error) {
+    req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+path, nil)
+    if err != nil {
+        return nil, err
+    }
+    if c.apiKey != "" {
+        req.Header.Set("X-API-Key", c.apiKey)
+    }
+    return req, nil
+}
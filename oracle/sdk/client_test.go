@@ -0,0 +1,106 @@
+package sdk
+
+import (
+    "context"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+func TestPriceRetriesAndUnwrapsEnvelope(t *testing.T) {
+    var calls int32
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.Header.Get("X-API-Key") != "key" {
+            t.Errorf("Expected API key header")
+        }
+        switch r.URL.Path {
+        case "/api/v2/feeds/ETHUSDT":
+            if atomic.AddInt32(&calls, 1) == 1 {
+                w.WriteHeader(http.StatusServiceUnavailable)
+                fmt.Fprint(w, `{"meta":{},"error":{"code":"unavailable","message":"no value yet"}}`)
+                return
+            }
+            fmt.Fprint(w, `{"data":{"symbol":"ETHUSDT","price":3000.5,"roundId":7},"meta":{}}`)
+        default:
+            w.WriteHeader(http.StatusNotFound)
+            fmt.Fprint(w, `{"meta":{},"error":{"code":"not_found","message":"unknown feed"}}`)
+        }
+    }))
+    defer srv.Close()
+
+    client := NewClient(srv.URL + "/")
+    client.SetAPIKey("key")
+    result, err := client.Price(context.Background(), "eth/usdt")
+    if err != nil {
+        t.Fatalf("Price failed: %v", err)
+    }
+    if result.Symbol != "ETHUSDT" || result.Price != 3000.5 || result.RoundID != 7 || calls != 2 {
+        t.Errorf("Expected round 7 after one retry, got %+v after %d calls", result, calls)
+    }
+
+    _, err = client.Price(context.Background(), "DOGE/USDT")
+    apiErr, ok := err.(*APIError)
+    if !ok || apiErr.Status != http.StatusNotFound || apiErr.Code != "not_found" {
+        t.Errorf("Expected not_found error without retries, got %v", err)
+    }
+}
+
+func TestSubscribeFiltersAndReconnects(t *testing.T) {
+    var connections int32
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        n := atomic.AddInt32(&connections, 1)
+        w.Header().Set("Content-Type", "text/event-stream")
+        fmt.Fprint(w, "event: alert\ndata: {\"kind\":\"stale\"}\n\n")
+        fmt.Fprint(w, "event: aggregate\ndata: {\"symbol\":\"BTCUSDT\",\"price\":1}\n\n")
+        fmt.Fprintf(w, "event: aggregate\ndata: {\"symbol\":\"ETHUSDT\",\"price\":2,\"roundId\":%d}\n\n", n)
+    }))
+    defer srv.Close()
+
+    ctx, cancel := context.WithCancel(context.Background())
+    sub, err := NewClient(srv.URL).Subscribe(ctx, "ETH/USDT")
+    if err != nil {
+        t.Fatalf("Subscribe failed: %v", err)
+    }
+
+    // Each connection ends after one round; the second arrives after a reconnect
+    for want := uint64(1); want <= 2; want++ {
+        select {
+        case result := <-sub.C:
+            if result.Symbol != "ETHUSDT" || result.RoundID != want {
+                t.Fatalf("Expected ETHUSDT round %d, got %+v", want, result)
+            }
+        case <-time.After(5 * time.Second):
+            t.Fatalf("Timed out waiting for round %d", want)
+        }
+    }
+    if status := sub.Status(); status.Reconnects < 1 || status.Rounds != 2 {
+        t.Errorf("Expected a reconnect and 2 rounds, got %+v", status)
+    }
+
+    cancel()
+    for range sub.C {
+    }
+}
+
+func TestPriceTimesOut(t *testing.T) {
+    release := make(chan struct{})
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        <-release
+    }))
+    defer srv.Close()
+    defer close(release)
+
+    client := NewClient(srv.URL)
+    client.SetRetries(0)
+    client.SetTimeout(50 * time.Millisecond)
+    start := time.Now()
+    if _, err := client.Price(context.Background(), "ETHUSDT"); err == nil {
+        t.Fatal("Expected a request without a response to time out")
+    }
+    if elapsed := time.Since(start); elapsed > 5*time.Second {
+        t.Errorf("Expected the timeout to end the request, took %v", elapsed)
+    }
+}
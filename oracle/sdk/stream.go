@@ -0,0 +1,198 @@
+package sdk
+
+import (
+    "bufio"
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "strings"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/common"
+)
+
+// maxEventBytes bounds a single server-sent event line
+const maxEventBytes = 1 << 20
+
+// idleTimeout drops a connection on which not even the oracle's 15 second
+// keep-alives arrive
+const idleTimeout = 45 * time.Second
+
+// SubscriptionStatus describes a subscription's connection to the oracle
+type SubscriptionStatus struct {
+    Connected  bool
+    Since      time.Time // when the current connection was made
+    Rounds     uint64    // rounds received of the subscribed feeds
+    Dropped    uint64    // rounds dropped while the consumer fell behind
+    Reconnects uint64
+    LastError  string
+}
+
+// Subscription delivers the completed rounds of its feeds as they are
+// published. Rounds are dropped, not queued, while the consumer falls
+// behind its buffer.
+type Subscription struct {
+    // C is closed when the subscription's context is cancelled
+    C <-chan *common.AggregateResult
+
+    ch     chan *common.AggregateResult
+    client *Client
+    feeds  map[string]bool
+
+    mu     sync.Mutex
+    status SubscriptionStatus
+}
+
+// Subscribe streams the completed rounds of the given feeds, or of every
+// feed when none are given, until ctx is cancelled. The connection is
+// re-established with backoff whenever it fails. An error is returned if
+// the first connection is refused, e.g. for an invalid API key.
+func (c *Client) Subscribe(ctx context.Context, feeds ...string) (*Subscription, error) {
+    ch := make(chan *common.AggregateResult, 100)
+    s := &Subscription{C: ch, ch: ch, client: c}
+    if len(feeds) > 0 {
+        s.feeds = make(map[string]bool, len(feeds))
+        for _, feed := range feeds {
+            s.feeds[Symbol(feed)] = true
+        }
+    }
+
+    resp, err := s.connect(ctx)
+    if err != nil {
+        return nil, err
+    }
+    go s.run(ctx, resp)
+    return s, nil
+}
+
+// Status returns the state of the subscription's connection
+func (s *Subscription) Status() SubscriptionStatus {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.status
+}
+
+// run reads the stream, reconnecting with backoff, until ctx is cancelled
+func (s *Subscription) run(ctx context.Context, resp *http.Response) {
+    defer close(s.ch)
+    backoff := minBackoff
+    for {
+        var err error
+        if resp != nil {
+            err = s.read(resp)
+            resp = nil
+        }
+        if ctx.Err() != nil {
+            return
+        }
+
+        s.mu.Lock()
+        wasConnected := s.status.Connected
+        s.status.Connected = false
+        if err != nil {
+            s.status.LastError = err.Error()
+        }
+        s.mu.Unlock()
+        if wasConnected {
+            backoff = minBackoff
+        }
+
+        select {
+        case <-ctx.Done():
+            return
+        case <-time.After(backoff):
+        }
+        if backoff *= 2; backoff > maxBackoff {
+            backoff = maxBackoff
+        }
+
+        s.mu.Lock()
+        s.status.Reconnects++
+        s.mu.Unlock()
+        if resp, err = s.connect(ctx); err != nil {
+            s.mu.Lock()
+            s.status.LastError = err.Error()
+            s.mu.Unlock()
+        }
+    }
+}
+
+// connect opens the event stream
+func (s *Subscription) connect(ctx context.Context) (*http.Response, error) {
+    req, err := s.client.newRequest(ctx, "/api/v1/stream")
+    if err != nil {
+        return nil, err
+    }
+    req.Header.Set("Accept", "text/event-stream")
+    // The stream stays open; idleTimeout detects a stalled connection
+    client := *s.client.http
+    client.Timeout = 0
+    resp, err := client.Do(req)
+    if err != nil {
+        return nil, err
+    }
+    if resp.StatusCode != http.StatusOK {
+        resp.Body.Close()
+        return nil, &APIError{Status: resp.StatusCode, Message: resp.Status}
+    }
+
+    s.mu.Lock()
+    s.status.Connected = true
+    s.status.Since = time.Now()
+    s.status.LastError = ""
+    s.mu.Unlock()
+    return resp, nil
+}
+
+// read delivers the rounds of one connection until it ends
+func (s *Subscription) read(resp *http.Response) error {
+    defer resp.Body.Close()
+    // The request is bound to ctx; a stalled connection is dropped by
+    // closing the body under the scanner
+    idle := time.AfterFunc(idleTimeout, func() { resp.Body.Close() })
+    defer idle.Stop()
+
+    scanner := bufio.NewScanner(resp.Body)
+    scanner.Buffer(make([]byte, 64*1024), maxEventBytes)
+    var eventType, data string
+    for scanner.Scan() {
+        idle.Reset(idleTimeout)
+        line := scanner.Text()
+        switch {
+        case line == "":
+            if eventType == "aggregate" && data != "" {
+                s.deliver(data)
+            }
+            eventType, data = "", ""
+        case strings.HasPrefix(line, "event:"):
+            eventType = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+        case strings.HasPrefix(line, "data:"):
+            data += strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+        }
+    }
+    if err := scanner.Err(); err != nil {
+        return err
+    }
+    return fmt.Errorf("stream closed by oracle")
+}
+
+// deliver passes one round to the consumer if it is subscribed to its feed
+func (s *Subscription) deliver(data string) {
+    var result common.AggregateResult
+    if err := json.Unmarshal([]byte(data), &result); err != nil {
+        return
+    }
+    if s.feeds != nil && !s.feeds[result.Symbol] {
+        return
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.status.Rounds++
+    select {
+    case s.ch <- &result:
+    default:
+        s.status.Dropped++
+    }
+}
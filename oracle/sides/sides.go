@@ -0,0 +1,154 @@
+// Package sides publishes the bid, mid and ask that pairs configured with
+// bidAsk aggregate every round as sub-feeds of their own, such as
+// ETHUSD.bid, so that each can be served, stored, streamed and published
+// like any other feed
+package sides
+
+import (
+    "sort"
+    "strings"
+    "sync"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+)
+
+// Sides of a pair's market, the suffixes of its sub-feeds
+const (
+    Bid = "bid"
+    Mid = "mid"
+    Ask = "ask"
+)
+
+// Symbol returns the sub-feed of a pair for a side
+func Symbol(pair, side string) string {
+    return pair + "." + side
+}
+
+// Split returns the pair and side of a sub-feed symbol
+func Split(symbol string) (pair, side string, ok bool) {
+    i := strings.LastIndex(symbol, ".")
+    if i <= 0 {
+        return "", "", false
+    }
+    switch side = symbol[i+1:]; side {
+    case Bid, Mid, Ask:
+        return symbol[:i], side, true
+    }
+    return "", "", false
+}
+
+// Feeds splits the rounds of pairs aggregating bid and ask into their
+// sub-feeds
+type Feeds struct {
+    bus   *events.Bus
+    sub   *events.Subscription
+    pairs map[string]bool
+
+    mu     sync.RWMutex
+    latest map[string]*common.AggregateResult
+}
+
+// New creates the sub-feeds of the pairs configured with bidAsk
+func New(pairs map[string]*common.PairConfig, bus *events.Bus) *Feeds {
+    f := &Feeds{
+        bus:    bus,
+        pairs:  make(map[string]bool),
+        latest: make(map[string]*common.AggregateResult),
+    }
+    for symbol, pair := range pairs {
+        if pair.BidAsk != nil {
+            f.pairs[symbol] = true
+        }
+    }
+    return f
+}
+
+// Start subscribes the feeds to aggregate events
+func (f *Feeds) Start() {
+    f.sub = f.bus.SubscribeFunc(256, f.handle, events.Aggregate).Named("sides")
+}
+
+// Stop unsubscribes the feeds
+func (f *Feeds) Stop() {
+    if f.sub != nil {
+        f.sub.Close()
+    }
+}
+
+// IsSide reports whether symbol is a bid, mid or ask sub-feed
+func (f *Feeds) IsSide(symbol string) bool {
+    pair, _, ok := Split(symbol)
+    return ok && f.pairs[pair]
+}
+
+// Symbols returns every sub-feed, sorted
+func (f *Feeds) Symbols() []string {
+    symbols := make([]string, 0, 3*len(f.pairs))
+    for pair := range f.pairs {
+        symbols = append(symbols, Symbol(pair, Bid), Symbol(pair, Mid), Symbol(pair, Ask))
+    }
+    sort.Strings(symbols)
+    return symbols
+}
+
+// Latest returns the most recent value of a sub-feed
+func (f *Feeds) Latest(symbol string) (*common.AggregateResult, bool) {
+    f.mu.RLock()
+    defer f.mu.RUnlock()
+    result, ok := f.latest[symbol]
+    return result, ok
+}
+
+// handle publishes the sides of a pair's round as rounds of its sub-feeds
+func (f *Feeds) handle(event events.Event) {
+    result, ok := event.Payload.(*common.AggregateResult)
+    pair := strings.ReplaceAll(event.Symbol, "/", "")
+    if !ok || !f.pairs[pair] || result.Bid <= 0 {
+        return
+    }
+    for _, side := range []string{Bid, Mid, Ask} {
+        sub := split(result, side)
+        sub.Symbol = Symbol(pair, side)
+        f.mu.Lock()
+        f.latest[sub.Symbol] = sub
+        f.mu.Unlock()
+        f.bus.Publish(events.Event{
+            Type:    events.Aggregate,
+            Symbol:  sub.Symbol,
+            Payload: sub,
+        })
+    }
+}
+
+// split returns one side of a round as a round of its own, sharing the
+// round's ID so the sides of a round can be matched; its sources are the
+// side quoted by each source quoting both
+func split(result *common.AggregateResult, side string) *common.AggregateResult {
+    sub := &common.AggregateResult{
+        PricePoint:     common.PricePoint{Timestamp: result.Timestamp},
+        RoundID:        result.RoundID,
+        ConfigVersion:  result.ConfigVersion,
+        FallbackReason: result.FallbackReason,
+    }
+    sub.Price = pick(result.Bid, result.Mid, result.Ask, side)
+    for _, source := range result.Sources {
+        if source.Bid <= 0 || source.Ask < source.Bid {
+            continue
+        }
+        point := source
+        point.Price = pick(source.Bid, (source.Bid+source.Ask)/2, source.Ask, side)
+        sub.Sources = append(sub.Sources, point)
+    }
+    return sub
+}
+
+func pick(bid, mid, ask float64, side string) float64 {
+    switch side {
+    case Bid:
+        return bid
+    case Ask:
+        return ask
+    }
+    return mid
+}
@@ -0,0 +1,73 @@
+package sides
+
+import (
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+)
+
+func TestSplit(t *testing.T) {
+    if pair, side, ok := Split("ETHUSD.bid"); !ok || pair != "ETHUSD" || side != Bid {
+        t.Errorf("Expected ETHUSD bid, got %q %q %v", pair, side, ok)
+    }
+    for _, symbol := range []string{"ETHUSD", "ETHUSD.last", ".ask"} {
+        if _, _, ok := Split(symbol); ok {
+            t.Errorf("Expected %q not to be a sub-feed", symbol)
+        }
+    }
+}
+
+func TestFeedsPublishSides(t *testing.T) {
+    bus := events.NewBus()
+    feeds := New(map[string]*common.PairConfig{
+        "ETHUSD": {BidAsk: &common.BidAskConfig{}},
+        "BTCUSD": {},
+    }, bus)
+    feeds.Start()
+    defer feeds.Stop()
+    sub := bus.Subscribe(16, events.Aggregate)
+    defer sub.Close()
+
+    if !feeds.IsSide("ETHUSD.ask") || feeds.IsSide("BTCUSD.ask") {
+        t.Error("Expected only pairs with bidAsk to have sub-feeds")
+    }
+    if symbols := feeds.Symbols(); len(symbols) != 3 || symbols[0] != "ETHUSD.ask" {
+        t.Errorf("Expected the three sub-feeds of ETHUSD, got %v", symbols)
+    }
+
+    round := &common.AggregateResult{
+        Symbol:     "ETHUSD",
+        PricePoint: common.PricePoint{Price: 3000.5, Timestamp: time.Now(), Bid: 3000, Ask: 3001},
+        Mid:        3000.5,
+        RoundID:    7,
+        Sources: []common.SourcePrice{
+            {Source: "binance", PricePoint: common.PricePoint{Price: 3000.4, Bid: 3000, Ask: 3001}},
+            {Source: "coinbase", PricePoint: common.PricePoint{Price: 3000.6}},
+        },
+    }
+    bus.Publish(events.Event{Type: events.Aggregate, Symbol: "ETHUSD", Payload: round})
+
+    got := make(map[string]*common.AggregateResult)
+    timeout := time.After(time.Second)
+    for len(got) < 3 {
+        select {
+        case event := <-sub.C:
+            if event.Symbol != "ETHUSD" {
+                got[event.Symbol] = event.Payload.(*common.AggregateResult)
+            }
+        case <-timeout:
+            t.Fatalf("Expected three sub-feed rounds, got %v", got)
+        }
+    }
+    if bid := got["ETHUSD.bid"]; bid.Price != 3000 || bid.RoundID != 7 || len(bid.Sources) != 1 || bid.Sources[0].Price != 3000 {
+        t.Errorf("Expected the bid from binance alone, got %+v", bid)
+    }
+    if ask, ok := feeds.Latest("ETHUSD.ask"); !ok || ask.Price != 3001 {
+        t.Errorf("Expected the latest ask, got %+v", ask)
+    }
+    if mid := got["ETHUSD.mid"]; mid.Price != 3000.5 || mid.Sources[0].Price != 3000.5 {
+        t.Errorf("Expected the mid, got %+v", mid)
+    }
+}
@@ -1,81 +1,605 @@
 package crypto
 
 import (
-    "encoding/json"
+    "context"
     "fmt"
-    "io/ioutil"
     "log"
+    "math"
     "net/http"
     "sort"
+    "strings"
+    "sync"
     "time"
     "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/drill"
+    "yetaXYZ/oracle/events"
+    "yetaXYZ/oracle/evm"
+    "yetaXYZ/oracle/fetch"
+    "yetaXYZ/oracle/workers"
 )
 
 // CryptoAggregator handles cryptocurrency price aggregation
 type CryptoAggregator struct {
     config *common.BaseConfig
     client *http.Client
+    bus    *events.Bus
+
+    roundsMu sync.Mutex
+    rounds   map[string]uint64
+
+    readersMu sync.Mutex
+    readers   map[string]*evm.PoolReader
+
+    // maintenance is nil unless exchange status monitoring is enabled
+    maintenance *MaintenanceMonitor
+
+    // books is nil unless exchange order books are streamed
+    books *BookStreams
+
+    // feeds provides the prices of quote member feeds
+    feeds FeedLookup
+
+    // volumes converts reported volumes to a common basis
+    volumes *volumeNormalizer
+
+    // latency tracks exchanges against their latency SLOs
+    latency *latencyTracker
+
+    // drills may force sources open for operational drills
+    drills *drill.Drills
+
+    // workers is nil unless fetches are handed to worker processes
+    workers workers.Queue
+
+    // freezes holds pairs frozen on divergence until acknowledged
+    freezes *freezer
 }
 
 // NewCryptoAggregator creates a new CryptoAggregator
 func NewCryptoAggregator(config *common.BaseConfig) *CryptoAggregator {
     return &CryptoAggregator{
         config: config,
-        client: &http.Client{
-            Timeout: 10 * time.Second,
-        },
+        client: fetch.NewClient(10 * time.Second),
+        rounds:  make(map[string]uint64),
+        readers: make(map[string]*evm.PoolReader),
+        volumes: newVolumeNormalizer(),
+        latency: newLatencyTracker(),
+        freezes: newFreezer(),
     }
 }
 
+// SetEventBus sets the bus that fetch results and aggregates are published on
+func (a *CryptoAggregator) SetEventBus(bus *events.Bus) {
+    a.bus = bus
+}
+
+// SetMaintenance sets the monitor used to skip exchanges under maintenance
+func (a *CryptoAggregator) SetMaintenance(m *MaintenanceMonitor) {
+    a.maintenance = m
+}
+
+// SetDrills sets the drills that may force sources open
+func (a *CryptoAggregator) SetDrills(d *drill.Drills) {
+    a.drills = d
+}
+
+// SetBooks sets the streamed order books that price exchanges with an
+// orderBook config
+func (a *CryptoAggregator) SetBooks(b *BookStreams) {
+    a.books = b
+}
+
 // FetchPrice fetches the price for a given trading pair
 func (a *CryptoAggregator) FetchPrice(symbol string) (*common.PricePoint, error) {
+    result, err := a.Aggregate(symbol)
+    if err != nil {
+        return nil, err
+    }
+    return &result.PricePoint, nil
+}
+
+// Aggregate runs an aggregation round for a trading pair and returns the
+// aggregate together with the individual source prices that produced it
+func (a *CryptoAggregator) Aggregate(symbol string) (*common.AggregateResult, error) {
+    // Pin the config snapshot for the whole round
+    snapshot, err := CurrentConfig()
+    if err != nil {
+        return nil, err
+    }
+
     // Get pair configuration
-    pairConfig, err := GetPairConfig(symbol)
+    pairConfig, err := snapshot.PairConfig(symbol)
     if err != nil {
         return nil, fmt.Errorf("failed to get pair config: %v", err)
     }
 
-    prices := make([]*common.PricePoint, 0)
+    // Sources still outstanding when the latency budget runs out are
+    // abandoned once the others reach quorum; the budget starts after the
+    // sampling window
+    var deadline time.Time
+    if budget := pairConfig.LatencyBudget(); budget > 0 {
+        deadline = time.Now().Add(pairConfig.Aggregation.SamplingWindow() + budget)
+    }
+
+    // Fetch the primary tier, then fallback tiers in order while the
+    // primaries fall short of the minimum or disagree beyond the guard.
+    // Primary exchanges demoted for missing their latency SLO come first
+    // among the fallbacks.
+    primary, demoted := a.deprioritize(snapshot.Base, pairConfig.Sources)
+    fallbacks := make([]common.SourcesConfig, 0, len(pairConfig.FallbackTiers)+1)
+    labels := make([]string, 0, len(pairConfig.FallbackTiers)+1)
+    if demoted.CEX.Enabled {
+        fallbacks = append(fallbacks, demoted)
+        labels = append(labels, tierDeprioritized)
+    }
+    for i, tier := range pairConfig.FallbackTiers {
+        fallbacks = append(fallbacks, tier)
+        labels = append(labels, tierLabel(i+1))
+    }
+
+    prices, sources, abandoned, failures := a.fetchTier(deadline, snapshot.Base, symbol, pairConfig, primary, "", 0)
+    fallbackReason := ""
+    reason := needsFallback(pairConfig, prices)
+    for i, tier := range fallbacks {
+        if reason == "" {
+            break
+        }
+        if fallbackReason == "" {
+            fallbackReason = reason
+        }
+        log.Printf("Fetching %s tier for %s: %s", labels[i], symbol, reason)
+
+        tierPrices, tierSources, tierAbandoned, tierFailures := a.fetchTier(deadline, snapshot.Base, symbol, pairConfig, tier, labels[i], len(prices))
+        prices = append(prices, tierPrices...)
+        sources = append(sources, tierSources...)
+        abandoned = append(abandoned, tierAbandoned...)
+        failures = append(failures, tierFailures...)
+        reason = needsFallback(pairConfig, prices)
+    }
+
+    if len(prices) < pairConfig.MinimumSources {
+        return nil, &InsufficientSourcesError{Symbol: symbol, Got: len(prices), Need: pairConfig.MinimumSources, Failures: failures}
+    }
+
+    // Calculate the weighted median price
+    weights := sourceWeights(pairConfig, sources)
+
+    // Drop prices still beyond the deviation guard after the fallback
+    // tiers, then outliers beyond the weighted IQR fences, never below
+    // MinimumSources
+    kept, rejected := rejectSources(pairConfig, prices, weights)
+    keptPrices := make([]*common.PricePoint, 0, len(kept))
+    keptWeights := make([]float64, 0, len(kept))
+    keptSources := make([]common.SourcePrice, 0, len(kept))
+    for _, i := range kept {
+        keptPrices = append(keptPrices, prices[i])
+        keptWeights = append(keptWeights, weights[i])
+        keptSources = append(keptSources, sources[i])
+    }
+    var rejectedSources []common.SourcePrice
+    for _, i := range rejected {
+        rejectedSources = append(rejectedSources, sources[i])
+    }
+
+    medianPoint := a.calculateMedian(keptPrices, keptWeights)
+    if medianPoint == nil {
+        return nil, fmt.Errorf("no prices available for %s", symbol)
+    }
+
+    // Apply the pair's output transform, keeping the aggregated price
+    rawPrice := 0.0
+    if pairConfig.Transform != "" {
+        price, err := a.transform(pairConfig, medianPoint.Price)
+        if err != nil {
+            return nil, fmt.Errorf("failed to transform %s: %v", symbol, err)
+        }
+        rawPrice = medianPoint.Price
+        medianPoint.Price = price
+    }
+
+    // Clamp range feeds to their bounds, keeping the unclamped price
+    var clamped *common.Clamped
+    if price, bound := pairConfig.Bounds.Clamp(medianPoint.Price); bound != "" {
+        clamped = &common.Clamped{Bound: bound, Price: medianPoint.Price}
+        if rawPrice == 0 {
+            rawPrice = medianPoint.Price
+        }
+        medianPoint.Price = price
+    }
+
+    // A round tripping the pair's hard thresholds freezes it at its last
+    // good round; frozen pairs serve that round until acknowledged
+    if fr, tripped := a.freezes.check(symbol, pairConfig.Freeze, medianPoint.Price, maxDeviation(keptPrices), time.Now()); fr != nil {
+        if tripped {
+            a.freezeAlert(fr)
+        }
+        return frozenRound(fr)
+    }
+
+    result := &common.AggregateResult{
+        Symbol:         symbol,
+        PricePoint:     *medianPoint,
+        Sources:        keptSources,
+        Rejected:       rejectedSources,
+        RoundID:        a.nextRound(symbol),
+        ConfigVersion:  snapshot.Version,
+        FallbackReason: fallbackReason,
+        RawPrice:       rawPrice,
+        Clamped:        clamped,
+    }
+    if len(abandoned) > 0 {
+        result.Abandoned = abandoned
+    }
+    if pairConfig.BidAsk != nil {
+        a.aggregateSides(pairConfig, result, keptWeights)
+    }
+    a.freezes.record(pairConfig.Freeze, result)
+
+    a.bus.Publish(events.Event{
+        Type:    events.Aggregate,
+        Symbol:  symbol,
+        Payload: result,
+    })
+
+    return result, nil
+}
+
+// Reasons for consulting fallback source tiers
+const (
+    fallbackInsufficient = "insufficient primary sources"
+    fallbackDeviation    = "source deviation guard tripped"
+)
+
+// needsFallback reports why the collected prices are not yet sufficient,
+// or an empty string when no fallback is needed
+func needsFallback(pair *common.PairConfig, prices []*common.PricePoint) string {
+    if len(prices) < pair.MinimumSources {
+        return fallbackInsufficient
+    }
+    if pair.MaxSourceDeviation > 0 && maxDeviation(prices) > pair.MaxSourceDeviation {
+        return fallbackDeviation
+    }
+    return ""
+}
+
+// maxDeviation returns the largest relative distance of any price from the median
+func maxDeviation(prices []*common.PricePoint) float64 {
+    values := make([]float64, 0, len(prices))
+    for _, p := range prices {
+        values = append(values, p.Price)
+    }
+    if len(values) == 0 {
+        return 0
+    }
+
+    mid := median(values)
+    if mid == 0 {
+        return 0
+    }
+    worst := 0.0
+    for _, v := range values {
+        if d := abs(v-mid) / mid; d > worst {
+            worst = d
+        }
+    }
+    return worst
+}
+
+// fetchTier fetches every enabled source of a tier concurrently and returns
+// the prices that were obtained, attributed to their sources, in config
+// order. Once the round's deadline has passed, sources still outstanding are
+// abandoned as soon as have plus the prices obtained meet the pair's
+// minimum; without quorum the tier keeps waiting for them. A zero deadline
+// waits for every source.
+func (a *CryptoAggregator) fetchTier(deadline time.Time, base *common.BaseConfig, symbol string, pairConfig *common.PairConfig, tier common.SourcesConfig, tierName string, have int) ([]*common.PricePoint, []common.SourcePrice, []string, []common.SourceFailure) {
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+
+    jobs, failures := a.sourceJobs(base, symbol, pairConfig, tier, tierName)
+
+    // With a sampling window each source is read at randomized offsets
+    // within it rather than all at once
+    window, samples := pairConfig.Aggregation.SamplingWindow(), pairConfig.Aggregation.Samples()
+    start := time.Now()
+    done := make(chan int, len(jobs))
+    for i := range jobs {
+        go func(i int) {
+            job := &jobs[i]
+            if window > 0 {
+                job.price, job.err = job.sample(ctx, start, window, samples)
+            } else {
+                job.price, job.err = job.fetch(ctx)
+            }
+            job.latency = time.Since(start) - job.waited
+            done <- i
+        }(i)
+    }
+
+    var expired <-chan time.Time
+    if !deadline.IsZero() {
+        timer := time.NewTimer(time.Until(deadline))
+        defer timer.Stop()
+        expired = timer.C
+    }
+
+    finished := make([]bool, len(jobs))
+    pending, obtained := len(jobs), 0
+    pastDeadline := false
+    for pending > 0 && !(pastDeadline && have+obtained >= pairConfig.MinimumSources) {
+        select {
+        case i := <-done:
+            finished[i] = true
+            pending--
+            if jobs[i].err == nil && jobs[i].price != nil {
+                obtained++
+            }
+        case <-expired:
+            pastDeadline = true
+            expired = nil
+        }
+    }
+
+    prices := make([]*common.PricePoint, 0, obtained)
+    sources := make([]common.SourcePrice, 0, obtained)
+    abandoned := make([]string, 0)
+    for i := range jobs {
+        job := &jobs[i]
+        if !finished[i] {
+            // The fetch goroutine sees the cancelled context and exits
+            abandoned = append(abandoned, job.source.Source)
+            err := &LatencyBudgetError{Source: job.source.Source, Budget: pairConfig.LatencyBudget()}
+            a.publishFetch(symbol, job.source.Source, nil, err, time.Since(start))
+            a.observeLatency(base, job.source.Source, time.Since(start))
+            failures = append(failures, common.SourceFailure{Source: job.source.Source, Tier: tierName, Reason: err.Error()})
+            continue
+        }
+
+        a.publishFetch(symbol, job.source.Source, job.price, job.err, job.latency)
+        a.observeLatency(base, job.source.Source, job.latency)
+        if job.err != nil {
+            log.Printf("Error fetching price from %s for %s: %v", job.source.Source, symbol, job.err)
+            failures = append(failures, common.SourceFailure{Source: job.source.Source, Tier: tierName, Reason: job.err.Error()})
+            continue
+        }
+        if job.price == nil {
+            failures = append(failures, common.SourceFailure{Source: job.source.Source, Tier: tierName, Reason: "no price returned"})
+            continue
+        }
+        job.price.Price *= job.scale
+        job.price.Bid *= job.scale
+        job.price.Ask *= job.scale
+        source := job.source
+        source.PricePoint = *job.price
+        prices = append(prices, job.price)
+        sources = append(sources, source)
+    }
+    if len(abandoned) > 0 {
+        log.Printf("Abandoned %v for %s after the %s latency budget", abandoned, symbol, pairConfig.LatencyBudget())
+    }
+
+    return prices, sources, abandoned, failures
+}
+
+// sourceJobs returns the fetches of a tier's sources, skipping exchanges
+// under maintenance and those whose quote conversion is unavailable, and
+// why each skipped source was skipped
+func (a *CryptoAggregator) sourceJobs(base *common.BaseConfig, symbol string, pairConfig *common.PairConfig, tier common.SourcesConfig, tierName string) ([]sourceFetch, []common.SourceFailure) {
+    jobs := make([]sourceFetch, 0)
+    skipped := make([]common.SourceFailure, 0)
 
     // Fetch from enabled CEX sources
-    if pairConfig.Sources.CEX.Enabled {
-        for _, exchange := range pairConfig.Sources.CEX.Exchanges {
-            var price *common.PricePoint
-            var err error
-
-            switch exchange {
-            case "binance":
-                price, err = a.fetchBinancePrice(symbol)
-            case "coinbase":
-                price, err = a.fetchCoinbasePrice(pairConfig.BaseCurrency + "-" + pairConfig.QuoteCurrency)
-            case "kraken":
-                price, err = a.fetchKrakenPrice(symbol)
+    if tier.CEX.Enabled {
+        for _, exchange := range tier.CEX.Exchanges {
+            // Skip announced maintenance rather than counting failures
+            if window, ok := a.maintenance.Active(exchange, time.Now()); ok {
+                reason := "under maintenance"
+                if window.Description != "" {
+                    reason += ": " + window.Description
+                }
+                skipped = append(skipped, common.SourceFailure{Source: exchange, Tier: tierName, Reason: reason})
+                continue
             }
 
+            // Convert sources quoted in another member of the quote class,
+            // leaving them out while the conversion is unavailable
+            quote, venueSymbol := quoteAsset(base, symbol, pairConfig, exchange)
+            source, factor, err := a.quotedSource(base, pairConfig, exchange, tierName, quote)
             if err != nil {
-                log.Printf("Error fetching price from %s for %s: %v", exchange, symbol, err)
+                log.Printf("Skipping %s for %s: %v", exchange, symbol, err)
+                skipped = append(skipped, common.SourceFailure{Source: exchange, Tier: tierName, Reason: err.Error()})
                 continue
             }
 
-            if price != nil {
-                price.Price *= pairConfig.Sources.CEX.Weight
-                prices = append(prices, price)
+            exchange := exchange
+            baseURL := ExchangeURL(base, exchange)
+            skew := clockSkew(base, exchange)
+            volume := volumeSemantics(base, exchange)
+            // An exchange chronically slower than its SLO gets less time
+            var timeout time.Duration
+            if slo := a.latencyDemotion(base, exchange); slo != nil && slo.SlowAction() == common.SLOShortenTimeout {
+                timeout = slo.Timeout()
             }
+            jobs = append(jobs, sourceFetch{
+                source: source,
+                scale:  factor,
+                fetch: func(ctx context.Context) (*common.PricePoint, error) {
+                    if timeout > 0 {
+                        var cancel context.CancelFunc
+                        ctx, cancel = context.WithTimeout(ctx, timeout)
+                        defer cancel()
+                    }
+                    var price *common.PricePoint
+                    var err error
+                    // A synced, fresh order book takes precedence over REST
+                    if book, ok := a.books.Price(exchange, venueSymbol, time.Now()); ok {
+                        price = book
+                    } else {
+                        job := workers.Job{Source: exchange, Exchange: exchange, Symbol: venueSymbol, BaseURL: baseURL}
+                        price, err = a.dispatch(ctx, job, func(ctx context.Context) (*common.PricePoint, error) {
+                            return a.fetchExchange(ctx, exchange, baseURL, venueSymbol)
+                        })
+                    }
+                    if err != nil || price == nil {
+                        return price, err
+                    }
+                    price.Volume = a.volumes.normalize(exchange+"/"+venueSymbol, volume, price.Volume, price.Price, time.Now())
+                    if price.Timestamp, err = sourceTimestamp(exchange, skew, price.Timestamp, time.Now()); err != nil {
+                        return nil, err
+                    }
+                    return price, nil
+                },
+            })
         }
     }
 
-    if len(prices) < pairConfig.MinimumSources {
-        return nil, fmt.Errorf("insufficient price sources for %s: got %d, need %d", symbol, len(prices), pairConfig.MinimumSources)
+    // Fetch from configured DEX pools via on-chain reads
+    if tier.DEX.Enabled {
+        // Pools trading the base against another member of the quote
+        // class are converted like CEX sources
+        for _, pool := range tier.DEX.Pools {
+            pool := pool
+            source, factor, err := a.quotedSource(base, pairConfig, PoolSourceName(pool), tierName, pool.Quote)
+            if err != nil {
+                log.Printf("Skipping %s for %s: %v", source.Source, symbol, err)
+                skipped = append(skipped, common.SourceFailure{Source: source.Source, Tier: tierName, Reason: err.Error()})
+                continue
+            }
+            jobs = append(jobs, sourceFetch{
+                source: source,
+                scale:  factor,
+                fetch: func(ctx context.Context) (*common.PricePoint, error) {
+                    job := workers.Job{Source: PoolSourceName(pool), Base: pairConfig.BaseCurrency, Quote: pairConfig.QuoteCurrency, Pool: &pool}
+                    return a.dispatch(ctx, job, func(ctx context.Context) (*common.PricePoint, error) {
+                        return a.fetchPoolPrice(ctx, pairConfig, pool)
+                    })
+                },
+            })
+        }
+        for _, subgraph := range tier.DEX.Subgraphs {
+            subgraph := subgraph
+            source, factor, err := a.quotedSource(base, pairConfig, subgraphSourceName(subgraph), tierName, subgraph.Quote)
+            if err != nil {
+                log.Printf("Skipping %s for %s: %v", source.Source, symbol, err)
+                skipped = append(skipped, common.SourceFailure{Source: source.Source, Tier: tierName, Reason: err.Error()})
+                continue
+            }
+            jobs = append(jobs, sourceFetch{
+                source: source,
+                scale:  factor,
+                fetch: func(ctx context.Context) (*common.PricePoint, error) {
+                    job := workers.Job{Source: subgraphSourceName(subgraph), Base: pairConfig.BaseCurrency, Quote: pairConfig.QuoteCurrency, Subgraph: &subgraph}
+                    return a.dispatch(ctx, job, func(ctx context.Context) (*common.PricePoint, error) {
+                        return a.fetchSubgraphPrice(ctx, pairConfig, subgraph)
+                    })
+                },
+            })
+        }
+    }
+
+    // A drill forcing a source open fails its fetch without sending it, so
+    // that the failure reaches fallbacks and alerting like a real outage
+    for i := range jobs {
+        if source := jobs[i].source.Source; a.drills.SourceOpen(source, time.Now()) {
+            jobs[i].fetch = func(ctx context.Context) (*common.PricePoint, error) {
+                return nil, &drill.OpenError{Source: source}
+            }
+        }
+    }
+
+    return jobs, skipped
+}
+
+// sourceFetch is the fetch of one source within a tier
+type sourceFetch struct {
+    source common.SourcePrice // attribution, without the price
+    scale  float64            // applied to the fetched price
+    fetch  func(ctx context.Context) (*common.PricePoint, error)
+
+    price   *common.PricePoint
+    err     error
+    latency time.Duration
+    waited  time.Duration // spent waiting for sampling offsets
+}
+
+// LatencyBudgetError is published for sources abandoned because they did
+// not respond within the round's latency budget
+type LatencyBudgetError struct {
+    Source string
+    Budget time.Duration
+}
+
+func (e *LatencyBudgetError) Error() string {
+    return fmt.Sprintf("%s abandoned after the %s latency budget", e.Source, e.Budget)
+}
+
+// publishFetch publishes the outcome of a single source fetch
+func (a *CryptoAggregator) publishFetch(symbol, source string, price *common.PricePoint, err error, latency time.Duration) {
+    a.bus.Publish(events.Event{
+        Type:   events.FetchResult,
+        Symbol: symbol,
+        Payload: &events.FetchResultPayload{
+            Source:  source,
+            Price:   price,
+            Err:     err,
+            Latency: latency,
+        },
+    })
+}
+
+// ResumeRounds continues round numbering after a restart so that round IDs
+// already recorded elsewhere are never reused
+func (a *CryptoAggregator) ResumeRounds(last map[string]uint64) {
+    a.roundsMu.Lock()
+    defer a.roundsMu.Unlock()
+    for symbol, round := range last {
+        if round > a.rounds[symbol] {
+            a.rounds[symbol] = round
+        }
+    }
+}
+
+// Rounds returns the last round ID issued for each trading pair
+func (a *CryptoAggregator) Rounds() map[string]uint64 {
+    a.roundsMu.Lock()
+    defer a.roundsMu.Unlock()
+    rounds := make(map[string]uint64, len(a.rounds))
+    for symbol, round := range a.rounds {
+        rounds[symbol] = round
     }
+    return rounds
+}
 
-    // Calculate median price
-    return a.calculateMedian(prices), nil
+// nextRound returns the next round ID for a trading pair
+func (a *CryptoAggregator) nextRound(symbol string) uint64 {
+    a.roundsMu.Lock()
+    defer a.roundsMu.Unlock()
+    a.rounds[symbol]++
+    return a.rounds[symbol]
+}
+
+// defaultExchangeURLs are the API roots of exchanges whose baseURL is not
+// configured
+var defaultExchangeURLs = map[string]string{
+    "binance":  "https://api.binance.com/api/v3",
+    "coinbase": "https://api.coinbase.com/api/v3/brokerage",
+    "kraken":   "https://api.kraken.com/0/public",
+}
+
+// ExchangeURL returns the API root of an exchange, preferring its
+// configured baseURL so fetches can be pointed at a proxy or a fake
+func ExchangeURL(base *common.BaseConfig, exchange string) string {
+    if base != nil {
+        if details, ok := base.Exchanges.CEX[exchange]; ok && details.BaseURL != "" {
+            return strings.TrimRight(details.BaseURL, "/")
+        }
+    }
+    return defaultExchangeURLs[exchange]
 }
 
 // fetchBinancePrice fetches price from Binance
-func (a *CryptoAggregator) fetchBinancePrice(symbol string) (*common.PricePoint, error) {
-    url := fmt.Sprintf("https://api.binance.com/api/v3/ticker/24hr?symbol=%s", symbol)
-    resp, err := a.client.Get(url)
+func (a *CryptoAggregator) fetchBinancePrice(ctx context.Context, baseURL, symbol string) (*common.PricePoint, error) {
+    url := fmt.Sprintf("%s/ticker/24hr?symbol=%s", baseURL, symbol)
+    resp, err := a.get(fetch.WithSchema(ctx, "binance", "ticker"), url)
     if err != nil {
         return nil, err
     }
@@ -84,14 +608,12 @@ func (a *CryptoAggregator) fetchBinancePrice(symbol string) (*common.PricePoint,
     var data struct {
         LastPrice string `json:"lastPrice"`
         Volume    string `json:"volume"`
+        BidPrice  string `json:"bidPrice"`
+        AskPrice  string `json:"askPrice"`
+        CloseTime int64  `json:"closeTime"` // end of the ticker's window, exchange clock
     }
 
-    body, err := ioutil.ReadAll(resp.Body)
-    if err != nil {
-        return nil, err
-    }
-
-    if err := json.Unmarshal(body, &data); err != nil {
+    if err := fetch.DecodeJSON(resp, &data); err != nil {
         return nil, err
     }
 
@@ -105,48 +627,64 @@ func (a *CryptoAggregator) fetchBinancePrice(symbol string) (*common.PricePoint,
         return nil, err
     }
 
-    return &common.PricePoint{
+    timestamp := time.Now()
+    if data.CloseTime > 0 {
+        timestamp = time.UnixMilli(data.CloseTime)
+    }
+
+    point := &common.PricePoint{
         Price:     price,
         Volume:    volume,
-        Timestamp: time.Now(),
-    }, nil
+        Timestamp: timestamp,
+    }
+    point.Bid, point.Ask = parseTouch(data.BidPrice, data.AskPrice)
+    return point, nil
 }
 
-// fetchCoinbasePrice fetches price from Coinbase
-func (a *CryptoAggregator) fetchCoinbasePrice(symbol string) (*common.PricePoint, error) {
-    url := fmt.Sprintf("https://api.coinbase.com/v2/prices/%s/spot", symbol)
-    resp, err := a.client.Get(url)
+// fetchCoinbasePrice fetches price and 24h volume from the public
+// Advanced Trade product endpoint
+func (a *CryptoAggregator) fetchCoinbasePrice(ctx context.Context, baseURL, product string) (*common.PricePoint, error) {
+    url := fmt.Sprintf("%s/market/products/%s", baseURL, product)
+    resp, err := a.get(fetch.WithSchema(ctx, "coinbase", "product"), url)
     if err != nil {
         return nil, err
     }
     defer resp.Body.Close()
 
     var data struct {
-        Data struct {
-            Amount string `json:"amount"`
-        } `json:"data"`
+        Price           string `json:"price"`
+        Volume24h       string `json:"volume_24h"` // base asset
+        TradingDisabled bool   `json:"trading_disabled"`
+    }
+
+    if err := fetch.DecodeJSON(resp, &data); err != nil {
+        return nil, err
+    }
+    if data.TradingDisabled {
+        return nil, fmt.Errorf("trading disabled on Coinbase for %s", product)
     }
 
-    if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+    price, err := parseFloat(data.Price)
+    if err != nil {
         return nil, err
     }
 
-    price, err := parseFloat(data.Data.Amount)
+    volume, err := parseFloat(data.Volume24h)
     if err != nil {
         return nil, err
     }
 
     return &common.PricePoint{
         Price:     price,
-        Volume:    0, // Coinbase spot API doesn't provide volume
+        Volume:    volume,
         Timestamp: time.Now(),
     }, nil
 }
 
 // fetchKrakenPrice fetches price from Kraken
-func (a *CryptoAggregator) fetchKrakenPrice(symbol string) (*common.PricePoint, error) {
-    url := fmt.Sprintf("https://api.kraken.com/0/public/Ticker?pair=%s", symbol)
-    resp, err := a.client.Get(url)
+func (a *CryptoAggregator) fetchKrakenPrice(ctx context.Context, baseURL, symbol string) (*common.PricePoint, error) {
+    url := fmt.Sprintf("%s/Ticker?pair=%s", baseURL, symbol)
+    resp, err := a.get(fetch.WithSchema(ctx, "kraken", "ticker", "result"), url)
     if err != nil {
         return nil, err
     }
@@ -156,10 +694,12 @@ func (a *CryptoAggregator) fetchKrakenPrice(symbol string) (*common.PricePoint,
         Result map[string]struct {
             LastTrade []string `json:"c"`
             Volume    []string `json:"v"`
+            Ask       []string `json:"a"`
+            Bid       []string `json:"b"`
         } `json:"result"`
     }
 
-    if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+    if err := fetch.DecodeJSON(resp, &data); err != nil {
         return nil, err
     }
 
@@ -167,19 +707,16 @@ func (a *CryptoAggregator) fetchKrakenPrice(symbol string) (*common.PricePoint,
     var result struct {
         LastTrade []string
         Volume    []string
+        Ask       []string
+        Bid       []string
     }
     for _, v := range data.Result {
-        result = struct {
-            LastTrade []string
-            Volume    []string
-        }{
-            LastTrade: v.LastTrade,
-            Volume:    v.Volume,
-        }
+        result.LastTrade, result.Volume = v.LastTrade, v.Volume
+        result.Ask, result.Bid = v.Ask, v.Bid
         break
     }
 
-    if len(result.LastTrade) < 1 || len(result.Volume) < 1 {
+    if len(result.LastTrade) < 1 || len(result.Volume) < 2 {
         return nil, fmt.Errorf("invalid response from Kraken")
     }
 
@@ -188,35 +725,83 @@ func (a *CryptoAggregator) fetchKrakenPrice(symbol string) (*common.PricePoint,
         return nil, err
     }
 
-    volume, err := parseFloat(result.Volume[0])
+    // v holds today's volume, since UTC midnight, then the last 24 hours'
+    volume, err := parseFloat(result.Volume[1])
     if err != nil {
         return nil, err
     }
 
-    return &common.PricePoint{
+    point := &common.PricePoint{
         Price:     price,
         Volume:    volume,
         Timestamp: time.Now(),
-    }, nil
+    }
+    // a and b lead with the price of the best ask and bid
+    if len(result.Bid) > 0 && len(result.Ask) > 0 {
+        point.Bid, point.Ask = parseTouch(result.Bid[0], result.Ask[0])
+    }
+    return point, nil
+}
+
+// get performs a GET request that is abandoned when ctx is cancelled
+func (a *CryptoAggregator) get(ctx context.Context, url string) (*http.Response, error) {
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+    if err != nil {
+        return nil, err
+    }
+    return a.client.Do(req)
+}
+
+// WeightedMedian returns the weighted median price of source prices under
+// the pair's weights, as a round with these sources would aggregate them
+func WeightedMedian(pair *common.PairConfig, sources []common.SourcePrice) float64 {
+    prices := make([]*common.PricePoint, len(sources))
+    for i := range sources {
+        prices[i] = &sources[i].PricePoint
+    }
+    if point := weightedMedian(prices, sourceWeights(pair, sources)); point != nil {
+        return point.Price
+    }
+    return 0
+}
+
+// calculateMedian calculates the weighted median price from multiple
+// sources; weights[i] belongs to prices[i]
+func (a *CryptoAggregator) calculateMedian(prices []*common.PricePoint, weights []float64) *common.PricePoint {
+    return weightedMedian(prices, weights)
 }
 
-// calculateMedian calculates the median price from multiple sources
-func (a *CryptoAggregator) calculateMedian(prices []*common.PricePoint) *common.PricePoint {
+// weightedMedian returns the first price by ascending order whose
+// cumulative weight exceeds half of the total, with the total volume. With
+// equal weights this is the upper median.
+func weightedMedian(prices []*common.PricePoint, weights []float64) *common.PricePoint {
     if len(prices) == 0 {
         return nil
     }
 
-    // Sort prices
-    sort.Slice(prices, func(i, j int) bool {
-        return prices[i].Price < prices[j].Price
-    })
-
-    // Calculate median price and total volume
-    medianIdx := len(prices) / 2
+    // Sort prices together with their weights
+    order := make([]int, len(prices))
+    totalWeight := 0.0
     totalVolume := 0.0
-    for _, p := range prices {
+    for i, p := range prices {
+        order[i] = i
+        totalWeight += weights[i]
         totalVolume += p.Volume
     }
+    sort.SliceStable(order, func(i, j int) bool {
+        return prices[order[i]].Price < prices[order[j]].Price
+    })
+
+    // The median is the first price whose cumulative weight exceeds half
+    medianIdx := order[len(order)-1]
+    cumulative := 0.0
+    for _, i := range order {
+        cumulative += weights[i]
+        if cumulative > totalWeight/2 {
+            medianIdx = i
+            break
+        }
+    }
 
     return &common.PricePoint{
         Price:     prices[medianIdx].Price,
@@ -225,9 +810,101 @@ func (a *CryptoAggregator) calculateMedian(prices []*common.PricePoint) *common.
     }
 }
 
+// sourceWeight returns the configured weight of a source, defaulting to 1
+func sourceWeight(pair *common.PairConfig, source string) float64 {
+    if w, ok := pair.SourceWeights[source]; ok && w > 0 {
+        return w
+    }
+    return 1
+}
+
+// tierWeight returns the weight of the CEX or DEX sources of the tier a
+// source price came from, defaulting to 1
+func tierWeight(pair *common.PairConfig, source common.SourcePrice) float64 {
+    label := configuredTier(source.Tier)
+    for i, tier := range pairTiers(pair) {
+        if tierLabel(i) != label {
+            continue
+        }
+        weight := 0.0
+        if tier.CEX.Enabled {
+            for _, exchange := range tier.CEX.Exchanges {
+                if exchange == source.Source {
+                    weight = tier.CEX.Weight
+                }
+            }
+        }
+        if tier.DEX.Enabled {
+            for _, pool := range tier.DEX.Pools {
+                if PoolSourceName(pool) == source.Source {
+                    weight = tier.DEX.Weight
+                }
+            }
+            for _, subgraph := range tier.DEX.Subgraphs {
+                if subgraphSourceName(subgraph) == source.Source {
+                    weight = tier.DEX.Weight
+                }
+            }
+        }
+        if weight > 0 {
+            return weight
+        }
+        break
+    }
+    return 1
+}
+
+// sourceWeights returns the weight of each source price, boosting the
+// configured weights by volume share as set in the pair's AggregationParams
+func sourceWeights(pair *common.PairConfig, sources []common.SourcePrice) []float64 {
+    totalVolume := 0.0
+    for _, source := range sources {
+        totalVolume += source.Volume
+    }
+
+    weights := make([]float64, len(sources))
+    for i, source := range sources {
+        weights[i] = sourceWeight(pair, source.Source) * tierWeight(pair, source)
+        if totalVolume > 0 {
+            weights[i] *= volumeMultiplier(pair.Aggregation, source.Volume/totalVolume)
+        }
+    }
+    return weights
+}
+
+// volumeMultiplier returns the weight multiplier for a source holding
+// share of the total reported volume
+func volumeMultiplier(params common.AggregationParams, share float64) float64 {
+    multiplier := 1.0
+    switch params.VolumeBoost {
+    case common.VolumeBoostLinear:
+        multiplier = 1 + share
+    case common.VolumeBoostSqrt:
+        multiplier = 1 + math.Sqrt(share)
+    }
+    if params.MaxVolumeMultiplier > 0 && multiplier > params.MaxVolumeMultiplier {
+        multiplier = params.MaxVolumeMultiplier
+    }
+    return multiplier
+}
+
+// parseTouch parses a ticker's best bid and ask, or returns zeros unless
+// both are valid and uncrossed
+func parseTouch(bid, ask string) (float64, float64) {
+    b, err := parseFloat(bid)
+    if err != nil {
+        return 0, 0
+    }
+    a, err := parseFloat(ask)
+    if err != nil || b <= 0 || a < b {
+        return 0, 0
+    }
+    return b, a
+}
+
 // parseFloat helper function to parse string to float64
 func parseFloat(s string) (float64, error) {
     var f float64
     _, err := fmt.Sscanf(s, "%f", &f)
     return f, err
-}
\ No newline at end of file
+}
@@ -0,0 +1,102 @@
+package crypto
+
+import (
+    "encoding/json"
+    "fmt"
+    "log"
+    "os"
+    "path/filepath"
+    "sync"
+)
+
+// The config archive keeps the canonical encoding of every configuration
+// version the oracle has run with, so a past round can be replayed under
+// the parameters it was aggregated with rather than the current ones
+var (
+    archiveMu  sync.RWMutex
+    archived   = make(map[string][]byte)
+    archiveDir string
+)
+
+// SetConfigArchive also writes configuration versions to dir as
+// <version>.json, so that they outlive restarts. Versions loaded before
+// the call are written at once.
+func SetConfigArchive(dir string) error {
+    if err := os.MkdirAll(dir, 0755); err != nil {
+        return fmt.Errorf("failed to create config archive: %v", err)
+    }
+    archiveMu.Lock()
+    defer archiveMu.Unlock()
+    archiveDir = dir
+    for version, canonical := range archived {
+        if err := writeArchived(version, canonical); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// archiveConfig records a configuration version
+func archiveConfig(version string, canonical []byte) {
+    archiveMu.Lock()
+    defer archiveMu.Unlock()
+    if _, ok := archived[version]; ok {
+        return
+    }
+    archived[version] = canonical
+    if archiveDir != "" {
+        if err := writeArchived(version, canonical); err != nil {
+            // The version stays available until the next restart
+            log.Printf("%v", err)
+        }
+    }
+}
+
+// writeArchived writes a version to the archive directory unless it is
+// there already; versions are content hashes, so the file never changes
+func writeArchived(version string, canonical []byte) error {
+    path := filepath.Join(archiveDir, version+".json")
+    if _, err := os.Stat(path); err == nil {
+        return nil
+    }
+    tmp := path + ".tmp"
+    if err := os.WriteFile(tmp, canonical, 0644); err != nil {
+        return fmt.Errorf("failed to archive config %s: %v", version, err)
+    }
+    if err := os.Rename(tmp, path); err != nil {
+        return fmt.Errorf("failed to archive config %s: %v", version, err)
+    }
+    return nil
+}
+
+// ConfigAt returns the configuration of a version the oracle has run with,
+// from memory or the archive directory. LoadedAt is unset.
+func ConfigAt(version string) (*ConfigSnapshot, bool) {
+    archiveMu.RLock()
+    canonical, ok := archived[version]
+    dir := archiveDir
+    archiveMu.RUnlock()
+    if !ok {
+        if dir == "" || filepath.Base(version) != version {
+            return nil, false
+        }
+        data, err := os.ReadFile(filepath.Join(dir, version+".json"))
+        if err != nil {
+            return nil, false
+        }
+        canonical = data
+    }
+
+    // Decoded afresh so the caller cannot change the archived version
+    var config EffectiveConfig
+    if err := json.Unmarshal(canonical, &config); err != nil {
+        return nil, false
+    }
+    return &ConfigSnapshot{
+        Version:    version,
+        Base:       config.Base,
+        Pairs:      config.Pairs,
+        Derived:    config.Derived,
+        Statistics: config.Statistics,
+    }, true
+}
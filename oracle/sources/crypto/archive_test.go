@@ -0,0 +1,41 @@
+package crypto
+
+import (
+    "testing"
+
+    "yetaXYZ/oracle/common"
+)
+
+func TestConfigArchive(t *testing.T) {
+    pairs := map[string]*common.PairConfig{"ETHUSD": {BaseCurrency: "ETH", QuoteCurrency: "USD", MinimumSources: 2}}
+    snapshot, err := newConfigSnapshot(&common.BaseConfig{}, pairs, nil, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    // Changing the live config leaves the archived version as it ran
+    pairs["ETHUSD"].MinimumSources = 3
+
+    past, ok := ConfigAt(snapshot.Version)
+    if !ok {
+        t.Fatalf("Expected version %s to be archived", snapshot.Version)
+    }
+    if pair, err := past.PairConfig("ETH/USD"); err != nil || pair.MinimumSources != 2 {
+        t.Errorf("Expected the archived pair config, got %+v, %v", pair, err)
+    }
+
+    // Versions outlive restarts in the archive directory
+    dir := t.TempDir()
+    if err := SetConfigArchive(dir); err != nil {
+        t.Fatal(err)
+    }
+    defer func() { archiveDir = "" }()
+    archiveMu.Lock()
+    delete(archived, snapshot.Version)
+    archiveMu.Unlock()
+    if _, ok := ConfigAt(snapshot.Version); !ok {
+        t.Error("Expected the version to be read from the archive directory")
+    }
+    if _, ok := ConfigAt("../" + snapshot.Version); ok {
+        t.Error("Expected a version outside the archive to be rejected")
+    }
+}
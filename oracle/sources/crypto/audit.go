@@ -0,0 +1,331 @@
+package crypto
+
+import (
+    "context"
+    "crypto/rand"
+    "fmt"
+    "log"
+    "math"
+    "math/big"
+    "sort"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+)
+
+// AuditConfig tunes spot-check auditing of sources
+type AuditConfig struct {
+    // Delay is how long after a round its sources are re-read
+    Delay time.Duration
+    // Sources is the number of sources re-read per round, drawn at random
+    // in proportion to their weights
+    Sources int
+    // Tolerance is the relative difference between a re-read and the
+    // recorded price that counts as divergent
+    Tolerance float64
+    // Window is the number of recent audits of a source considered
+    Window int
+    // MinAudits is the number of recent audits needed to flag a source
+    MinAudits int
+    // FlagShare is the share of recent audits that must diverge to flag
+    FlagShare float64
+}
+
+// DefaultAuditConfig is used for unset audit settings
+var DefaultAuditConfig = AuditConfig{
+    Delay:     2 * time.Second,
+    Sources:   1,
+    Tolerance: 0.005,
+    Window:    20,
+    MinAudits: 5,
+    FlagShare: 0.5,
+}
+
+// auditTimeout bounds a re-read
+const auditTimeout = 10 * time.Second
+
+// SourceAudit is the spot-check record of a source on a feed
+type SourceAudit struct {
+    Symbol    string `json:"symbol"`
+    Source    string `json:"source"`
+    Audits    uint64 `json:"audits"`    // since start
+    Divergent uint64 `json:"divergent"` // since start
+    Failed    uint64 `json:"failed"`    // re-reads without a price
+    // RecentAudits and RecentDivergent count the audits in the window;
+    // MeanDivergence is their mean signed relative difference
+    RecentAudits    int        `json:"recentAudits"`
+    RecentDivergent int        `json:"recentDivergent"`
+    MeanDivergence  float64    `json:"meanDivergence"`
+    LastDivergence  float64    `json:"lastDivergence"`
+    LastAuditAt     time.Time  `json:"lastAuditAt"`
+    Flagged         bool       `json:"flagged"`
+    FlaggedAt       *time.Time `json:"flaggedAt,omitempty"`
+
+    recent []float64
+}
+
+// Auditor re-reads a weighted random subset of each round's sources
+// shortly after the round and compares the re-reads with the recorded
+// prices. A source whose re-reads diverge consistently is flagged: it may
+// be flaky, cached inconsistently or serving manipulated prices to the
+// oracle's reads.
+type Auditor struct {
+    aggregator *CryptoAggregator
+    bus        *events.Bus
+    config     AuditConfig
+
+    // refetch re-reads one source of a round; random draws in [0, 1)
+    refetch func(ctx context.Context, symbol string, source common.SourcePrice) (float64, error)
+    random  func() float64
+
+    mu     sync.RWMutex
+    audits map[string]*SourceAudit
+}
+
+// NewAuditor creates an auditor re-reading sources through aggregator
+func NewAuditor(aggregator *CryptoAggregator, bus *events.Bus, config AuditConfig) *Auditor {
+    if config.Delay <= 0 {
+        config.Delay = DefaultAuditConfig.Delay
+    }
+    if config.Sources <= 0 {
+        config.Sources = DefaultAuditConfig.Sources
+    }
+    if config.Tolerance <= 0 {
+        config.Tolerance = DefaultAuditConfig.Tolerance
+    }
+    if config.Window <= 0 {
+        config.Window = DefaultAuditConfig.Window
+    }
+    if config.MinAudits <= 0 {
+        config.MinAudits = DefaultAuditConfig.MinAudits
+    }
+    if config.FlagShare <= 0 {
+        config.FlagShare = DefaultAuditConfig.FlagShare
+    }
+    a := &Auditor{
+        aggregator: aggregator,
+        bus:        bus,
+        config:     config,
+        random:     secureRandom,
+        audits:     make(map[string]*SourceAudit),
+    }
+    a.refetch = a.reread
+    return a
+}
+
+// Run audits live rounds until ctx is cancelled
+func (a *Auditor) Run(ctx context.Context) {
+    sub := a.bus.SubscribeFunc(256, func(e events.Event) {
+        result, ok := e.Payload.(*common.AggregateResult)
+        if !ok || result.Backfilled || len(result.Sources) == 0 {
+            return
+        }
+        time.AfterFunc(a.config.Delay, func() {
+            if ctx.Err() == nil {
+                a.Audit(ctx, result)
+            }
+        })
+    }, events.Aggregate).Named("audit")
+    defer sub.Close()
+    <-ctx.Done()
+}
+
+// Audit re-reads the sources drawn from a round and records how far each
+// re-read is from the recorded price
+func (a *Auditor) Audit(ctx context.Context, result *common.AggregateResult) {
+    snapshot, err := CurrentConfig()
+    if err != nil {
+        return
+    }
+    pair, err := snapshot.PairConfig(result.Symbol)
+    if err != nil {
+        return
+    }
+
+    for _, source := range a.pick(pair, result) {
+        price, err := a.refetch(ctx, result.Symbol, source)
+        if err != nil {
+            a.failed(result.Symbol, source.Source)
+            log.Printf("Audit re-read of %s for %s failed: %v", source.Source, result.Symbol, err)
+            continue
+        }
+        a.record(result.Symbol, source.Source, source.Price, price, time.Now())
+    }
+}
+
+// pick draws the sources of a round to audit without replacement, each in
+// proportion to its weight, so that sources moving the median more are
+// audited more often. Rejected sources are drawn too.
+func (a *Auditor) pick(pair *common.PairConfig, result *common.AggregateResult) []common.SourcePrice {
+    candidates := append(append([]common.SourcePrice(nil), result.Sources...), result.Rejected...)
+    weights := sourceWeights(pair, candidates)
+
+    picked := make([]common.SourcePrice, 0, a.config.Sources)
+    for len(picked) < a.config.Sources && len(candidates) > 0 {
+        total := 0.0
+        for _, w := range weights {
+            total += w
+        }
+        i := len(candidates) - 1
+        target := a.random() * total
+        for j, w := range weights {
+            if target < w {
+                i = j
+                break
+            }
+            target -= w
+        }
+        picked = append(picked, candidates[i])
+        candidates = append(candidates[:i], candidates[i+1:]...)
+        weights = append(weights[:i], weights[i+1:]...)
+    }
+    return picked
+}
+
+// reread fetches one source of a pair again as its tier fetches it
+func (a *Auditor) reread(ctx context.Context, symbol string, source common.SourcePrice) (float64, error) {
+    snapshot, err := CurrentConfig()
+    if err != nil {
+        return 0, err
+    }
+    pair, err := snapshot.PairConfig(symbol)
+    if err != nil {
+        return 0, err
+    }
+    for i, tier := range pairTiers(pair) {
+        if tierLabel(i) != configuredTier(source.Tier) {
+            continue
+        }
+        jobs, _ := a.aggregator.sourceJobs(snapshot.Base, symbol, pair, tier, source.Tier)
+        for _, job := range jobs {
+            if job.source.Source != source.Source {
+                continue
+            }
+            ctx, cancel := context.WithTimeout(ctx, auditTimeout)
+            price, err := job.fetch(ctx)
+            cancel()
+            if err != nil {
+                return 0, err
+            }
+            if price == nil {
+                return 0, fmt.Errorf("no price")
+            }
+            return price.Price * job.scale, nil
+        }
+    }
+    return 0, fmt.Errorf("source no longer fetched")
+}
+
+// record folds an audit into the source's record, flagging or clearing it
+func (a *Auditor) record(symbol, source string, recorded, reread float64, now time.Time) {
+    if recorded <= 0 {
+        return
+    }
+    divergence := (reread - recorded) / recorded
+
+    a.mu.Lock()
+    s := a.audit(symbol, source)
+    s.Audits++
+    if math.Abs(divergence) > a.config.Tolerance {
+        s.Divergent++
+    }
+    s.LastDivergence = divergence
+    s.LastAuditAt = now
+    s.recent = append(s.recent, divergence)
+    if len(s.recent) > a.config.Window {
+        s.recent = s.recent[len(s.recent)-a.config.Window:]
+    }
+    s.RecentAudits, s.RecentDivergent, s.MeanDivergence = len(s.recent), 0, 0
+    for _, d := range s.recent {
+        if math.Abs(d) > a.config.Tolerance {
+            s.RecentDivergent++
+        }
+        s.MeanDivergence += d / float64(len(s.recent))
+    }
+
+    flag := s.RecentAudits >= a.config.MinAudits && float64(s.RecentDivergent) >= a.config.FlagShare*float64(s.RecentAudits)
+    changed := flag != s.Flagged
+    s.Flagged = flag
+    if flag && changed {
+        flaggedAt := now
+        s.FlaggedAt = &flaggedAt
+    } else if !flag {
+        s.FlaggedAt = nil
+    }
+    audit := *s
+    a.mu.Unlock()
+
+    if !changed {
+        return
+    }
+    severity := events.SeverityInfo
+    message := fmt.Sprintf("%s re-reads for %s are consistent again: %d of the last %d audits diverged", source, symbol, audit.RecentDivergent, audit.RecentAudits)
+    if flag {
+        severity = events.SeverityWarning
+        message = fmt.Sprintf("%s re-reads for %s diverge from recorded prices in %d of the last %d audits (mean %+.3f%%); the endpoint may be flaky or manipulated", source, symbol, audit.RecentDivergent, audit.RecentAudits, audit.MeanDivergence*100)
+    }
+    log.Printf("Source audit: %s", message)
+    a.bus.Publish(events.Event{
+        Type:   events.Alert,
+        Symbol: symbol,
+        Payload: &events.AlertPayload{
+            Severity: severity,
+            Kind:     "source_audit",
+            Message:  message,
+        },
+    })
+}
+
+// failed counts a re-read that returned no price
+func (a *Auditor) failed(symbol, source string) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    a.audit(symbol, source).Failed++
+}
+
+// audit returns the record of a source on a feed; callers hold mu
+func (a *Auditor) audit(symbol, source string) *SourceAudit {
+    key := symbol + "/" + source
+    s, ok := a.audits[key]
+    if !ok {
+        s = &SourceAudit{Symbol: symbol, Source: source}
+        a.audits[key] = s
+    }
+    return s
+}
+
+// Audits returns the records of every audited source, flagged ones first
+func (a *Auditor) Audits() []SourceAudit {
+    a.mu.RLock()
+    out := make([]SourceAudit, 0, len(a.audits))
+    for _, s := range a.audits {
+        audit := *s
+        audit.recent = nil
+        out = append(out, audit)
+    }
+    a.mu.RUnlock()
+
+    sort.Slice(out, func(i, j int) bool {
+        if out[i].Flagged != out[j].Flagged {
+            return out[i].Flagged
+        }
+        if out[i].Symbol != out[j].Symbol {
+            return out[i].Symbol < out[j].Symbol
+        }
+        return out[i].Source < out[j].Source
+    })
+    return out
+}
+
+// secureRandom returns a uniform value in [0, 1) from a cryptographic
+// source, so that the sources audited cannot be anticipated
+func secureRandom() float64 {
+    const precision = 1 << 53
+    n, err := rand.Int(rand.Reader, big.NewInt(precision))
+    if err != nil {
+        return 0
+    }
+    return float64(n.Int64()) / precision
+}
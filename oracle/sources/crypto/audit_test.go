@@ -0,0 +1,78 @@
+package crypto
+
+import (
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+)
+
+func TestAuditorPicksByWeight(t *testing.T) {
+    pair := &common.PairConfig{SourceWeights: map[string]float64{"binance": 3}}
+    result := &common.AggregateResult{
+        Sources:  []common.SourcePrice{{Source: "binance"}, {Source: "coinbase"}},
+        Rejected: []common.SourcePrice{{Source: "kraken"}},
+    }
+    a := NewAuditor(nil, events.NewBus(), AuditConfig{Sources: 2})
+
+    // Weights 3, 1 and 1 out of 5: 0.5 falls in binance's share, then 0.6
+    // of the remaining 2 falls in kraken's
+    draws := []float64{0.5, 0.6}
+    a.random = func() float64 {
+        r := draws[0]
+        draws = draws[1:]
+        return r
+    }
+    picked := a.pick(pair, result)
+    if len(picked) != 2 || picked[0].Source != "binance" || picked[1].Source != "kraken" {
+        t.Errorf("Expected binance then kraken, got %+v", picked)
+    }
+
+    a.config.Sources = 5
+    a.random = func() float64 { return 0.99 }
+    if picked := a.pick(pair, result); len(picked) != 3 {
+        t.Errorf("Expected every source at most once, got %+v", picked)
+    }
+}
+
+func TestAuditorFlagsConsistentDivergence(t *testing.T) {
+    bus := events.NewBus()
+    alerts := bus.Subscribe(16, events.Alert)
+    a := NewAuditor(nil, bus, AuditConfig{Window: 6, MinAudits: 4, FlagShare: 0.5, Tolerance: 0.005})
+    now := time.Now()
+
+    // Occasional divergence is not enough
+    a.record("ETHUSDT", "kraken", 100, 101, now)
+    for i := 0; i < 3; i++ {
+        a.record("ETHUSDT", "kraken", 100, 100.1, now)
+    }
+    if audits := a.Audits(); len(audits) != 1 || audits[0].Flagged || audits[0].RecentDivergent != 1 {
+        t.Fatalf("Expected kraken unflagged, got %+v", audits)
+    }
+
+    // Re-reads consistently above the recorded price flag the source
+    a.record("ETHUSDT", "kraken", 100, 102, now)
+    a.record("ETHUSDT", "kraken", 100, 102, now)
+    audit := a.Audits()[0]
+    if !audit.Flagged || audit.FlaggedAt == nil || audit.RecentAudits != 6 || audit.Divergent != 3 {
+        t.Fatalf("Expected kraken flagged, got %+v", audit)
+    }
+    if alert := (<-alerts.C).Payload.(*events.AlertPayload); alert.Kind != "source_audit" || alert.Severity != events.SeverityWarning {
+        t.Errorf("Expected a source audit warning, got %+v", alert)
+    }
+
+    // Only the window counts: consistent re-reads clear the flag
+    for i := 0; i < 4; i++ {
+        a.record("ETHUSDT", "kraken", 100, 100, now)
+    }
+    if audit := a.Audits()[0]; audit.Flagged || audit.RecentAudits != 6 {
+        t.Errorf("Expected kraken cleared, got %+v", audit)
+    }
+    if alert := (<-alerts.C).Payload.(*events.AlertPayload); alert.Severity != events.SeverityInfo {
+        t.Errorf("Expected a clearing alert, got %+v", alert)
+    }
+    if len(alerts.C) != 0 {
+        t.Errorf("Expected alerts only on changes, got %d more", len(alerts.C))
+    }
+}
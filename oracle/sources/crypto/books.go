@@ -0,0 +1,727 @@
+package crypto
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "log"
+    "net/http"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/fetch"
+    "yetaXYZ/oracle/symbols"
+)
+
+// defaultBookStreamURLs are the public WebSocket endpoints of exchanges
+// whose order book stream has no URL configured
+var defaultBookStreamURLs = map[string]string{
+    "binance": "wss://stream.binance.com:9443/ws",
+    "kraken":  "wss://ws.kraken.com/v2",
+}
+
+// bookStreamers lists the exchanges whose order books can be streamed
+var bookStreamers = map[string]func(b *BookStreams, ctx context.Context, s *bookStream) error{
+    "binance": (*BookStreams).streamBinance,
+    "kraken":  (*BookStreams).streamKraken,
+}
+
+// krakenBookDepths are the depths Kraken's book channel accepts
+var krakenBookDepths = map[int]bool{10: true, 25: true, 100: true, 500: true, 1000: true}
+
+// Reconnection backoff of a failed stream
+const (
+    bookRetryBase = time.Second
+    bookRetryMax  = time.Minute
+)
+
+// BookStatus is the state of one streamed order book
+type BookStatus struct {
+    Exchange  string    `json:"exchange"`
+    Symbol    string    `json:"symbol"` // as the exchange's REST API names it
+    Synced    bool      `json:"synced"`
+    UpdatedAt time.Time `json:"updatedAt,omitempty"`
+    Bid       float64   `json:"bid,omitempty"`
+    Ask       float64   `json:"ask,omitempty"`
+    Mid       float64   `json:"mid,omitempty"`
+    Micro     float64   `json:"microprice,omitempty"`
+    Updates   uint64    `json:"updates"`
+    Resyncs   uint64    `json:"resyncs"`
+    LastError string    `json:"lastError,omitempty"`
+    // Trade prints of books priced by trade: those kept, those dropped as
+    // bad ticks and those received before the book was synced
+    Trades          uint64         `json:"trades,omitempty"`
+    FilteredTrades  uint64         `json:"filteredTrades,omitempty"`
+    UncheckedTrades uint64         `json:"uncheckedTrades,omitempty"`
+    LastTrade       float64        `json:"lastTrade,omitempty"`
+    LastTradeAt     *time.Time     `json:"lastTradeAt,omitempty"`
+    LastFiltered    *FilteredTrade `json:"lastFiltered,omitempty"`
+}
+
+// BookStreams keeps local order books of the pairs' CEX sources from
+// exchange WebSocket deltas, so that those sources are priced from the
+// book's mid or microprice instead of REST last trade prices: a book is
+// fresher than a polled ticker and a single print cannot move it. Books
+// priced by trade also follow the trade stream and keep the last print
+// that is not a bad tick against the book.
+type BookStreams struct {
+    client *http.Client
+
+    mu      sync.RWMutex
+    streams map[string]*bookStream
+}
+
+// bookStream is the stream of one exchange symbol and its local book
+type bookStream struct {
+    exchange string
+    venue    string // REST symbol, e.g. ETHUSDT
+    stream   string // WebSocket symbol, e.g. ethusdt or ETH/USDT
+    config   common.OrderBookStreamConfig
+    baseURL  string // REST API root, for snapshots
+    cancel   context.CancelFunc
+
+    mu   sync.RWMutex
+    book localBook
+}
+
+// NewBookStreams creates the order book streams manager
+func NewBookStreams() *BookStreams {
+    return &BookStreams{
+        client:  fetch.NewClient(10 * time.Second),
+        streams: make(map[string]*bookStream),
+    }
+}
+
+// Run streams the books of the configured pairs' sources on exchanges with
+// an orderBook config, picking up config changes at interval, until ctx is
+// cancelled
+func (b *BookStreams) Run(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    for {
+        if snapshot, err := CurrentConfig(); err == nil {
+            b.sync(ctx, snapshot.Base, snapshot.Pairs)
+        }
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+// sync starts the streams the config needs and stops the others
+func (b *BookStreams) sync(ctx context.Context, base *common.BaseConfig, pairs map[string]*common.PairConfig) {
+    wanted := make(map[string]*bookStream)
+    for symbol, pair := range pairs {
+        for _, tier := range pairTiers(pair) {
+            if !tier.CEX.Enabled {
+                continue
+            }
+            for _, exchange := range tier.CEX.Exchanges {
+                details, ok := base.Exchanges.CEX[exchange]
+                if !ok || details.OrderBook == nil || bookStreamers[exchange] == nil {
+                    continue
+                }
+                quote, venue := quoteAsset(base, symbol, pair, exchange)
+                wanted[exchange+"/"+venue] = &bookStream{
+                    exchange: exchange,
+                    venue:    venue,
+                    stream:   symbols.New(base).StreamTicker(exchange, pair.BaseCurrency, quote),
+                    config:   *details.OrderBook,
+                    baseURL:  ExchangeURL(base, exchange),
+                }
+            }
+        }
+    }
+
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    for key, s := range b.streams {
+        if w, ok := wanted[key]; !ok || w.config != s.config || w.baseURL != s.baseURL {
+            s.cancel()
+            delete(b.streams, key)
+        }
+    }
+    for key, s := range wanted {
+        if _, ok := b.streams[key]; ok {
+            continue
+        }
+        streamCtx, cancel := context.WithCancel(ctx)
+        s.cancel = cancel
+        b.streams[key] = s
+        go b.maintain(streamCtx, s)
+    }
+}
+
+// maintain keeps a stream connected, rebuilding its book after every
+// reconnect, until ctx is cancelled
+func (b *BookStreams) maintain(ctx context.Context, s *bookStream) {
+    backoff := bookRetryBase
+    for {
+        started := time.Now()
+        err := bookStreamers[s.exchange](b, ctx, s)
+        if ctx.Err() != nil {
+            return
+        }
+        s.mu.Lock()
+        s.book.synced = false
+        s.book.resyncs++
+        if err != nil {
+            s.book.lastError = err.Error()
+        }
+        s.mu.Unlock()
+        log.Printf("Order book stream of %s on %s interrupted: %v", s.venue, s.exchange, err)
+
+        // A stream that stayed up for a while reconnects promptly
+        if time.Since(started) > bookRetryMax {
+            backoff = bookRetryBase
+        }
+        select {
+        case <-ctx.Done():
+            return
+        case <-time.After(backoff):
+        }
+        if backoff *= 2; backoff > bookRetryMax {
+            backoff = bookRetryMax
+        }
+    }
+}
+
+// Price returns the book price of an exchange's symbol, or false while the
+// book is not synced or is older than its maximum age
+func (b *BookStreams) Price(exchange, venue string, now time.Time) (*common.PricePoint, bool) {
+    if b == nil {
+        return nil, false
+    }
+    b.mu.RLock()
+    s, ok := b.streams[exchange+"/"+venue]
+    b.mu.RUnlock()
+    if !ok {
+        return nil, false
+    }
+
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    if !s.book.synced || now.Sub(s.book.updatedAt) > s.config.MaxAge() {
+        return nil, false
+    }
+    // The last print that passed the filter, while it is recent; a quiet
+    // market is priced from the book's microprice
+    mode := s.config.PriceMode()
+    book := s.book.top(1)
+    var bid, ask float64
+    if len(book.Bids) > 0 && len(book.Asks) > 0 {
+        bid, ask = book.Bids[0].Price, book.Asks[0].Price
+    }
+    if mode == common.BookPriceTrade {
+        if tape := s.book.trades; tape.accepted > 0 && now.Sub(tape.at) <= s.config.MaxAge() {
+            return &common.PricePoint{Price: tape.price, Timestamp: tape.at, Bid: bid, Ask: ask}, true
+        }
+    }
+    var price float64
+    var err error
+    if mode == common.BookPriceMid {
+        price, err = book.Mid()
+    } else {
+        price, err = book.Microprice()
+    }
+    if err != nil {
+        return nil, false
+    }
+    // Books carry no traded volume
+    return &common.PricePoint{Price: price, Timestamp: s.book.updatedAt, Bid: bid, Ask: ask}, true
+}
+
+// Depth returns the streamed levels of an exchange's symbol, or false while
+// the book is not synced or is older than its maximum age
+func (b *BookStreams) Depth(exchange, venue string, now time.Time) (*OrderBook, bool) {
+    if b == nil {
+        return nil, false
+    }
+    b.mu.RLock()
+    s, ok := b.streams[exchange+"/"+venue]
+    b.mu.RUnlock()
+    if !ok {
+        return nil, false
+    }
+
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    if !s.book.synced || now.Sub(s.book.updatedAt) > s.config.MaxAge() {
+        return nil, false
+    }
+    return s.book.top(len(s.book.bids) + len(s.book.asks)), true
+}
+
+// Status returns the state of every streamed book, by exchange and symbol
+func (b *BookStreams) Status() []BookStatus {
+    if b == nil {
+        return []BookStatus{}
+    }
+    b.mu.RLock()
+    streams := make([]*bookStream, 0, len(b.streams))
+    for _, s := range b.streams {
+        streams = append(streams, s)
+    }
+    b.mu.RUnlock()
+
+    out := make([]BookStatus, 0, len(streams))
+    for _, s := range streams {
+        s.mu.RLock()
+        status := BookStatus{
+            Exchange:  s.exchange,
+            Symbol:    s.venue,
+            Synced:    s.book.synced,
+            UpdatedAt: s.book.updatedAt,
+            Updates:   s.book.updates,
+            Resyncs:   s.book.resyncs,
+            LastError: s.book.lastError,
+
+            Trades:          s.book.trades.accepted,
+            FilteredTrades:  s.book.trades.filtered,
+            UncheckedTrades: s.book.trades.unchecked,
+            LastTrade:       s.book.trades.price,
+            LastFiltered:    s.book.trades.lastFiltered,
+        }
+        if s.book.trades.accepted > 0 {
+            at := s.book.trades.at
+            status.LastTradeAt = &at
+        }
+        if top := s.book.top(1); len(top.Bids) > 0 && len(top.Asks) > 0 {
+            status.Bid, status.Ask = top.Bids[0].Price, top.Asks[0].Price
+            status.Mid, _ = top.Mid()
+            status.Micro, _ = top.Microprice()
+        }
+        s.mu.RUnlock()
+        out = append(out, status)
+    }
+    sort.Slice(out, func(i, j int) bool {
+        if out[i].Exchange != out[j].Exchange {
+            return out[i].Exchange < out[j].Exchange
+        }
+        return out[i].Symbol < out[j].Symbol
+    })
+    return out
+}
+
+// localBook is an order book maintained from deltas
+type localBook struct {
+    bids, asks map[float64]float64 // price -> quantity
+    lastID     uint64              // last applied update, Binance only
+    synced     bool
+    updatedAt  time.Time
+    updates    uint64
+    resyncs    uint64
+    lastError  string
+    trades     tradeTape // books priced by trade only
+}
+
+// reset replaces the book with a snapshot
+func (l *localBook) reset(bids, asks []BookLevel, now time.Time) {
+    l.bids = make(map[float64]float64, len(bids))
+    l.asks = make(map[float64]float64, len(asks))
+    for _, level := range bids {
+        l.set(true, level)
+    }
+    for _, level := range asks {
+        l.set(false, level)
+    }
+    l.synced = true
+    l.updatedAt = now
+    l.lastError = ""
+}
+
+// set applies a level; a zero quantity removes it
+func (l *localBook) set(bid bool, level BookLevel) {
+    side := l.asks
+    if bid {
+        side = l.bids
+    }
+    if level.Quantity == 0 {
+        delete(side, level.Price)
+        return
+    }
+    side[level.Price] = level.Quantity
+}
+
+// truncate drops levels beyond depth on each side, for streams that do
+// not delete levels leaving the subscribed depth
+func (l *localBook) truncate(depth int) {
+    book := l.top(depth)
+    l.bids = make(map[float64]float64, len(book.Bids))
+    l.asks = make(map[float64]float64, len(book.Asks))
+    for _, level := range book.Bids {
+        l.bids[level.Price] = level.Quantity
+    }
+    for _, level := range book.Asks {
+        l.asks[level.Price] = level.Quantity
+    }
+}
+
+// top returns the best depth levels of each side
+func (l *localBook) top(depth int) *OrderBook {
+    levels := func(side map[float64]float64) []BookLevel {
+        out := make([]BookLevel, 0, len(side))
+        for price, qty := range side {
+            out = append(out, BookLevel{Price: price, Quantity: qty})
+        }
+        return out
+    }
+    book := newOrderBook(levels(l.bids), levels(l.asks))
+    if len(book.Bids) > depth {
+        book.Bids = book.Bids[:depth]
+    }
+    if len(book.Asks) > depth {
+        book.Asks = book.Asks[:depth]
+    }
+    return book
+}
+
+// crossed reports whether the best bid reaches the best ask, a sign of a
+// book out of sync
+func (l *localBook) crossed() bool {
+    book := l.top(1)
+    return len(book.Bids) > 0 && len(book.Asks) > 0 && book.Bids[0].Price >= book.Asks[0].Price
+}
+
+// readMessages reads a stream's messages into a channel until it fails;
+// the read deadline notices a silent stream
+func readMessages(ws *fetch.WebSocket, timeout time.Duration, messages chan<- []byte, errs chan<- error) {
+    for {
+        ws.SetReadDeadline(time.Now().Add(timeout))
+        message, err := ws.ReadMessage()
+        if err != nil {
+            errs <- err
+            return
+        }
+        messages <- message
+    }
+}
+
+// binanceDepthUpdate is an event of Binance's diff depth stream
+type binanceDepthUpdate struct {
+    First uint64     `json:"U"`
+    Last  uint64     `json:"u"`
+    Bids  [][]string `json:"b"`
+    Asks  [][]string `json:"a"`
+}
+
+// streamBinance follows Binance's diff depth stream: updates are buffered
+// from connecting, a REST snapshot is fetched, updates it already covers
+// are dropped and every later update must continue where the previous one
+// ended
+func (b *BookStreams) streamBinance(ctx context.Context, s *bookStream) error {
+    url := s.config.URL
+    if url == "" {
+        url = defaultBookStreamURLs["binance"]
+    }
+    ws, err := fetch.DialWebSocket(ctx, strings.TrimRight(url, "/")+"/"+s.stream+"@depth@100ms")
+    if err != nil {
+        return err
+    }
+    defer ws.Close()
+    if s.config.PriceMode() == common.BookPriceTrade {
+        subscribe, err := json.Marshal(map[string]interface{}{
+            "method": "SUBSCRIBE",
+            "params": []string{s.stream + "@trade"},
+            "id":     1,
+        })
+        if err != nil {
+            return err
+        }
+        if err := ws.WriteText(subscribe); err != nil {
+            return err
+        }
+    }
+
+    messages, errs := make(chan []byte, 1024), make(chan error, 1)
+    go readMessages(ws, s.config.MaxAge(), messages, errs)
+
+    snapshot, err := b.binanceSnapshot(ctx, s)
+    if err != nil {
+        return err
+    }
+    s.mu.Lock()
+    s.book.reset(snapshot.bids, snapshot.asks, time.Now())
+    s.book.lastID = snapshot.lastID
+    s.book.synced = false // until the first update bridges the snapshot
+    s.mu.Unlock()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return nil
+        case err := <-errs:
+            return err
+        case message := <-messages:
+            var event struct {
+                Type string `json:"e"`
+            }
+            if err := json.Unmarshal(message, &event); err != nil {
+                return fmt.Errorf("invalid stream event: %v", err)
+            }
+            if event.Type == "trade" {
+                s.mu.Lock()
+                err := s.book.applyBinanceTrade(message, s.config.TradeTolerance())
+                s.mu.Unlock()
+                if err != nil {
+                    return err
+                }
+                continue
+            }
+            if event.Type != "depthUpdate" {
+                continue // subscription replies
+            }
+            var update binanceDepthUpdate
+            if err := json.Unmarshal(message, &update); err != nil {
+                return fmt.Errorf("invalid depth update: %v", err)
+            }
+            s.mu.Lock()
+            err := s.book.applyBinance(update, time.Now())
+            s.mu.Unlock()
+            if err != nil {
+                return err
+            }
+        }
+    }
+}
+
+// applyBinance applies a Binance depth update in sequence
+func (l *localBook) applyBinance(update binanceDepthUpdate, now time.Time) error {
+    if update.Last <= l.lastID {
+        return nil // covered by the snapshot
+    }
+    if !l.synced && update.First > l.lastID+1 {
+        return fmt.Errorf("depth updates start at %d, after the snapshot's %d", update.First, l.lastID)
+    }
+    if l.synced && update.First != l.lastID+1 {
+        return fmt.Errorf("depth update gap: expected %d, got %d", l.lastID+1, update.First)
+    }
+    bids, err := parseBookLevels(update.Bids)
+    if err != nil {
+        return err
+    }
+    asks, err := parseBookLevels(update.Asks)
+    if err != nil {
+        return err
+    }
+    for _, level := range bids {
+        l.set(true, level)
+    }
+    for _, level := range asks {
+        l.set(false, level)
+    }
+    l.lastID = update.Last
+    l.synced = true
+    l.updatedAt = now
+    l.updates++
+    // Levels far from the touch only grow the book; keep it bounded well
+    // beyond the depth used
+    if len(l.bids)+len(l.asks) > 2*binanceBookLimit {
+        l.truncate(binanceBookLimit / 2)
+    }
+    if l.crossed() {
+        return fmt.Errorf("book crossed after update %d", update.Last)
+    }
+    return nil
+}
+
+// binanceBookLimit is the number of levels per side of a depth snapshot
+const binanceBookLimit = 1000
+
+// binanceBookSnapshot is a REST depth snapshot with its update ID
+type binanceBookSnapshot struct {
+    lastID     uint64
+    bids, asks []BookLevel
+}
+
+// binanceSnapshot fetches the depth snapshot a diff stream builds on
+func (b *BookStreams) binanceSnapshot(ctx context.Context, s *bookStream) (*binanceBookSnapshot, error) {
+    req, err := http.NewRequestWithContext(fetch.WithSchema(ctx, "binance", "depth"), http.MethodGet, fmt.Sprintf("%s/depth?symbol=%s&limit=%d", s.baseURL, s.venue, binanceBookLimit), nil)
+    if err != nil {
+        return nil, err
+    }
+    resp, err := b.client.Do(req)
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("unexpected status from Binance: %s", resp.Status)
+    }
+
+    var data struct {
+        LastUpdateID uint64     `json:"lastUpdateId"`
+        Bids         [][]string `json:"bids"`
+        Asks         [][]string `json:"asks"`
+    }
+    if err := fetch.DecodeJSON(resp, &data); err != nil {
+        return nil, err
+    }
+    snapshot := &binanceBookSnapshot{lastID: data.LastUpdateID}
+    if snapshot.bids, err = parseBookLevels(data.Bids); err != nil {
+        return nil, err
+    }
+    if snapshot.asks, err = parseBookLevels(data.Asks); err != nil {
+        return nil, err
+    }
+    return snapshot, nil
+}
+
+// krakenBookMessage is a message of Kraken's v2 book channel
+type krakenBookMessage struct {
+    Channel string `json:"channel"`
+    Type    string `json:"type"`
+    Method  string `json:"method"`
+    Success *bool  `json:"success"`
+    Error   string `json:"error"`
+    Data    []struct {
+        Bids []krakenBookLevel `json:"bids"`
+        Asks []krakenBookLevel `json:"asks"`
+    } `json:"data"`
+}
+
+// krakenBookLevel is a price level of Kraken's v2 book channel
+type krakenBookLevel struct {
+    Price float64 `json:"price"`
+    Qty   float64 `json:"qty"`
+}
+
+// streamKraken follows Kraken's v2 book channel: a snapshot of the
+// subscribed depth, then updates after which levels beyond the depth are
+// dropped
+func (b *BookStreams) streamKraken(ctx context.Context, s *bookStream) error {
+    url := s.config.URL
+    if url == "" {
+        url = defaultBookStreamURLs["kraken"]
+    }
+    ws, err := fetch.DialWebSocket(ctx, url)
+    if err != nil {
+        return err
+    }
+    defer ws.Close()
+
+    subscriptions := []map[string]interface{}{{
+        "channel": "book",
+        "symbol":  []string{s.stream},
+        "depth":   s.config.Levels(),
+    }}
+    if s.config.PriceMode() == common.BookPriceTrade {
+        subscriptions = append(subscriptions, map[string]interface{}{
+            "channel":  "trade",
+            "symbol":   []string{s.stream},
+            "snapshot": false,
+        })
+    }
+    for _, params := range subscriptions {
+        subscribe, err := json.Marshal(map[string]interface{}{"method": "subscribe", "params": params})
+        if err != nil {
+            return err
+        }
+        if err := ws.WriteText(subscribe); err != nil {
+            return err
+        }
+    }
+
+    messages, errs := make(chan []byte, 1024), make(chan error, 1)
+    go readMessages(ws, s.config.MaxAge(), messages, errs)
+    for {
+        select {
+        case <-ctx.Done():
+            return nil
+        case err := <-errs:
+            return err
+        case message := <-messages:
+            var m krakenBookMessage
+            if err := json.Unmarshal(message, &m); err != nil {
+                return fmt.Errorf("invalid book message: %v", err)
+            }
+            var err error
+            s.mu.Lock()
+            if m.Channel == "trade" {
+                err = s.book.applyKrakenTrades(message, s.config.TradeTolerance())
+            } else {
+                err = s.book.applyKraken(m, s.config.Levels(), time.Now())
+            }
+            s.mu.Unlock()
+            if err != nil {
+                return err
+            }
+        }
+    }
+}
+
+// applyKraken applies a Kraken book message, ignoring other channels
+func (l *localBook) applyKraken(m krakenBookMessage, depth int, now time.Time) error {
+    if m.Method == "subscribe" && m.Success != nil && !*m.Success {
+        return fmt.Errorf("subscription refused: %s", m.Error)
+    }
+    if m.Channel != "book" {
+        return nil
+    }
+    switch m.Type {
+    case "snapshot":
+        var bids, asks []BookLevel
+        for _, d := range m.Data {
+            bids = append(bids, krakenLevels(d.Bids)...)
+            asks = append(asks, krakenLevels(d.Asks)...)
+        }
+        l.reset(bids, asks, now)
+    case "update":
+        if !l.synced {
+            return fmt.Errorf("book update before a snapshot")
+        }
+        for _, d := range m.Data {
+            for _, level := range krakenLevels(d.Bids) {
+                l.set(true, level)
+            }
+            for _, level := range krakenLevels(d.Asks) {
+                l.set(false, level)
+            }
+        }
+        l.truncate(depth)
+        l.updatedAt = now
+    default:
+        return nil
+    }
+    l.updates++
+    if l.crossed() {
+        return fmt.Errorf("book crossed")
+    }
+    return nil
+}
+
+// krakenLevels converts Kraken levels into book levels
+func krakenLevels(levels []krakenBookLevel) []BookLevel {
+    out := make([]BookLevel, 0, len(levels))
+    for _, level := range levels {
+        out = append(out, BookLevel{Price: level.Price, Quantity: level.Qty})
+    }
+    return out
+}
+
+// validateOrderBook checks an exchange's order book stream config
+func validateOrderBook(exchange string, c *common.OrderBookStreamConfig) error {
+    if bookStreamers[exchange] == nil {
+        return fmt.Errorf("exchange %s: order book streaming is not supported", exchange)
+    }
+    switch c.PriceMode() {
+    case common.BookPriceMid, common.BookPriceMicroprice, common.BookPriceTrade:
+    default:
+        return fmt.Errorf("exchange %s: unknown order book price %q", exchange, c.Price)
+    }
+    if c.MaxAgeSeconds < 0 || c.Depth < 0 {
+        return fmt.Errorf("exchange %s: order book maxAgeSeconds and depth must not be negative", exchange)
+    }
+    if c.MaxTradeDeviation < 0 || c.MaxTradeDeviation >= 1 {
+        return fmt.Errorf("exchange %s: order book maxTradeDeviation must be between 0 and 1", exchange)
+    }
+    if exchange == "kraken" && !krakenBookDepths[c.Levels()] {
+        return fmt.Errorf("exchange kraken: order book depth must be 10, 25, 100, 500 or 1000")
+    }
+    return nil
+}
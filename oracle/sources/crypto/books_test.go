@@ -0,0 +1,203 @@
+package crypto
+
+import (
+    "fmt"
+    "math"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+)
+
+func TestMicroprice(t *testing.T) {
+    book := &OrderBook{
+        Bids: []BookLevel{{Price: 100, Quantity: 3}},
+        Asks: []BookLevel{{Price: 102, Quantity: 1}},
+    }
+    // Heavier bids lean the price towards the ask
+    micro, err := book.Microprice()
+    if err != nil || micro != 101.5 {
+        t.Errorf("Expected 101.5, got %v, %v", micro, err)
+    }
+    if _, err := (&OrderBook{}).Microprice(); err == nil {
+        t.Error("Expected an empty book to fail")
+    }
+}
+
+func TestBinanceBookSequencing(t *testing.T) {
+    now := time.Now()
+    var book localBook
+    book.reset([]BookLevel{{Price: 100, Quantity: 1}, {Price: 99, Quantity: 2}}, []BookLevel{{Price: 101, Quantity: 1}}, now)
+    book.lastID, book.synced = 10, false
+
+    // Updates the snapshot covers are dropped
+    if err := book.applyBinance(binanceDepthUpdate{First: 5, Last: 10, Bids: [][]string{{"100", "0"}}}, now); err != nil || book.synced {
+        t.Fatalf("Expected a covered update to be ignored, got %v", err)
+    }
+    // The first update must bridge the snapshot
+    if err := book.applyBinance(binanceDepthUpdate{First: 12, Last: 13}, now); err == nil {
+        t.Error("Expected an update after a gap to fail")
+    }
+    if err := book.applyBinance(binanceDepthUpdate{First: 9, Last: 12, Bids: [][]string{{"100", "0"}}, Asks: [][]string{{"100.5", "4"}}}, now); err != nil {
+        t.Fatalf("Expected the bridging update to apply, got %v", err)
+    }
+    top := book.top(1)
+    if !book.synced || top.Bids[0].Price != 99 || top.Asks[0].Price != 100.5 {
+        t.Errorf("Expected best 99 / 100.5, got %+v", top)
+    }
+    // Later updates must follow on without gaps
+    if err := book.applyBinance(binanceDepthUpdate{First: 14, Last: 15}, now); err == nil {
+        t.Error("Expected a sequence gap to fail")
+    }
+    if err := book.applyBinance(binanceDepthUpdate{First: 13, Last: 13, Bids: [][]string{{"101", "1"}}}, now); err == nil {
+        t.Error("Expected a crossed book to fail")
+    }
+}
+
+func TestKrakenBook(t *testing.T) {
+    now := time.Now()
+    var book localBook
+    update := krakenBookMessage{Channel: "book", Type: "update"}
+    if err := book.applyKraken(update, 2, now); err == nil {
+        t.Error("Expected an update before the snapshot to fail")
+    }
+
+    snapshot := krakenBookMessage{Channel: "book", Type: "snapshot"}
+    snapshot.Data = append(snapshot.Data, struct {
+        Bids []krakenBookLevel `json:"bids"`
+        Asks []krakenBookLevel `json:"asks"`
+    }{
+        Bids: []krakenBookLevel{{Price: 100, Qty: 1}, {Price: 99, Qty: 1}},
+        Asks: []krakenBookLevel{{Price: 101, Qty: 1}, {Price: 102, Qty: 1}},
+    })
+    if err := book.applyKraken(snapshot, 2, now); err != nil || !book.synced {
+        t.Fatalf("Expected the snapshot to sync the book, got %v", err)
+    }
+
+    // A new best bid pushes the worst bid out of the subscribed depth
+    update.Data = append(update.Data, snapshot.Data[0])
+    update.Data[0].Bids = []krakenBookLevel{{Price: 100.5, Qty: 2}}
+    update.Data[0].Asks = []krakenBookLevel{{Price: 101, Qty: 0}}
+    if err := book.applyKraken(update, 2, now); err != nil {
+        t.Fatal(err)
+    }
+    top := book.top(10)
+    if len(top.Bids) != 2 || top.Bids[0].Price != 100.5 || top.Bids[1].Price != 100 || len(top.Asks) != 1 || top.Asks[0].Price != 102 {
+        t.Errorf("Expected the book truncated to depth 2, got %+v", top)
+    }
+
+    failed := false
+    refused := krakenBookMessage{Method: "subscribe", Success: &failed, Error: "Currency pair not supported"}
+    if err := book.applyKraken(refused, 2, now); err == nil {
+        t.Error("Expected a refused subscription to fail")
+    }
+}
+
+func TestBookStreamPrice(t *testing.T) {
+    now := time.Now()
+    stream := &bookStream{exchange: "binance", venue: "ETHUSDT", config: common.OrderBookStreamConfig{MaxAgeSeconds: 5}}
+    stream.book.reset([]BookLevel{{Price: 100, Quantity: 3}}, []BookLevel{{Price: 102, Quantity: 1}}, now)
+    books := NewBookStreams()
+    books.streams["binance/ETHUSDT"] = stream
+
+    point, ok := books.Price("binance", "ETHUSDT", now.Add(time.Second))
+    if !ok || point.Price != 101.5 || !point.Timestamp.Equal(now) {
+        t.Errorf("Expected the microprice by default, got %+v", point)
+    }
+    stream.config.Price = common.BookPriceMid
+    if point, _ := books.Price("binance", "ETHUSDT", now); math.Abs(point.Price-101) > 1e-9 {
+        t.Errorf("Expected the mid, got %v", point.Price)
+    }
+    if _, ok := books.Price("binance", "ETHUSDT", now.Add(6*time.Second)); ok {
+        t.Error("Expected a stale book to fall back to REST")
+    }
+    stream.book.synced = false
+    if _, ok := books.Price("binance", "ETHUSDT", now); ok {
+        t.Error("Expected an unsynced book to fall back to REST")
+    }
+    var none *BookStreams
+    if _, ok := none.Price("binance", "ETHUSDT", now); ok {
+        t.Error("Expected no price without streams")
+    }
+}
+
+func TestTradeFilter(t *testing.T) {
+    now := time.Now()
+    stream := &bookStream{exchange: "binance", venue: "ETHUSDT", config: common.OrderBookStreamConfig{Price: common.BookPriceTrade, MaxAgeSeconds: 5}}
+    books := NewBookStreams()
+    books.streams["binance/ETHUSDT"] = stream
+
+    // Prints before the book is synced cannot be judged
+    if stream.book.applyTrade(101, now, stream.config.TradeTolerance()) {
+        t.Error("Expected a print before the book to be dropped")
+    }
+    stream.book.reset([]BookLevel{{Price: 100, Quantity: 3}}, []BookLevel{{Price: 102, Quantity: 1}}, now)
+    if _, ok := books.Price("binance", "ETHUSDT", now); !ok {
+        t.Error("Expected the microprice before the first print")
+    }
+
+    if err := stream.book.applyBinanceTrade([]byte(fmt.Sprintf(`{"e": "trade", "p": "101.20", "T": %d}`, now.UnixMilli())), stream.config.TradeTolerance()); err != nil {
+        t.Fatalf("Failed to apply a trade: %v", err)
+    }
+    for _, price := range []float64{90, 102.4, 103, 0} {
+        stream.book.applyTrade(price, now.Add(time.Second), stream.config.TradeTolerance())
+    }
+    point, ok := books.Price("binance", "ETHUSDT", now.Add(time.Second))
+    if !ok || point.Price != 102.4 {
+        t.Errorf("Expected the last print within the touch, got %+v", point)
+    }
+
+    status := books.Status()[0]
+    if status.Trades != 2 || status.FilteredTrades != 3 || status.UncheckedTrades != 1 {
+        t.Errorf("Expected 2 kept, 3 filtered and 1 unchecked print, got %+v", status)
+    }
+    if status.LastFiltered == nil || status.LastFiltered.Price != 0 || status.LastFiltered.Bid != 100 {
+        t.Errorf("Expected the last bad tick recorded, got %+v", status.LastFiltered)
+    }
+
+    // A quiet market falls back to the book
+    if point, ok := books.Price("binance", "ETHUSDT", now.Add(7*time.Second)); ok {
+        t.Errorf("Expected the stale book to fall back to REST, got %+v", point)
+    }
+    stream.book.updatedAt = now.Add(7 * time.Second)
+    if point, _ := books.Price("binance", "ETHUSDT", now.Add(7*time.Second)); point.Price != 101.5 {
+        t.Errorf("Expected the microprice once the last print is stale, got %+v", point)
+    }
+}
+
+func TestValidateOrderBook(t *testing.T) {
+    if err := validateOrderBook("binance", &common.OrderBookStreamConfig{}); err != nil {
+        t.Errorf("Expected defaults to validate, got %v", err)
+    }
+    if err := validateOrderBook("kraken", &common.OrderBookStreamConfig{Price: common.BookPriceTrade, MaxTradeDeviation: 0.01}); err != nil {
+        t.Errorf("Expected trade pricing to validate, got %v", err)
+    }
+    for exchange, config := range map[string]*common.OrderBookStreamConfig{
+        "coinbase": {},
+        "binance":  {Price: "last"},
+        "kraken":   {Depth: 20},
+    } {
+        if err := validateOrderBook(exchange, config); err == nil {
+            t.Errorf("Expected %s %+v to be rejected", exchange, config)
+        }
+    }
+}
+
+func TestBookStreamDepth(t *testing.T) {
+    now := time.Now()
+    stream := &bookStream{exchange: "binance", venue: "ETHUSDT", config: common.OrderBookStreamConfig{MaxAgeSeconds: 5}}
+    stream.book.reset([]BookLevel{{Price: 100, Quantity: 3}, {Price: 99, Quantity: 1}}, []BookLevel{{Price: 102, Quantity: 1}, {Price: 103, Quantity: 2}}, now)
+    books := NewBookStreams()
+    books.streams["binance/ETHUSDT"] = stream
+
+    book, ok := books.Depth("binance", "ETHUSDT", now)
+    if !ok || len(book.Bids) != 2 || len(book.Asks) != 2 || book.Asks[0].Price != 102 || book.Bids[0].Price != 100 {
+        t.Fatalf("Expected every streamed level, best first, got %+v", book)
+    }
+    if _, ok := books.Depth("binance", "ETHUSDT", now.Add(6*time.Second)); ok {
+        t.Error("Expected a stale book to fall back to a snapshot")
+    }
+    if _, ok := books.Depth("kraken", "ETHUSDT", now); ok {
+        t.Error("Expected no depth for a book not streamed")
+    }
+}
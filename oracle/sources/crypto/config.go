@@ -1,25 +1,164 @@
 package crypto
 
 import (
+    "crypto/sha256"
+    "encoding/hex"
     "encoding/json"
     "fmt"
-    "io/ioutil"
+    "os"
     "path/filepath"
+    "sort"
     "strings"
-    
+    "sync/atomic"
+    "time"
+
     "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/derived"
+    "yetaXYZ/oracle/expr"
+    "yetaXYZ/oracle/symbols"
 )
 
 var (
-    BaseConfig *common.BaseConfig
-    PairsConfig map[string]*common.PairConfig
+    BaseConfig       *common.BaseConfig
+    PairsConfig      map[string]*common.PairConfig
+    DerivedConfig    map[string]*common.DerivedFeedConfig
+    StatisticsConfig map[string]*common.StatisticFeedConfig
+    // ProfilesConfig are the named aggregation tuning profiles pairs may
+    // reference instead of repeating their parameters
+    ProfilesConfig map[string]common.AggregationParams
+
+    // ExternalFeeds are feeds published by other services, such as
+    // benchmark rates and price indices, that derived feeds may take as
+    // inputs; set before ValidateConfig
+    ExternalFeeds = map[string]bool{}
+
+    currentConfig atomic.Pointer[ConfigSnapshot]
 )
 
+// ConfigSnapshot is an immutable, versioned view of the resolved configuration.
+// Aggregation rounds capture a snapshot once so that every step of a round
+// uses the same weights and parameters, even if the config is reloaded.
+type ConfigSnapshot struct {
+    Version    string
+    LoadedAt   time.Time
+    Base       *common.BaseConfig
+    Pairs      map[string]*common.PairConfig
+    Derived    map[string]*common.DerivedFeedConfig
+    Statistics map[string]*common.StatisticFeedConfig
+}
+
+// EffectiveConfig is the resolved configuration as one document: the base
+// config with onboarded assets merged in, and the feeds of pairs.json. Its
+// canonical JSON encoding determines a snapshot's version.
+type EffectiveConfig struct {
+    Base       *common.BaseConfig                     `json:"base"`
+    Pairs      map[string]*common.PairConfig          `json:"pairs"`
+    Derived    map[string]*common.DerivedFeedConfig   `json:"derived,omitempty"`
+    Statistics map[string]*common.StatisticFeedConfig `json:"statistics,omitempty"`
+}
+
+// newConfigSnapshot resolves a snapshot and derives its version from the
+// canonical JSON encoding of the configuration
+func newConfigSnapshot(base *common.BaseConfig, pairs map[string]*common.PairConfig, derived map[string]*common.DerivedFeedConfig, statistics map[string]*common.StatisticFeedConfig) (*ConfigSnapshot, error) {
+    canonical, err := json.Marshal(EffectiveConfig{base, pairs, derived, statistics})
+    if err != nil {
+        return nil, fmt.Errorf("failed to encode config: %v", err)
+    }
+    sum := sha256.Sum256(canonical)
+    version := hex.EncodeToString(sum[:8])
+    archiveConfig(version, canonical)
+
+    return &ConfigSnapshot{
+        Version:    version,
+        LoadedAt:   time.Now(),
+        Base:       base,
+        Pairs:      pairs,
+        Derived:    derived,
+        Statistics: statistics,
+    }, nil
+}
+
+// CurrentConfig returns the active configuration snapshot
+func CurrentConfig() (*ConfigSnapshot, error) {
+    if snapshot := currentConfig.Load(); snapshot != nil && snapshot.Base == BaseConfig {
+        return snapshot, nil
+    }
+    if BaseConfig == nil || PairsConfig == nil {
+        return nil, fmt.Errorf("configuration not loaded")
+    }
+
+    // Configuration was assigned directly rather than through LoadConfig
+    snapshot, err := newConfigSnapshot(BaseConfig, PairsConfig, DerivedConfig, StatisticsConfig)
+    if err != nil {
+        return nil, err
+    }
+    currentConfig.Store(snapshot)
+    return snapshot, nil
+}
+
+// Effective returns the snapshot's configuration as one document
+func (c *ConfigSnapshot) Effective() EffectiveConfig {
+    return EffectiveConfig{Base: c.Base, Pairs: c.Pairs, Derived: c.Derived, Statistics: c.Statistics}
+}
+
+// PairConfig returns the configuration for a trading pair within the snapshot
+func (c *ConfigSnapshot) PairConfig(symbol string) (*common.PairConfig, error) {
+    config, ok := c.Pairs[strings.ReplaceAll(symbol, "/", "")]
+    if !ok {
+        return nil, fmt.Errorf("pair config not found for symbol: %s", symbol)
+    }
+    return config, nil
+}
+
+// PairVersion returns a short hash of a pair's configuration within the
+// snapshot, or an empty string when the pair is not configured
+func (c *ConfigSnapshot) PairVersion(symbol string) string {
+    pair, ok := c.Pairs[symbol]
+    if !ok {
+        return ""
+    }
+    canonical, err := json.Marshal(pair)
+    if err != nil {
+        return ""
+    }
+    sum := sha256.Sum256(canonical)
+    return hex.EncodeToString(sum[:8])
+}
+
+// Group returns the pairs tagged with a group within the snapshot, sorted
+func (c *ConfigSnapshot) Group(group string) []string {
+    members := make([]string, 0)
+    for symbol, pair := range c.Pairs {
+        for _, g := range pair.Groups {
+            if g == group {
+                members = append(members, symbol)
+                break
+            }
+        }
+    }
+    sort.Strings(members)
+    return members
+}
+
+// Groups returns the pairs of every group within the snapshot
+func (c *ConfigSnapshot) Groups() map[string][]string {
+    groups := make(map[string][]string)
+    for symbol, pair := range c.Pairs {
+        for _, g := range pair.Groups {
+            groups[g] = append(groups[g], symbol)
+        }
+    }
+    for _, members := range groups {
+        sort.Strings(members)
+    }
+    return groups
+}
+
 // LoadConfig loads the configuration from the specified directory
 func LoadConfig(configDir string) error {
     // Load base config
     baseConfigPath := filepath.Join(configDir, "base", "config.json")
-    data, err := ioutil.ReadFile(baseConfigPath)
+    data, err := os.ReadFile(baseConfigPath)
     if err != nil {
         return fmt.Errorf("failed to read base config: %v", err)
     }
@@ -28,25 +167,204 @@ func LoadConfig(configDir string) error {
     if err := json.Unmarshal(data, BaseConfig); err != nil {
         return fmt.Errorf("failed to parse base config: %v", err)
     }
+    if err := loadOnboardedAssets(configDir, BaseConfig); err != nil {
+        return err
+    }
 
     // Load pairs config
     pairsConfigPath := filepath.Join(configDir, "pairs", "pairs.json")
-    data, err = ioutil.ReadFile(pairsConfigPath)
+    data, err = os.ReadFile(pairsConfigPath)
     if err != nil {
         return fmt.Errorf("failed to read pairs config: %v", err)
     }
 
     var pairsData struct {
-        Pairs map[string]*common.PairConfig `json:"pairs"`
+        Profiles   map[string]common.AggregationParams    `json:"profiles"`
+        Pairs      map[string]*common.PairConfig          `json:"pairs"`
+        Derived    map[string]*common.DerivedFeedConfig   `json:"derived"`
+        Statistics map[string]*common.StatisticFeedConfig `json:"statistics"`
     }
     if err := json.Unmarshal(data, &pairsData); err != nil {
         return fmt.Errorf("failed to parse pairs config: %v", err)
     }
+    if err := resolveProfiles(pairsData.Profiles, pairsData.Pairs); err != nil {
+        return err
+    }
+    ProfilesConfig = pairsData.Profiles
     PairsConfig = pairsData.Pairs
+    DerivedConfig = pairsData.Derived
+    StatisticsConfig = pairsData.Statistics
+
+    snapshot, err := newConfigSnapshot(BaseConfig, PairsConfig, DerivedConfig, StatisticsConfig)
+    if err != nil {
+        return err
+    }
+    currentConfig.Store(snapshot)
+
+    return nil
+}
+
+// pairsFile is pairs.json with the entries left encoded, so that writing
+// one entry back leaves the others untouched
+type pairsFile struct {
+    Profiles   map[string]json.RawMessage `json:"profiles,omitempty"`
+    Pairs      map[string]json.RawMessage `json:"pairs"`
+    Derived    json.RawMessage            `json:"derived,omitempty"`
+    Statistics json.RawMessage            `json:"statistics,omitempty"`
+}
+
+// readPairsFile reads pairs.json for writing entries back
+func readPairsFile(path string) (*pairsFile, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read pairs config: %v", err)
+    }
+    var pairsData pairsFile
+    if err := json.Unmarshal(data, &pairsData); err != nil {
+        return nil, fmt.Errorf("failed to parse pairs config: %v", err)
+    }
+    if pairsData.Pairs == nil {
+        pairsData.Pairs = make(map[string]json.RawMessage)
+    }
+    return &pairsData, nil
+}
+
+// write writes the pairs file back to path
+func (f *pairsFile) write(path string) error {
+    data, err := json.MarshalIndent(f, "", "    ")
+    if err != nil {
+        return fmt.Errorf("failed to encode pairs config: %v", err)
+    }
+    return os.WriteFile(path, data, 0644)
+}
+
+// UpdatePairConfig writes a single pair's configuration back to pairs.json,
+// leaving the other pair entries untouched. The aggregation parameters of a
+// pair with a profile are written only where they differ from the profile.
+func UpdatePairConfig(configDir, symbol string, pair *common.PairConfig) error {
+    pairsConfigPath := filepath.Join(configDir, "pairs", "pairs.json")
+    pairsData, err := readPairsFile(pairsConfigPath)
+    if err != nil {
+        return err
+    }
+
+    if pair.Profile != "" {
+        raw, ok := pairsData.Profiles[pair.Profile]
+        if !ok {
+            return fmt.Errorf("pair %s: unknown profile %q", symbol, pair.Profile)
+        }
+        var profile common.AggregationParams
+        if err := json.Unmarshal(raw, &profile); err != nil {
+            return fmt.Errorf("failed to parse profile %s: %v", pair.Profile, err)
+        }
+        own := *pair
+        own.Aggregation = profileOverrides(profile, pair.Aggregation)
+        pair = &own
+    }
+
+    encoded, err := json.Marshal(pair)
+    if err != nil {
+        return fmt.Errorf("failed to encode pair %s: %v", symbol, err)
+    }
+    pairsData.Pairs[symbol] = encoded
+    return pairsData.write(pairsConfigPath)
+}
+
+// onboardedAssets is assets/assets.json, the asset address book entries
+// added by oraclectl asset add
+type onboardedAssets struct {
+    Assets map[string]json.RawMessage `json:"assets"`
+}
 
+// loadOnboardedAssets adds the entries of assets/assets.json, if present, to
+// the base config's asset address book
+func loadOnboardedAssets(configDir string, base *common.BaseConfig) error {
+    data, err := os.ReadFile(filepath.Join(configDir, "assets", "assets.json"))
+    if os.IsNotExist(err) {
+        return nil
+    }
+    if err != nil {
+        return fmt.Errorf("failed to read assets config: %v", err)
+    }
+
+    var assets struct {
+        Assets common.AssetConfig `json:"assets"`
+    }
+    if err := json.Unmarshal(data, &assets); err != nil {
+        return fmt.Errorf("failed to parse assets config: %v", err)
+    }
+    if base.Assets == nil {
+        base.Assets = make(common.AssetConfig, len(assets.Assets))
+    }
+    for symbol, asset := range assets.Assets {
+        if _, exists := base.Assets[symbol]; exists {
+            return fmt.Errorf("asset %s is defined in both the base config and assets.json", symbol)
+        }
+        base.Assets[symbol] = asset
+    }
     return nil
 }
 
+// UpdateAssetConfig writes a single asset to assets/assets.json, leaving the
+// other onboarded assets untouched
+func UpdateAssetConfig(configDir, symbol string, asset *common.Asset) error {
+    assetsConfigPath := filepath.Join(configDir, "assets", "assets.json")
+    assets := onboardedAssets{Assets: make(map[string]json.RawMessage)}
+    data, err := os.ReadFile(assetsConfigPath)
+    if err == nil {
+        if err := json.Unmarshal(data, &assets); err != nil {
+            return fmt.Errorf("failed to parse assets config: %v", err)
+        }
+        if assets.Assets == nil {
+            assets.Assets = make(map[string]json.RawMessage)
+        }
+    } else if !os.IsNotExist(err) {
+        return fmt.Errorf("failed to read assets config: %v", err)
+    }
+
+    encoded, err := json.Marshal(asset)
+    if err != nil {
+        return fmt.Errorf("failed to encode asset %s: %v", symbol, err)
+    }
+    assets.Assets[symbol] = encoded
+
+    data, err = json.MarshalIndent(assets, "", "    ")
+    if err != nil {
+        return fmt.Errorf("failed to encode assets config: %v", err)
+    }
+    return os.WriteFile(assetsConfigPath, data, 0644)
+}
+
+// ApplyPairConfig writes a pair's configuration and activates it. If the
+// resulting configuration does not validate, the previous pairs.json is
+// restored and reloaded.
+func ApplyPairConfig(configDir, symbol string, pair *common.PairConfig) error {
+    pairsConfigPath := filepath.Join(configDir, "pairs", "pairs.json")
+    previous, err := os.ReadFile(pairsConfigPath)
+    if err != nil {
+        return fmt.Errorf("failed to read pairs config: %v", err)
+    }
+
+    if err := UpdatePairConfig(configDir, symbol, pair); err != nil {
+        return err
+    }
+    err = LoadConfig(configDir)
+    if err == nil {
+        err = ValidateConfig()
+    }
+    if err == nil {
+        return nil
+    }
+
+    if restoreErr := os.WriteFile(pairsConfigPath, previous, 0644); restoreErr != nil {
+        return fmt.Errorf("invalid configuration (%v) and failed to restore previous: %v", err, restoreErr)
+    }
+    if reloadErr := LoadConfig(configDir); reloadErr != nil {
+        return fmt.Errorf("invalid configuration (%v) and failed to reload previous: %v", err, reloadErr)
+    }
+    return fmt.Errorf("invalid configuration: %v", err)
+}
+
 // GetChainConfig returns the configuration for a specific chain
 func GetChainConfig(chainID string) (*common.Chain, error) {
     config, ok := BaseConfig.Chains[chainID]
@@ -68,8 +386,8 @@ func GetAssetConfig(symbol string) (*common.Asset, error) {
 // GetPairConfig returns the configuration for a specific trading pair
 func GetPairConfig(symbol string) (*common.PairConfig, error) {
     // Convert symbol format from BTC/USDT to BTCUSDT
-    symbol = strings.ReplaceAll(symbol, "/", "")
-    
+    symbol = symbols.PairSymbol(symbol)
+
     config, ok := PairsConfig[symbol]
     if !ok {
         return nil, fmt.Errorf("pair config not found for symbol: %s", symbol)
@@ -92,7 +410,7 @@ func getExchangesForAssets(baseAsset, quoteAsset *common.Asset) []string {
 // getDEXExchangesForAssets returns a map of chain to DEX list that support both assets
 func getDEXExchangesForAssets(baseAsset, quoteAsset *common.Asset) map[string][]string {
     dexMap := make(map[string][]string)
-    
+
     // Check each chain where both assets exist
     for chainID := range baseAsset.Chains {
         if _, ok := quoteAsset.Chains[chainID]; ok {
@@ -108,7 +426,7 @@ func getDEXExchangesForAssets(baseAsset, quoteAsset *common.Asset) map[string][]
             }
         }
     }
-    
+
     return dexMap
 }
 
@@ -136,17 +454,446 @@ func ValidateConfig() error {
         return fmt.Errorf("pairs configuration not loaded")
     }
 
-    if len(BaseConfig.Exchanges.CEX) == 0 && len(BaseConfig.Exchanges.DEX) == 0 {
+    return validateConfig(BaseConfig, PairsConfig, DerivedConfig, StatisticsConfig)
+}
+
+// validateConfig checks a resolved configuration without touching the
+// package-level config
+func validateConfig(base *common.BaseConfig, pairs map[string]*common.PairConfig, derivedFeeds map[string]*common.DerivedFeedConfig, statistics map[string]*common.StatisticFeedConfig) error {
+    if len(base.Exchanges.CEX) == 0 && len(base.Exchanges.DEX) == 0 {
         return fmt.Errorf("no exchanges configured")
     }
 
-    if len(BaseConfig.Assets) == 0 {
+    if len(base.Assets) == 0 {
         return fmt.Errorf("no assets configured")
     }
 
-    if len(PairsConfig) == 0 {
+    if len(pairs) == 0 {
         return fmt.Errorf("no trading pairs configured")
     }
 
+    for symbol, asset := range base.Assets {
+        for chainID, info := range asset.Chains {
+            if _, ok := base.Chains[chainID]; !ok {
+                return fmt.Errorf("asset %s references unknown chain %s", symbol, chainID)
+            }
+            if info.Address != "" && !symbols.IsAddress(info.Address) {
+                return fmt.Errorf("asset %s has invalid address on chain %s: %s", symbol, chainID, info.Address)
+            }
+        }
+    }
+
+    for name, details := range base.Exchanges.CEX {
+        // Coinbase is read through Advanced Trade, whose paths the legacy
+        // v2 API does not serve
+        if name == "coinbase" && strings.HasSuffix(strings.TrimRight(details.BaseURL, "/"), "/v2") {
+            return fmt.Errorf("exchange coinbase: baseURL %s is the legacy v2 API, want the Advanced Trade root %s", details.BaseURL, defaultExchangeURLs["coinbase"])
+        }
+        if details.OrderBook != nil {
+            if err := validateOrderBook(name, details.OrderBook); err != nil {
+                return err
+            }
+        }
+        if details.ClockSkew != nil {
+            if err := validateClockSkew(name, details.ClockSkew); err != nil {
+                return err
+            }
+        }
+        if details.Volume != nil {
+            if err := validateVolume(name, details.Volume); err != nil {
+                return err
+            }
+        }
+        if details.LatencySLO != nil {
+            if err := validateLatencySLO(name, details.LatencySLO); err != nil {
+                return err
+            }
+        }
+        if err := validateSymbols(name, details.Symbols); err != nil {
+            return err
+        }
+        if err := validateClientTLS("exchange "+name, details.HTTP.TLS); err != nil {
+            return err
+        }
+    }
+
+    for name, details := range base.Exchanges.DEX {
+        if auth := details.Auth; auth != nil && (auth.KeyEnv == "") == (auth.KeyFile == "") {
+            return fmt.Errorf("DEX %s: auth needs exactly one of keyEnv and keyFile", name)
+        }
+        if err := validateClientTLS("DEX "+name, details.HTTP.TLS); err != nil {
+            return err
+        }
+    }
+    if base.HTTP.TLS != nil {
+        return fmt.Errorf("http: client certificates are configured per source, not globally")
+    }
+
+    for symbol, pair := range pairs {
+        for source, weight := range pair.SourceWeights {
+            if weight <= 0 {
+                return fmt.Errorf("pair %s: weight of source %s must be positive", symbol, source)
+            }
+        }
+        if err := validateAggregation("pair "+symbol, pair.Aggregation); err != nil {
+            return err
+        }
+        if pair.UpdateFrequencySeconds > 0 && pair.Aggregation.SamplingWindow() >= time.Duration(pair.UpdateFrequencySeconds)*time.Second {
+            return fmt.Errorf("pair %s: samplingWindowMs must be shorter than the update interval", symbol)
+        }
+        if err := validateQuoteAssets(base, symbol, pair); err != nil {
+            return err
+        }
+        if err := validateBidAsk(symbol, pair); err != nil {
+            return err
+        }
+        if err := validateFreeze(symbol, pair.Freeze); err != nil {
+            return err
+        }
+        if err := validateDEXPools(base, symbol, pair, pair.Sources.DEX); err != nil {
+            return err
+        }
+        for _, tier := range pair.FallbackTiers {
+            if err := validateDEXPools(base, symbol, pair, tier.DEX); err != nil {
+                return err
+            }
+        }
+    }
+
+    // Derived feeds must reference known feeds and must not form cycles
+    feeds := make(map[string]bool, len(pairs))
+    for symbol := range pairs {
+        feeds[symbol] = true
+    }
+    inputs := make(map[string]bool, len(feeds)+len(ExternalFeeds))
+    for symbol := range feeds {
+        inputs[symbol] = true
+    }
+    for symbol := range ExternalFeeds {
+        inputs[symbol] = true
+    }
+    if _, err := derived.NewGraph(derivedFeeds, inputs); err != nil {
+        return fmt.Errorf("invalid derived feeds: %v", err)
+    }
+
+    // Quote member feeds must be feeds the oracle produces, such as the
+    // rates of monitored pegs
+    for unit, class := range base.QuoteClasses {
+        for asset, member := range class.Members {
+            if member.Feed == "" {
+                continue
+            }
+            if _, ok := derivedFeeds[member.Feed]; !ok && !inputs[member.Feed] {
+                return fmt.Errorf("quote class %s: member %s references unknown feed %s", unit, asset, member.Feed)
+            }
+            // Conversions need a round at hand, not one aggregated on query
+            if pair, ok := pairs[member.Feed]; ok && pair.OnDemand != nil {
+                return fmt.Errorf("quote class %s: member %s references on-demand feed %s", unit, asset, member.Feed)
+            }
+        }
+    }
+
+    for name, stat := range statistics {
+        if err := validateStatistic(name, stat, feeds); err != nil {
+            return err
+        }
+    }
+
+    for symbol, pair := range pairs {
+        if err := validateTransform(symbol, pair, feeds); err != nil {
+            return err
+        }
+        if err := validateBounds(symbol, pair.Bounds); err != nil {
+            return err
+        }
+        if err := validateColdStart(symbol, pair, feeds); err != nil {
+            return err
+        }
+        if err := validateGroups(symbol, pair.Groups); err != nil {
+            return err
+        }
+        if pair.OnDemand != nil && (pair.OnDemand.TTLSeconds < 0 || pair.OnDemand.MaxRoundsPerHour < 0) {
+            return fmt.Errorf("pair %s: onDemand ttlSeconds and maxRoundsPerHour must not be negative", symbol)
+        }
+    }
+
     return nil
-} 
\ No newline at end of file
+}
+
+// validateGroups checks a pair's group tags, which appear in admin URLs
+func validateGroups(symbol string, groups []string) error {
+    seen := make(map[string]bool, len(groups))
+    for _, group := range groups {
+        if group == "" || strings.ContainsAny(group, "/?#% ") {
+            return fmt.Errorf("pair %s: invalid group %q", symbol, group)
+        }
+        if seen[group] {
+            return fmt.Errorf("pair %s: duplicate group %s", symbol, group)
+        }
+        seen[group] = true
+    }
+    return nil
+}
+
+// validateAggregation checks the aggregation parameters of a pair or
+// profile, named by owner as in "pair BTCUSDT"
+func validateAggregation(owner string, params common.AggregationParams) error {
+    switch params.VolumeBoost {
+    case "", common.VolumeBoostNone, common.VolumeBoostLinear, common.VolumeBoostSqrt:
+    default:
+        return fmt.Errorf("%s: unknown volume boost %q", owner, params.VolumeBoost)
+    }
+    if params.MaxVolumeMultiplier != 0 && params.MaxVolumeMultiplier < 1 {
+        return fmt.Errorf("%s: maxVolumeMultiplier must be at least 1", owner)
+    }
+    if params.IQRMultiplier < 0 {
+        return fmt.Errorf("%s: iqrMultiplier must not be negative", owner)
+    }
+    if params.SamplingWindowMs < 0 || params.SamplesPerSource < 0 {
+        return fmt.Errorf("%s: samplingWindowMs and samplesPerSource must not be negative", owner)
+    }
+    if params.SamplesPerSource > 1 && params.SamplingWindowMs == 0 {
+        return fmt.Errorf("%s: samplesPerSource requires a samplingWindowMs", owner)
+    }
+    return nil
+}
+
+// validateTransform checks that a pair's transform parses and references
+// only the price, its variables and other pair or derived feeds
+func validateTransform(symbol string, pair *common.PairConfig, feeds map[string]bool) error {
+    if pair.Transform == "" {
+        if len(pair.TransformVariables) > 0 {
+            return fmt.Errorf("pair %s: transformVariables without a transform", symbol)
+        }
+        return nil
+    }
+    e, err := expr.Parse(pair.Transform)
+    if err != nil {
+        return fmt.Errorf("pair %s: %v", symbol, err)
+    }
+    for _, name := range e.Identifiers() {
+        _, variable := pair.TransformVariables[name]
+        _, derivedFeed := DerivedConfig[name]
+        switch {
+        case name == "price" || variable:
+        case name == symbol:
+            return fmt.Errorf("pair %s: transform references its own feed", symbol)
+        case !feeds[name] && !derivedFeed:
+            return fmt.Errorf("pair %s: transform references unknown variable or feed %s", symbol, name)
+        }
+    }
+    return nil
+}
+
+// validateColdStart checks that a pair's cold start proxy is another known
+// feed and its window and bounds are usable
+func validateColdStart(symbol string, pair *common.PairConfig, feeds map[string]bool) error {
+    c := pair.ColdStart
+    if c == nil {
+        return nil
+    }
+    if c.WindowHours < 0 || c.BoundsSigma < 0 {
+        return fmt.Errorf("pair %s: coldStart windowHours and boundsSigma must not be negative", symbol)
+    }
+    if c.ProxyFeed == "" {
+        if !pair.Sources.CEX.Enabled || len(pair.Sources.CEX.Exchanges) == 0 {
+            return fmt.Errorf("pair %s: coldStart without a proxyFeed needs exchange klines", symbol)
+        }
+        if c.Window() > 30*24*time.Hour {
+            return fmt.Errorf("pair %s: coldStart kline window must not exceed 30 days", symbol)
+        }
+        return nil
+    }
+    if c.ProxyFeed == symbol {
+        return fmt.Errorf("pair %s: coldStart proxy is the pair itself", symbol)
+    }
+    if !feeds[c.ProxyFeed] && DerivedConfig[c.ProxyFeed] == nil {
+        return fmt.Errorf("pair %s: coldStart proxy references unknown feed %s", symbol, c.ProxyFeed)
+    }
+    return nil
+}
+
+// validateQuoteAssets checks that every exchange fetched in another quote
+// asset uses a member of the class of the pair's quote currency
+func validateQuoteAssets(base *common.BaseConfig, symbol string, pair *common.PairConfig) error {
+    for exchange, quote := range pair.QuoteAssets {
+        if err := validateQuoteMember(base, symbol, pair, exchange, quote); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// validateQuoteMember checks that a source fetched in quote can be
+// converted into the pair's quote currency
+func validateQuoteMember(base *common.BaseConfig, symbol string, pair *common.PairConfig, source, quote string) error {
+    if quote == "" || quote == pair.QuoteCurrency {
+        return nil
+    }
+    member, ok := base.QuoteClasses[pair.QuoteCurrency].Member(quote)
+    if !ok {
+        return fmt.Errorf("pair %s: quote asset %s of %s is not in the %s quote class", symbol, quote, source, pair.QuoteCurrency)
+    }
+    if member.Feed == symbol {
+        return fmt.Errorf("pair %s: quote asset %s of %s is converted by the pair itself", symbol, quote, source)
+    }
+    if member.Factor < 0 || member.MaxAdjustment < 0 || member.MaxAgeSeconds < 0 {
+        return fmt.Errorf("pair %s: quote member %s must not have negative settings", symbol, quote)
+    }
+    return nil
+}
+
+// validateDEXPools checks that every configured pool trades exactly the
+// pair's base asset and its quote asset, the pair's quote currency unless
+// the pool names another member of its class, as identified by the asset
+// address book
+func validateDEXPools(base *common.BaseConfig, symbol string, pair *common.PairConfig, dexConfig common.DEXSourceConfig) error {
+    if !dexConfig.Enabled {
+        return nil
+    }
+
+    configured := len(dexConfig.Pools) + len(dexConfig.Subgraphs)
+    book := symbols.New(base)
+    if _, ok := book.Asset(pair.BaseCurrency); !ok && configured > 0 {
+        return fmt.Errorf("pair %s: base asset %s not configured", symbol, pair.BaseCurrency)
+    }
+
+    for _, pool := range dexConfig.Pools {
+        if !symbols.IsAddress(pool.Address) {
+            return fmt.Errorf("pair %s: invalid pool address %q", symbol, pool.Address)
+        }
+        quote := poolQuote(pair, pool.Quote)
+        if _, ok := book.Asset(quote); !ok {
+            return fmt.Errorf("pair %s: quote asset %s not configured", symbol, quote)
+        }
+        if err := validateQuoteMember(base, symbol, pair, PoolSourceName(pool), pool.Quote); err != nil {
+            return err
+        }
+
+        baseAddr, err := book.Address(pool.Chain, pair.BaseCurrency)
+        if err != nil {
+            return fmt.Errorf("pair %s: %v", symbol, err)
+        }
+        quoteAddr, err := book.Address(pool.Chain, quote)
+        if err != nil {
+            return fmt.Errorf("pair %s: %v", symbol, err)
+        }
+
+        matches := (strings.EqualFold(pool.Token0, baseAddr) && strings.EqualFold(pool.Token1, quoteAddr)) ||
+            (strings.EqualFold(pool.Token0, quoteAddr) && strings.EqualFold(pool.Token1, baseAddr))
+        if !matches {
+            return fmt.Errorf("pair %s: pool %s tokens (%s, %s) do not match %s/%s addresses on chain %s",
+                symbol, pool.Address, pool.Token0, pool.Token1, pair.BaseCurrency, quote, pool.Chain)
+        }
+    }
+
+    seen := make(map[string]bool, len(dexConfig.Subgraphs))
+    for _, source := range dexConfig.Subgraphs {
+        details, ok := base.Exchanges.DEX[source.Exchange]
+        if !ok || details.Type != "subgraph" {
+            return fmt.Errorf("pair %s: %s is not a subgraph DEX", symbol, source.Exchange)
+        }
+        if details.Chain == "" {
+            return fmt.Errorf("pair %s: subgraph DEX %s has no chain", symbol, source.Exchange)
+        }
+        if seen[source.Exchange] {
+            return fmt.Errorf("pair %s: subgraph DEX %s is listed twice in a tier", symbol, source.Exchange)
+        }
+        seen[source.Exchange] = true
+        if (len(source.Pools) > 0) == (source.TopPools > 0) {
+            return fmt.Errorf("pair %s: subgraph source %s needs either pools or topPools", symbol, source.Exchange)
+        }
+        if source.TopPools < 0 || source.TopPools > 100 {
+            return fmt.Errorf("pair %s: subgraph source %s topPools must be between 1 and 100", symbol, source.Exchange)
+        }
+        for _, address := range source.Pools {
+            if !symbols.IsAddress(address) {
+                return fmt.Errorf("pair %s: invalid pool address %q", symbol, address)
+            }
+        }
+        if err := validateQuoteMember(base, symbol, pair, subgraphSourceName(source), source.Quote); err != nil {
+            return err
+        }
+        for _, asset := range []string{pair.BaseCurrency, poolQuote(pair, source.Quote)} {
+            if _, err := book.Address(details.Chain, asset); err != nil {
+                return fmt.Errorf("pair %s: %v", symbol, err)
+            }
+        }
+    }
+
+    return nil
+}
+
+// validateStatistic checks that a statistic feed references known feeds
+func validateStatistic(name string, stat *common.StatisticFeedConfig, pairs map[string]bool) error {
+    inputs := 1
+    switch stat.Type {
+    case common.StatisticVolatility:
+    case common.StatisticCorrelation:
+        inputs = 2
+    default:
+        return fmt.Errorf("statistic %s: unknown type %q", name, stat.Type)
+    }
+    if len(stat.Inputs) != inputs {
+        return fmt.Errorf("statistic %s: %s takes exactly %d input(s)", name, stat.Type, inputs)
+    }
+    for _, input := range stat.Inputs {
+        if !pairs[input] && DerivedConfig[input] == nil {
+            return fmt.Errorf("statistic %s depends on unknown feed %s", name, input)
+        }
+    }
+    if stat.WindowHours <= 0 {
+        return fmt.Errorf("statistic %s: windowHours must be positive", name)
+    }
+    return nil
+}
+
+// validateBidAsk checks a pair's bid/ask aggregation
+func validateBidAsk(symbol string, pair *common.PairConfig) error {
+    c := pair.BidAsk
+    if c == nil {
+        return nil
+    }
+    switch c.Method {
+    case "", common.BidAskMedian, common.BidAskWidest:
+    default:
+        return fmt.Errorf("pair %s: unknown bidAsk method %q", symbol, c.Method)
+    }
+    if c.MinimumSources < 0 {
+        return fmt.Errorf("pair %s: bidAsk minimumSources must not be negative", symbol)
+    }
+    // A transform such as 1 / price would turn the bid into the ask
+    if pair.Transform != "" {
+        return fmt.Errorf("pair %s: bidAsk cannot be combined with a transform", symbol)
+    }
+    return nil
+}
+
+// validateClientTLS checks that a client certificate has one source for
+// its certificate and one for its key
+func validateClientTLS(source string, c *common.ClientTLS) error {
+    if c == nil {
+        return nil
+    }
+    if (c.CertFile == "") == (c.CertEnv == "") {
+        return fmt.Errorf("%s: tls needs exactly one of certFile and certEnv", source)
+    }
+    if (c.KeyFile == "") == (c.KeyEnv == "") {
+        return fmt.Errorf("%s: tls needs exactly one of keyFile and keyEnv", source)
+    }
+    return nil
+}
+
+// validateSymbols checks the asset aliases of an exchange, keyed by
+// canonical symbol
+func validateSymbols(exchange string, aliases map[string]string) error {
+    for asset, alias := range aliases {
+        if asset == "" || asset != symbols.Canonical(asset) {
+            return fmt.Errorf("exchange %s: symbols key %q is not a canonical asset symbol", exchange, asset)
+        }
+        if strings.TrimSpace(alias) == "" {
+            return fmt.Errorf("exchange %s: empty symbol alias for %s", exchange, asset)
+        }
+    }
+    return nil
+}
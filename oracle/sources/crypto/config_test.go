@@ -0,0 +1,147 @@
+package crypto
+
+import (
+    "strings"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+)
+
+const (
+    testWETH = "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
+    testUSDC = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+    testPool = "0x8ad599c3A0ff1De082011EFDDc58f1908eb6e6D8"
+)
+
+func TestValidateDEXPools(t *testing.T) {
+    base := &common.BaseConfig{
+        Assets: common.AssetConfig{
+            "ETH":  {Name: "Ethereum", Chains: map[string]common.ChainAssetInfo{"1": {Type: "native", Address: testWETH}}},
+            "USDC": {Name: "USD Coin", Chains: map[string]common.ChainAssetInfo{"1": {Type: "token", Address: testUSDC}}},
+        },
+    }
+
+    pair := func(pool common.DEXPool) *common.PairConfig {
+        return &common.PairConfig{
+            BaseCurrency:  "ETH",
+            QuoteCurrency: "USDC",
+            Sources: common.SourcesConfig{
+                DEX: common.DEXSourceConfig{Enabled: true, Pools: []common.DEXPool{pool}},
+            },
+        }
+    }
+
+    // Token order in the pool does not matter and addresses are case-insensitive
+    valid := common.DEXPool{Chain: "1", Address: testPool, Token0: strings.ToLower(testUSDC), Token1: testWETH}
+    if err := validateDEXPools(base, "ETHUSDC", pair(valid), pair(valid).Sources.DEX); err != nil {
+        t.Errorf("Expected valid pool, got %v", err)
+    }
+
+    wrongToken := common.DEXPool{Chain: "1", Address: testPool, Token0: testUSDC, Token1: testPool}
+    if err := validateDEXPools(base, "ETHUSDC", pair(wrongToken), pair(wrongToken).Sources.DEX); err == nil {
+        t.Error("Expected error for pool with mismatched token, got nil")
+    }
+
+    unknownChain := common.DEXPool{Chain: "56", Address: testPool, Token0: testUSDC, Token1: testWETH}
+    if err := validateDEXPools(base, "ETHUSDC", pair(unknownChain), pair(unknownChain).Sources.DEX); err == nil {
+        t.Error("Expected error for chain without asset addresses, got nil")
+    }
+
+    badAddress := common.DEXPool{Chain: "1", Address: "0x1234", Token0: testUSDC, Token1: testWETH}
+    if err := validateDEXPools(base, "ETHUSDC", pair(badAddress), pair(badAddress).Sources.DEX); err == nil {
+        t.Error("Expected error for invalid pool address, got nil")
+    }
+}
+
+func TestValidateQuoteAssets(t *testing.T) {
+    base := &common.BaseConfig{
+        QuoteClasses: map[string]common.QuoteClass{
+            "USD": {Members: map[string]common.QuoteMember{
+                "USDT": {Feed: "USDTUSD"},
+                "USDC": {},
+            }},
+        },
+    }
+    pair := func(quotes map[string]string) *common.PairConfig {
+        return &common.PairConfig{BaseCurrency: "BTC", QuoteCurrency: "USD", QuoteAssets: quotes}
+    }
+
+    if err := validateQuoteAssets(base, "BTCUSD", pair(map[string]string{"binance": "USDT", "coinbase": "USD"})); err != nil {
+        t.Errorf("Expected valid quote assets, got %v", err)
+    }
+    if err := validateQuoteAssets(base, "BTCUSD", pair(map[string]string{"binance": "DAI"})); err == nil {
+        t.Error("Expected error for asset outside the quote class, got nil")
+    }
+    if err := validateQuoteAssets(base, "USDTUSD", pair(map[string]string{"binance": "USDT"})); err == nil {
+        t.Error("Expected error for feed converting itself, got nil")
+    }
+}
+
+func TestDEXPoolsQuotedInClassMember(t *testing.T) {
+    base := &common.BaseConfig{
+        Assets: common.AssetConfig{
+            "ETH":  {Name: "Ethereum", Chains: map[string]common.ChainAssetInfo{"1": {Type: "native", Address: testWETH}}},
+            "USDC": {Name: "USD Coin", Chains: map[string]common.ChainAssetInfo{"1": {Type: "token", Address: testUSDC}}},
+        },
+        QuoteClasses: map[string]common.QuoteClass{
+            "USD": {Members: map[string]common.QuoteMember{"USDC": {Feed: "USDCUSD", MaxAdjustment: 0.05}}},
+        },
+    }
+    pool := common.DEXPool{Chain: "1", Exchange: "uniswap_v3", Address: testPool, Token0: testUSDC, Token1: testWETH}
+    pair := &common.PairConfig{BaseCurrency: "ETH", QuoteCurrency: "USD", Sources: common.SourcesConfig{
+        DEX: common.DEXSourceConfig{Enabled: true, Pools: []common.DEXPool{pool}},
+    }}
+
+    // USD has no address: the pool must name the member it trades against
+    if err := validateDEXPools(base, "ETHUSD", pair, pair.Sources.DEX); err == nil {
+        t.Error("Expected error for a pool quoted in the USD unit, got nil")
+    }
+    pair.Sources.DEX.Pools[0].Quote = "USDC"
+    if err := validateDEXPools(base, "ETHUSD", pair, pair.Sources.DEX); err != nil {
+        t.Errorf("Expected valid USDC-quoted pool, got %v", err)
+    }
+
+    // The pool's prices are converted at the USDC peg rate
+    a := NewCryptoAggregator(base)
+    rate := 0.97
+    a.SetFeedLookup(func(symbol string) (*common.AggregateResult, bool) {
+        if symbol == "USDCUSD" {
+            return &common.AggregateResult{PricePoint: common.PricePoint{Price: rate, Timestamp: time.Now()}}, true
+        }
+        return nil, false
+    })
+    jobs, skipped := a.sourceJobs(base, "ETHUSD", pair, pair.Sources, "")
+    if len(jobs) != 1 || len(skipped) != 0 || jobs[0].scale != 0.97 || jobs[0].source.Quote != "USDC" || jobs[0].source.QuoteRate != 0.97 {
+        t.Errorf("Expected the pool converted at 0.97, got %+v, skipped %v", jobs, skipped)
+    }
+
+    // and left out while the depeg is beyond the maximum adjustment
+    rate = 0.9
+    if jobs, skipped := a.sourceJobs(base, "ETHUSD", pair, pair.Sources, ""); len(jobs) != 0 || len(skipped) != 1 {
+        t.Errorf("Expected the pool skipped at a 0.9 peg rate, got %d jobs, skipped %v", len(jobs), skipped)
+    }
+}
+
+func TestConfigGroups(t *testing.T) {
+    snapshot := &ConfigSnapshot{Pairs: map[string]*common.PairConfig{
+        "ETHUSD":  {Groups: []string{"lending-a", "majors"}},
+        "BTCUSD":  {Groups: []string{"majors"}},
+        "LINKUSD": {},
+    }}
+    if members := snapshot.Group("majors"); len(members) != 2 || members[0] != "BTCUSD" || members[1] != "ETHUSD" {
+        t.Errorf("Expected BTCUSD and ETHUSD, got %v", members)
+    }
+    if groups := snapshot.Groups(); len(groups) != 2 || len(groups["lending-a"]) != 1 {
+        t.Errorf("Expected two groups, got %v", groups)
+    }
+
+    if err := validateGroups("ETHUSD", []string{"lending-a", "majors"}); err != nil {
+        t.Errorf("Expected valid groups, got %v", err)
+    }
+    for _, groups := range [][]string{{""}, {"a/b"}, {"majors", "majors"}} {
+        if err := validateGroups("ETHUSD", groups); err == nil {
+            t.Errorf("Expected %q to be rejected", groups)
+        }
+    }
+}
@@ -0,0 +1,129 @@
+package crypto
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/evm"
+    "yetaXYZ/oracle/sources/dex"
+    "yetaXYZ/oracle/symbols"
+)
+
+// defaultPoolTimeout bounds on-chain pool reads when the DEX has no timeout
+const defaultPoolTimeout = 5 * time.Second
+
+// poolReader returns the on-chain reader for a chain, creating it on first use
+func (a *CryptoAggregator) poolReader(chainID string) (*evm.PoolReader, error) {
+    a.readersMu.Lock()
+    defer a.readersMu.Unlock()
+
+    if reader, ok := a.readers[chainID]; ok {
+        return reader, nil
+    }
+
+    chain, ok := a.config.Chains[chainID]
+    if !ok || len(chain.RPCUrls) == 0 {
+        return nil, fmt.Errorf("no RPC endpoint configured for chain %s", chainID)
+    }
+
+    reader := evm.NewPoolReader(evm.NewChainClient(chainID, chain.RPCUrls, a.client))
+    a.readers[chainID] = reader
+    return reader, nil
+}
+
+// fetchPoolPrice reads the base asset price directly from a DEX pool contract,
+// normalizing reserves with the tokens' on-chain decimals
+func (a *CryptoAggregator) fetchPoolPrice(ctx context.Context, pair *common.PairConfig, pool common.DEXPool) (*common.PricePoint, error) {
+    baseAsset, ok := a.config.Assets[pair.BaseCurrency]
+    if !ok {
+        return nil, fmt.Errorf("asset config not found for symbol: %s", pair.BaseCurrency)
+    }
+    baseToken, ok := baseAsset.AddressOn(pool.Chain)
+    if !ok {
+        return nil, fmt.Errorf("no address for %s on chain %s", pair.BaseCurrency, pool.Chain)
+    }
+
+    reader, err := a.poolReader(pool.Chain)
+    if err != nil {
+        return nil, err
+    }
+
+    details := a.config.Exchanges.DEX[pool.Exchange]
+    timeout := defaultPoolTimeout
+    if details.Timeout > 0 {
+        timeout = time.Duration(details.Timeout) * time.Millisecond
+    }
+    ctx, cancel := context.WithTimeout(ctx, timeout)
+    defer cancel()
+
+    var price float64
+    switch dex.Protocol(pool.Exchange, details) {
+    case dex.ProtocolUniswapV2:
+        price, err = reader.V2Price(ctx, pool.Address, baseToken)
+    default:
+        price, err = reader.V3Price(ctx, pool.Address, baseToken)
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    return &common.PricePoint{
+        Price:     price,
+        Volume:    0, // pool state reads carry no traded volume
+        Timestamp: time.Now(),
+    }, nil
+}
+
+// PoolSourceName identifies a pool in source attributions
+func PoolSourceName(pool common.DEXPool) string {
+    return pool.Exchange + ":" + pool.Address
+}
+
+// subgraphSourceName identifies a multi-pool subgraph source in source
+// attributions
+func subgraphSourceName(source common.SubgraphPoolSource) string {
+    return source.Exchange + ":pools"
+}
+
+// fetchSubgraphPrice prices the base asset as the liquidity-weighted median
+// of a subgraph source's pools holding the DEX's minimum liquidity
+func (a *CryptoAggregator) fetchSubgraphPrice(ctx context.Context, pair *common.PairConfig, source common.SubgraphPoolSource) (*common.PricePoint, error) {
+    details := a.config.Exchanges.DEX[source.Exchange]
+    baseToken, err := symbols.New(a.config).Address(details.Chain, pair.BaseCurrency)
+    if err != nil {
+        return nil, err
+    }
+
+    timeout := defaultPoolTimeout
+    if details.Timeout > 0 {
+        timeout = time.Duration(details.Timeout) * time.Millisecond
+    }
+    ctx, cancel := context.WithTimeout(ctx, timeout)
+    defer cancel()
+
+    var pools []dex.Pool
+    if source.TopPools > 0 {
+        quoteToken, err := symbols.New(a.config).Address(details.Chain, poolQuote(pair, source.Quote))
+        if err != nil {
+            return nil, err
+        }
+        pools, err = dex.TopPools(ctx, a.client, source.Exchange, details, baseToken, quoteToken, source.TopPools)
+        if err != nil {
+            return nil, err
+        }
+    } else if pools, err = dex.Pools(ctx, a.client, source.Exchange, details, source.Pools); err != nil {
+        return nil, err
+    }
+
+    price, _, err := dex.MedianPrice(pools, baseToken, float64(details.MinLiquidity))
+    if err != nil {
+        return nil, fmt.Errorf("%s: %v", subgraphSourceName(source), err)
+    }
+    return &common.PricePoint{
+        Price:     price,
+        Volume:    0, // pool states carry no traded volume
+        Timestamp: time.Now(),
+    }, nil
+}
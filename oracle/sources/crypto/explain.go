@@ -0,0 +1,296 @@
+package crypto
+
+import (
+    "fmt"
+    "sort"
+
+    "yetaXYZ/oracle/common"
+)
+
+// Source statuses of an explanation
+const (
+    SourceUsed        = "used"        // in the weighted median
+    SourceRejected    = "rejected"    // outside the IQR fences
+    SourceAbandoned   = "abandoned"   // exceeded the latency budget
+    SourceUnavailable = "unavailable" // fetched without a price
+    SourceSkipped     = "skipped"     // fallback tier not consulted
+)
+
+// Explanation is a step-by-step trace of how a round's price was derived
+// from its source prices
+type Explanation struct {
+    Symbol        string  `json:"symbol"`
+    RoundID       uint64  `json:"roundId"`
+    Price         float64 `json:"price"`
+    ConfigVersion string  `json:"configVersion"`
+    // ConfigChanged marks a round aggregated under another config version;
+    // the trace uses the current config and may not reproduce the price
+    ConfigChanged  bool                `json:"configChanged,omitempty"`
+    FallbackReason string              `json:"fallbackReason,omitempty"`
+    Sources        []SourceExplanation `json:"sources"`
+    Outliers       OutlierExplanation  `json:"outliers"`
+    Median         MedianExplanation   `json:"median"`
+    Transform      *TransformStep      `json:"transform,omitempty"`
+    // Clamped is set when the pair's bounds replaced the price
+    Clamped *common.Clamped `json:"clamped,omitempty"`
+    // Reproduced reports whether replaying the steps yields the round's
+    // aggregated price
+    Reproduced bool `json:"reproduced"`
+}
+
+// SourceExplanation is the treatment of one configured source in a round
+type SourceExplanation struct {
+    Source string `json:"source"`
+    Tier   string `json:"tier,omitempty"`
+    Quote  string `json:"quote,omitempty"`
+    // QuoteRate converted the price from Quote into the pair's quote
+    QuoteRate float64 `json:"quoteRate,omitempty"`
+    Status    string  `json:"status"`
+    Reason    string  `json:"reason,omitempty"`
+    // Raw values and weights, for sources with a price
+    Price            float64 `json:"price,omitempty"`
+    Volume           float64 `json:"volume,omitempty"`
+    AgeMs            int64   `json:"ageMs,omitempty"` // before the round's timestamp
+    StaticWeight     float64 `json:"staticWeight,omitempty"`
+    VolumeMultiplier float64 `json:"volumeMultiplier,omitempty"`
+    Weight           float64 `json:"weight,omitempty"`
+}
+
+// OutlierExplanation is the IQR rejection step
+type OutlierExplanation struct {
+    Applied    bool    `json:"applied"`
+    Reason     string  `json:"reason,omitempty"`
+    Multiplier float64 `json:"multiplier,omitempty"`
+    Q1         float64 `json:"q1,omitempty"`
+    Median     float64 `json:"median,omitempty"`
+    Q3         float64 `json:"q3,omitempty"`
+    IQR        float64 `json:"iqr,omitempty"`
+    // IQRFloored marks an IQR raised to its floor fraction of the median
+    IQRFloored bool    `json:"iqrFloored,omitempty"`
+    Low        float64 `json:"low,omitempty"`
+    High       float64 `json:"high,omitempty"`
+    // ClosestKept marks a round where the fences left fewer than the
+    // minimum sources and the points closest to the median were kept
+    ClosestKept bool `json:"closestKept,omitempty"`
+}
+
+// MedianExplanation is the weighted median walk over the kept sources
+type MedianExplanation struct {
+    TotalWeight float64      `json:"totalWeight"`
+    Half        float64      `json:"half"`
+    Walk        []MedianStep `json:"walk"`
+    Selected    string       `json:"selected"`
+    Price       float64      `json:"price"`
+    Reason      string       `json:"reason"`
+}
+
+// MedianStep is one source in the cumulative weight walk, by ascending price
+type MedianStep struct {
+    Source     string  `json:"source"`
+    Price      float64 `json:"price"`
+    Weight     float64 `json:"weight"`
+    Cumulative float64 `json:"cumulative"`
+}
+
+// TransformStep is the pair's output transform applied to the median
+type TransformStep struct {
+    Expression string  `json:"expression"`
+    Input      float64 `json:"input"`
+    Output     float64 `json:"output"`
+}
+
+// Explain traces how result was aggregated for pair: each configured
+// source's treatment, the IQR fences, the static and volume-boosted weights
+// and the weighted median walk. The steps are replayed from the round's
+// source prices under pair, which currentVersion identifies.
+func Explain(pair *common.PairConfig, result *common.AggregateResult, currentVersion string) *Explanation {
+    e := &Explanation{
+        Symbol:         result.Symbol,
+        RoundID:        result.RoundID,
+        Price:          result.Price,
+        ConfigVersion:  result.ConfigVersion,
+        ConfigChanged:  result.ConfigVersion != currentVersion,
+        FallbackReason: result.FallbackReason,
+    }
+
+    // Weights are computed over every source with a price, kept or not
+    all := append(append([]common.SourcePrice(nil), result.Sources...), result.Rejected...)
+    prices := make([]*common.PricePoint, len(all))
+    for i := range all {
+        prices[i] = &all[i].PricePoint
+    }
+    weights := sourceWeights(pair, all)
+    totalVolume := 0.0
+    for _, s := range all {
+        totalVolume += s.Volume
+    }
+
+    // Prices beyond the deviation guard were rejected before the fences
+    candidates, dropped := rejectDeviating(prices, pair.MaxSourceDeviation, pair.MinimumSources)
+    deviating := make(map[int]bool, len(dropped))
+    for _, i := range dropped {
+        deviating[i] = true
+    }
+    candidatePrices := make([]*common.PricePoint, len(candidates))
+    candidateWeights := make([]float64, len(candidates))
+    for j, i := range candidates {
+        candidatePrices[j], candidateWeights[j] = prices[i], weights[i]
+    }
+    e.Outliers = explainOutliers(pair, candidatePrices, candidateWeights, len(result.Sources))
+    e.Median = explainMedian(result.Sources, weights[:len(result.Sources)])
+
+    aggregated := result.Price
+    if result.RawPrice != 0 {
+        aggregated = result.RawPrice
+    }
+    if pair.Transform != "" && result.RawPrice != 0 {
+        output := result.Price
+        if result.Clamped != nil {
+            output = result.Clamped.Price
+        }
+        e.Transform = &TransformStep{Expression: pair.Transform, Input: result.RawPrice, Output: output}
+    }
+    e.Clamped = result.Clamped
+    e.Reproduced = len(result.Sources) > 0 && e.Median.Price == aggregated
+
+    // Walk the configured sources in fetch order
+    priced := make(map[string]int, len(all))
+    for i, s := range all {
+        priced[configuredTier(s.Tier)+"/"+s.Source] = i
+    }
+    abandoned := make(map[string]bool, len(result.Abandoned))
+    for _, source := range result.Abandoned {
+        abandoned[source] = true
+    }
+    for t, tier := range pairTiers(pair) {
+        label := tierLabel(t)
+        for _, source := range tierSources(tier) {
+            s := SourceExplanation{Source: source, Tier: label}
+            i, ok := priced[label+"/"+source]
+            switch {
+            case ok:
+                sp := all[i]
+                s.Tier = sp.Tier
+                s.Quote, s.QuoteRate = sp.Quote, sp.QuoteRate
+                s.Price, s.Volume = sp.Price, sp.Volume
+                if !sp.Timestamp.IsZero() {
+                    s.AgeMs = result.Timestamp.Sub(sp.Timestamp).Milliseconds()
+                }
+                s.StaticWeight = sourceWeight(pair, source) * tierWeight(pair, sp)
+                s.VolumeMultiplier = 1
+                if totalVolume > 0 {
+                    s.VolumeMultiplier = volumeMultiplier(pair.Aggregation, sp.Volume/totalVolume)
+                }
+                s.Weight = weights[i]
+                if i < len(result.Sources) {
+                    s.Status = SourceUsed
+                } else {
+                    s.Status = SourceRejected
+                    s.Reason = e.Outliers.rejection(sp.Price)
+                    if deviating[i] {
+                        s.Reason = fmt.Sprintf("more than %g from the median of the round's prices", pair.MaxSourceDeviation)
+                    }
+                }
+            case abandoned[source]:
+                s.Status = SourceAbandoned
+                s.Reason = fmt.Sprintf("no response within the %s latency budget", pair.LatencyBudget())
+            case t > 0 && result.FallbackReason == "":
+                s.Status = SourceSkipped
+                s.Reason = "primary sources sufficed"
+            default:
+                s.Status = SourceUnavailable
+                s.Reason = "fetch failed, under maintenance or quote conversion unavailable"
+            }
+            e.Sources = append(e.Sources, s)
+        }
+    }
+    return e
+}
+
+// explainOutliers recomputes the fences of rejectOutliers; kept is the
+// number of sources the round kept
+func explainOutliers(pair *common.PairConfig, prices []*common.PricePoint, weights []float64, kept int) OutlierExplanation {
+    multiplier := pair.Aggregation.IQRMultiplier
+    if multiplier <= 0 {
+        return OutlierExplanation{Reason: "outlier rejection disabled"}
+    }
+    if len(prices) < 3 {
+        return OutlierExplanation{Reason: fmt.Sprintf("%d sources, need at least 3", len(prices)), Multiplier: multiplier}
+    }
+
+    order := make([]int, len(prices))
+    for i := range order {
+        order[i] = i
+    }
+    sort.SliceStable(order, func(i, j int) bool { return prices[order[i]].Price < prices[order[j]].Price })
+    o := OutlierExplanation{
+        Applied:    true,
+        Multiplier: multiplier,
+        Q1:         weightedQuantile(prices, weights, order, 0.25),
+        Median:     weightedQuantile(prices, weights, order, 0.5),
+        Q3:         weightedQuantile(prices, weights, order, 0.75),
+    }
+    o.IQR = o.Q3 - o.Q1
+    if floor := o.Median * minIQRFraction; o.IQR < floor {
+        o.IQR = floor
+        o.IQRFloored = true
+    }
+    o.Low, o.High = o.Q1-multiplier*o.IQR, o.Q3+multiplier*o.IQR
+
+    within := 0
+    for _, p := range prices {
+        if p.Price >= o.Low && p.Price <= o.High {
+            within++
+        }
+    }
+    o.ClosestKept = within < pair.MinimumSources && kept == pair.MinimumSources
+    return o
+}
+
+// rejection explains why a price was rejected
+func (o OutlierExplanation) rejection(price float64) string {
+    switch {
+    case price < o.Low:
+        return fmt.Sprintf("below the low fence %g", o.Low)
+    case price > o.High:
+        return fmt.Sprintf("above the high fence %g", o.High)
+    case o.ClosestKept:
+        return "too few sources within the fences; farther from the median than those kept"
+    }
+    return "within the current fences"
+}
+
+// explainMedian replays calculateMedian over the kept sources
+func explainMedian(sources []common.SourcePrice, weights []float64) MedianExplanation {
+    m := MedianExplanation{}
+    if len(sources) == 0 {
+        m.Reason = "no sources kept"
+        return m
+    }
+
+    order := make([]int, len(sources))
+    for i := range sources {
+        order[i] = i
+        m.TotalWeight += weights[i]
+    }
+    sort.SliceStable(order, func(i, j int) bool { return sources[order[i]].Price < sources[order[j]].Price })
+    m.Half = m.TotalWeight / 2
+
+    selected := order[len(order)-1]
+    found := false
+    cumulative := 0.0
+    for _, i := range order {
+        cumulative += weights[i]
+        m.Walk = append(m.Walk, MedianStep{Source: sources[i].Source, Price: sources[i].Price, Weight: weights[i], Cumulative: cumulative})
+        if !found && cumulative > m.Half {
+            selected, found = i, true
+        }
+    }
+    m.Selected, m.Price = sources[selected].Source, sources[selected].Price
+    if found {
+        m.Reason = fmt.Sprintf("first price by ascending order whose cumulative weight exceeds half of %g", m.TotalWeight)
+    } else {
+        m.Reason = "no cumulative weight exceeded half; the highest price was selected"
+    }
+    return m
+}
@@ -0,0 +1,87 @@
+package crypto
+
+import (
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+)
+
+func TestExplainReplaysRound(t *testing.T) {
+    pair := &common.PairConfig{
+        BaseCurrency:   "ETH",
+        QuoteCurrency:  "USDT",
+        MinimumSources: 2,
+        Sources: common.SourcesConfig{
+            CEX: common.CEXSourceConfig{Enabled: true, Weight: 1, Exchanges: []string{"binance", "coinbase", "kraken", "okx"}},
+        },
+        FallbackTiers: []common.SourcesConfig{
+            {CEX: common.CEXSourceConfig{Enabled: true, Weight: 1, Exchanges: []string{"bitstamp"}}},
+        },
+        SourceWeights: map[string]float64{"binance": 2},
+        Aggregation:   common.AggregationParams{IQRMultiplier: 1.5, VolumeBoost: common.VolumeBoostLinear},
+    }
+
+    now := time.Now()
+    sources := []common.SourcePrice{
+        {Source: "binance", PricePoint: common.PricePoint{Price: 3000, Volume: 300, Timestamp: now.Add(-200 * time.Millisecond)}},
+        {Source: "coinbase", PricePoint: common.PricePoint{Price: 3001, Volume: 100, Timestamp: now}},
+        {Source: "kraken", PricePoint: common.PricePoint{Price: 3300, Volume: 100, Timestamp: now}},
+    }
+    prices := make([]*common.PricePoint, len(sources))
+    for i := range sources {
+        prices[i] = &sources[i].PricePoint
+    }
+    weights := sourceWeights(pair, sources)
+    kept, rejected := rejectOutliers(prices, weights, pair.Aggregation.IQRMultiplier, pair.MinimumSources)
+    if len(kept) != 2 || len(rejected) != 1 || rejected[0] != 2 {
+        t.Fatalf("Expected kraken to be rejected, got kept %v rejected %v", kept, rejected)
+    }
+    a := &CryptoAggregator{}
+    median := a.calculateMedian([]*common.PricePoint{prices[0], prices[1]}, []float64{weights[0], weights[1]})
+
+    result := &common.AggregateResult{
+        Symbol:        "ETHUSDT",
+        PricePoint:    common.PricePoint{Price: median.Price, Timestamp: now},
+        Sources:       sources[:2],
+        Rejected:      sources[2:],
+        RoundID:       7,
+        ConfigVersion: "v1",
+        Abandoned:     []string{"okx"},
+    }
+    e := Explain(pair, result, "v1")
+
+    if !e.Reproduced || e.ConfigChanged {
+        t.Errorf("Expected the round to be reproduced under the same config, got %+v", e)
+    }
+    if !e.Outliers.Applied || e.Outliers.High >= 3300 {
+        t.Errorf("Expected fences below kraken, got %+v", e.Outliers)
+    }
+    if e.Median.Selected != "binance" || e.Median.Price != 3000 || len(e.Median.Walk) != 2 {
+        t.Errorf("Expected binance to be selected by the walk, got %+v", e.Median)
+    }
+
+    want := map[string]string{
+        "binance":  SourceUsed,
+        "coinbase": SourceUsed,
+        "kraken":   SourceRejected,
+        "okx":      SourceAbandoned,
+        "bitstamp": SourceSkipped,
+    }
+    if len(e.Sources) != len(want) {
+        t.Fatalf("Expected %d sources, got %+v", len(want), e.Sources)
+    }
+    for _, s := range e.Sources {
+        if s.Status != want[s.Source] {
+            t.Errorf("%s: expected status %s, got %s", s.Source, want[s.Source], s.Status)
+        }
+    }
+    binance := e.Sources[0]
+    if binance.StaticWeight != 2 || binance.VolumeMultiplier != 1.6 || binance.Weight != 3.2 || binance.AgeMs != 200 {
+        t.Errorf("Unexpected binance weights or age: %+v", binance)
+    }
+
+    if e := Explain(pair, result, "v2"); !e.ConfigChanged {
+        t.Error("Expected a round under another config version to be flagged")
+    }
+}
@@ -0,0 +1,20 @@
+package crypto
+
+import (
+    "fmt"
+
+    "yetaXYZ/oracle/common"
+)
+
+// InsufficientSourcesError is returned for a round that obtained fewer
+// prices than the pair's minimum, with why each missing source failed
+type InsufficientSourcesError struct {
+    Symbol   string
+    Got      int
+    Need     int
+    Failures []common.SourceFailure
+}
+
+func (e *InsufficientSourcesError) Error() string {
+    return fmt.Sprintf("insufficient price sources for %s: got %d, need %d", e.Symbol, e.Got, e.Need)
+}
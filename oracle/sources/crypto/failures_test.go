@@ -0,0 +1,65 @@
+package crypto
+
+import (
+    "errors"
+    "io"
+    "net/http"
+    "strings"
+    "testing"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+)
+
+func TestAggregateReportsEachSourceFailure(t *testing.T) {
+    savedBase, savedPairs := BaseConfig, PairsConfig
+    defer func() { BaseConfig, PairsConfig = savedBase, savedPairs }()
+
+    BaseConfig = &common.BaseConfig{}
+    PairsConfig = map[string]*common.PairConfig{"BTCUSDT": {
+        BaseCurrency:   "BTC",
+        QuoteCurrency:  "USDT",
+        MinimumSources: 2,
+        Sources:        common.SourcesConfig{CEX: common.CEXSourceConfig{Enabled: true, Weight: 1, Exchanges: []string{"binance", "kraken"}}},
+        FallbackTiers:  []common.SourcesConfig{{CEX: common.CEXSourceConfig{Enabled: true, Weight: 1, Exchanges: []string{"coinbase"}}}},
+    }}
+
+    a := NewCryptoAggregator(BaseConfig)
+    a.SetEventBus(events.NewBus())
+    a.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+        status, body := http.StatusOK, `{"result": {"XXBTZUSD": {"c": ["65010", "1"], "v": ["5", "5"]}}}`
+        if r.URL.Host != "api.kraken.com" {
+            status, body = http.StatusServiceUnavailable, `{}`
+        }
+        return &http.Response{
+            StatusCode: status,
+            Status:     http.StatusText(status),
+            Header:     http.Header{"Content-Type": []string{"application/json"}},
+            Body:       io.NopCloser(strings.NewReader(body)),
+            Request:    r,
+        }, nil
+    })
+
+    _, err := a.Aggregate("BTCUSDT")
+    var insufficient *InsufficientSourcesError
+    if !errors.As(err, &insufficient) {
+        t.Fatalf("Expected an InsufficientSourcesError, got %v", err)
+    }
+    if insufficient.Got != 1 || insufficient.Need != 2 {
+        t.Errorf("Expected 1 of 2 sources, got %d of %d", insufficient.Got, insufficient.Need)
+    }
+    if err.Error() != "insufficient price sources for BTCUSDT: got 1, need 2" {
+        t.Errorf("Expected the message to be unchanged, got %q", err)
+    }
+
+    tiers := make(map[string]string)
+    for _, f := range insufficient.Failures {
+        if f.Reason == "" {
+            t.Errorf("Expected a reason for %s", f.Source)
+        }
+        tiers[f.Source] = f.Tier
+    }
+    if len(tiers) != 2 || tiers["binance"] != "" || tiers["coinbase"] != "fallback-1" {
+        t.Errorf("Expected binance and the coinbase fallback to fail, got %+v", insufficient.Failures)
+    }
+}
@@ -0,0 +1,51 @@
+package crypto
+
+import (
+    "testing"
+
+    "yetaXYZ/oracle/common"
+)
+
+func TestNeedsFallback(t *testing.T) {
+    pair := &common.PairConfig{MinimumSources: 2, MaxSourceDeviation: 0.01}
+    points := func(values ...float64) []*common.PricePoint {
+        out := make([]*common.PricePoint, 0, len(values))
+        for _, v := range values {
+            out = append(out, &common.PricePoint{Price: v})
+        }
+        return out
+    }
+
+    if reason := needsFallback(pair, points(100)); reason != fallbackInsufficient {
+        t.Errorf("Expected %q, got %q", fallbackInsufficient, reason)
+    }
+    if reason := needsFallback(pair, points(100, 100.5)); reason != "" {
+        t.Errorf("Expected no fallback, got %q", reason)
+    }
+    if reason := needsFallback(pair, points(100, 100.2, 105)); reason != fallbackDeviation {
+        t.Errorf("Expected %q, got %q", fallbackDeviation, reason)
+    }
+
+    // Without a deviation guard only the minimum matters
+    pair.MaxSourceDeviation = 0
+    if reason := needsFallback(pair, points(100, 150)); reason != "" {
+        t.Errorf("Expected no fallback, got %q", reason)
+    }
+}
+
+func TestRejectDeviating(t *testing.T) {
+    pair := &common.PairConfig{MinimumSources: 2, MaxSourceDeviation: 0.01}
+    prices := []*common.PricePoint{{Price: 105}, {Price: 100}, {Price: 100.2}, {Price: 100.1}}
+
+    // The deviating primary is dropped once the fallback tier outvotes it
+    kept, rejected := rejectSources(pair, prices, []float64{1, 1, 1, 1})
+    if len(kept) != 3 || len(rejected) != 1 || rejected[0] != 0 {
+        t.Errorf("Expected the 105 price rejected, got kept %v rejected %v", kept, rejected)
+    }
+
+    // Never below the minimum: the prices closest to the median are kept
+    pair.MinimumSources = 4
+    if kept, rejected := rejectDeviating(prices, pair.MaxSourceDeviation, pair.MinimumSources); len(kept) != 4 || len(rejected) != 0 {
+        t.Errorf("Expected every price kept at the minimum, got kept %v rejected %v", kept, rejected)
+    }
+}
@@ -0,0 +1,367 @@
+package crypto
+
+import (
+    "encoding/json"
+    "fmt"
+    "log"
+    "os"
+    "sort"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+)
+
+// maxAcknowledged is the number of acknowledged freezes kept for operators
+const maxAcknowledged = 100
+
+// Freeze triggers
+const (
+    FreezeDivergence = "divergence" // kept sources disagreed beyond maxDivergence
+    FreezeJump       = "jump"       // the price moved beyond maxJump from the last good round
+)
+
+// Freeze is a pair held at its last good round after a round tripped one of
+// its hard thresholds
+type Freeze struct {
+    Symbol    string  `json:"symbol"`
+    Trigger   string  `json:"trigger"`
+    Value     float64 `json:"value"` // divergence or jump of the tripping round
+    Threshold float64 `json:"threshold"`
+    Price     float64 `json:"price"` // price of the tripping round
+    // LastGood is the round served while frozen; nil when the pair froze
+    // on its first round, in which case it serves no value
+    LastGood *common.AggregateResult `json:"lastGood,omitempty"`
+    FrozenAt time.Time               `json:"frozenAt"`
+    // Rounds aggregated since the freeze, and the latest of their prices
+    // and divergences, so operators can tell when the market has settled
+    Rounds           int     `json:"rounds"`
+    LatestPrice      float64 `json:"latestPrice,omitempty"`
+    LatestDivergence float64 `json:"latestDivergence,omitempty"`
+    // Acknowledged is set once an operator resumed the pair
+    Acknowledged *Acknowledgement `json:"acknowledged,omitempty"`
+}
+
+// Acknowledgement records the operator who resumed a frozen pair and why
+type Acknowledgement struct {
+    Operator string    `json:"operator"`
+    Reason   string    `json:"reason"`
+    At       time.Time `json:"at"`
+}
+
+// FrozenError is returned for a pair that froze before it had a good round
+type FrozenError struct {
+    Symbol string
+}
+
+func (e *FrozenError) Error() string {
+    return fmt.Sprintf("%s is frozen with no good round to serve", e.Symbol)
+}
+
+// FreezeState is what carries freezes across restarts and to a promoted
+// standby: the standing freezes, the last good rounds jumps are judged
+// against and the acknowledged freezes
+type FreezeState struct {
+    Frozen       []Freeze                           `json:"frozen,omitempty"`
+    Good         map[string]*common.AggregateResult `json:"good,omitempty"`
+    Acknowledged []Freeze                           `json:"acknowledged,omitempty"`
+}
+
+// freezer tracks the last good round of pairs with a freeze config and the
+// pairs frozen awaiting acknowledgement
+type freezer struct {
+    mu           sync.Mutex
+    good         map[string]*common.AggregateResult
+    frozen       map[string]*Freeze
+    acknowledged []Freeze // oldest first
+
+    // path is the state file every change is saved to; empty keeps
+    // freezes in memory only
+    path   string
+    saveMu sync.Mutex
+}
+
+func newFreezer() *freezer {
+    return &freezer{
+        good:   make(map[string]*common.AggregateResult),
+        frozen: make(map[string]*Freeze),
+    }
+}
+
+// validateFreeze checks a pair's freeze thresholds
+func validateFreeze(symbol string, c *common.FreezeConfig) error {
+    if c == nil {
+        return nil
+    }
+    if c.MaxDivergence < 0 || c.MaxJump < 0 {
+        return fmt.Errorf("pair %s: freeze thresholds must not be negative", symbol)
+    }
+    if c.MaxDivergence == 0 && c.MaxJump == 0 {
+        return fmt.Errorf("pair %s: freeze needs maxDivergence or maxJump", symbol)
+    }
+    return nil
+}
+
+// check returns the freeze a round of symbol at price with the given
+// divergence falls under: the pair's standing freeze, updated with the
+// round, or a new one when the round trips a threshold of c. It returns
+// nil for a round that may be served, and whether the freeze is new. New
+// freezes are saved before the round is served.
+func (f *freezer) check(symbol string, c *common.FreezeConfig, price, divergence float64, now time.Time) (*Freeze, bool) {
+    fr, tripped := f.trip(symbol, c, price, divergence, now)
+    if tripped {
+        f.save()
+    }
+    return fr, tripped
+}
+
+// trip applies a round to the freeze state, see check
+func (f *freezer) trip(symbol string, c *common.FreezeConfig, price, divergence float64, now time.Time) (*Freeze, bool) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    if fr, ok := f.frozen[symbol]; ok {
+        fr.Rounds++
+        fr.LatestPrice, fr.LatestDivergence = price, divergence
+        out := *fr
+        return &out, false
+    }
+    if c == nil {
+        return nil, false
+    }
+
+    good := f.good[symbol]
+    fr := &Freeze{Symbol: symbol, Price: price, FrozenAt: now, LastGood: good}
+    switch {
+    case c.MaxDivergence > 0 && divergence > c.MaxDivergence:
+        fr.Trigger, fr.Value, fr.Threshold = FreezeDivergence, divergence, c.MaxDivergence
+    case c.MaxJump > 0 && good != nil && good.Price > 0 && abs(price-good.Price)/good.Price > c.MaxJump:
+        fr.Trigger, fr.Value, fr.Threshold = FreezeJump, abs(price-good.Price)/good.Price, c.MaxJump
+    default:
+        return nil, false
+    }
+    f.frozen[symbol] = fr
+    out := *fr
+    return &out, true
+}
+
+// record keeps result as the last good round of a pair with a freeze config
+func (f *freezer) record(c *common.FreezeConfig, result *common.AggregateResult) {
+    if c == nil {
+        return
+    }
+    f.mu.Lock()
+    f.good[result.Symbol] = result
+    f.mu.Unlock()
+    f.save()
+}
+
+// acknowledge resumes a frozen pair on an operator's authority. Unless
+// apply is set the freeze is only returned as acknowledging would leave it.
+func (f *freezer) acknowledge(symbol, operator, reason string, now time.Time, apply bool) (*Freeze, error) {
+    if reason == "" {
+        return nil, fmt.Errorf("acknowledging a freeze requires a reason")
+    }
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    fr, ok := f.frozen[symbol]
+    if !ok {
+        return nil, fmt.Errorf("%s is not frozen", symbol)
+    }
+    out := *fr
+    out.Acknowledged = &Acknowledgement{Operator: operator, Reason: reason, At: now}
+    if !apply {
+        return &out, nil
+    }
+
+    delete(f.frozen, symbol)
+    // The dislocation was accepted: the next round is judged afresh rather
+    // than against the value served while frozen
+    delete(f.good, symbol)
+    f.acknowledged = append(f.acknowledged, out)
+    if len(f.acknowledged) > maxAcknowledged {
+        f.acknowledged = f.acknowledged[len(f.acknowledged)-maxAcknowledged:]
+    }
+    return &out, nil
+}
+
+// state returns a copy of the freeze state
+func (f *freezer) state() FreezeState {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    state := FreezeState{
+        Frozen:       make([]Freeze, 0, len(f.frozen)),
+        Good:         make(map[string]*common.AggregateResult, len(f.good)),
+        Acknowledged: append([]Freeze{}, f.acknowledged...),
+    }
+    for _, fr := range f.frozen {
+        state.Frozen = append(state.Frozen, *fr)
+    }
+    sort.Slice(state.Frozen, func(i, j int) bool { return state.Frozen[i].Symbol < state.Frozen[j].Symbol })
+    for symbol, result := range f.good {
+        state.Good[symbol] = result
+    }
+    return state
+}
+
+// restore replaces the freeze state with state
+func (f *freezer) restore(state FreezeState) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.frozen = make(map[string]*Freeze, len(state.Frozen))
+    for i := range state.Frozen {
+        fr := state.Frozen[i]
+        f.frozen[fr.Symbol] = &fr
+    }
+    f.good = make(map[string]*common.AggregateResult, len(state.Good))
+    for symbol, result := range state.Good {
+        f.good[symbol] = result
+    }
+    f.acknowledged = append([]Freeze{}, state.Acknowledged...)
+}
+
+// load restores the freeze state saved in path, if any, and saves every
+// later change there
+func (f *freezer) load(path string) error {
+    data, err := os.ReadFile(path)
+    switch {
+    case os.IsNotExist(err):
+    case err != nil:
+        return fmt.Errorf("failed to read freeze state: %v", err)
+    default:
+        var state FreezeState
+        if err := json.Unmarshal(data, &state); err != nil {
+            return fmt.Errorf("failed to parse freeze state: %v", err)
+        }
+        f.restore(state)
+    }
+    f.saveMu.Lock()
+    f.path = path
+    f.saveMu.Unlock()
+    return nil
+}
+
+// save writes the freeze state to the state file, if any. A failed write
+// is logged; the freeze itself still holds in memory.
+func (f *freezer) save() {
+    f.saveMu.Lock()
+    defer f.saveMu.Unlock()
+    if f.path == "" {
+        return
+    }
+    data, err := json.Marshal(f.state())
+    if err == nil {
+        tmp := f.path + ".tmp"
+        if err = os.WriteFile(tmp, data, 0600); err == nil {
+            err = os.Rename(tmp, f.path)
+        }
+    }
+    if err != nil {
+        log.Printf("Freeze state not saved to %s: %v", f.path, err)
+    }
+}
+
+// list returns the standing freezes ordered by symbol and the acknowledged
+// ones, newest first
+func (f *freezer) list() ([]Freeze, []Freeze) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    frozen := make([]Freeze, 0, len(f.frozen))
+    for _, fr := range f.frozen {
+        frozen = append(frozen, *fr)
+    }
+    sort.Slice(frozen, func(i, j int) bool { return frozen[i].Symbol < frozen[j].Symbol })
+    acknowledged := make([]Freeze, 0, len(f.acknowledged))
+    for i := len(f.acknowledged) - 1; i >= 0; i-- {
+        acknowledged = append(acknowledged, f.acknowledged[i])
+    }
+    return frozen, acknowledged
+}
+
+// frozenRound returns the round to serve for a frozen pair: its last good
+// round flagged Frozen, or a FrozenError without one
+func frozenRound(fr *Freeze) (*common.AggregateResult, error) {
+    if fr.LastGood == nil {
+        return nil, &FrozenError{Symbol: fr.Symbol}
+    }
+    served := *fr.LastGood
+    served.Frozen = true
+    return &served, nil
+}
+
+// freezeAlert raises the alert of a new freeze
+func (a *CryptoAggregator) freezeAlert(fr *Freeze) {
+    message := fmt.Sprintf("%s frozen: %s of %.2f%% exceeds the %.2f%% limit at %v", fr.Symbol, fr.Trigger, fr.Value*100, fr.Threshold*100, fr.Price)
+    if fr.LastGood != nil {
+        message += fmt.Sprintf("; serving round %d at %v until an operator acknowledges", fr.LastGood.RoundID, fr.LastGood.Price)
+    } else {
+        message += "; no good round to serve until an operator acknowledges"
+    }
+    log.Printf("Freeze: %s", message)
+    a.bus.Publish(events.Event{
+        Type:      events.Alert,
+        Symbol:    fr.Symbol,
+        Timestamp: time.Now(),
+        Payload: &events.AlertPayload{
+            Severity: events.SeverityCritical,
+            Kind:     "feed_freeze",
+            Message:  message,
+        },
+    })
+}
+
+// Freezes returns the pairs frozen awaiting acknowledgement and the most
+// recently acknowledged freezes
+func (a *CryptoAggregator) Freezes() (frozen, acknowledged []Freeze) {
+    return a.freezes.list()
+}
+
+// PreviewAcknowledge returns the freeze of symbol as Acknowledge would
+// record it, without resuming the pair
+func (a *CryptoAggregator) PreviewAcknowledge(symbol, operator, reason string) (*Freeze, error) {
+    return a.freezes.acknowledge(symbol, operator, reason, time.Now(), false)
+}
+
+// Acknowledge resumes a frozen pair on an operator's authority, recording
+// the reason. The pair's next round is served if it trips no threshold;
+// the jump threshold is not applied to it.
+func (a *CryptoAggregator) Acknowledge(symbol, operator, reason string) (*Freeze, error) {
+    fr, err := a.freezes.acknowledge(symbol, operator, reason, time.Now(), true)
+    if err != nil {
+        return nil, err
+    }
+    a.freezes.save()
+    message := fmt.Sprintf("%s acknowledged the %s freeze of %s: %s", operator, fr.Trigger, symbol, reason)
+    log.Printf("Freeze: %s", message)
+    a.bus.Publish(events.Event{
+        Type:      events.Alert,
+        Symbol:    symbol,
+        Timestamp: time.Now(),
+        Payload: &events.AlertPayload{
+            Severity: events.SeverityWarning,
+            Kind:     "feed_freeze",
+            Message:  message,
+        },
+    })
+    return fr, nil
+}
+
+// SetFreezeState keeps freezes in a state file, so that pairs stay frozen
+// across restarts until acknowledged. Freezes saved there are restored.
+// Call before the first round.
+func (a *CryptoAggregator) SetFreezeState(path string) error {
+    return a.freezes.load(path)
+}
+
+// FreezeState returns the freezes and last good rounds, for a standby to
+// take over
+func (a *CryptoAggregator) FreezeState() FreezeState {
+    return a.freezes.state()
+}
+
+// RestoreFreezes takes over the freezes of another instance, such as the
+// leader a standby mirrored, in place of its own, and saves them
+func (a *CryptoAggregator) RestoreFreezes(state FreezeState) {
+    a.freezes.restore(state)
+    a.freezes.save()
+}
@@ -0,0 +1,170 @@
+package crypto
+
+import (
+    "fmt"
+    "io"
+    "net/http"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+)
+
+func TestFreezeOnDivergence(t *testing.T) {
+    savedBase, savedPairs := BaseConfig, PairsConfig
+    defer func() { BaseConfig, PairsConfig = savedBase, savedPairs }()
+
+    BaseConfig = &common.BaseConfig{}
+    PairsConfig = map[string]*common.PairConfig{"ETHUSD": {
+        BaseCurrency:   "ETH",
+        QuoteCurrency:  "USD",
+        MinimumSources: 2,
+        Sources:        common.SourcesConfig{CEX: common.CEXSourceConfig{Enabled: true, Weight: 1, Exchanges: []string{"binance", "kraken"}}},
+        Freeze:         &common.FreezeConfig{MaxDivergence: 0.1, MaxJump: 0.3},
+    }}
+    binance, kraken := "3000", "3002"
+    a := NewCryptoAggregator(BaseConfig)
+    bus := events.NewBus()
+    alerts := bus.Subscribe(16, events.Alert)
+    a.SetEventBus(bus)
+    a.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+        body := fmt.Sprintf(`{"lastPrice": "%s", "volume": "10"}`, binance)
+        if r.URL.Host == "api.kraken.com" {
+            body = fmt.Sprintf(`{"result": {"XETHZUSD": {"c": ["%s", "1"], "v": ["5", "5"]}}}`, kraken)
+        }
+        return &http.Response{
+            StatusCode: http.StatusOK,
+            Header:     http.Header{"Content-Type": []string{"application/json"}},
+            Body:       io.NopCloser(strings.NewReader(body)),
+            Request:    r,
+        }, nil
+    })
+    aggregate := func() *common.AggregateResult {
+        result, err := a.Aggregate("ETHUSD")
+        if err != nil {
+            t.Fatal(err)
+        }
+        return result
+    }
+
+    good := aggregate()
+    if good.Frozen || good.RoundID != 1 {
+        t.Fatalf("Expected a served first round, got %+v", good)
+    }
+
+    // Kraken breaks away: the pair freezes at the good round
+    kraken = "4000"
+    frozen := aggregate()
+    if !frozen.Frozen || frozen.RoundID != good.RoundID || frozen.Price != good.Price {
+        t.Fatalf("Expected round %d served frozen, got %+v", good.RoundID, frozen)
+    }
+    if alert := (<-alerts.C).Payload.(*events.AlertPayload); alert.Kind != "feed_freeze" || alert.Severity != events.SeverityCritical {
+        t.Errorf("Expected a critical freeze alert, got %+v", alert)
+    }
+
+    // Settled prices keep it frozen until acknowledged
+    kraken = "3002"
+    if result := aggregate(); !result.Frozen {
+        t.Fatalf("Expected the pair to stay frozen, got %+v", result)
+    }
+    standing, _ := a.Freezes()
+    if len(standing) != 1 || standing[0].Trigger != FreezeDivergence || standing[0].Rounds != 1 || standing[0].LatestPrice != good.Price {
+        t.Fatalf("Expected one divergence freeze with one later round at %v, got %+v", good.Price, standing)
+    }
+
+    if _, err := a.Acknowledge("ETHUSD", "alice", ""); err == nil {
+        t.Fatal("Expected an acknowledgement without a reason to be refused")
+    }
+    if _, err := a.PreviewAcknowledge("ETHUSD", "alice", "kraken feed glitch"); err != nil {
+        t.Fatal(err)
+    }
+    if result := aggregate(); !result.Frozen {
+        t.Fatal("Expected a preview to leave the pair frozen")
+    }
+    if _, err := a.Acknowledge("ETHUSD", "alice", "kraken feed glitch"); err != nil {
+        t.Fatal(err)
+    }
+    if result := aggregate(); result.Frozen || result.RoundID != good.RoundID+1 {
+        t.Fatalf("Expected round %d served after the acknowledgement, got %+v", good.RoundID+1, result)
+    }
+    standing, acknowledged := a.Freezes()
+    if len(standing) != 0 || len(acknowledged) != 1 || acknowledged[0].Acknowledged.Operator != "alice" || acknowledged[0].Acknowledged.Reason != "kraken feed glitch" {
+        t.Fatalf("Expected the freeze acknowledged by alice, got %+v / %+v", standing, acknowledged)
+    }
+    if _, err := a.Acknowledge("ETHUSD", "alice", "again"); err == nil {
+        t.Error("Expected acknowledging a pair that is not frozen to fail")
+    }
+
+    // A move beyond maxJump freezes too; acknowledging it accepts the new level
+    binance, kraken = "4500", "4502"
+    if result := aggregate(); !result.Frozen {
+        t.Fatalf("Expected a jump freeze, got %+v", result)
+    }
+    if standing, _ := a.Freezes(); len(standing) != 1 || standing[0].Trigger != FreezeJump {
+        t.Fatalf("Expected one jump freeze, got %+v", standing)
+    }
+    if _, err := a.Acknowledge("ETHUSD", "bob", "ETH repriced on ETF news"); err != nil {
+        t.Fatal(err)
+    }
+    if result := aggregate(); result.Frozen || result.Price < 4500 {
+        t.Fatalf("Expected the new level served after the acknowledgement, got %+v", result)
+    }
+}
+
+func TestFreezeStateSurvivesRestart(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "freezes.json")
+    c := &common.FreezeConfig{MaxJump: 0.1}
+    now := time.Now()
+
+    f := newFreezer()
+    if err := f.load(path); err != nil {
+        t.Fatal(err)
+    }
+    good := &common.AggregateResult{Symbol: "ETHUSD", PricePoint: common.PricePoint{Price: 3000}, RoundID: 7}
+    f.record(c, good)
+    f.record(c, &common.AggregateResult{Symbol: "BTCUSD", PricePoint: common.PricePoint{Price: 60000}, RoundID: 3})
+    if _, tripped := f.check("ETHUSD", c, 4000, 0, now); !tripped {
+        t.Fatal("Expected a jump freeze")
+    }
+
+    // A restarted instance still serves the good round frozen
+    restarted := newFreezer()
+    if err := restarted.load(path); err != nil {
+        t.Fatal(err)
+    }
+    fr, tripped := restarted.check("ETHUSD", c, 3000, 0, now)
+    if fr == nil || tripped || fr.LastGood == nil || fr.LastGood.RoundID != 7 {
+        t.Fatalf("Expected ETHUSD to stay frozen at round 7 after a restart, got %+v", fr)
+    }
+    // and judges jumps of the other pairs against their last good rounds
+    if _, tripped := restarted.check("BTCUSD", c, 70000, 0, now); !tripped {
+        t.Error("Expected BTCUSD's jump judged against its saved good round")
+    }
+
+    if _, err := restarted.acknowledge("ETHUSD", "alice", "verified", now, true); err != nil {
+        t.Fatal(err)
+    }
+    restarted.save()
+    again := newFreezer()
+    if err := again.load(path); err != nil {
+        t.Fatal(err)
+    }
+    frozen, acknowledged := again.list()
+    if len(frozen) != 1 || frozen[0].Symbol != "BTCUSD" || len(acknowledged) != 1 || acknowledged[0].Symbol != "ETHUSD" {
+        t.Errorf("Expected the acknowledgement saved, got %+v / %+v", frozen, acknowledged)
+    }
+}
+
+func TestValidateFreeze(t *testing.T) {
+    for _, c := range []*common.FreezeConfig{{}, {MaxDivergence: -0.1}, {MaxJump: -1, MaxDivergence: 0.1}} {
+        if err := validateFreeze("ETHUSD", c); err == nil {
+            t.Errorf("Expected %+v to be rejected", c)
+        }
+    }
+    if err := validateFreeze("ETHUSD", &common.FreezeConfig{MaxJump: 0.3}); err != nil {
+        t.Error(err)
+    }
+}
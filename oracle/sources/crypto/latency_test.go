@@ -0,0 +1,72 @@
+package crypto
+
+import (
+    "io"
+    "net/http"
+    "strings"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+)
+
+// roundTripFunc serves requests without a network
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestFetchTierAbandonsSlowSourcesAfterQuorum(t *testing.T) {
+    a := NewCryptoAggregator(&common.BaseConfig{})
+    a.SetEventBus(events.NewBus())
+    a.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+        body := ""
+        switch r.URL.Host {
+        case "api.binance.com":
+            body = `{"lastPrice": "65000", "volume": "10"}`
+        case "api.kraken.com":
+            body = `{"result": {"XXBTZUSD": {"c": ["65010", "1"], "v": ["5", "5"]}}}`
+        default:
+            // Coinbase hangs until the round gives up on it
+            <-r.Context().Done()
+            return nil, r.Context().Err()
+        }
+        return &http.Response{
+            StatusCode: http.StatusOK,
+            Header:     http.Header{"Content-Type": []string{"application/json"}},
+            Body:       io.NopCloser(strings.NewReader(body)),
+            Request:    r,
+        }, nil
+    })
+
+    pair := &common.PairConfig{
+        BaseCurrency:    "BTC",
+        QuoteCurrency:   "USDT",
+        MinimumSources:  2,
+        LatencyBudgetMs: 50,
+    }
+    tier := common.SourcesConfig{CEX: common.CEXSourceConfig{Enabled: true, Weight: 1, Exchanges: []string{"binance", "coinbase", "kraken"}}}
+
+    start := time.Now()
+    prices, sources, abandoned, failures := a.fetchTier(start.Add(pair.LatencyBudget()), nil, "BTCUSDT", pair, tier, "", 0)
+    if elapsed := time.Since(start); elapsed > time.Second {
+        t.Fatalf("Round waited %s for the slow source", elapsed)
+    }
+    if len(prices) != 2 || sources[0].Source != "binance" || sources[1].Source != "kraken" {
+        t.Errorf("Expected binance and kraken prices, got %+v", sources)
+    }
+    if len(abandoned) != 1 || abandoned[0] != "coinbase" {
+        t.Errorf("Expected coinbase abandoned, got %v", abandoned)
+    }
+    if len(failures) != 1 || failures[0].Source != "coinbase" || !strings.Contains(failures[0].Reason, "latency budget") {
+        t.Errorf("Expected coinbase's failure to name the latency budget, got %+v", failures)
+    }
+
+    // Without quorum the round keeps waiting rather than abandoning
+    pair.MinimumSources = 3
+    tier.CEX.Exchanges = []string{"binance", "kraken"}
+    _, _, abandoned, _ = a.fetchTier(time.Now().Add(pair.LatencyBudget()), nil, "BTCUSDT", pair, tier, "", 0)
+    if len(abandoned) != 0 {
+        t.Errorf("Expected nothing abandoned, got %v", abandoned)
+    }
+}
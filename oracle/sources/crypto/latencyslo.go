@@ -0,0 +1,249 @@
+package crypto
+
+import (
+    "fmt"
+    "log"
+    "math"
+    "sort"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+)
+
+// minLatencySamples is the number of fetches a source's p95 is judged on
+// at the least, so that a few slow fetches after a restart do not demote it
+const minLatencySamples = 20
+
+// tierDeprioritized labels primary exchanges fetched after the primary
+// tier, ahead of the fallback tiers, while they miss their latency SLO
+const tierDeprioritized = "deprioritized"
+
+// latencySLO returns the latency SLO of an exchange, nil without one
+func latencySLO(base *common.BaseConfig, exchange string) *common.LatencySLOConfig {
+    if base != nil {
+        if details, ok := base.Exchanges.CEX[exchange]; ok {
+            return details.LatencySLO
+        }
+    }
+    return nil
+}
+
+// validateLatencySLO checks an exchange's latency SLO
+func validateLatencySLO(exchange string, c *common.LatencySLOConfig) error {
+    if c.P95Ms <= 0 {
+        return fmt.Errorf("exchange %s: latencySlo p95Ms must be positive", exchange)
+    }
+    switch c.Action {
+    case "", common.SLOShortenTimeout, common.SLOFallback:
+    default:
+        return fmt.Errorf("exchange %s: unknown latencySlo action %q, want %s or %s", exchange, c.Action, common.SLOShortenTimeout, common.SLOFallback)
+    }
+    if c.TimeoutMs < 0 || c.CooldownSeconds < 0 {
+        return fmt.Errorf("exchange %s: latencySlo values must not be negative", exchange)
+    }
+    if c.Window != 0 && c.Window < minLatencySamples {
+        return fmt.Errorf("exchange %s: latencySlo window must be at least %d fetches", exchange, minLatencySamples)
+    }
+    return nil
+}
+
+// LatencyStats is an exchange's response latency against its SLO
+type LatencyStats struct {
+    Source  string  `json:"source"`
+    SLOMs   int     `json:"sloMs"`
+    P95Ms   float64 `json:"p95Ms"`   // over the recent fetches
+    Samples int     `json:"samples"` // recent fetches measured
+    // Deprioritized is set while the exchange is demoted for missing its
+    // SLO, with the action taken and until when
+    Deprioritized bool       `json:"deprioritized"`
+    Action        string     `json:"action,omitempty"`
+    TimeoutMs     int64      `json:"timeoutMs,omitempty"` // under shorten_timeout
+    Since         *time.Time `json:"since,omitempty"`
+    Until         *time.Time `json:"until,omitempty"`
+    Demotions     uint64     `json:"demotions"` // since start
+}
+
+// sourceLatency is the recent fetch latency of one exchange
+type sourceLatency struct {
+    stats   LatencyStats
+    samples []time.Duration
+}
+
+// latencyTracker keeps the rolling p95 fetch latency of exchanges with a
+// latency SLO and demotes those whose p95 exceeds it for a cooldown
+type latencyTracker struct {
+    mu      sync.Mutex
+    sources map[string]*sourceLatency
+}
+
+func newLatencyTracker() *latencyTracker {
+    return &latencyTracker{sources: make(map[string]*sourceLatency)}
+}
+
+// observe records a fetch of an exchange, returning its stats when the
+// fetch demoted it. Fetches while demoted are not recorded: the window
+// starts over once the cooldown ends.
+func (t *latencyTracker) observe(exchange string, slo *common.LatencySLOConfig, latency time.Duration, now time.Time) *LatencyStats {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    s := t.source(exchange, slo)
+    if s.stats.Deprioritized {
+        return nil
+    }
+
+    s.samples = append(s.samples, latency)
+    if window := slo.Samples(); len(s.samples) > window {
+        s.samples = s.samples[len(s.samples)-window:]
+    }
+    p95 := percentile95(s.samples)
+    s.stats.P95Ms = float64(p95) / float64(time.Millisecond)
+    s.stats.Samples = len(s.samples)
+    if len(s.samples) < minLatencySamples || p95 <= slo.Objective() {
+        return nil
+    }
+
+    since, until := now, now.Add(slo.Cooldown())
+    s.stats.Deprioritized = true
+    s.stats.Action = slo.SlowAction()
+    if s.stats.Action == common.SLOShortenTimeout {
+        s.stats.TimeoutMs = slo.Timeout().Milliseconds()
+    }
+    s.stats.Since, s.stats.Until = &since, &until
+    s.stats.Demotions++
+    stats := s.stats
+    return &stats
+}
+
+// demotion returns the SLO an exchange is currently demoted under, if
+// any, and reports whether a demotion was lifted: its cooldown ended or
+// its SLO was removed
+func (t *latencyTracker) demotion(exchange string, slo *common.LatencySLOConfig, now time.Time) (*common.LatencySLOConfig, bool) {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    s, ok := t.sources[exchange]
+    if !ok || !s.stats.Deprioritized {
+        return nil, false
+    }
+    if slo != nil && now.Before(*s.stats.Until) {
+        return slo, false
+    }
+    s.stats.Deprioritized = false
+    s.stats.Action, s.stats.TimeoutMs = "", 0
+    s.stats.Since, s.stats.Until = nil, nil
+    s.samples = nil
+    s.stats.P95Ms, s.stats.Samples = 0, 0
+    return nil, true
+}
+
+// source returns the record of an exchange; callers hold mu
+func (t *latencyTracker) source(exchange string, slo *common.LatencySLOConfig) *sourceLatency {
+    s, ok := t.sources[exchange]
+    if !ok {
+        s = &sourceLatency{stats: LatencyStats{Source: exchange}}
+        t.sources[exchange] = s
+    }
+    s.stats.SLOMs = slo.P95Ms
+    return s
+}
+
+// stats returns the records of every tracked exchange, demoted ones first
+func (t *latencyTracker) stats() []LatencyStats {
+    t.mu.Lock()
+    out := make([]LatencyStats, 0, len(t.sources))
+    for _, s := range t.sources {
+        out = append(out, s.stats)
+    }
+    t.mu.Unlock()
+
+    sort.Slice(out, func(i, j int) bool {
+        if out[i].Deprioritized != out[j].Deprioritized {
+            return out[i].Deprioritized
+        }
+        return out[i].Source < out[j].Source
+    })
+    return out
+}
+
+// percentile95 returns the 95th percentile of latencies by nearest rank
+func percentile95(latencies []time.Duration) time.Duration {
+    if len(latencies) == 0 {
+        return 0
+    }
+    sorted := append([]time.Duration(nil), latencies...)
+    sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+    return sorted[int(math.Ceil(0.95*float64(len(sorted))))-1]
+}
+
+// observeLatency records a fetch of an exchange with a latency SLO and
+// alerts when the fetch demoted it
+func (a *CryptoAggregator) observeLatency(base *common.BaseConfig, exchange string, latency time.Duration) {
+    slo := latencySLO(base, exchange)
+    if slo == nil {
+        return
+    }
+    stats := a.latency.observe(exchange, slo, latency, time.Now())
+    if stats == nil {
+        return
+    }
+
+    action := "fetched after the primary tier"
+    if stats.Action == common.SLOShortenTimeout {
+        action = fmt.Sprintf("fetched with a %dms timeout", stats.TimeoutMs)
+    }
+    message := fmt.Sprintf("%s p95 latency %.0fms over the last %d fetches exceeds its %dms SLO; %s until %s", exchange, stats.P95Ms, stats.Samples, stats.SLOMs, action, stats.Until.Format(time.RFC3339))
+    log.Printf("Latency SLO: %s", message)
+    a.bus.Publish(events.Event{
+        Type:      events.Alert,
+        Timestamp: time.Now(),
+        Payload: &events.AlertPayload{
+            Severity: events.SeverityWarning,
+            Kind:     "source_slow",
+            Message:  message,
+        },
+    })
+}
+
+// latencyDemotion returns the SLO an exchange is demoted under, nil when
+// it is not, logging the end of a demotion
+func (a *CryptoAggregator) latencyDemotion(base *common.BaseConfig, exchange string) *common.LatencySLOConfig {
+    slo, lifted := a.latency.demotion(exchange, latencySLO(base, exchange), time.Now())
+    if lifted {
+        log.Printf("Latency SLO: %s cooldown ended, measuring it again at full priority", exchange)
+    }
+    return slo
+}
+
+// deprioritize splits a pair's primary tier into the exchanges fetched at
+// full priority and those demoted to fallback for missing their latency
+// SLO, which keep the tier's CEX weight
+func (a *CryptoAggregator) deprioritize(base *common.BaseConfig, tier common.SourcesConfig) (common.SourcesConfig, common.SourcesConfig) {
+    primary, demoted := tier, tier
+    primary.CEX.Exchanges, demoted.CEX.Exchanges = nil, nil
+    demoted.DEX = common.DEXSourceConfig{}
+    for _, exchange := range tier.CEX.Exchanges {
+        if slo := a.latencyDemotion(base, exchange); slo != nil && slo.SlowAction() == common.SLOFallback {
+            demoted.CEX.Exchanges = append(demoted.CEX.Exchanges, exchange)
+            continue
+        }
+        primary.CEX.Exchanges = append(primary.CEX.Exchanges, exchange)
+    }
+    demoted.CEX.Enabled = tier.CEX.Enabled && len(demoted.CEX.Exchanges) > 0
+    return primary, demoted
+}
+
+// configuredTier maps the tier label a source price records to the label
+// of the configured tier it belongs to
+func configuredTier(label string) string {
+    if label == tierDeprioritized {
+        return tierLabel(0)
+    }
+    return label
+}
+
+// LatencyStats returns the latency of every exchange with a latency SLO,
+// demoted ones first
+func (a *CryptoAggregator) LatencyStats() []LatencyStats {
+    return a.latency.stats()
+}
@@ -0,0 +1,88 @@
+package crypto
+
+import (
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+)
+
+func TestLatencyTrackerDemotesChronicallySlowSources(t *testing.T) {
+    tracker := newLatencyTracker()
+    slo := &common.LatencySLOConfig{P95Ms: 200, Action: common.SLOFallback, CooldownSeconds: 60}
+    now := time.Now()
+
+    // A single slow fetch among fast ones stays within the p95
+    for i := 0; i < minLatencySamples-1; i++ {
+        if stats := tracker.observe("kraken", slo, 50*time.Millisecond, now); stats != nil {
+            t.Fatalf("Demoted after %d fast fetches: %+v", i+1, stats)
+        }
+    }
+    if stats := tracker.observe("kraken", slo, time.Second, now); stats != nil {
+        t.Fatalf("Demoted for one slow fetch: %+v", stats)
+    }
+
+    var demoted *LatencyStats
+    for i := 0; i < 5 && demoted == nil; i++ {
+        demoted = tracker.observe("kraken", slo, time.Second, now)
+    }
+    if demoted == nil || !demoted.Deprioritized || demoted.Action != common.SLOFallback || demoted.P95Ms != 1000 || demoted.Demotions != 1 {
+        t.Fatalf("Expected kraken demoted to fallback at a 1000ms p95, got %+v", demoted)
+    }
+
+    if got, lifted := tracker.demotion("kraken", slo, now.Add(59*time.Second)); got != slo || lifted {
+        t.Errorf("Expected kraken demoted within its cooldown")
+    }
+    if got, lifted := tracker.demotion("kraken", slo, now.Add(time.Minute)); got != nil || !lifted {
+        t.Errorf("Expected the demotion lifted after the cooldown")
+    }
+    if stats := tracker.stats(); len(stats) != 1 || stats[0].Deprioritized || stats[0].Samples != 0 || stats[0].Demotions != 1 {
+        t.Errorf("Expected kraken measured afresh, got %+v", stats)
+    }
+}
+
+func TestDeprioritizeMovesSlowExchangesAfterPrimaryTier(t *testing.T) {
+    base := &common.BaseConfig{Exchanges: common.ExchangeConfig{CEX: map[string]common.CEXDetails{
+        "binance":  {LatencySLO: &common.LatencySLOConfig{P95Ms: 100, TimeoutMs: 150}},
+        "coinbase": {LatencySLO: &common.LatencySLOConfig{P95Ms: 100, Action: common.SLOFallback}},
+        "kraken":   {},
+    }}}
+    a := NewCryptoAggregator(base)
+    a.SetEventBus(events.NewBus())
+    for i := 0; i < minLatencySamples; i++ {
+        a.observeLatency(base, "binance", time.Second)
+        a.observeLatency(base, "coinbase", time.Second)
+        a.observeLatency(base, "kraken", time.Second)
+    }
+
+    tier := common.SourcesConfig{CEX: common.CEXSourceConfig{Enabled: true, Weight: 0.5, Exchanges: []string{"binance", "coinbase", "kraken"}}}
+    primary, demoted := a.deprioritize(base, tier)
+    if got := primary.CEX.Exchanges; len(got) != 2 || got[0] != "binance" || got[1] != "kraken" {
+        t.Errorf("Expected binance, with a shorter timeout, and kraken to stay primary, got %v", got)
+    }
+    if got := demoted.CEX.Exchanges; !demoted.CEX.Enabled || len(got) != 1 || got[0] != "coinbase" || demoted.CEX.Weight != 0.5 {
+        t.Errorf("Expected coinbase deprioritized at the primary weight, got %+v", demoted.CEX)
+    }
+
+    stats := a.LatencyStats()
+    if len(stats) != 2 || !stats[0].Deprioritized || stats[0].Source != "binance" || stats[0].TimeoutMs != 150 {
+        t.Errorf("Expected binance and coinbase deprioritized, got %+v", stats)
+    }
+}
+
+func TestValidateLatencySLO(t *testing.T) {
+    for _, c := range []*common.LatencySLOConfig{
+        {},
+        {P95Ms: 100, Action: "drop"},
+        {P95Ms: 100, Window: 5},
+        {P95Ms: 100, CooldownSeconds: -1},
+    } {
+        if err := validateLatencySLO("kraken", c); err == nil {
+            t.Errorf("Expected %+v to be rejected", c)
+        }
+    }
+    if err := validateLatencySLO("kraken", &common.LatencySLOConfig{P95Ms: 100, Action: common.SLOFallback}); err != nil {
+        t.Errorf("Unexpected error: %v", err)
+    }
+}
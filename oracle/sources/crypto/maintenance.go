@@ -0,0 +1,312 @@
+package crypto
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "net/http"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/fetch"
+)
+
+// Origins of maintenance windows
+const (
+    maintenanceSystemStatus = "system_status" // exchange API reports maintenance now
+    maintenanceStatusPage   = "status_page"   // scheduled on the exchange status page
+)
+
+// MaintenanceWindow is a period during which an exchange's API is
+// expected to be unavailable. A zero Until means until further notice.
+type MaintenanceWindow struct {
+    Exchange    string    `json:"exchange"`
+    Origin      string    `json:"origin"`
+    Description string    `json:"description"`
+    From        time.Time `json:"from"`
+    Until       time.Time `json:"until,omitempty"`
+}
+
+// covers reports whether the window is in effect at t
+func (w MaintenanceWindow) covers(t time.Time) bool {
+    return !t.Before(w.From) && (w.Until.IsZero() || t.Before(w.Until))
+}
+
+// MaintenanceMonitor polls exchange status APIs and status pages for
+// current and announced maintenance so that affected sources are skipped
+// instead of failing every round
+type MaintenanceMonitor struct {
+    config *common.BaseConfig
+    client *http.Client
+
+    mu      sync.RWMutex
+    windows map[string][]MaintenanceWindow
+}
+
+// NewMaintenanceMonitor creates a monitor for the configured exchanges
+func NewMaintenanceMonitor(config *common.BaseConfig) *MaintenanceMonitor {
+    return &MaintenanceMonitor{
+        config:  config,
+        client:  fetch.NewClient(10 * time.Second),
+        windows: make(map[string][]MaintenanceWindow),
+    }
+}
+
+// Run refreshes maintenance windows at interval until ctx is cancelled
+func (m *MaintenanceMonitor) Run(ctx context.Context, interval time.Duration) {
+    m.Refresh(time.Now())
+
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            m.Refresh(time.Now())
+        }
+    }
+}
+
+// Refresh fetches the maintenance state of every exchange
+func (m *MaintenanceMonitor) Refresh(now time.Time) {
+    for exchange, details := range m.config.Exchanges.CEX {
+        m.mu.RLock()
+        previous := m.windows[exchange]
+        m.mu.RUnlock()
+        windows := m.fetchWindows(exchange, details, previous, now)
+
+        m.mu.Lock()
+        _, wasActive := activeWindow(m.windows[exchange], now)
+        m.windows[exchange] = windows
+        active, isActive := activeWindow(windows, now)
+        m.mu.Unlock()
+
+        if isActive && !wasActive {
+            log.Printf("Exchange %s under maintenance (%s), skipping its sources", exchange, active.Description)
+        } else if wasActive && !isActive {
+            log.Printf("Exchange %s maintenance ended", exchange)
+        }
+    }
+}
+
+// fetchWindows collects the maintenance windows of one exchange. The
+// system status and the status page are fetched separately: one that
+// cannot be fetched keeps the windows it gave in previous.
+func (m *MaintenanceMonitor) fetchWindows(exchange string, details common.CEXDetails, previous []MaintenanceWindow, now time.Time) []MaintenanceWindow {
+    windows := make([]MaintenanceWindow, 0)
+
+    baseURL := strings.TrimRight(details.BaseURL, "/")
+    var description string
+    var err error
+    switch exchange {
+    case "binance":
+        description, err = m.binanceStatus(binanceStatusURL(baseURL))
+    case "kraken":
+        description, err = m.krakenStatus(baseURL)
+    }
+    switch {
+    case err != nil:
+        log.Printf("Error fetching maintenance status of %s: %v", exchange, err)
+        windows = append(windows, originWindows(previous, maintenanceSystemStatus)...)
+    case description != "":
+        windows = append(windows, MaintenanceWindow{
+            Exchange:    exchange,
+            Origin:      maintenanceSystemStatus,
+            Description: description,
+            From:        now,
+        })
+    }
+
+    if details.StatusPage != "" {
+        scheduled, err := m.statusPageWindows(exchange, strings.TrimRight(details.StatusPage, "/"), details.StatusComponents)
+        if err != nil {
+            log.Printf("Error fetching maintenance status page of %s: %v", exchange, err)
+            scheduled = originWindows(previous, maintenanceStatusPage)
+        }
+        windows = append(windows, scheduled...)
+    }
+    return windows
+}
+
+// originWindows returns the windows of the given origin
+func originWindows(windows []MaintenanceWindow, origin string) []MaintenanceWindow {
+    var out []MaintenanceWindow
+    for _, w := range windows {
+        if w.Origin == origin {
+            out = append(out, w)
+        }
+    }
+    return out
+}
+
+// binanceStatusURL returns the system status endpoint next to the Binance
+// REST API at baseURL, e.g. https://api.binance.com/api/v3
+func binanceStatusURL(baseURL string) string {
+    return strings.TrimSuffix(baseURL, "/api/v3") + "/sapi/v1/system/status"
+}
+
+// binanceStatus returns a description when Binance reports maintenance
+func (m *MaintenanceMonitor) binanceStatus(url string) (string, error) {
+    resp, err := m.client.Get(url)
+    if err != nil {
+        return "", err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return "", fmt.Errorf("unexpected status from Binance: %s", resp.Status)
+    }
+
+    var data struct {
+        Status int    `json:"status"` // 0 normal, 1 system maintenance
+        Msg    string `json:"msg"`
+    }
+    if err := fetch.DecodeJSON(resp, &data); err != nil {
+        return "", err
+    }
+    if data.Status != 0 {
+        return data.Msg, nil
+    }
+    return "", nil
+}
+
+// krakenStatus returns a description when Kraken is not fully online.
+// In cancel_only mode no trades print, so prices would be frozen.
+func (m *MaintenanceMonitor) krakenStatus(baseURL string) (string, error) {
+    resp, err := m.client.Get(baseURL + "/SystemStatus")
+    if err != nil {
+        return "", err
+    }
+    defer resp.Body.Close()
+
+    var data struct {
+        Error  []string `json:"error"`
+        Result struct {
+            Status string `json:"status"`
+        } `json:"result"`
+    }
+    if err := fetch.DecodeJSON(resp, &data); err != nil {
+        return "", err
+    }
+    if len(data.Error) > 0 {
+        return "", fmt.Errorf("Kraken error: %s", strings.Join(data.Error, ", "))
+    }
+    switch data.Result.Status {
+    case "maintenance", "cancel_only":
+        return data.Result.Status, nil
+    }
+    return "", nil
+}
+
+// statusPageWindows returns the unfinished scheduled maintenances announced
+// on a Statuspage-hosted status page that affect the given components
+func (m *MaintenanceMonitor) statusPageWindows(exchange, page string, components []string) ([]MaintenanceWindow, error) {
+    resp, err := m.client.Get(page + "/api/v2/scheduled-maintenances.json")
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("unexpected status from %s status page: %s", exchange, resp.Status)
+    }
+
+    var data struct {
+        ScheduledMaintenances []struct {
+            Name           string    `json:"name"`
+            Status         string    `json:"status"`
+            ScheduledFor   time.Time `json:"scheduled_for"`
+            ScheduledUntil time.Time `json:"scheduled_until"`
+            Components     []struct {
+                Name string `json:"name"`
+            } `json:"components"`
+        } `json:"scheduled_maintenances"`
+    }
+    if err := fetch.DecodeJSON(resp, &data); err != nil {
+        return nil, err
+    }
+
+    windows := make([]MaintenanceWindow, 0)
+    for _, sm := range data.ScheduledMaintenances {
+        if sm.Status == "completed" {
+            continue
+        }
+        names := make([]string, 0, len(sm.Components))
+        for _, c := range sm.Components {
+            names = append(names, c.Name)
+        }
+        if !affects(names, components) {
+            continue
+        }
+        window := MaintenanceWindow{
+            Exchange:    exchange,
+            Origin:      maintenanceStatusPage,
+            Description: sm.Name,
+            From:        sm.ScheduledFor,
+            Until:       sm.ScheduledUntil,
+        }
+        // Overrunning maintenance stays in effect until marked completed
+        if sm.Status == "in_progress" || sm.Status == "verifying" {
+            window.Until = time.Time{}
+        }
+        windows = append(windows, window)
+    }
+    return windows, nil
+}
+
+// Active returns the maintenance window in effect for an exchange at t.
+// A nil monitor never reports maintenance.
+func (m *MaintenanceMonitor) Active(exchange string, t time.Time) (MaintenanceWindow, bool) {
+    if m == nil {
+        return MaintenanceWindow{}, false
+    }
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+    return activeWindow(m.windows[exchange], t)
+}
+
+// Windows returns all known current and upcoming windows ordered by start
+func (m *MaintenanceMonitor) Windows() []MaintenanceWindow {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+    out := make([]MaintenanceWindow, 0)
+    for _, windows := range m.windows {
+        out = append(out, windows...)
+    }
+    sort.Slice(out, func(i, j int) bool {
+        if !out[i].From.Equal(out[j].From) {
+            return out[i].From.Before(out[j].From)
+        }
+        return out[i].Exchange < out[j].Exchange
+    })
+    return out
+}
+
+// affects reports whether any affected component name contains one of the
+// watched components; no watched components means every maintenance counts
+func affects(affected, watched []string) bool {
+    if len(watched) == 0 {
+        return true
+    }
+    for _, name := range affected {
+        for _, w := range watched {
+            if strings.Contains(strings.ToLower(name), strings.ToLower(w)) {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// activeWindow returns the first window covering t
+func activeWindow(windows []MaintenanceWindow, t time.Time) (MaintenanceWindow, bool) {
+    for _, w := range windows {
+        if w.covers(t) {
+            return w, true
+        }
+    }
+    return MaintenanceWindow{}, false
+}
@@ -0,0 +1,71 @@
+package crypto
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+)
+
+func TestMaintenanceMonitor(t *testing.T) {
+    now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+    failing := false
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "application/json")
+        switch r.URL.Path {
+        case "/binance/sapi/v1/system/status":
+            if failing {
+                http.Error(w, "unavailable", http.StatusBadGateway)
+                return
+            }
+            w.Write([]byte(`{"status": 1, "msg": "system maintenance"}`))
+        case "/kraken/SystemStatus":
+            w.Write([]byte(`{"error": [], "result": {"status": "online"}}`))
+        case "/status/api/v2/scheduled-maintenances.json":
+            w.Write([]byte(`{"scheduled_maintenances": [
+                {"name": "Spot API upgrade", "status": "scheduled", "scheduled_for": "2024-06-01T13:00:00Z", "scheduled_until": "2024-06-01T14:00:00Z", "components": [{"name": "API"}]},
+                {"name": "ETH deposits", "status": "in_progress", "scheduled_for": "2024-06-01T11:00:00Z", "scheduled_until": "2024-06-01T11:30:00Z", "components": [{"name": "Funding"}]},
+                {"name": "Past upgrade", "status": "completed", "scheduled_for": "2024-05-01T13:00:00Z", "scheduled_until": "2024-05-01T14:00:00Z", "components": [{"name": "API"}]}
+            ]}`))
+        default:
+            http.NotFound(w, r)
+        }
+    }))
+    defer srv.Close()
+
+    monitor := NewMaintenanceMonitor(&common.BaseConfig{Exchanges: common.ExchangeConfig{CEX: map[string]common.CEXDetails{
+        "binance": {BaseURL: srv.URL + "/binance/api/v3"},
+        "kraken":  {BaseURL: srv.URL + "/kraken", StatusPage: srv.URL + "/status", StatusComponents: []string{"API"}},
+    }}})
+    monitor.Refresh(now)
+
+    if w, ok := monitor.Active("binance", now); !ok || w.Origin != maintenanceSystemStatus {
+        t.Errorf("Expected Binance system maintenance, got %+v %v", w, ok)
+    }
+    if _, ok := monitor.Active("kraken", now); ok {
+        t.Error("Expected Kraken available before its announced window")
+    }
+    if w, ok := monitor.Active("kraken", now.Add(90*time.Minute)); !ok || w.Description != "Spot API upgrade" {
+        t.Errorf("Expected Kraken under announced maintenance, got %+v %v", w, ok)
+    }
+    if _, ok := monitor.Active("kraken", now.Add(3*time.Hour)); ok {
+        t.Error("Expected Kraken available after its announced window")
+    }
+    if windows := monitor.Windows(); len(windows) != 2 {
+        t.Errorf("Expected 2 known windows, got %+v", windows)
+    }
+
+    // A failed status fetch keeps the venue's previous state
+    failing = true
+    monitor.Refresh(now)
+    if w, ok := monitor.Active("binance", now); !ok || w.Origin != maintenanceSystemStatus {
+        t.Errorf("Expected Binance maintenance kept after a failed fetch, got %+v %v", w, ok)
+    }
+
+    var none *MaintenanceMonitor
+    if _, ok := none.Active("binance", now); ok {
+        t.Error("Expected a nil monitor to report no maintenance")
+    }
+}
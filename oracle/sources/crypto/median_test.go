@@ -0,0 +1,103 @@
+package crypto
+
+import (
+    "math"
+    "testing"
+
+    "yetaXYZ/oracle/common"
+)
+
+func TestWeightedMedian(t *testing.T) {
+    agg := NewCryptoAggregator(&common.BaseConfig{})
+    points := func(prices ...float64) []*common.PricePoint {
+        out := make([]*common.PricePoint, len(prices))
+        for i, p := range prices {
+            out[i] = &common.PricePoint{Price: p, Volume: 1}
+        }
+        return out
+    }
+
+    tests := []struct {
+        name     string
+        prices   []*common.PricePoint
+        weights  []float64
+        expected float64
+    }{
+        {"odd equal weights", points(3, 1, 2), []float64{1, 1, 1}, 2},
+        {"even equal weights picks upper median", points(4, 1, 3, 2), []float64{1, 1, 1, 1}, 3},
+        {"heavy source dominates", points(100, 101, 102), []float64{1, 1, 5}, 102},
+        {"light outlier ignored", points(100, 101, 150), []float64{2, 2, 0.5}, 101},
+    }
+    for _, tt := range tests {
+        result := agg.calculateMedian(tt.prices, tt.weights)
+        if result.Price != tt.expected {
+            t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, result.Price)
+        }
+        if result.Volume != float64(len(tt.prices)) {
+            t.Errorf("%s: expected total volume %d, got %v", tt.name, len(tt.prices), result.Volume)
+        }
+    }
+
+    pair := &common.PairConfig{SourceWeights: map[string]float64{"binance": 2}}
+    if sourceWeight(pair, "binance") != 2 || sourceWeight(pair, "kraken") != 1 {
+        t.Error("Expected configured weight for binance and default weight for kraken")
+    }
+}
+
+func TestVolumeBoost(t *testing.T) {
+    sources := []common.SourcePrice{
+        {Source: "binance", PricePoint: common.PricePoint{Price: 100, Volume: 75}},
+        {Source: "kraken", PricePoint: common.PricePoint{Price: 101, Volume: 25}},
+        {Source: "coinbase", PricePoint: common.PricePoint{Price: 102}},
+    }
+
+    tests := []struct {
+        name     string
+        params   common.AggregationParams
+        expected []float64
+    }{
+        {"disabled by default", common.AggregationParams{}, []float64{1, 1, 1}},
+        {"linear", common.AggregationParams{VolumeBoost: common.VolumeBoostLinear}, []float64{1.75, 1.25, 1}},
+        {"sqrt", common.AggregationParams{VolumeBoost: common.VolumeBoostSqrt}, []float64{1 + math.Sqrt(0.75), 1.5, 1}},
+        {"capped", common.AggregationParams{VolumeBoost: common.VolumeBoostLinear, MaxVolumeMultiplier: 1.5}, []float64{1.5, 1.25, 1}},
+    }
+    for _, tt := range tests {
+        weights := sourceWeights(&common.PairConfig{Aggregation: tt.params}, sources)
+        for i, w := range weights {
+            if math.Abs(w-tt.expected[i]) > 1e-9 {
+                t.Errorf("%s: expected weight %v for %s, got %v", tt.name, tt.expected[i], sources[i].Source, w)
+            }
+        }
+    }
+
+    if err := validateAggregation("pair BTCUSDT", common.AggregationParams{VolumeBoost: "cubic"}); err == nil {
+        t.Error("Expected unknown volume boost to be rejected")
+    }
+}
+
+func TestTierWeights(t *testing.T) {
+    pool := common.DEXPool{Chain: "1", Exchange: "uniswap_v3", Address: "0xpool"}
+    pair := &common.PairConfig{
+        Sources: common.SourcesConfig{
+            CEX: common.CEXSourceConfig{Enabled: true, Weight: 2, Exchanges: []string{"binance"}},
+            DEX: common.DEXSourceConfig{Enabled: true, Weight: 0.5, Pools: []common.DEXPool{pool}},
+        },
+        FallbackTiers: []common.SourcesConfig{
+            {CEX: common.CEXSourceConfig{Enabled: true, Exchanges: []string{"kraken"}}},
+        },
+        SourceWeights: map[string]float64{"binance": 1.5},
+    }
+    sources := []common.SourcePrice{
+        {Source: "binance", PricePoint: common.PricePoint{Price: 100}},
+        {Source: PoolSourceName(pool), PricePoint: common.PricePoint{Price: 101}},
+        {Source: "kraken", Tier: tierLabel(1), PricePoint: common.PricePoint{Price: 102}},
+    }
+
+    // Tier weights multiply source weights; an unset tier weight counts as 1
+    expected := []float64{3, 0.5, 1}
+    for i, w := range sourceWeights(pair, sources) {
+        if w != expected[i] {
+            t.Errorf("Expected weight %v for %s, got %v", expected[i], sources[i].Source, w)
+        }
+    }
+}
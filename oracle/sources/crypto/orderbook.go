@@ -0,0 +1,310 @@
+package crypto
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "net/http"
+    "sort"
+    "strings"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/fetch"
+)
+
+// Trade sides accepted by FetchExecutionEstimate
+const (
+    SideBuy  = "buy"
+    SideSell = "sell"
+)
+
+// BookLevel is a single price level of an order book
+type BookLevel struct {
+    Price    float64
+    Quantity float64
+}
+
+// OrderBook is a snapshot of the top levels of an exchange order book.
+// Bids are sorted best (highest) first, asks best (lowest) first.
+type OrderBook struct {
+    Bids []BookLevel
+    Asks []BookLevel
+}
+
+// depthCacheTTL is how long a fetched depth snapshot serves execution
+// estimates, so that requests for a pair share one exchange call
+const depthCacheTTL = 5 * time.Second
+
+// depthFetchers lists the exchanges that can provide order book depth
+var depthFetchers = map[string]func(a *CryptoAggregator, ctx context.Context, symbol string) (*OrderBook, error){
+    "binance": (*CryptoAggregator).fetchBinanceDepth,
+    "kraken":  (*CryptoAggregator).fetchKrakenDepth,
+}
+
+// Mid returns the midpoint between the best bid and the best ask
+func (b *OrderBook) Mid() (float64, error) {
+    if len(b.Bids) == 0 || len(b.Asks) == 0 {
+        return 0, fmt.Errorf("order book is empty")
+    }
+    return (b.Bids[0].Price + b.Asks[0].Price) / 2, nil
+}
+
+// Microprice returns the best bid and ask weighted by the size on the
+// opposite side, leaning towards the side more likely to trade next
+func (b *OrderBook) Microprice() (float64, error) {
+    if len(b.Bids) == 0 || len(b.Asks) == 0 {
+        return 0, fmt.Errorf("order book is empty")
+    }
+    bid, ask := b.Bids[0], b.Asks[0]
+    if bid.Quantity+ask.Quantity <= 0 {
+        return (bid.Price + ask.Price) / 2, nil
+    }
+    return (bid.Price*ask.Quantity + ask.Price*bid.Quantity) / (bid.Quantity + ask.Quantity), nil
+}
+
+// ExecutionPrice walks the book and returns the volume-weighted average
+// price for filling notional (in quote currency) on the given side,
+// skipping levels without a price or quantity
+func (b *OrderBook) ExecutionPrice(side string, notional float64) (float64, error) {
+    levels := b.Asks
+    if side == SideSell {
+        levels = b.Bids
+    }
+
+    remaining := notional
+    filledQty := 0.0
+    for _, level := range levels {
+        // A zero-priced or empty level would fill nothing, or divide by zero
+        if level.Price <= 0 || level.Quantity <= 0 {
+            continue
+        }
+        levelNotional := level.Price * level.Quantity
+        if levelNotional >= remaining {
+            filledQty += remaining / level.Price
+            remaining = 0
+            break
+        }
+        filledQty += level.Quantity
+        remaining -= levelNotional
+    }
+
+    if remaining > 0 {
+        return 0, fmt.Errorf("insufficient depth: %.2f of %.2f unfilled", remaining, notional)
+    }
+    return notional / filledQty, nil
+}
+
+// FetchExecutionEstimate estimates the execution price and slippage for a
+// trade of size (quote currency notional) across the pair's primary CEX
+// sources with order book depth. DEX pools have no order book and DEX
+// aggregator quotes are not supported, so the estimate is CEX-only.
+func (a *CryptoAggregator) FetchExecutionEstimate(ctx context.Context, symbol string, size float64, side string) (*common.ExecutionEstimate, error) {
+    if size <= 0 {
+        return nil, fmt.Errorf("size must be positive")
+    }
+    if side != SideBuy && side != SideSell {
+        return nil, fmt.Errorf("invalid side %q: must be %s or %s", side, SideBuy, SideSell)
+    }
+
+    pairConfig, err := GetPairConfig(symbol)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get pair config: %v", err)
+    }
+    symbol = strings.ReplaceAll(symbol, "/", "")
+
+    var mids, execs []float64
+    sources := make([]string, 0)
+    if pairConfig.Sources.CEX.Enabled {
+        for _, exchange := range pairConfig.Sources.CEX.Exchanges {
+            fetchDepth, ok := depthFetchers[exchange]
+            if !ok {
+                continue
+            }
+
+            // A streamed book costs no request, but keeps fewer levels
+            // than a snapshot and may be too shallow for the size
+            book, streamed := a.books.Depth(exchange, symbol, time.Now())
+            var exec float64
+            if streamed {
+                exec, err = book.ExecutionPrice(side, size)
+            }
+            if !streamed || err != nil {
+                // Snapshots are shared for a while, so that anonymous
+                // requests cannot run through the exchange's rate limit
+                if book, err = fetchDepth(a, fetch.WithCache(ctx, depthCacheTTL), symbol); err != nil {
+                    log.Printf("Error fetching order book from %s for %s: %v", exchange, symbol, err)
+                    continue
+                }
+                if exec, err = book.ExecutionPrice(side, size); err != nil {
+                    log.Printf("Cannot fill %.2f on %s for %s: %v", size, exchange, symbol, err)
+                    continue
+                }
+            }
+
+            mid, err := book.Mid()
+            if err != nil {
+                log.Printf("Invalid order book from %s for %s: %v", exchange, symbol, err)
+                continue
+            }
+
+            mids = append(mids, mid)
+            execs = append(execs, exec)
+            sources = append(sources, exchange)
+        }
+    }
+
+    if len(sources) == 0 {
+        return nil, fmt.Errorf("no order book sources available for %s", symbol)
+    }
+
+    mid := median(mids)
+    exec := median(execs)
+    return &common.ExecutionEstimate{
+        Side:           side,
+        Size:           size,
+        MidPrice:       mid,
+        ExecutionPrice: exec,
+        SlippageBps:    abs(exec-mid) / mid * 10000,
+        Sources:        sources,
+        Timestamp:      time.Now(),
+    }, nil
+}
+
+// fetchBinanceDepth fetches the order book from Binance
+func (a *CryptoAggregator) fetchBinanceDepth(ctx context.Context, symbol string) (*OrderBook, error) {
+    url := fmt.Sprintf("%s/depth?symbol=%s&limit=500", a.cexBaseURL("binance"), symbol)
+    resp, err := a.get(fetch.WithSchema(ctx, "binance", "depth"), url)
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("unexpected status from Binance: %s", resp.Status)
+    }
+
+    var data struct {
+        Bids [][]string `json:"bids"`
+        Asks [][]string `json:"asks"`
+    }
+    if err := fetch.DecodeJSON(resp, &data); err != nil {
+        return nil, err
+    }
+
+    bids, err := parseBookLevels(data.Bids)
+    if err != nil {
+        return nil, err
+    }
+    asks, err := parseBookLevels(data.Asks)
+    if err != nil {
+        return nil, err
+    }
+    return newOrderBook(bids, asks), nil
+}
+
+// fetchKrakenDepth fetches the order book from Kraken
+func (a *CryptoAggregator) fetchKrakenDepth(ctx context.Context, symbol string) (*OrderBook, error) {
+    url := fmt.Sprintf("%s/Depth?pair=%s&count=500", a.cexBaseURL("kraken"), symbol)
+    resp, err := a.get(fetch.WithSchema(ctx, "kraken", "depth", "result"), url)
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("unexpected status from Kraken: %s", resp.Status)
+    }
+
+    // Kraken levels are [price, volume, timestamp] with mixed types
+    var data struct {
+        Error  []string `json:"error"`
+        Result map[string]struct {
+            Bids [][]interface{} `json:"bids"`
+            Asks [][]interface{} `json:"asks"`
+        } `json:"result"`
+    }
+    if err := fetch.DecodeJSON(resp, &data); err != nil {
+        return nil, err
+    }
+    if len(data.Error) > 0 {
+        return nil, fmt.Errorf("Kraken error: %s", strings.Join(data.Error, ", "))
+    }
+
+    for _, book := range data.Result {
+        bids, err := parseBookLevels(stringLevels(book.Bids))
+        if err != nil {
+            return nil, err
+        }
+        asks, err := parseBookLevels(stringLevels(book.Asks))
+        if err != nil {
+            return nil, err
+        }
+        return newOrderBook(bids, asks), nil
+    }
+    return nil, fmt.Errorf("invalid response from Kraken")
+}
+
+// cexBaseURL returns the configured base URL for a centralized exchange
+func (a *CryptoAggregator) cexBaseURL(exchange string) string {
+    return strings.TrimRight(a.config.Exchanges.CEX[exchange].BaseURL, "/")
+}
+
+// newOrderBook builds an order book with levels sorted best first
+func newOrderBook(bids, asks []BookLevel) *OrderBook {
+    sort.Slice(bids, func(i, j int) bool { return bids[i].Price > bids[j].Price })
+    sort.Slice(asks, func(i, j int) bool { return asks[i].Price < asks[j].Price })
+    return &OrderBook{Bids: bids, Asks: asks}
+}
+
+// parseBookLevels converts [price, quantity, ...] string tuples into levels
+func parseBookLevels(raw [][]string) ([]BookLevel, error) {
+    levels := make([]BookLevel, 0, len(raw))
+    for _, entry := range raw {
+        if len(entry) < 2 {
+            return nil, fmt.Errorf("malformed order book level: %v", entry)
+        }
+        price, err := parseFloat(entry[0])
+        if err != nil {
+            return nil, err
+        }
+        qty, err := parseFloat(entry[1])
+        if err != nil {
+            return nil, err
+        }
+        levels = append(levels, BookLevel{Price: price, Quantity: qty})
+    }
+    return levels, nil
+}
+
+// stringLevels converts loosely typed JSON tuples into string tuples
+func stringLevels(raw [][]interface{}) [][]string {
+    out := make([][]string, 0, len(raw))
+    for _, entry := range raw {
+        row := make([]string, 0, len(entry))
+        for _, v := range entry {
+            row = append(row, fmt.Sprint(v))
+        }
+        out = append(out, row)
+    }
+    return out
+}
+
+// median returns the median of values, averaging the middle pair
+func median(values []float64) float64 {
+    sorted := append([]float64(nil), values...)
+    sort.Float64s(sorted)
+    mid := len(sorted) / 2
+    if len(sorted)%2 == 0 {
+        return (sorted[mid-1] + sorted[mid]) / 2
+    }
+    return sorted[mid]
+}
+
+// abs returns the absolute value of f
+func abs(f float64) float64 {
+    if f < 0 {
+        return -f
+    }
+    return f
+}
@@ -0,0 +1,66 @@
+package crypto
+
+import (
+    "math"
+    "testing"
+)
+
+func TestOrderBookExecutionPrice(t *testing.T) {
+    book := newOrderBook(
+        []BookLevel{{Price: 99, Quantity: 1}, {Price: 100, Quantity: 1}},
+        []BookLevel{{Price: 102, Quantity: 1}, {Price: 101, Quantity: 1}},
+    )
+
+    mid, err := book.Mid()
+    if err != nil {
+        t.Fatalf("Failed to compute mid: %v", err)
+    }
+    if mid != 100.5 {
+        t.Errorf("Expected mid 100.5, got %f", mid)
+    }
+
+    // Fits in the best ask level
+    price, err := book.ExecutionPrice(SideBuy, 50)
+    if err != nil {
+        t.Fatalf("Failed to compute execution price: %v", err)
+    }
+    if price != 101 {
+        t.Errorf("Expected execution price 101, got %f", price)
+    }
+
+    // Walks into the second ask level: 101 + 101 of 102
+    price, err = book.ExecutionPrice(SideBuy, 202)
+    if err != nil {
+        t.Fatalf("Failed to compute execution price: %v", err)
+    }
+    expected := 202 / (1 + 101.0/102)
+    if math.Abs(price-expected) > 1e-9 {
+        t.Errorf("Expected execution price %f, got %f", expected, price)
+    }
+
+    // Sells hit the bids
+    price, err = book.ExecutionPrice(SideSell, 100)
+    if err != nil {
+        t.Fatalf("Failed to compute execution price: %v", err)
+    }
+    if price != 100 {
+        t.Errorf("Expected execution price 100, got %f", price)
+    }
+
+    // More than the book can absorb
+    if _, err := book.ExecutionPrice(SideBuy, 1000); err == nil {
+        t.Error("Expected insufficient depth error, got nil")
+    }
+}
+
+func TestOrderBookSkipsEmptyLevels(t *testing.T) {
+    book := newOrderBook(nil, []BookLevel{{Price: 0, Quantity: 5}, {Price: 101, Quantity: 0}, {Price: 102, Quantity: 1}})
+
+    price, err := book.ExecutionPrice(SideBuy, 51)
+    if err != nil {
+        t.Fatalf("Failed to compute execution price: %v", err)
+    }
+    if price != 102 {
+        t.Errorf("Expected execution price 102 past the empty levels, got %f", price)
+    }
+}
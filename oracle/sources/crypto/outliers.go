@@ -0,0 +1,129 @@
+package crypto
+
+import (
+    "sort"
+
+    "yetaXYZ/oracle/common"
+)
+
+// minIQRFraction floors the interquartile range at a fraction of the median
+// so that a few identical quotes do not reject every other venue
+const minIQRFraction = 0.0005
+
+// rejectSources splits source prices into kept and rejected indices: those
+// beyond the pair's deviation guard are rejected first, then outliers among
+// the rest
+func rejectSources(pair *common.PairConfig, prices []*common.PricePoint, weights []float64) (kept, rejected []int) {
+    candidates, rejected := rejectDeviating(prices, pair.MaxSourceDeviation, pair.MinimumSources)
+    candidatePrices := make([]*common.PricePoint, len(candidates))
+    candidateWeights := make([]float64, len(candidates))
+    for j, i := range candidates {
+        candidatePrices[j], candidateWeights[j] = prices[i], weights[i]
+    }
+
+    inner, outliers := rejectOutliers(candidatePrices, candidateWeights, pair.Aggregation.IQRMultiplier, pair.MinimumSources)
+    for _, j := range inner {
+        kept = append(kept, candidates[j])
+    }
+    for _, j := range outliers {
+        rejected = append(rejected, candidates[j])
+    }
+    sort.Ints(rejected)
+    return kept, rejected
+}
+
+// rejectDeviating splits source prices into kept and rejected indices,
+// rejecting those farther than max (a fraction) from the median of all of
+// them once any is. Rejection never leaves fewer than minimum sources: the
+// points closest to the median are kept instead.
+func rejectDeviating(prices []*common.PricePoint, max float64, minimum int) (kept, rejected []int) {
+    all := make([]int, len(prices))
+    values := make([]float64, len(prices))
+    for i, p := range prices {
+        all[i] = i
+        values[i] = p.Price
+    }
+    if max <= 0 || maxDeviation(prices) <= max {
+        return all, nil
+    }
+
+    mid := median(values)
+    sort.SliceStable(all, func(i, j int) bool {
+        return abs(prices[all[i]].Price-mid) < abs(prices[all[j]].Price-mid)
+    })
+    for n, i := range all {
+        if n >= minimum && abs(prices[i].Price-mid)/mid > max {
+            rejected = append(rejected, i)
+        } else {
+            kept = append(kept, i)
+        }
+    }
+    sort.Ints(kept)
+    sort.Ints(rejected)
+    return kept, rejected
+}
+
+// rejectOutliers splits source prices into kept and rejected indices using
+// fences of multiplier times the weighted interquartile range. Rejection
+// never leaves fewer than minimum sources: the points closest to the
+// weighted median are kept instead.
+func rejectOutliers(prices []*common.PricePoint, weights []float64, multiplier float64, minimum int) (kept, rejected []int) {
+    all := make([]int, len(prices))
+    for i := range prices {
+        all[i] = i
+    }
+    if multiplier <= 0 || len(prices) < 3 {
+        return all, nil
+    }
+
+    sort.SliceStable(all, func(i, j int) bool { return prices[all[i]].Price < prices[all[j]].Price })
+    q1 := weightedQuantile(prices, weights, all, 0.25)
+    mid := weightedQuantile(prices, weights, all, 0.5)
+    q3 := weightedQuantile(prices, weights, all, 0.75)
+
+    iqr := q3 - q1
+    if floor := mid * minIQRFraction; iqr < floor {
+        iqr = floor
+    }
+    low, high := q1-multiplier*iqr, q3+multiplier*iqr
+
+    for _, i := range all {
+        if p := prices[i].Price; p >= low && p <= high {
+            kept = append(kept, i)
+        } else {
+            rejected = append(rejected, i)
+        }
+    }
+    sort.Ints(kept)
+    sort.Ints(rejected)
+    if len(kept) >= minimum {
+        return kept, rejected
+    }
+
+    // Too few survivors: keep the points closest to the weighted median
+    sort.SliceStable(all, func(i, j int) bool {
+        return abs(prices[all[i]].Price-mid) < abs(prices[all[j]].Price-mid)
+    })
+    kept = append([]int(nil), all[:minimum]...)
+    rejected = append([]int(nil), all[minimum:]...)
+    sort.Ints(kept)
+    sort.Ints(rejected)
+    return kept, rejected
+}
+
+// weightedQuantile returns the first price, in the given ascending order,
+// at which the cumulative weight reaches q of the total weight
+func weightedQuantile(prices []*common.PricePoint, weights []float64, order []int, q float64) float64 {
+    total := 0.0
+    for _, i := range order {
+        total += weights[i]
+    }
+    cumulative := 0.0
+    for _, i := range order {
+        cumulative += weights[i]
+        if cumulative >= q*total {
+            return prices[i].Price
+        }
+    }
+    return prices[order[len(order)-1]].Price
+}
@@ -0,0 +1,39 @@
+package crypto
+
+import (
+    "reflect"
+    "testing"
+
+    "yetaXYZ/oracle/common"
+)
+
+func TestRejectOutliers(t *testing.T) {
+    points := func(prices ...float64) []*common.PricePoint {
+        out := make([]*common.PricePoint, len(prices))
+        for i, p := range prices {
+            out[i] = &common.PricePoint{Price: p}
+        }
+        return out
+    }
+
+    tests := []struct {
+        name     string
+        prices   []*common.PricePoint
+        weights  []float64
+        minimum  int
+        kept     []int
+        rejected []int
+    }{
+        {"disabled below three sources", points(100, 200), []float64{1, 1}, 1, []int{0, 1}, nil},
+        {"single outlier", points(100, 100.2, 99.9, 100.1, 130), []float64{1, 1, 1, 1, 1}, 3, []int{0, 1, 2, 3}, []int{4}},
+        {"identical quotes keep close venues", points(100, 100, 100, 100.01), []float64{1, 1, 1, 1}, 2, []int{0, 1, 2, 3}, nil},
+        {"heavy venue shifts quartiles", points(100, 104, 104.1, 104.2), []float64{3, 1, 1, 1}, 2, []int{0, 1, 2, 3}, nil},
+        {"never below minimum", points(100, 100, 100, 100, 100, 100, 110, 111), []float64{1, 1, 1, 1, 1, 1, 1, 1}, 7, []int{0, 1, 2, 3, 4, 5, 6}, []int{7}},
+    }
+    for _, tt := range tests {
+        kept, rejected := rejectOutliers(tt.prices, tt.weights, 1.5, tt.minimum)
+        if !reflect.DeepEqual(kept, tt.kept) || !reflect.DeepEqual(rejected, tt.rejected) {
+            t.Errorf("%s: expected kept %v rejected %v, got %v %v", tt.name, tt.kept, tt.rejected, kept, rejected)
+        }
+    }
+}
@@ -0,0 +1,221 @@
+package crypto
+
+import (
+    "encoding/json"
+    "fmt"
+    "reflect"
+    "sort"
+    "strings"
+
+    "yetaXYZ/oracle/common"
+)
+
+// Change is one difference in a feed's effective behavior. From is unset
+// for additions and To for removals.
+type Change struct {
+    Field string      `json:"field"`
+    From  interface{} `json:"from,omitempty"`
+    To    interface{} `json:"to,omitempty"`
+}
+
+// PreviewPairConfig validates a pair configuration against the running
+// configuration, as ApplyPairConfig would, and returns how the feed's
+// behavior would change, without writing or activating anything
+func PreviewPairConfig(symbol string, pair *common.PairConfig) ([]Change, error) {
+    snapshot, err := CurrentConfig()
+    if err != nil {
+        return nil, err
+    }
+    if pair == nil {
+        return nil, fmt.Errorf("pair is required")
+    }
+    pair, err = resolveProfile(ProfilesConfig, symbol, pair)
+    if err != nil {
+        return nil, fmt.Errorf("invalid configuration: %v", err)
+    }
+
+    pairs := make(map[string]*common.PairConfig, len(snapshot.Pairs)+1)
+    for name, p := range snapshot.Pairs {
+        pairs[name] = p
+    }
+    pairs[symbol] = pair
+    if err := validateConfig(snapshot.Base, pairs, snapshot.Derived, snapshot.Statistics); err != nil {
+        return nil, fmt.Errorf("invalid configuration: %v", err)
+    }
+    return DiffPairConfig(snapshot.Pairs[symbol], pair)
+}
+
+// DiffPairConfig compares the effective behavior of two configurations of a
+// pair; current is nil for a new pair. Sources are compared by the weight
+// they carry in each tier's median, so setting an explicit weight of 1 is
+// not a change.
+func DiffPairConfig(current, proposed *common.PairConfig) ([]Change, error) {
+    before, err := effectiveBehavior(current)
+    if err != nil {
+        return nil, err
+    }
+    after, err := effectiveBehavior(proposed)
+    if err != nil {
+        return nil, err
+    }
+    return diffFields(before, after), nil
+}
+
+// DiffConfig compares two effective configurations, such as the configs of
+// a deployment's repository and what a running server has loaded. Pairs are
+// compared by their effective behavior as in DiffPairConfig, under
+// pairs.<symbol>.<field>; every other setting under its dotted JSON path,
+// e.g. base.exchanges.kraken.type. Changes read from from to to.
+func DiffConfig(from, to EffectiveConfig) ([]Change, error) {
+    before, err := effectiveFields(from)
+    if err != nil {
+        return nil, err
+    }
+    after, err := effectiveFields(to)
+    if err != nil {
+        return nil, err
+    }
+    return diffFields(before, after), nil
+}
+
+// effectiveFields flattens an effective configuration into dotted fields
+func effectiveFields(config EffectiveConfig) (map[string]interface{}, error) {
+    fields := make(map[string]interface{})
+    for symbol, pair := range config.Pairs {
+        behavior, err := effectiveBehavior(pair)
+        if err != nil {
+            return nil, fmt.Errorf("pair %s: %v", symbol, err)
+        }
+        for field, value := range behavior {
+            fields["pairs."+symbol+"."+field] = value
+        }
+    }
+
+    config.Pairs = nil
+    encoded, err := json.Marshal(config)
+    if err != nil {
+        return nil, fmt.Errorf("failed to encode config: %v", err)
+    }
+    var settings map[string]interface{}
+    if err := json.Unmarshal(encoded, &settings); err != nil {
+        return nil, fmt.Errorf("failed to decode config: %v", err)
+    }
+    delete(settings, "pairs")
+    flatten(fields, "", settings)
+    return fields, nil
+}
+
+// diffFields lists the fields that differ between two flattened
+// configurations, ordered by field
+func diffFields(before, after map[string]interface{}) []Change {
+    changes := make([]Change, 0)
+    for field, from := range before {
+        to, ok := after[field]
+        if !ok {
+            changes = append(changes, Change{Field: field, From: from})
+        } else if !reflect.DeepEqual(from, to) {
+            changes = append(changes, Change{Field: field, From: from, To: to})
+        }
+    }
+    for field, to := range after {
+        if _, ok := before[field]; !ok {
+            changes = append(changes, Change{Field: field, To: to})
+        }
+    }
+    sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+    return changes
+}
+
+// effectiveBehavior flattens a pair configuration into dotted fields: the
+// weight of every source each tier fetches under sources.<tier>.<source>,
+// and every other setting as configured
+func effectiveBehavior(pair *common.PairConfig) (map[string]interface{}, error) {
+    fields := make(map[string]interface{})
+    if pair == nil {
+        return fields, nil
+    }
+
+    for i, tier := range pairTiers(pair) {
+        name := "primary"
+        if i > 0 {
+            name = tierLabel(i)
+        }
+        for _, source := range tierSources(tier) {
+            fields["sources."+name+"."+source] = sourceWeight(pair, source)
+        }
+    }
+
+    encoded, err := json.Marshal(pair)
+    if err != nil {
+        return nil, fmt.Errorf("failed to encode pair: %v", err)
+    }
+    var settings map[string]interface{}
+    if err := json.Unmarshal(encoded, &settings); err != nil {
+        return nil, fmt.Errorf("failed to decode pair: %v", err)
+    }
+    delete(settings, "sources")
+    delete(settings, "fallbackTiers")
+    delete(settings, "sourceWeights")
+    flatten(fields, "", settings)
+    return fields, nil
+}
+
+// flatten copies nested JSON objects into fields under dotted names
+func flatten(fields map[string]interface{}, prefix string, value map[string]interface{}) {
+    for key, v := range value {
+        name := strings.TrimPrefix(prefix+"."+key, ".")
+        if nested, ok := v.(map[string]interface{}); ok {
+            flatten(fields, name, nested)
+            continue
+        }
+        fields[name] = v
+    }
+}
+
+// pairTiers returns a pair's primary sources followed by its fallback tiers
+func pairTiers(pair *common.PairConfig) []common.SourcesConfig {
+    return append([]common.SourcesConfig{pair.Sources}, pair.FallbackTiers...)
+}
+
+// tierLabel names the i-th tier of a pair as source prices record it:
+// empty for the primary tier, fallback-i for the others
+func tierLabel(i int) string {
+    if i == 0 {
+        return ""
+    }
+    return fmt.Sprintf("fallback-%d", i)
+}
+
+// PairSources returns the names of every source of a pair, the primary tier
+// first, without duplicates
+func PairSources(pair *common.PairConfig) []string {
+    sources := make([]string, 0)
+    seen := make(map[string]bool)
+    for _, tier := range pairTiers(pair) {
+        for _, source := range tierSources(tier) {
+            if !seen[source] {
+                seen[source] = true
+                sources = append(sources, source)
+            }
+        }
+    }
+    return sources
+}
+
+// tierSources returns the names of the sources a tier fetches, in the order
+// they are fetched
+func tierSources(tier common.SourcesConfig) []string {
+    var sources []string
+    if tier.CEX.Enabled {
+        sources = append(sources, tier.CEX.Exchanges...)
+    }
+    if tier.DEX.Enabled {
+        for _, pool := range tier.DEX.Pools {
+            sources = append(sources, PoolSourceName(pool))
+        }
+        for _, subgraph := range tier.DEX.Subgraphs {
+            sources = append(sources, subgraphSourceName(subgraph))
+        }
+    }
+    return sources
+}
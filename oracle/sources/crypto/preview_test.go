@@ -0,0 +1,136 @@
+package crypto
+
+import (
+    "strings"
+    "testing"
+
+    "yetaXYZ/oracle/common"
+)
+
+func TestDiffPairConfig(t *testing.T) {
+    current := &common.PairConfig{
+        BaseCurrency:   "ETH",
+        QuoteCurrency:  "USDT",
+        MinimumSources: 2,
+        Sources: common.SourcesConfig{
+            CEX: common.CEXSourceConfig{Enabled: true, Weight: 1, Exchanges: []string{"binance", "kraken"}},
+        },
+        Aggregation: common.AggregationParams{IQRMultiplier: 3},
+    }
+    proposed := *current
+    proposed.Sources.CEX.Exchanges = []string{"binance", "coinbase"}
+    proposed.SourceWeights = map[string]float64{"binance": 1, "coinbase": 2}
+    proposed.Aggregation = common.AggregationParams{IQRMultiplier: 4}
+
+    changes, err := DiffPairConfig(current, &proposed)
+    if err != nil {
+        t.Fatalf("Failed to diff: %v", err)
+    }
+    want := []Change{
+        {Field: "aggregation.iqrMultiplier", From: 3.0, To: 4.0},
+        {Field: "sources.primary.coinbase", To: 2.0},
+        {Field: "sources.primary.kraken", From: 1.0},
+    }
+    if len(changes) != len(want) {
+        t.Fatalf("Expected %d changes, got %+v", len(want), changes)
+    }
+    for i := range want {
+        if changes[i] != want[i] {
+            t.Errorf("Change %d: expected %+v, got %+v", i, want[i], changes[i])
+        }
+    }
+
+    if changes, _ := DiffPairConfig(current, current); len(changes) != 0 {
+        t.Errorf("Expected no changes, got %+v", changes)
+    }
+    if changes, _ := DiffPairConfig(nil, current); len(changes) == 0 {
+        t.Error("Expected a new pair to show every setting as added")
+    }
+}
+
+func TestPreviewPairConfigLeavesConfigUntouched(t *testing.T) {
+    savedBase, savedPairs := BaseConfig, PairsConfig
+    defer func() { BaseConfig, PairsConfig = savedBase, savedPairs }()
+
+    BaseConfig = &common.BaseConfig{
+        Exchanges: common.ExchangeConfig{CEX: map[string]common.CEXDetails{"binance": {Name: "Binance"}}},
+        Assets:    common.AssetConfig{"ETH": {Name: "Ethereum"}},
+    }
+    pair := &common.PairConfig{
+        BaseCurrency:  "ETH",
+        QuoteCurrency: "USDT",
+        Sources:       common.SourcesConfig{CEX: common.CEXSourceConfig{Enabled: true, Exchanges: []string{"binance"}}},
+    }
+    PairsConfig = map[string]*common.PairConfig{"ETHUSDT": pair}
+
+    proposed := *pair
+    proposed.SourceWeights = map[string]float64{"binance": 2}
+    changes, err := PreviewPairConfig("ETHUSDT", &proposed)
+    if err != nil {
+        t.Fatalf("Failed to preview: %v", err)
+    }
+    if len(changes) != 1 || changes[0].Field != "sources.primary.binance" {
+        t.Errorf("Expected the binance weight to change, got %+v", changes)
+    }
+    if PairsConfig["ETHUSDT"] != pair {
+        t.Error("Expected the running configuration to be unchanged")
+    }
+
+    proposed.SourceWeights = map[string]float64{"binance": -1}
+    if _, err := PreviewPairConfig("ETHUSDT", &proposed); err == nil {
+        t.Error("Expected error for a negative weight, got nil")
+    }
+}
+
+func TestDiffConfig(t *testing.T) {
+    pair := &common.PairConfig{
+        BaseCurrency:   "ETH",
+        QuoteCurrency:  "USDT",
+        MinimumSources: 2,
+        Sources: common.SourcesConfig{
+            CEX: common.CEXSourceConfig{Enabled: true, Weight: 1, Exchanges: []string{"binance", "kraken"}},
+        },
+    }
+    deployed := EffectiveConfig{
+        Base:  &common.BaseConfig{HTTP: common.HTTPIdentity{UserAgent: "oracle/1", CacheMs: 500}},
+        Pairs: map[string]*common.PairConfig{"ETHUSDT": pair},
+    }
+
+    drifted := *pair
+    drifted.MinimumSources = 3
+    drifted.SourceWeights = map[string]float64{"binance": 1}
+    local := EffectiveConfig{
+        Base:  &common.BaseConfig{HTTP: common.HTTPIdentity{UserAgent: "oracle/2", CacheMs: 500}},
+        Pairs: map[string]*common.PairConfig{"ETHUSDT": &drifted, "BTCUSDT": pair},
+    }
+
+    changes, err := DiffConfig(deployed, local)
+    if err != nil {
+        t.Fatalf("Failed to diff: %v", err)
+    }
+    fields := make(map[string]Change)
+    for _, c := range changes {
+        fields[c.Field] = c
+    }
+    if c := fields["base.http.userAgent"]; c.From != "oracle/1" || c.To != "oracle/2" {
+        t.Errorf("Expected the user agent change, got %+v", c)
+    }
+    if c := fields["pairs.ETHUSDT.minimumSources"]; c.From != 2.0 || c.To != 3.0 {
+        t.Errorf("Expected the minimum sources change, got %+v", c)
+    }
+    if c, ok := fields["pairs.BTCUSDT.sources.primary.binance"]; !ok || c.From != nil {
+        t.Errorf("Expected the new pair's sources as added, got %+v", c)
+    }
+    if _, ok := fields["base.http.cacheMs"]; ok {
+        t.Error("Expected unchanged settings to be left out")
+    }
+    for _, c := range changes {
+        if strings.HasPrefix(c.Field, "pairs.ETHUSDT.sources") {
+            t.Errorf("Expected an explicit weight of 1 not to be a change, got %+v", c)
+        }
+    }
+
+    if changes, _ := DiffConfig(deployed, deployed); len(changes) != 0 {
+        t.Errorf("Expected no changes, got %+v", changes)
+    }
+}
@@ -0,0 +1,135 @@
+package crypto
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+
+    "yetaXYZ/oracle/common"
+)
+
+// resolveProfiles checks the profiles and sets the aggregation parameters of
+// every pair that references one, see resolveProfile
+func resolveProfiles(profiles map[string]common.AggregationParams, pairs map[string]*common.PairConfig) error {
+    for name, profile := range profiles {
+        if err := validateAggregation("profile "+name, profile); err != nil {
+            return err
+        }
+    }
+    for symbol, pair := range pairs {
+        resolved, err := resolveProfile(profiles, symbol, pair)
+        if err != nil {
+            return err
+        }
+        pairs[symbol] = resolved
+    }
+    return nil
+}
+
+// resolveProfile returns a pair with its profile's aggregation parameters
+// applied to the fields the pair leaves unset. Pairs without a profile are
+// returned as they are.
+func resolveProfile(profiles map[string]common.AggregationParams, symbol string, pair *common.PairConfig) (*common.PairConfig, error) {
+    if pair == nil || pair.Profile == "" {
+        return pair, nil
+    }
+    profile, ok := profiles[pair.Profile]
+    if !ok {
+        return nil, fmt.Errorf("pair %s: unknown profile %q", symbol, pair.Profile)
+    }
+    resolved := *pair
+    resolved.Aggregation = mergeProfile(profile, pair.Aggregation)
+    return &resolved, nil
+}
+
+// mergeProfile returns the profile's parameters overridden by the fields
+// own sets
+func mergeProfile(profile, own common.AggregationParams) common.AggregationParams {
+    merged := profile
+    if own.VolumeBoost != "" {
+        merged.VolumeBoost = own.VolumeBoost
+    }
+    if own.MaxVolumeMultiplier != 0 {
+        merged.MaxVolumeMultiplier = own.MaxVolumeMultiplier
+    }
+    if own.IQRMultiplier != 0 {
+        merged.IQRMultiplier = own.IQRMultiplier
+    }
+    if own.SamplingWindowMs != 0 {
+        merged.SamplingWindowMs = own.SamplingWindowMs
+    }
+    if own.SamplesPerSource != 0 {
+        merged.SamplesPerSource = own.SamplesPerSource
+    }
+    return merged
+}
+
+// profileOverrides returns the fields of resolved parameters that differ
+// from the profile's, which is what a pair must set for itself
+func profileOverrides(profile, resolved common.AggregationParams) common.AggregationParams {
+    var own common.AggregationParams
+    if resolved.VolumeBoost != profile.VolumeBoost {
+        own.VolumeBoost = resolved.VolumeBoost
+    }
+    if resolved.MaxVolumeMultiplier != profile.MaxVolumeMultiplier {
+        own.MaxVolumeMultiplier = resolved.MaxVolumeMultiplier
+    }
+    if resolved.IQRMultiplier != profile.IQRMultiplier {
+        own.IQRMultiplier = resolved.IQRMultiplier
+    }
+    if resolved.SamplingWindowMs != profile.SamplingWindowMs {
+        own.SamplingWindowMs = resolved.SamplingWindowMs
+    }
+    if resolved.SamplesPerSource != profile.SamplesPerSource {
+        own.SamplesPerSource = resolved.SamplesPerSource
+    }
+    return own
+}
+
+// ApplyProfiles writes profiles into pairs.json, adding new ones and
+// replacing those of the same name, and reloads the configuration so that
+// the pairs referencing them take their parameters. If the resulting
+// configuration does not validate, the previous pairs.json is restored and
+// reloaded.
+func ApplyProfiles(configDir string, profiles map[string]common.AggregationParams) error {
+    pairsConfigPath := filepath.Join(configDir, "pairs", "pairs.json")
+    previous, err := os.ReadFile(pairsConfigPath)
+    if err != nil {
+        return fmt.Errorf("failed to read pairs config: %v", err)
+    }
+
+    pairsData, err := readPairsFile(pairsConfigPath)
+    if err != nil {
+        return err
+    }
+    if pairsData.Profiles == nil {
+        pairsData.Profiles = make(map[string]json.RawMessage, len(profiles))
+    }
+    for name, profile := range profiles {
+        encoded, err := json.Marshal(profile)
+        if err != nil {
+            return fmt.Errorf("failed to encode profile %s: %v", name, err)
+        }
+        pairsData.Profiles[name] = encoded
+    }
+    if err := pairsData.write(pairsConfigPath); err != nil {
+        return err
+    }
+
+    err = LoadConfig(configDir)
+    if err == nil {
+        err = ValidateConfig()
+    }
+    if err == nil {
+        return nil
+    }
+
+    if restoreErr := os.WriteFile(pairsConfigPath, previous, 0644); restoreErr != nil {
+        return fmt.Errorf("invalid configuration (%v) and failed to restore previous: %v", err, restoreErr)
+    }
+    if reloadErr := LoadConfig(configDir); reloadErr != nil {
+        return fmt.Errorf("invalid configuration (%v) and failed to reload previous: %v", err, reloadErr)
+    }
+    return fmt.Errorf("invalid configuration: %v", err)
+}
@@ -0,0 +1,89 @@
+package crypto
+
+import (
+    "encoding/json"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/testutil"
+)
+
+func TestProfiles(t *testing.T) {
+    savedBase, savedPairs, savedProfiles := BaseConfig, PairsConfig, ProfilesConfig
+    defer func() { BaseConfig, PairsConfig, ProfilesConfig = savedBase, savedPairs, savedProfiles }()
+
+    liquid := common.AggregationParams{VolumeBoost: common.VolumeBoostSqrt, MaxVolumeMultiplier: 3, IQRMultiplier: 1.5}
+    config := testutil.NewConfig().
+        WithExchange(testutil.NewBinance(t)).
+        WithProfile("major-liquid", liquid).
+        WithPair("BTCUSDT", "BTC", "USDT", 1, "binance").
+        WithPair("ETHUSDT", "ETH", "USDT", 1, "binance")
+    config.Pairs["BTCUSDT"].Profile = "major-liquid"
+    config.Pairs["ETHUSDT"].Profile = "major-liquid"
+    config.Pairs["ETHUSDT"].Aggregation = common.AggregationParams{IQRMultiplier: 3}
+    dir := config.Write(t)
+
+    if err := LoadConfig(dir); err != nil {
+        t.Fatal(err)
+    }
+    if got := PairsConfig["BTCUSDT"].Aggregation; got != liquid {
+        t.Errorf("Expected BTCUSDT to take the profile's parameters, got %+v", got)
+    }
+    eth := PairsConfig["ETHUSDT"].Aggregation
+    if eth.IQRMultiplier != 3 || eth.VolumeBoost != common.VolumeBoostSqrt {
+        t.Errorf("Expected ETHUSDT to override only iqrMultiplier, got %+v", eth)
+    }
+
+    // Writing a pair back keeps only what differs from its profile
+    btc := *PairsConfig["BTCUSDT"]
+    btc.Aggregation.SamplingWindowMs = 500
+    if err := UpdatePairConfig(dir, "BTCUSDT", &btc); err != nil {
+        t.Fatal(err)
+    }
+    data, err := os.ReadFile(filepath.Join(dir, "pairs", "pairs.json"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    var written struct {
+        Profiles map[string]common.AggregationParams `json:"profiles"`
+        Pairs    map[string]*common.PairConfig       `json:"pairs"`
+    }
+    if err := json.Unmarshal(data, &written); err != nil {
+        t.Fatal(err)
+    }
+    if got := written.Pairs["BTCUSDT"].Aggregation; got != (common.AggregationParams{SamplingWindowMs: 500}) {
+        t.Errorf("Expected only samplingWindowMs written for BTCUSDT, got %+v", got)
+    }
+    if written.Profiles["major-liquid"] != liquid {
+        t.Errorf("Expected the profiles kept, got %+v", written.Profiles)
+    }
+
+    // Retuning the profile retunes the pairs that inherit from it
+    retuned := liquid
+    retuned.IQRMultiplier = 2
+    if err := ApplyProfiles(dir, map[string]common.AggregationParams{"major-liquid": retuned}); err != nil {
+        t.Fatal(err)
+    }
+    if got := PairsConfig["BTCUSDT"].Aggregation; got.IQRMultiplier != 2 || got.SamplingWindowMs != 500 {
+        t.Errorf("Expected BTCUSDT retuned with its own sampling window, got %+v", got)
+    }
+    if got := PairsConfig["ETHUSDT"].Aggregation; got.IQRMultiplier != 3 {
+        t.Errorf("Expected ETHUSDT to keep its override, got %+v", got)
+    }
+
+    // An invalid profile is refused and the previous one restored
+    err = ApplyProfiles(dir, map[string]common.AggregationParams{"major-liquid": {VolumeBoost: "cubic"}})
+    if err == nil || !strings.Contains(err.Error(), "profile major-liquid") {
+        t.Fatalf("Expected an invalid profile to be refused, got %v", err)
+    }
+    if ProfilesConfig["major-liquid"] != retuned {
+        t.Errorf("Expected the previous profile restored, got %+v", ProfilesConfig["major-liquid"])
+    }
+
+    if _, err := PreviewPairConfig("BTCUSDT", &common.PairConfig{Profile: "long-tail"}); err == nil {
+        t.Error("Expected a pair with an unknown profile to be refused")
+    }
+}
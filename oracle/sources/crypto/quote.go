@@ -0,0 +1,73 @@
+package crypto
+
+import (
+    "fmt"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/symbols"
+)
+
+// FeedLookup returns the latest round of a feed
+type FeedLookup func(symbol string) (*common.AggregateResult, bool)
+
+// SetFeedLookup sets where the prices of quote member feeds are read from
+func (a *CryptoAggregator) SetFeedLookup(lookup FeedLookup) {
+    a.feeds = lookup
+}
+
+// quoteAsset returns the asset an exchange is fetched in for a pair and the
+// exchange symbol to fetch, e.g. BTCUSDT for a BTCUSD pair on binance
+func quoteAsset(base *common.BaseConfig, symbol string, pairConfig *common.PairConfig, exchange string) (string, string) {
+    quote, ok := pairConfig.QuoteAssets[exchange]
+    if !ok {
+        quote = pairConfig.QuoteCurrency
+    }
+    if pairConfig.BaseCurrency == "" {
+        return quote, symbol
+    }
+    return quote, symbols.New(base).Ticker(exchange, pairConfig.BaseCurrency, quote)
+}
+
+// quotedSource returns the attribution of a source fetched in quote and
+// the factor converting its prices into the pair's quote currency
+func (a *CryptoAggregator) quotedSource(base *common.BaseConfig, pairConfig *common.PairConfig, name, tierName, quote string) (common.SourcePrice, float64, error) {
+    source := common.SourcePrice{Source: name, Tier: tierName}
+    if quote == "" || quote == pairConfig.QuoteCurrency {
+        return source, 1, nil
+    }
+    factor, err := a.quoteFactor(base, pairConfig, quote)
+    if err != nil {
+        return source, 0, err
+    }
+    source.Quote, source.QuoteRate = quote, factor
+    return source, factor, nil
+}
+
+// poolQuote returns the asset a DEX source trades the pair's base against
+func poolQuote(pairConfig *common.PairConfig, quote string) string {
+    if quote == "" {
+        return pairConfig.QuoteCurrency
+    }
+    return quote
+}
+
+// quoteFactor returns the factor converting prices quoted in quote into the
+// pair's quote currency
+func (a *CryptoAggregator) quoteFactor(base *common.BaseConfig, pairConfig *common.PairConfig, quote string) (float64, error) {
+    if quote == pairConfig.QuoteCurrency {
+        return 1, nil
+    }
+    member, ok := base.QuoteClasses[pairConfig.QuoteCurrency].Member(quote)
+    if !ok {
+        return 0, fmt.Errorf("%s is not in the %s quote class", quote, pairConfig.QuoteCurrency)
+    }
+
+    var feed *common.PricePoint
+    if member.Feed != "" && a.feeds != nil {
+        if result, ok := a.feeds(member.Feed); ok {
+            feed = &result.PricePoint
+        }
+    }
+    return member.Rate(feed, time.Now())
+}
@@ -0,0 +1,73 @@
+package crypto
+
+import (
+    "context"
+    "crypto/rand"
+    "fmt"
+    "math/big"
+    "sort"
+    "time"
+
+    "yetaXYZ/oracle/common"
+)
+
+// sample fetches the source n times at randomized offsets within the window
+// from start and returns its median sample. Offsets are drawn from a
+// cryptographic source so that no read time can be anticipated.
+func (job *sourceFetch) sample(ctx context.Context, start time.Time, window time.Duration, n int) (*common.PricePoint, error) {
+    offsets, err := randomOffsets(window, n)
+    if err != nil {
+        return nil, err
+    }
+
+    samples := make([]*common.PricePoint, 0, n)
+    var lastErr error
+    for _, offset := range offsets {
+        if wait := time.Until(start.Add(offset)); wait > 0 {
+            timer := time.NewTimer(wait)
+            select {
+            case <-ctx.Done():
+                timer.Stop()
+                return medianSample(samples, ctx.Err())
+            case <-timer.C:
+            }
+            job.waited += wait
+        }
+        price, err := job.fetch(ctx)
+        if err != nil {
+            lastErr = err
+            continue
+        }
+        if price != nil {
+            samples = append(samples, price)
+        }
+    }
+    return medianSample(samples, lastErr)
+}
+
+// medianSample returns the sample with the median price, the lower middle
+// one for an even count, or err without samples
+func medianSample(samples []*common.PricePoint, err error) (*common.PricePoint, error) {
+    if len(samples) == 0 {
+        if err == nil {
+            return nil, nil
+        }
+        return nil, err
+    }
+    sort.Slice(samples, func(i, j int) bool { return samples[i].Price < samples[j].Price })
+    return samples[(len(samples)-1)/2], nil
+}
+
+// randomOffsets returns n sorted offsets drawn uniformly from [0, window)
+func randomOffsets(window time.Duration, n int) ([]time.Duration, error) {
+    offsets := make([]time.Duration, n)
+    for i := range offsets {
+        v, err := rand.Int(rand.Reader, big.NewInt(int64(window)))
+        if err != nil {
+            return nil, fmt.Errorf("failed to draw sampling offset: %v", err)
+        }
+        offsets[i] = time.Duration(v.Int64())
+    }
+    sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+    return offsets, nil
+}
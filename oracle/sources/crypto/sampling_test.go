@@ -0,0 +1,57 @@
+package crypto
+
+import (
+    "context"
+    "fmt"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+)
+
+func TestSampleSpreadsFetchesOverWindow(t *testing.T) {
+    prices := []float64{10, 30, 20}
+    var fetchedAt []time.Duration
+    start := time.Now()
+    job := &sourceFetch{fetch: func(ctx context.Context) (*common.PricePoint, error) {
+        fetchedAt = append(fetchedAt, time.Since(start))
+        return &common.PricePoint{Price: prices[len(fetchedAt)-1]}, nil
+    }}
+
+    window := 150 * time.Millisecond
+    price, err := job.sample(context.Background(), start, window, 3)
+    if err != nil {
+        t.Fatalf("Failed to sample: %v", err)
+    }
+    if price.Price != 20 {
+        t.Errorf("Expected the median sample 20, got %f", price.Price)
+    }
+    if len(fetchedAt) != 3 || fetchedAt[2] > window+50*time.Millisecond {
+        t.Errorf("Expected 3 fetches within the window, got %v", fetchedAt)
+    }
+    if job.waited <= 0 || job.waited > window {
+        t.Errorf("Expected the wait for offsets to be recorded within the window, got %s", job.waited)
+    }
+}
+
+func TestSampleKeepsSuccessfulSamples(t *testing.T) {
+    calls := 0
+    job := &sourceFetch{fetch: func(ctx context.Context) (*common.PricePoint, error) {
+        calls++
+        if calls == 2 {
+            return nil, fmt.Errorf("timeout")
+        }
+        return &common.PricePoint{Price: float64(calls)}, nil
+    }}
+    price, err := job.sample(context.Background(), time.Now(), 10*time.Millisecond, 3)
+    if err != nil || price == nil || price.Price != 1 {
+        t.Errorf("Expected the lower median of the two successful samples, got %+v, %v", price, err)
+    }
+
+    failing := &sourceFetch{fetch: func(ctx context.Context) (*common.PricePoint, error) {
+        return nil, fmt.Errorf("unavailable")
+    }}
+    if _, err := failing.sample(context.Background(), time.Now(), 10*time.Millisecond, 2); err == nil {
+        t.Error("Expected error when every sample fails, got nil")
+    }
+}
@@ -0,0 +1,58 @@
+package crypto
+
+import (
+    "log"
+
+    "yetaXYZ/oracle/common"
+)
+
+// aggregateSides sets the bid, mid and ask of a round from its kept
+// sources quoting both sides, weighted as in the round's median. A round
+// with fewer such sources than the pair's minimum gets none, leaving its
+// sub-feeds at their last values.
+func (a *CryptoAggregator) aggregateSides(pair *common.PairConfig, result *common.AggregateResult, weights []float64) {
+    var bids, asks, mids []*common.PricePoint
+    var sideWeights []float64
+    for i, source := range result.Sources {
+        if source.Bid <= 0 || source.Ask < source.Bid {
+            continue
+        }
+        bids = append(bids, &common.PricePoint{Price: source.Bid})
+        asks = append(asks, &common.PricePoint{Price: source.Ask})
+        mids = append(mids, &common.PricePoint{Price: (source.Bid + source.Ask) / 2})
+        sideWeights = append(sideWeights, weights[i])
+    }
+    need := pair.BidAsk.MinimumSources
+    if need < 1 {
+        need = 1
+    }
+    if len(bids) < need {
+        log.Printf("Only %d sources of %s quote both sides, %d needed for its bid and ask", len(bids), result.Symbol, need)
+        return
+    }
+
+    var bid, ask float64
+    if pair.BidAsk.Method == common.BidAskWidest {
+        bid, ask = bids[0].Price, asks[0].Price
+        for i := range bids {
+            if bids[i].Price < bid {
+                bid = bids[i].Price
+            }
+            if asks[i].Price > ask {
+                ask = asks[i].Price
+            }
+        }
+    } else {
+        bid = a.calculateMedian(bids, sideWeights).Price
+        ask = a.calculateMedian(asks, sideWeights).Price
+    }
+    // The median of the mids need not fall between the sides it is
+    // published with
+    mid := a.calculateMedian(mids, sideWeights).Price
+    if mid < bid {
+        mid = bid
+    } else if mid > ask {
+        mid = ask
+    }
+    result.Bid, result.Mid, result.Ask = bid, mid, ask
+}
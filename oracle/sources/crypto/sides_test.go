@@ -0,0 +1,76 @@
+package crypto
+
+import (
+    "io"
+    "net/http"
+    "strings"
+    "testing"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+)
+
+func TestAggregateSides(t *testing.T) {
+    savedBase, savedPairs := BaseConfig, PairsConfig
+    defer func() { BaseConfig, PairsConfig = savedBase, savedPairs }()
+
+    bodies := map[string]string{
+        "api.binance.com":  `{"lastPrice": "3000", "volume": "10", "bidPrice": "2999", "askPrice": "3001"}`,
+        "api.kraken.com":   `{"result": {"XETHZUSD": {"c": ["3002", "1"], "v": ["5", "5"], "a": ["3003", "1", "1"], "b": ["3001.5", "1", "1"]}}}`,
+        "api.coinbase.com": `{"price": "3001", "volume_24h": "3"}`,
+    }
+    aggregate := func(bidAsk *common.BidAskConfig) *common.AggregateResult {
+        BaseConfig = &common.BaseConfig{}
+        PairsConfig = map[string]*common.PairConfig{"ETHUSD": {
+            BaseCurrency:   "ETH",
+            QuoteCurrency:  "USD",
+            MinimumSources: 1,
+            Sources:        common.SourcesConfig{CEX: common.CEXSourceConfig{Enabled: true, Weight: 1, Exchanges: []string{"binance", "kraken", "coinbase"}}},
+            BidAsk:         bidAsk,
+        }}
+        a := NewCryptoAggregator(BaseConfig)
+        a.SetEventBus(events.NewBus())
+        a.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+            return &http.Response{
+                StatusCode: http.StatusOK,
+                Header:     http.Header{"Content-Type": []string{"application/json"}},
+                Body:       io.NopCloser(strings.NewReader(bodies[r.URL.Host])),
+                Request:    r,
+            }, nil
+        })
+        result, err := a.Aggregate("ETHUSD")
+        if err != nil {
+            t.Fatal(err)
+        }
+        return result
+    }
+
+    // Coinbase's product endpoint quotes no sides and is left out
+    result := aggregate(&common.BidAskConfig{})
+    if result.Bid != 3001.5 || result.Mid != 3002.25 || result.Ask != 3003 {
+        t.Errorf("Expected the weighted medians 3001.5/3002.25/3003, got %v/%v/%v", result.Bid, result.Mid, result.Ask)
+    }
+    result = aggregate(&common.BidAskConfig{Method: common.BidAskWidest})
+    if result.Bid != 2999 || result.Ask != 3003 {
+        t.Errorf("Expected the widest sides 2999/3003, got %v/%v", result.Bid, result.Ask)
+    }
+    result = aggregate(&common.BidAskConfig{MinimumSources: 3})
+    if result.Bid != 0 || result.Ask != 0 || result.Price == 0 {
+        t.Errorf("Expected a priced round without sides, got %+v", result)
+    }
+    result = aggregate(nil)
+    if result.Bid != 0 || result.Mid != 0 {
+        t.Errorf("Expected no sides without bidAsk, got %+v", result)
+    }
+}
+
+func TestParseTouch(t *testing.T) {
+    if bid, ask := parseTouch("100.5", "100.7"); bid != 100.5 || ask != 100.7 {
+        t.Errorf("Expected 100.5/100.7, got %v/%v", bid, ask)
+    }
+    for _, touch := range [][2]string{{"101", "100"}, {"", "100"}, {"0", "0"}} {
+        if bid, ask := parseTouch(touch[0], touch[1]); bid != 0 || ask != 0 {
+            t.Errorf("Expected %v to be rejected, got %v/%v", touch, bid, ask)
+        }
+    }
+}
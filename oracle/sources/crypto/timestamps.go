@@ -0,0 +1,65 @@
+package crypto
+
+import (
+    "fmt"
+    "time"
+
+    "yetaXYZ/oracle/common"
+)
+
+// TimestampError is returned for a source whose own timestamp is too far
+// ahead of the local clock or too old, after allowing for clock skew
+type TimestampError struct {
+    Source    string
+    Timestamp time.Time
+    Future    bool
+}
+
+func (e *TimestampError) Error() string {
+    if e.Future {
+        return fmt.Sprintf("%s reported a future timestamp %s", e.Source, e.Timestamp.Format(time.RFC3339Nano))
+    }
+    return fmt.Sprintf("%s reported a stale timestamp %s", e.Source, e.Timestamp.Format(time.RFC3339Nano))
+}
+
+// clockSkew returns an exchange's clock skew config, the defaults when it
+// has none
+func clockSkew(base *common.BaseConfig, exchange string) *common.ClockSkewConfig {
+    if base != nil {
+        if details, ok := base.Exchanges.CEX[exchange]; ok && details.ClockSkew != nil {
+            return details.ClockSkew
+        }
+    }
+    return &common.ClockSkewConfig{}
+}
+
+// sourceTimestamp interprets an upstream timestamp against the local clock:
+// one ahead within the tolerated skew is taken as now, so that downstream
+// ages never go negative, and one older than the maximum age plus the
+// allowance for a lagging clock is stale
+func sourceTimestamp(source string, skew *common.ClockSkewConfig, upstream, now time.Time) (time.Time, error) {
+    if upstream.IsZero() {
+        return now, nil
+    }
+    if ahead := upstream.Sub(now); ahead > 0 {
+        if ahead > skew.Ahead() {
+            return time.Time{}, &TimestampError{Source: source, Timestamp: upstream, Future: true}
+        }
+        return now, nil
+    }
+    if now.Sub(upstream) > skew.MaxAge()+skew.Behind() {
+        return time.Time{}, &TimestampError{Source: source, Timestamp: upstream}
+    }
+    return upstream, nil
+}
+
+// validateClockSkew checks an exchange's clock skew config
+func validateClockSkew(exchange string, c *common.ClockSkewConfig) error {
+    if c.AheadMs < 0 || c.BehindMs < 0 || c.MaxAgeSeconds < 0 {
+        return fmt.Errorf("exchange %s: clockSkew values must not be negative", exchange)
+    }
+    if c.Ahead() >= c.MaxAge() || c.Behind() >= c.MaxAge() {
+        return fmt.Errorf("exchange %s: clockSkew aheadMs and behindMs must be below maxAgeSeconds", exchange)
+    }
+    return nil
+}
@@ -0,0 +1,51 @@
+package crypto
+
+import (
+    "errors"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+)
+
+func TestSourceTimestamp(t *testing.T) {
+    now := time.Now()
+    skew := &common.ClockSkewConfig{AheadMs: 500, BehindMs: 2000, MaxAgeSeconds: 10}
+
+    // An exchange clock slightly ahead is read as now
+    if ts, err := sourceTimestamp("binance", skew, now.Add(400*time.Millisecond), now); err != nil || !ts.Equal(now) {
+        t.Errorf("Expected a timestamp within the skew clamped to now, got %v, %v", ts, err)
+    }
+    var tsErr *TimestampError
+    if _, err := sourceTimestamp("binance", skew, now.Add(time.Second), now); !errors.As(err, &tsErr) || !tsErr.Future {
+        t.Errorf("Expected a future timestamp error, got %v", err)
+    }
+
+    // A lagging clock extends the maximum age
+    past := now.Add(-11 * time.Second)
+    if ts, err := sourceTimestamp("binance", skew, past, now); err != nil || !ts.Equal(past) {
+        t.Errorf("Expected a timestamp within the allowance kept, got %v, %v", ts, err)
+    }
+    if _, err := sourceTimestamp("binance", skew, now.Add(-13*time.Second), now); !errors.As(err, &tsErr) || tsErr.Future {
+        t.Errorf("Expected a stale timestamp error, got %v", err)
+    }
+
+    if ts, err := sourceTimestamp("kraken", skew, time.Time{}, now); err != nil || !ts.Equal(now) {
+        t.Errorf("Expected a missing timestamp read as now, got %v, %v", ts, err)
+    }
+}
+
+func TestValidateClockSkew(t *testing.T) {
+    if err := validateClockSkew("binance", &common.ClockSkewConfig{}); err != nil {
+        t.Errorf("Expected defaults to validate, got %v", err)
+    }
+    for _, config := range []*common.ClockSkewConfig{
+        {AheadMs: -1},
+        {AheadMs: 5000, MaxAgeSeconds: 5},
+        {BehindMs: 90000},
+    } {
+        if err := validateClockSkew("binance", config); err == nil {
+            t.Errorf("Expected %+v to be rejected", config)
+        }
+    }
+}
@@ -0,0 +1,101 @@
+package crypto
+
+import (
+    "encoding/json"
+    "fmt"
+    "time"
+)
+
+// FilteredTrade is a trade print dropped as a bad tick, with the touch it
+// was checked against
+type FilteredTrade struct {
+    Price     float64   `json:"price"`
+    Bid       float64   `json:"bid"`
+    Ask       float64   `json:"ask"`
+    Deviation float64   `json:"deviation"` // relative distance outside the touch
+    At        time.Time `json:"at"`
+}
+
+// tradeTape is the last trade print of a streamed symbol that passed the
+// bad tick filter, with counters of the prints seen
+type tradeTape struct {
+    price    float64
+    at       time.Time // exchange trade time
+    accepted uint64
+    filtered uint64
+    // unchecked counts prints received while the book was not synced,
+    // which cannot be judged and are dropped
+    unchecked    uint64
+    lastFiltered *FilteredTrade
+}
+
+// applyTrade runs a trade print through the bad tick filter: a print
+// further than tolerance outside the best bid and ask, relative to the
+// touch, is dropped and counted. It reports whether the print was kept.
+func (l *localBook) applyTrade(price float64, at time.Time, tolerance float64) bool {
+    top := l.top(1)
+    if !l.synced || len(top.Bids) == 0 || len(top.Asks) == 0 {
+        l.trades.unchecked++
+        return false
+    }
+    bid, ask := top.Bids[0].Price, top.Asks[0].Price
+    deviation := 0.0
+    switch {
+    case price < bid:
+        deviation = (bid - price) / bid
+    case price > ask:
+        deviation = (price - ask) / ask
+    }
+    if price <= 0 || deviation > tolerance {
+        l.trades.filtered++
+        l.trades.lastFiltered = &FilteredTrade{Price: price, Bid: bid, Ask: ask, Deviation: deviation, At: at}
+        return false
+    }
+    l.trades.accepted++
+    if !at.Before(l.trades.at) {
+        l.trades.price, l.trades.at = price, at
+    }
+    return true
+}
+
+// binanceTrade is an event of Binance's trade stream
+type binanceTrade struct {
+    Price string `json:"p"`
+    Time  int64  `json:"T"` // trade time, exchange clock
+}
+
+// applyBinanceTrade runs a Binance trade event through the filter
+func (l *localBook) applyBinanceTrade(message []byte, tolerance float64) error {
+    var trade binanceTrade
+    if err := json.Unmarshal(message, &trade); err != nil {
+        return fmt.Errorf("invalid trade: %v", err)
+    }
+    price, err := parseFloat(trade.Price)
+    if err != nil {
+        return fmt.Errorf("invalid trade price %q", trade.Price)
+    }
+    l.applyTrade(price, time.UnixMilli(trade.Time), tolerance)
+    return nil
+}
+
+// krakenTradeMessage is a message of Kraken's v2 trade channel; the
+// snapshot carries the most recent trades
+type krakenTradeMessage struct {
+    Data []struct {
+        Price     float64   `json:"price"`
+        Timestamp time.Time `json:"timestamp"`
+    } `json:"data"`
+}
+
+// applyKrakenTrades runs the prints of a Kraken trade message through the
+// filter
+func (l *localBook) applyKrakenTrades(message []byte, tolerance float64) error {
+    var m krakenTradeMessage
+    if err := json.Unmarshal(message, &m); err != nil {
+        return fmt.Errorf("invalid trade message: %v", err)
+    }
+    for _, trade := range m.Data {
+        l.applyTrade(trade.Price, trade.Timestamp, tolerance)
+    }
+    return nil
+}
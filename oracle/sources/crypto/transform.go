@@ -0,0 +1,53 @@
+package crypto
+
+import (
+    "fmt"
+    "math"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/expr"
+)
+
+// transform applies a pair's output transform to its aggregated price.
+// Identifiers resolve to the price, the pair's transform variables or the
+// latest price of another feed.
+func (a *CryptoAggregator) transform(pair *common.PairConfig, price float64) (float64, error) {
+    e, err := expr.Parse(pair.Transform)
+    if err != nil {
+        return 0, err
+    }
+    return e.Eval(func(name string) (float64, error) {
+        if name == "price" {
+            return price, nil
+        }
+        if value, ok := pair.TransformVariables[name]; ok {
+            return value, nil
+        }
+        if a.feeds != nil {
+            if result, ok := a.feeds(name); ok {
+                return result.Price, nil
+            }
+        }
+        return 0, fmt.Errorf("feed %s unavailable", name)
+    })
+}
+
+// validateBounds checks that a range feed's floor and cap are finite and
+// the floor is below the cap
+func validateBounds(symbol string, bounds *common.PriceBounds) error {
+    if bounds == nil {
+        return nil
+    }
+    for _, bound := range []*float64{bounds.Floor, bounds.Cap} {
+        if bound != nil && (math.IsNaN(*bound) || math.IsInf(*bound, 0)) {
+            return fmt.Errorf("pair %s: bounds must be finite", symbol)
+        }
+    }
+    if bounds.Floor == nil && bounds.Cap == nil {
+        return fmt.Errorf("pair %s: bounds need a floor or a cap", symbol)
+    }
+    if bounds.Floor != nil && bounds.Cap != nil && *bounds.Floor >= *bounds.Cap {
+        return fmt.Errorf("pair %s: bounds floor must be below the cap", symbol)
+    }
+    return nil
+}
@@ -0,0 +1,77 @@
+package crypto
+
+import (
+    "math"
+    "testing"
+
+    "yetaXYZ/oracle/common"
+)
+
+func TestTransformResolvesVariablesAndFeeds(t *testing.T) {
+    a := NewCryptoAggregator(&common.BaseConfig{})
+    a.SetFeedLookup(func(symbol string) (*common.AggregateResult, bool) {
+        if symbol == "ETHBTC" {
+            return &common.AggregateResult{PricePoint: common.PricePoint{Price: 0.05}}, true
+        }
+        return nil, false
+    })
+
+    pair := &common.PairConfig{Transform: "(price - fundingAdjustment) * ETHBTC", TransformVariables: map[string]float64{"fundingAdjustment": 10}}
+    price, err := a.transform(pair, 3010)
+    if err != nil || math.Abs(price-150) > 1e-9 {
+        t.Errorf("Expected 150, got %f, %v", price, err)
+    }
+
+    pair.Transform = "price * SOLUSDT"
+    if _, err := a.transform(pair, 3010); err == nil {
+        t.Error("Expected error for an unavailable feed, got nil")
+    }
+}
+
+func TestValidateTransform(t *testing.T) {
+    feeds := map[string]bool{"ETHUSDT": true, "BTCUSDT": true}
+    cases := map[string]bool{
+        "price * 1e8":     true,
+        "1 / price":       true,
+        "price / BTCUSDT": true,
+        "price - funding": true,
+        "price - unknown": false,
+        "price * ETHUSDT": false, // its own feed
+        "price *":         false,
+    }
+    for transform, valid := range cases {
+        pair := &common.PairConfig{Transform: transform, TransformVariables: map[string]float64{"funding": 1}}
+        err := validateTransform("ETHUSDT", pair, feeds)
+        if valid && err != nil {
+            t.Errorf("%s: expected valid, got %v", transform, err)
+        }
+        if !valid && err == nil {
+            t.Errorf("%s: expected error, got nil", transform)
+        }
+    }
+}
+
+func TestBounds(t *testing.T) {
+    floor, ceiling := 0.0, 1.0
+    bounds := &common.PriceBounds{Floor: &floor, Cap: &ceiling}
+    for price, want := range map[float64]struct {
+        price float64
+        bound string
+    }{-0.2: {0, common.BoundFloor}, 0.4: {0.4, ""}, 1.3: {1, common.BoundCap}} {
+        if got, bound := bounds.Clamp(price); got != want.price || bound != want.bound {
+            t.Errorf("%v: expected %v (%s), got %v (%s)", price, want.price, want.bound, got, bound)
+        }
+    }
+    if got, bound := (*common.PriceBounds)(nil).Clamp(5); got != 5 || bound != "" {
+        t.Errorf("Expected no bounds to keep the price, got %v (%s)", got, bound)
+    }
+
+    if err := validateBounds("ETHUSDT", bounds); err != nil {
+        t.Errorf("Expected valid bounds, got %v", err)
+    }
+    for _, invalid := range []*common.PriceBounds{{}, {Floor: &ceiling, Cap: &floor}, {Floor: &ceiling, Cap: &ceiling}} {
+        if err := validateBounds("ETHUSDT", invalid); err == nil {
+            t.Errorf("Expected %+v to be rejected", invalid)
+        }
+    }
+}
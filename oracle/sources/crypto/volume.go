@@ -0,0 +1,128 @@
+package crypto
+
+import (
+    "fmt"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/common"
+)
+
+// minVolumeSpan is the least time a since-midnight or lifetime volume must
+// cover before it is extrapolated to 24 hours; earlier it is unknown
+const minVolumeSpan = time.Hour
+
+// builtinVolumes are the semantics of the ticker volumes the aggregator
+// reads from known exchanges
+var builtinVolumes = map[string]common.VolumeSemantics{
+    "binance":  {Basis: common.VolumeRolling24h, Unit: common.VolumeUnitBase}, // ticker/24hr volume
+    "coinbase": {Basis: common.VolumeRolling24h, Unit: common.VolumeUnitBase}, // product volume_24h
+    "kraken":   {Basis: common.VolumeRolling24h, Unit: common.VolumeUnitBase}, // Ticker v[1]
+}
+
+// volumeSemantics returns what an exchange's volume measures, the
+// configured semantics taking precedence over the built-in ones
+func volumeSemantics(base *common.BaseConfig, exchange string) common.VolumeSemantics {
+    semantics := builtinVolumes[exchange]
+    if base != nil {
+        if details, ok := base.Exchanges.CEX[exchange]; ok && details.Volume != nil {
+            semantics = *details.Volume
+        }
+    }
+    if semantics.Basis == "" {
+        semantics.Basis = common.VolumeRolling24h
+    }
+    if semantics.Unit == "" {
+        semantics.Unit = common.VolumeUnitBase
+    }
+    return semantics
+}
+
+// validateVolume checks an exchange's volume semantics
+func validateVolume(exchange string, v *common.VolumeSemantics) error {
+    switch v.Basis {
+    case "", common.VolumeRolling24h, common.VolumeSinceMidnight, common.VolumeLifetime:
+    default:
+        return fmt.Errorf("exchange %s: unknown volume basis %q, want %s, %s or %s", exchange, v.Basis, common.VolumeRolling24h, common.VolumeSinceMidnight, common.VolumeLifetime)
+    }
+    switch v.Unit {
+    case "", common.VolumeUnitBase, common.VolumeUnitQuote:
+    default:
+        return fmt.Errorf("exchange %s: unknown volume unit %q, want %s or %s", exchange, v.Unit, common.VolumeUnitBase, common.VolumeUnitQuote)
+    }
+    return nil
+}
+
+// volumeReading is a lifetime volume counter as read at a time
+type volumeReading struct {
+    at    time.Time
+    total float64
+}
+
+// volumeNormalizer converts reported volumes to rolling 24h base volume.
+// Lifetime counters are differenced against the readings kept over the
+// last 24 hours of each source.
+type volumeNormalizer struct {
+    mu       sync.Mutex
+    readings map[string][]volumeReading
+}
+
+func newVolumeNormalizer() *volumeNormalizer {
+    return &volumeNormalizer{readings: make(map[string][]volumeReading)}
+}
+
+// normalize converts a volume reported by the source key with the given
+// semantics at price. Zero stands for an unknown volume, which leaves the
+// source's weight unboosted.
+func (n *volumeNormalizer) normalize(key string, semantics common.VolumeSemantics, volume, price float64, now time.Time) float64 {
+    if volume <= 0 {
+        return 0
+    }
+    switch semantics.Basis {
+    case common.VolumeSinceMidnight:
+        now = now.UTC()
+        elapsed := now.Sub(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC))
+        if elapsed < minVolumeSpan {
+            return 0
+        }
+        volume *= float64(24*time.Hour) / float64(elapsed)
+    case common.VolumeLifetime:
+        volume = n.delta(key, volume, now)
+    }
+    // Converted last, as a lifetime quote counter is differenced in quote
+    if semantics.Unit == common.VolumeUnitQuote {
+        if price <= 0 {
+            return 0
+        }
+        volume /= price
+    }
+    return volume
+}
+
+// delta returns the 24h volume implied by a lifetime counter, extrapolated
+// from a shorter history once it spans minVolumeSpan
+func (n *volumeNormalizer) delta(key string, total float64, now time.Time) float64 {
+    n.mu.Lock()
+    defer n.mu.Unlock()
+
+    readings := n.readings[key]
+    if len(readings) > 0 && total < readings[len(readings)-1].total {
+        // The counter was reset; history before it no longer differences
+        readings = nil
+    }
+    readings = append(readings, volumeReading{at: now, total: total})
+    // Keep the latest reading at least 24h old as the anchor of the window
+    cutoff := now.Add(-24 * time.Hour)
+    first := 0
+    for first+1 < len(readings) && !readings[first+1].at.After(cutoff) {
+        first++
+    }
+    readings = append(readings[:0], readings[first:]...)
+    n.readings[key] = readings
+
+    span := now.Sub(readings[0].at)
+    if span < minVolumeSpan {
+        return 0
+    }
+    return (total - readings[0].total) * float64(24*time.Hour) / float64(span)
+}
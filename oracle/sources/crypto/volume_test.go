@@ -0,0 +1,65 @@
+package crypto
+
+import (
+    "math"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+)
+
+func TestVolumeNormalize(t *testing.T) {
+    n := newVolumeNormalizer()
+    noon := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+    rolling := common.VolumeSemantics{Basis: common.VolumeRolling24h, Unit: common.VolumeUnitBase}
+    if v := n.normalize("binance/ETHUSDT", rolling, 100, 3000, noon); v != 100 {
+        t.Errorf("Expected rolling base volume unchanged, got %v", v)
+    }
+    quote := common.VolumeSemantics{Basis: common.VolumeRolling24h, Unit: common.VolumeUnitQuote}
+    if v := n.normalize("x/ETHUSD", quote, 300000, 3000, noon); v != 100 {
+        t.Errorf("Expected quote volume converted to 100 base, got %v", v)
+    }
+
+    // Half a day since midnight counts double
+    midnight := common.VolumeSemantics{Basis: common.VolumeSinceMidnight, Unit: common.VolumeUnitBase}
+    if v := n.normalize("x/ETHUSD", midnight, 50, 3000, noon); v != 100 {
+        t.Errorf("Expected since-midnight volume extrapolated to 100, got %v", v)
+    }
+    if v := n.normalize("x/ETHUSD", midnight, 5, 3000, noon.Add(-11*time.Hour-30*time.Minute)); v != 0 {
+        t.Errorf("Expected volume shortly after midnight unknown, got %v", v)
+    }
+
+    // A lifetime counter is differenced, extrapolated after an hour
+    lifetime := common.VolumeSemantics{Basis: common.VolumeLifetime, Unit: common.VolumeUnitBase}
+    key := "y/ETHUSD"
+    if v := n.normalize(key, lifetime, 1e6, 3000, noon); v != 0 {
+        t.Errorf("Expected a first lifetime reading unknown, got %v", v)
+    }
+    if v := n.normalize(key, lifetime, 1e6+10, 3000, noon.Add(2*time.Hour)); math.Abs(v-120) > 1e-9 {
+        t.Errorf("Expected 10 over 2h extrapolated to 120, got %v", v)
+    }
+    if v := n.normalize(key, lifetime, 1e6+150, 3000, noon.Add(26*time.Hour)); math.Abs(v-140) > 1e-9 {
+        t.Errorf("Expected the last 24h differenced to 140, got %v", v)
+    }
+    // A reset starts the history over
+    if v := n.normalize(key, lifetime, 3, 3000, noon.Add(27*time.Hour)); v != 0 {
+        t.Errorf("Expected a reset counter unknown, got %v", v)
+    }
+}
+
+func TestVolumeSemantics(t *testing.T) {
+    base := &common.BaseConfig{}
+    base.Exchanges.CEX = map[string]common.CEXDetails{
+        "kraken": {Volume: &common.VolumeSemantics{Basis: common.VolumeSinceMidnight}},
+    }
+    if v := volumeSemantics(base, "kraken"); v.Basis != common.VolumeSinceMidnight || v.Unit != common.VolumeUnitBase {
+        t.Errorf("Expected configured semantics with the default unit, got %+v", v)
+    }
+    if v := volumeSemantics(base, "binance"); v.Basis != common.VolumeRolling24h {
+        t.Errorf("Expected built-in semantics, got %+v", v)
+    }
+    if err := validateVolume("kraken", &common.VolumeSemantics{Basis: "weekly"}); err == nil {
+        t.Error("Expected an unknown basis to be rejected")
+    }
+}
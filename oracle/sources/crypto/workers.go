@@ -0,0 +1,62 @@
+package crypto
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/workers"
+)
+
+// SetWorkers makes the aggregator a coordinator: REST and DEX fetches are
+// handed to the workers consuming queue, while order books, conversions
+// and aggregation stay local
+func (a *CryptoAggregator) SetWorkers(queue workers.Queue) {
+    a.workers = queue
+}
+
+// dispatch runs a fetch on a worker when the aggregator coordinates
+// workers, and locally otherwise. A job no worker could take is fetched
+// locally, so that a queue outage slows collection down rather than
+// stopping it.
+func (a *CryptoAggregator) dispatch(ctx context.Context, job workers.Job, local func(ctx context.Context) (*common.PricePoint, error)) (*common.PricePoint, error) {
+    if a.workers == nil {
+        return local(ctx)
+    }
+    price, err := a.workers.Dispatch(ctx, job)
+    if errors.Is(err, workers.ErrUnavailable) {
+        log.Printf("Fetching %s locally: %v", job.Source, err)
+        return local(ctx)
+    }
+    return price, err
+}
+
+// fetchExchange fetches an exchange's REST ticker for a symbol
+func (a *CryptoAggregator) fetchExchange(ctx context.Context, exchange, baseURL, symbol string) (*common.PricePoint, error) {
+    switch exchange {
+    case "binance":
+        return a.fetchBinancePrice(ctx, baseURL, symbol)
+    case "coinbase":
+        return a.fetchCoinbasePrice(ctx, baseURL, symbol)
+    case "kraken":
+        return a.fetchKrakenPrice(ctx, baseURL, symbol)
+    }
+    return nil, fmt.Errorf("unsupported exchange %s", exchange)
+}
+
+// RunJob runs a job taken from the worker queue: the fetch the coordinator
+// would have made itself
+func (a *CryptoAggregator) RunJob(ctx context.Context, job workers.Job) (*common.PricePoint, error) {
+    pair := &common.PairConfig{BaseCurrency: job.Base, QuoteCurrency: job.Quote}
+    switch {
+    case job.Exchange != "":
+        return a.fetchExchange(ctx, job.Exchange, job.BaseURL, job.Symbol)
+    case job.Pool != nil:
+        return a.fetchPoolPrice(ctx, pair, *job.Pool)
+    case job.Subgraph != nil:
+        return a.fetchSubgraphPrice(ctx, pair, *job.Subgraph)
+    }
+    return nil, fmt.Errorf("job %s names no source to fetch", job.ID)
+}
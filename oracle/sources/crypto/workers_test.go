@@ -0,0 +1,59 @@
+package crypto
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "testing"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/workers"
+)
+
+// fakeQueue answers jobs from a map of prices by source
+type fakeQueue struct {
+    prices map[string]float64
+    jobs   []workers.Job
+}
+
+func (q *fakeQueue) Dispatch(ctx context.Context, job workers.Job) (*common.PricePoint, error) {
+    q.jobs = append(q.jobs, job)
+    if price, ok := q.prices[job.Source]; ok {
+        return &common.PricePoint{Price: price}, nil
+    }
+    return nil, fmt.Errorf("%w: no workers consuming oracle.fetch", workers.ErrUnavailable)
+}
+
+func (q *fakeQueue) Serve(ctx context.Context, handler workers.Handler) error {
+    return nil
+}
+
+func TestDispatchFallsBackToLocalFetches(t *testing.T) {
+    a := NewCryptoAggregator(&common.BaseConfig{})
+    local := func(ctx context.Context) (*common.PricePoint, error) {
+        return &common.PricePoint{Price: 1}, nil
+    }
+
+    if price, err := a.dispatch(context.Background(), workers.Job{Source: "binance"}, local); err != nil || price.Price != 1 {
+        t.Errorf("Expected a local fetch without workers, got %+v, %v", price, err)
+    }
+
+    queue := &fakeQueue{prices: map[string]float64{"binance": 3000}}
+    a.SetWorkers(queue)
+    if price, err := a.dispatch(context.Background(), workers.Job{Source: "binance"}, local); err != nil || price.Price != 3000 {
+        t.Errorf("Expected the worker's price, got %+v, %v", price, err)
+    }
+    if price, err := a.dispatch(context.Background(), workers.Job{Source: "kraken"}, local); err != nil || price.Price != 1 {
+        t.Errorf("Expected a local fetch while no worker takes the job, got %+v, %v", price, err)
+    }
+    if len(queue.jobs) != 2 {
+        t.Errorf("Expected both jobs offered to workers, got %d", len(queue.jobs))
+    }
+
+    if _, err := a.RunJob(context.Background(), workers.Job{ID: "7"}); err == nil {
+        t.Error("Expected a job without a source to be rejected")
+    }
+    if _, err := a.RunJob(context.Background(), workers.Job{ID: "8", Exchange: "bitstamp"}); err == nil || errors.Is(err, workers.ErrUnavailable) {
+        t.Errorf("Expected an unsupported exchange error, got %v", err)
+    }
+}
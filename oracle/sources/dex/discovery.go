@@ -0,0 +1,126 @@
+package dex
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "net/http"
+    "sort"
+    "strings"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/symbols"
+)
+
+// Supported pool protocols
+const (
+    ProtocolUniswapV2 = "uniswap_v2"
+    ProtocolUniswapV3 = "uniswap_v3"
+)
+
+// Candidate is a discovered pool suggested as a price source
+type Candidate struct {
+    common.DEXPool
+    FeeTier      int     `json:"feeTier,omitempty"`
+    LiquidityUSD float64 `json:"liquidityUSD"`
+}
+
+// Protocol returns the pool protocol of a DEX, inferring it from the
+// exchange name when it is not configured explicitly
+func Protocol(name string, details common.DEXDetails) string {
+    if details.Protocol != "" {
+        return details.Protocol
+    }
+    if strings.HasSuffix(strings.ToLower(name), "v2") {
+        return ProtocolUniswapV2
+    }
+    return ProtocolUniswapV3
+}
+
+// Discover finds the deepest pools trading tokenA against tokenB on a single
+// subgraph-backed DEX, ordered by liquidity and filtered by MinLiquidity
+func Discover(ctx context.Context, client *http.Client, name string, details common.DEXDetails, chainID, tokenA, tokenB string, limit int) ([]Candidate, error) {
+    pools, err := TopPools(ctx, client, name, details, tokenA, tokenB, limit)
+    if err != nil {
+        return nil, err
+    }
+
+    candidates := make([]Candidate, 0, len(pools))
+    for _, p := range pools {
+        if p.LiquidityUSD < float64(details.MinLiquidity) {
+            continue
+        }
+        candidates = append(candidates, Candidate{
+            DEXPool: common.DEXPool{
+                Chain:    chainID,
+                Exchange: name,
+                Address:  p.Address,
+                Token0:   p.Token0,
+                Token1:   p.Token1,
+            },
+            FeeTier:      p.FeeTier,
+            LiquidityUSD: p.LiquidityUSD,
+        })
+    }
+
+    return candidates, nil
+}
+
+// DiscoverAll runs discovery on every subgraph DEX configured for the chain
+// and returns up to limit candidates, deepest first
+func DiscoverAll(ctx context.Context, client *http.Client, config *common.BaseConfig, chainID, tokenA, tokenB string, limit int) ([]Candidate, error) {
+    all := make([]Candidate, 0)
+    queried := 0
+    for name, details := range config.Exchanges.DEX {
+        if details.Type != "subgraph" || (details.Chain != "" && details.Chain != chainID) {
+            continue
+        }
+        queried++
+
+        candidates, err := Discover(ctx, client, name, details, chainID, tokenA, tokenB, limit)
+        if err != nil {
+            log.Printf("Pool discovery failed on %s: %v", name, err)
+            continue
+        }
+        all = append(all, candidates...)
+    }
+
+    if queried == 0 {
+        return nil, fmt.Errorf("no subgraph DEX configured for chain %s", chainID)
+    }
+
+    sort.Slice(all, func(i, j int) bool {
+        return all[i].LiquidityUSD > all[j].LiquidityUSD
+    })
+    if len(all) > limit {
+        all = all[:limit]
+    }
+    return all, nil
+}
+
+// ApplyCandidates adds the candidate pools to a pair's DEX sources, skipping
+// pools that are already configured, and returns the number added
+func ApplyCandidates(pair *common.PairConfig, candidates []Candidate) int {
+    existing := make(map[string]bool)
+    for _, pool := range pair.Sources.DEX.Pools {
+        existing[symbols.SubgraphID(pool.Address)] = true
+    }
+
+    added := 0
+    for _, c := range candidates {
+        if existing[symbols.SubgraphID(c.Address)] {
+            continue
+        }
+        pair.Sources.DEX.Pools = append(pair.Sources.DEX.Pools, c.DEXPool)
+        existing[symbols.SubgraphID(c.Address)] = true
+        added++
+    }
+
+    if added > 0 && !pair.Sources.DEX.Enabled {
+        pair.Sources.DEX.Enabled = true
+        if pair.Sources.DEX.Weight == 0 {
+            pair.Sources.DEX.Weight = 1.0
+        }
+    }
+    return added
+}
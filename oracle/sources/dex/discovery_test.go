@@ -0,0 +1,107 @@
+package dex
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "yetaXYZ/oracle/common"
+)
+
+func TestDiscoverAll(t *testing.T) {
+    subgraph := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        var req struct {
+            Query string `json:"query"`
+        }
+        json.NewDecoder(r.Body).Decode(&req)
+        if !strings.Contains(req.Query, "pools(") {
+            t.Errorf("Expected a v3 pools query, got %s", req.Query)
+        }
+        fmt.Fprint(w, `{"data":{"pools":[
+            {"id":"0xdeep","feeTier":"500","totalValueLockedUSD":"250000000","token0":{"id":"0xa"},"token1":{"id":"0xb"}},
+            {"id":"0xshallow","feeTier":"10000","totalValueLockedUSD":"1000","token0":{"id":"0xa"},"token1":{"id":"0xb"}},
+            {"id":"0xsame","feeTier":"3000","totalValueLockedUSD":"5000000","token0":{"id":"0xa"},"token1":{"id":"0xa"}}
+        ]}}`)
+    }))
+    defer subgraph.Close()
+
+    config := &common.BaseConfig{
+        Exchanges: common.ExchangeConfig{
+            DEX: map[string]common.DEXDetails{
+                "uniswap_v3": {Name: "Uniswap V3", Type: "subgraph", Chain: "1", Endpoint: subgraph.URL, MinLiquidity: 1000000},
+                "other_v3":   {Name: "Other", Type: "subgraph", Chain: "56", Endpoint: "http://unused.invalid"},
+            },
+        },
+    }
+
+    candidates, err := DiscoverAll(context.Background(), http.DefaultClient, config, "1", "0xA", "0xB", 5)
+    if err != nil {
+        t.Fatalf("Discovery failed: %v", err)
+    }
+    if len(candidates) != 1 {
+        t.Fatalf("Expected 1 candidate above min liquidity, got %d", len(candidates))
+    }
+    if candidates[0].Address != "0xdeep" || candidates[0].FeeTier != 500 {
+        t.Errorf("Unexpected candidate: %+v", candidates[0])
+    }
+
+    pair := &common.PairConfig{}
+    if added := ApplyCandidates(pair, candidates); added != 1 {
+        t.Errorf("Expected 1 pool added, got %d", added)
+    }
+    if added := ApplyCandidates(pair, candidates); added != 0 {
+        t.Errorf("Expected duplicate pool to be skipped, got %d added", added)
+    }
+    if !pair.Sources.DEX.Enabled {
+        t.Error("Expected DEX sources to be enabled")
+    }
+}
+
+func TestDiscoverSendsSubgraphAuth(t *testing.T) {
+    var got []string
+    subgraph := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        got = append(got, r.Header.Get("Authorization")+"|"+r.Header.Get("X-Api-Key"))
+        fmt.Fprint(w, `{"data":{"pools":[]}}`)
+    }))
+    defer subgraph.Close()
+
+    keyFile := filepath.Join(t.TempDir(), "graph.key")
+    if err := os.WriteFile(keyFile, []byte("first\n"), 0o600); err != nil {
+        t.Fatal(err)
+    }
+    t.Setenv("TEST_SUBGRAPH_KEY", "envkey")
+
+    bearer := common.DEXDetails{Type: "subgraph", Endpoint: subgraph.URL, Auth: &common.SubgraphAuth{KeyFile: keyFile}}
+    custom := common.DEXDetails{Type: "subgraph", Endpoint: subgraph.URL, Auth: &common.SubgraphAuth{Header: "X-Api-Key", KeyEnv: "TEST_SUBGRAPH_KEY"}}
+
+    ctx := context.Background()
+    if _, err := Discover(ctx, http.DefaultClient, "uniswap_v3", bearer, "1", "0xa", "0xb", 1); err != nil {
+        t.Fatalf("Discovery failed: %v", err)
+    }
+    // Rotating the key file takes effect on the next request
+    if err := os.WriteFile(keyFile, []byte("second"), 0o600); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := Discover(ctx, http.DefaultClient, "uniswap_v3", bearer, "1", "0xa", "0xb", 1); err != nil {
+        t.Fatalf("Discovery failed: %v", err)
+    }
+    if _, err := Discover(ctx, http.DefaultClient, "uniswap_v3", custom, "1", "0xa", "0xb", 1); err != nil {
+        t.Fatalf("Discovery failed: %v", err)
+    }
+
+    want := []string{"Bearer first|", "Bearer second|", "|envkey"}
+    if strings.Join(got, ",") != strings.Join(want, ",") {
+        t.Errorf("Expected headers %v, got %v", want, got)
+    }
+
+    missing := common.DEXDetails{Type: "subgraph", Endpoint: subgraph.URL, Auth: &common.SubgraphAuth{KeyEnv: "TEST_SUBGRAPH_UNSET"}}
+    if _, err := Discover(ctx, http.DefaultClient, "uniswap_v3", missing, "1", "0xa", "0xb", 1); err == nil {
+        t.Error("Expected error for an unset key, got nil")
+    }
+}
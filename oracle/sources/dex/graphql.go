@@ -0,0 +1,107 @@
+package dex
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "os"
+    "strings"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/credentials"
+    "yetaXYZ/oracle/fetch"
+    "yetaXYZ/oracle/redact"
+)
+
+// endpointURL returns the subgraph endpoint with credential references
+// such as ${THE_GRAPH_API_KEY} expanded
+func endpointURL(details common.DEXDetails) string {
+    return credentials.Expand(details.Endpoint)
+}
+
+// authHeader returns the header name and value authenticating a request to
+// a subgraph, reading the key afresh so rotated keys apply immediately
+func authHeader(auth *common.SubgraphAuth) (string, string, error) {
+    var key string
+    if auth.KeyFile != "" {
+        data, err := os.ReadFile(auth.KeyFile)
+        if err != nil {
+            return "", "", fmt.Errorf("failed to read subgraph key: %v", err)
+        }
+        key = strings.TrimSpace(string(data))
+    } else {
+        key = credentials.Get(auth.KeyEnv)
+    }
+    if key == "" {
+        return "", "", fmt.Errorf("subgraph key is not set")
+    }
+
+    header, scheme := auth.Header, auth.Scheme
+    if header == "" {
+        header = "Authorization"
+    }
+    if scheme == "" && http.CanonicalHeaderKey(header) == "Authorization" {
+        scheme = "Bearer"
+    }
+    if scheme != "" {
+        key = scheme + " " + key
+    }
+    return header, key, nil
+}
+
+// graphqlQuery posts a GraphQL query to a subgraph and decodes the data
+// field into out
+func graphqlQuery(ctx context.Context, client *http.Client, details common.DEXDetails, query string, variables map[string]interface{}, out interface{}) error {
+    payload, err := json.Marshal(map[string]interface{}{
+        "query":     query,
+        "variables": variables,
+    })
+    if err != nil {
+        return err
+    }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpointURL(details), bytes.NewReader(payload))
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Content-Type", "application/json")
+    if details.Auth != nil {
+        header, value, err := authHeader(details.Auth)
+        if err != nil {
+            return err
+        }
+        req.Header.Set(header, value)
+    }
+
+    // Transport errors echo the endpoint, which may embed an API key
+    resp, err := client.Do(req)
+    if err != nil {
+        return redact.Error(err)
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("subgraph returned %s", resp.Status)
+    }
+
+    var envelope struct {
+        Data   json.RawMessage `json:"data"`
+        Errors []struct {
+            Message string `json:"message"`
+        } `json:"errors"`
+    }
+    if err := fetch.DecodeJSON(resp, &envelope); err != nil {
+        return err
+    }
+    if len(envelope.Errors) > 0 {
+        messages := make([]string, 0, len(envelope.Errors))
+        for _, e := range envelope.Errors {
+            messages = append(messages, e.Message)
+        }
+        return fmt.Errorf("subgraph error: %s", strings.Join(messages, "; "))
+    }
+
+    return json.Unmarshal(envelope.Data, out)
+}
@@ -0,0 +1,167 @@
+package dex
+
+import (
+    "context"
+    "fmt"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/symbols"
+)
+
+const v3PoolHoursQuery = `query($pool: String!, $from: Int!, $to: Int!, $first: Int!, $skip: Int!) {
+  poolHourDatas(first: $first, skip: $skip, orderBy: periodStartUnix, orderDirection: asc,
+      where: {pool: $pool, periodStartUnix_gte: $from, periodStartUnix_lt: $to}) {
+    periodStartUnix
+    token0Price
+    token1Price
+    volumeToken0
+    volumeToken1
+    pool { token0 { id } token1 { id } }
+  }
+}`
+
+const v2PairHoursQuery = `query($pool: String!, $from: Int!, $to: Int!, $first: Int!, $skip: Int!) {
+  pairHourDatas(first: $first, skip: $skip, orderBy: hourStartUnix, orderDirection: asc,
+      where: {pair: $pool, hourStartUnix_gte: $from, hourStartUnix_lt: $to}) {
+    hourStartUnix
+    reserve0
+    reserve1
+    hourlyVolumeToken0
+    hourlyVolumeToken1
+    pair { token0 { id } token1 { id } }
+  }
+}`
+
+type subgraphHour struct {
+    PeriodStartUnix    int64       `json:"periodStartUnix"`
+    HourStartUnix      int64       `json:"hourStartUnix"`
+    Token0Price        string      `json:"token0Price"`
+    Token1Price        string      `json:"token1Price"`
+    Reserve0           string      `json:"reserve0"`
+    Reserve1           string      `json:"reserve1"`
+    VolumeToken0       string      `json:"volumeToken0"`
+    VolumeToken1       string      `json:"volumeToken1"`
+    HourlyVolumeToken0 string      `json:"hourlyVolumeToken0"`
+    HourlyVolumeToken1 string      `json:"hourlyVolumeToken1"`
+    Pool               *hourTokens `json:"pool"` // V3
+    Pair               *hourTokens `json:"pair"` // V2
+}
+
+type hourTokens struct {
+    Token0 struct {
+        ID string `json:"id"`
+    } `json:"token0"`
+    Token1 struct {
+        ID string `json:"id"`
+    } `json:"token1"`
+}
+
+// PoolHour is the state of a pool at the end of an hour, as indexed by a
+// subgraph
+type PoolHour struct {
+    Open time.Time
+    // Price is the base token's price in the pool's other token at the
+    // hour's last trade, and Volume the base token traded in the hour
+    Price  float64
+    Volume float64
+}
+
+// PoolHours reads the hourly history of a pool opened in [from, to) from a
+// subgraph DEX, pricing base
+func PoolHours(ctx context.Context, client *http.Client, name string, details common.DEXDetails, address, base string, from, to time.Time) ([]PoolHour, error) {
+    if details.Type != "subgraph" {
+        return nil, fmt.Errorf("DEX %s is not subgraph-backed", name)
+    }
+    v2 := Protocol(name, details) == ProtocolUniswapV2
+
+    hours := make([]PoolHour, 0)
+    for skip := 0; ; skip += maxPageSize {
+        variables := map[string]interface{}{
+            "pool": symbols.SubgraphID(address), "from": from.Unix(), "to": to.Unix(),
+            "first": maxPageSize, "skip": skip,
+        }
+        var data struct {
+            PoolHourDatas []subgraphHour `json:"poolHourDatas"`
+            PairHourDatas []subgraphHour `json:"pairHourDatas"`
+        }
+        query := v3PoolHoursQuery
+        if v2 {
+            query = v2PairHoursQuery
+        }
+        if err := graphqlQuery(ctx, client, details, query, variables, &data); err != nil {
+            return nil, err
+        }
+        page := data.PoolHourDatas
+        if v2 {
+            page = data.PairHourDatas
+        }
+
+        for _, h := range page {
+            hour, ok, err := parseHour(h, base)
+            if err != nil {
+                return nil, err
+            }
+            if ok {
+                hours = append(hours, hour)
+            }
+        }
+        if len(page) < maxPageSize {
+            break
+        }
+    }
+    return hours, nil
+}
+
+// parseHour converts a subgraph hour's decimal strings; ok is false for an
+// hour without a price
+func parseHour(h subgraphHour, base string) (hour PoolHour, ok bool, err error) {
+    tokens := h.Pool
+    if tokens == nil {
+        tokens = h.Pair
+    }
+    if tokens == nil {
+        return PoolHour{}, false, fmt.Errorf("subgraph hour without its pool")
+    }
+
+    start := h.PeriodStartUnix
+    if start == 0 {
+        start = h.HourStartUnix
+    }
+    volume0, volume1 := h.VolumeToken0, h.VolumeToken1
+    if h.Pair != nil {
+        volume0, volume1 = h.HourlyVolumeToken0, h.HourlyVolumeToken1
+    }
+    // Token0Price is token0 per token1, as in Pool
+    var price, volume string
+    switch {
+    case strings.EqualFold(base, tokens.Token0.ID):
+        price, volume = h.Token1Price, volume0
+    case strings.EqualFold(base, tokens.Token1.ID):
+        price, volume = h.Token0Price, volume1
+    default:
+        return PoolHour{}, false, fmt.Errorf("pool does not trade %s", base)
+    }
+
+    hour = PoolHour{Open: time.Unix(start, 0).UTC()}
+    if h.Pair != nil {
+        // V2 hours carry the closing reserves instead of prices
+        reserve0, _ := strconv.ParseFloat(h.Reserve0, 64)
+        reserve1, _ := strconv.ParseFloat(h.Reserve1, 64)
+        if reserve0 <= 0 || reserve1 <= 0 {
+            return PoolHour{}, false, nil
+        }
+        hour.Price = reserve1 / reserve0
+        if strings.EqualFold(base, tokens.Token1.ID) {
+            hour.Price = reserve0 / reserve1
+        }
+    } else if hour.Price, err = strconv.ParseFloat(price, 64); err != nil {
+        return PoolHour{}, false, fmt.Errorf("invalid price for pool hour %d: %v", start, err)
+    }
+    // Volume is left unset where a subgraph does not report it
+    hour.Volume, _ = strconv.ParseFloat(volume, 64)
+    return hour, hour.Price > 0, nil
+}
@@ -0,0 +1,214 @@
+package dex
+
+import (
+    "context"
+    "fmt"
+    "net/http"
+    "sort"
+    "strconv"
+    "strings"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/symbols"
+)
+
+// maxPageSize is the most entities a subgraph returns per query
+const maxPageSize = 1000
+
+const v3PoolsQuery = `query($where: Pool_filter!, $first: Int!, $skip: Int!) {
+  pools(first: $first, skip: $skip, orderBy: totalValueLockedUSD, orderDirection: desc, where: $where) {
+    id
+    feeTier
+    totalValueLockedUSD
+    token0Price
+    token1Price
+    token0 { id }
+    token1 { id }
+  }
+}`
+
+const v2PairsQuery = `query($where: Pair_filter!, $first: Int!, $skip: Int!) {
+  pairs(first: $first, skip: $skip, orderBy: reserveUSD, orderDirection: desc, where: $where) {
+    id
+    reserveUSD
+    token0Price
+    token1Price
+    token0 { id }
+    token1 { id }
+  }
+}`
+
+type subgraphPool struct {
+    ID                  string `json:"id"`
+    FeeTier             string `json:"feeTier"`
+    TotalValueLockedUSD string `json:"totalValueLockedUSD"`
+    ReserveUSD          string `json:"reserveUSD"`
+    Token0Price         string `json:"token0Price"`
+    Token1Price         string `json:"token1Price"`
+    Token0              struct {
+        ID string `json:"id"`
+    } `json:"token0"`
+    Token1 struct {
+        ID string `json:"id"`
+    } `json:"token1"`
+}
+
+// Pool is a pool's state as indexed by a subgraph
+type Pool struct {
+    Address      string
+    Token0       string
+    Token1       string
+    FeeTier      int // Uniswap V3 pools only
+    LiquidityUSD float64
+    // Token0Price is token0 per token1 and Token1Price token1 per token0,
+    // as the Uniswap subgraphs define them
+    Token0Price float64
+    Token1Price float64
+}
+
+// PriceOf returns the price of token in the pool's other token
+func (p Pool) PriceOf(token string) (float64, bool) {
+    switch {
+    case strings.EqualFold(token, p.Token0):
+        return p.Token1Price, p.Token1Price > 0
+    case strings.EqualFold(token, p.Token1):
+        return p.Token0Price, p.Token0Price > 0
+    }
+    return 0, false
+}
+
+// Pools queries the given pools of a subgraph DEX, deepest first
+func Pools(ctx context.Context, client *http.Client, name string, details common.DEXDetails, addresses []string) ([]Pool, error) {
+    ids := make([]string, len(addresses))
+    for i, address := range addresses {
+        ids[i] = symbols.SubgraphID(address)
+    }
+    return queryPools(ctx, client, name, details, map[string]interface{}{"id_in": ids}, len(ids))
+}
+
+// TopPools queries the limit deepest pools trading tokenA against tokenB on
+// a subgraph DEX
+func TopPools(ctx context.Context, client *http.Client, name string, details common.DEXDetails, tokenA, tokenB string, limit int) ([]Pool, error) {
+    tokens := []string{symbols.SubgraphID(tokenA), symbols.SubgraphID(tokenB)}
+    pools, err := queryPools(ctx, client, name, details, map[string]interface{}{"token0_in": tokens, "token1_in": tokens}, limit)
+    if err != nil {
+        return nil, err
+    }
+    // token0_in/token1_in also matches tokenA/tokenA style pools
+    kept := pools[:0]
+    for _, p := range pools {
+        if p.Token0 != p.Token1 {
+            kept = append(kept, p)
+        }
+    }
+    return kept, nil
+}
+
+// queryPools pages through the pools matching where, deepest first, until
+// limit pools are read or none are left
+func queryPools(ctx context.Context, client *http.Client, name string, details common.DEXDetails, where map[string]interface{}, limit int) ([]Pool, error) {
+    if details.Type != "subgraph" {
+        return nil, fmt.Errorf("DEX %s is not subgraph-backed", name)
+    }
+    v2 := Protocol(name, details) == ProtocolUniswapV2
+
+    pools := make([]Pool, 0, limit)
+    for len(pools) < limit {
+        first := limit - len(pools)
+        if first > maxPageSize {
+            first = maxPageSize
+        }
+        variables := map[string]interface{}{"where": where, "first": first, "skip": len(pools)}
+
+        var page []subgraphPool
+        if v2 {
+            var data struct {
+                Pairs []subgraphPool `json:"pairs"`
+            }
+            if err := graphqlQuery(ctx, client, details, v2PairsQuery, variables, &data); err != nil {
+                return nil, err
+            }
+            page = data.Pairs
+        } else {
+            var data struct {
+                Pools []subgraphPool `json:"pools"`
+            }
+            if err := graphqlQuery(ctx, client, details, v3PoolsQuery, variables, &data); err != nil {
+                return nil, err
+            }
+            page = data.Pools
+        }
+
+        for _, p := range page {
+            pool, err := parsePool(p)
+            if err != nil {
+                return nil, err
+            }
+            pools = append(pools, pool)
+        }
+        if len(page) < first {
+            break
+        }
+    }
+    return pools, nil
+}
+
+// parsePool converts a subgraph pool's decimal strings
+func parsePool(p subgraphPool) (Pool, error) {
+    liquidity := p.TotalValueLockedUSD
+    if liquidity == "" {
+        liquidity = p.ReserveUSD
+    }
+    liquidityUSD, err := strconv.ParseFloat(liquidity, 64)
+    if err != nil {
+        return Pool{}, fmt.Errorf("invalid liquidity for pool %s: %v", p.ID, err)
+    }
+    pool := Pool{Address: p.ID, Token0: p.Token0.ID, Token1: p.Token1.ID, LiquidityUSD: liquidityUSD}
+    pool.FeeTier, _ = strconv.Atoi(p.FeeTier)
+    // Prices are left unset where a subgraph does not report them
+    pool.Token0Price, _ = strconv.ParseFloat(p.Token0Price, 64)
+    pool.Token1Price, _ = strconv.ParseFloat(p.Token1Price, 64)
+    return pool, nil
+}
+
+// MedianPrice returns the liquidity-weighted median price of token across
+// the pools holding at least minLiquidity, and the number of pools priced.
+// Weighting by liquidity keeps a shallow, imbalanced pool from moving the
+// price.
+func MedianPrice(pools []Pool, token string, minLiquidity float64) (float64, int, error) {
+    type quote struct {
+        price, weight float64
+    }
+    quotes := make([]quote, 0, len(pools))
+    var total float64
+    for _, p := range pools {
+        if p.LiquidityUSD < minLiquidity {
+            continue
+        }
+        price, ok := p.PriceOf(token)
+        if !ok {
+            continue
+        }
+        quotes = append(quotes, quote{price: price, weight: p.LiquidityUSD})
+        total += p.LiquidityUSD
+    }
+    if len(quotes) == 0 {
+        return 0, 0, fmt.Errorf("no pool prices %s with enough liquidity", token)
+    }
+    if total <= 0 {
+        for i := range quotes {
+            quotes[i].weight = 1
+        }
+        total = float64(len(quotes))
+    }
+
+    sort.Slice(quotes, func(i, j int) bool { return quotes[i].price < quotes[j].price })
+    var cumulative float64
+    for _, q := range quotes {
+        cumulative += q.weight
+        if cumulative >= total/2 {
+            return q.price, len(quotes), nil
+        }
+    }
+    return quotes[len(quotes)-1].price, len(quotes), nil
+}
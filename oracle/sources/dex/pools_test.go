@@ -0,0 +1,68 @@
+package dex
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "yetaXYZ/oracle/common"
+)
+
+func TestTopPoolsPages(t *testing.T) {
+    var skips []int
+    subgraph := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        var req struct {
+            Variables struct {
+                First int `json:"first"`
+                Skip  int `json:"skip"`
+            } `json:"variables"`
+        }
+        json.NewDecoder(r.Body).Decode(&req)
+        skips = append(skips, req.Variables.Skip)
+        rows := make([]map[string]interface{}, 0)
+        for i := req.Variables.Skip; i < 1200 && i < req.Variables.Skip+req.Variables.First; i++ {
+            rows = append(rows, map[string]interface{}{
+                "id":                  fmt.Sprintf("0x%d", i),
+                "totalValueLockedUSD": fmt.Sprint(1e9 - float64(i)),
+                "token0Price":         "0.0005",
+                "token1Price":         "2000",
+                "token0":              map[string]string{"id": "0xa"},
+                "token1":              map[string]string{"id": "0xb"},
+            })
+        }
+        json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"pools": rows}})
+    }))
+    defer subgraph.Close()
+
+    details := common.DEXDetails{Type: "subgraph", Chain: "1", Endpoint: subgraph.URL}
+    pools, err := TopPools(context.Background(), http.DefaultClient, "uniswap_v3", details, "0xA", "0xB", 1500)
+    if err != nil {
+        t.Fatalf("Failed to query pools: %v", err)
+    }
+    if len(pools) != 1200 || len(skips) != 2 || skips[1] != 1000 {
+        t.Errorf("Expected 1200 pools over two pages, got %d pools at skips %v", len(pools), skips)
+    }
+    if price, ok := pools[0].PriceOf("0xA"); !ok || price != 2000 {
+        t.Errorf("Expected token0 priced 2000 in token1, got %v", price)
+    }
+}
+
+func TestMedianPrice(t *testing.T) {
+    pools := []Pool{
+        {Address: "0xdeep", Token0: "0xa", Token1: "0xb", LiquidityUSD: 9e7, Token0Price: 1.0 / 2000, Token1Price: 2000},
+        {Address: "0xmid", Token0: "0xb", Token1: "0xa", LiquidityUSD: 2e7, Token0Price: 2010, Token1Price: 1.0 / 2010},
+        // Shallow and imbalanced, it does not move the median
+        {Address: "0xshallow", Token0: "0xa", Token1: "0xb", LiquidityUSD: 5e6, Token0Price: 1.0 / 2500, Token1Price: 2500},
+        {Address: "0xdust", Token0: "0xa", Token1: "0xb", LiquidityUSD: 10, Token1Price: 9000},
+    }
+    price, used, err := MedianPrice(pools, "0xA", 1e6)
+    if err != nil || price != 2000 || used != 3 {
+        t.Errorf("Expected 2000 from 3 pools, got %v from %d (%v)", price, used, err)
+    }
+    if _, _, err := MedianPrice(pools, "0xc", 0); err == nil {
+        t.Error("Expected an error for a token no pool holds")
+    }
+}
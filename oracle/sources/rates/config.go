@@ -0,0 +1,98 @@
+package rates
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+
+    "yetaXYZ/oracle/common"
+)
+
+// Supported rate providers
+const (
+    ProviderNYFed = "nyfed"
+    ProviderFRED  = "fred"
+    ProviderBLS   = "bls"
+)
+
+// Publication frequencies
+const (
+    FrequencyDaily   = "daily"
+    FrequencyMonthly = "monthly" // e.g. CPI, effective on the first of the reference month
+)
+
+// BenchmarkConfig describes where a benchmark rate is published and when
+type BenchmarkConfig struct {
+    Provider string `json:"provider"`
+    // Series is the provider's identifier, e.g. "secured/sofr" for the
+    // New York Fed, "DTB3" for FRED or "CUUR0000SA0" for BLS
+    Series string `json:"series"`
+    // Frequency is daily (default) or monthly
+    Frequency string `json:"frequency,omitempty"`
+    // PublishLagDays is how many business days after the effective date
+    // the rate is published; for monthly series, after the reference
+    // month ends
+    PublishLagDays int `json:"publishLagDays"`
+    // PublishHour is the New York hour on the publication day by which
+    // the rate is expected to be available
+    PublishHour int `json:"publishHour"`
+    // GraceBusinessDays tolerates late publications before flagging staleness
+    GraceBusinessDays int `json:"graceBusinessDays,omitempty"`
+}
+
+// Config holds the rates module configuration
+type Config struct {
+    // FREDAPIKeyEnv names the environment variable holding the FRED API key
+    FREDAPIKeyEnv string `json:"fredApiKeyEnv"`
+    // BLSAPIKeyEnv names the environment variable holding the optional BLS
+    // registration key, which raises the daily request limit
+    BLSAPIKeyEnv    string                      `json:"blsApiKeyEnv,omitempty"`
+    IntervalMinutes int                         `json:"intervalMinutes"`
+    Benchmarks      map[string]*BenchmarkConfig `json:"benchmarks"`
+    // Attributions are the credits providers require, keyed by provider
+    // (nyfed, fred or bls)
+    Attributions map[string]*common.Attribution `json:"attributions,omitempty"`
+}
+
+// LoadConfig loads rates/rates.json from the config directory. A missing
+// file yields an empty configuration.
+func LoadConfig(configDir string) (*Config, error) {
+    data, err := os.ReadFile(filepath.Join(configDir, "rates", "rates.json"))
+    if os.IsNotExist(err) {
+        return &Config{Benchmarks: map[string]*BenchmarkConfig{}}, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read rates config: %v", err)
+    }
+
+    var config Config
+    if err := json.Unmarshal(data, &config); err != nil {
+        return nil, fmt.Errorf("failed to parse rates config: %v", err)
+    }
+    if config.Benchmarks == nil {
+        config.Benchmarks = map[string]*BenchmarkConfig{}
+    }
+    return &config, config.Validate()
+}
+
+// Validate checks every benchmark definition
+func (c *Config) Validate() error {
+    for name, b := range c.Benchmarks {
+        switch b.Provider {
+        case ProviderNYFed, ProviderFRED, ProviderBLS:
+        default:
+            return fmt.Errorf("benchmark %s has unknown provider %q", name, b.Provider)
+        }
+        if b.Frequency != "" && b.Frequency != FrequencyDaily && b.Frequency != FrequencyMonthly {
+            return fmt.Errorf("benchmark %s has unknown frequency %q", name, b.Frequency)
+        }
+        if b.Series == "" {
+            return fmt.Errorf("benchmark %s has no series", name)
+        }
+        if b.PublishLagDays < 0 || b.PublishHour < 0 || b.PublishHour > 23 || b.GraceBusinessDays < 0 {
+            return fmt.Errorf("benchmark %s has an invalid publication schedule", name)
+        }
+    }
+    return nil
+}
@@ -0,0 +1,162 @@
+package rates
+
+import (
+    "fmt"
+    "net/http"
+    "net/url"
+    "strconv"
+    "strings"
+    "time"
+
+    "yetaXYZ/oracle/credentials"
+    "yetaXYZ/oracle/fetch"
+    "yetaXYZ/oracle/redact"
+)
+
+// Provider endpoints, variables so tests can point them at local servers
+var (
+    nyfedBaseURL = "https://markets.newyorkfed.org/api/rates"
+    fredBaseURL  = "https://api.stlouisfed.org/fred/series/observations"
+    blsBaseURL   = "https://api.bls.gov/publicAPI/v2/timeseries/data"
+)
+
+// fetchNYFed fetches the latest published rate of a New York Fed reference
+// rate such as secured/sofr or unsecured/effr
+func fetchNYFed(client *http.Client, series string) (rate float64, effective time.Time, err error) {
+    resp, err := client.Get(fmt.Sprintf("%s/%s/last/1.json", nyfedBaseURL, series))
+    if err != nil {
+        return 0, time.Time{}, err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return 0, time.Time{}, fmt.Errorf("unexpected status from New York Fed: %s", resp.Status)
+    }
+
+    var data struct {
+        RefRates []struct {
+            EffectiveDate string  `json:"effectiveDate"`
+            PercentRate   float64 `json:"percentRate"`
+        } `json:"refRates"`
+    }
+    if err := fetch.DecodeJSON(resp, &data); err != nil {
+        return 0, time.Time{}, err
+    }
+    if len(data.RefRates) == 0 {
+        return 0, time.Time{}, fmt.Errorf("no rates returned for %s", series)
+    }
+
+    effective, err = time.Parse("2006-01-02", data.RefRates[0].EffectiveDate)
+    if err != nil {
+        return 0, time.Time{}, fmt.Errorf("invalid effective date: %v", err)
+    }
+    return data.RefRates[0].PercentRate, effective, nil
+}
+
+// fetchFRED fetches the latest non-missing observation of a FRED series
+func fetchFRED(client *http.Client, series, apiKeyEnv string) (rate float64, effective time.Time, err error) {
+    apiKey := credentials.Get(apiKeyEnv)
+    if apiKey == "" {
+        return 0, time.Time{}, fmt.Errorf("FRED API key not set in %s", apiKeyEnv)
+    }
+
+    params := url.Values{}
+    params.Set("series_id", series)
+    params.Set("api_key", apiKey)
+    params.Set("file_type", "json")
+    params.Set("sort_order", "desc")
+    params.Set("limit", "10")
+
+    // The API key travels in the query string and would leak via url.Error
+    resp, err := client.Get(fredBaseURL + "?" + params.Encode())
+    if err != nil {
+        return 0, time.Time{}, redact.Error(err)
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return 0, time.Time{}, fmt.Errorf("unexpected status from FRED: %s", resp.Status)
+    }
+
+    var data struct {
+        Observations []struct {
+            Date  string `json:"date"`
+            Value string `json:"value"`
+        } `json:"observations"`
+    }
+    if err := fetch.DecodeJSON(resp, &data); err != nil {
+        return 0, time.Time{}, err
+    }
+
+    // FRED reports holidays as "." rather than omitting them
+    for _, obs := range data.Observations {
+        value, err := strconv.ParseFloat(obs.Value, 64)
+        if err != nil {
+            continue
+        }
+        effective, err = time.Parse("2006-01-02", obs.Date)
+        if err != nil {
+            return 0, time.Time{}, fmt.Errorf("invalid observation date: %v", err)
+        }
+        return value, effective, nil
+    }
+    return 0, time.Time{}, fmt.Errorf("no observations returned for %s", series)
+}
+
+// fetchBLS fetches the latest monthly observation of a Bureau of Labor
+// Statistics series such as CUUR0000SA0 (CPI-U). The registration key is
+// optional.
+func fetchBLS(client *http.Client, series, apiKeyEnv string) (value float64, effective time.Time, err error) {
+    endpoint := fmt.Sprintf("%s/%s", blsBaseURL, url.PathEscape(series))
+    if key := credentials.Get(apiKeyEnv); apiKeyEnv != "" && key != "" {
+        endpoint += "?registrationkey=" + url.QueryEscape(key)
+    }
+
+    resp, err := client.Get(endpoint)
+    if err != nil {
+        return 0, time.Time{}, redact.Error(err)
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return 0, time.Time{}, fmt.Errorf("unexpected status from BLS: %s", resp.Status)
+    }
+
+    var data struct {
+        Status  string   `json:"status"`
+        Message []string `json:"message"`
+        Results struct {
+            Series []struct {
+                Data []struct {
+                    Year   string `json:"year"`
+                    Period string `json:"period"`
+                    Value  string `json:"value"`
+                } `json:"data"`
+            } `json:"series"`
+        } `json:"Results"`
+    }
+    if err := fetch.DecodeJSON(resp, &data); err != nil {
+        return 0, time.Time{}, redact.Error(err)
+    }
+    if data.Status != "REQUEST_SUCCEEDED" {
+        return 0, time.Time{}, fmt.Errorf("BLS request failed: %s %v", data.Status, data.Message)
+    }
+    if len(data.Results.Series) == 0 {
+        return 0, time.Time{}, fmt.Errorf("no observations returned for %s", series)
+    }
+
+    // Observations are newest first; M13 is the annual average and
+    // unavailable months are reported as "-"
+    for _, obs := range data.Results.Series[0].Data {
+        if !strings.HasPrefix(obs.Period, "M") || obs.Period == "M13" {
+            continue
+        }
+        value, err := strconv.ParseFloat(obs.Value, 64)
+        if err != nil {
+            continue
+        }
+        effective, err = time.Parse("2006-01", obs.Year+"-"+strings.TrimPrefix(obs.Period, "M"))
+        if err != nil {
+            return 0, time.Time{}, fmt.Errorf("invalid observation period: %v", err)
+        }
+        return value, effective, nil
+    }
+    return 0, time.Time{}, fmt.Errorf("no observations returned for %s", series)
+}
@@ -0,0 +1,246 @@
+package rates
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "net/http"
+    "sort"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/calendar"
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+    "yetaXYZ/oracle/fetch"
+)
+
+// defaultInterval is used when the config does not set IntervalMinutes
+const defaultInterval = 15 * time.Minute
+
+// Observation is the latest published value of a benchmark rate
+type Observation struct {
+    Benchmark string  `json:"benchmark"`
+    Provider  string  `json:"provider"`
+    Rate      float64 `json:"rate"` // percent, or the index level of a price index
+    // EffectiveDate is the date the rate applies to, not when it was fetched
+    EffectiveDate time.Time `json:"effectiveDate"`
+    // ExpectedDate is the latest effective date that should be published by now
+    ExpectedDate time.Time `json:"expectedDate"`
+    Stale        bool      `json:"stale"`
+    FetchedAt    time.Time `json:"fetchedAt"`
+    RoundID      uint64    `json:"roundId"`
+}
+
+// Service polls benchmark rates and judges their freshness against the
+// publication calendar rather than wall-clock age, so a rate published on
+// Friday is not stale over the weekend
+type Service struct {
+    config   *Config
+    client   *http.Client
+    bus      *events.Bus
+    calendar *calendar.Calendar
+
+    mu     sync.RWMutex
+    latest map[string]*Observation
+    rounds map[string]uint64
+}
+
+// NewService creates a rates service
+func NewService(config *Config, bus *events.Bus) *Service {
+    return &Service{
+        config:   config,
+        client:   fetch.NewClient(10 * time.Second),
+        bus:      bus,
+        calendar: calendar.USFederal(),
+        latest:   make(map[string]*Observation),
+        rounds:   make(map[string]uint64),
+    }
+}
+
+// Interval returns the configured polling interval
+func (s *Service) Interval() time.Duration {
+    if s.config.IntervalMinutes > 0 {
+        return time.Duration(s.config.IntervalMinutes) * time.Minute
+    }
+    return defaultInterval
+}
+
+// Run polls every benchmark at interval until ctx is cancelled
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        s.FetchAll(time.Now())
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+// FetchAll fetches every configured benchmark and publishes new values
+func (s *Service) FetchAll(now time.Time) {
+    for _, name := range s.Benchmarks() {
+        obs, err := s.fetch(name, s.config.Benchmarks[name], now)
+        if err != nil {
+            log.Printf("Error fetching rate %s: %v", name, err)
+            continue
+        }
+
+        if obs.Stale {
+            s.bus.Publish(events.Event{
+                Type:   events.Alert,
+                Symbol: name,
+                Payload: &events.AlertPayload{
+                    Severity: events.SeverityWarning,
+                    Kind:     "rate_stale",
+                    Message:  fmt.Sprintf("latest effective date %s, expected %s", obs.EffectiveDate.Format("2006-01-02"), obs.ExpectedDate.Format("2006-01-02")),
+                },
+            })
+        }
+
+        // Only a new effective date starts a new round
+        s.mu.Lock()
+        previous := s.latest[name]
+        if previous != nil && previous.EffectiveDate.Equal(obs.EffectiveDate) && previous.Rate == obs.Rate {
+            obs.RoundID = previous.RoundID
+            s.latest[name] = obs
+            s.mu.Unlock()
+            continue
+        }
+        s.rounds[name]++
+        obs.RoundID = s.rounds[name]
+        s.latest[name] = obs
+        s.mu.Unlock()
+
+        s.bus.Publish(events.Event{
+            Type:   events.Aggregate,
+            Symbol: name,
+            Payload: &common.AggregateResult{
+                Symbol: name,
+                PricePoint: common.PricePoint{
+                    Price:     obs.Rate,
+                    Timestamp: obs.EffectiveDate,
+                },
+                Sources: []common.SourcePrice{{
+                    Source:     obs.Provider,
+                    PricePoint: common.PricePoint{Price: obs.Rate, Timestamp: obs.EffectiveDate},
+                }},
+                RoundID: obs.RoundID,
+            },
+        })
+    }
+}
+
+// fetch retrieves a single benchmark and evaluates its staleness
+func (s *Service) fetch(name string, b *BenchmarkConfig, now time.Time) (*Observation, error) {
+    var rate float64
+    var effective time.Time
+    var err error
+
+    switch b.Provider {
+    case ProviderNYFed:
+        rate, effective, err = fetchNYFed(s.client, b.Series)
+    case ProviderFRED:
+        rate, effective, err = fetchFRED(s.client, b.Series, s.config.FREDAPIKeyEnv)
+    case ProviderBLS:
+        rate, effective, err = fetchBLS(s.client, b.Series, s.config.BLSAPIKeyEnv)
+    default:
+        err = fmt.Errorf("unknown provider %q", b.Provider)
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    expected := ExpectedEffectiveDate(s.calendar, b, now)
+    stale := IsStale(s.calendar, b, effective, expected)
+    if b.Frequency == FrequencyMonthly {
+        // The grace period delays each month's release deadline
+        due := expectedMonth(s.calendar, b, now, b.PublishLagDays+b.GraceBusinessDays)
+        stale = effective.Format("2006-01-02") < due.Format("2006-01-02")
+    }
+    return &Observation{
+        Benchmark:     name,
+        Provider:      b.Provider,
+        Rate:          rate,
+        EffectiveDate: effective,
+        ExpectedDate:  expected,
+        Stale:         stale,
+        FetchedAt:     now,
+    }, nil
+}
+
+// ExpectedEffectiveDate returns the latest effective date that should have
+// been published by now according to the benchmark's publication schedule
+func ExpectedEffectiveDate(cal *calendar.Calendar, b *BenchmarkConfig, now time.Time) time.Time {
+    if b.Frequency == FrequencyMonthly {
+        return expectedMonth(cal, b, now, b.PublishLagDays)
+    }
+    local := now.In(cal.Location)
+    publication := cal.Date(local)
+
+    // Before the publication hour, or on a non-business day, the most
+    // recent publication happened on an earlier business day
+    if !cal.IsBusinessDay(publication) || local.Hour() < b.PublishHour {
+        publication = cal.AddBusinessDays(publication, -1)
+    }
+    return cal.AddBusinessDays(publication, -b.PublishLagDays)
+}
+
+// expectedMonth returns the first day of the latest reference month whose
+// release, lagDays business days after the month ends, is due by now
+func expectedMonth(cal *calendar.Calendar, b *BenchmarkConfig, now time.Time, lagDays int) time.Time {
+    local := now.In(cal.Location)
+    month := time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, cal.Location).AddDate(0, -1, 0)
+    for {
+        monthEnd := month.AddDate(0, 1, -1)
+        release := cal.AddBusinessDays(monthEnd, lagDays).Add(time.Duration(b.PublishHour) * time.Hour)
+        if !local.Before(release) {
+            return month
+        }
+        month = month.AddDate(0, -1, 0)
+    }
+}
+
+// IsStale reports whether an effective date lags the expected one by more
+// than the benchmark's grace period in business days
+func IsStale(cal *calendar.Calendar, b *BenchmarkConfig, effective, expected time.Time) bool {
+    effectiveDay := effective.Format("2006-01-02")
+    oldest := cal.AddBusinessDays(cal.Date(expected), -b.GraceBusinessDays).Format("2006-01-02")
+    return effectiveDay < oldest
+}
+
+// Benchmarks returns the configured benchmark names in sorted order
+func (s *Service) Benchmarks() []string {
+    names := make([]string, 0, len(s.config.Benchmarks))
+    for name := range s.config.Benchmarks {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+// IsBenchmark reports whether name is a configured benchmark rate
+func (s *Service) IsBenchmark(name string) bool {
+    return s.config.Benchmarks[name] != nil
+}
+
+// Provider returns the provider publishing a benchmark, empty for unknown
+// benchmarks
+func (s *Service) Provider(name string) string {
+    if b := s.config.Benchmarks[name]; b != nil {
+        return b.Provider
+    }
+    return ""
+}
+
+// Latest returns the most recent observation of a benchmark
+func (s *Service) Latest(name string) (*Observation, bool) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    obs, ok := s.latest[name]
+    return obs, ok
+}
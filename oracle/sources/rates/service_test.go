@@ -0,0 +1,151 @@
+package rates
+
+import (
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/calendar"
+    "yetaXYZ/oracle/events"
+)
+
+func TestExpectedEffectiveDate(t *testing.T) {
+    cal := calendar.USFederal()
+    sofr := &BenchmarkConfig{Provider: ProviderNYFed, Series: "secured/sofr", PublishLagDays: 1, PublishHour: 8}
+
+    at := func(s string) time.Time {
+        ts, err := time.ParseInLocation("2006-01-02 15:04", s, cal.Location)
+        if err != nil {
+            t.Fatalf("Invalid time %s: %v", s, err)
+        }
+        return ts
+    }
+
+    tests := []struct {
+        now      string
+        expected string
+    }{
+        {"2024-04-16 09:00", "2024-04-15"}, // Tuesday after publication
+        {"2024-04-16 07:00", "2024-04-12"}, // Tuesday before publication
+        {"2024-04-13 12:00", "2024-04-11"}, // Saturday: Friday published Thursday's rate
+        {"2024-05-28 09:00", "2024-05-24"}, // Tuesday after Memorial Day
+    }
+    for _, tt := range tests {
+        got := ExpectedEffectiveDate(cal, sofr, at(tt.now)).Format("2006-01-02")
+        if got != tt.expected {
+            t.Errorf("At %s expected effective date %s, got %s", tt.now, tt.expected, got)
+        }
+    }
+
+    expected := ExpectedEffectiveDate(cal, sofr, at("2024-04-16 09:00"))
+    if IsStale(cal, sofr, at("2024-04-15 00:00"), expected) {
+        t.Error("Expected current rate not to be stale")
+    }
+    if !IsStale(cal, sofr, at("2024-04-12 00:00"), expected) {
+        t.Error("Expected rate one business day behind to be stale")
+    }
+    graced := *sofr
+    graced.GraceBusinessDays = 1
+    if IsStale(cal, &graced, at("2024-04-12 00:00"), expected) {
+        t.Error("Expected grace period to tolerate a late publication")
+    }
+}
+
+func TestFetchAll(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        switch r.URL.Path {
+        case "/nyfed/secured/sofr/last/1.json":
+            fmt.Fprint(w, `{"refRates":[{"effectiveDate":"2024-04-15","type":"SOFR","percentRate":5.31}]}`)
+        case "/fred":
+            fmt.Fprint(w, `{"observations":[{"date":"2024-04-16","value":"."},{"date":"2024-04-15","value":"5.25"}]}`)
+        default:
+            http.NotFound(w, r)
+        }
+    }))
+    defer server.Close()
+
+    nyfedBaseURL, fredBaseURL = server.URL+"/nyfed", server.URL+"/fred"
+    t.Setenv("TEST_FRED_KEY", "key")
+
+    bus := events.NewBus()
+    sub := bus.Subscribe(10, events.Aggregate)
+    defer sub.Close()
+
+    service := NewService(&Config{
+        FREDAPIKeyEnv: "TEST_FRED_KEY",
+        Benchmarks: map[string]*BenchmarkConfig{
+            "SOFR": {Provider: ProviderNYFed, Series: "secured/sofr", PublishLagDays: 1, PublishHour: 8},
+            "DTB3": {Provider: ProviderFRED, Series: "DTB3", PublishLagDays: 1, PublishHour: 16},
+        },
+    }, bus)
+
+    now := time.Date(2024, 4, 16, 18, 0, 0, 0, time.UTC)
+    service.FetchAll(now)
+    service.FetchAll(now)
+
+    sofr, ok := service.Latest("SOFR")
+    if !ok || sofr.Rate != 5.31 || sofr.Stale || sofr.RoundID != 1 {
+        t.Errorf("Unexpected SOFR observation: %+v", sofr)
+    }
+    bill, ok := service.Latest("DTB3")
+    if !ok || bill.Rate != 5.25 || bill.EffectiveDate.Format("2006-01-02") != "2024-04-15" {
+        t.Errorf("Unexpected DTB3 observation: %+v", bill)
+    }
+
+    // Unchanged observations must not publish new rounds
+    if n := len(sub.C); n != 2 {
+        t.Errorf("Expected 2 aggregate events, got %d", n)
+    }
+}
+
+func TestMonthlyIndex(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path != "/bls/CUUR0000SA0" {
+            http.NotFound(w, r)
+            return
+        }
+        fmt.Fprint(w, `{"status":"REQUEST_SUCCEEDED","Results":{"series":[{"seriesID":"CUUR0000SA0","data":[`+
+            `{"year":"2024","period":"M13","value":"310.0"},{"year":"2024","period":"M05","value":"-"},`+
+            `{"year":"2024","period":"M04","value":"313.548"}]}]}}`)
+    }))
+    defer server.Close()
+    blsBaseURL = server.URL + "/bls"
+
+    cal := calendar.USFederal()
+    at := func(s string) time.Time {
+        ts, err := time.ParseInLocation("2006-01-02 15:04", s, cal.Location)
+        if err != nil {
+            t.Fatalf("Invalid time %s: %v", s, err)
+        }
+        return ts
+    }
+
+    // May's CPI is released on the 8th business day of June, the 12th
+    cpi := &BenchmarkConfig{Provider: ProviderBLS, Series: "CUUR0000SA0", Frequency: FrequencyMonthly, PublishLagDays: 8, PublishHour: 9, GraceBusinessDays: 3}
+    for now, expected := range map[string]string{
+        "2024-06-12 10:00": "2024-05-01",
+        "2024-06-12 08:00": "2024-04-01",
+        "2024-06-01 12:00": "2024-04-01",
+    } {
+        if got := ExpectedEffectiveDate(cal, cpi, at(now)).Format("2006-01-02"); got != expected {
+            t.Errorf("At %s expected reference month %s, got %s", now, expected, got)
+        }
+    }
+
+    service := NewService(&Config{Benchmarks: map[string]*BenchmarkConfig{"CPI": cpi}}, events.NewBus())
+    obs, err := service.fetch("CPI", cpi, at("2024-06-14 10:00"))
+    if err != nil {
+        t.Fatalf("Fetch failed: %v", err)
+    }
+    if obs.Rate != 313.548 || obs.EffectiveDate.Format("2006-01") != "2024-04" {
+        t.Errorf("Expected April's index 313.548, got %v for %s", obs.Rate, obs.EffectiveDate)
+    }
+    if obs.Stale {
+        t.Error("Expected a late May release to be tolerated within the grace period")
+    }
+    if obs, _ = service.fetch("CPI", cpi, at("2024-06-18 10:00")); !obs.Stale {
+        t.Error("Expected April's index to be stale once May's release is overdue")
+    }
+}
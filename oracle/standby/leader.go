@@ -0,0 +1,114 @@
+package standby
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+    "yetaXYZ/oracle/publish"
+    "yetaXYZ/oracle/sources/crypto"
+)
+
+// SyncInterval is how often the leader sends its round IDs and breaker
+// state, which doubles as the stream's heartbeat
+const SyncInterval = 500 * time.Millisecond
+
+// Events of the state-sync stream
+const (
+    // eventSnapshot carries the full State, first on every connection
+    eventSnapshot = "snapshot"
+    // eventRound carries a completed round of a mirrored feed
+    eventRound = "round"
+    // eventSync carries the State without rounds, every SyncInterval
+    eventSync = "sync"
+)
+
+// State is the part of a leader's state that a standby mirrors so that it
+// can take over without priming its feeds
+type State struct {
+    // Rounds is the latest round of each mirrored feed
+    Rounds map[string]*common.AggregateResult `json:"rounds,omitempty"`
+    // RoundIDs is the last round ID the leader issued for each pair
+    RoundIDs map[string]uint64 `json:"roundIds"`
+    // Breaker is the publish breaker's state, nil without publishing
+    Breaker *publish.BreakerState `json:"breaker,omitempty"`
+    // Freezes are the pairs frozen on divergence and their last good
+    // rounds
+    Freezes *crypto.FreezeState `json:"freezes,omitempty"`
+}
+
+// Leader serves the state-sync stream that standbys mirror
+type Leader struct {
+    bus      *events.Bus
+    state    func() State
+    mirrored func(symbol string) bool
+}
+
+// NewLeader creates a leader streaming state() and the completed rounds of
+// the feeds mirrored accepts
+func NewLeader(bus *events.Bus, state func() State, mirrored func(symbol string) bool) *Leader {
+    return &Leader{bus: bus, state: state, mirrored: mirrored}
+}
+
+// ServeHTTP streams a snapshot of the leader's state, then every completed
+// round and, each SyncInterval, the round IDs and breaker state, as
+// server-sent events
+func (l *Leader) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    flusher, ok := w.(http.Flusher)
+    if !ok {
+        http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+        return
+    }
+
+    sub := l.bus.Subscribe(256, events.Aggregate).Named("standby")
+    defer sub.Close()
+
+    w.Header().Set("Content-Type", "text/event-stream")
+    w.Header().Set("Cache-Control", "no-cache")
+    w.Header().Set("Connection", "keep-alive")
+    if err := writeEvent(w, eventSnapshot, l.state()); err != nil {
+        return
+    }
+    flusher.Flush()
+
+    ticker := time.NewTicker(SyncInterval)
+    defer ticker.Stop()
+
+    for {
+        var err error
+        select {
+        case <-r.Context().Done():
+            return
+        case <-ticker.C:
+            state := l.state()
+            state.Rounds = nil
+            err = writeEvent(w, eventSync, state)
+        case e, ok := <-sub.C:
+            if !ok {
+                return // bus closed at shutdown
+            }
+            result, isRound := e.Payload.(*common.AggregateResult)
+            if !isRound || !l.mirrored(e.Symbol) {
+                continue
+            }
+            err = writeEvent(w, eventRound, result)
+        }
+        if err != nil {
+            return
+        }
+        flusher.Flush()
+    }
+}
+
+// writeEvent writes one server-sent event with a JSON payload
+func writeEvent(w http.ResponseWriter, event string, payload interface{}) error {
+    data, err := json.Marshal(payload)
+    if err != nil {
+        return err
+    }
+    _, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+    return err
+}
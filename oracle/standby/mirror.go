@@ -0,0 +1,346 @@
+package standby
+
+import (
+    "bufio"
+    "context"
+    "encoding/json"
+    "fmt"
+    "log"
+    "net/http"
+    "strings"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+    "yetaXYZ/oracle/fetch"
+)
+
+// maxEventBytes bounds a single server-sent event line; a snapshot carries
+// the latest round of every feed
+const maxEventBytes = 16 << 20
+
+// idleTimeout drops a connection on which the leader's sync events stopped
+// arriving
+const idleTimeout = 4 * SyncInterval
+
+// Reconnect backoff after the leader's stream ends or fails, short so that
+// a standby follows a restarted leader within a second
+const (
+    minBackoff = 100 * time.Millisecond
+    maxBackoff = 5 * time.Second
+)
+
+// Status describes a standby's link to its leader
+type Status struct {
+    Leader     string    `json:"leader"`
+    Connected  bool      `json:"connected"`
+    Since      time.Time `json:"since,omitempty"`    // when the current connection was made
+    LastSync   time.Time `json:"lastSync,omitempty"` // when the last event was received
+    Feeds      int       `json:"feeds"`              // feeds with a mirrored round
+    Holds      int       `json:"holds"`              // rounds held by the leader's publish breaker
+    Reconnects uint64    `json:"reconnects"`
+    LastError  string    `json:"lastError,omitempty"`
+    Promoted   bool      `json:"promoted"`
+    PromotedAt time.Time `json:"promotedAt,omitempty"`
+    PromotedBy string    `json:"promotedBy,omitempty"`
+}
+
+// Mirror keeps a standby's copy of its leader's latest rounds, round IDs
+// and publish breaker state from the leader's state-sync stream, and hands
+// them over when the standby is promoted. Mirrored rounds are republished
+// on the local bus so that the standby's history and derived feeds stay
+// current too.
+type Mirror struct {
+    leader string
+    bus    *events.Bus
+    client *http.Client
+    // promote receives who requested promotion; promoted is closed once
+    // the takeover has run
+    promote  chan string
+    promoted chan struct{}
+
+    mu        sync.RWMutex
+    token     string
+    failover  time.Duration
+    requested bool
+    state     State
+    status    Status
+}
+
+// NewMirror creates a mirror of the leader at the given base URL
+func NewMirror(leader string, bus *events.Bus) *Mirror {
+    leader = strings.TrimRight(leader, "/")
+    return &Mirror{
+        leader: leader,
+        bus:    bus,
+        // The stream is long-lived; stalls are detected by idleTimeout
+        client:   &http.Client{Transport: fetch.Transport},
+        promote:  make(chan string, 1),
+        promoted: make(chan struct{}),
+        state: State{
+            Rounds:   make(map[string]*common.AggregateResult),
+            RoundIDs: make(map[string]uint64),
+        },
+        status: Status{Leader: leader},
+    }
+}
+
+// SetToken authenticates the mirror to the leader's admin API
+func (m *Mirror) SetToken(token string) {
+    m.mu.Lock()
+    m.token = token
+    m.mu.Unlock()
+}
+
+// SetFailover promotes the standby by itself once the leader has been
+// unreachable for after, provided it was mirrored at least once; 0, the
+// default, leaves promotion to an operator
+func (m *Mirror) SetFailover(after time.Duration) {
+    m.mu.Lock()
+    m.failover = after
+    m.mu.Unlock()
+}
+
+// Run mirrors the leader until the standby is promoted, then calls takeover
+// with the mirrored state. It returns without calling takeover when ctx is
+// cancelled first.
+func (m *Mirror) Run(ctx context.Context, takeover func(context.Context, State)) {
+    followCtx, cancel := context.WithCancel(ctx)
+    defer cancel()
+    stopped := make(chan struct{})
+    go func() {
+        defer close(stopped)
+        m.followLoop(followCtx)
+    }()
+
+    var operator string
+    select {
+    case <-ctx.Done():
+        return
+    case operator = <-m.promote:
+    }
+    cancel()
+    <-stopped
+
+    m.mu.Lock()
+    m.status.Connected = false
+    m.status.Promoted = true
+    m.status.PromotedAt = time.Now()
+    m.status.PromotedBy = operator
+    state := m.state
+    m.mu.Unlock()
+    log.Printf("Promoted to leader by %s, taking over %d mirrored feeds from %s", operator, len(state.Rounds), m.leader)
+
+    takeover(ctx, state)
+    close(m.promoted)
+}
+
+// Promote ends mirroring on an operator's request and returns once Run has
+// handed the mirrored state over
+func (m *Mirror) Promote(ctx context.Context, operator string) (Status, error) {
+    if !m.request(operator) {
+        return Status{}, fmt.Errorf("already promoted")
+    }
+    select {
+    case <-m.promoted:
+        return m.Status(), nil
+    case <-ctx.Done():
+        return Status{}, ctx.Err()
+    }
+}
+
+// request asks Run to promote the standby, at most once
+func (m *Mirror) request(operator string) bool {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    if m.requested {
+        return false
+    }
+    m.requested = true
+    m.promote <- operator
+    return true
+}
+
+// followLoop follows the leader's stream, reconnecting with backoff, until
+// ctx is cancelled or the failover period passes without the leader
+func (m *Mirror) followLoop(ctx context.Context) {
+    backoff := minBackoff
+    var lost time.Time
+    for {
+        err := m.follow(ctx)
+        if ctx.Err() != nil {
+            return
+        }
+
+        m.mu.Lock()
+        wasConnected := m.status.Connected
+        m.status.Connected = false
+        m.status.Reconnects++
+        if err != nil {
+            m.status.LastError = err.Error()
+        }
+        synced := !m.status.LastSync.IsZero()
+        failover := m.failover
+        m.mu.Unlock()
+
+        if wasConnected || lost.IsZero() {
+            lost = time.Now()
+        }
+        if wasConnected {
+            backoff = minBackoff
+        }
+        if failover > 0 && synced && time.Since(lost) >= failover {
+            log.Printf("Leader %s unreachable for %s: %v; failing over", m.leader, time.Since(lost).Round(time.Millisecond), err)
+            m.request("failover")
+            return
+        }
+        log.Printf("State-sync stream from %s ended: %v; reconnecting in %s", m.leader, err, backoff)
+        select {
+        case <-ctx.Done():
+            return
+        case <-time.After(backoff):
+        }
+        if backoff *= 2; backoff > maxBackoff {
+            backoff = maxBackoff
+        }
+    }
+}
+
+// follow reads one connection to the leader's stream until it ends
+func (m *Mirror) follow(ctx context.Context) error {
+    req, err := http.NewRequest("GET", m.leader+"/api/v1/admin/standby/stream", nil)
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Accept", "text/event-stream")
+    m.mu.RLock()
+    token := m.token
+    m.mu.RUnlock()
+    if token != "" {
+        req.Header.Set("Authorization", "Bearer "+token)
+    }
+
+    streamCtx, cancel := context.WithCancel(ctx)
+    defer cancel()
+    idle := time.AfterFunc(idleTimeout, cancel)
+    defer idle.Stop()
+
+    resp, err := m.client.Do(req.WithContext(streamCtx))
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("unexpected status from leader: %s", resp.Status)
+    }
+
+    m.mu.Lock()
+    m.status.Connected = true
+    m.status.Since = time.Now()
+    m.status.LastError = ""
+    m.mu.Unlock()
+    log.Printf("Mirroring %s", m.leader)
+
+    scanner := bufio.NewScanner(resp.Body)
+    scanner.Buffer(make([]byte, 64*1024), maxEventBytes)
+    var eventType, data string
+    for scanner.Scan() {
+        idle.Reset(idleTimeout)
+        line := scanner.Text()
+        switch {
+        case line == "":
+            if eventType != "" && data != "" {
+                m.dispatch(eventType, data)
+            }
+            eventType, data = "", ""
+        case strings.HasPrefix(line, "event:"):
+            eventType = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+        case strings.HasPrefix(line, "data:"):
+            data += strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+        }
+    }
+    if err := scanner.Err(); err != nil {
+        return err
+    }
+    return fmt.Errorf("stream closed by leader")
+}
+
+// dispatch applies one event of the stream to the mirrored state and
+// republishes new rounds on the local bus
+func (m *Mirror) dispatch(eventType, data string) {
+    var fresh []*common.AggregateResult
+    switch eventType {
+    case eventSnapshot:
+        var state State
+        if err := json.Unmarshal([]byte(data), &state); err != nil {
+            log.Printf("Invalid state snapshot: %v", err)
+            return
+        }
+        m.mu.Lock()
+        for symbol, result := range state.Rounds {
+            if m.record(symbol, result) {
+                fresh = append(fresh, result)
+            }
+        }
+        m.state.RoundIDs = state.RoundIDs
+        m.state.Breaker = state.Breaker
+        m.state.Freezes = state.Freezes
+        m.mu.Unlock()
+    case eventRound:
+        var result common.AggregateResult
+        if err := json.Unmarshal([]byte(data), &result); err != nil {
+            log.Printf("Invalid mirrored round: %v", err)
+            return
+        }
+        m.mu.Lock()
+        if m.record(result.Symbol, &result) {
+            fresh = append(fresh, &result)
+        }
+        m.mu.Unlock()
+    case eventSync:
+        var state State
+        if err := json.Unmarshal([]byte(data), &state); err != nil {
+            log.Printf("Invalid state sync: %v", err)
+            return
+        }
+        m.mu.Lock()
+        m.state.RoundIDs = state.RoundIDs
+        m.state.Breaker = state.Breaker
+        m.state.Freezes = state.Freezes
+        m.mu.Unlock()
+    default:
+        return
+    }
+
+    for _, result := range fresh {
+        m.bus.Publish(events.Event{Type: events.Aggregate, Symbol: result.Symbol, Timestamp: result.Timestamp, Payload: result})
+    }
+
+    m.mu.Lock()
+    m.status.LastSync = time.Now()
+    m.status.Feeds = len(m.state.Rounds)
+    m.status.Holds = 0
+    if m.state.Breaker != nil {
+        m.status.Holds = len(m.state.Breaker.Holds)
+    }
+    m.mu.Unlock()
+}
+
+// record keeps a round as the latest of its feed, reporting whether it was
+// not already mirrored, as rounds sent again in a snapshot are. Callers
+// hold mu.
+func (m *Mirror) record(symbol string, result *common.AggregateResult) bool {
+    if current, ok := m.state.Rounds[symbol]; ok && current.RoundID == result.RoundID && current.Timestamp.Equal(result.Timestamp) {
+        return false
+    }
+    m.state.Rounds[symbol] = result
+    return true
+}
+
+// Status returns the current state of the link to the leader
+func (m *Mirror) Status() Status {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+    return m.status
+}
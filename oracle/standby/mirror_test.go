@@ -0,0 +1,119 @@
+package standby
+
+import (
+    "context"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+    "yetaXYZ/oracle/publish"
+)
+
+func TestMirrorTakesOverLeaderState(t *testing.T) {
+    leaderBus := events.NewBus()
+    leader := NewLeader(leaderBus, func() State {
+        return State{
+            Rounds: map[string]*common.AggregateResult{
+                "ETHUSDT": {Symbol: "ETHUSDT", PricePoint: common.PricePoint{Price: 3000}, RoundID: 7},
+            },
+            RoundIDs: map[string]uint64{"ETHUSDT": 7, "BTCUSDT": 12},
+            Breaker: &publish.BreakerState{
+                Published: map[string]float64{"BTCUSDT": 60000},
+                Holds: []publish.HeldRound{{
+                    Hold:  publish.Hold{Symbol: "BTCUSDT", RoundID: 12, Price: 80000, Published: 60000},
+                    Round: &common.AggregateResult{Symbol: "BTCUSDT", PricePoint: common.PricePoint{Price: 80000}, RoundID: 12},
+                }},
+            },
+        }
+    }, func(symbol string) bool { return symbol != "ETHBTC" })
+    srv := httptest.NewServer(leader)
+    defer srv.Close()
+    defer srv.CloseClientConnections()
+
+    bus := events.NewBus()
+    mirrored := bus.Subscribe(10, events.Aggregate)
+    defer mirrored.Close()
+
+    m := NewMirror(srv.URL, bus)
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    takenOver := make(chan State, 1)
+    go m.Run(ctx, func(ctx context.Context, state State) { takenOver <- state })
+
+    next := func() *common.AggregateResult {
+        select {
+        case e := <-mirrored.C:
+            return e.Payload.(*common.AggregateResult)
+        case <-time.After(5 * time.Second):
+            t.Fatal("Timed out waiting for a mirrored round")
+            return nil
+        }
+    }
+    if result := next(); result.Symbol != "ETHUSDT" || result.RoundID != 7 {
+        t.Fatalf("Expected the snapshot's ETHUSDT round, got %+v", result)
+    }
+
+    // Rounds completed on the leader follow the snapshot, except for
+    // feeds the standby computes itself
+    leaderBus.Publish(events.Event{Type: events.Aggregate, Symbol: "ETHBTC", Payload: &common.AggregateResult{Symbol: "ETHBTC", RoundID: 1}})
+    leaderBus.Publish(events.Event{Type: events.Aggregate, Symbol: "ETHUSDT", Payload: &common.AggregateResult{Symbol: "ETHUSDT", PricePoint: common.PricePoint{Price: 3010}, RoundID: 8}})
+    if result := next(); result.Symbol != "ETHUSDT" || result.RoundID != 8 {
+        t.Fatalf("Expected ETHUSDT round 8, got %+v", result)
+    }
+
+    status, err := m.Promote(context.Background(), "alice")
+    if err != nil {
+        t.Fatalf("Failed to promote: %v", err)
+    }
+    if !status.Promoted || status.PromotedBy != "alice" || status.Feeds != 1 || status.Holds != 1 {
+        t.Errorf("Expected a promoted standby with 1 feed and 1 hold, got %+v", status)
+    }
+    state := <-takenOver
+    if state.Rounds["ETHUSDT"].Price != 3010 || state.RoundIDs["BTCUSDT"] != 12 {
+        t.Errorf("Expected the latest rounds and round IDs, got %+v", state)
+    }
+    if state.Breaker == nil || len(state.Breaker.Holds) != 1 || state.Breaker.Holds[0].Round.RoundID != 12 {
+        t.Errorf("Expected the held BTCUSDT round, got %+v", state.Breaker)
+    }
+    if _, err := m.Promote(context.Background(), "bob"); err == nil {
+        t.Error("Expected promoting twice to fail")
+    }
+}
+
+func TestMirrorFailsOverWhenLeaderIsLost(t *testing.T) {
+    leader := NewLeader(events.NewBus(), func() State {
+        return State{RoundIDs: map[string]uint64{"ETHUSDT": 3}}
+    }, func(string) bool { return true })
+    srv := httptest.NewServer(leader)
+
+    m := NewMirror(srv.URL, events.NewBus())
+    m.SetFailover(200 * time.Millisecond)
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    takenOver := make(chan State, 1)
+    go m.Run(ctx, func(ctx context.Context, state State) { takenOver <- state })
+
+    deadline := time.Now().Add(5 * time.Second)
+    for m.Status().LastSync.IsZero() {
+        if time.Now().After(deadline) {
+            t.Fatal("Timed out waiting for the snapshot")
+        }
+        time.Sleep(10 * time.Millisecond)
+    }
+    srv.CloseClientConnections()
+    srv.Close()
+
+    select {
+    case state := <-takenOver:
+        if state.RoundIDs["ETHUSDT"] != 3 {
+            t.Errorf("Expected the mirrored round IDs, got %+v", state.RoundIDs)
+        }
+    case <-time.After(5 * time.Second):
+        t.Fatal("Timed out waiting for failover")
+    }
+    if status := m.Status(); !status.Promoted || status.PromotedBy != "failover" {
+        t.Errorf("Expected an automatic promotion, got %+v", status)
+    }
+}
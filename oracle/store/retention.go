@@ -0,0 +1,305 @@
+package store
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "log"
+    "os"
+    "path/filepath"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/common"
+)
+
+// Downsampling resolutions
+const (
+    minuteInterval = "1m"
+    hourInterval   = "1h"
+)
+
+// Retention is how long each resolution of history is kept. Raw rounds
+// older than RawDays become 1m candles, 1m candles older than MinuteDays
+// become 1h candles and 1h candles older than HourDays are deleted. A zero
+// value keeps that resolution indefinitely.
+type Retention struct {
+    RawDays    int `json:"rawDays"`
+    MinuteDays int `json:"minuteDays"`
+    HourDays   int `json:"hourDays"`
+    // CompactionIntervalSeconds is how often retention is applied
+    CompactionIntervalSeconds int `json:"compactionIntervalSeconds"`
+}
+
+// Config holds the store configuration
+type Config struct {
+    Retention Retention `json:"retention"`
+    // RingSize is how many of each feed's latest rounds are kept in memory
+    // for TWAP windows and statistic feeds; 0 uses the default
+    RingSize int `json:"ringSize,omitempty"`
+    // ConfigArchive is a directory keeping every configuration version
+    // rounds were aggregated under, so they can be reconstructed after a
+    // restart; empty keeps versions in memory only
+    ConfigArchive string `json:"configArchive,omitempty"`
+    // FreezeState is a file keeping frozen pairs and their last good
+    // rounds, so that a restart does not resume them unacknowledged
+    FreezeState string `json:"freezeState,omitempty"`
+}
+
+// LoadConfig loads store/store.json from the config directory. A missing
+// file keeps all history at full resolution.
+func LoadConfig(configDir string) (*Config, error) {
+    data, err := os.ReadFile(filepath.Join(configDir, "store", "store.json"))
+    if os.IsNotExist(err) {
+        return &Config{}, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read store config: %v", err)
+    }
+
+    var config Config
+    if err := json.Unmarshal(data, &config); err != nil {
+        return nil, fmt.Errorf("failed to parse store config: %v", err)
+    }
+    if err := config.Retention.Validate(); err != nil {
+        return nil, err
+    }
+    if config.RingSize < 0 {
+        return nil, fmt.Errorf("ringSize must not be negative")
+    }
+    return &config, nil
+}
+
+// Validate checks that each resolution is kept at least as long as the
+// finer one it is built from
+func (r Retention) Validate() error {
+    if r.RawDays < 0 || r.MinuteDays < 0 || r.HourDays < 0 || r.CompactionIntervalSeconds < 0 {
+        return fmt.Errorf("retention periods must not be negative")
+    }
+    if r.MinuteDays > 0 && r.MinuteDays < r.RawDays {
+        return fmt.Errorf("minuteDays must not be shorter than rawDays")
+    }
+    if r.HourDays > 0 && (r.HourDays < r.MinuteDays || r.HourDays < r.RawDays) {
+        return fmt.Errorf("hourDays must not be shorter than minuteDays and rawDays")
+    }
+    if r.MinuteDays > 0 && r.RawDays == 0 {
+        return fmt.Errorf("minuteDays requires rawDays")
+    }
+    return nil
+}
+
+// Interval returns the compaction interval, hourly by default
+func (r Retention) Interval() time.Duration {
+    if r.CompactionIntervalSeconds <= 0 {
+        return time.Hour
+    }
+    return time.Duration(r.CompactionIntervalSeconds) * time.Second
+}
+
+// CompactionReport summarizes one compaction pass
+type CompactionReport struct {
+    At          time.Time     `json:"at"`
+    Duration    time.Duration `json:"duration"`
+    Downsampled int           `json:"downsampled"` // rounds merged into candles
+    Deleted     int           `json:"deleted"`     // rounds past retention
+    Errors      int           `json:"errors"`
+}
+
+// Compactor applies a retention policy to a store in the background
+type Compactor struct {
+    store     Store
+    retention Retention
+
+    mu   sync.RWMutex
+    last *CompactionReport
+}
+
+// NewCompactor creates a compactor applying retention to s
+func NewCompactor(s Store, retention Retention) *Compactor {
+    return &Compactor{store: s, retention: retention}
+}
+
+// Retention returns the applied retention policy
+func (c *Compactor) Retention() Retention {
+    return c.retention
+}
+
+// Run compacts the store at interval until ctx is cancelled
+func (c *Compactor) Run(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            c.Compact(time.Now())
+        }
+    }
+}
+
+// Compact downsamples and expires the history of every feed
+func (c *Compactor) Compact(now time.Time) CompactionReport {
+    report := CompactionReport{At: now}
+    started := time.Now()
+
+    symbols, err := c.store.Symbols()
+    if err != nil {
+        log.Printf("Compaction failed to list feeds: %v", err)
+        report.Errors++
+    }
+    day := 24 * time.Hour
+    for _, symbol := range symbols {
+        if c.retention.HourDays > 0 {
+            cutoff := now.Add(-time.Duration(c.retention.HourDays) * day).Truncate(time.Hour)
+            c.apply(symbol, cutoff, 0, &report)
+        }
+        if c.retention.MinuteDays > 0 {
+            cutoff := now.Add(-time.Duration(c.retention.MinuteDays) * day).Truncate(time.Hour)
+            c.apply(symbol, cutoff, time.Hour, &report)
+        }
+        if c.retention.RawDays > 0 {
+            cutoff := now.Add(-time.Duration(c.retention.RawDays) * day).Truncate(time.Minute)
+            c.apply(symbol, cutoff, time.Minute, &report)
+        }
+    }
+    report.Duration = time.Since(started)
+
+    if report.Downsampled > 0 || report.Deleted > 0 {
+        log.Printf("Compaction downsampled %d and deleted %d rounds in %s", report.Downsampled, report.Deleted, report.Duration)
+    }
+    c.mu.Lock()
+    c.last = &report
+    c.mu.Unlock()
+    return report
+}
+
+// apply downsamples a feed's rounds before cutoff to bucket, or deletes
+// them when bucket is zero
+func (c *Compactor) apply(symbol string, cutoff time.Time, bucket time.Duration, report *CompactionReport) {
+    rounds, err := c.store.Rounds(symbol, time.Time{}, cutoff)
+    if err != nil {
+        log.Printf("Compaction failed to load rounds of %s: %v", symbol, err)
+        report.Errors++
+        return
+    }
+    // Rounds is inclusive of cutoff, Replace is not
+    if n := len(rounds); n > 0 && !rounds[n-1].Timestamp.Before(cutoff) {
+        rounds = rounds[:n-1]
+    }
+    if len(rounds) == 0 {
+        return
+    }
+
+    var replacement []*common.AggregateResult
+    if bucket > 0 {
+        replacement = Downsample(rounds, bucket)
+        if len(replacement) == len(rounds) {
+            return
+        }
+    }
+    if err := c.store.Replace(symbol, time.Time{}, cutoff, replacement); err != nil {
+        log.Printf("Compaction failed to replace rounds of %s: %v", symbol, err)
+        report.Errors++
+        return
+    }
+    if bucket > 0 {
+        report.Downsampled += len(rounds) - len(replacement)
+    } else {
+        report.Deleted += len(rounds)
+    }
+}
+
+// Last returns the report of the most recent compaction, nil before the first
+func (c *Compactor) Last() *CompactionReport {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    return c.last
+}
+
+// Downsample merges rounds, oldest first, into one candle per bucket.
+// Candles already at bucket or a coarser interval are kept as they are.
+// Each candle carries the close, volume, timestamp and round ID of the
+// last round of its bucket; per-source prices are dropped.
+func Downsample(rounds []*common.AggregateResult, bucket time.Duration) []*common.AggregateResult {
+    out := make([]*common.AggregateResult, 0, len(rounds))
+    var current *common.AggregateResult
+    var start time.Time
+    for _, r := range rounds {
+        if r.Candle != nil && candleDuration(r.Candle.Interval) >= bucket {
+            out = append(out, r)
+            current = nil
+            continue
+        }
+        if current != nil && r.Timestamp.Truncate(bucket).Equal(start) {
+            merge(current, r)
+            continue
+        }
+        current = newCandle(r, bucket)
+        start = r.Timestamp.Truncate(bucket)
+        out = append(out, current)
+    }
+    return out
+}
+
+// newCandle starts a candle from its bucket's first round
+func newCandle(r *common.AggregateResult, bucket time.Duration) *common.AggregateResult {
+    candle := &common.Candle{Interval: intervalName(bucket), Open: r.Price, High: r.Price, Low: r.Price, Close: r.Price, Rounds: 1}
+    if r.Candle != nil {
+        candle.Open, candle.High, candle.Low, candle.Rounds = r.Candle.Open, r.Candle.High, r.Candle.Low, r.Candle.Rounds
+    }
+    return &common.AggregateResult{
+        Symbol:        r.Symbol,
+        PricePoint:    r.PricePoint,
+        RoundID:       r.RoundID,
+        ConfigVersion: r.ConfigVersion,
+        MarketClosed:  r.MarketClosed,
+        Backfilled:    r.Backfilled,
+        Candle:        candle,
+    }
+}
+
+// merge extends a candle with a later round of the same bucket
+func merge(candle *common.AggregateResult, r *common.AggregateResult) {
+    high, low, rounds := r.Price, r.Price, 1
+    if r.Candle != nil {
+        high, low, rounds = r.Candle.High, r.Candle.Low, r.Candle.Rounds
+    }
+    if high > candle.Candle.High {
+        candle.Candle.High = high
+    }
+    if low < candle.Candle.Low {
+        candle.Candle.Low = low
+    }
+    candle.Candle.Close = r.Price
+    candle.Candle.Rounds += rounds
+    candle.PricePoint = r.PricePoint
+    candle.RoundID = r.RoundID
+    candle.ConfigVersion = r.ConfigVersion
+    candle.MarketClosed = r.MarketClosed
+    candle.Backfilled = candle.Backfilled && r.Backfilled
+}
+
+// intervalName returns the name of a downsampling interval
+func intervalName(bucket time.Duration) string {
+    switch bucket {
+    case time.Minute:
+        return minuteInterval
+    case time.Hour:
+        return hourInterval
+    }
+    return bucket.String()
+}
+
+// candleDuration parses the interval name of a candle
+func candleDuration(name string) time.Duration {
+    switch name {
+    case minuteInterval:
+        return time.Minute
+    case hourInterval:
+        return time.Hour
+    }
+    d, _ := time.ParseDuration(name)
+    return d
+}
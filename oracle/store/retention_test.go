@@ -0,0 +1,94 @@
+package store
+
+import (
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+)
+
+func round(symbol string, price float64, t time.Time) *common.AggregateResult {
+    return &common.AggregateResult{Symbol: symbol, PricePoint: common.PricePoint{Price: price, Timestamp: t}}
+}
+
+func TestDownsample(t *testing.T) {
+    base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+    rounds := []*common.AggregateResult{
+        round("BTCUSD", 100, base.Add(5*time.Second)),
+        round("BTCUSD", 104, base.Add(20*time.Second)),
+        round("BTCUSD", 98, base.Add(40*time.Second)),
+        round("BTCUSD", 101, base.Add(55*time.Second)),
+        round("BTCUSD", 102, base.Add(65*time.Second)),
+    }
+
+    minutes := Downsample(rounds, time.Minute)
+    if len(minutes) != 2 {
+        t.Fatalf("Expected 2 candles, got %d", len(minutes))
+    }
+    c := minutes[0]
+    want := common.Candle{Interval: "1m", Open: 100, High: 104, Low: 98, Close: 101, Rounds: 4}
+    if *c.Candle != want || c.Price != 101 || !c.Timestamp.Equal(base.Add(55*time.Second)) {
+        t.Errorf("Expected candle %+v closing at 101, got %+v %+v", want, c, c.Candle)
+    }
+
+    hours := Downsample(minutes, time.Hour)
+    if len(hours) != 1 {
+        t.Fatalf("Expected 1 candle, got %d", len(hours))
+    }
+    want = common.Candle{Interval: "1h", Open: 100, High: 104, Low: 98, Close: 102, Rounds: 5}
+    if *hours[0].Candle != want {
+        t.Errorf("Expected candle %+v, got %+v", want, hours[0].Candle)
+    }
+
+    // Coarser candles are never split or re-merged into finer ones
+    again := Downsample(hours, time.Minute)
+    if len(again) != 1 || again[0] != hours[0] {
+        t.Errorf("Expected the hourly candle to be kept, got %+v", again)
+    }
+}
+
+func TestCompactor(t *testing.T) {
+    now := time.Date(2024, 6, 30, 0, 0, 30, 0, time.UTC)
+    s := NewMemoryStore()
+    // One round every 30 seconds over the last 20 days
+    for ts := now.Add(-20 * 24 * time.Hour); ts.Before(now); ts = ts.Add(30 * time.Second) {
+        s.SaveRound(round("ETHUSD", 2000, ts))
+    }
+
+    c := NewCompactor(s, Retention{RawDays: 1, MinuteDays: 7, HourDays: 14})
+    report := c.Compact(now)
+    if report.Errors != 0 || report.Deleted == 0 || report.Downsampled == 0 {
+        t.Fatalf("Expected rounds downsampled and deleted, got %+v", report)
+    }
+
+    stats, _ := s.Stats()
+    if stats.Raw != 2*24*60+1 {
+        t.Errorf("Expected one day of raw rounds, got %d", stats.Raw)
+    }
+    if stats.Candles["1m"] != 6*24*60 || stats.Candles["1h"] != 7*24 {
+        t.Errorf("Expected 6 days of 1m and 7 days of 1h candles, got %+v", stats.Candles)
+    }
+    if want := now.Add(-14 * 24 * time.Hour).Truncate(time.Hour); stats.Oldest.Before(want) {
+        t.Errorf("Expected nothing older than %s, got %s", want, stats.Oldest)
+    }
+
+    // Compacting again changes nothing
+    if report := c.Compact(now); report.Downsampled != 0 || report.Deleted != 0 {
+        t.Errorf("Expected an idempotent compaction, got %+v", report)
+    }
+    if c.Last() == nil {
+        t.Error("Expected the last compaction report")
+    }
+}
+
+func TestRetentionValidate(t *testing.T) {
+    if err := (Retention{RawDays: 7, MinuteDays: 3}).Validate(); err == nil {
+        t.Error("Expected an error for minute candles expiring before raw rounds")
+    }
+    if err := (Retention{MinuteDays: 3}).Validate(); err == nil {
+        t.Error("Expected an error for minute candles without raw retention")
+    }
+    if err := (Retention{RawDays: 7, MinuteDays: 90}).Validate(); err != nil {
+        t.Errorf("Unexpected error: %v", err)
+    }
+}
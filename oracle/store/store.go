@@ -0,0 +1,236 @@
+package store
+
+import (
+    "fmt"
+    "sort"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/events"
+)
+
+// Store persists completed aggregation rounds
+type Store interface {
+    // SaveRound records a completed round
+    SaveRound(result *common.AggregateResult) error
+    // Rounds returns the rounds of a feed within [from, to], oldest first
+    Rounds(symbol string, from, to time.Time) ([]*common.AggregateResult, error)
+    // Latest returns the most recent round of a feed
+    Latest(symbol string) (*common.AggregateResult, error)
+    // Round returns the most recent round of a feed with the given ID;
+    // rounds downsampled into candles are no longer found
+    Round(symbol string, roundID uint64) (*common.AggregateResult, error)
+    // Symbols returns every feed with stored rounds
+    Symbols() ([]string, error)
+    // Replace swaps the rounds of a feed within [from, to) for the given
+    // ones, which must lie within the same range
+    Replace(symbol string, from, to time.Time, rounds []*common.AggregateResult) error
+    // Stats reports the number of stored rounds by resolution
+    Stats() (Stats, error)
+}
+
+// Stats describes the size of a store
+type Stats struct {
+    Symbols int `json:"symbols"`
+    Rounds  int `json:"rounds"`
+    Raw     int `json:"raw"` // rounds at full resolution
+    // Candles counts downsampled rounds by interval
+    Candles map[string]int `json:"candles"`
+    Oldest  time.Time      `json:"oldest,omitempty"`
+}
+
+// ErrNotFound is returned when no round matches a query
+type ErrNotFound struct {
+    Symbol  string
+    RoundID uint64 // set when a specific round was asked for
+}
+
+func (e *ErrNotFound) Error() string {
+    if e.RoundID != 0 {
+        return fmt.Sprintf("round %d of %s is not stored", e.RoundID, e.Symbol)
+    }
+    return "no stored rounds for " + e.Symbol
+}
+
+// MemoryStore is an in-process Store keeping rounds ordered by timestamp
+type MemoryStore struct {
+    mu     sync.RWMutex
+    rounds map[string][]*common.AggregateResult
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+    return &MemoryStore{
+        rounds: make(map[string][]*common.AggregateResult),
+    }
+}
+
+// SaveRound records a completed round
+func (m *MemoryStore) SaveRound(result *common.AggregateResult) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    rounds := m.rounds[result.Symbol]
+    // Rounds almost always arrive in order; insert in place otherwise
+    i := len(rounds)
+    for i > 0 && rounds[i-1].Timestamp.After(result.Timestamp) {
+        i--
+    }
+    rounds = append(rounds, nil)
+    copy(rounds[i+1:], rounds[i:])
+    rounds[i] = result
+    m.rounds[result.Symbol] = rounds
+    return nil
+}
+
+// Rounds returns the rounds of a feed within [from, to], oldest first
+func (m *MemoryStore) Rounds(symbol string, from, to time.Time) ([]*common.AggregateResult, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    rounds := m.rounds[symbol]
+    start := sort.Search(len(rounds), func(i int) bool { return !rounds[i].Timestamp.Before(from) })
+    end := sort.Search(len(rounds), func(i int) bool { return rounds[i].Timestamp.After(to) })
+    if start >= end {
+        return nil, nil
+    }
+    return append([]*common.AggregateResult(nil), rounds[start:end]...), nil
+}
+
+// Latest returns the most recent round of a feed
+func (m *MemoryStore) Latest(symbol string) (*common.AggregateResult, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    rounds := m.rounds[symbol]
+    if len(rounds) == 0 {
+        return nil, &ErrNotFound{Symbol: symbol}
+    }
+    return rounds[len(rounds)-1], nil
+}
+
+// Round returns the most recent round of a feed with the given ID. IDs
+// restart with the process, so an older run may have used the same one.
+func (m *MemoryStore) Round(symbol string, roundID uint64) (*common.AggregateResult, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    rounds := m.rounds[symbol]
+    for i := len(rounds) - 1; i >= 0; i-- {
+        if rounds[i].RoundID == roundID && rounds[i].Candle == nil {
+            return rounds[i], nil
+        }
+    }
+    return nil, &ErrNotFound{Symbol: symbol, RoundID: roundID}
+}
+
+// Symbols returns every feed with stored rounds
+func (m *MemoryStore) Symbols() ([]string, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    symbols := make([]string, 0, len(m.rounds))
+    for symbol := range m.rounds {
+        symbols = append(symbols, symbol)
+    }
+    sort.Strings(symbols)
+    return symbols, nil
+}
+
+// Replace swaps the rounds of a feed within [from, to) for the given ones
+func (m *MemoryStore) Replace(symbol string, from, to time.Time, rounds []*common.AggregateResult) error {
+    for _, r := range rounds {
+        if r.Timestamp.Before(from) || !r.Timestamp.Before(to) {
+            return fmt.Errorf("round at %s outside replaced range", r.Timestamp.Format(time.RFC3339))
+        }
+    }
+    replacement := append([]*common.AggregateResult(nil), rounds...)
+    sort.SliceStable(replacement, func(i, j int) bool { return replacement[i].Timestamp.Before(replacement[j].Timestamp) })
+
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    existing := m.rounds[symbol]
+    start := sort.Search(len(existing), func(i int) bool { return !existing[i].Timestamp.Before(from) })
+    end := sort.Search(len(existing), func(i int) bool { return !existing[i].Timestamp.Before(to) })
+
+    updated := make([]*common.AggregateResult, 0, len(existing)-(end-start)+len(replacement))
+    updated = append(updated, existing[:start]...)
+    updated = append(updated, replacement...)
+    updated = append(updated, existing[end:]...)
+    if len(updated) == 0 {
+        delete(m.rounds, symbol)
+        return nil
+    }
+    m.rounds[symbol] = updated
+    return nil
+}
+
+// Stats reports the number of stored rounds by resolution
+func (m *MemoryStore) Stats() (Stats, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    stats := Stats{Symbols: len(m.rounds), Candles: make(map[string]int)}
+    for _, rounds := range m.rounds {
+        stats.Rounds += len(rounds)
+        for _, r := range rounds {
+            if r.Candle == nil {
+                stats.Raw++
+            } else {
+                stats.Candles[r.Candle.Interval]++
+            }
+        }
+        if len(rounds) > 0 && (stats.Oldest.IsZero() || rounds[0].Timestamp.Before(stats.Oldest)) {
+            stats.Oldest = rounds[0].Timestamp
+        }
+    }
+    return stats, nil
+}
+
+// DefaultMaxAge is how old a feed's latest round may be when checks compare
+// feeds, unless they are configured otherwise
+const DefaultMaxAge = 2 * time.Minute
+
+// Fresh returns the latest positive price of a feed no older than maxAge,
+// or DefaultMaxAge when maxAge is zero
+func Fresh(s Store, symbol string, maxAge time.Duration, now time.Time) (float64, error) {
+    latest, err := s.Latest(symbol)
+    if err != nil {
+        return 0, fmt.Errorf("%s unavailable", symbol)
+    }
+    if maxAge <= 0 {
+        maxAge = DefaultMaxAge
+    }
+    if now.Sub(latest.Timestamp) > maxAge {
+        return 0, fmt.Errorf("%s older than %s", symbol, maxAge)
+    }
+    if latest.Price <= 0 {
+        return 0, fmt.Errorf("%s has no positive price", symbol)
+    }
+    return latest.Price, nil
+}
+
+// Record subscribes the store to aggregate events so every completed round
+// is persisted without the aggregator calling the store directly. Rounds
+// queue rather than being dropped while the store is slow.
+func Record(s Store, bus *events.Bus) *events.Subscription {
+    return bus.SubscribeQueue("store", func(e events.Event) {
+        result, ok := e.Payload.(*common.AggregateResult)
+        if !ok {
+            return
+        }
+        if err := s.SaveRound(result); err != nil {
+            bus.Publish(events.Event{
+                Type:   events.Alert,
+                Symbol: e.Symbol,
+                Payload: &events.AlertPayload{
+                    Severity: events.SeverityWarning,
+                    Kind:     "store_write_failed",
+                    Message:  err.Error(),
+                },
+            })
+        }
+    }, events.Aggregate)
+}
@@ -0,0 +1,60 @@
+package store
+
+import (
+    "errors"
+    "testing"
+    "time"
+)
+
+func TestRoundLookup(t *testing.T) {
+    base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+    s := NewMemoryStore()
+    for i, price := range []float64{100, 101, 102} {
+        r := round("BTCUSD", price, base.Add(time.Duration(i)*time.Second))
+        r.RoundID = uint64(i + 1)
+        s.SaveRound(r)
+    }
+    // A later run numbering its rounds from 1 again
+    restarted := round("BTCUSD", 110, base.Add(time.Hour))
+    restarted.RoundID = 1
+    s.SaveRound(restarted)
+
+    if r, err := s.Round("BTCUSD", 2); err != nil || r.Price != 101 {
+        t.Errorf("Expected round 2 at 101, got %+v, %v", r, err)
+    }
+    if r, err := s.Round("BTCUSD", 1); err != nil || r.Price != 110 {
+        t.Errorf("Expected the most recent round 1, got %+v, %v", r, err)
+    }
+
+    var notFound *ErrNotFound
+    if _, err := s.Round("BTCUSD", 9); !errors.As(err, &notFound) || notFound.RoundID != 9 {
+        t.Errorf("Expected round 9 not to be found, got %v", err)
+    }
+
+    // Downsampled rounds are summarized rather than kept
+    rounds, _ := s.Rounds("BTCUSD", base, base.Add(time.Minute))
+    s.Replace("BTCUSD", base, base.Add(time.Minute), Downsample(rounds, time.Minute))
+    if _, err := s.Round("BTCUSD", 3); err == nil {
+        t.Error("Expected a downsampled round not to be found")
+    }
+}
+
+func TestFresh(t *testing.T) {
+    now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+    s := NewMemoryStore()
+    s.SaveRound(round("ETHUSD", 3000, now.Add(-time.Minute)))
+    s.SaveRound(round("USDCUSD", 0, now))
+
+    if price, err := Fresh(s, "ETHUSD", 0, now); err != nil || price != 3000 {
+        t.Errorf("Expected 3000 within the default max age, got %v, %v", price, err)
+    }
+    if _, err := Fresh(s, "ETHUSD", 30*time.Second, now); err == nil || err.Error() != "ETHUSD older than 30s" {
+        t.Errorf("Expected a stale price error, got %v", err)
+    }
+    if _, err := Fresh(s, "USDCUSD", 0, now); err == nil {
+        t.Error("Expected a zero price to be refused")
+    }
+    if _, err := Fresh(s, "BTCUSD", 0, now); err == nil || err.Error() != "BTCUSD unavailable" {
+        t.Errorf("Expected an unavailable feed, got %v", err)
+    }
+}
@@ -0,0 +1,139 @@
+// Package symbols owns the mapping between canonical asset symbols and the
+// names the rest of the world uses for them: exchange REST and stream
+// tickers, contract addresses on each chain and subgraph token IDs.
+// Fetchers, config validation and the token list importer look names up
+// here instead of assembling them from strings.
+package symbols
+
+import (
+    "encoding/hex"
+    "fmt"
+    "sort"
+    "strings"
+
+    "yetaXYZ/oracle/common"
+)
+
+// Canonical returns the canonical form of an asset symbol, e.g. ETH for
+// " eth"
+func Canonical(symbol string) string {
+    return strings.ToUpper(strings.TrimSpace(symbol))
+}
+
+// Pair returns the canonical symbol of the pair base/quote, e.g. ETHUSDT
+func Pair(base, quote string) string {
+    return Canonical(base) + Canonical(quote)
+}
+
+// PairSymbol returns the pair symbol of one written with a slash, e.g.
+// ETHUSDT for ETH/USDT
+func PairSymbol(symbol string) string {
+    return strings.ReplaceAll(symbol, "/", "")
+}
+
+// IsAddress reports whether s is a 0x-prefixed 20-byte hex address
+func IsAddress(s string) bool {
+    if len(s) != 42 || !strings.HasPrefix(s, "0x") {
+        return false
+    }
+    _, err := hex.DecodeString(s[2:])
+    return err == nil
+}
+
+// SubgraphID returns the ID subgraphs index a token or pool address by:
+// the address in lower case
+func SubgraphID(address string) string {
+    return strings.ToLower(address)
+}
+
+// Book resolves canonical symbols against a base config: its asset
+// address book and the asset aliases of its exchanges
+type Book struct {
+    assets common.AssetConfig
+    cex    map[string]common.CEXDetails
+}
+
+// New creates a book over base, which may be nil
+func New(base *common.BaseConfig) *Book {
+    if base == nil {
+        return &Book{}
+    }
+    return &Book{assets: base.Assets, cex: base.Exchanges.CEX}
+}
+
+// Alias returns the symbol an exchange lists a canonical asset under
+func (b *Book) Alias(exchange, asset string) string {
+    asset = Canonical(asset)
+    if alias, ok := b.cex[exchange].Symbols[asset]; ok && alias != "" {
+        return alias
+    }
+    return asset
+}
+
+// Ticker returns the symbol an exchange's REST API names the pair
+// base/quote by, e.g. ETHUSDT on Binance and Kraken and ETH-USDT, a
+// product ID, on Coinbase
+func (b *Book) Ticker(exchange, base, quote string) string {
+    base, quote = b.Alias(exchange, base), b.Alias(exchange, quote)
+    if exchange == "coinbase" {
+        return base + "-" + quote
+    }
+    return base + quote
+}
+
+// StreamTicker returns the symbol an exchange's WebSocket API names the
+// pair base/quote by, e.g. ethusdt on Binance and ETH/USDT on Kraken
+func (b *Book) StreamTicker(exchange, base, quote string) string {
+    switch exchange {
+    case "binance":
+        return strings.ToLower(b.Ticker(exchange, base, quote))
+    case "kraken":
+        return b.Alias(exchange, base) + "/" + b.Alias(exchange, quote)
+    }
+    return b.Ticker(exchange, base, quote)
+}
+
+// Asset returns the address book entry of an asset
+func (b *Book) Asset(symbol string) (common.Asset, bool) {
+    asset, ok := b.assets[Canonical(symbol)]
+    return asset, ok
+}
+
+// Address returns the contract address of an asset on a chain from the
+// address book; 0x-prefixed values are taken as addresses already
+func (b *Book) Address(chainID, symbolOrAddress string) (string, error) {
+    if strings.HasPrefix(symbolOrAddress, "0x") {
+        return symbolOrAddress, nil
+    }
+    asset, ok := b.Asset(symbolOrAddress)
+    if !ok {
+        return "", fmt.Errorf("asset config not found for symbol: %s", symbolOrAddress)
+    }
+    address, ok := asset.AddressOn(chainID)
+    if !ok {
+        return "", fmt.Errorf("no address for %s on chain %s", symbolOrAddress, chainID)
+    }
+    return address, nil
+}
+
+// TokenID returns the subgraph token ID of an asset on a chain
+func (b *Book) TokenID(chainID, symbolOrAddress string) (string, error) {
+    address, err := b.Address(chainID, symbolOrAddress)
+    if err != nil {
+        return "", err
+    }
+    return SubgraphID(address), nil
+}
+
+// SymbolsAt returns the assets the address book has at an address on a
+// chain, in order. A native asset shares its wrapped token's address.
+func (b *Book) SymbolsAt(chainID, address string) []string {
+    symbols := make([]string, 0)
+    for symbol, asset := range b.assets {
+        if configured, ok := asset.AddressOn(chainID); ok && strings.EqualFold(configured, address) {
+            symbols = append(symbols, symbol)
+        }
+    }
+    sort.Strings(symbols)
+    return symbols
+}
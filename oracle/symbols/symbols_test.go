@@ -0,0 +1,72 @@
+package symbols
+
+import (
+    "testing"
+
+    "yetaXYZ/oracle/common"
+)
+
+const weth = "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
+
+func testBook() *Book {
+    return New(&common.BaseConfig{
+        Assets: common.AssetConfig{
+            "ETH":  {Chains: map[string]common.ChainAssetInfo{"1": {Type: "native", Address: weth}}},
+            "WETH": {Chains: map[string]common.ChainAssetInfo{"1": {Type: "wrapped", Address: weth}}},
+        },
+        Exchanges: common.ExchangeConfig{CEX: map[string]common.CEXDetails{
+            "kraken": {Symbols: map[string]string{"BTC": "XBT"}},
+        }},
+    })
+}
+
+func TestTickers(t *testing.T) {
+    book := testBook()
+    for _, c := range []struct {
+        exchange, base, quote string
+        ticker, stream        string
+    }{
+        {"binance", "eth", "USDT", "ETHUSDT", "ethusdt"},
+        {"coinbase", "ETH", "USD", "ETH-USD", "ETH-USD"},
+        {"kraken", "BTC", "USD", "XBTUSD", "XBT/USD"},
+        {"binance", "BTC", "USDT", "BTCUSDT", "btcusdt"},
+    } {
+        if got := book.Ticker(c.exchange, c.base, c.quote); got != c.ticker {
+            t.Errorf("Ticker(%s, %s, %s) = %s, want %s", c.exchange, c.base, c.quote, got, c.ticker)
+        }
+        if got := book.StreamTicker(c.exchange, c.base, c.quote); got != c.stream {
+            t.Errorf("StreamTicker(%s, %s, %s) = %s, want %s", c.exchange, c.base, c.quote, got, c.stream)
+        }
+    }
+    if got := PairSymbol("ETH/USDT"); got != "ETHUSDT" {
+        t.Errorf("PairSymbol(ETH/USDT) = %s", got)
+    }
+}
+
+func TestAddresses(t *testing.T) {
+    book := testBook()
+    if got, err := book.Address("1", "eth"); err != nil || got != weth {
+        t.Errorf("Address(1, eth) = %s, %v", got, err)
+    }
+    if got, err := book.TokenID("1", "WETH"); err != nil || got != "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2" {
+        t.Errorf("TokenID(1, WETH) = %s, %v", got, err)
+    }
+    if got, err := book.Address("1", "0xabc"); err != nil || got != "0xabc" {
+        t.Errorf("Expected addresses passed through, got %s, %v", got, err)
+    }
+    if _, err := book.Address("10", "ETH"); err == nil {
+        t.Errorf("Expected no ETH address on chain 10")
+    }
+    if _, err := book.Address("1", "DOGE"); err == nil {
+        t.Errorf("Expected DOGE to be unknown")
+    }
+    if got := book.SymbolsAt("1", "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"); len(got) != 2 || got[0] != "ETH" || got[1] != "WETH" {
+        t.Errorf("SymbolsAt = %v, want [ETH WETH]", got)
+    }
+    if !IsAddress(weth) || IsAddress("0xabc") || IsAddress("C02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2xx") {
+        t.Errorf("IsAddress misjudged an address")
+    }
+    if got, err := New(nil).Address("1", "ETH"); err == nil {
+        t.Errorf("Expected an empty book to know no assets, got %s", got)
+    }
+}
@@ -0,0 +1,109 @@
+package testutil
+
+import (
+    "encoding/json"
+    "os"
+    "path/filepath"
+    "testing"
+
+    "yetaXYZ/oracle/common"
+)
+
+// Config builds a base and pairs configuration pointing at fake servers
+type Config struct {
+    Base     *common.BaseConfig
+    Pairs    map[string]*common.PairConfig
+    Profiles map[string]common.AggregationParams
+}
+
+// NewConfig returns an empty configuration
+func NewConfig() *Config {
+    return &Config{
+        Base: &common.BaseConfig{
+            Exchanges: common.ExchangeConfig{
+                CEX: make(map[string]common.CEXDetails),
+                DEX: make(map[string]common.DEXDetails),
+            },
+            Chains: make(common.ChainConfig),
+            Assets: make(common.AssetConfig),
+        },
+        Pairs: make(map[string]*common.PairConfig),
+    }
+}
+
+// WithExchange configures a fake exchange under its name
+func (c *Config) WithExchange(e *Exchange) *Config {
+    c.Base.Exchanges.CEX[e.Name] = common.CEXDetails{Name: e.Name, BaseURL: e.BaseURL(), Timeout: 5000}
+    return c
+}
+
+// WithSubgraph configures a fake subgraph as a DEX of the given protocol
+// (uniswap_v2 or uniswap_v3) on a chain
+func (c *Config) WithSubgraph(name, protocol, chain string, s *Subgraph) *Config {
+    c.Base.Exchanges.DEX[name] = common.DEXDetails{
+        Name:     name,
+        Type:     "subgraph",
+        Protocol: protocol,
+        Chain:    chain,
+        Endpoint: s.URL(),
+        Timeout:  5000,
+    }
+    return c
+}
+
+// WithProfile adds a tuning profile pairs can reference by name
+func (c *Config) WithProfile(name string, params common.AggregationParams) *Config {
+    if c.Profiles == nil {
+        c.Profiles = make(map[string]common.AggregationParams)
+    }
+    c.Profiles[name] = params
+    return c
+}
+
+// WithPair adds a pair fetched from the given exchanges, registering its
+// assets, and returns the configuration. The pair can be adjusted further
+// through c.Pairs[symbol].
+func (c *Config) WithPair(symbol, base, quote string, minimumSources int, exchanges ...string) *Config {
+    for _, asset := range []string{base, quote} {
+        if _, ok := c.Base.Assets[asset]; !ok {
+            c.Base.Assets[asset] = common.Asset{Name: asset, Decimals: 18}
+        }
+    }
+    c.Pairs[symbol] = &common.PairConfig{
+        BaseCurrency:           base,
+        QuoteCurrency:          quote,
+        MinimumSources:         minimumSources,
+        UpdateFrequencySeconds: 5,
+        Sources: common.SourcesConfig{
+            CEX: common.CEXSourceConfig{Enabled: true, Weight: 1, Exchanges: exchanges},
+        },
+    }
+    return c
+}
+
+// Write writes the configuration to a temporary config directory laid out
+// like config/, for crypto.LoadConfig, and returns the directory
+func (c *Config) Write(t testing.TB) string {
+    t.Helper()
+    dir := t.TempDir()
+    write := func(path string, v interface{}) {
+        data, err := json.MarshalIndent(v, "", "    ")
+        if err != nil {
+            t.Fatalf("Failed to encode %s: %v", path, err)
+        }
+        path = filepath.Join(dir, path)
+        if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+            t.Fatal(err)
+        }
+        if err := os.WriteFile(path, data, 0644); err != nil {
+            t.Fatal(err)
+        }
+    }
+    write(filepath.Join("base", "config.json"), c.Base)
+    pairs := map[string]interface{}{"pairs": c.Pairs}
+    if len(c.Profiles) > 0 {
+        pairs["profiles"] = c.Profiles
+    }
+    write(filepath.Join("pairs", "pairs.json"), pairs)
+    return dir
+}
@@ -0,0 +1,219 @@
+// Package testutil provides fake exchange and subgraph servers, config
+// builders and golden aggregation fixtures for integration tests of code
+// that embeds the aggregator
+package testutil
+
+import (
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "strconv"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+)
+
+// Quote is the ticker a fake exchange serves for one pair
+type Quote struct {
+    Price  float64 `json:"price"`
+    Volume float64 `json:"volume,omitempty"`
+}
+
+// Exchange is a fake centralized exchange serving the ticker endpoints the
+// aggregator reads. Its BaseURL goes in the exchange's baseURL config.
+type Exchange struct {
+    // Name is the exchange's config name, e.g. "binance"
+    Name string
+
+    server *httptest.Server
+    prefix string                          // API root path
+    symbol func(base, quote string) string // venue symbol of a pair
+    serve  func(e *Exchange, w http.ResponseWriter, r *http.Request)
+
+    mu       sync.Mutex
+    quotes   map[string]Quote
+    status   int
+    delay    time.Duration
+    requests int
+}
+
+// NewBinance starts a fake Binance serving /api/v3/ticker/24hr. The server
+// is closed when the test ends.
+func NewBinance(t testing.TB) *Exchange {
+    return newExchange(t, "binance", "/api/v3", concat, serveBinance)
+}
+
+// NewCoinbase starts a fake Coinbase serving the Advanced Trade
+// /api/v3/brokerage/market/products/{product}
+func NewCoinbase(t testing.TB) *Exchange {
+    return newExchange(t, "coinbase", "/api/v3/brokerage", func(base, quote string) string { return base + "-" + quote }, serveCoinbase)
+}
+
+// NewKraken starts a fake Kraken serving /0/public/Ticker
+func NewKraken(t testing.TB) *Exchange {
+    return newExchange(t, "kraken", "/0/public", concat, serveKraken)
+}
+
+func concat(base, quote string) string { return base + quote }
+
+func newExchange(t testing.TB, name, prefix string, symbol func(base, quote string) string, serve func(e *Exchange, w http.ResponseWriter, r *http.Request)) *Exchange {
+    e := &Exchange{
+        Name:   name,
+        prefix: prefix,
+        symbol: symbol,
+        serve:  serve,
+        quotes: make(map[string]Quote),
+    }
+    e.server = httptest.NewServer(http.HandlerFunc(e.handle))
+    t.Cleanup(e.server.Close)
+    return e
+}
+
+// BaseURL returns the API root to configure as the exchange's baseURL
+func (e *Exchange) BaseURL() string {
+    return e.server.URL + e.prefix
+}
+
+// SetQuote serves price and volume for the pair base/quote
+func (e *Exchange) SetQuote(base, quote string, q Quote) {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+    e.quotes[e.symbol(base, quote)] = q
+}
+
+// RemoveQuote stops serving the pair, which the exchange then reports as
+// unknown
+func (e *Exchange) RemoveQuote(base, quote string) {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+    delete(e.quotes, e.symbol(base, quote))
+}
+
+// Fail answers every request with status, as during an outage; 0 recovers
+func (e *Exchange) Fail(status int) {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+    e.status = status
+}
+
+// Delay holds every response for d, or until the request is cancelled
+func (e *Exchange) Delay(d time.Duration) {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+    e.delay = d
+}
+
+// Requests returns the number of requests served so far
+func (e *Exchange) Requests() int {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+    return e.requests
+}
+
+func (e *Exchange) handle(w http.ResponseWriter, r *http.Request) {
+    e.mu.Lock()
+    e.requests++
+    status, delay := e.status, e.delay
+    e.mu.Unlock()
+
+    if delay > 0 {
+        select {
+        case <-time.After(delay):
+        case <-r.Context().Done():
+            return
+        }
+    }
+    if status != 0 {
+        http.Error(w, http.StatusText(status), status)
+        return
+    }
+    if !strings.HasPrefix(r.URL.Path, e.prefix+"/") {
+        http.NotFound(w, r)
+        return
+    }
+    w.Header().Set("Content-Type", "application/json")
+    e.serve(e, w, r)
+}
+
+// quote returns the ticker served for a venue symbol
+func (e *Exchange) quote(symbol string) (Quote, bool) {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+    q, ok := e.quotes[symbol]
+    return q, ok
+}
+
+func serveBinance(e *Exchange, w http.ResponseWriter, r *http.Request) {
+    if r.URL.Path != e.prefix+"/ticker/24hr" {
+        http.NotFound(w, r)
+        return
+    }
+    symbol := r.URL.Query().Get("symbol")
+    q, ok := e.quote(symbol)
+    if !ok {
+        w.WriteHeader(http.StatusBadRequest)
+        json.NewEncoder(w).Encode(map[string]interface{}{"code": -1121, "msg": "Invalid symbol."})
+        return
+    }
+    json.NewEncoder(w).Encode(map[string]string{
+        "symbol":    symbol,
+        "lastPrice": formatFloat(q.Price),
+        "volume":    formatFloat(q.Volume),
+    })
+}
+
+func serveCoinbase(e *Exchange, w http.ResponseWriter, r *http.Request) {
+    parts := strings.Split(strings.TrimPrefix(r.URL.Path, e.prefix+"/"), "/")
+    if len(parts) != 3 || parts[0] != "market" || parts[1] != "products" {
+        http.NotFound(w, r)
+        return
+    }
+    q, ok := e.quote(parts[2])
+    if !ok {
+        w.WriteHeader(http.StatusNotFound)
+        json.NewEncoder(w).Encode(map[string]string{"error": "NOT_FOUND", "message": "ProductID is invalid"})
+        return
+    }
+    base, quote, _ := strings.Cut(parts[2], "-")
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "product_id":        parts[2],
+        "base_currency_id":  base,
+        "quote_currency_id": quote,
+        "price":             formatFloat(q.Price),
+        "volume_24h":        formatFloat(q.Volume),
+        "trading_disabled":  false,
+    })
+}
+
+func serveKraken(e *Exchange, w http.ResponseWriter, r *http.Request) {
+    if r.URL.Path != e.prefix+"/Ticker" {
+        http.NotFound(w, r)
+        return
+    }
+    pair := r.URL.Query().Get("pair")
+    q, ok := e.quote(pair)
+    if !ok {
+        // Kraken reports errors in the body of a 200 response
+        json.NewEncoder(w).Encode(map[string]interface{}{
+            "error":  []string{"EQuery:Unknown asset pair"},
+            "result": map[string]interface{}{},
+        })
+        return
+    }
+    volume := formatFloat(q.Volume)
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "error": []string{},
+        "result": map[string]interface{}{
+            pair: map[string][]string{
+                "c": {formatFloat(q.Price), "1"},
+                "v": {volume, volume},
+            },
+        },
+    })
+}
+
+// formatFloat formats a price the way exchanges do, as a decimal string
+func formatFloat(v float64) string {
+    return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,99 @@
+package testutil
+
+import (
+    _ "embed"
+    "encoding/json"
+    "fmt"
+    "strings"
+
+    "yetaXYZ/oracle/common"
+)
+
+//go:embed fixtures/aggregation.json
+var aggregationFixtures []byte
+
+// Fixture is a golden aggregation scenario: the quotes each exchange
+// serves for a pair and the round the aggregator must produce from them
+type Fixture struct {
+    Name        string             `json:"name"`
+    Description string             `json:"description"`
+    Symbol      string             `json:"symbol"`
+    Pair        *common.PairConfig `json:"pair"`
+    // Quotes are keyed by exchange; exchanges without a quote do not list
+    // the pair
+    Quotes map[string]Quote `json:"quotes"`
+    Want   Expected         `json:"want"`
+}
+
+// Expected is the outcome of a fixture's round
+type Expected struct {
+    Price    float64  `json:"price,omitempty"`
+    Sources  []string `json:"sources,omitempty"`  // kept sources, in config order
+    Rejected []string `json:"rejected,omitempty"` // outliers
+    // Error is part of the error message of a round that must fail
+    Error string `json:"error,omitempty"`
+}
+
+// AggregationFixtures returns the golden aggregation scenarios
+func AggregationFixtures() ([]Fixture, error) {
+    var fixtures []Fixture
+    if err := json.Unmarshal(aggregationFixtures, &fixtures); err != nil {
+        return nil, fmt.Errorf("failed to parse aggregation fixtures: %v", err)
+    }
+    return fixtures, nil
+}
+
+// Serve sets the fixture's quotes on the fake exchanges, removing the pair
+// from exchanges the fixture gives no quote for
+func (f Fixture) Serve(exchanges ...*Exchange) {
+    for _, e := range exchanges {
+        if q, ok := f.Quotes[e.Name]; ok {
+            e.SetQuote(f.Pair.BaseCurrency, f.Pair.QuoteCurrency, q)
+        } else {
+            e.RemoveQuote(f.Pair.BaseCurrency, f.Pair.QuoteCurrency)
+        }
+    }
+}
+
+// Check compares the result of the fixture's round with the expected one
+func (f Fixture) Check(result *common.AggregateResult, err error) error {
+    if f.Want.Error != "" {
+        if err == nil || !strings.Contains(err.Error(), f.Want.Error) {
+            return fmt.Errorf("%s: expected error %q, got %v", f.Name, f.Want.Error, err)
+        }
+        return nil
+    }
+    if err != nil {
+        return fmt.Errorf("%s: %v", f.Name, err)
+    }
+    if result.Price != f.Want.Price {
+        return fmt.Errorf("%s: expected price %v, got %v", f.Name, f.Want.Price, result.Price)
+    }
+    if got := sourceNames(result.Sources); !equal(got, f.Want.Sources) {
+        return fmt.Errorf("%s: expected sources %v, got %v", f.Name, f.Want.Sources, got)
+    }
+    if got := sourceNames(result.Rejected); !equal(got, f.Want.Rejected) {
+        return fmt.Errorf("%s: expected rejected %v, got %v", f.Name, f.Want.Rejected, got)
+    }
+    return nil
+}
+
+func sourceNames(sources []common.SourcePrice) []string {
+    names := make([]string, len(sources))
+    for i, s := range sources {
+        names[i] = s.Source
+    }
+    return names
+}
+
+func equal(a, b []string) bool {
+    if len(a) != len(b) {
+        return false
+    }
+    for i := range a {
+        if a[i] != b[i] {
+            return false
+        }
+    }
+    return true
+}
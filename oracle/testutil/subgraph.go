@@ -0,0 +1,172 @@
+package testutil
+
+import (
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+    "testing"
+)
+
+// Pool is a liquidity pool indexed by a fake subgraph
+type Pool struct {
+    ID           string
+    Token0       string
+    Token1       string
+    FeeTier      int // Uniswap V3 pools only
+    LiquidityUSD float64
+    // Price is token0's price in token1; unset, no prices are reported
+    Price float64
+}
+
+// Subgraph is a fake Uniswap V2/V3 subgraph answering the pool queries of
+// pool discovery and subgraph pool sources, filtered by tokens or ids and
+// paged. V3 pools are returned for pools( queries and V2 pairs for pairs(
+// queries.
+type Subgraph struct {
+    server *httptest.Server
+
+    mu      sync.Mutex
+    pools   []Pool
+    header  string
+    value   string
+    queries int
+}
+
+// NewSubgraph starts a fake subgraph. The server is closed when the test
+// ends.
+func NewSubgraph(t testing.TB) *Subgraph {
+    s := &Subgraph{}
+    s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+    t.Cleanup(s.server.Close)
+    return s
+}
+
+// URL returns the endpoint to configure for the subgraph DEX
+func (s *Subgraph) URL() string {
+    return s.server.URL
+}
+
+// AddPool indexes a pool
+func (s *Subgraph) AddPool(p Pool) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.pools = append(s.pools, p)
+}
+
+// RequireHeader rejects queries without header set to value, as gateways
+// requiring an API key do
+func (s *Subgraph) RequireHeader(header, value string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.header, s.value = header, value
+}
+
+// Queries returns the number of queries answered so far
+func (s *Subgraph) Queries() int {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.queries
+}
+
+func (s *Subgraph) handle(w http.ResponseWriter, r *http.Request) {
+    s.mu.Lock()
+    header, value := s.header, s.value
+    s.mu.Unlock()
+    if header != "" && r.Header.Get(header) != value {
+        http.Error(w, "auth error: missing or invalid API key", http.StatusUnauthorized)
+        return
+    }
+
+    var req struct {
+        Query     string `json:"query"`
+        Variables struct {
+            Where struct {
+                Tokens []string `json:"token0_in"`
+                IDs    []string `json:"id_in"`
+            } `json:"where"`
+            First int `json:"first"`
+            Skip  int `json:"skip"`
+        } `json:"variables"`
+    }
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    w.Header().Set("Content-Type", "application/json")
+
+    field := ""
+    switch {
+    case strings.Contains(req.Query, "pools("):
+        field = "pools"
+    case strings.Contains(req.Query, "pairs("):
+        field = "pairs"
+    default:
+        json.NewEncoder(w).Encode(map[string]interface{}{
+            "errors": []map[string]string{{"message": "unsupported query"}},
+        })
+        return
+    }
+
+    s.mu.Lock()
+    s.queries++
+    matches := s.match(req.Variables.Where.Tokens, req.Variables.Where.IDs)
+    s.mu.Unlock()
+    if req.Variables.Skip >= len(matches) {
+        matches = nil
+    } else {
+        matches = matches[req.Variables.Skip:]
+    }
+    if req.Variables.First > 0 && len(matches) > req.Variables.First {
+        matches = matches[:req.Variables.First]
+    }
+
+    rows := make([]map[string]interface{}, 0, len(matches))
+    for _, p := range matches {
+        row := map[string]interface{}{
+            "id":     p.ID,
+            "token0": map[string]string{"id": strings.ToLower(p.Token0)},
+            "token1": map[string]string{"id": strings.ToLower(p.Token1)},
+        }
+        liquidity := formatFloat(p.LiquidityUSD)
+        if field == "pools" {
+            row["feeTier"] = strconv.Itoa(p.FeeTier)
+            row["totalValueLockedUSD"] = liquidity
+        } else {
+            row["reserveUSD"] = liquidity
+        }
+        if p.Price > 0 {
+            row["token0Price"] = formatFloat(1 / p.Price)
+            row["token1Price"] = formatFloat(p.Price)
+        }
+        rows = append(rows, row)
+    }
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "data": map[string]interface{}{field: rows},
+    })
+}
+
+// match returns the pools whose tokens are both among tokens, as
+// token0_in/token1_in filters do, or whose ids are among ids, deepest
+// first; callers hold mu
+func (s *Subgraph) match(tokens, ids []string) []Pool {
+    in := make(map[string]bool, len(tokens))
+    for _, token := range tokens {
+        in[strings.ToLower(token)] = true
+    }
+    byID := make(map[string]bool, len(ids))
+    for _, id := range ids {
+        byID[strings.ToLower(id)] = true
+    }
+    var matches []Pool
+    for _, p := range s.pools {
+        if (in[strings.ToLower(p.Token0)] && in[strings.ToLower(p.Token1)]) || byID[strings.ToLower(p.ID)] {
+            matches = append(matches, p)
+        }
+    }
+    sort.SliceStable(matches, func(i, j int) bool { return matches[i].LiquidityUSD > matches[j].LiquidityUSD })
+    return matches
+}
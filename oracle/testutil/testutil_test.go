@@ -0,0 +1,104 @@
+package testutil_test
+
+import (
+    "context"
+    "net/http"
+    "testing"
+
+    "yetaXYZ/oracle/sources/crypto"
+    "yetaXYZ/oracle/sources/dex"
+    "yetaXYZ/oracle/testutil"
+)
+
+func TestAggregationFixtures(t *testing.T) {
+    binance, coinbase, kraken := testutil.NewBinance(t), testutil.NewCoinbase(t), testutil.NewKraken(t)
+    fixtures, err := testutil.AggregationFixtures()
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    for _, f := range fixtures {
+        config := testutil.NewConfig().WithExchange(binance).WithExchange(coinbase).WithExchange(kraken)
+        config.WithPair(f.Symbol, f.Pair.BaseCurrency, f.Pair.QuoteCurrency, f.Pair.MinimumSources)
+        config.Pairs[f.Symbol] = f.Pair
+        if err := crypto.LoadConfig(config.Write(t)); err != nil {
+            t.Fatalf("%s: failed to load config: %v", f.Name, err)
+        }
+        if err := crypto.ValidateConfig(); err != nil {
+            t.Fatalf("%s: invalid config: %v", f.Name, err)
+        }
+
+        f.Serve(binance, coinbase, kraken)
+        if err := f.Check(crypto.NewCryptoAggregator(crypto.BaseConfig).Aggregate(f.Symbol)); err != nil {
+            t.Error(err)
+        }
+    }
+}
+
+func TestExchangeOutage(t *testing.T) {
+    binance, kraken := testutil.NewBinance(t), testutil.NewKraken(t)
+    config := testutil.NewConfig().WithExchange(binance).WithExchange(kraken).WithPair("ETHUSDT", "ETH", "USDT", 2, "binance", "kraken")
+    if err := crypto.LoadConfig(config.Write(t)); err != nil {
+        t.Fatal(err)
+    }
+    binance.SetQuote("ETH", "USDT", testutil.Quote{Price: 3000})
+    kraken.SetQuote("ETH", "USDT", testutil.Quote{Price: 3001})
+
+    aggregator := crypto.NewCryptoAggregator(crypto.BaseConfig)
+    if _, err := aggregator.Aggregate("ETHUSDT"); err != nil {
+        t.Fatalf("Expected a round, got %v", err)
+    }
+    kraken.Fail(http.StatusServiceUnavailable)
+    if _, err := aggregator.Aggregate("ETHUSDT"); err == nil {
+        t.Error("Expected the round to fail during the outage, got nil")
+    }
+    if kraken.Requests() != 2 {
+        t.Errorf("Expected 2 requests to kraken, got %d", kraken.Requests())
+    }
+}
+
+func TestCoinbaseVolume(t *testing.T) {
+    binance, coinbase := testutil.NewBinance(t), testutil.NewCoinbase(t)
+    config := testutil.NewConfig().WithExchange(binance).WithExchange(coinbase).WithPair("ETHUSDT", "ETH", "USDT", 2, "binance", "coinbase")
+    if err := crypto.LoadConfig(config.Write(t)); err != nil {
+        t.Fatal(err)
+    }
+    binance.SetQuote("ETH", "USDT", testutil.Quote{Price: 3000, Volume: 100})
+    coinbase.SetQuote("ETH", "USDT", testutil.Quote{Price: 3001, Volume: 40})
+
+    result, err := crypto.NewCryptoAggregator(crypto.BaseConfig).Aggregate("ETHUSDT")
+    if err != nil {
+        t.Fatalf("Expected a round, got %v", err)
+    }
+    for _, source := range result.Sources {
+        if source.Source == "coinbase" && source.Volume != 40 {
+            t.Errorf("Expected Coinbase's 24h volume of 40, got %v", source.Volume)
+        }
+    }
+    if result.Volume != 140 {
+        t.Errorf("Expected a total volume of 140, got %v", result.Volume)
+    }
+}
+
+func TestSubgraphDiscovery(t *testing.T) {
+    subgraph := testutil.NewSubgraph(t)
+    subgraph.RequireHeader("Authorization", "Bearer test-key")
+    subgraph.AddPool(testutil.Pool{ID: "0xshallow", Token0: "0xA", Token1: "0xB", FeeTier: 3000, LiquidityUSD: 2e6})
+    subgraph.AddPool(testutil.Pool{ID: "0xdeep", Token0: "0xA", Token1: "0xB", FeeTier: 500, LiquidityUSD: 9e7})
+    subgraph.AddPool(testutil.Pool{ID: "0xother", Token0: "0xA", Token1: "0xC", FeeTier: 500, LiquidityUSD: 5e8})
+
+    config := testutil.NewConfig().WithSubgraph("uniswap_v3", "uniswap_v3", "1", subgraph)
+    details := config.Base.Exchanges.DEX["uniswap_v3"]
+    if _, err := dex.Discover(context.Background(), http.DefaultClient, "uniswap_v3", details, "1", "0xa", "0xb", 5); err == nil {
+        t.Error("Expected discovery without the API key to fail, got nil")
+    }
+
+    subgraph.RequireHeader("", "")
+    candidates, err := dex.Discover(context.Background(), http.DefaultClient, "uniswap_v3", details, "1", "0xa", "0xb", 5)
+    if err != nil {
+        t.Fatalf("Discovery failed: %v", err)
+    }
+    if len(candidates) != 2 || candidates[0].Address != "0xdeep" || candidates[0].FeeTier != 500 {
+        t.Errorf("Expected both 0xA/0xB pools, deepest first, got %+v", candidates)
+    }
+}
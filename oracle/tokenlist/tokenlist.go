@@ -0,0 +1,229 @@
+package tokenlist
+
+import (
+    "fmt"
+    "net/http"
+    "sort"
+    "strconv"
+    "strings"
+
+    "yetaXYZ/oracle/common"
+    "yetaXYZ/oracle/fetch"
+    "yetaXYZ/oracle/symbols"
+)
+
+// DefaultListURL is the Uniswap default token list
+const DefaultListURL = "https://tokens.uniswap.org"
+
+// DefaultCoinGeckoURL is the public CoinGecko API
+const DefaultCoinGeckoURL = "https://api.coingecko.com/api/v3"
+
+// maxListBytes bounds token list and CoinGecko responses, which are far
+// larger than price responses
+const maxListBytes = 64 << 20
+
+// Token is an entry of a token list (https://tokenlists.org)
+type Token struct {
+    ChainID  int    `json:"chainId"`
+    Address  string `json:"address"`
+    Name     string `json:"name"`
+    Symbol   string `json:"symbol"`
+    Decimals int    `json:"decimals"`
+}
+
+// List is a token list
+type List struct {
+    Name   string  `json:"name"`
+    Tokens []Token `json:"tokens"`
+}
+
+// Platform is a CoinGecko asset platform; ChainID is zero for non-EVM
+// platforms
+type Platform struct {
+    ID      string `json:"id"`
+    ChainID int    `json:"chain_identifier"`
+}
+
+// Coin is a CoinGecko coin with its contract address per platform ID
+type Coin struct {
+    ID        string            `json:"id"`
+    Symbol    string            `json:"symbol"`
+    Name      string            `json:"name"`
+    Platforms map[string]string `json:"platforms"`
+}
+
+// FetchList downloads a token list
+func FetchList(client *http.Client, url string) (*List, error) {
+    var list List
+    if err := get(client, url, &list); err != nil {
+        return nil, fmt.Errorf("failed to fetch token list %s: %v", url, err)
+    }
+    return &list, nil
+}
+
+// FetchPlatforms downloads CoinGecko's asset platforms
+func FetchPlatforms(client *http.Client, baseURL string) ([]Platform, error) {
+    var platforms []Platform
+    if err := get(client, strings.TrimRight(baseURL, "/")+"/asset_platforms", &platforms); err != nil {
+        return nil, fmt.Errorf("failed to fetch CoinGecko asset platforms: %v", err)
+    }
+    return platforms, nil
+}
+
+// FetchCoins downloads CoinGecko's coin list with platform addresses
+func FetchCoins(client *http.Client, baseURL string) ([]Coin, error) {
+    var coins []Coin
+    if err := get(client, strings.TrimRight(baseURL, "/")+"/coins/list?include_platform=true", &coins); err != nil {
+        return nil, fmt.Errorf("failed to fetch CoinGecko coins: %v", err)
+    }
+    return coins, nil
+}
+
+// get decodes a JSON response
+func get(client *http.Client, url string, out interface{}) error {
+    resp, err := client.Get(url)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("unexpected status: %s", resp.Status)
+    }
+    return fetch.DecodeJSONLimit(resp, maxListBytes, out)
+}
+
+// Candidate is an asset entry resolved for operator review
+type Candidate struct {
+    Symbol string
+    Asset  common.Asset
+    // Sources names where each chain's address was found, by chain ID
+    Sources map[string][]string
+    // Conflicts describe disagreements between sources; the address of the
+    // first token list wins
+    Conflicts []string
+}
+
+// Resolve merges what the token lists and CoinGecko know about symbol on
+// the given chains. Token lists take precedence over CoinGecko. When several
+// CoinGecko coins share the symbol, coinGeckoID selects one; otherwise the
+// coin sharing a contract address with the token lists is used.
+func Resolve(symbol string, chains map[string]bool, lists []*List, platforms []Platform, coins []Coin, coinGeckoID string) (*Candidate, error) {
+    c := &Candidate{
+        Symbol:  symbols.Canonical(symbol),
+        Asset:   common.Asset{Chains: make(map[string]common.ChainAssetInfo)},
+        Sources: make(map[string][]string),
+    }
+
+    decimals := -1
+    for _, list := range lists {
+        for _, token := range list.Tokens {
+            chainID := strconv.Itoa(token.ChainID)
+            if !strings.EqualFold(token.Symbol, c.Symbol) || !chains[chainID] {
+                continue
+            }
+            if c.Asset.Name == "" {
+                c.Asset.Name = token.Name
+            }
+            if decimals < 0 {
+                decimals = token.Decimals
+            } else if token.Decimals != decimals {
+                c.Conflicts = append(c.Conflicts, fmt.Sprintf("%s lists %d decimals on chain %s, not %d", list.Name, token.Decimals, chainID, decimals))
+            }
+            c.add(chainID, token.Address, list.Name)
+        }
+    }
+
+    coin, err := pickCoin(c, coins, platforms, coinGeckoID)
+    if err != nil {
+        return nil, err
+    }
+    if coin != nil {
+        c.Asset.CoinGeckoID = coin.ID
+        if c.Asset.Name == "" {
+            c.Asset.Name = coin.Name
+        }
+        for _, p := range platforms {
+            chainID := strconv.Itoa(p.ChainID)
+            if address := coin.Platforms[p.ID]; p.ChainID != 0 && address != "" && chains[chainID] {
+                c.add(chainID, address, "coingecko")
+            }
+        }
+    }
+
+    if len(c.Asset.Chains) == 0 {
+        return nil, fmt.Errorf("no address for %s found on the configured chains", c.Symbol)
+    }
+    if decimals < 0 {
+        return nil, fmt.Errorf("decimals of %s unknown: not in any token list", c.Symbol)
+    }
+    c.Asset.Decimals = decimals
+    sort.Strings(c.Conflicts)
+    return c, nil
+}
+
+// add records an address of the asset, keeping the first one per chain
+func (c *Candidate) add(chainID, address, source string) {
+    existing, ok := c.Asset.Chains[chainID]
+    if !ok {
+        c.Asset.Chains[chainID] = common.ChainAssetInfo{Type: "token", Address: address}
+        c.Sources[chainID] = append(c.Sources[chainID], source)
+        return
+    }
+    if !strings.EqualFold(existing.Address, address) {
+        c.Conflicts = append(c.Conflicts, fmt.Sprintf("%s has %s on chain %s, not %s", source, address, chainID, existing.Address))
+        return
+    }
+    for _, s := range c.Sources[chainID] {
+        if s == source {
+            return
+        }
+    }
+    c.Sources[chainID] = append(c.Sources[chainID], source)
+}
+
+// pickCoin selects the CoinGecko coin of the candidate, nil when CoinGecko
+// has none
+func pickCoin(c *Candidate, coins []Coin, platforms []Platform, coinGeckoID string) (*Coin, error) {
+    if coinGeckoID != "" {
+        for i := range coins {
+            if coins[i].ID == coinGeckoID {
+                return &coins[i], nil
+            }
+        }
+        return nil, fmt.Errorf("unknown CoinGecko coin %s", coinGeckoID)
+    }
+
+    chainOf := make(map[string]string, len(platforms))
+    for _, p := range platforms {
+        if p.ChainID != 0 {
+            chainOf[p.ID] = strconv.Itoa(p.ChainID)
+        }
+    }
+    matches := make([]*Coin, 0)
+    for i := range coins {
+        if strings.EqualFold(coins[i].Symbol, c.Symbol) {
+            matches = append(matches, &coins[i])
+        }
+    }
+    switch len(matches) {
+    case 0:
+        return nil, nil
+    case 1:
+        return matches[0], nil
+    }
+
+    // Many coins share popular symbols; trust the one the lists agree with
+    for _, coin := range matches {
+        for platform, address := range coin.Platforms {
+            if known, ok := c.Asset.Chains[chainOf[platform]]; ok && address != "" && strings.EqualFold(known.Address, address) {
+                return coin, nil
+            }
+        }
+    }
+    ids := make([]string, 0, len(matches))
+    for _, coin := range matches {
+        ids = append(ids, coin.ID)
+    }
+    sort.Strings(ids)
+    return nil, fmt.Errorf("%d CoinGecko coins use the symbol %s (%s); choose one with its ID", len(ids), c.Symbol, strings.Join(ids, ", "))
+}
@@ -0,0 +1,76 @@
+package tokenlist
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+)
+
+func TestResolve(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "application/json")
+        switch r.URL.Path {
+        case "/list":
+            w.Write([]byte(`{"name": "Test List", "tokens": [
+                {"chainId": 1, "address": "0xB50721BCf8d664c30412Cfbc6cf7a15145234ad1", "name": "Arbitrum", "symbol": "ARB", "decimals": 18},
+                {"chainId": 42161, "address": "0x912CE59144191C1204E64559FE8253a0e49E6548", "name": "Arbitrum", "symbol": "ARB", "decimals": 18},
+                {"chainId": 999, "address": "0x0000000000000000000000000000000000000001", "name": "Arbitrum", "symbol": "ARB", "decimals": 18},
+                {"chainId": 1, "address": "0xdAC17F958D2ee523a2206206994597C13D831ec7", "name": "Tether", "symbol": "USDT", "decimals": 6}
+            ]}`))
+        case "/api/asset_platforms":
+            w.Write([]byte(`[{"id": "ethereum", "chain_identifier": 1}, {"id": "arbitrum-one", "chain_identifier": 42161}, {"id": "solana", "chain_identifier": null}]`))
+        case "/api/coins/list":
+            w.Write([]byte(`[
+                {"id": "arbitrum", "symbol": "arb", "name": "Arbitrum", "platforms": {"ethereum": "0xb50721bcf8d664c30412cfbc6cf7a15145234ad1", "arbitrum-one": "0x912ce59144191c1204e64559fe8253a0e49e6548"}},
+                {"id": "arbdoge", "symbol": "arb", "name": "ARB Doge", "platforms": {"arbitrum-one": "0x0000000000000000000000000000000000000002"}}
+            ]`))
+        default:
+            http.NotFound(w, r)
+        }
+    }))
+    defer server.Close()
+
+    list, err := FetchList(server.Client(), server.URL+"/list")
+    if err != nil {
+        t.Fatalf("Failed to fetch list: %v", err)
+    }
+    platforms, err := FetchPlatforms(server.Client(), server.URL+"/api")
+    if err != nil {
+        t.Fatalf("Failed to fetch platforms: %v", err)
+    }
+    coins, err := FetchCoins(server.Client(), server.URL+"/api")
+    if err != nil {
+        t.Fatalf("Failed to fetch coins: %v", err)
+    }
+
+    chains := map[string]bool{"1": true, "42161": true}
+    c, err := Resolve("arb", chains, []*List{list}, platforms, coins, "")
+    if err != nil {
+        t.Fatalf("Failed to resolve: %v", err)
+    }
+    if c.Symbol != "ARB" || c.Asset.Name != "Arbitrum" || c.Asset.Decimals != 18 || c.Asset.CoinGeckoID != "arbitrum" {
+        t.Errorf("Unexpected metadata: %+v", c.Asset)
+    }
+    if len(c.Asset.Chains) != 2 || c.Asset.Chains["42161"].Address != "0x912CE59144191C1204E64559FE8253a0e49E6548" {
+        t.Errorf("Expected addresses on the configured chains only, got %+v", c.Asset.Chains)
+    }
+    if sources := c.Sources["1"]; len(sources) != 2 || sources[0] != "Test List" || sources[1] != "coingecko" {
+        t.Errorf("Expected the address to be confirmed by both sources, got %v", sources)
+    }
+    if len(c.Conflicts) != 0 {
+        t.Errorf("Expected no conflicts, got %v", c.Conflicts)
+    }
+
+    // Without token lists the coin is ambiguous unless chosen by ID
+    if _, err := Resolve("ARB", chains, nil, platforms, coins, ""); err == nil || !strings.Contains(err.Error(), "arbdoge") {
+        t.Errorf("Expected an ambiguity error, got %v", err)
+    }
+    c, err = Resolve("ARB", chains, []*List{list}, platforms, coins, "arbdoge")
+    if err != nil {
+        t.Fatalf("Failed to resolve by ID: %v", err)
+    }
+    if len(c.Conflicts) != 1 || !strings.Contains(c.Conflicts[0], "0x0000000000000000000000000000000000000002") {
+        t.Errorf("Expected the differing CoinGecko address as a conflict, got %v", c.Conflicts)
+    }
+}
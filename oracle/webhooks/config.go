@@ -0,0 +1,108 @@
+package webhooks
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "time"
+)
+
+// Transition events
+const (
+    FeedDegraded    = "feed_degraded"    // a feed left ok: degraded, stale or unavailable
+    FeedRecovered   = "feed_recovered"   // a feed is back to ok
+    SourceDown      = "source_down"      // a source failed sourceFailures fetches in a row
+    SourceRecovered = "source_recovered" // a down source fetched successfully again
+)
+
+// Sink is an HTTP endpoint notified of state transitions
+type Sink struct {
+    Name string `json:"name"`
+    // URL receives a POST per transition; may reference environment
+    // variables (${NAME})
+    URL string `json:"url"`
+    // Secret signs the body with HMAC-SHA256 in X-Oracle-Signature; may
+    // reference environment variables
+    Secret string `json:"secret,omitempty"`
+    // Events limits the sink to these transition events; empty means all
+    Events []string `json:"events,omitempty"`
+    // Feeds limits feed transitions to these feeds; empty means all
+    Feeds []string `json:"feeds,omitempty"`
+}
+
+// Config configures state-transition webhooks
+type Config struct {
+    Enabled bool   `json:"enabled"`
+    Sinks   []Sink `json:"sinks"`
+    // SourceFailures is how many consecutive failed fetches mark a source
+    // down, default 5
+    SourceFailures int `json:"sourceFailures,omitempty"`
+    // IntervalSeconds is how often feed states are evaluated, default 5
+    IntervalSeconds int `json:"intervalSeconds,omitempty"`
+    // TimeoutSeconds bounds one delivery attempt, default 5
+    TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+    // MaxAttempts bounds deliveries of one transition to a sink, default 3
+    MaxAttempts int `json:"maxAttempts,omitempty"`
+}
+
+// LoadConfig loads webhooks/webhooks.json from the config directory. A
+// missing file disables webhooks.
+func LoadConfig(configDir string) (*Config, error) {
+    data, err := os.ReadFile(filepath.Join(configDir, "webhooks", "webhooks.json"))
+    if os.IsNotExist(err) {
+        return &Config{}, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read webhooks config: %v", err)
+    }
+
+    var config Config
+    if err := json.Unmarshal(data, &config); err != nil {
+        return nil, fmt.Errorf("failed to parse webhooks config: %v", err)
+    }
+    if config.SourceFailures <= 0 {
+        config.SourceFailures = 5
+    }
+    if config.MaxAttempts <= 0 {
+        config.MaxAttempts = 3
+    }
+    if !config.Enabled {
+        return &config, nil
+    }
+
+    known := map[string]bool{FeedDegraded: true, FeedRecovered: true, SourceDown: true, SourceRecovered: true}
+    names := make(map[string]bool, len(config.Sinks))
+    for i := range config.Sinks {
+        sink := &config.Sinks[i]
+        if sink.Name == "" || sink.URL == "" {
+            return nil, fmt.Errorf("webhook sink %d requires name and url", i)
+        }
+        if names[sink.Name] {
+            return nil, fmt.Errorf("duplicate webhook sink %s", sink.Name)
+        }
+        names[sink.Name] = true
+        for _, event := range sink.Events {
+            if !known[event] {
+                return nil, fmt.Errorf("webhook sink %s: unknown event %s", sink.Name, event)
+            }
+        }
+    }
+    return &config, nil
+}
+
+// Interval returns the feed state evaluation interval
+func (c *Config) Interval() time.Duration {
+    if c.IntervalSeconds <= 0 {
+        return 5 * time.Second
+    }
+    return time.Duration(c.IntervalSeconds) * time.Second
+}
+
+// Timeout returns the delivery timeout
+func (c *Config) Timeout() time.Duration {
+    if c.TimeoutSeconds <= 0 {
+        return 5 * time.Second
+    }
+    return time.Duration(c.TimeoutSeconds) * time.Second
+}
@@ -0,0 +1,359 @@
+// Package webhooks notifies external monitoring of feed and source state
+// transitions, so that it gets edge-triggered events rather than having to
+// diff polled health data
+package webhooks
+
+import (
+    "bytes"
+    "context"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "log"
+    "net/http"
+    "sort"
+    "sync"
+    "time"
+
+    "yetaXYZ/oracle/credentials"
+    "yetaXYZ/oracle/events"
+    "yetaXYZ/oracle/redact"
+)
+
+// Feed states with special meaning; others, such as degraded, stale or
+// unavailable, are passed through from the summary's quality flags
+const (
+    StateOK           = "ok"
+    StateMarketClosed = "market_closed"
+)
+
+// Source states
+const (
+    sourceUp   = "up"
+    sourceDown = "down"
+)
+
+// queueSize bounds the transitions waiting for delivery to one sink
+const queueSize = 256
+
+// Transition is a change of a feed's or source's state
+type Transition struct {
+    Event     string    `json:"event"`
+    Feed      string    `json:"feed,omitempty"`
+    Source    string    `json:"source,omitempty"`
+    From      string    `json:"from"`
+    To        string    `json:"to"`
+    Reason    string    `json:"reason,omitempty"`
+    Timestamp time.Time `json:"timestamp"`
+}
+
+// SinkStatus reports deliveries to one sink
+type SinkStatus struct {
+    Name         string    `json:"name"`
+    Delivered    uint64    `json:"delivered"`
+    Failed       uint64    `json:"failed"`
+    Dropped      uint64    `json:"dropped"`
+    Pending      int       `json:"pending"`
+    LastDelivery time.Time `json:"lastDelivery,omitempty"`
+    LastError    string    `json:"lastError,omitempty"`
+}
+
+// FeedStates returns the current state of every feed, or nil while they
+// are not known yet, e.g. during priming
+type FeedStates func() map[string]string
+
+// sink is a configured endpoint with its delivery queue
+type sink struct {
+    Sink
+    events map[string]bool
+    feeds  map[string]bool
+    queue  chan Transition
+    status SinkStatus // guarded by Notifier.mu
+}
+
+// accepts reports whether the sink is subscribed to a transition
+func (s *sink) accepts(t Transition) bool {
+    if s.events != nil && !s.events[t.Event] {
+        return false
+    }
+    return t.Feed == "" || s.feeds == nil || s.feeds[t.Feed]
+}
+
+// sourceState counts consecutive failed fetches of a source
+type sourceState struct {
+    failures int
+    down     bool
+}
+
+// Notifier detects feed and source state transitions and posts them to
+// the configured sinks
+type Notifier struct {
+    config *Config
+    states FeedStates
+    bus    *events.Bus
+    client *http.Client
+    sinks  []*sink
+
+    mu      sync.Mutex
+    feeds   map[string]string // last known state of each feed
+    sources map[string]*sourceState
+    closed  bool
+    workers sync.WaitGroup
+}
+
+// NewNotifier creates a notifier for the sinks of config, evaluating feed
+// states from states and source fetches from the bus. Deliveries run until
+// Close.
+func NewNotifier(config *Config, states FeedStates, bus *events.Bus) *Notifier {
+    n := &Notifier{
+        config:  config,
+        states:  states,
+        bus:     bus,
+        client:  &http.Client{Timeout: config.Timeout()},
+        feeds:   make(map[string]string),
+        sources: make(map[string]*sourceState),
+    }
+    for _, s := range config.Sinks {
+        sk := &sink{Sink: s, queue: make(chan Transition, queueSize), status: SinkStatus{Name: s.Name}}
+        if len(s.Events) > 0 {
+            sk.events = make(map[string]bool, len(s.Events))
+            for _, event := range s.Events {
+                sk.events[event] = true
+            }
+        }
+        if len(s.Feeds) > 0 {
+            sk.feeds = make(map[string]bool, len(s.Feeds))
+            for _, feed := range s.Feeds {
+                sk.feeds[feed] = true
+            }
+        }
+        n.sinks = append(n.sinks, sk)
+        n.workers.Add(1)
+        go n.deliver(sk)
+    }
+    return n
+}
+
+// Interval returns the configured feed state evaluation interval
+func (n *Notifier) Interval() time.Duration {
+    return n.config.Interval()
+}
+
+// Run tracks source fetches and evaluates feed states at interval until
+// ctx is cancelled
+func (n *Notifier) Run(ctx context.Context, interval time.Duration) {
+    sub := n.bus.SubscribeFunc(1024, n.observeFetch, events.FetchResult).Named("webhooks")
+    defer sub.Close()
+
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            n.Evaluate(time.Now())
+        }
+    }
+}
+
+// Evaluate compares every feed's state with the last one seen and notifies
+// changes. The first state seen of a feed is its baseline. Closed markets
+// are not a health change and keep the state from before the close.
+func (n *Notifier) Evaluate(now time.Time) {
+    states := n.states()
+    if states == nil {
+        return
+    }
+    feeds := make([]string, 0, len(states))
+    for feed := range states {
+        feeds = append(feeds, feed)
+    }
+    sort.Strings(feeds)
+
+    n.mu.Lock()
+    defer n.mu.Unlock()
+    for _, feed := range feeds {
+        state := states[feed]
+        if state == StateMarketClosed {
+            continue
+        }
+        previous, known := n.feeds[feed]
+        n.feeds[feed] = state
+        if !known || previous == state {
+            continue
+        }
+        event := FeedDegraded
+        if state == StateOK {
+            event = FeedRecovered
+        }
+        n.notify(Transition{Event: event, Feed: feed, From: previous, To: state, Timestamp: now})
+    }
+}
+
+// observeFetch tracks consecutive fetch failures of a source
+func (n *Notifier) observeFetch(e events.Event) {
+    payload, ok := e.Payload.(*events.FetchResultPayload)
+    if !ok {
+        return
+    }
+
+    n.mu.Lock()
+    defer n.mu.Unlock()
+    state, ok := n.sources[payload.Source]
+    if !ok {
+        state = &sourceState{}
+        n.sources[payload.Source] = state
+    }
+    if payload.Err == nil {
+        state.failures = 0
+        if state.down {
+            state.down = false
+            n.notify(Transition{Event: SourceRecovered, Source: payload.Source, From: sourceDown, To: sourceUp, Timestamp: e.Timestamp})
+        }
+        return
+    }
+    state.failures++
+    if !state.down && state.failures >= n.config.SourceFailures {
+        state.down = true
+        n.notify(Transition{
+            Event:     SourceDown,
+            Source:    payload.Source,
+            From:      sourceUp,
+            To:        sourceDown,
+            Reason:    fmt.Sprintf("%d consecutive failed fetches, last for %s: %v", state.failures, e.Symbol, payload.Err),
+            Timestamp: e.Timestamp,
+        })
+    }
+}
+
+// notify queues a transition for every subscribed sink; n.mu must be held
+func (n *Notifier) notify(t Transition) {
+    if n.closed {
+        return
+    }
+    log.Printf("State transition: %s %s%s %s -> %s", t.Event, t.Feed, t.Source, t.From, t.To)
+    for _, s := range n.sinks {
+        if !s.accepts(t) {
+            continue
+        }
+        select {
+        case s.queue <- t:
+        default:
+            s.status.Dropped++
+        }
+    }
+}
+
+// deliver posts a sink's queued transitions in order until its queue is
+// closed
+func (n *Notifier) deliver(s *sink) {
+    defer n.workers.Done()
+    for t := range s.queue {
+        err := n.post(s, t)
+        n.mu.Lock()
+        if err != nil {
+            s.status.Failed++
+            s.status.LastError = err.Error()
+        } else {
+            s.status.Delivered++
+            s.status.LastDelivery = time.Now()
+            s.status.LastError = ""
+        }
+        n.mu.Unlock()
+        if err != nil {
+            log.Printf("Webhook %s: dropped %s after %d attempts: %v", s.Name, t.Event, n.config.MaxAttempts, err)
+        }
+    }
+}
+
+// post delivers one transition, retrying with backoff
+func (n *Notifier) post(s *sink, t Transition) error {
+    body, err := json.Marshal(t)
+    if err != nil {
+        return err
+    }
+    backoff := time.Second
+    for attempt := 1; ; attempt++ {
+        err = n.postOnce(s, body)
+        if err == nil || attempt >= n.config.MaxAttempts {
+            return err
+        }
+        time.Sleep(backoff)
+        backoff *= 2
+    }
+}
+
+// postOnce makes a single delivery attempt
+func (n *Notifier) postOnce(s *sink, body []byte) error {
+    // Credentials in the URL and secret are resolved per delivery
+    req, err := http.NewRequest("POST", credentials.Expand(s.URL), bytes.NewReader(body))
+    if err != nil {
+        return redact.Error(err)
+    }
+    req.Header.Set("Content-Type", "application/json")
+    if secret := credentials.Expand(s.Secret); secret != "" {
+        mac := hmac.New(sha256.New, []byte(secret))
+        mac.Write(body)
+        req.Header.Set("X-Oracle-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+    }
+    resp, err := n.client.Do(req)
+    if err != nil {
+        return redact.Error(err)
+    }
+    resp.Body.Close()
+    if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+        return fmt.Errorf("sink returned %s", resp.Status)
+    }
+    return nil
+}
+
+// Status reports deliveries to every sink
+func (n *Notifier) Status() []SinkStatus {
+    n.mu.Lock()
+    defer n.mu.Unlock()
+    out := make([]SinkStatus, 0, len(n.sinks))
+    for _, s := range n.sinks {
+        status := s.status
+        status.Pending = len(s.queue)
+        out = append(out, status)
+    }
+    return out
+}
+
+// Close stops accepting transitions and waits until ctx is done for those
+// queued to be delivered. Transitions still undelivered then are logged
+// so they are not lost silently.
+func (n *Notifier) Close(ctx context.Context) error {
+    n.mu.Lock()
+    if !n.closed {
+        n.closed = true
+        for _, s := range n.sinks {
+            close(s.queue)
+        }
+    }
+    n.mu.Unlock()
+
+    done := make(chan struct{})
+    go func() {
+        n.workers.Wait()
+        close(done)
+    }()
+    select {
+    case <-done:
+        return nil
+    case <-ctx.Done():
+    }
+
+    pending := 0
+    for _, s := range n.sinks {
+        for t := range s.queue {
+            data, _ := json.Marshal(t)
+            log.Printf("Webhook %s: undelivered at shutdown: %s", s.Name, data)
+            pending++
+        }
+    }
+    return fmt.Errorf("%d webhook deliveries undelivered at shutdown", pending)
+}
@@ -0,0 +1,98 @@
+package webhooks
+
+import (
+    "context"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "sync"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/events"
+)
+
+func TestNotifierEdgeTriggered(t *testing.T) {
+    var mu sync.Mutex
+    received := map[string][]Transition{}
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        body, _ := io.ReadAll(r.Body)
+        if r.URL.Path == "/signed" {
+            mac := hmac.New(sha256.New, []byte("s3cret"))
+            mac.Write(body)
+            if r.Header.Get("X-Oracle-Signature") != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+                t.Errorf("Invalid signature %q", r.Header.Get("X-Oracle-Signature"))
+            }
+        }
+        var tr Transition
+        if err := json.Unmarshal(body, &tr); err != nil {
+            t.Errorf("Invalid body: %v", err)
+        }
+        mu.Lock()
+        received[r.URL.Path] = append(received[r.URL.Path], tr)
+        mu.Unlock()
+    }))
+    defer srv.Close()
+
+    config := &Config{
+        Enabled:        true,
+        SourceFailures: 2,
+        MaxAttempts:    1,
+        Sinks: []Sink{
+            {Name: "all", URL: srv.URL + "/signed", Secret: "s3cret"},
+            {Name: "eth", URL: srv.URL + "/eth", Events: []string{FeedDegraded, FeedRecovered}, Feeds: []string{"ETHUSDT"}},
+        },
+    }
+    var states map[string]string
+    n := NewNotifier(config, func() map[string]string { return states }, events.NewBus())
+
+    now := time.Now()
+    n.Evaluate(now) // nil while priming
+    for _, step := range []map[string]string{
+        {"ETHUSDT": "ok", "BTCUSDT": "degraded"}, // baseline
+        {"ETHUSDT": "ok", "BTCUSDT": "degraded"},
+        {"ETHUSDT": "stale", "BTCUSDT": "market_closed"},
+        {"ETHUSDT": "ok", "BTCUSDT": "degraded"},
+    } {
+        states = step
+        n.Evaluate(now)
+    }
+
+    fail := events.Event{Symbol: "ETHUSDT", Payload: &events.FetchResultPayload{Source: "kraken", Err: errors.New("timeout")}}
+    n.observeFetch(fail)
+    n.observeFetch(fail)
+    n.observeFetch(fail)
+    n.observeFetch(events.Event{Symbol: "ETHUSDT", Payload: &events.FetchResultPayload{Source: "kraken"}})
+
+    if err := n.Close(context.Background()); err != nil {
+        t.Fatalf("Close failed: %v", err)
+    }
+
+    all := received["/signed"]
+    want := []string{FeedDegraded, FeedRecovered, SourceDown, SourceRecovered}
+    if len(all) != len(want) {
+        t.Fatalf("Expected %v, got %+v", want, all)
+    }
+    for i, event := range want {
+        if all[i].Event != event {
+            t.Errorf("Transition %d: expected %s, got %+v", i, event, all[i])
+        }
+    }
+    if all[0].Feed != "ETHUSDT" || all[0].From != "ok" || all[0].To != "stale" {
+        t.Errorf("Expected ETHUSDT ok -> stale, got %+v", all[0])
+    }
+    if all[2].Source != "kraken" || all[2].Reason == "" {
+        t.Errorf("Expected kraken down with a reason, got %+v", all[2])
+    }
+    if len(received["/eth"]) != 2 {
+        t.Errorf("Expected only ETHUSDT feed transitions on the filtered sink, got %+v", received["/eth"])
+    }
+    if status := n.Status(); status[0].Delivered != 4 || status[1].Delivered != 2 {
+        t.Errorf("Unexpected sink status %+v", status)
+    }
+}
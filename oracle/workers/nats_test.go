@@ -0,0 +1,222 @@
+package workers
+
+import (
+    "bufio"
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "net"
+    "strconv"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "yetaXYZ/oracle/common"
+)
+
+// fakeNATS is a NATS server speaking just enough of the protocol for
+// request-reply with queue groups and no-responder replies
+type fakeNATS struct {
+    ln   net.Listener
+    mu   sync.Mutex
+    subs []fakeSub
+}
+
+type fakeSub struct {
+    w       *fakeWriter
+    subject string
+    queue   string
+    sid     string
+}
+
+type fakeWriter struct {
+    mu   sync.Mutex
+    conn net.Conn
+}
+
+func (w *fakeWriter) send(s string) {
+    w.mu.Lock()
+    defer w.mu.Unlock()
+    io.WriteString(w.conn, s)
+}
+
+func newFakeNATS(t *testing.T) *fakeNATS {
+    ln, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    s := &fakeNATS{ln: ln}
+    go func() {
+        for {
+            conn, err := ln.Accept()
+            if err != nil {
+                return
+            }
+            go s.serve(conn)
+        }
+    }()
+    t.Cleanup(func() { ln.Close() })
+    return s
+}
+
+func (s *fakeNATS) url() string {
+    return "nats://" + s.ln.Addr().String()
+}
+
+func (s *fakeNATS) serve(conn net.Conn) {
+    defer conn.Close()
+    w := &fakeWriter{conn: conn}
+    w.send("INFO {\"headers\":true}\r\n")
+    r := bufio.NewReader(conn)
+    for {
+        line, err := readLine(r)
+        if err != nil {
+            return
+        }
+        fields := strings.Fields(line)
+        switch fields[0] {
+        case "PING":
+            w.send("PONG\r\n")
+        case "SUB":
+            sub := fakeSub{w: w, subject: fields[1], sid: fields[len(fields)-1]}
+            if len(fields) == 4 {
+                sub.queue = fields[2]
+            }
+            s.mu.Lock()
+            s.subs = append(s.subs, sub)
+            s.mu.Unlock()
+        case "PUB":
+            size, _ := strconv.Atoi(fields[len(fields)-1])
+            payload := make([]byte, size+2)
+            if _, err := io.ReadFull(r, payload); err != nil {
+                return
+            }
+            reply := ""
+            if len(fields) == 4 {
+                reply = fields[2]
+            }
+            s.publish(fields[1], reply, payload[:size])
+        }
+    }
+}
+
+func (s *fakeNATS) publish(subject, reply string, data []byte) {
+    s.mu.Lock()
+    var targets []fakeSub
+    groups := make(map[string]bool)
+    for _, sub := range s.subs {
+        if !matches(sub.subject, subject) || groups[sub.queue] {
+            continue
+        }
+        if sub.queue != "" {
+            groups[sub.queue] = true
+        }
+        targets = append(targets, sub)
+    }
+    s.mu.Unlock()
+
+    if len(targets) == 0 && reply != "" {
+        headers := "NATS/1.0 503\r\n\r\n"
+        s.publishHeaders(reply, headers)
+        return
+    }
+    for _, sub := range targets {
+        if reply != "" {
+            sub.w.send(fmt.Sprintf("MSG %s %s %s %d\r\n%s\r\n", subject, sub.sid, reply, len(data), data))
+        } else {
+            sub.w.send(fmt.Sprintf("MSG %s %s %d\r\n%s\r\n", subject, sub.sid, len(data), data))
+        }
+    }
+}
+
+func (s *fakeNATS) publishHeaders(subject, headers string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    for _, sub := range s.subs {
+        if matches(sub.subject, subject) {
+            sub.w.send(fmt.Sprintf("HMSG %s %s %d %d\r\n%s\r\n", subject, sub.sid, len(headers), len(headers), headers))
+        }
+    }
+}
+
+// matches supports a trailing single-token wildcard
+func matches(pattern, subject string) bool {
+    if strings.HasSuffix(pattern, ".*") {
+        prefix := strings.TrimSuffix(pattern, "*")
+        return strings.HasPrefix(subject, prefix) && !strings.Contains(subject[len(prefix):], ".")
+    }
+    return pattern == subject
+}
+
+func TestNATSDispatchesJobsToWorkers(t *testing.T) {
+    server := newFakeNATS(t)
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+
+    worker, err := NewNATS(server.url(), "", "worker-1")
+    if err != nil {
+        t.Fatal(err)
+    }
+    jobs := make(chan Job, 2)
+    go worker.Serve(ctx, func(ctx context.Context, job Job) (*common.PricePoint, error) {
+        jobs <- job
+        if job.Exchange == "kraken" {
+            return nil, errors.New("kraken: rate limited")
+        }
+        return &common.PricePoint{Price: 3000, Volume: 12}, nil
+    })
+
+    coordinator, err := NewNATS(server.url(), "", "coordinator")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer coordinator.Close()
+
+    // Until the worker has subscribed the server answers for it
+    var price *common.PricePoint
+    deadline := time.Now().Add(5 * time.Second)
+    for {
+        dispatchCtx, done := context.WithTimeout(ctx, time.Second)
+        price, err = coordinator.Dispatch(dispatchCtx, Job{Source: "binance", Exchange: "binance", Symbol: "ETHUSDT"})
+        done()
+        if !errors.Is(err, ErrUnavailable) || time.Now().After(deadline) {
+            break
+        }
+        time.Sleep(10 * time.Millisecond)
+    }
+    if err != nil || price == nil || price.Price != 3000 || price.Volume != 12 {
+        t.Fatalf("Expected the worker's price, got %+v, %v", price, err)
+    }
+    if job := <-jobs; job.Symbol != "ETHUSDT" || job.ID == "" || job.TimeoutMs <= 0 || job.TimeoutMs > 1000 {
+        t.Errorf("Expected the job with the remaining deadline, got %+v", job)
+    }
+
+    if _, err := coordinator.Dispatch(ctx, Job{Source: "kraken", Exchange: "kraken"}); err == nil || err.Error() != "kraken: rate limited" || errors.Is(err, ErrUnavailable) {
+        t.Errorf("Expected the worker's fetch error, got %v", err)
+    }
+}
+
+func TestNATSWithoutWorkersIsUnavailable(t *testing.T) {
+    server := newFakeNATS(t)
+    coordinator, err := NewNATS(server.url(), "prices.fetch", "coordinator")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer coordinator.Close()
+    if _, err := coordinator.Dispatch(context.Background(), Job{Source: "binance"}); !errors.Is(err, ErrUnavailable) {
+        t.Errorf("Expected no responders to be unavailable, got %v", err)
+    }
+
+    unreachable, _ := NewNATS("nats://127.0.0.1:1", "", "coordinator")
+    if _, err := unreachable.Dispatch(context.Background(), Job{Source: "binance"}); !errors.Is(err, ErrUnavailable) {
+        t.Errorf("Expected an unreachable server to be unavailable, got %v", err)
+    }
+
+    for _, bad := range []string{"http://localhost:4222", "nats://"} {
+        if _, err := NewNATS(bad, "", "coordinator"); err == nil {
+            t.Errorf("Expected %q to be rejected", bad)
+        }
+    }
+}
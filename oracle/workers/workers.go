@@ -0,0 +1,57 @@
+// Package workers moves source fetches off the aggregating instance: a
+// coordinator pushes fetch jobs onto a queue and a fleet of stateless
+// worker processes runs the fetchers and returns the prices, so that data
+// collection scales horizontally apart from aggregation.
+package workers
+
+import (
+    "context"
+    "errors"
+
+    "yetaXYZ/oracle/common"
+)
+
+// DefaultSubject is the subject jobs are published on unless configured
+const DefaultSubject = "oracle.fetch"
+
+// ErrUnavailable is returned, wrapped, when a job could not be handed to a
+// worker: the queue is unreachable or no worker is consuming it
+var ErrUnavailable = errors.New("worker queue unavailable")
+
+// Job is the fetch of one source. CEX jobs name the exchange and its
+// symbol; DEX jobs carry the pool or subgraph source with the pair's base
+// and quote assets.
+type Job struct {
+    ID       string                     `json:"id"`
+    Source   string                     `json:"source"` // as attributed in rounds
+    Exchange string                     `json:"exchange,omitempty"`
+    Symbol   string                     `json:"symbol,omitempty"` // exchange symbol
+    BaseURL  string                     `json:"baseUrl,omitempty"`
+    Base     string                     `json:"base,omitempty"`
+    Quote    string                     `json:"quote,omitempty"`
+    Pool     *common.DEXPool            `json:"pool,omitempty"`
+    Subgraph *common.SubgraphPoolSource `json:"subgraph,omitempty"`
+    // TimeoutMs is what is left of the coordinator's deadline for the
+    // fetch when the job is sent; 0 when it has none
+    TimeoutMs int64 `json:"timeoutMs,omitempty"`
+}
+
+// Result is a worker's reply to a job: the fetched price or why the fetch
+// failed
+type Result struct {
+    ID     string             `json:"id"`
+    Worker string             `json:"worker"`
+    Price  *common.PricePoint `json:"price,omitempty"`
+    Error  string             `json:"error,omitempty"`
+}
+
+// Handler runs a job on a worker
+type Handler func(ctx context.Context, job Job) (*common.PricePoint, error)
+
+// Queue carries jobs from a coordinator to workers and their results back
+type Queue interface {
+    // Dispatch hands a job to one worker and waits for its result
+    Dispatch(ctx context.Context, job Job) (*common.PricePoint, error)
+    // Serve runs handler on the jobs a worker takes until ctx is cancelled
+    Serve(ctx context.Context, handler Handler) error
+}